@@ -5,4 +5,7 @@ import "context"
 // API is the common interface for the target func api.
 type API interface {
 	Apply(ctx context.Context, req *ApplyRequest) (*ApplyResponse, error)
+	Outputs(ctx context.Context, req *OutputsRequest) (*OutputsResponse, error)
+	StateRemove(ctx context.Context, req *StateRemoveRequest) error
+	Import(ctx context.Context, req *ImportRequest) (*ImportResponse, error)
 }
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -10,6 +9,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/func/func/config"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
@@ -17,7 +17,7 @@ import (
 
 // SourceProvider provides source code for upload.
 type SourceProvider interface {
-	Source(sha string) *bytes.Buffer
+	Source(sha string) *config.SourceArchive
 }
 
 // Client is a func api client.
@@ -30,32 +30,48 @@ type Client struct {
 // Apply applies the given hcl configuration.
 //
 // If source code is needed, source is uploaded. After upload, apply is
-// retried.
-func (c *Client) Apply(ctx context.Context, req *ApplyRequest) error {
+// retried. The response from the final apply is returned.
+func (c *Client) Apply(ctx context.Context, req *ApplyRequest) (*ApplyResponse, error) {
 	logger := c.Logger
 
 	logger.Info("Apply")
 	for {
 		resp, err := c.API.Apply(ctx, req)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if len(resp.SourcesRequired) > 0 {
 			logger.Debug(fmt.Sprintf("%d Sources required", len(resp.SourcesRequired)))
 
 			if err := c.uploadSources(ctx, resp.SourcesRequired); err != nil {
-				return errors.Wrap(err, "upload source")
+				return nil, errors.Wrap(err, "upload source")
 			}
 
 			// Retry after source files have been uploaded
 			logger.Debug("Retry request with sources uploaded")
 			continue
 		}
-		break
+		return resp, nil
 	}
+}
 
-	return nil
+// Outputs returns the resolved outputs for a project.
+func (c *Client) Outputs(ctx context.Context, req *OutputsRequest) (*OutputsResponse, error) {
+	c.Logger.Info("Outputs")
+	return c.API.Outputs(ctx, req)
+}
+
+// StateRemove removes a resource from the project state without deleting it.
+func (c *Client) StateRemove(ctx context.Context, req *StateRemoveRequest) error {
+	c.Logger.Info("State remove")
+	return c.API.StateRemove(ctx, req)
+}
+
+// Import imports existing resources of a type into the project state.
+func (c *Client) Import(ctx context.Context, req *ImportRequest) (*ImportResponse, error) {
+	c.Logger.Info("Import")
+	return c.API.Import(ctx, req)
 }
 
 func (c *Client) uploadSources(ctx context.Context, srcs []*SourceRequest) error {
@@ -77,11 +93,19 @@ func (c *Client) uploadSource(ctx context.Context, src *SourceRequest) error {
 	logger.Debug(fmt.Sprintf("Uploading %s", src.Key))
 
 	data := c.Source.Source(src.Key)
+	if data == nil {
+		return errors.Errorf("source %s not found", src.Key)
+	}
 
-	req, err := http.NewRequest(http.MethodPut, src.URL, data)
+	// Stream the archive, the content length must be set explicitly as it
+	// cannot be determined from the reader.
+	body := data.Reader()
+	defer body.Close() // nolint: errcheck
+	req, err := http.NewRequest(http.MethodPut, src.URL, body)
 	if err != nil {
 		return err
 	}
+	req.ContentLength = data.Len()
 	for k, v := range src.Headers {
 		req.Header.Add(k, v)
 	}
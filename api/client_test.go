@@ -9,6 +9,7 @@ import (
 	"sync/atomic"
 	"testing"
 
+	"github.com/func/func/config"
 	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/hcl2/hclpack"
@@ -52,7 +53,7 @@ func TestClient_Apply_request(t *testing.T) {
 		Config:  body,
 	}
 
-	if err := cli.Apply(context.Background(), req); err != nil {
+	if _, err := cli.Apply(context.Background(), req); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -79,7 +80,7 @@ func TestClient_Apply_diagnostics(t *testing.T) {
 		Config:  &hclpack.Body{},
 	}
 
-	err := cli.Apply(context.Background(), req)
+	_, err := cli.Apply(context.Background(), req)
 	if err == nil {
 		t.Fatalf("Error is nil")
 	}
@@ -101,6 +102,10 @@ func TestClient_Apply_uploadSources(t *testing.T) {
 			http.Error(w, "header foo is not bar", http.StatusBadRequest)
 			return
 		}
+		if r.ContentLength != 6 {
+			http.Error(w, "content length is not set", http.StatusBadRequest)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		atomic.AddInt64(&uploads, 1) // Uploads happen concurrently
 	}))
@@ -153,22 +158,41 @@ func TestClient_Apply_uploadSources(t *testing.T) {
 		Config:  &hclpack.Body{},
 	}
 
-	err := cli.Apply(context.Background(), req)
+	_, err := cli.Apply(context.Background(), req)
 	if err != nil {
 		t.Fatal(err)
 	}
 }
 
 type mockRPC struct {
-	apply func(context.Context, *ApplyRequest) (*ApplyResponse, error)
+	apply   func(context.Context, *ApplyRequest) (*ApplyResponse, error)
+	outputs func(context.Context, *OutputsRequest) (*OutputsResponse, error)
+	stateRm func(context.Context, *StateRemoveRequest) error
+	imp     func(context.Context, *ImportRequest) (*ImportResponse, error)
 }
 
 func (m *mockRPC) Apply(ctx context.Context, req *ApplyRequest) (*ApplyResponse, error) {
 	return m.apply(ctx, req)
 }
 
+func (m *mockRPC) Outputs(ctx context.Context, req *OutputsRequest) (*OutputsResponse, error) {
+	return m.outputs(ctx, req)
+}
+
+func (m *mockRPC) StateRemove(ctx context.Context, req *StateRemoveRequest) error {
+	return m.stateRm(ctx, req)
+}
+
+func (m *mockRPC) Import(ctx context.Context, req *ImportRequest) (*ImportResponse, error) {
+	return m.imp(ctx, req)
+}
+
 type sourcemap map[string][]byte
 
-func (s sourcemap) Source(sha string) *bytes.Buffer {
-	return bytes.NewBuffer(s[sha])
+func (s sourcemap) Source(sha string) *config.SourceArchive {
+	data, ok := s[sha]
+	if !ok {
+		return nil
+	}
+	return config.NewSourceArchive(bytes.NewReader(data), int64(len(data)))
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -45,8 +46,14 @@ func (c *Client) Apply(ctx context.Context, req *api.ApplyRequest) (*api.ApplyRe
 	}
 
 	r := applyRequest{
-		Project: req.Project,
-		Config:  cfg,
+		Project:   req.Project,
+		Config:    cfg,
+		Variables: valuesToWire(req.Variables),
+		Env:       req.Env,
+		Retry:     req.Retry,
+
+		StrictTypes:     req.StrictTypes,
+		StrictVariables: req.StrictVariables,
 	}
 
 	var buf bytes.Buffer
@@ -79,7 +86,10 @@ func (c *Client) Apply(ctx context.Context, req *api.ApplyRequest) (*api.ApplyRe
 		if len(response.Diagnostics) > 0 {
 			return nil, diagsToHCL(response.Diagnostics)
 		}
-		apiresp := &api.ApplyResponse{}
+		apiresp := &api.ApplyResponse{
+			Outputs:   valuesFromWire(response.Outputs),
+			Sensitive: response.Sensitive,
+		}
 		if len(response.SourcesRequired) > 0 {
 			apiresp.SourcesRequired = make([]*api.SourceRequest, len(response.SourcesRequired))
 			for i, s := range response.SourcesRequired {
@@ -94,11 +104,106 @@ func (c *Client) Apply(ctx context.Context, req *api.ApplyRequest) (*api.ApplyRe
 	default:
 		var errresp Error
 		if err := json.Unmarshal(body, &errresp); err != nil {
-			return nil, fmt.Errorf(resp.Status)
+			return nil, errors.New(resp.Status)
 		}
 		if errresp.Msg == "" {
-			return nil, fmt.Errorf(resp.Status)
+			return nil, errors.New(resp.Status)
+		}
+		return nil, errors.New(errresp.Msg)
+	}
+}
+
+// Outputs marshals an OutputsRequest and sends it over the wire.
+//
+// Output values are transferred as plain json, the returned values have the
+// types implied by their json representation.
+func (c *Client) Outputs(ctx context.Context, req *api.OutputsRequest) (*api.OutputsResponse, error) {
+	if req.Project == "" {
+		return nil, fmt.Errorf("project not set")
+	}
+
+	var response outputsResponse
+	if err := c.call(ctx, "/outputs", outputsRequest{Project: req.Project}, &response); err != nil {
+		return nil, err
+	}
+	return &api.OutputsResponse{
+		Outputs:   valuesFromWire(response.Outputs),
+		Sensitive: response.Sensitive,
+	}, nil
+}
+
+// StateRemove marshals a StateRemoveRequest and sends it over the wire.
+func (c *Client) StateRemove(ctx context.Context, req *api.StateRemoveRequest) error {
+	if req.Project == "" {
+		return fmt.Errorf("project not set")
+	}
+
+	r := stateRemoveRequest{
+		Project: req.Project,
+		Type:    req.Type,
+		Name:    req.Name,
+		Force:   req.Force,
+	}
+	return c.call(ctx, "/state/rm", r, nil)
+}
+
+// Import marshals an ImportRequest and sends it over the wire.
+func (c *Client) Import(ctx context.Context, req *api.ImportRequest) (*api.ImportResponse, error) {
+	if req.Project == "" {
+		return nil, fmt.Errorf("project not set")
+	}
+
+	var response importResponse
+	r := importRequest{
+		Project: req.Project,
+		Type:    req.Type,
+	}
+	if err := c.call(ctx, "/import", r, &response); err != nil {
+		return nil, err
+	}
+	return &api.ImportResponse{Imported: response.Imported}, nil
+}
+
+// call sends req as json to the given path and decodes the response into
+// resp. If resp is nil, the response body is discarded.
+//
+// If the server does not respond with 200 OK, the error message from the
+// server is returned as an error.
+func (c *Client) call(ctx context.Context, path string, req, resp interface{}) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(req); err != nil {
+		return fmt.Errorf("encode request: %v", err)
+	}
+	httpreq, err := http.NewRequest(http.MethodPost, c.Endpoint+path, &buf)
+	if err != nil {
+		return fmt.Errorf("build request: %v", err)
+	}
+	httpreq.Header.Add("Content-Type", "application/json")
+
+	cli := c.httpClient()
+	httpresp, err := cli.Do(httpreq.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("send request: %v", err)
+	}
+	body, err := ioutil.ReadAll(httpresp.Body)
+	if err != nil {
+		return fmt.Errorf("read body: %v", err)
+	}
+	_ = httpresp.Body.Close()
+
+	if httpresp.StatusCode != http.StatusOK {
+		var errresp Error
+		if err := json.Unmarshal(body, &errresp); err != nil || errresp.Msg == "" {
+			return errors.New(httpresp.Status)
 		}
-		return nil, fmt.Errorf(errresp.Msg)
+		return errors.New(errresp.Msg)
+	}
+
+	if resp == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return fmt.Errorf("decode response: %v", err)
 	}
+	return nil
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/hcl2/hcl/hclsyntax"
 	"github.com/hashicorp/hcl2/hclpack"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestClient_Apply(t *testing.T) {
@@ -92,6 +93,91 @@ func TestClient_Apply(t *testing.T) {
 			},
 			want: &api.ApplyResponse{},
 		},
+		{
+			name: "Variables",
+			req: &api.ApplyRequest{
+				Project:   "proj",
+				Config:    &hclpack.Body{},
+				Variables: map[string]cty.Value{"region": cty.StringVal("us-east-1")},
+			},
+			handler: func(t *testing.T) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var body applyRequest
+					if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+						t.Fatalf("Decode request: %v", err)
+					}
+					got := body.Variables["region"].Value
+					if !got.RawEquals(cty.StringVal("us-east-1")) {
+						t.Errorf("Variable region = %#v, want %#v", got, cty.StringVal("us-east-1"))
+					}
+					respond(t, w, &api.ApplyResponse{}, http.StatusOK)
+				})
+			},
+			want: &api.ApplyResponse{},
+		},
+		{
+			name: "Env",
+			req: &api.ApplyRequest{
+				Project: "proj",
+				Config:  &hclpack.Body{},
+				Env:     map[string]string{"BUCKET": "my-bucket"},
+			},
+			handler: func(t *testing.T) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var body applyRequest
+					if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+						t.Fatalf("Decode request: %v", err)
+					}
+					if got := body.Env["BUCKET"]; got != "my-bucket" {
+						t.Errorf("Env BUCKET = %q, want %q", got, "my-bucket")
+					}
+					respond(t, w, &api.ApplyResponse{}, http.StatusOK)
+				})
+			},
+			want: &api.ApplyResponse{},
+		},
+		{
+			name: "StrictTypes",
+			req: &api.ApplyRequest{
+				Project:     "proj",
+				Config:      &hclpack.Body{},
+				StrictTypes: true,
+			},
+			handler: func(t *testing.T) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var body applyRequest
+					if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+						t.Fatalf("Decode request: %v", err)
+					}
+					if !body.StrictTypes {
+						t.Errorf("StrictTypes not set")
+					}
+					respond(t, w, &api.ApplyResponse{}, http.StatusOK)
+				})
+			},
+			want: &api.ApplyResponse{},
+		},
+		{
+			name: "StrictVariables",
+			req: &api.ApplyRequest{
+				Project:         "proj",
+				Config:          &hclpack.Body{},
+				StrictVariables: true,
+			},
+			handler: func(t *testing.T) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var body applyRequest
+					if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+						t.Fatalf("Decode request: %v", err)
+					}
+					if !body.StrictVariables {
+						t.Errorf("StrictVariables not set")
+					}
+					respond(t, w, &api.ApplyResponse{}, http.StatusOK)
+				})
+			},
+			want: &api.ApplyResponse{},
+		},
 		{
 			name: "Source",
 			req: &api.ApplyRequest{
@@ -165,6 +251,73 @@ func TestClient_Apply(t *testing.T) {
 	}
 }
 
+func TestClient_Outputs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.URL.Path; v != "/outputs" {
+			t.Errorf("Path not match; got = %s, want = %s", v, "/outputs")
+		}
+		var req outputsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Project != "proj" {
+			t.Errorf("Project not match; got = %s, want = %s", req.Project, "proj")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"outputs":{"arn":"arn:foo","count":3,"key":"abc"},"sensitive":["key"]}`))
+	}))
+	defer ts.Close()
+
+	cli := &Client{Endpoint: ts.URL}
+	got, err := cli.Outputs(context.Background(), &api.OutputsRequest{Project: "proj"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &api.OutputsResponse{
+		Outputs: map[string]cty.Value{
+			"arn":   cty.StringVal("arn:foo"),
+			"count": cty.NumberIntVal(3),
+			"key":   cty.StringVal("abc"),
+		},
+		Sensitive: []string{"key"},
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool { return a.Equals(b).True() }),
+	}
+	if diff := cmp.Diff(got, want, opts...); diff != "" {
+		t.Errorf("Outputs() Response (-got +want)\n%s", diff)
+	}
+}
+
+func TestClient_Import(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.URL.Path; v != "/import" {
+			t.Errorf("Path not match; got = %s, want = %s", v, "/import")
+		}
+		var req importRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		want := importRequest{Project: "proj", Type: "aws_iam_role"}
+		if diff := cmp.Diff(req, want); diff != "" {
+			t.Errorf("Request (-got +want)\n%s", diff)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"imported":["admin","deploy"]}`))
+	}))
+	defer ts.Close()
+
+	cli := &Client{Endpoint: ts.URL}
+	got, err := cli.Import(context.Background(), &api.ImportRequest{Project: "proj", Type: "aws_iam_role"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &api.ImportResponse{Imported: []string{"admin", "deploy"}}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Import() Response (-got +want)\n%s", diff)
+	}
+}
+
 // func config(t *testing.T, source string) *hclpack.Body {
 // 	body, err := hclpack.PackNativeFile([]byte(source), t.Name(), hcl.InitialPos)
 // 	if err != nil {
@@ -26,6 +26,9 @@ type Server struct {
 func (s *Server) setupRoutes() {
 	s.router = http.NewServeMux()
 	s.router.HandleFunc("/apply", s.handleApply())
+	s.router.HandleFunc("/outputs", s.handleOutputs())
+	s.router.HandleFunc("/state/rm", s.handleStateRemove())
+	s.router.HandleFunc("/import", s.handleImport())
 }
 
 // ServeHTTP implements http.Handler.
@@ -47,35 +50,52 @@ func (s *Server) decode(w http.ResponseWriter, r *http.Request, v interface{}) e
 	return json.NewDecoder(r.Body).Decode(v)
 }
 
-func (s *Server) handleApply() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			s.respond(w, Error{Msg: "Method not allowed"}, http.StatusMethodNotAllowed)
-			return
-		}
+// readRequest checks that r is a json POST request and decodes the body into
+// v. If the request is not valid, an error is written to w and false is
+// returned.
+func (s *Server) readRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		s.respond(w, Error{Msg: "Method not allowed"}, http.StatusMethodNotAllowed)
+		return false
+	}
 
-		if r.Body == nil {
-			s.Logger.Debug("Body not set")
-			s.respond(w, Error{Msg: "No body"}, http.StatusBadRequest)
-			return
-		}
+	if r.Body == nil {
+		s.Logger.Debug("Body not set")
+		s.respond(w, Error{Msg: "No body"}, http.StatusBadRequest)
+		return false
+	}
 
-		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
-			s.respond(w, Error{Msg: "Invalid content type"}, http.StatusUnsupportedMediaType)
-			return
-		}
+	if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+		s.respond(w, Error{Msg: "Invalid content type"}, http.StatusUnsupportedMediaType)
+		return false
+	}
 
+	if err := s.decode(w, r, v); err != nil {
+		s.Logger.Debug("Could not decode body", zap.Error(err))
+		s.respond(w, Error{Msg: "Could not decode body"}, http.StatusBadRequest)
+		return false
+	}
+	_ = r.Body.Close()
+
+	return true
+}
+
+func (s *Server) handleApply() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		var body applyRequest
-		if err := s.decode(w, r, &body); err != nil {
-			s.Logger.Debug("Could not decode body", zap.Error(err))
-			s.respond(w, Error{Msg: "Could not decode body"}, http.StatusBadRequest)
+		if !s.readRequest(w, r, &body) {
 			return
 		}
-		_ = r.Body.Close()
 
 		apireq := &api.ApplyRequest{
-			Project: body.Project,
-			Config:  body.Config,
+			Project:   body.Project,
+			Config:    body.Config,
+			Variables: valuesFromWire(body.Variables),
+			Env:       body.Env,
+			Retry:     body.Retry,
+
+			StrictTypes:     body.StrictTypes,
+			StrictVariables: body.StrictVariables,
 		}
 
 		apiresp, err := s.API.Apply(r.Context(), apireq)
@@ -90,17 +110,7 @@ func (s *Server) handleApply() http.HandlerFunc {
 					s.respond(w, response, http.StatusBadRequest)
 					return
 				}
-				var status int
-				switch aerr.Code {
-				case api.ValidationError:
-					status = http.StatusBadRequest
-				case api.Unavailable:
-					status = http.StatusServiceUnavailable
-				default:
-					// Unknown error
-					status = http.StatusInternalServerError
-				}
-				s.respond(w, Error{Msg: aerr.Message}, status)
+				s.respond(w, Error{Msg: aerr.Message}, errorStatus(aerr.Code))
 				return
 			}
 			// Unknown error
@@ -118,8 +128,108 @@ func (s *Server) handleApply() http.HandlerFunc {
 		}
 		response := applyResponse{
 			SourcesRequired: src,
+			Outputs:         valuesToWire(apiresp.Outputs),
+			Sensitive:       apiresp.Sensitive,
+		}
+
+		s.respond(w, response, http.StatusOK)
+	}
+}
+
+func (s *Server) handleOutputs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body outputsRequest
+		if !s.readRequest(w, r, &body) {
+			return
+		}
+
+		apiresp, err := s.API.Outputs(r.Context(), &api.OutputsRequest{Project: body.Project})
+		if err != nil {
+			s.Logger.Debug("Outputs error", zap.Error(err))
+			if aerr, ok := err.(*api.Error); ok {
+				s.respond(w, Error{Msg: aerr.Message}, errorStatus(aerr.Code))
+				return
+			}
+			// Unknown error
+			s.respond(w, Error{Msg: "Could not get outputs"}, http.StatusInternalServerError)
+			return
+		}
+
+		response := outputsResponse{
+			Outputs:   valuesToWire(apiresp.Outputs),
+			Sensitive: apiresp.Sensitive,
 		}
 
 		s.respond(w, response, http.StatusOK)
 	}
 }
+
+func (s *Server) handleStateRemove() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body stateRemoveRequest
+		if !s.readRequest(w, r, &body) {
+			return
+		}
+
+		apireq := &api.StateRemoveRequest{
+			Project: body.Project,
+			Type:    body.Type,
+			Name:    body.Name,
+			Force:   body.Force,
+		}
+
+		if err := s.API.StateRemove(r.Context(), apireq); err != nil {
+			s.Logger.Debug("State remove error", zap.Error(err))
+			if aerr, ok := err.(*api.Error); ok {
+				s.respond(w, Error{Msg: aerr.Message}, errorStatus(aerr.Code))
+				return
+			}
+			// Unknown error
+			s.respond(w, Error{Msg: "Could not remove resource"}, http.StatusInternalServerError)
+			return
+		}
+
+		s.respond(w, struct{}{}, http.StatusOK)
+	}
+}
+
+func (s *Server) handleImport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body importRequest
+		if !s.readRequest(w, r, &body) {
+			return
+		}
+
+		apireq := &api.ImportRequest{
+			Project: body.Project,
+			Type:    body.Type,
+		}
+
+		apiresp, err := s.API.Import(r.Context(), apireq)
+		if err != nil {
+			s.Logger.Debug("Import error", zap.Error(err))
+			if aerr, ok := err.(*api.Error); ok {
+				s.respond(w, Error{Msg: aerr.Message}, errorStatus(aerr.Code))
+				return
+			}
+			// Unknown error
+			s.respond(w, Error{Msg: "Could not import resources"}, http.StatusInternalServerError)
+			return
+		}
+
+		s.respond(w, importResponse{Imported: apiresp.Imported}, http.StatusOK)
+	}
+}
+
+// errorStatus returns the http status code to respond with for an api error.
+func errorStatus(code api.ErrorCode) int {
+	switch code {
+	case api.ValidationError:
+		return http.StatusBadRequest
+	case api.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		// Unknown error
+		return http.StatusInternalServerError
+	}
+}
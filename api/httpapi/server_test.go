@@ -217,3 +217,15 @@ type mockApply struct {
 func (m *mockApply) Apply(context.Context, *api.ApplyRequest) (*api.ApplyResponse, error) {
 	return m.resp, m.err
 }
+
+func (m *mockApply) Outputs(context.Context, *api.OutputsRequest) (*api.OutputsResponse, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockApply) StateRemove(context.Context, *api.StateRemoveRequest) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (m *mockApply) Import(context.Context, *api.ImportRequest) (*api.ImportResponse, error) {
+	return nil, fmt.Errorf("not implemented")
+}
@@ -1,15 +1,27 @@
 package httpapi
 
-import "github.com/hashicorp/hcl2/hclpack"
+import (
+	"github.com/hashicorp/hcl2/hclpack"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
 
 type applyRequest struct {
-	Project string        `json:"proj"`
-	Config  *hclpack.Body `json:"cfg"`
+	Project   string            `json:"proj"`
+	Config    *hclpack.Body     `json:"cfg"`
+	Variables valueMap          `json:"vars,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	Retry     []string          `json:"retry,omitempty"`
+
+	StrictTypes     bool `json:"strict_types,omitempty"`
+	StrictVariables bool `json:"strict_vars,omitempty"`
 }
 
 type applyResponse struct {
 	SourcesRequired []*sourceRequest `json:"srcs,omitempty"`
 	Diagnostics     []*diagnostic    `json:"diags,omitempty"`
+	Outputs         valueMap         `json:"outputs,omitempty"`
+	Sensitive       []string         `json:"sensitive,omitempty"`
 }
 
 type sourceRequest struct {
@@ -17,3 +29,54 @@ type sourceRequest struct {
 	URL     string            `json:"url"`
 	Headers map[string]string `json:"headers"`
 }
+
+type stateRemoveRequest struct {
+	Project string `json:"proj"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Force   bool   `json:"force,omitempty"`
+}
+
+type importRequest struct {
+	Project string `json:"proj"`
+	Type    string `json:"type"`
+}
+
+type importResponse struct {
+	Imported []string `json:"imported,omitempty"`
+}
+
+type outputsRequest struct {
+	Project string `json:"proj"`
+}
+
+type outputsResponse struct {
+	Outputs   valueMap `json:"outputs"`
+	Sensitive []string `json:"sensitive,omitempty"`
+}
+
+// valueMap contains named values encoded as plain json values. Type
+// information is not retained.
+type valueMap map[string]ctyjson.SimpleJSONValue
+
+func valuesToWire(values map[string]cty.Value) valueMap {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make(valueMap, len(values))
+	for k, v := range values {
+		out[k] = ctyjson.SimpleJSONValue{Value: v}
+	}
+	return out
+}
+
+func valuesFromWire(values valueMap) map[string]cty.Value {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make(map[string]cty.Value, len(values))
+	for k, v := range values {
+		out[k] = v.Value
+	}
+	return out
+}
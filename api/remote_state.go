@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// remoteState reads the outputs of other projects while the config of a
+// project is decoded.
+type remoteState struct {
+	ctx     context.Context
+	server  *Server
+	project string // Project that is being decoded.
+}
+
+// RemoteOutputs returns the resolved outputs of another project.
+//
+// Projects are isolated from each other, only the values a project exposes as
+// outputs can be read. Sensitive outputs are not included. A project cannot
+// read its own outputs, as they are being replaced.
+func (r *remoteState) RemoteOutputs(project string) (map[string]cty.Value, error) {
+	if project == r.project {
+		return nil, fmt.Errorf("a project cannot read its own outputs")
+	}
+
+	g, err := r.server.Storage.GetGraph(r.ctx, project)
+	if err != nil {
+		return nil, errors.Wrap(err, "get graph")
+	}
+	if g == nil {
+		return nil, nil
+	}
+
+	var outputs []*resource.Output
+	for _, o := range g.Outputs {
+		if o.Sensitive {
+			continue
+		}
+		outputs = append(outputs, o)
+	}
+
+	values, err := r.server.resolveOutputs(r.ctx, project, outputs)
+	if err != nil {
+		return nil, err
+	}
+	if values == nil {
+		// The project has been applied but does not have any outputs.
+		values = map[string]cty.Value{}
+	}
+	return values, nil
+}
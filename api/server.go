@@ -7,6 +7,7 @@ import (
 	"github.com/func/func/resource"
 	"github.com/func/func/resource/reconciler"
 	"github.com/func/func/source"
+	"github.com/zclconf/go-cty/cty"
 	"go.uber.org/zap"
 )
 
@@ -15,9 +16,19 @@ type Reconciler interface {
 	Reconcile(ctx context.Context, id, project string, graph reconciler.Graph) error
 }
 
-// Storage persists resolved graphs.
+// An Importer imports resources that were created outside of func into the
+// state of a project.
+type Importer interface {
+	ImportAll(ctx context.Context, id, project, typename string) ([]*resource.Deployed, error)
+}
+
+// Storage persists resolved graphs and provides access to deployed
+// resources.
 type Storage interface {
 	PutGraph(ctx context.Context, project string, g *resource.Graph) error
+	GetGraph(ctx context.Context, project string) (*resource.Graph, error)
+	ListResources(ctx context.Context, project string) ([]*resource.Deployed, error)
+	DeleteResource(ctx context.Context, project string, res *resource.Deployed) error
 }
 
 // A Registry is used for matching resource type names to resource
@@ -40,6 +51,13 @@ type Server struct {
 	Storage   Storage
 	Validator Validator
 
+	// Defaults contains default resource input values per provider, used
+	// when neither the resource nor a provider block sets the value.
+	Defaults map[string]map[string]cty.Value
+
 	// If set, reconciliation is done synchronously.
 	Reconciler Reconciler
+
+	// If set, resources can be imported.
+	Importer Importer
 }
@@ -7,10 +7,12 @@ import (
 	"github.com/func/func/config"
 	"github.com/func/func/resource"
 	"github.com/func/func/resource/hcldecoder"
+	"github.com/func/func/resource/reconciler"
 	"github.com/func/func/source"
 	"github.com/hashicorp/hcl2/hcl"
 	"github.com/pkg/errors"
 	"github.com/segmentio/ksuid"
+	"github.com/zclconf/go-cty/cty"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -22,6 +24,27 @@ type ApplyRequest struct {
 
 	// Config is the configuration to apply.
 	Config hcl.Body
+
+	// Variables contains values for variables declared in the config.
+	Variables map[string]cty.Value
+
+	// Env contains the values of environment variables read with the env
+	// function in the config. The server never reads its own environment
+	// for the config.
+	Env map[string]string
+
+	// StrictTypes rejects values that must be converted to the type of the
+	// input they are set to. See hcldecoder.Decoder.StrictTypes.
+	StrictTypes bool
+
+	// StrictVariables rejects declared variables that are not used and
+	// values for variables that are not declared. See
+	// hcldecoder.Decoder.StrictVariables.
+	StrictVariables bool
+
+	// Retry lists resources to attempt even if they are quarantined after
+	// previous failures. Resources are given as type.name.
+	Retry []string
 }
 
 // ApplyResponse is returned from applying resources.
@@ -32,6 +55,15 @@ type ApplyRequest struct {
 type ApplyResponse struct {
 	// SourcesRequired is set if source code uploads are required.
 	SourcesRequired []*SourceRequest
+
+	// Outputs contains the resolved output values. Outputs are only set if
+	// the resources were reconciled synchronously.
+	Outputs map[string]cty.Value
+
+	// Sensitive contains the sorted names of the outputs that are marked
+	// sensitive. Their values are included in Outputs but should not be
+	// displayed.
+	Sensitive []string
 }
 
 // An SourceRequest describes a single upload request.
@@ -70,6 +102,17 @@ func (s *Server) Apply(ctx context.Context, req *ApplyRequest) (*ApplyResponse,
 	dec := &hcldecoder.Decoder{
 		Resources: s.Registry,
 		Validator: s.Validator,
+		Variables: req.Variables,
+		Env:       req.Env,
+		Defaults:  s.Defaults,
+
+		StrictTypes:     req.StrictTypes,
+		StrictVariables: req.StrictVariables,
+		RemoteState: &remoteState{
+			ctx:     ctx,
+			server:  s,
+			project: req.Project,
+		},
 	}
 
 	srcs, diags := dec.DecodeBody(req.Config, g)
@@ -116,10 +159,18 @@ func (s *Server) Apply(ctx context.Context, req *ApplyRequest) (*ApplyResponse,
 
 	if s.Reconciler != nil {
 		id := ksuid.New().String()
+		ctx := reconciler.WithRetry(ctx, req.Retry...)
 		if err := s.Reconciler.Reconcile(ctx, id, req.Project, g); err != nil {
 			logger.Error("Reconciler error", zap.Error(err))
 			return nil, &Error{Code: Unavailable}
 		}
+		outputs, err := s.resolveOutputs(ctx, req.Project, g.Outputs)
+		if err != nil {
+			logger.Error("Could not resolve outputs", zap.Error(err))
+			return nil, &Error{Code: Unavailable}
+		}
+		resp.Outputs = outputs
+		resp.Sensitive = sensitiveOutputs(g.Outputs)
 		return resp, nil
 	}
 
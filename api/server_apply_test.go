@@ -16,6 +16,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/hcl2/hclpack"
+	"github.com/zclconf/go-cty/cty"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -154,6 +155,200 @@ func TestServer_Apply_OK(t *testing.T) {
 	// TODO: check reconciler
 }
 
+func TestServer_Apply_RemoteState(t *testing.T) {
+	store := &teststore.Store{}
+	store.SeedGraph("network", &resource.Graph{
+		Outputs: []*resource.Output{
+			{
+				Name: "vpc_id",
+				Expression: resource.Expression{
+					resource.ExprReference{Path: cty.GetAttrPath("vpc").GetAttr("id")},
+				},
+			},
+			{
+				Name: "secret",
+				Expression: resource.Expression{
+					resource.ExprReference{Path: cty.GetAttrPath("vpc").GetAttr("secret")},
+				},
+				Sensitive: true,
+			},
+		},
+	})
+	store.SeedResources("network", []*resource.Deployed{{
+		Desired: &resource.Desired{
+			Name:  "vpc",
+			Type:  "vpc",
+			Input: cty.EmptyObjectVal,
+		},
+		ID: "123",
+		Output: cty.ObjectVal(map[string]cty.Value{
+			"id":     cty.StringVal("vpc-123"),
+			"secret": cty.StringVal("hunter2"),
+		}),
+	}})
+
+	type subnetDef struct {
+		resource.Definition
+		VPC string `func:"input"`
+	}
+	s := &Server{
+		Logger: zaptest.NewLogger(t),
+		Registry: &resource.Registry{
+			Types: map[string]reflect.Type{"subnet": reflect.TypeOf(subnetDef{})},
+		},
+		Storage: store,
+	}
+
+	req := &ApplyRequest{
+		Project: "app",
+		Config: configJSON(t, "file.hcl", `
+			data "remote_state" "network" {
+				project = "network"
+			}
+			resource "subnet" {
+				type = "subnet"
+				vpc  = data.remote_state.network.vpc_id
+			}
+		`),
+	}
+	if _, err := s.Apply(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := store.GetGraph(context.Background(), "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := g.Resource("subnet").Input
+	want := cty.ObjectVal(map[string]cty.Value{"vpc": cty.StringVal("vpc-123")})
+	if !got.RawEquals(want) {
+		t.Errorf("Input does not match\nGot:  %s\nWant: %s", got.GoString(), want.GoString())
+	}
+
+	t.Run("Sensitive", func(t *testing.T) {
+		req := &ApplyRequest{
+			Project: "app",
+			Config: configJSON(t, "file.hcl", `
+				data "remote_state" "network" {
+					project = "network"
+				}
+				resource "subnet" {
+					type = "subnet"
+					vpc  = data.remote_state.network.secret
+				}
+			`),
+		}
+		_, err := s.Apply(context.Background(), req)
+		aerr, ok := err.(*Error)
+		if !ok {
+			t.Fatalf("want *Error, got %v", err)
+		}
+		if len(aerr.Diagnostics) == 0 {
+			t.Error("No diagnostics returned")
+		}
+	})
+
+	t.Run("Self", func(t *testing.T) {
+		req := &ApplyRequest{
+			Project: "network",
+			Config: configJSON(t, "file.hcl", `
+				data "remote_state" "network" {
+					project = "network"
+				}
+			`),
+		}
+		_, err := s.Apply(context.Background(), req)
+		aerr, ok := err.(*Error)
+		if !ok {
+			t.Fatalf("want *Error, got %v", err)
+		}
+		if len(aerr.Diagnostics) == 0 {
+			t.Error("No diagnostics returned")
+		}
+	})
+}
+
+func TestServer_Apply_StrictTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		wantErr bool
+	}{
+		{"Convert", false, false},
+		{"Strict", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				Logger: zaptest.NewLogger(t),
+				Registry: &resource.Registry{
+					Types: map[string]reflect.Type{"str": reflect.TypeOf(strDef{})},
+				},
+				Storage: &teststore.Store{},
+			}
+
+			req := &ApplyRequest{
+				Project: "testproject",
+				Config: configJSON(t, "file.hcl", `
+					resource "foo" {
+						type  = "str"
+						value = 123
+					}
+				`),
+				StrictTypes: tt.strict,
+			}
+			_, err := s.Apply(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Apply() error = %v, wantErr = %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServer_Apply_StrictVariables(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		wantErr bool
+	}{
+		{"Lenient", false, false},
+		{"Strict", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				Logger: zaptest.NewLogger(t),
+				Registry: &resource.Registry{
+					Types: map[string]reflect.Type{"str": reflect.TypeOf(strDef{})},
+				},
+				Storage: &teststore.Store{},
+			}
+
+			req := &ApplyRequest{
+				Project: "testproject",
+				Config: configJSON(t, "file.hcl", `
+					resource "foo" {
+						type  = "str"
+						value = "abc"
+					}
+				`),
+				// Not declared in the config.
+				Variables:       map[string]cty.Value{"name": cty.StringVal("world")},
+				StrictVariables: tt.strict,
+			}
+			_, err := s.Apply(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Apply() error = %v, wantErr = %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type strDef struct {
+	resource.Definition
+	Value string `func:"input"`
+}
+
 func configJSON(t *testing.T, filename, config string) *hclpack.Body {
 	t.Helper()
 	body, diags := hclpack.PackNativeFile([]byte(config), filename, hcl.InitialPos)
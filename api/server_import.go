@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/segmentio/ksuid"
+	"go.uber.org/zap"
+)
+
+// An ImportRequest is the request to pass to Import().
+type ImportRequest struct {
+	// Project is the project to import the resources into.
+	Project string
+
+	// Type is the resource type to import.
+	Type string
+}
+
+// ImportResponse is returned from Import.
+type ImportResponse struct {
+	// Imported contains the names of the imported resources. Resources that
+	// had already been imported are not included.
+	Imported []string
+}
+
+// Import imports all existing resources of a type into the state of a
+// project. The resources are created outside of func; importing them does
+// not change them.
+//
+// The resource type must support listing its resources.
+//
+// The returned error is always of type *Error.
+func (s *Server) Import(ctx context.Context, req *ImportRequest) (*ImportResponse, error) {
+	logger := s.Logger
+	logger.Info("Import", zap.String("project", req.Project), zap.String("type", req.Type))
+
+	if req.Project == "" {
+		logger.Debug("Project not set")
+		return nil, &Error{Code: ValidationError, Message: "Project not set"}
+	}
+	if req.Type == "" {
+		logger.Debug("Type not set")
+		return nil, &Error{Code: ValidationError, Message: "Resource type must be set"}
+	}
+	if s.Importer == nil {
+		logger.Debug("Importer not set")
+		return nil, &Error{Code: ValidationError, Message: "Import is not supported"}
+	}
+	if s.Registry.Type(req.Type) == nil {
+		logger.Debug("Type not registered")
+		return nil, &Error{
+			Code:    ValidationError,
+			Message: fmt.Sprintf("Resource type %q not supported", req.Type),
+		}
+	}
+
+	id := ksuid.New().String()
+	imported, err := s.Importer.ImportAll(ctx, id, req.Project, req.Type)
+	if err != nil {
+		logger.Error("Could not import resources", zap.Error(err))
+		return nil, &Error{Code: Unavailable}
+	}
+
+	names := make([]string, len(imported))
+	for i, res := range imported {
+		names[i] = res.Name
+	}
+	return &ImportResponse{Imported: names}, nil
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/func/func/resource"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServer_Import(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       *ImportRequest
+		importErr error
+		want      *ImportResponse
+		wantErr   error
+	}{
+		{
+			name: "OK",
+			req:  &ImportRequest{Project: "proj", Type: "t"},
+			want: &ImportResponse{Imported: []string{"a", "b"}},
+		},
+		{
+			name:    "NoProject",
+			req:     &ImportRequest{Type: "t"},
+			wantErr: &Error{Code: ValidationError, Message: "Project not set"},
+		},
+		{
+			name:    "NotRegistered",
+			req:     &ImportRequest{Project: "proj", Type: "other"},
+			wantErr: &Error{Code: ValidationError, Message: `Resource type "other" not supported`},
+		},
+		{
+			name:      "ImportError",
+			req:       &ImportRequest{Project: "proj", Type: "t"},
+			importErr: errors.New("list failed"),
+			wantErr:   &Error{Code: Unavailable},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imp := importerFunc(func(ctx context.Context, id, project, typename string) ([]*resource.Deployed, error) {
+				if project != "proj" || typename != "t" {
+					t.Errorf("ImportAll() project = %q, type = %q", project, typename)
+				}
+				if tt.importErr != nil {
+					return nil, tt.importErr
+				}
+				return []*resource.Deployed{
+					{Desired: &resource.Desired{Name: "a", Type: "t"}},
+					{Desired: &resource.Desired{Name: "b", Type: "t"}},
+				}, nil
+			})
+
+			s := &Server{
+				Logger: zaptest.NewLogger(t),
+				Registry: &resource.Registry{
+					Types: map[string]reflect.Type{"t": reflect.TypeOf(struct{}{})},
+				},
+				Importer: imp,
+			}
+
+			got, err := s.Import(context.Background(), tt.req)
+			if diff := cmp.Diff(err, tt.wantErr); diff != "" {
+				t.Errorf("Import() error (-got +want)\n%s", diff)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("Import() (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+type importerFunc func(ctx context.Context, id, project, typename string) ([]*resource.Deployed, error)
+
+func (f importerFunc) ImportAll(ctx context.Context, id, project, typename string) ([]*resource.Deployed, error) {
+	return f(ctx, id, project, typename)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"sort"
+
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"go.uber.org/zap"
+)
+
+// An OutputsRequest is the request to pass to Outputs().
+type OutputsRequest struct {
+	// Project is the project to get outputs for.
+	Project string
+}
+
+// OutputsResponse is returned from Outputs.
+type OutputsResponse struct {
+	// Outputs contains the output values, keyed by output name.
+	Outputs map[string]cty.Value
+
+	// Sensitive contains the sorted names of the outputs that are marked
+	// sensitive. Their values are included in Outputs but should not be
+	// displayed.
+	Sensitive []string
+}
+
+// Outputs returns the resolved outputs for a project.
+//
+// The outputs are resolved from the most recently stored graph and the
+// currently deployed resources.
+//
+// The returned error is always of type *Error.
+func (s *Server) Outputs(ctx context.Context, req *OutputsRequest) (*OutputsResponse, error) {
+	logger := s.Logger
+	logger.Info("Outputs", zap.String("project", req.Project))
+
+	if req.Project == "" {
+		logger.Debug("Project not set")
+		return nil, &Error{Code: ValidationError, Message: "Project not set"}
+	}
+
+	g, err := s.Storage.GetGraph(ctx, req.Project)
+	if err != nil {
+		logger.Error("Could not get graph", zap.Error(err))
+		return nil, &Error{Code: Unavailable}
+	}
+	if g == nil {
+		logger.Debug("Project has no graph")
+		return nil, &Error{Code: ValidationError, Message: "Project has not been applied"}
+	}
+
+	outputs, err := s.resolveOutputs(ctx, req.Project, g.Outputs)
+	if err != nil {
+		logger.Error("Could not resolve outputs", zap.Error(err))
+		return nil, &Error{Code: Unavailable, Message: err.Error()}
+	}
+
+	return &OutputsResponse{
+		Outputs:   outputs,
+		Sensitive: sensitiveOutputs(g.Outputs),
+	}, nil
+}
+
+// resolveOutputs resolves outputs against the resources currently deployed in
+// a project.
+func (s *Server) resolveOutputs(ctx context.Context, project string, outputs []*resource.Output) (map[string]cty.Value, error) {
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+	deployed, err := s.Storage.ListResources(ctx, project)
+	if err != nil {
+		return nil, errors.Wrap(err, "list resources")
+	}
+	return resource.ResolveOutputs(outputs, deployed)
+}
+
+// sensitiveOutputs returns the sorted names of the outputs that are marked
+// sensitive.
+func sensitiveOutputs(outputs []*resource.Output) []string {
+	var names []string
+	for _, o := range outputs {
+		if o.Sensitive {
+			names = append(names, o.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
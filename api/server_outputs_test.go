@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/func/func/resource"
+	"github.com/func/func/storage/teststore"
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServer_Outputs_NoProject(t *testing.T) {
+	s := &Server{
+		Logger: zaptest.NewLogger(t),
+	}
+
+	_, err := s.Outputs(context.Background(), &OutputsRequest{})
+	wantErr := &Error{Code: ValidationError, Message: "Project not set"}
+	if diff := cmp.Diff(err, wantErr); diff != "" {
+		t.Errorf("Error (-got +want)\n%s", diff)
+	}
+}
+
+func TestServer_Outputs_NotApplied(t *testing.T) {
+	s := &Server{
+		Logger:  zaptest.NewLogger(t),
+		Storage: &teststore.Store{},
+	}
+
+	_, err := s.Outputs(context.Background(), &OutputsRequest{Project: "testproject"})
+	wantErr := &Error{Code: ValidationError, Message: "Project has not been applied"}
+	if diff := cmp.Diff(err, wantErr); diff != "" {
+		t.Errorf("Error (-got +want)\n%s", diff)
+	}
+}
+
+func TestServer_Outputs_OK(t *testing.T) {
+	store := &teststore.Store{}
+	store.SeedGraph("testproject", &resource.Graph{
+		Outputs: []*resource.Output{
+			{
+				Name: "arn",
+				Expression: resource.Expression{
+					resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("arn")},
+				},
+			},
+			{
+				Name: "secret",
+				Expression: resource.Expression{
+					resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("secret")},
+				},
+				Sensitive: true,
+			},
+		},
+	})
+	store.SeedResources("testproject", []*resource.Deployed{{
+		Desired: &resource.Desired{
+			Name:  "foo",
+			Type:  "bar",
+			Input: cty.EmptyObjectVal,
+		},
+		ID: "123",
+		Output: cty.ObjectVal(map[string]cty.Value{
+			"arn":    cty.StringVal("arn:foo"),
+			"secret": cty.StringVal("hunter2"),
+		}),
+	}})
+
+	s := &Server{
+		Logger:  zaptest.NewLogger(t),
+		Storage: store,
+	}
+
+	resp, err := s.Outputs(context.Background(), &OutputsRequest{Project: "testproject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sensitive values are returned, the names are listed so the client
+	// can redact them.
+	want := map[string]cty.Value{
+		"arn":    cty.StringVal("arn:foo"),
+		"secret": cty.StringVal("hunter2"),
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool { return a.RawEquals(b) }),
+	}
+	if diff := cmp.Diff(resp.Outputs, want, opts...); diff != "" {
+		t.Errorf("Outputs (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(resp.Sensitive, []string{"secret"}); diff != "" {
+		t.Errorf("Sensitive (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/func/func/resource"
+	"go.uber.org/zap"
+)
+
+// A StateRemoveRequest is the request to pass to StateRemove().
+type StateRemoveRequest struct {
+	// Project is the project to remove the resource from.
+	Project string
+
+	// Type and Name identify the resource to remove.
+	Type string
+	Name string
+
+	// Force removes the resource even if other resources depend on it.
+	Force bool
+}
+
+// StateRemove removes a deployed resource from the state without deleting
+// it. The resource is no longer managed, the provider is not invoked.
+//
+// If other deployed resources depend on the resource, it is not removed
+// unless Force is set.
+//
+// The returned error is always of type *Error.
+func (s *Server) StateRemove(ctx context.Context, req *StateRemoveRequest) error {
+	logger := s.Logger
+	logger.Info("State remove",
+		zap.String("project", req.Project),
+		zap.String("type", req.Type),
+		zap.String("name", req.Name),
+	)
+
+	if req.Project == "" {
+		logger.Debug("Project not set")
+		return &Error{Code: ValidationError, Message: "Project not set"}
+	}
+	if req.Type == "" || req.Name == "" {
+		logger.Debug("Resource not set")
+		return &Error{Code: ValidationError, Message: "Resource type and name must be set"}
+	}
+
+	resources, err := s.Storage.ListResources(ctx, req.Project)
+	if err != nil {
+		logger.Error("Could not list resources", zap.Error(err))
+		return &Error{Code: Unavailable}
+	}
+
+	var target *resource.Deployed
+	var dependents []string
+	for _, res := range resources {
+		if res.Type == req.Type && res.Name == req.Name {
+			target = res
+			continue
+		}
+		for _, dep := range res.Deps {
+			if dep == req.Name {
+				dependents = append(dependents, fmt.Sprintf("%s.%s", res.Type, res.Name))
+				break
+			}
+		}
+	}
+	if target == nil {
+		logger.Debug("Resource not found")
+		return &Error{
+			Code:    ValidationError,
+			Message: fmt.Sprintf("Resource %s.%s not found", req.Type, req.Name),
+		}
+	}
+	if len(dependents) > 0 && !req.Force {
+		sort.Strings(dependents)
+		logger.Debug("Resource has dependents", zap.Strings("dependents", dependents))
+		return &Error{
+			Code: ValidationError,
+			Message: fmt.Sprintf(
+				"Resource %s.%s is a dependency of %s",
+				req.Type, req.Name, strings.Join(dependents, ", "),
+			),
+		}
+	}
+
+	if err := s.Storage.DeleteResource(ctx, req.Project, target); err != nil {
+		logger.Error("Could not remove resource", zap.Error(err))
+		return &Error{Code: Unavailable}
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/func/func/resource"
+	"github.com/func/func/storage/teststore"
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServer_StateRemove(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       *StateRemoveRequest
+		wantErr   error
+		wantNames []string // Remaining resources
+	}{
+		{
+			name:      "NoDependents",
+			req:       &StateRemoveRequest{Project: "proj", Type: "t", Name: "child"},
+			wantNames: []string{"parent"},
+		},
+		{
+			name: "HasDependents",
+			req:  &StateRemoveRequest{Project: "proj", Type: "t", Name: "parent"},
+			wantErr: &Error{
+				Code:    ValidationError,
+				Message: "Resource t.parent is a dependency of t.child",
+			},
+			wantNames: []string{"child", "parent"},
+		},
+		{
+			name:      "Force",
+			req:       &StateRemoveRequest{Project: "proj", Type: "t", Name: "parent", Force: true},
+			wantNames: []string{"child"},
+		},
+		{
+			name: "NotFound",
+			req:  &StateRemoveRequest{Project: "proj", Type: "other", Name: "parent"},
+			wantErr: &Error{
+				Code:    ValidationError,
+				Message: "Resource other.parent not found",
+			},
+			wantNames: []string{"child", "parent"},
+		},
+		{
+			name:      "NoProject",
+			req:       &StateRemoveRequest{Type: "t", Name: "child"},
+			wantErr:   &Error{Code: ValidationError, Message: "Project not set"},
+			wantNames: []string{"child", "parent"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &teststore.Store{}
+			store.SeedResources("proj", []*resource.Deployed{
+				{
+					Desired: &resource.Desired{Name: "parent", Type: "t", Input: cty.EmptyObjectVal},
+					ID:      "1",
+					Output:  cty.EmptyObjectVal,
+				},
+				{
+					Desired: &resource.Desired{Name: "child", Type: "t", Input: cty.EmptyObjectVal},
+					ID:      "2",
+					Output:  cty.EmptyObjectVal,
+					Deps:    []string{"parent"},
+				},
+			})
+
+			s := &Server{
+				Logger:  zaptest.NewLogger(t),
+				Storage: store,
+			}
+
+			err := s.StateRemove(context.Background(), tt.req)
+			if diff := cmp.Diff(err, tt.wantErr); diff != "" {
+				t.Errorf("Error (-got +want)\n%s", diff)
+			}
+
+			remaining, err := store.ListResources(context.Background(), "proj")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, res := range remaining {
+				names = append(names, res.Name)
+			}
+			sort.Strings(names)
+			if diff := cmp.Diff(names, tt.wantNames); diff != "" {
+				t.Errorf("Remaining resources (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
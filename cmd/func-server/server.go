@@ -10,6 +10,7 @@ import (
 	"github.com/func/func/api"
 	"github.com/func/func/api/httpapi"
 	"github.com/func/func/provider/aws"
+	"github.com/func/func/provider/local"
 	"github.com/func/func/resource"
 	"github.com/func/func/resource/reconciler"
 	"github.com/func/func/resource/validation"
@@ -18,6 +19,7 @@ import (
 	"github.com/mattn/go-isatty"
 	"github.com/segmentio/ksuid"
 	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
 	"go.uber.org/zap"
 )
 
@@ -34,6 +36,17 @@ var startCommand = &cobra.Command{
 		aws.Register(reg)
 		aws.AddValidators(validator)
 
+		localExec, err := cmd.Flags().GetBool("enable-local-exec")
+		if err != nil {
+			panic(err)
+		}
+		if localExec {
+			// local_exec runs commands from the config on this host, with
+			// the server's credentials. Any client of the API can run
+			// arbitrary commands, so it is only enabled on request.
+			local.Register(reg)
+		}
+
 		cfg, err := external.LoadDefaultAWSConfig()
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
@@ -70,6 +83,27 @@ var startCommand = &cobra.Command{
 		}
 		dynamo := dynamodb.New(cfg, table, reg)
 
+		concurrency, err := parallelism(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n%s", err, cmd.UsageString())
+			os.Exit(2)
+		}
+
+		projectTags, err := cmd.Flags().GetBool("project-tags")
+		if err != nil {
+			panic(err)
+		}
+
+		refresh, err := cmd.Flags().GetBool("refresh")
+		if err != nil {
+			panic(err)
+		}
+
+		quarantine, err := cmd.Flags().GetDuration("quarantine")
+		if err != nil {
+			panic(err)
+		}
+
 		var logger *zap.Logger
 		if isatty.IsTerminal(os.Stdout.Fd()) {
 			l, err := zap.NewDevelopment()
@@ -88,23 +122,32 @@ var startCommand = &cobra.Command{
 			}()
 		}
 
+		rec := &reconciler.Reconciler{
+			Logger:             logger.Named("reconciler"),
+			Resources:          dynamo,
+			Source:             s3src,
+			Registry:           reg,
+			Validator:          validator,
+			Concurrency:        concurrency,
+			ProjectTags:        projectTags,
+			RefreshBeforeApply: refresh,
+			Quarantine:         quarantine,
+			IDGen: reconciler.IDGeneratorFunc(func() string {
+				return ksuid.New().String()
+			}),
+		}
+
 		api := &api.Server{
 			Logger:    logger.Named("server"),
 			Registry:  reg,
 			Source:    s3src,
 			Storage:   dynamo,
 			Validator: validator,
+			Defaults:  providerDefaults(),
 
 			// Setting reconciler enables sync reconciliation
-			Reconciler: &reconciler.Reconciler{
-				Logger:    logger.Named("reconciler"),
-				Resources: dynamo,
-				Source:    s3src,
-				Registry:  reg,
-				IDGen: reconciler.IDGeneratorFunc(func() string {
-					return ksuid.New().String()
-				}),
-			},
+			Reconciler: rec,
+			Importer:   rec,
 		}
 
 		server := &httpapi.Server{
@@ -131,6 +174,41 @@ func init() {
 	startCommand.Flags().String("s3-bucket", "", "S3 bucket for source code uploads. Env var: FUNC_S3_BUCKET")
 	startCommand.Flags().Duration("upload-expiry", 5*time.Minute, "Time for upload url expiry")
 	startCommand.Flags().String("dynamodb-table", "", "DynamoDB table for storage. Env var: FUNC_DYNAMODB_TABLE")
+	startCommand.Flags().Bool("project-tags", true, "Tag resources with the project and module they belong to")
+	startCommand.Flags().Bool("refresh", false, "Read the live state of resources before applying changes")
+	startCommand.Flags().Duration("quarantine", 0, "Time to skip resources that failed to apply, 0 to disable")
+	startCommand.Flags().Bool("enable-local-exec", false, "Allow local_exec resources. Lets any API client run commands on the server host")
+	addParallelismFlag(startCommand)
 
 	cmd.AddCommand(startCommand)
 }
+
+// addParallelismFlag adds the --parallelism flag to a command.
+func addParallelismFlag(cmd *cobra.Command) {
+	cmd.Flags().Int("parallelism", reconciler.DefaultConcurrency, "Maximum number of resources to reconcile concurrently")
+}
+
+// parallelism returns the reconciler concurrency set with the --parallelism
+// flag. An error is returned if the value is not positive.
+func parallelism(cmd *cobra.Command) (uint, error) {
+	n, err := cmd.Flags().GetInt("parallelism")
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("parallelism must be greater than 0, got %d", n)
+	}
+	return uint(n), nil
+}
+
+// providerDefaults returns default resource input values from the
+// environment.
+func providerDefaults() map[string]map[string]cty.Value {
+	defaults := make(map[string]map[string]cty.Value)
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		defaults["aws"] = map[string]cty.Value{
+			"region": cty.StringVal(region),
+		}
+	}
+	return defaults
+}
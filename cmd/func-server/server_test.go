@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/func/func/resource/reconciler"
+	"github.com/spf13/cobra"
+)
+
+func TestParallelism(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    uint
+		wantErr bool
+	}{
+		{"Default", nil, uint(reconciler.DefaultConcurrency), false},
+		{"Set", []string{"--parallelism", "3"}, 3, false},
+		{"Zero", []string{"--parallelism", "0"}, 0, true},
+		{"Negative", []string{"--parallelism=-1"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			addParallelismFlag(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+			got, err := parallelism(cmd)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parallelism() error = %v, wantErr = %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parallelism() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/func/func/api"
 	"github.com/func/func/api/httpapi"
 	"github.com/func/func/config"
+	"github.com/func/func/resource"
 	"github.com/func/func/source"
 	"github.com/hashicorp/hcl2/hcl"
 	"github.com/spf13/cobra"
@@ -88,11 +89,45 @@ var applyCommand = &cobra.Command{
 			}
 		}
 
+		vars, diags := loadVars(cmd, loader)
+		if len(diags) > 0 {
+			loader.WriteDiagnostics(os.Stderr, diags)
+			if diags.HasErrors() {
+				os.Exit(2)
+			}
+		}
+
 		addr, err := cmd.Flags().GetString("server")
 		if err != nil {
 			panic(err)
 		}
 
+		compact, err := cmd.Flags().GetBool("compact-warnings")
+		if err != nil {
+			panic(err)
+		}
+
+		strictTypes, err := cmd.Flags().GetBool("strict-types")
+		if err != nil {
+			panic(err)
+		}
+
+		strictVars, err := cmd.Flags().GetBool("strict-variables")
+		if err != nil {
+			panic(err)
+		}
+
+		retry, err := cmd.Flags().GetStringArray("retry")
+		if err != nil {
+			panic(err)
+		}
+		for _, addr := range retry {
+			if _, err := resource.ParseAddress(addr); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+		}
+
 		cli := &api.Client{
 			API:    &httpapi.Client{Endpoint: addr},
 			Source: loader,
@@ -100,13 +135,26 @@ var applyCommand = &cobra.Command{
 		}
 
 		req := &api.ApplyRequest{
-			Project: project.Name,
-			Config:  cfg,
+			Project:   project.Name,
+			Config:    cfg,
+			Variables: vars,
+			Env:       loadEnv(cfg),
+			Retry:     retry,
+
+			StrictTypes:     strictTypes,
+			StrictVariables: strictVars,
 		}
 
 		ctx := signalContext(context.Background())
-		if err := cli.Apply(ctx, req); err != nil {
+		resp, err := cli.Apply(ctx, req)
+		if cerr := loader.Close(); cerr != nil {
+			logger.Warn("Could not remove temporary files", zap.Error(cerr))
+		}
+		if err != nil {
 			if diags, ok := err.(hcl.Diagnostics); ok {
+				if compact {
+					diags = config.CompactWarnings(diags)
+				}
 				loader.WriteDiagnostics(os.Stderr, diags)
 				os.Exit(2)
 				return
@@ -114,6 +162,13 @@ var applyCommand = &cobra.Command{
 			logger.Fatal(err.Error())
 		}
 
+		if len(resp.Outputs) > 0 {
+			if err := printOutputs(os.Stdout, resp.Outputs, resp.Sensitive); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+
 		logger.Info(fmt.Sprintf("Done in %s", time.Since(start).Truncate(time.Millisecond)))
 	},
 }
@@ -121,6 +176,12 @@ var applyCommand = &cobra.Command{
 func init() {
 	applyCommand.Flags().Bool("verbose", false, "Verbose output")
 	applyCommand.Flags().String("server", "https://api.func.io", "Server endpoint")
+	applyCommand.Flags().StringArray("var", nil, "Set a variable value, in the form name=value")
+	applyCommand.Flags().String("var-file", "", "Load variable values from a file")
+	applyCommand.Flags().Bool("compact-warnings", false, "Show only the first of similar warnings")
+	applyCommand.Flags().StringArray("retry", nil, "Retry a quarantined resource, in the form type.name")
+	applyCommand.Flags().Bool("strict-types", false, "Fail on values that must be converted to the input type")
+	applyCommand.Flags().Bool("strict-variables", false, "Fail on unused variables and values for undeclared variables")
 
 	cmd.AddCommand(applyCommand)
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/func/func/config"
+	"github.com/spf13/cobra"
+)
+
+var fmtCommand = &cobra.Command{
+	Use:   "fmt [dir]",
+	Short: "Format config files",
+	Long: "Rewrite config files in the project to a canonical format.\n\n" +
+		"The names of the files that were changed are printed.\n" +
+		"With --check, files are not changed. If any file is not formatted, the command exits with status 3.",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+
+		project, err := config.FindProject(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if project == nil {
+			green := color.New(color.FgGreen).SprintFunc()
+			fmt.Fprintln(os.Stderr, "Project not found")
+			fmt.Fprintf(os.Stderr, "Set up a new project with %s\n", green("func project new"))
+			os.Exit(2)
+			return
+		}
+
+		check, err := cmd.Flags().GetBool("check")
+		if err != nil {
+			panic(err)
+		}
+
+		loader := &config.Loader{}
+		files, diags := loader.Format(project.RootDir)
+		if len(diags) > 0 {
+			loader.WriteDiagnostics(os.Stderr, diags)
+			if diags.HasErrors() {
+				os.Exit(2)
+			}
+		}
+
+		for _, f := range files {
+			fmt.Println(f.Filename)
+			if check {
+				continue
+			}
+			info, err := os.Stat(f.Filename)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if err := ioutil.WriteFile(f.Filename, f.Formatted, info.Mode()); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+
+		if check && len(files) > 0 {
+			os.Exit(3)
+		}
+	},
+}
+
+func init() {
+	fmtCommand.Flags().Bool("check", false, "Check if files are formatted without changing them")
+
+	cmd.AddCommand(fmtCommand)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/func/func/api"
+	"github.com/func/func/api/httpapi"
+	"github.com/func/func/config"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var importCommand = &cobra.Command{
+	Use:   "import <type>",
+	Short: "Import existing resources into state",
+	Long: "Import all existing resources of a type into state.\n\n" +
+		"The resources are named after their ids in the provider and are not changed.\n" +
+		"Imported resources are kept in state until a resource with the same name is\n" +
+		"added to the config, after which they are managed like any other resource.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		project, err := config.FindProject(".")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if project == nil {
+			green := color.New(color.FgGreen).SprintFunc()
+			fmt.Fprintln(os.Stderr, "Project not found")
+			fmt.Fprintf(os.Stderr, "Set up a new project with %s\n", green("func project new"))
+			os.Exit(2)
+			return
+		}
+
+		addr, err := cmd.Flags().GetString("server")
+		if err != nil {
+			panic(err)
+		}
+
+		cli := &api.Client{
+			API:    &httpapi.Client{Endpoint: addr},
+			Logger: zap.NewNop(),
+		}
+
+		req := &api.ImportRequest{
+			Project: project.Name,
+			Type:    args[0],
+		}
+
+		ctx := signalContext(context.Background())
+		resp, err := cli.Import(ctx, req)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		for _, name := range resp.Imported {
+			fmt.Printf("Imported %s.%s\n", args[0], name)
+		}
+		fmt.Printf("%d resources imported\n", len(resp.Imported))
+	},
+}
+
+func init() {
+	importCommand.Flags().String("server", "https://api.func.io", "Server endpoint")
+
+	cmd.AddCommand(importCommand)
+}
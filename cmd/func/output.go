@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/func/func/api"
+	"github.com/func/func/api/httpapi"
+	"github.com/func/func/config"
+	"github.com/func/func/ctyext"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	"go.uber.org/zap"
+)
+
+var outputCommand = &cobra.Command{
+	Use:   "output [name]",
+	Short: "Show output values",
+	Long: "Show output values from the most recent apply.\n\n" +
+		"If a name is given, only the value of that output is printed.\n" +
+		"Outputs marked sensitive are redacted when all outputs are listed.\n" +
+		"The value of a sensitive output is only printed when it is requested by name.",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		project, err := config.FindProject(".")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if project == nil {
+			green := color.New(color.FgGreen).SprintFunc()
+			fmt.Fprintln(os.Stderr, "Project not found")
+			fmt.Fprintf(os.Stderr, "Set up a new project with %s\n", green("func project new"))
+			os.Exit(2)
+			return
+		}
+
+		addr, err := cmd.Flags().GetString("server")
+		if err != nil {
+			panic(err)
+		}
+
+		cli := &api.Client{
+			API:    &httpapi.Client{Endpoint: addr},
+			Logger: zap.NewNop(),
+		}
+
+		ctx := signalContext(context.Background())
+		resp, err := cli.Outputs(ctx, &api.OutputsRequest{Project: project.Name})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		if len(args) == 1 {
+			v, ok := resp.Outputs[args[0]]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Output %q not found\n", args[0])
+				os.Exit(1)
+			}
+			str, err := outputString(v)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			fmt.Println(str)
+			return
+		}
+
+		if err := printOutputs(os.Stdout, resp.Outputs, resp.Sensitive); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	outputCommand.Flags().String("server", "https://api.func.io", "Server endpoint")
+
+	cmd.AddCommand(outputCommand)
+}
+
+// printOutputs writes all outputs to w, sorted by name. The values of the
+// outputs listed in sensitive are redacted.
+func printOutputs(w io.Writer, outputs map[string]cty.Value, sensitive []string) error {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	redact := make(map[string]bool, len(sensitive))
+	for _, name := range sensitive {
+		redact[name] = true
+	}
+
+	cyan := color.New(color.FgCyan).SprintFunc()
+	for _, name := range names {
+		if redact[name] {
+			fmt.Fprintf(w, "%s = <sensitive>\n", cyan(name))
+			continue
+		}
+		str, err := outputString(outputs[name])
+		if err != nil {
+			return fmt.Errorf("output %s: %v", name, err)
+		}
+		fmt.Fprintf(w, "%s = %s\n", cyan(name), str)
+	}
+	return nil
+}
+
+// outputString formats an output value for display. Strings are printed as-is,
+// other values are encoded as json.
+func outputString(v cty.Value) (string, error) {
+	if v.Type() == cty.String && v.IsKnown() && !v.IsNull() {
+		return v.AsString(), nil
+	}
+	b, err := ctyext.MarshalJSON(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
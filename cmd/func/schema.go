@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/func/func/provider/aws"
+	"github.com/func/func/provider/local"
+	"github.com/func/func/resource"
+	"github.com/func/func/resource/hcldecoder"
+	"github.com/func/func/suggest"
+	"github.com/spf13/cobra"
+)
+
+var schemaCommand = &cobra.Command{
+	Use:   "schema <type>",
+	Short: "Print a config template for a resource type",
+	Long: "Print a config template for a resource type.\n\n" +
+		"Every input of the resource is listed with a placeholder value. A comment describes the type\n" +
+		"of the input, whether it is required and how it is validated.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reg := &resource.Registry{}
+		aws.Register(reg)
+		local.Register(reg)
+
+		dec := &hcldecoder.Decoder{Resources: reg}
+		out, ok := dec.Skeleton(args[0])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Resource type %q not supported\n", args[0])
+			if s := suggest.String(args[0], reg.Typenames()); s != "" {
+				fmt.Fprintf(os.Stderr, "Did you mean %q?\n", s)
+			}
+			os.Exit(2)
+			return
+		}
+		fmt.Print(string(out))
+	},
+}
+
+func init() {
+	cmd.AddCommand(schemaCommand)
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var stateCommand = &cobra.Command{
+	Use:   "state",
+	Short: "Manage deployed resource state",
+}
+
+func init() {
+	cmd.AddCommand(stateCommand)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/func/func/api"
+	"github.com/func/func/api/httpapi"
+	"github.com/func/func/config"
+	"github.com/func/func/resource"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var stateRmCommand = &cobra.Command{
+	Use:   "rm <type.name>",
+	Short: "Remove a resource from state without deleting it",
+	Long: "Remove a resource from state without deleting it.\n\n" +
+		"The resource is no longer managed by func. The resource itself is left as is.\n" +
+		"If other resources depend on the resource, --force must be set.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		res, err := resource.ParseAddress(args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+			return
+		}
+
+		project, err := config.FindProject(".")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if project == nil {
+			green := color.New(color.FgGreen).SprintFunc()
+			fmt.Fprintln(os.Stderr, "Project not found")
+			fmt.Fprintf(os.Stderr, "Set up a new project with %s\n", green("func project new"))
+			os.Exit(2)
+			return
+		}
+
+		addr, err := cmd.Flags().GetString("server")
+		if err != nil {
+			panic(err)
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			panic(err)
+		}
+
+		cli := &api.Client{
+			API:    &httpapi.Client{Endpoint: addr},
+			Logger: zap.NewNop(),
+		}
+
+		req := &api.StateRemoveRequest{
+			Project: project.Name,
+			Type:    res.Type,
+			Name:    res.ResourceName(),
+			Force:   force,
+		}
+
+		ctx := signalContext(context.Background())
+		if err := cli.StateRemove(ctx, req); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Removed %s from state\n", args[0])
+	},
+}
+
+func init() {
+	stateRmCommand.Flags().Bool("force", false, "Remove even if other resources depend on the resource")
+	stateRmCommand.Flags().String("server", "https://api.func.io", "Server endpoint")
+
+	stateCommand.AddCommand(stateRmCommand)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/func/func/config"
+	"github.com/func/func/provider/aws"
+	"github.com/func/func/provider/local"
+	"github.com/func/func/resource"
+	"github.com/func/func/resource/hcldecoder"
+	"github.com/func/func/resource/validation"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/spf13/cobra"
+)
+
+var validateCommand = &cobra.Command{
+	Use:   "validate [dir]",
+	Short: "Validate config files",
+	Long: "Validate the config files in the project without applying them.\n\n" +
+		"The config is decoded and resource inputs are validated as they would be on apply.\n" +
+		"With --diagnostics-format json, diagnostics are written to stdout as a JSON array, for editor integration.\n" +
+		"If the config contains errors, the command exits with status 2.",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+
+		format, err := cmd.Flags().GetString("diagnostics-format")
+		if err != nil {
+			panic(err)
+		}
+		if format != "text" && format != "json" {
+			fmt.Fprintf(os.Stderr, "Unsupported diagnostics format %q, must be text or json\n", format)
+			os.Exit(1)
+		}
+
+		project, err := config.FindProject(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if project == nil {
+			green := color.New(color.FgGreen).SprintFunc()
+			fmt.Fprintln(os.Stderr, "Project not found")
+			fmt.Fprintf(os.Stderr, "Set up a new project with %s\n", green("func project new"))
+			os.Exit(2)
+			return
+		}
+
+		loader := &config.Loader{}
+		diags := validateProject(cmd, loader, project.RootDir)
+
+		if format == "json" {
+			if err := config.WriteDiagnosticsJSON(os.Stdout, diags); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		} else if len(diags) > 0 {
+			compact, err := cmd.Flags().GetBool("compact-warnings")
+			if err != nil {
+				panic(err)
+			}
+			if compact {
+				diags = config.CompactWarnings(diags)
+			}
+			loader.WriteDiagnostics(os.Stderr, diags)
+		}
+		if diags.HasErrors() {
+			os.Exit(2)
+		}
+	},
+}
+
+// validateProject loads and decodes the config in the project root
+// directory. Source code is not collected.
+func validateProject(cmd *cobra.Command, loader *config.Loader, root string) hcl.Diagnostics {
+	body, diags := loader.Load(root)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	vars, morediags := loadVars(cmd, loader)
+	diags = append(diags, morediags...)
+	if morediags.HasErrors() {
+		return diags
+	}
+
+	validator := validation.New()
+	validation.AddBuiltin(validator)
+	reg := &resource.Registry{}
+	aws.Register(reg)
+	aws.AddValidators(validator)
+	local.Register(reg)
+
+	quiet, err := cmd.Flags().GetBool("quiet-conversions")
+	if err != nil {
+		panic(err)
+	}
+
+	strictTypes, err := cmd.Flags().GetBool("strict-types")
+	if err != nil {
+		panic(err)
+	}
+
+	strictVars, err := cmd.Flags().GetBool("strict-variables")
+	if err != nil {
+		panic(err)
+	}
+
+	dec := &hcldecoder.Decoder{
+		Resources:        reg,
+		Validator:        validator,
+		Variables:        vars,
+		Env:              loadEnv(body),
+		QuietConversions: quiet,
+		StrictTypes:      strictTypes,
+		StrictVariables:  strictVars,
+	}
+	_, morediags = dec.DecodeBody(body, &resource.Graph{})
+	return append(diags, morediags...)
+}
+
+func init() {
+	validateCommand.Flags().String("diagnostics-format", "text", "Format for diagnostics: text or json")
+	validateCommand.Flags().StringArray("var", nil, "Set a variable value, in the form name=value")
+	validateCommand.Flags().String("var-file", "", "Load variable values from a file")
+	validateCommand.Flags().Bool("compact-warnings", false, "Show only the first of similar warnings")
+	validateCommand.Flags().Bool("quiet-conversions", false, "Do not warn about values converted to the input type")
+	validateCommand.Flags().Bool("strict-types", false, "Fail on values that must be converted to the input type")
+	validateCommand.Flags().Bool("strict-variables", false, "Fail on unused variables and values for undeclared variables")
+
+	cmd.AddCommand(validateCommand)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/func/func/config"
+)
+
+func TestValidateProject_source(t *testing.T) {
+	dir, err := ioutil.TempDir("", "func-validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"func.hcl": `
+			resource "lambda" {
+			  type          = "aws_lambda_function"
+			  source        = "./src"
+			  function_name = "hello"
+			  handler       = "index.handler"
+			  region        = "us-east-1"
+			  role          = "arn:aws:iam::123456789012:role/lambda"
+			  runtime       = "nodejs10.x"
+			}
+		`,
+		"src/index.js": `exports.handler = () => {}`,
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Source files are not collected when validating.
+	loader := &config.Loader{}
+	defer loader.Close()
+	diags := validateProject(validateCommand, loader, dir)
+	if diags.HasErrors() {
+		t.Errorf("validateProject() error = %v", diags)
+	}
+}
+
+func TestValidateProject_remoteState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "func-validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := `
+		data "remote_state" "network" {
+		  project = "network"
+		}
+		resource "role" {
+		  type                        = "aws_iam_role"
+		  role_name                   = "${data.remote_state.network.prefix}-role"
+		  assume_role_policy_document = "{}"
+		}
+	`
+	if err := ioutil.WriteFile(filepath.Join(dir, "func.hcl"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Remote state is not read when validating, the outputs are unknown.
+	loader := &config.Loader{}
+	defer loader.Close()
+	diags := validateProject(validateCommand, loader, dir)
+	if diags.HasErrors() {
+		t.Errorf("validateProject() error = %v", diags)
+	}
+}
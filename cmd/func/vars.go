@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/func/func/config"
+	"github.com/func/func/resource/hcldecoder"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hclpack"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// loadVars loads variable values set with the --var-file and --var flags.
+// Values set with --var take precedence over values in the file.
+func loadVars(cmd *cobra.Command, loader *config.Loader) (map[string]cty.Value, hcl.Diagnostics) {
+	file, err := cmd.Flags().GetString("var-file")
+	if err != nil {
+		panic(err)
+	}
+	args, err := cmd.Flags().GetStringArray("var")
+	if err != nil {
+		panic(err)
+	}
+
+	vars := make(map[string]cty.Value)
+	var diags hcl.Diagnostics
+	if file != "" {
+		vv, morediags := loader.LoadVars(file)
+		diags = append(diags, morediags...)
+		if morediags.HasErrors() {
+			return nil, diags
+		}
+		for k, v := range vv {
+			vars[k] = v
+		}
+	}
+
+	for _, arg := range args {
+		i := strings.Index(arg, "=")
+		if i <= 0 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid variable",
+				Detail:   fmt.Sprintf("The value %q must be in the form name=value.", arg),
+			})
+			continue
+		}
+		vars[arg[:i]] = cty.StringVal(arg[i+1:])
+	}
+
+	return vars, diags
+}
+
+// loadEnv returns the values of the environment variables read with the env
+// function in the config. The config is decoded on the server, so the values
+// are sent with the request instead of being read there.
+func loadEnv(body *hclpack.Body) map[string]string {
+	env := make(map[string]string)
+	for _, name := range hcldecoder.EnvNames(body) {
+		if v, ok := os.LookupEnv(name); ok {
+			env[name] = v
+		}
+	}
+	return env
+}
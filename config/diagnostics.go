@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/hcl2/hcl"
+)
+
+// A JSONDiagnostic is the JSON representation of a diagnostic, written by
+// WriteDiagnosticsJSON.
+type JSONDiagnostic struct {
+	Severity string     `json:"severity"` // "error" or "warning".
+	Summary  string     `json:"summary"`
+	Detail   string     `json:"detail,omitempty"`
+	Range    *JSONRange `json:"range,omitempty"`   // Source range the diagnostic applies to.
+	Context  *JSONRange `json:"context,omitempty"` // Optional enclosing range, such as a block.
+}
+
+// A JSONRange is a range in a source file.
+type JSONRange struct {
+	Filename string  `json:"filename"`
+	Start    JSONPos `json:"start"`
+	End      JSONPos `json:"end"`
+}
+
+// A JSONPos is a position in a source file. Line and column are 1-based, in
+// characters. Byte is a 0-based offset.
+type JSONPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+// WriteDiagnosticsJSON writes diagnostics as a JSON array to w, for
+// consumption by editors and other tools. An empty array is written if there
+// are no diagnostics.
+func WriteDiagnosticsJSON(w io.Writer, diags hcl.Diagnostics) error {
+	out := make([]JSONDiagnostic, len(diags))
+	for i, d := range diags {
+		sev := "warning"
+		if d.Severity == hcl.DiagError {
+			sev = "error"
+		}
+		out[i] = JSONDiagnostic{
+			Severity: sev,
+			Summary:  d.Summary,
+			Detail:   d.Detail,
+			Range:    jsonRange(d.Subject),
+			Context:  jsonRange(d.Context),
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func jsonRange(rng *hcl.Range) *JSONRange {
+	if rng == nil {
+		return nil
+	}
+	return &JSONRange{
+		Filename: rng.Filename,
+		Start:    JSONPos{Line: rng.Start.Line, Column: rng.Start.Column, Byte: rng.Start.Byte},
+		End:      JSONPos{Line: rng.End.Line, Column: rng.End.Column, Byte: rng.End.Byte},
+	}
+}
+
+// CompactWarnings returns the diagnostics with warnings grouped by summary.
+// Only the first warning with each summary is kept; the number of omitted
+// warnings is added to its detail. Errors are kept as is.
+func CompactWarnings(diags hcl.Diagnostics) hcl.Diagnostics {
+	counts := make(map[string]int)
+	for _, d := range diags {
+		if d.Severity == hcl.DiagWarning {
+			counts[d.Summary]++
+		}
+	}
+
+	out := make(hcl.Diagnostics, 0, len(diags))
+	seen := make(map[string]bool)
+	for _, d := range diags {
+		if d.Severity != hcl.DiagWarning {
+			out = append(out, d)
+			continue
+		}
+		if seen[d.Summary] {
+			continue
+		}
+		seen[d.Summary] = true
+		if n := counts[d.Summary] - 1; n > 0 {
+			cp := *d
+			more := fmt.Sprintf("(and %d more similar warnings)", n)
+			if n == 1 {
+				more = "(and 1 more similar warning)"
+			}
+			if cp.Detail != "" {
+				more = cp.Detail + " " + more
+			}
+			cp.Detail = more
+			d = &cp
+		}
+		out = append(out, d)
+	}
+	return out
+}
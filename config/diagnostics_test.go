@@ -0,0 +1,125 @@
+package config_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/func/func/config"
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl2/hcl"
+)
+
+func TestWriteDiagnosticsJSON(t *testing.T) {
+	diags := hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported argument",
+			Detail:   `An argument named "foo" is not expected here.`,
+			Subject: &hcl.Range{
+				Filename: "func.hcl",
+				Start:    hcl.Pos{Line: 3, Column: 3, Byte: 40},
+				End:      hcl.Pos{Line: 3, Column: 6, Byte: 43},
+			},
+			Context: &hcl.Range{
+				Filename: "func.hcl",
+				Start:    hcl.Pos{Line: 1, Column: 1, Byte: 0},
+				End:      hcl.Pos{Line: 4, Column: 2, Byte: 50},
+			},
+		},
+		{
+			Severity: hcl.DiagWarning,
+			Summary:  "Unused variable",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := config.WriteDiagnosticsJSON(&buf, diags); err != nil {
+		t.Fatalf("WriteDiagnosticsJSON() error = %v", err)
+	}
+
+	want := `[
+  {
+    "severity": "error",
+    "summary": "Unsupported argument",
+    "detail": "An argument named \"foo\" is not expected here.",
+    "range": {
+      "filename": "func.hcl",
+      "start": {
+        "line": 3,
+        "column": 3,
+        "byte": 40
+      },
+      "end": {
+        "line": 3,
+        "column": 6,
+        "byte": 43
+      }
+    },
+    "context": {
+      "filename": "func.hcl",
+      "start": {
+        "line": 1,
+        "column": 1,
+        "byte": 0
+      },
+      "end": {
+        "line": 4,
+        "column": 2,
+        "byte": 50
+      }
+    }
+  },
+  {
+    "severity": "warning",
+    "summary": "Unused variable"
+  }
+]
+`
+	if diff := cmp.Diff(buf.String(), want); diff != "" {
+		t.Errorf("JSON (-got +want)\n%s", diff)
+	}
+}
+
+func TestWriteDiagnosticsJSON_empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := config.WriteDiagnosticsJSON(&buf, nil); err != nil {
+		t.Fatalf("WriteDiagnosticsJSON() error = %v", err)
+	}
+	if got, want := buf.String(), "[]\n"; got != want {
+		t.Errorf("JSON = %q, want %q", got, want)
+	}
+}
+
+func TestCompactWarnings(t *testing.T) {
+	conv := func(line int) *hcl.Diagnostic {
+		return &hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Value is converted from number to string",
+			Subject:  &hcl.Range{Filename: "func.hcl", Start: hcl.Pos{Line: line}},
+		}
+	}
+	unused := &hcl.Diagnostic{Severity: hcl.DiagWarning, Summary: "Unused variable", Detail: "Remove it."}
+	errDiag := &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "Unsupported argument"}
+
+	diags := hcl.Diagnostics{conv(1), unused, conv(2), errDiag, conv(3)}
+	got := config.CompactWarnings(diags)
+
+	want := hcl.Diagnostics{
+		{
+			Severity: hcl.DiagWarning,
+			Summary:  "Value is converted from number to string",
+			Detail:   "(and 2 more similar warnings)",
+			Subject:  &hcl.Range{Filename: "func.hcl", Start: hcl.Pos{Line: 1}},
+		},
+		unused,
+		errDiag,
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("CompactWarnings() (-got +want)\n%s", diff)
+	}
+
+	// Input is not modified.
+	if diags[0].Detail != "" {
+		t.Errorf("Input diagnostic modified, detail = %q", diags[0].Detail)
+	}
+}
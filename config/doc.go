@@ -16,6 +16,22 @@
 //    memory  = 512             # to resource
 //  }
 //
+// JSON
+//
+// Config files with the extension .hcl.json are written in HCL's JSON syntax,
+// for config generated by other tools. Block labels are object keys, and
+// nested blocks within a resource are written as a list of objects:
+//
+//  {
+//    "resource": {
+//      "func": {
+//        "type": "aws_lambda_function",
+//        "memory": 512,
+//        "environment": [{"variables": {"STAGE": "dev"}}]
+//      }
+//    }
+//  }
+//
 // Source code
 //
 // If a resource specifies a source attribute, the source files from the
@@ -38,6 +54,10 @@
 // Source() can be used to get a pointer to the source archive when source code
 // is needed.
 //
+// The Loader keeps the archives until it is closed. If the same loader loads
+// the config again and the contents of a source directory have not changed,
+// the existing archive is reused instead of compressing the files again.
+//
 // Except for the source, the entire body of a resource is specific to the
 // resource type, set by the first label.
 package config
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"bytes"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hclwrite"
+)
+
+// A FormattedFile is a config file that is not formatted canonically.
+type FormattedFile struct {
+	// Filename is the path to the file, as passed to Load.
+	Filename string
+
+	// Formatted contains the canonically formatted content of the file.
+	Formatted []byte
+}
+
+// Format formats the config files in root canonically: nested blocks are
+// indented consistently and the equals signs of consecutive attributes are
+// aligned. The files Load would load are formatted. Comments and the
+// structure of the files are preserved, only whitespace is changed.
+//
+// Files that are already formatted canonically are not returned. The files
+// are not modified, it is up to the caller to write the formatted content.
+//
+// A file that cannot be parsed is not formatted and produces diagnostics.
+func (l *Loader) Format(root string) ([]FormattedFile, hcl.Diagnostics) {
+	var out []FormattedFile
+	var diags hcl.Diagnostics
+	err := l.walk(root, func(path string, _ fs.FileInfo) error {
+		if !isConfigFile(path) || isJSONFile(path) {
+			// JSON files are not formatted.
+			return nil
+		}
+
+		f, morediags := l.loadFile(path)
+		if morediags.HasErrors() {
+			diags = append(diags, morediags...)
+			return nil
+		}
+
+		formatted := hclwrite.Format(f.bytes)
+		if !bytes.Equal(formatted, f.bytes) {
+			out = append(out, FormattedFile{
+				Filename:  path,
+				Formatted: formatted,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, append(diags, diagErr(err)...)
+	}
+	return out, diags
+}
@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/func/func/config"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoader_Format(t *testing.T) {
+	messy := `# The function
+resource "func" {
+type="aws_lambda_function"
+    source =   "./src"
+  handler  = "index.handler"
+
+        environment {
+  variables = {
+  FOO = "bar"
+  }
+    }
+}
+`
+	formatted := `# The function
+resource "func" {
+  type    = "aws_lambda_function"
+  source  = "./src"
+  handler = "index.handler"
+
+  environment {
+    variables = {
+      FOO = "bar"
+    }
+  }
+}
+`
+
+	tests := []struct {
+		name string
+		src  string
+		want []config.FormattedFile
+	}{
+		{
+			name: "Messy",
+			src:  messy,
+			want: []config.FormattedFile{
+				{Filename: "project/func.hcl", Formatted: []byte(formatted)},
+			},
+		},
+		{
+			name: "Formatted",
+			src:  formatted,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"project/func.hcl":      {Data: []byte(tt.src)},
+				"project/src/index.js":  {Data: []byte("exports.handler = () => {}")},
+				"project/src/notes.txt": {Data: []byte("not=formatted")},
+			}
+			l := &config.Loader{FS: fsys}
+			got, diags := l.Format("project")
+			if diags.HasErrors() {
+				t.Fatalf("Format() diagnostics = %v", diags)
+			}
+			bytesAsString := cmp.Transformer("string", func(b []byte) string { return string(b) })
+			if diff := cmp.Diff(got, tt.want, bytesAsString); diff != "" {
+				t.Errorf("Format() (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoader_Format_syntaxError(t *testing.T) {
+	fsys := fstest.MapFS{
+		"project/func.hcl": {Data: []byte(`resource "func" {`)},
+	}
+	l := &config.Loader{FS: fsys}
+	got, diags := l.Format("project")
+	if !diags.HasErrors() {
+		t.Fatalf("Format() did not return errors")
+	}
+	if len(got) > 0 {
+		t.Errorf("Format() returned %d files, want none", len(got))
+	}
+}
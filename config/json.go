@@ -0,0 +1,462 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl2/gohcl"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	hcljson "github.com/hashicorp/hcl2/hcl/json"
+	"github.com/hashicorp/hcl2/hclpack"
+)
+
+// packJSONFile packs a config file written in HCL's JSON syntax into a body.
+//
+// hclpack only packs native syntax, and JSON does not distinguish blocks from
+// attributes without a schema. The structure of the file is instead decided
+// as follows:
+//
+// Top level blocks are written as in HCL's JSON syntax, where the labels are
+// object keys, for example {"resource": {"lambda": {"type": "..."}}}.
+//
+// Within a resource, the lifecycle property is a block. Any other property
+// that is a list of objects is decoded as blocks, one per object. This means
+// a nested block must be written as a list, even if only one is allowed. All
+// other properties are attributes.
+//
+// Attribute values have the same meaning as in HCL's JSON syntax: strings,
+// including object keys, are templates. A string is packed as a template of
+// its decoded value. Other values are packed as literal JSON, unless they
+// contain a template, in which case they are packed as an equivalent native
+// expression. Ranges in diagnostics point to the JSON source.
+func packJSONFile(src []byte, filename string) (*hclpack.Body, hcl.Diagnostics) {
+	// Parse with hcljson for syntax errors, as it produces better
+	// diagnostics. After this, the source is known to be valid JSON.
+	if _, diags := hcljson.Parse(src, filename); diags.HasErrors() {
+		return nil, diags
+	}
+
+	p := &jsonPacker{src: src, filename: filename}
+	for i, c := range src {
+		if c == '\n' {
+			p.lines = append(p.lines, i+1)
+		}
+	}
+	scan := &jsonScanner{src: src}
+	root := scan.value()
+	if root.kind != '{' {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Incorrect JSON value type",
+			Detail:   "The root of a config file must be an object.",
+			Subject:  p.rng(root.start, root.end).Ptr(),
+		}}
+	}
+	body := p.body(root, rootSchema, false)
+	if p.diags.HasErrors() {
+		return nil, p.diags
+	}
+	return body, p.diags
+}
+
+var (
+	rootSchema, _      = gohcl.ImpliedBodySchema(Root{})
+	moduleSchema, _    = gohcl.ImpliedBodySchema(Module{})
+	resourceSchema, _  = gohcl.ImpliedBodySchema(Resource{})
+	lifecycleSchema, _ = gohcl.ImpliedBodySchema(Lifecycle{})
+)
+
+// jsonChildSchema returns the schema for the body of a block of the given
+// type. If remain is set, the body contains resource config, where lists of
+// objects are blocks.
+func jsonChildSchema(typ string) (schema *hcl.BodySchema, remain bool) {
+	switch typ {
+	case "module":
+		return moduleSchema, false
+	case "resource":
+		return resourceSchema, true
+	case "lifecycle":
+		return lifecycleSchema, false
+	default:
+		return nil, false
+	}
+}
+
+type jsonPacker struct {
+	src      []byte
+	filename string
+	lines    []int // Offsets where lines after the first one start.
+	diags    hcl.Diagnostics
+}
+
+func (p *jsonPacker) body(obj *jsonValue, schema *hcl.BodySchema, remain bool) *hclpack.Body {
+	body := &hclpack.Body{
+		// The closing brace, where HCL would add a missing item.
+		MissingItemRange_: p.rng(obj.end-1, obj.end-1),
+	}
+	for _, prop := range obj.props {
+		if prop.name == "//" {
+			// Comment
+			continue
+		}
+		if header, ok := blockHeader(schema, prop.name); ok {
+			childSchema, childRemain := jsonChildSchema(prop.name)
+			typeRange := p.rng(prop.nameStart, prop.nameEnd)
+			blocks := p.blocks(header, typeRange, prop.value, nil, nil, childSchema, childRemain || remain)
+			body.ChildBlocks = append(body.ChildBlocks, blocks...)
+			continue
+		}
+		if remain && prop.value.isObjectList() {
+			typeRange := p.rng(prop.nameStart, prop.nameEnd)
+			header := hcl.BlockHeaderSchema{Type: prop.name}
+			blocks := p.blocks(header, typeRange, prop.value, nil, nil, nil, true)
+			body.ChildBlocks = append(body.ChildBlocks, blocks...)
+			continue
+		}
+		if body.Attributes == nil {
+			body.Attributes = make(map[string]hclpack.Attribute)
+		}
+		body.Attributes[prop.name] = p.attribute(prop)
+	}
+	return body
+}
+
+// blocks returns the blocks declared by v. The labels are read from nested
+// object keys, until all labels in the header are set. A list creates a block
+// for every element.
+func (p *jsonPacker) blocks(header hcl.BlockHeaderSchema, typeRange hcl.Range, v *jsonValue, labels []string, labelRanges []hcl.Range, schema *hcl.BodySchema, remain bool) []hclpack.Block { // nolint: lll
+	if v.kind == '[' {
+		var out []hclpack.Block
+		for _, el := range v.elems {
+			out = append(out, p.blocks(header, typeRange, el, labels, labelRanges, schema, remain)...)
+		}
+		return out
+	}
+	if v.kind != '{' {
+		p.diags = append(p.diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Incorrect JSON value type",
+			Detail:   fmt.Sprintf("A JSON object is required here, to define a %s block.", header.Type),
+			Subject:  p.rng(v.start, v.end).Ptr(),
+		})
+		return nil
+	}
+	if len(labels) < len(header.LabelNames) {
+		var out []hclpack.Block
+		for _, prop := range v.props {
+			labels := append(labels[:len(labels):len(labels)], prop.name)
+			labelRanges := append(labelRanges[:len(labelRanges):len(labelRanges)], p.rng(prop.nameStart, prop.nameEnd))
+			out = append(out, p.blocks(header, typeRange, prop.value, labels, labelRanges, schema, remain)...)
+		}
+		return out
+	}
+
+	defRange := typeRange
+	if len(labelRanges) > 0 {
+		defRange = hcl.RangeBetween(typeRange, labelRanges[len(labelRanges)-1])
+	}
+	return []hclpack.Block{{
+		Type:        header.Type,
+		Labels:      labels,
+		Body:        *p.body(v, schema, remain),
+		DefRange:    defRange,
+		TypeRange:   typeRange,
+		LabelRanges: labelRanges,
+	}}
+}
+
+func (p *jsonPacker) attribute(prop jsonProp) hclpack.Attribute {
+	v := prop.value
+	startRange := p.rng(v.start, v.end)
+	if v.kind != 0 {
+		// Opening brace or bracket.
+		startRange = p.rng(v.start, v.start+1)
+	}
+	expr := hclpack.Expression{
+		Range_:      p.rng(v.start, v.end),
+		StartRange_: startRange,
+	}
+	switch {
+	case v.kind == 0 && p.src[v.start] == '"':
+		expr.Source = []byte(p.str(v))
+		expr.SourceType = hclpack.ExprTemplate
+	case !p.hasTemplate(v):
+		expr.Source = append([]byte(nil), p.src[v.start:v.end]...)
+		expr.SourceType = hclpack.ExprLiteralJSON
+	default:
+		var b strings.Builder
+		p.native(&b, v)
+		expr.Source = []byte(b.String())
+		expr.SourceType = hclpack.ExprNative
+	}
+	return hclpack.Attribute{
+		Expr:      expr,
+		Range:     p.rng(prop.nameStart, v.end),
+		NameRange: p.rng(prop.nameStart, prop.nameEnd),
+	}
+}
+
+// str returns the decoded value of a JSON string.
+func (p *jsonPacker) str(v *jsonValue) string {
+	var s string
+	if err := json.Unmarshal(p.src[v.start:v.end], &s); err != nil {
+		// Not possible, the source is valid.
+		panic(err)
+	}
+	return s
+}
+
+// hasTemplate returns true if v contains a string with a template sequence.
+// Without one, evaluating a string as a template returns the string as is.
+func (p *jsonPacker) hasTemplate(v *jsonValue) bool {
+	isTemplate := func(s string) bool {
+		return strings.Contains(s, "${") || strings.Contains(s, "%{")
+	}
+	switch v.kind {
+	case '{':
+		for _, prop := range v.props {
+			if isTemplate(prop.name) || p.hasTemplate(prop.value) {
+				return true
+			}
+		}
+	case '[':
+		for _, el := range v.elems {
+			if p.hasTemplate(el) {
+				return true
+			}
+		}
+	default:
+		return p.src[v.start] == '"' && isTemplate(p.str(v))
+	}
+	return false
+}
+
+// native writes v as a native expression. Strings and object keys are
+// written as quoted templates of their decoded values.
+func (p *jsonPacker) native(b *strings.Builder, v *jsonValue) {
+	switch v.kind {
+	case '{':
+		b.WriteByte('{')
+		for i, prop := range v.props {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(quoteTemplate(prop.name))
+			b.WriteString(" = ")
+			p.native(b, prop.value)
+		}
+		b.WriteByte('}')
+	case '[':
+		b.WriteByte('[')
+		for i, el := range v.elems {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			p.native(b, el)
+		}
+		b.WriteByte(']')
+	default:
+		if p.src[v.start] == '"' {
+			b.WriteString(quoteTemplate(p.str(v)))
+			return
+		}
+		// Numbers, true, false and null are the same in both syntaxes.
+		b.Write(p.src[v.start:v.end])
+	}
+}
+
+// quoteTemplate returns a template as a quoted template in native syntax. The
+// literal text is escaped, template sequences are kept as is.
+func quoteTemplate(tmpl string) string {
+	toks, _ := hclsyntax.LexTemplate([]byte(tmpl), "", hcl.Pos{Line: 1, Column: 1})
+	var b strings.Builder
+	b.WriteByte('"')
+	depth, last := 0, 0
+	for _, tok := range toks {
+		switch tok.Type {
+		case hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+			depth++
+		case hclsyntax.TokenTemplateSeqEnd:
+			depth--
+		case hclsyntax.TokenStringLit:
+			if depth == 0 {
+				b.WriteString(tmpl[last:tok.Range.Start.Byte])
+				escapeLiteral(&b, string(tok.Bytes))
+				last = tok.Range.End.Byte
+				continue
+			}
+		}
+		// Tokens in template sequences are written with the whitespace
+		// before them.
+		b.WriteString(tmpl[last:tok.Range.End.Byte])
+		last = tok.Range.End.Byte
+	}
+	b.WriteString(tmpl[last:])
+	b.WriteByte('"')
+	return b.String()
+}
+
+// escapeLiteral writes literal template text escaped for a quoted template.
+// Template escapes ($${ and %%{) are the same in both, so they are kept.
+func escapeLiteral(b *strings.Builder, s string) {
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, "\\u%04x", r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+}
+
+// rng returns the range between the given byte offsets.
+func (p *jsonPacker) rng(start, end int) hcl.Range {
+	return hcl.Range{
+		Filename: p.filename,
+		Start:    p.pos(start),
+		End:      p.pos(end),
+	}
+}
+
+func (p *jsonPacker) pos(offset int) hcl.Pos {
+	line := sort.Search(len(p.lines), func(i int) bool { return p.lines[i] > offset })
+	lineStart := 0
+	if line > 0 {
+		lineStart = p.lines[line-1]
+	}
+	return hcl.Pos{
+		Line:   line + 1,
+		Column: utf8.RuneCount(p.src[lineStart:offset]) + 1,
+		Byte:   offset,
+	}
+}
+
+func blockHeader(schema *hcl.BodySchema, name string) (hcl.BlockHeaderSchema, bool) {
+	if schema == nil {
+		return hcl.BlockHeaderSchema{}, false
+	}
+	for _, b := range schema.Blocks {
+		if b.Type == name {
+			return b, true
+		}
+	}
+	return hcl.BlockHeaderSchema{}, false
+}
+
+// A jsonValue is a value in a JSON document, with byte offsets to its
+// source.
+type jsonValue struct {
+	start, end int
+	kind       byte         // '{' for objects, '[' for lists, 0 for other values.
+	props      []jsonProp   // Object properties, in source order.
+	elems      []*jsonValue // List elements.
+}
+
+func (v *jsonValue) isObjectList() bool {
+	if v.kind != '[' || len(v.elems) == 0 {
+		return false
+	}
+	for _, el := range v.elems {
+		if el.kind != '{' {
+			return false
+		}
+	}
+	return true
+}
+
+type jsonProp struct {
+	name               string
+	nameStart, nameEnd int
+	value              *jsonValue
+}
+
+// jsonScanner reads values with their offsets from JSON source. The source
+// must be valid JSON.
+type jsonScanner struct {
+	src []byte
+	pos int
+}
+
+// skip skips whitespace and separators. The separators do not need to be
+// checked, as the source is valid.
+func (s *jsonScanner) skip() {
+	for s.pos < len(s.src) {
+		switch s.src[s.pos] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (s *jsonScanner) value() *jsonValue {
+	s.skip()
+	v := &jsonValue{start: s.pos}
+	switch c := s.src[s.pos]; c {
+	case '{':
+		v.kind = c
+		s.pos++
+		for s.skip(); s.src[s.pos] != '}'; s.skip() {
+			prop := jsonProp{nameStart: s.pos}
+			s.str()
+			prop.nameEnd = s.pos
+			if err := json.Unmarshal(s.src[prop.nameStart:prop.nameEnd], &prop.name); err != nil {
+				// Not possible, the source is valid.
+				panic(err)
+			}
+			prop.value = s.value()
+			v.props = append(v.props, prop)
+		}
+		s.pos++
+	case '[':
+		v.kind = c
+		s.pos++
+		for s.skip(); s.src[s.pos] != ']'; s.skip() {
+			v.elems = append(v.elems, s.value())
+		}
+		s.pos++
+	case '"':
+		s.str()
+	default:
+		// Number, true, false or null.
+		for s.pos < len(s.src) {
+			switch s.src[s.pos] {
+			case ' ', '\t', '\r', '\n', ',', ']', '}':
+				v.end = s.pos
+				return v
+			}
+			s.pos++
+		}
+	}
+	v.end = s.pos
+	return v
+}
+
+// str reads a string, including the quotes.
+func (s *jsonScanner) str() {
+	s.pos++
+	for s.src[s.pos] != '"' {
+		if s.src[s.pos] == '\\' {
+			s.pos++
+		}
+		s.pos++
+	}
+	s.pos++
+}
@@ -1,21 +1,24 @@
 package config
 
 import (
-	"bytes"
 	"crypto/md5"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/hashicorp/hcl2/gohcl"
 	"github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/hcl2/hclpack"
 	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
 	"golang.org/x/crypto/ssh/terminal"
 )
 
@@ -23,6 +26,7 @@ type file struct {
 	name  string
 	bytes []byte
 	body  *hclpack.Body
+	sum   [sha256.Size]byte // Digest of bytes.
 }
 
 func (f *file) empty() bool {
@@ -37,17 +41,37 @@ type SourceCompressor interface {
 	Compress(w io.Writer, dir string) error
 }
 
-// A Loader loads configuration files from .hcl files on disk.
+// An FSCompressor compresses source files in a file system. When the Loader
+// reads files from a file system, the Compressor must implement FSCompressor.
+type FSCompressor interface {
+	// CompressFS compresses the given directory in fsys into w.
+	CompressFS(w io.Writer, fsys fs.FS, dir string) error
+}
+
+// A Loader loads configuration files from .hcl files on disk. Files with the
+// extension .hcl.json are loaded using HCL's JSON syntax.
 //
 // If the Compressor is not set, the source files are not compressed and the
 // source attribute is only removed from the output.
 //
+// Parsed files and source archives are cached for the lifetime of the
+// loader. When loading again, files with unchanged contents are not parsed
+// again and source directories with unchanged contents are not compressed
+// again.
+//
 // The zero value is ready to load files.
 type Loader struct {
 	Compressor SourceCompressor
 
+	// FS sets the file system to load files from. If set, paths passed to the
+	// loader are slash-separated paths within FS. If not set, files are
+	// loaded from the local disk.
+	FS fs.FS
+
 	files   map[string]*file
-	sources map[string]*bytes.Buffer
+	sources map[string]*SourceArchive
+	temp    []*os.File             // Temporary files backing sources.
+	dirs    map[string]*SourceInfo // Source info by source directory digest.
 }
 
 // WriteDiagnostics writes diagnostics as a human readable string to w. It
@@ -83,16 +107,10 @@ func (l *Loader) WriteDiagnostics(w io.Writer, diags hcl.Diagnostics) {
 // source files from resource are collected and processed as described in the
 // package documentation.
 //
-// If an empty .hcl file is encountered, it is not added.
+// If an empty config file is encountered, it is not added.
 func (l *Loader) Load(root string) (*hclpack.Body, hcl.Diagnostics) {
 	var bodies []*hclpack.Body
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return errors.WithStack(err)
-		}
-		if info.IsDir() {
-			return nil
-		}
+	err := l.walk(root, func(path string, _ fs.FileInfo) error {
 		if !isConfigFile(path) {
 			return nil
 		}
@@ -106,17 +124,22 @@ func (l *Loader) Load(root string) (*hclpack.Body, hcl.Diagnostics) {
 			return nil
 		}
 
+		// Process a copy of the body; the parsed body is cached and may be
+		// loaded again.
+		body := *f.body
+		body.ChildBlocks = make([]hclpack.Block, len(f.body.ChildBlocks))
 		for i, b := range f.body.ChildBlocks {
 			if b.Type == "resource" {
 				block, diags := l.processResource(b, path)
 				if diags.HasErrors() {
 					return diags
 				}
-				f.body.ChildBlocks[i] = block
+				b = block
 			}
+			body.ChildBlocks[i] = b
 		}
 
-		bodies = append(bodies, f.body)
+		bodies = append(bodies, &body)
 		return nil
 	})
 	if err != nil {
@@ -128,39 +151,189 @@ func (l *Loader) Load(root string) (*hclpack.Body, hcl.Diagnostics) {
 	return mergeBodies(bodies), nil
 }
 
+// LoadVars loads variable values from a file. The file must only contain
+// attributes, where the attribute name is the name of the variable:
+//
+//   region = "us-east-1"
+//   memory = 512
+//
+// The values must be static, references are not allowed.
+func (l *Loader) LoadVars(filename string) (map[string]cty.Value, hcl.Diagnostics) {
+	f, diags := l.loadFile(filename)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	_, diags = f.body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	// The expressions are read from the packed attributes, as the attributes
+	// returned from JustAttributes all share the same expression.
+	vars := make(map[string]cty.Value, len(f.body.Attributes))
+	for name, attr := range f.body.Attributes {
+		expr := attr.Expr
+		v, morediags := expr.Value(nil)
+		diags = append(diags, morediags...)
+		if morediags.HasErrors() {
+			continue
+		}
+		vars[name] = v
+	}
+	return vars, diags
+}
+
 // Source returns the compressed source for a given digest.
 //
 // The digests are encoded into the body returned from Load. When source files
 // are needed for a given digest, the list of files can be returned with
 // Source().
 //
-// The result is only valid if Load() has been executed without error.
-func (l *Loader) Source(sha256 string) *bytes.Buffer {
+// The result is only valid if Load() has been executed without error. The
+// archive can be read until the loader is closed.
+func (l *Loader) Source(sha256 string) *SourceArchive {
 	return l.sources[sha256]
 }
 
+// Close removes temporary files created for source archives. Sources returned
+// from Source must not be used after the loader has been closed.
+func (l *Loader) Close() error {
+	var errs []string
+	for _, f := range l.temp {
+		if err := f.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if err := os.Remove(f.Name()); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	l.temp = nil
+	l.sources = nil
+	l.dirs = nil
+	if len(errs) > 0 {
+		return errors.Errorf("remove temporary files: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
 func isConfigFile(filename string) bool {
-	return filepath.Ext(filename) == ".hcl"
+	return filepath.Ext(filename) == ".hcl" || isJSONFile(filename)
+}
+
+// isJSONFile returns true if the file is a config file in HCL's JSON syntax.
+// The extension is .hcl.json, so other JSON files, such as in source
+// directories, are not loaded.
+func isJSONFile(filename string) bool {
+	return strings.HasSuffix(filename, ".hcl.json")
+}
+
+// walk calls fn for every file in root, traversing into sub directories.
+func (l *Loader) walk(root string, fn func(path string, info fs.FileInfo) error) error {
+	if l.FS != nil {
+		return fs.WalkDir(l.FS, root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			return fn(path, info)
+		})
+	}
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if info.IsDir() {
+			return nil
+		}
+		return fn(path, info)
+	})
+}
+
+// readFile reads a file from the file system, or from disk if a file system
+// was not set.
+func (l *Loader) readFile(filename string) ([]byte, error) {
+	if l.FS != nil {
+		return fs.ReadFile(l.FS, filename)
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// compress compresses the source directory into w.
+func (l *Loader) compress(w io.Writer, dir string) error {
+	if l.FS == nil {
+		return l.Compressor.Compress(w, dir)
+	}
+	c, ok := l.Compressor.(FSCompressor)
+	if !ok {
+		return errors.New("compressor does not support file systems")
+	}
+	return c.CompressFS(w, l.FS, dir)
+}
+
+// sourceDir returns the source directory for a resource in filename.
+func (l *Loader) sourceDir(filename, src string) string {
+	if l.FS != nil {
+		return path.Join(path.Dir(filename), src)
+	}
+	return filepath.Join(filepath.Dir(filename), src)
+}
+
+// hashDir computes a digest of the contents of a source directory. The digest
+// covers the relative path, the mode and the contents of every file in the
+// directory.
+func (l *Loader) hashDir(dir string) (string, error) {
+	h := sha256.New()
+	err := l.walk(dir, func(path string, info fs.FileInfo) error {
+		rel := strings.TrimPrefix(filepath.ToSlash(path), filepath.ToSlash(dir))
+		b, err := l.readFile(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		sum := sha256.Sum256(b)
+		fmt.Fprintf(h, "%s\x00%s\x00%x\x00", rel, info.Mode(), sum)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 func (l *Loader) loadFile(filename string) (*file, hcl.Diagnostics) {
 	if l.files == nil {
 		l.files = make(map[string]*file)
 	}
-	if f, ok := l.files[filename]; ok {
-		return f, nil
-	}
 
-	src, err := ioutil.ReadFile(filename)
+	src, err := l.readFile(filename)
 	if err != nil {
 		return nil, diagErr(err)
 	}
 
+	sum := sha256.Sum256(src)
+	if f, ok := l.files[filename]; ok && f.body != nil && f.sum == sum {
+		// Not changed since previously loaded.
+		return f, nil
+	}
+
 	// Add placeholder file, so diagnostics can match the source if packing the
 	// file fails.
 	l.files[filename] = &file{bytes: src}
 
-	body, diags := hclpack.PackNativeFile(src, filename, hcl.Pos{Line: 1, Column: 1})
+	pack := func(src []byte, filename string) (*hclpack.Body, hcl.Diagnostics) {
+		return hclpack.PackNativeFile(src, filename, hcl.Pos{Line: 1, Column: 1})
+	}
+	if isJSONFile(filename) {
+		pack = packJSONFile
+	}
+
+	body, diags := pack(src, filename)
 	if diags.HasErrors() {
 		return nil, diags
 	}
@@ -169,6 +342,7 @@ func (l *Loader) loadFile(filename string) (*file, hcl.Diagnostics) {
 		name:  filename,
 		bytes: src,
 		body:  body,
+		sum:   sum,
 	}
 	l.files[filename] = f
 
@@ -183,19 +357,47 @@ func (l *Loader) processResource(block hclpack.Block, filename string) (hclpack.
 			return hclpack.Block{}, diags
 		}
 
-		// Delete source attribute; no longer needed.
-		delete(block.Body.Attributes, "source")
+		// Copy attributes without source attribute; it is no longer needed.
+		// The original attributes belong to the cached file and are not
+		// modified.
+		attrs := make(map[string]hclpack.Attribute, len(block.Body.Attributes))
+		for name, attr := range block.Body.Attributes {
+			if name != "source" {
+				attrs[name] = attr
+			}
+		}
+		block.Body.Attributes = attrs
 
-		dir := filepath.Dir(filename)
-		dir = filepath.Join(dir, src)
+		if l.Compressor == nil {
+			// Source files are not collected.
+			return block, nil
+		}
+
+		dir := l.sourceDir(filename, src)
+
+		dirSum, err := l.hashDir(dir)
+		if err != nil {
+			return hclpack.Block{}, hcl.Diagnostics{{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Could not read source files: %v", err),
+				Subject:  srcAttr.Expr.StartRange().Ptr(),
+				Context:  srcAttr.Expr.Range().Ptr(),
+			}}
+		}
+		if info, ok := l.dirs[dirSum]; ok {
+			// Source files have not changed, reuse existing archive.
+			block.Body.Attributes["source"] = sourceAttr(srcAttr, info)
+			return block, nil
+		}
 
-		var buf bytes.Buffer
 		sha := sha256.New()
 		md5 := md5.New()
 
-		w := io.MultiWriter(&buf, sha, md5)
-
-		if err := l.Compressor.Compress(w, dir); err != nil {
+		// Spool the archive to disk, large archives are not kept in memory.
+		f, archive, err := spool(func(w io.Writer) error {
+			return l.compress(io.MultiWriter(w, sha, md5), dir)
+		})
+		if err != nil {
 			return hclpack.Block{}, hcl.Diagnostics{{
 				Severity: hcl.DiagError,
 				Summary:  fmt.Sprintf("Could not create source archive: %v", err),
@@ -207,27 +409,39 @@ func (l *Loader) processResource(block hclpack.Block, filename string) (hclpack.
 		key := hex.EncodeToString(sha.Sum(nil))
 
 		if l.sources == nil {
-			l.sources = make(map[string]*bytes.Buffer)
+			l.sources = make(map[string]*SourceArchive)
 		}
-		l.sources[key] = &buf
+		l.temp = append(l.temp, f)
+		l.sources[key] = archive
 
-		srcInfo := SourceInfo{
-			Len: buf.Len(),
+		srcInfo := &SourceInfo{
+			Len: int(archive.Len()),
 			MD5: base64.StdEncoding.EncodeToString(md5.Sum(nil)),
 			Key: key,
 		}
 
-		srcAttr.Expr = hclpack.Expression{
-			Source:      []byte(`"` + srcInfo.EncodeToString() + `"`),
-			SourceType:  hclpack.ExprLiteralJSON,
-			Range_:      srcAttr.Expr.Range_,
-			StartRange_: srcAttr.Expr.StartRange_,
+		if l.dirs == nil {
+			l.dirs = make(map[string]*SourceInfo)
 		}
-		block.Body.Attributes["source"] = srcAttr
+		l.dirs[dirSum] = srcInfo
+
+		block.Body.Attributes["source"] = sourceAttr(srcAttr, srcInfo)
 	}
 	return block, nil
 }
 
+// sourceAttr returns a copy of the source attribute with the expression
+// replaced with the encoded source info.
+func sourceAttr(attr hclpack.Attribute, info *SourceInfo) hclpack.Attribute {
+	attr.Expr = hclpack.Expression{
+		Source:      []byte(`"` + info.EncodeToString() + `"`),
+		SourceType:  hclpack.ExprLiteralJSON,
+		Range_:      attr.Expr.Range_,
+		StartRange_: attr.Expr.StartRange_,
+	}
+	return attr
+}
+
 // mergeBodies merges the contents of the given bodies.
 //
 // It behaves in a similar way to hcl.MergeBodies, except the *hclpack.Body
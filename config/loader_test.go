@@ -9,15 +9,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/func/func/config"
 	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/hcl2/gohcl"
 	"github.com/hashicorp/hcl2/hcl"
+	hcljson "github.com/hashicorp/hcl2/hcl/json"
 	"github.com/hashicorp/hcl2/hclpack"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestLoader_Load(t *testing.T) {
@@ -202,6 +209,341 @@ func TestLoader_Load(t *testing.T) {
 	}
 }
 
+func TestLoader_Load_fs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"project/func.hcl": {Data: []byte(`
+			resource "lambda" {
+				type   = "aws_lambda_function"
+				source = "./src"
+			}
+		`)},
+		"project/src/index.js":     {Data: []byte("exports.handler = () => {}")},
+		"project/src/lib/util.js":  {Data: []byte("module.exports = {}")},
+		"project/other/ignored.js": {Data: []byte("")},
+	}
+
+	l := &config.Loader{
+		FS:         fsys,
+		Compressor: &fsCompressor{},
+	}
+	body, diags := l.Load("project")
+	if diags.HasErrors() {
+		t.Fatalf("Load() diagnostics = %v", diags)
+	}
+
+	if len(body.ChildBlocks) != 1 {
+		t.Fatalf("Got %d blocks, want 1", len(body.ChildBlocks))
+	}
+	attr := body.ChildBlocks[0].Body.Attributes["source"]
+	var str string
+	if diags := gohcl.DecodeExpression(&attr.Expr, nil, &str); diags.HasErrors() {
+		t.Fatalf("Decode source: %v", diags)
+	}
+	info, err := config.DecodeSourceString(str)
+	if err != nil {
+		t.Fatalf("DecodeSourceString() error = %v", err)
+	}
+
+	b, err := l.Source(info.Key).Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+	got := string(b)
+	want := "index.js\nlib/util.js"
+	if got != want {
+		t.Errorf("Source = %q, want %q", got, want)
+	}
+}
+
+func TestLoader_Load_json(t *testing.T) {
+	fsys := fstest.MapFS{
+		"project/func.hcl.json": {Data: []byte(`{
+  "resource": {
+    "lambda": {
+      "type": "aws_lambda_function",
+      "tags": { "team": "core" },
+      "environment": [{ "variables": { "A": "a" } }]
+    }
+  }
+}`)},
+		// Other JSON files are not config files.
+		"project/src/package.json": {Data: []byte(`{"name": "lambda"}`)},
+	}
+
+	l := &config.Loader{FS: fsys}
+	body, diags := l.Load("project")
+	if diags.HasErrors() {
+		t.Fatalf("Load() diagnostics = %v", diags)
+	}
+
+	if len(body.ChildBlocks) != 1 {
+		t.Fatalf("Got %d blocks, want 1", len(body.ChildBlocks))
+	}
+	block := body.ChildBlocks[0]
+	if block.Type != "resource" || len(block.Labels) != 1 || block.Labels[0] != "lambda" {
+		t.Errorf("Got block %s %v, want resource [lambda]", block.Type, block.Labels)
+	}
+
+	file := "project/func.hcl.json"
+	wantLabel := hcl.Range{
+		Filename: file,
+		Start:    hcl.Pos{Line: 3, Column: 5, Byte: 22},
+		End:      hcl.Pos{Line: 3, Column: 13, Byte: 30},
+	}
+	if diff := cmp.Diff(block.LabelRanges, []hcl.Range{wantLabel}); diff != "" {
+		t.Errorf("LabelRanges (-got, +want)\n%s", diff)
+	}
+
+	var typ string
+	attr := block.Body.Attributes["type"]
+	if diags := gohcl.DecodeExpression(&attr.Expr, nil, &typ); diags.HasErrors() {
+		t.Fatalf("Decode type: %v", diags)
+	}
+	if typ != "aws_lambda_function" {
+		t.Errorf("Type = %q, want %q", typ, "aws_lambda_function")
+	}
+	wantExpr := hcl.Range{
+		Filename: file,
+		Start:    hcl.Pos{Line: 4, Column: 15, Byte: 48},
+		End:      hcl.Pos{Line: 4, Column: 36, Byte: 69},
+	}
+	if diff := cmp.Diff(attr.Expr.Range(), wantExpr); diff != "" {
+		t.Errorf("Expression range (-got, +want)\n%s", diff)
+	}
+
+	var tags map[string]string
+	attr = block.Body.Attributes["tags"]
+	if diags := gohcl.DecodeExpression(&attr.Expr, nil, &tags); diags.HasErrors() {
+		t.Fatalf("Decode tags: %v", diags)
+	}
+	if diff := cmp.Diff(tags, map[string]string{"team": "core"}); diff != "" {
+		t.Errorf("Tags (-got, +want)\n%s", diff)
+	}
+
+	if len(block.Body.ChildBlocks) != 1 || block.Body.ChildBlocks[0].Type != "environment" {
+		t.Errorf("Got nested blocks %+v, want one environment block", block.Body.ChildBlocks)
+	}
+}
+
+func TestLoader_Load_jsonValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"String", `"hello"`},
+		{"Escapes", `"a\/b \b \f \u00e9 \"q\" \\ \t"`},
+		{"Template", `"${var.x}-\/"`},
+		{"TemplateEscape", `"$${var.x} %%{if}"`},
+		{"TemplateQuotes", `"\"${var.x == \"X\" ? \"a\\\"b\" : \"\"}\""`},
+		{"Number", `1.5`},
+		{"Bool", `true`},
+		{"Null", `null`},
+		{"ListOfObjects", `[{"a": "a\/b \b", "b": 1}, {"a": "c", "b": null}]`},
+		{"ListOfObjectsTemplate", `[{"a": "a\/b \b", "b": 1}, {"a": "${var.x}\n", "b": true}]`},
+		{"ObjectKeyTemplate", `{"${var.x}": ["\/", "${var.x}"], "k": {"n": [1, 2]}}`},
+	}
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{"x": cty.StringVal("X")}),
+		},
+	}
+	schema := &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "variable", LabelNames: []string{"name"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := []byte(fmt.Sprintf(`{"variable": {"v": {"default": %s}}}`, tt.value))
+
+			// The value must be the same as when parsed with hcljson.
+			f, diags := hcljson.Parse(src, "func.hcl.json")
+			if diags.HasErrors() {
+				t.Fatalf("Parse() diagnostics = %v", diags)
+			}
+			cont, diags := f.Body.Content(schema)
+			if diags.HasErrors() {
+				t.Fatalf("Content() diagnostics = %v", diags)
+			}
+			attrs, diags := cont.Blocks[0].Body.JustAttributes()
+			if diags.HasErrors() {
+				t.Fatalf("JustAttributes() diagnostics = %v", diags)
+			}
+			want, diags := attrs["default"].Expr.Value(ctx)
+			if diags.HasErrors() {
+				t.Fatalf("Value() diagnostics = %v", diags)
+			}
+
+			l := &config.Loader{FS: fstest.MapFS{"project/func.hcl.json": {Data: src}}}
+			body, diags := l.Load("project")
+			if diags.HasErrors() {
+				t.Fatalf("Load() diagnostics = %v", diags)
+			}
+			attr := body.ChildBlocks[0].Body.Attributes["default"]
+			got, diags := attr.Expr.Value(ctx)
+			if diags.HasErrors() {
+				t.Fatalf("Value() diagnostics = %v", diags)
+			}
+
+			if !got.RawEquals(want) {
+				t.Errorf("Value = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoader_Load_cache(t *testing.T) {
+	fsys := fstest.MapFS{
+		"project/func.hcl": {Data: []byte(`
+			resource "lambda" {
+				type   = "aws_lambda_function"
+				source = "./src"
+			}
+		`)},
+		"project/src/index.js": {Data: []byte("exports.handler = () => {}")},
+	}
+
+	c := &countingCompressor{}
+	l := &config.Loader{
+		FS:         fsys,
+		Compressor: c,
+	}
+	defer func() {
+		if err := l.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	}()
+
+	load := func() string {
+		t.Helper()
+		body, diags := l.Load("project")
+		if diags.HasErrors() {
+			t.Fatalf("Load() diagnostics = %v", diags)
+		}
+		attr := body.ChildBlocks[0].Body.Attributes["source"]
+		var str string
+		if diags := gohcl.DecodeExpression(&attr.Expr, nil, &str); diags.HasErrors() {
+			t.Fatalf("Decode source: %v", diags)
+		}
+		return str
+	}
+
+	first := load()
+	second := load()
+	if c.calls != 1 {
+		t.Errorf("Compressed %d times with unchanged sources, want 1", c.calls)
+	}
+	if second != first {
+		t.Errorf("Source changed with unchanged sources\nGot  %s\nWant %s", second, first)
+	}
+
+	fsys["project/src/index.js"] = &fstest.MapFile{Data: []byte("exports.handler = () => 'changed'")}
+	third := load()
+	if c.calls != 2 {
+		t.Errorf("Compressed %d times after changing sources, want 2", c.calls)
+	}
+	if third == first {
+		t.Errorf("Source did not change after changing source files")
+	}
+
+	// File modes are stored in the archive, making a file executable changes the
+	// source.
+	fsys["project/src/index.js"] = &fstest.MapFile{Data: []byte("exports.handler = () => 'changed'"), Mode: 0755}
+	fourth := load()
+	if c.calls != 3 {
+		t.Errorf("Compressed %d times after changing file mode, want 3", c.calls)
+	}
+	if fourth == third {
+		t.Errorf("Source did not change after changing file mode")
+	}
+
+	fsys["project/func.hcl"] = &fstest.MapFile{Data: []byte(`
+		resource "renamed" {
+			type   = "aws_lambda_function"
+			source = "./src"
+		}
+	`)}
+	body, diags := l.Load("project")
+	if diags.HasErrors() {
+		t.Fatalf("Load() diagnostics = %v", diags)
+	}
+	if got := body.ChildBlocks[0].Labels[0]; got != "renamed" {
+		t.Errorf("Label = %q after changing config, want %q", got, "renamed")
+	}
+	if c.calls != 3 {
+		t.Errorf("Compressed %d times after changing config only, want 3", c.calls)
+	}
+}
+
+func TestLoader_Source_large(t *testing.T) {
+	const size = 16 << 20
+
+	fsys := fstest.MapFS{
+		"project/func.hcl": {Data: []byte(`
+			resource "lambda" {
+				type   = "aws_lambda_function"
+				source = "./src"
+			}
+		`)},
+		"project/src/index.js": {Data: []byte("exports.handler = () => {}")},
+	}
+
+	l := &config.Loader{
+		FS:         fsys,
+		Compressor: &largeCompressor{size: size},
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	body, diags := l.Load("project")
+	if diags.HasErrors() {
+		t.Fatalf("Load() diagnostics = %v", diags)
+	}
+	defer func() {
+		if err := l.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	}()
+
+	attr := body.ChildBlocks[0].Body.Attributes["source"]
+	var str string
+	if diags := gohcl.DecodeExpression(&attr.Expr, nil, &str); diags.HasErrors() {
+		t.Fatalf("Decode source: %v", diags)
+	}
+	info, err := config.DecodeSourceString(str)
+	if err != nil {
+		t.Fatalf("DecodeSourceString() error = %v", err)
+	}
+
+	src := l.Source(info.Key)
+	if src.Len() != size {
+		t.Errorf("Len() = %d, want %d", src.Len(), size)
+	}
+
+	// Stream the archive to compute its digest.
+	r := src.Reader()
+	sha := sha256.New()
+	if _, err := io.Copy(sha, r); err != nil {
+		t.Fatalf("Read source: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close reader: %v", err)
+	}
+	if got := hex.EncodeToString(sha.Sum(nil)); got != info.Key {
+		t.Errorf("Streamed digest = %s, want %s", got, info.Key)
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	// The archive must not be retained in memory.
+	if after.HeapAlloc > before.HeapAlloc && after.HeapAlloc-before.HeapAlloc > size/2 {
+		t.Errorf("Heap grew by %d bytes for a %d byte archive", after.HeapAlloc-before.HeapAlloc, size)
+	}
+}
+
 func TestLoader_Source(t *testing.T) {
 	tests := []struct {
 		name string
@@ -261,6 +603,24 @@ func TestLoader_Source_notFound(t *testing.T) {
 	}
 }
 
+func TestLoader_LoadVars(t *testing.T) {
+	l := &config.Loader{}
+	got, diags := l.LoadVars("testdata/vars/vars.hcl")
+	if diags.HasErrors() {
+		t.Fatalf("LoadVars() error = %v", diags)
+	}
+	want := map[string]cty.Value{
+		"region": cty.StringVal("us-east-1"),
+		"memory": cty.NumberIntVal(512),
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool { return a.RawEquals(b) }),
+	}
+	if diff := cmp.Diff(got, want, opts...); diff != "" {
+		t.Errorf("LoadVars() (-got +want)\n%s", diff)
+	}
+}
+
 func TestLoader_jsonRoundTrip(t *testing.T) {
 	// This doesn't specifically test against anything in config, it's just to
 	// protect against breaking changes in the hcl library, which is very
@@ -365,6 +725,80 @@ func (m *mockCompressor) Compress(w io.Writer, dir string) error {
 	return nil
 }
 
+// fsCompressor writes the sorted names of the files in the source directory,
+// relative to the directory.
+type fsCompressor struct{}
+
+func (fsCompressor) Compress(w io.Writer, dir string) error {
+	return fmt.Errorf("not supported")
+}
+
+func (fsCompressor) CompressFS(w io.Writer, fsys fs.FS, dir string) error {
+	var names []string
+	err := fs.WalkDir(fsys, dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			names = append(names, strings.TrimPrefix(path, dir+"/"))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	_, err = io.WriteString(w, strings.Join(names, "\n"))
+	return err
+}
+
+// countingCompressor writes the contents of the source files and counts the
+// number of times it was called.
+type countingCompressor struct {
+	calls int
+}
+
+func (c *countingCompressor) Compress(w io.Writer, dir string) error {
+	return fmt.Errorf("not supported")
+}
+
+func (c *countingCompressor) CompressFS(w io.Writer, fsys fs.FS, dir string) error {
+	c.calls++
+	return fs.WalkDir(fsys, dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	})
+}
+
+// largeCompressor writes an archive of the given size in small chunks.
+type largeCompressor struct {
+	size int
+}
+
+func (c *largeCompressor) Compress(w io.Writer, dir string) error {
+	chunk := make([]byte, 32<<10)
+	for n := 0; n < c.size; n += len(chunk) {
+		for i := range chunk {
+			chunk[i] = byte(n + i)
+		}
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *largeCompressor) CompressFS(w io.Writer, fsys fs.FS, dir string) error {
+	return c.Compress(w, dir)
+}
+
 func sourceInfoStr(t *testing.T, b []byte) string {
 	md5 := md5.New()
 	sha := sha256.New()
@@ -13,6 +13,108 @@ import (
 // resources that are part of the project.
 type Root struct {
 	Resources []Resource `hcl:"resource,block"`
+	Data      []Data     `hcl:"data,block"`
+	Modules   []Module   `hcl:"module,block"`
+	Outputs   []Output   `hcl:"output,block"`
+	Providers []Provider `hcl:"provider,block"`
+	Variables []Variable `hcl:"variable,block"`
+	Moved     []Moved    `hcl:"moved,block"`
+}
+
+// A Provider sets default input values for resources of a provider. The
+// defaults apply to resources with a type prefixed with the provider name,
+// for example aws_lambda_function for the aws provider. A value set on the
+// resource overrides the default.
+type Provider struct {
+	// Name is the name of the provider.
+	Name string `hcl:"name,label"`
+
+	// Alias optionally names an additional configuration for the provider.
+	// Resources select it with the provider meta-argument, as in
+	// provider = aws.prod. Resources that do not select a provider use the
+	// configuration without an alias.
+	Alias string `hcl:"alias,optional"`
+
+	// Profile is the name of the credentials profile to use for resources
+	// of the provider. If not set, the default credentials are used.
+	Profile string `hcl:"profile,optional"`
+
+	// Config contains the default input values, such as region.
+	Config hcl.Body `hcl:",remain"`
+}
+
+// A Variable is an input parameter to the configuration. Variables are
+// referred to as var.<name>.
+type Variable struct {
+	// Name is a unique name for the variable.
+	Name string `hcl:"name,label"`
+
+	// Description optionally describes the purpose of the variable.
+	Description string `hcl:"description,optional"`
+
+	// Default is the value to use if a value is not provided for the
+	// variable. If the default is not set, a value must be provided.
+	Default hcl.Expression `hcl:"default,optional"`
+}
+
+// A Data block reads data that is not managed by the project. Data is
+// referred to as data.<type>.<name>.
+type Data struct {
+	// Type is the type of data to read. The only supported type is
+	// remote_state, which reads the outputs of another project.
+	Type string `hcl:"type,label"`
+
+	// Name is a unique name (within the same type) for the data.
+	Name string `hcl:"name,label"`
+
+	// Config contains the arguments for the data. The contents depend on
+	// the type.
+	Config hcl.Body `hcl:",remain"`
+}
+
+// RemoteState is the configuration of a remote_state data block.
+type RemoteState struct {
+	// Project is the name of the project to read outputs from. The
+	// expression may refer to variables.
+	Project hcl.Expression `hcl:"project"`
+}
+
+// An Output is a value that is exposed to the user after resources have been
+// applied.
+type Output struct {
+	// Name is a unique name for the output.
+	Name string `hcl:"name,label"`
+
+	// Value is the expression for the output value. The expression may refer
+	// to resource inputs and outputs, it is resolved after the resources have
+	// been applied.
+	Value hcl.Expression `hcl:"value"`
+
+	// Sensitive marks the value as sensitive. A sensitive value is not
+	// displayed when outputs are listed. An output that refers to a
+	// sensitive resource field must be marked sensitive.
+	Sensitive bool `hcl:"sensitive,optional"`
+}
+
+// A Module groups resources under a shared namespace. Resource names only
+// need to be unique within the module.
+type Module struct {
+	// Name is the name of the module. It is used as a namespace for the
+	// resources declared in the module.
+	Name string `hcl:"name,label"`
+
+	// Resources contains the resources declared in the module.
+	Resources []Resource `hcl:"resource,block"`
+}
+
+// Moved records that a resource has been renamed. The deployed resource is
+// renamed instead of being deleted and created with the new name.
+type Moved struct {
+	// From is a reference to the previous name of the resource.
+	From hcl.Expression `hcl:"from"`
+
+	// To is a reference to the new name of the resource.
+	To hcl.Expression `hcl:"to"`
 }
 
 // Resource is a user specified resource specification.
@@ -25,6 +127,12 @@ type Resource struct {
 	// The type defines how the Config is decoded.
 	Type string `hcl:"type"`
 
+	// Description is an optional description of what the resource is for.
+	// It is shown in the log output when the resource is applied. If the
+	// resource type has a description input, the description is also passed
+	// to the resource.
+	Description hcl.Expression `hcl:"description,optional"`
+
 	// Config is a configuration body for the resource.
 	//
 	// The contents will depend on the resource type.
@@ -33,6 +141,35 @@ type Resource struct {
 	// SourceDigest contains information about the attached source code. The
 	// field is nil if the resource has no source.
 	Source string `hcl:"source,optional"`
+
+	// DependsOn is a list of references to other resources that the resource
+	// depends on, in addition to the resources it refers to in its config.
+	DependsOn hcl.Expression `hcl:"depends_on,optional"`
+
+	// ForEach is a map that creates one instance of the resource per key.
+	// Within the resource, each.key and each.value refer to the key and
+	// value of the current instance.
+	ForEach hcl.Expression `hcl:"for_each,optional"`
+
+	// Provider selects an aliased provider configuration for the resource,
+	// as in aws.prod. If not set, the provider without an alias is used.
+	Provider hcl.Expression `hcl:"provider,optional"`
+
+	// Lifecycle customizes how changes to the resource are handled. The field
+	// is nil if no lifecycle block was set.
+	Lifecycle *Lifecycle `hcl:"lifecycle,block"`
+}
+
+// Lifecycle contains lifecycle settings for a resource.
+type Lifecycle struct {
+	// IgnoreChanges is a list of references to input fields of the resource.
+	// Changes to these fields are ignored when deciding whether the resource
+	// needs to be updated.
+	IgnoreChanges hcl.Expression `hcl:"ignore_changes,optional"`
+
+	// PreventDestroy prevents the resource from being deleted. If the
+	// resource is removed from the configuration, applying fails instead.
+	PreventDestroy bool `hcl:"prevent_destroy,optional"`
 }
 
 // SourceInfo contains information about the resource source code.
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// A SourceArchive is a compressed source archive.
+//
+// The archive is read through an io.ReaderAt, allowing large archives to be
+// streamed without holding the entire archive in memory. Archives created by
+// the Loader are stored in temporary files.
+type SourceArchive struct {
+	r    io.ReaderAt
+	size int64
+}
+
+// NewSourceArchive creates a source archive that reads size bytes from r.
+func NewSourceArchive(r io.ReaderAt, size int64) *SourceArchive {
+	return &SourceArchive{r: r, size: size}
+}
+
+// Len returns the size of the archive in bytes.
+func (a *SourceArchive) Len() int64 {
+	return a.size
+}
+
+// ReadAt implements io.ReaderAt.
+func (a *SourceArchive) ReadAt(p []byte, off int64) (int, error) {
+	return io.NewSectionReader(a.r, 0, a.size).ReadAt(p, off)
+}
+
+// Reader returns a reader for reading the archive from the start. Multiple
+// readers may be used concurrently.
+func (a *SourceArchive) Reader() io.ReadCloser {
+	return ioutil.NopCloser(io.NewSectionReader(a.r, 0, a.size))
+}
+
+// Bytes reads the entire archive into memory. It is a convenience for small
+// archives, use Reader to stream large archives.
+func (a *SourceArchive) Bytes() ([]byte, error) {
+	b := make([]byte, a.size)
+	if _, err := a.ReadAt(b, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return b, nil
+}
+
+// spool writes the archive produced by write to a temporary file.
+func spool(write func(w io.Writer) error) (*os.File, *SourceArchive, error) {
+	f, err := ioutil.TempFile("", "func-source-")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "create temporary file")
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil, nil, errors.Wrap(err, "stat temporary file")
+	}
+	return f, NewSourceArchive(f, info.Size()), nil
+}
@@ -0,0 +1,81 @@
+package ctyext
+
+import (
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Diff returns the paths to all values that differ between a and b.
+//
+// Objects and maps are compared attribute by attribute and lists and tuples
+// element by element, so the returned paths point to the deepest value that
+// changed. If the types do not match, either value is null or unknown, or a
+// list changed length, the path to the value itself is returned instead.
+//
+// The returned paths are sorted by their string representation. If the values
+// are equal, nil is returned.
+func Diff(a, b cty.Value) []cty.Path {
+	paths := diff(a, b, nil)
+	sort.Slice(paths, func(i, j int) bool {
+		return PathString(paths[i]) < PathString(paths[j])
+	})
+	return paths
+}
+
+func diff(a, b cty.Value, path cty.Path) []cty.Path {
+	if a.RawEquals(b) {
+		return nil
+	}
+	ta, tb := a.Type(), b.Type()
+	if a.IsNull() || b.IsNull() || !a.IsKnown() || !b.IsKnown() || !ta.Equals(tb) {
+		return []cty.Path{path}
+	}
+
+	switch {
+	case ta.IsObjectType():
+		var out []cty.Path
+		for name := range ta.AttributeTypes() {
+			out = append(out, diff(a.GetAttr(name), b.GetAttr(name), appendStep(path, cty.GetAttrStep{Name: name}))...)
+		}
+		return out
+	case ta.IsMapType():
+		am, bm := a.AsValueMap(), b.AsValueMap()
+		var out []cty.Path
+		for k, av := range am {
+			step := cty.IndexStep{Key: cty.StringVal(k)}
+			bv, ok := bm[k]
+			if !ok {
+				out = append(out, appendStep(path, step))
+				continue
+			}
+			out = append(out, diff(av, bv, appendStep(path, step))...)
+		}
+		for k := range bm {
+			if _, ok := am[k]; !ok {
+				out = append(out, appendStep(path, cty.IndexStep{Key: cty.StringVal(k)}))
+			}
+		}
+		return out
+	case ta.IsListType(), ta.IsTupleType():
+		if a.LengthInt() != b.LengthInt() {
+			return []cty.Path{path}
+		}
+		var out []cty.Path
+		for i := 0; i < a.LengthInt(); i++ {
+			key := cty.NumberIntVal(int64(i))
+			out = append(out, diff(a.Index(key), b.Index(key), appendStep(path, cty.IndexStep{Key: key}))...)
+		}
+		return out
+	}
+
+	return []cty.Path{path}
+}
+
+// appendStep appends a step to a copy of path. The original path is not
+// modified.
+func appendStep(path cty.Path, step cty.PathStep) cty.Path {
+	out := make(cty.Path, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, step)
+}
@@ -0,0 +1,104 @@
+package ctyext_test
+
+import (
+	"testing"
+
+	"github.com/func/func/ctyext"
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b cty.Value
+		want []string
+	}{
+		{
+			"Equal",
+			cty.ObjectVal(map[string]cty.Value{"a": cty.StringVal("x")}),
+			cty.ObjectVal(map[string]cty.Value{"a": cty.StringVal("x")}),
+			nil,
+		},
+		{
+			"Primitive",
+			cty.StringVal("x"),
+			cty.StringVal("y"),
+			[]string{""},
+		},
+		{
+			"Attributes",
+			cty.ObjectVal(map[string]cty.Value{
+				"a": cty.StringVal("x"),
+				"b": cty.NumberIntVal(1),
+				"c": cty.True,
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"a": cty.StringVal("y"),
+				"b": cty.NumberIntVal(1),
+				"c": cty.False,
+			}),
+			[]string{"a", "c"},
+		},
+		{
+			"Nested",
+			cty.ObjectVal(map[string]cty.Value{
+				"a": cty.ObjectVal(map[string]cty.Value{
+					"b": cty.ListVal([]cty.Value{cty.StringVal("x"), cty.StringVal("y")}),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"a": cty.ObjectVal(map[string]cty.Value{
+					"b": cty.ListVal([]cty.Value{cty.StringVal("x"), cty.StringVal("z")}),
+				}),
+			}),
+			[]string{"a.b[1]"},
+		},
+		{
+			"ListLength",
+			cty.ObjectVal(map[string]cty.Value{
+				"a": cty.ListVal([]cty.Value{cty.StringVal("x")}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"a": cty.ListVal([]cty.Value{cty.StringVal("x"), cty.StringVal("y")}),
+			}),
+			[]string{"a"},
+		},
+		{
+			"MapKeys",
+			cty.MapVal(map[string]cty.Value{
+				"a": cty.StringVal("x"),
+				"b": cty.StringVal("y"),
+			}),
+			cty.MapVal(map[string]cty.Value{
+				"b": cty.StringVal("z"),
+				"c": cty.StringVal("x"),
+			}),
+			[]string{`["a"]`, `["b"]`, `["c"]`},
+		},
+		{
+			"Null",
+			cty.ObjectVal(map[string]cty.Value{"a": cty.NullVal(cty.String)}),
+			cty.ObjectVal(map[string]cty.Value{"a": cty.StringVal("x")}),
+			[]string{"a"},
+		},
+		{
+			"TypeMismatch",
+			cty.ObjectVal(map[string]cty.Value{"a": cty.StringVal("1")}),
+			cty.ObjectVal(map[string]cty.Value{"a": cty.NumberIntVal(1)}),
+			[]string{""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := ctyext.Diff(tt.a, tt.b)
+			var got []string
+			for _, p := range paths {
+				got = append(got, ctyext.PathString(p))
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("Diff() (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
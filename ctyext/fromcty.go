@@ -139,15 +139,7 @@ func setObject(val cty.Value, target reflect.Value, path cty.Path, fieldName Fie
 
 	attrTypes := val.Type().AttributeTypes()
 
-	ty := target.Type()
-	targetFields := make(map[string]int)
-	for i := 0; i < ty.NumField(); i++ {
-		field := ty.Field(i)
-		attrName := fieldName(field)
-		if attrName != "" {
-			targetFields[attrName] = i
-		}
-	}
+	targetFields := structFields(target.Type(), fieldName)
 
 	path = append(path, nil)
 
@@ -161,7 +153,7 @@ func setObject(val cty.Value, target reflect.Value, path cty.Path, fieldName Fie
 
 		ev := val.GetAttr(k)
 
-		targetField := target.Field(fieldIdx)
+		targetField := target.FieldByIndex(fieldIdx)
 		if err := fromCtyValue(ev, targetField, path, fieldName); err != nil {
 			return err
 		}
@@ -170,6 +162,35 @@ func setObject(val cty.Value, target reflect.Value, path cty.Path, fieldName Fie
 	return nil
 }
 
+// structFields returns the index sequence of each field in a struct type,
+// keyed by the attribute name returned from fieldName. The fields of exported
+// embedded structs are promoted, unless a field with the same name is declared
+// in the outer struct.
+func structFields(ty reflect.Type, fieldName FieldNameFunc) map[string][]int {
+	fields := make(map[string][]int)
+	var embedded []reflect.StructField
+	for i := 0; i < ty.NumField(); i++ {
+		field := ty.Field(i)
+		if field.Anonymous && field.PkgPath == "" && field.Type.Kind() == reflect.Struct {
+			embedded = append(embedded, field)
+			continue
+		}
+		attrName := fieldName(field)
+		if attrName != "" {
+			fields[attrName] = field.Index
+		}
+	}
+	for _, e := range embedded {
+		for name, index := range structFields(e.Type, fieldName) {
+			if _, ok := fields[name]; ok {
+				continue
+			}
+			fields[name] = append([]int{e.Index[0]}, index...)
+		}
+	}
+	return fields
+}
+
 func setTuple(val cty.Value, target reflect.Value, path cty.Path, fieldName FieldNameFunc) error {
 	if target.Kind() != reflect.Struct {
 		return PathError{Path: path, Err: fmt.Errorf("target is %s, not struct", target.Kind())}
@@ -179,7 +200,7 @@ func setTuple(val cty.Value, target reflect.Value, path cty.Path, fieldName Fiel
 	fieldCount := target.Type().NumField()
 
 	if fieldCount != len(elemTypes) {
-		return PathError{Path: path, Err: fmt.Errorf("a tuple of %d elements is required", fieldCount)}
+		return PathError{Path: path, Err: fmt.Errorf("a tuple with %s is required", elements(fieldCount))}
 	}
 
 	path = append(path, nil)
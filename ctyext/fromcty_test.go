@@ -314,6 +314,20 @@ func TestFromCtyValue(t *testing.T) {
 				Number: intptr(12),
 			},
 		},
+		{
+			val: cty.ObjectVal(map[string]cty.Value{
+				"name": cty.StringVal("Stephen"),
+				"city": cty.StringVal("New York"),
+			}),
+			target: reflect.TypeOf(testEmbeddedStruct{}),
+			fieldName: func(field reflect.StructField) string {
+				return field.Tag.Get("cty")
+			},
+			want: testEmbeddedStruct{
+				EmbeddedStruct: EmbeddedStruct{Name: "Stephen"},
+				City:           "New York",
+			},
+		},
 
 		// Tuples
 		{
@@ -475,6 +489,16 @@ type testStruct struct {
 	Number *int   `cty:"number"`
 }
 
+type testEmbeddedStruct struct {
+	EmbeddedStruct
+	City string `cty:"city"` // Shadows EmbeddedStruct.City
+}
+
+type EmbeddedStruct struct {
+	Name string `cty:"name"`
+	City string `cty:"city"`
+}
+
 type testTupleStruct struct {
 	Name   string
 	Number int
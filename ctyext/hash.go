@@ -0,0 +1,71 @@
+package ctyext
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Hash returns a stable hash of a value, for detecting changes.
+//
+// Equal values have equal hashes. Object attributes and map elements are
+// hashed in key order, and numbers by their value, so 1 and 1.0 hash the
+// same. The type is included in the hash, values of different types have
+// different hashes.
+//
+// Returns an empty string if the value is not wholly known.
+func Hash(v cty.Value) string {
+	if !v.IsWhollyKnown() {
+		return ""
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s;", v.Type().GoString())
+	writeHash(h, v)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeHash writes a representation of v to w. Strings and keys are length
+// prefixed, so the representation is unambiguous.
+func writeHash(w io.Writer, v cty.Value) {
+	if v.IsNull() {
+		fmt.Fprint(w, "~;")
+		return
+	}
+	ty := v.Type()
+	switch {
+	case ty == cty.String:
+		s := v.AsString()
+		fmt.Fprintf(w, "s%d:%s;", len(s), s)
+	case ty == cty.Number:
+		fmt.Fprintf(w, "n%s;", v.AsBigFloat().Text('g', -1))
+	case ty == cty.Bool:
+		fmt.Fprintf(w, "b%t;", v.True())
+	case ty.IsListType(), ty.IsSetType(), ty.IsTupleType():
+		fmt.Fprintf(w, "[%d;", v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			_, el := it.Element()
+			writeHash(w, el)
+		}
+		fmt.Fprint(w, "];")
+	case ty.IsMapType(), ty.IsObjectType():
+		vals := v.AsValueMap()
+		keys := make([]string, 0, len(vals))
+		for k := range vals {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(w, "{%d;", len(keys))
+		for _, k := range keys {
+			fmt.Fprintf(w, "%d:%s=", len(k), k)
+			writeHash(w, vals[k])
+		}
+		fmt.Fprint(w, "};")
+	default:
+		// Capsule values cannot be inspected.
+		fmt.Fprintf(w, "?%s;", v.GoString())
+	}
+}
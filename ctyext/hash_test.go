@@ -0,0 +1,110 @@
+package ctyext_test
+
+import (
+	"testing"
+
+	"github.com/func/func/ctyext"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestHash_equal(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b cty.Value
+	}{
+		{
+			"Number",
+			cty.NumberIntVal(1),
+			cty.NumberFloatVal(1.0),
+		},
+		{
+			"Map",
+			cty.MapVal(map[string]cty.Value{"a": cty.StringVal("x"), "b": cty.StringVal("y")}),
+			cty.MapVal(map[string]cty.Value{"b": cty.StringVal("y"), "a": cty.StringVal("x")}),
+		},
+		{
+			"Set",
+			cty.SetVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			cty.SetVal([]cty.Value{cty.StringVal("b"), cty.StringVal("a")}),
+		},
+		{
+			"Nested",
+			cty.ObjectVal(map[string]cty.Value{
+				"list": cty.ListVal([]cty.Value{cty.NumberIntVal(3)}),
+				"null": cty.NullVal(cty.String),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"null": cty.NullVal(cty.String),
+				"list": cty.ListVal([]cty.Value{cty.NumberFloatVal(3)}),
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := ctyext.Hash(tt.a), ctyext.Hash(tt.b)
+			if a == "" {
+				t.Fatal("Hash() is empty")
+			}
+			if a != b {
+				t.Errorf("Hashes differ\nA %s\nB %s", a, b)
+			}
+		})
+	}
+}
+
+func TestHash_different(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b cty.Value
+	}{
+		{
+			"String",
+			cty.StringVal("a"),
+			cty.StringVal("b"),
+		},
+		{
+			"Type",
+			cty.StringVal("1"),
+			cty.NumberIntVal(1),
+		},
+		{
+			"Null",
+			cty.StringVal(""),
+			cty.NullVal(cty.String),
+		},
+		{
+			"ListOrder",
+			cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			cty.ListVal([]cty.Value{cty.StringVal("b"), cty.StringVal("a")}),
+		},
+		{
+			// Without length prefixes, both would be written as ab.
+			"Boundary",
+			cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			cty.TupleVal([]cty.Value{cty.StringVal("ab"), cty.StringVal("")}),
+		},
+		{
+			"NestedValue",
+			cty.ObjectVal(map[string]cty.Value{
+				"obj": cty.ObjectVal(map[string]cty.Value{"n": cty.NumberIntVal(1)}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"obj": cty.ObjectVal(map[string]cty.Value{"n": cty.NumberIntVal(2)}),
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if a, b := ctyext.Hash(tt.a), ctyext.Hash(tt.b); a == b {
+				t.Errorf("Hashes are equal: %s", a)
+			}
+		})
+	}
+}
+
+func TestHash_unknown(t *testing.T) {
+	v := cty.ObjectVal(map[string]cty.Value{"a": cty.UnknownVal(cty.String)})
+	if got := ctyext.Hash(v); got != "" {
+		t.Errorf("Hash() = %q, want empty", got)
+	}
+}
@@ -0,0 +1,129 @@
+package ctyext
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// MarshalJSON encodes a cty value to JSON, based on the value's own type.
+//
+// Objects and maps are encoded as JSON objects with sorted keys, while lists,
+// sets and tuples are encoded as arrays. Whole numbers are encoded without a
+// fractional part or exponent. Other numbers are encoded with the shortest
+// representation that converts back to the same 64 bit float, so 1.23 is
+// encoded as 1.23. Null values are encoded as null.
+//
+// Unknown values, infinite numbers, dynamic values and capsules cannot be
+// encoded. If the value contains any of these, a PathError is returned.
+func MarshalJSON(v cty.Value) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := marshalJSON(&buf, v, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func marshalJSON(buf *bytes.Buffer, v cty.Value, path cty.Path) error {
+	if !v.IsKnown() {
+		return PathError{Path: path, Err: fmt.Errorf("value is not known")}
+	}
+	if v.IsNull() {
+		buf.WriteString("null")
+		return nil
+	}
+
+	ty := v.Type()
+	switch ty {
+	case cty.Bool:
+		buf.WriteString(strconv.FormatBool(v.True()))
+		return nil
+	case cty.Number:
+		bf := v.AsBigFloat()
+		if bf.IsInf() {
+			return PathError{Path: path, Err: fmt.Errorf("cannot encode infinity")}
+		}
+		buf.WriteString(formatNumber(bf))
+		return nil
+	case cty.String:
+		return writeJSONString(buf, v.AsString())
+	case cty.DynamicPseudoType:
+		return PathError{Path: path, Err: fmt.Errorf("dynamic types not supported")}
+	}
+
+	switch {
+	case ty.IsListType(), ty.IsSetType(), ty.IsTupleType():
+		buf.WriteByte('[')
+		i := 0
+		for it := v.ElementIterator(); it.Next(); i++ {
+			key, elem := it.Element()
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := marshalJSON(buf, elem, appendStep(path, cty.IndexStep{Key: key})); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case ty.IsMapType(), ty.IsObjectType():
+		vals := v.AsValueMap()
+		keys := make([]string, 0, len(vals))
+		for k := range vals {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONString(buf, k); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			var step cty.PathStep = cty.IndexStep{Key: cty.StringVal(k)}
+			if ty.IsObjectType() {
+				step = cty.GetAttrStep{Name: k}
+			}
+			if err := marshalJSON(buf, vals[k], appendStep(path, step)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case ty.IsCapsuleType():
+		return PathError{Path: path, Err: fmt.Errorf("capsule types not supported")}
+	}
+
+	// We should never fall out here
+	return PathError{Path: path, Err: fmt.Errorf("unsupported target type %#v", ty)}
+}
+
+// formatNumber formats a number for JSON encoding.
+func formatNumber(bf *big.Float) string {
+	if bf.IsInt() {
+		return bf.Text('f', 0)
+	}
+	f, _ := bf.Float64()
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// writeJSONString writes a quoted JSON string. Unlike json.Marshal, HTML
+// characters are not escaped.
+func writeJSONString(buf *bytes.Buffer, str string) error {
+	var tmp bytes.Buffer
+	enc := json.NewEncoder(&tmp)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(str); err != nil {
+		return err
+	}
+	// Encode adds a trailing newline.
+	buf.Write(bytes.TrimSuffix(tmp.Bytes(), []byte("\n")))
+	return nil
+}
@@ -0,0 +1,115 @@
+package ctyext_test
+
+import (
+	"testing"
+
+	"github.com/func/func/ctyext"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input cty.Value
+		want  string
+	}{
+		// Primitives
+		{"True", cty.True, `true`},
+		{"String", cty.StringVal("foo"), `"foo"`},
+		{"StringEscape", cty.StringVal("a \"b\"\n<c>&"), `"a \"b\"\n<c>&"`},
+		{"NullString", cty.NullVal(cty.String), `null`},
+		{"NullNumber", cty.NullVal(cty.Number), `null`},
+		{"NullBool", cty.NullVal(cty.Bool), `null`},
+
+		// Numbers
+		{"Zero", cty.NumberIntVal(0), `0`},
+		{"Int", cty.NumberIntVal(123), `123`},
+		{"NegativeInt", cty.NumberIntVal(-1), `-1`},
+		{"UInt", cty.NumberUIntVal(234), `234`},
+		{"Float", cty.NumberFloatVal(1.23), `1.23`},
+		{"FloatWhole", cty.NumberFloatVal(2), `2`},
+		{"LargeInt", cty.MustParseNumberVal("12345678901234567890123"), `12345678901234567890123`},
+
+		// Collections
+		{
+			"List",
+			cty.ListVal([]cty.Value{cty.NumberFloatVal(1.23), cty.NumberFloatVal(2.34)}),
+			`[1.23,2.34]`,
+		},
+		{"EmptyList", cty.ListValEmpty(cty.String), `[]`},
+		{"NullList", cty.NullVal(cty.List(cty.String)), `null`},
+		{
+			"Set",
+			cty.SetVal([]cty.Value{cty.StringVal("b"), cty.StringVal("a")}),
+			`["a","b"]`,
+		},
+		{
+			"Tuple",
+			cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.NumberIntVal(1), cty.NullVal(cty.Bool)}),
+			`["a",1,null]`,
+		},
+		{
+			"Map",
+			cty.MapVal(map[string]cty.Value{"b": cty.NumberIntVal(2), "a": cty.NumberIntVal(1)}),
+			`{"a":1,"b":2}`,
+		},
+		{
+			"NestedObject",
+			cty.ObjectVal(map[string]cty.Value{
+				"Version": cty.StringVal("2012-10-17"),
+				"Statement": cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"Effect":   cty.StringVal("Allow"),
+						"Action":   cty.ListVal([]cty.Value{cty.StringVal("sts:AssumeRole")}),
+						"Resource": cty.NullVal(cty.String),
+					}),
+				}),
+			}),
+			`{"Statement":[{"Action":["sts:AssumeRole"],"Effect":"Allow","Resource":null}],"Version":"2012-10-17"}`,
+		},
+		{"EmptyObject", cty.EmptyObjectVal, `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctyext.MarshalJSON(tt.input)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MarshalJSON()\nGot  %s\nWant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalJSON_error(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   cty.Value
+		wantErr string
+	}{
+		{
+			"Unknown",
+			cty.ObjectVal(map[string]cty.Value{
+				"foo": cty.ListVal([]cty.Value{cty.UnknownVal(cty.String)}),
+			}),
+			"foo[0]: value is not known",
+		},
+		{
+			"Infinity",
+			cty.MapVal(map[string]cty.Value{"a": cty.PositiveInfinity}),
+			`["a"]: cannot encode infinity`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ctyext.MarshalJSON(tt.input)
+			if err == nil {
+				t.Fatalf("MarshalJSON() want error")
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("Error\nGot  %v\nWant %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -21,6 +21,11 @@ func PathString(path cty.Path) string {
 			}
 			buf.WriteString(v.Name)
 		case cty.IndexStep:
+			if !v.Key.IsKnown() {
+				// Dynamic index, the key is not known.
+				buf.WriteString("[?]")
+				continue
+			}
 			if v.Key.Type() == cty.Number {
 				bf := v.Key.AsBigFloat()
 				val, _ := bf.Int64()
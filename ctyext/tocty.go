@@ -194,15 +194,7 @@ func getObject(val reflect.Value, attr map[string]cty.Type, path cty.Path, field
 		}
 		return cty.ObjectVal(vals), nil
 	case reflect.Struct:
-		ty := val.Type()
-		attrFields := make(map[string]int)
-		for i := 0; i < ty.NumField(); i++ {
-			field := ty.Field(i)
-			attrName := fieldName(field)
-			if attrName != "" {
-				attrFields[attrName] = i
-			}
-		}
+		attrFields := structFields(val.Type(), fieldName)
 
 		vals := make(map[string]cty.Value, len(attr))
 		for k, at := range attr {
@@ -212,7 +204,7 @@ func getObject(val reflect.Value, attr map[string]cty.Type, path cty.Path, field
 
 			if fieldIdx, have := attrFields[k]; have {
 				var err error
-				vals[k], err = toCtyValue(val.Field(fieldIdx), at, path, fieldName)
+				vals[k], err = toCtyValue(val.FieldByIndex(fieldIdx), at, path, fieldName)
 				if err != nil {
 					return cty.NilVal, err
 				}
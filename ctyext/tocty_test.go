@@ -328,6 +328,23 @@ func TestToCtyValue(t *testing.T) {
 				"number": cty.NullVal(cty.Number),
 			}),
 		},
+		{
+			val: testEmbeddedStruct{
+				EmbeddedStruct: EmbeddedStruct{Name: "Steven", City: "Paris"},
+				City:           "New York",
+			},
+			target: cty.Object(map[string]cty.Type{
+				"name": cty.String,
+				"city": cty.String,
+			}),
+			fieldName: func(field reflect.StructField) string {
+				return field.Tag.Get("cty")
+			},
+			want: cty.ObjectVal(map[string]cty.Value{
+				"name": cty.StringVal("Steven"),
+				"city": cty.StringVal("New York"),
+			}),
+		},
 		{
 			val: map[string]interface{}{
 				"name":   "Steven",
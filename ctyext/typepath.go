@@ -2,6 +2,7 @@ package ctyext
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/zclconf/go-cty/cty"
 )
@@ -43,6 +44,18 @@ func ApplyTypePath(ty cty.Type, path cty.Path) (cty.Type, error) {
 				ty = ty.ElementType()
 				continue
 			}
+			if ty.IsTupleType() {
+				elem, err := tupleElementType(ty, e.Key)
+				if err != nil {
+					str := err.Error()
+					if i > 0 {
+						str += fmt.Sprintf(" in %s", PathString(path[:i]))
+					}
+					return cty.NilType, fmt.Errorf(str)
+				}
+				ty = elem
+				continue
+			}
 			str := fmt.Sprintf("cannot access indexed type from %s", ty.FriendlyName())
 			if i > 0 {
 				str += fmt.Sprintf(" in %s", PathString(path[:i]))
@@ -52,3 +65,29 @@ func ApplyTypePath(ty cty.Type, path cty.Path) (cty.Type, error) {
 	}
 	return ty, nil
 }
+
+// tupleElementType returns the type of the element at the given index in a
+// tuple type.
+func tupleElementType(ty cty.Type, key cty.Value) (cty.Type, error) {
+	if key.Type() != cty.Number || !key.IsKnown() || key.IsNull() {
+		return cty.NilType, fmt.Errorf("tuple index must be a number")
+	}
+	index, acc := key.AsBigFloat().Int64()
+	if acc != big.Exact {
+		return cty.NilType, fmt.Errorf("tuple index must be a whole number")
+	}
+	elems := ty.TupleElementTypes()
+	if index < 0 || index >= int64(len(elems)) {
+		return cty.NilType, fmt.Errorf("index %d out of range for tuple with %s", index, elements(len(elems)))
+	}
+	return elems[index], nil
+}
+
+// elements returns the number of elements in a tuple, as in "1 element" or
+// "2 elements".
+func elements(n int) string {
+	if n == 1 {
+		return "1 element"
+	}
+	return fmt.Sprintf("%d elements", n)
+}
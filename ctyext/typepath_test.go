@@ -43,6 +43,61 @@ func TestApplyTypePath(t *testing.T) {
 			path:  cty.IndexPath(cty.NumberIntVal(2)).GetAttr("val"),
 			want:  cty.Number,
 		},
+		{
+			name:  "TupleIndex",
+			input: cty.Tuple([]cty.Type{cty.String, cty.Number}),
+			path:  cty.IndexPath(cty.NumberIntVal(1)),
+			want:  cty.Number,
+		},
+		{
+			name: "TupleIndexNested",
+			input: cty.Object(map[string]cty.Type{
+				"foo": cty.Tuple([]cty.Type{
+					cty.String,
+					cty.Object(map[string]cty.Type{"bar": cty.Bool}),
+				}),
+			}),
+			path: cty.GetAttrPath("foo").Index(cty.NumberIntVal(1)).GetAttr("bar"),
+			want: cty.Bool,
+		},
+		{
+			name: "TupleIndexOutOfRange",
+			input: cty.Object(map[string]cty.Type{
+				"foo": cty.Tuple([]cty.Type{cty.String, cty.Number}),
+			}),
+			path:    cty.GetAttrPath("foo").Index(cty.NumberIntVal(2)),
+			wantErr: "index 2 out of range for tuple with 2 elements in foo",
+		},
+		{
+			name:    "TupleIndexNegative",
+			input:   cty.Tuple([]cty.Type{cty.String}),
+			path:    cty.IndexPath(cty.NumberIntVal(-1)),
+			wantErr: "index -1 out of range for tuple with 1 element",
+		},
+		{
+			name:    "TupleIndexOne",
+			input:   cty.Tuple([]cty.Type{cty.String}),
+			path:    cty.IndexPath(cty.NumberIntVal(1)),
+			wantErr: "index 1 out of range for tuple with 1 element",
+		},
+		{
+			name:    "TupleIndexN",
+			input:   cty.Tuple([]cty.Type{cty.String, cty.Number, cty.Bool}),
+			path:    cty.IndexPath(cty.NumberIntVal(3)),
+			wantErr: "index 3 out of range for tuple with 3 elements",
+		},
+		{
+			name:    "TupleIndexFraction",
+			input:   cty.Tuple([]cty.Type{cty.String}),
+			path:    cty.IndexPath(cty.NumberFloatVal(0.5)),
+			wantErr: "tuple index must be a whole number",
+		},
+		{
+			name:    "TupleIndexString",
+			input:   cty.Tuple([]cty.Type{cty.String}),
+			path:    cty.IndexPath(cty.StringVal("0")),
+			wantErr: "tuple index must be a number",
+		},
 		{
 			name: "ObjectFieldNotFound",
 			input: cty.Object(map[string]cty.Type{
@@ -0,0 +1,243 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	"github.com/aws/aws-sdk-go-v2/service/acm/acmiface"
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
+	"github.com/func/func/provider/aws/internal/tags"
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+)
+
+// ACMCertificate requests a public SSL/TLS certificate from AWS Certificate
+// Manager.
+//
+// The certificate must be validated before it is issued. With DNS
+// validation, the records in the domain_validation_options output must be
+// created in the DNS zone for the domain, for example with Route53 records.
+// The resource is ready as soon as the validation records are available, it
+// does not wait for the certificate to be issued, so the records can be
+// created by resources that depend on the certificate.
+//
+// Certificates used with CloudFront must be requested in us-east-1. Changing
+// the region requests a new certificate.
+//
+// https://aws.amazon.com/certificate-manager/
+type ACMCertificate struct {
+	// Inputs
+
+	// The fully qualified domain name to secure with the certificate, such
+	// as www.example.com. Use an asterisk to create a wildcard certificate
+	// that protects several sites in the same domain, such as *.example.com.
+	//
+	// Changing the domain name requests a new certificate.
+	DomainName string `func:"input,force_new" validate:"min=1,max=253"`
+
+	base.ForceNewResource
+
+	// Additional fully qualified domain names to include in the certificate.
+	//
+	// Changing the names requests a new certificate.
+	SubjectAlternativeNames []string `func:"input,force_new"`
+
+	// Tags to attach to the certificate.
+	Tags map[string]string `func:"input"`
+
+	// The method to validate domain ownership with, either DNS or EMAIL. If
+	// not set, DNS is used.
+	//
+	// Changing the validation method requests a new certificate.
+	ValidationMethod *string `func:"input,force_new" validate:"oneof=DNS EMAIL"`
+
+	// Outputs
+
+	// The Amazon Resource Name (ARN) of the certificate.
+	ARN string `func:"output"`
+
+	// The records to create to validate ownership of the domains in the
+	// certificate. Only set for DNS validation.
+	DomainValidationOptions []ACMDomainValidation `func:"output"`
+}
+
+// ACMDomainValidation contains the DNS record to create to validate a domain
+// in an ACM certificate.
+type ACMDomainValidation struct {
+	// The domain name to validate.
+	DomainName string
+
+	// The name of the DNS record to create.
+	ResourceRecordName string
+
+	// The type of the DNS record to create. Currently always CNAME.
+	ResourceRecordType string
+
+	// The value of the DNS record to create.
+	ResourceRecordValue string
+}
+
+// Create requests a new certificate.
+func (p *ACMCertificate) Create(ctx context.Context, r *resource.CreateRequest) error {
+	svc, err := base.ACM(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	if p.ARN == "" {
+		method := acm.ValidationMethodDns
+		if p.ValidationMethod != nil {
+			method = acm.ValidationMethod(*p.ValidationMethod)
+		}
+
+		input := &acm.RequestCertificateInput{
+			DomainName:              aws.String(p.DomainName),
+			SubjectAlternativeNames: p.SubjectAlternativeNames,
+			ValidationMethod:        method,
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+
+		resp, err := svc.RequestCertificateRequest(input).Send(ctx)
+		if err != nil {
+			return base.Classify(err)
+		}
+
+		// Set output immediately so a retry does not request another
+		// certificate.
+		p.ARN = *resp.CertificateArn
+	}
+
+	// Tags cannot be set when requesting the certificate.
+	return p.updateTags(ctx, svc, nil)
+}
+
+// WaitReady waits for the domain validation records to become available.
+// ACM sets the records asynchronously after the certificate was requested.
+func (p *ACMCertificate) WaitReady(ctx context.Context, r *resource.WaitRequest) error {
+	svc, err := base.ACM(p, r.Auth)
+	if err != nil {
+		return err
+	}
+
+	input := &acm.DescribeCertificateInput{
+		CertificateArn: aws.String(p.ARN),
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+
+	resp, err := svc.DescribeCertificateRequest(input).Send(ctx)
+	if err != nil {
+		return err
+	}
+
+	cert := resp.Certificate
+	if cert.Status == acm.CertificateStatusFailed {
+		return backoff.Permanent(fmt.Errorf("certificate request failed: %s", cert.FailureReason))
+	}
+	if p.ValidationMethod != nil && *p.ValidationMethod != string(acm.ValidationMethodDns) {
+		return nil
+	}
+	if len(cert.DomainValidationOptions) == 0 {
+		return fmt.Errorf("validation records are not available")
+	}
+
+	opts := make([]ACMDomainValidation, 0, len(cert.DomainValidationOptions))
+	for _, o := range cert.DomainValidationOptions {
+		if o.ResourceRecord == nil {
+			return fmt.Errorf("validation record for %s is not available", *o.DomainName)
+		}
+		opts = append(opts, ACMDomainValidation{
+			DomainName:          *o.DomainName,
+			ResourceRecordName:  *o.ResourceRecord.Name,
+			ResourceRecordType:  string(o.ResourceRecord.Type),
+			ResourceRecordValue: *o.ResourceRecord.Value,
+		})
+	}
+	p.DomainValidationOptions = opts
+
+	return nil
+}
+
+// Delete deletes the certificate. A certificate cannot be deleted while it
+// is in use by another AWS resource.
+func (p *ACMCertificate) Delete(ctx context.Context, r *resource.DeleteRequest) error {
+	svc, err := base.ACM(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	input := &acm.DeleteCertificateInput{
+		CertificateArn: aws.String(p.ARN),
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err = svc.DeleteCertificateRequest(input).Send(ctx)
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case acm.ErrCodeResourceNotFoundException:
+			// Already deleted
+			return nil
+		case acm.ErrCodeResourceInUseException:
+			// The resource using the certificate may still be being deleted.
+			return err
+		}
+	}
+	return base.DeleteError(err)
+}
+
+// Update updates the tags on the certificate. All other changes, including
+// the region, require a new certificate.
+func (p *ACMCertificate) Update(ctx context.Context, r *resource.UpdateRequest) error {
+	svc, err := base.ACM(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	prev := r.Previous.(*ACMCertificate)
+	p.ARN = prev.ARN
+	p.DomainValidationOptions = prev.DomainValidationOptions
+
+	return p.updateTags(ctx, svc, prev.Tags)
+}
+
+func (p *ACMCertificate) updateTags(ctx context.Context, svc acmiface.ClientAPI, prev map[string]string) error {
+	diff := tags.Compare(prev, p.Tags)
+	if len(diff.Remove) > 0 {
+		remove := make([]acm.Tag, len(diff.Remove))
+		for i, k := range diff.Remove {
+			remove[i] = acm.Tag{Key: aws.String(k)}
+		}
+		input := &acm.RemoveTagsFromCertificateInput{
+			CertificateArn: aws.String(p.ARN),
+			Tags:           remove,
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		if _, err := svc.RemoveTagsFromCertificateRequest(input).Send(ctx); err != nil {
+			return base.Classify(err)
+		}
+	}
+
+	set := diff.Set()
+	if len(set) == 0 {
+		return nil
+	}
+	input := &acm.AddTagsToCertificateInput{
+		CertificateArn: aws.String(p.ARN),
+	}
+	tags.Convert(set, &input.Tags, "Key", "Value")
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err := svc.AddTagsToCertificateRequest(input).Send(ctx)
+	return base.Classify(err)
+}
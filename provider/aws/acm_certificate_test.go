@@ -0,0 +1,19 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/func/func/resource"
+)
+
+func TestACMCertificate_fields(t *testing.T) {
+	// A certificate cannot be moved to another region.
+	region, ok := resource.Fields(reflect.TypeOf(ACMCertificate{})).Inputs()["region"]
+	if !ok {
+		t.Fatalf("Region is not an input")
+	}
+	if !region.ForceNew() {
+		t.Errorf("Region is not force_new")
+	}
+}
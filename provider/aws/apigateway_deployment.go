@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"strings"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws/awserr"
 	"github.com/aws/aws-sdk-go-v2/service/apigateway"
 	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 )
 
@@ -32,8 +34,7 @@ type APIGatewayDeployment struct {
 	// The description for the Deployment resource to create.
 	Description *string `func:"input"`
 
-	// The region the API Gateway is deployed to.
-	Region string `func:"input"`
+	base.Resource
 
 	// The string identifier of the associated RestApi.
 	RestAPIID string `func:"input" name:"rest_api_id"`
@@ -71,7 +72,9 @@ type APIGatewayDeployment struct {
 	// The identifier for the deployment resource.
 	ID *string `func:"output"`
 
-	apigatewayService
+	// The URL to invoke the API with, including the stage. Only set if a
+	// stage name was given.
+	InvokeURL string `func:"output"`
 }
 
 // APIGatewayDeploymentCanarySettings contains settings for canary deployment,
@@ -106,7 +109,7 @@ type APIGatewayMethodSnapshot struct {
 
 // Create creates a new deployment.
 func (p *APIGatewayDeployment) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -152,7 +155,7 @@ func (p *APIGatewayDeployment) Create(ctx context.Context, r *resource.CreateReq
 				return err
 			}
 		}
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.APISummary = make(map[string]map[string]APIGatewayMethodSnapshot, len(resp.ApiSummary))
@@ -169,12 +172,20 @@ func (p *APIGatewayDeployment) Create(ctx context.Context, r *resource.CreateReq
 	p.CreatedDate = resp.CreatedDate.Format(time.RFC3339)
 	p.ID = resp.Id
 
+	p.InvokeURL = ""
+	if p.StageName != nil {
+		p.InvokeURL = fmt.Sprintf(
+			"https://%s.execute-api.%s.amazonaws.com/%s",
+			p.RestAPIID, p.Region, *p.StageName,
+		)
+	}
+
 	return nil
 }
 
 // Delete removes a deployment.
 func (p *APIGatewayDeployment) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -188,12 +199,14 @@ func (p *APIGatewayDeployment) Delete(ctx context.Context, r *resource.DeleteReq
 	}
 
 	_, err = svc.DeleteDeploymentRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
 // Update triggers a new deployment.
 // There is no concept of "updating" a deployment so it is identical to
-// creating a new one.
+// creating a new one. A new deployment is created whenever any input changes,
+// including the stage name or the ChangeTrigger, which can be set to a value
+// referencing the integrations in the API.
 func (p *APIGatewayDeployment) Update(ctx context.Context, r *resource.UpdateRequest) error {
 	// Update is the same as create
 	return p.Create(ctx, r.CreateRequest())
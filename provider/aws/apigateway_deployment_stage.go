@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/apigateway"
 	"github.com/cenkalti/backoff"
 	"github.com/func/func/provider/aws/internal/apigatewaypatch"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 	"github.com/pkg/errors"
 )
@@ -36,8 +37,7 @@ type APIGatewayStage struct {
 	// The version of the associated API documentation.
 	DocumentationVersion *string `func:"input"`
 
-	// The region the API Gateway is deployed to.
-	Region string `func:"input"`
+	base.Resource
 
 	// The string identifier of the associated Rest API.
 	RestAPIID string `func:"input" name:"rest_api_id"`
@@ -77,8 +77,6 @@ type APIGatewayStage struct {
 
 	// The ARN of the WebAcl associated with the Stage.
 	WebACLARN *string `func:"output" name:"web_acl_arn"`
-
-	apigatewayService
 }
 
 // APIGatewayCanarySettings contains settings for canary deployment.
@@ -170,7 +168,7 @@ func (p *APIGatewayStage) Type() string { return "aws_apigateway_stage" }
 
 // Create creates a new deployment.
 func (p *APIGatewayStage) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -209,7 +207,7 @@ func (p *APIGatewayStage) Create(ctx context.Context, r *resource.CreateRequest)
 
 	resp, err := svc.CreateStageRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	if resp.AccessLogSettings != nil {
@@ -242,7 +240,7 @@ func (p *APIGatewayStage) Create(ctx context.Context, r *resource.CreateRequest)
 
 // Delete removes a deployment.
 func (p *APIGatewayStage) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -256,7 +254,7 @@ func (p *APIGatewayStage) Delete(ctx context.Context, r *resource.DeleteRequest)
 	}
 
 	_, err = svc.DeleteStageRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
 // Update triggers a new deployment.
@@ -301,7 +299,7 @@ func (p *APIGatewayStage) Update(ctx context.Context, r *resource.UpdateRequest)
 		return nil
 	}
 
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -317,7 +315,7 @@ func (p *APIGatewayStage) Update(ctx context.Context, r *resource.UpdateRequest)
 
 	resp, err := svc.UpdateStageRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	if resp.AccessLogSettings != nil {
@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/apigateway"
 	"github.com/cenkalti/backoff"
 	"github.com/func/func/provider/aws/internal/apigatewaypatch"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 )
 
@@ -59,7 +60,10 @@ type APIGatewayIntegration struct {
 
 	// Specifies a put integration HTTP method.
 	//
-	// When the integration type is `HTTP` or `AWS`, this field is required.
+	// When the integration type is `HTTP`, `HTTP_PROXY` or `AWS`, the method
+	// defaults to `http_method`. When the integration type is `AWS_PROXY`,
+	// the method defaults to `POST`, which is required to invoke a Lambda
+	// function.
 	IntegrationHTTPMethod *string `func:"input"`
 
 	// Specifies the pass-through behavior for incoming requests based on the
@@ -78,8 +82,7 @@ type APIGatewayIntegration struct {
 	//   defined, unmapped content types will be rejected with the same 415 response.
 	PassthroughBehavior *string `func:"input" validate:"oneof=WHEN_NO_MATCH NEVER WHEN_NO_TEMPLATES"`
 
-	// The region the API Gateway is deployed to.
-	Region string `func:"input"`
+	base.Resource
 
 	// A key-value map specifying request parameters that are passed from the
 	// method request to the back end. The key is an integration request
@@ -106,7 +109,7 @@ type APIGatewayIntegration struct {
 
 	// Custom timeout between 50 and 29,000 milliseconds. The default value is 29,000
 	// milliseconds or 29 seconds.
-	TimeoutInMillis *int64 `func:"input" validate:"gte=50,lte=29000"`
+	TimeoutInMillis *int64 `func:"input,duration_ms" validate:"gte=50,lte=29000"`
 
 	// Specifies a put integration input's type.
 	//
@@ -169,8 +172,6 @@ type APIGatewayIntegration struct {
 	//
 	// The key in the map is the HTTP status code.
 	IntegrationResponses map[string]APIGatewayIntegrationResponse `func:"output"`
-
-	apigatewayService
 }
 
 // APIGatewayIntegrationResponse is the output from an integration for a
@@ -225,13 +226,31 @@ type APIGatewayIntegrationResponse struct {
 	StatusCode string
 }
 
-// Create creates a new resource.
-func (p *APIGatewayIntegration) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
-	if err != nil {
-		return err
+// maxNotFoundAttempts is the number of attempts to create an integration when
+// the method it belongs to is not found.
+const maxNotFoundAttempts = 5
+
+// SetDefaults sets the integration HTTP method if it was not set.
+func (p *APIGatewayIntegration) SetDefaults() {
+	if p.IntegrationHTTPMethod != nil {
+		return
+	}
+	switch p.IntegrationType {
+	case "HTTP", "HTTP_PROXY", "AWS":
+		p.IntegrationHTTPMethod = aws.String(p.HTTPMethod)
+	case "AWS_PROXY":
+		p.IntegrationHTTPMethod = aws.String("POST")
 	}
+}
+
+// Validate checks that the input to create the integration is valid, without
+// sending it to AWS.
+func (p *APIGatewayIntegration) Validate() error {
+	return p.putInput().Validate()
+}
 
+// putInput returns the input to create the integration with.
+func (p *APIGatewayIntegration) putInput() *apigateway.PutIntegrationInput {
 	input := &apigateway.PutIntegrationInput{
 		CacheNamespace:        p.CacheNamespace,
 		CacheKeyParameters:    p.CacheKeyParameters,
@@ -255,20 +274,34 @@ func (p *APIGatewayIntegration) Create(ctx context.Context, r *resource.CreateRe
 	if p.ContentHandling != nil {
 		input.ContentHandling = apigateway.ContentHandlingStrategy(*p.ContentHandling)
 	}
+	return input
+}
 
-	if err := input.Validate(); err != nil {
+// Create creates a new resource.
+func (p *APIGatewayIntegration) Create(ctx context.Context, r *resource.CreateRequest) error {
+	if err := p.Validate(); err != nil {
 		return backoff.Permanent(err)
 	}
 
-	resp, err := svc.PutIntegrationRequest(input).Send(ctx)
+	svc, err := base.APIGateway(p, r.Auth)
+	if err != nil {
+		return err
+	}
+
+	resp, err := svc.PutIntegrationRequest(p.putInput()).Send(ctx)
 	if err != nil {
 		if aerr, ok := err.(awserr.RequestFailure); ok {
 			if aerr.Code() == apigateway.ErrCodeNotFoundException {
-				// Retry
+				// The parent resource or method may not be visible yet.
+				// Retry a limited number of times in case it never
+				// appears.
+				if r.Attempt >= maxNotFoundAttempts {
+					return backoff.Permanent(err)
+				}
 				return err
 			}
 		}
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.IntegrationResponses = make(map[string]APIGatewayIntegrationResponse, len(resp.IntegrationResponses))
@@ -287,7 +320,7 @@ func (p *APIGatewayIntegration) Create(ctx context.Context, r *resource.CreateRe
 
 // Delete removes a resource.
 func (p *APIGatewayIntegration) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -303,7 +336,7 @@ func (p *APIGatewayIntegration) Delete(ctx context.Context, r *resource.DeleteRe
 
 	_, err = svc.DeleteIntegrationRequest(input).Send(ctx)
 	if err != nil {
-		return handleDelError(err)
+		return base.DeleteError(err)
 	}
 
 	return nil
@@ -353,7 +386,7 @@ func (p *APIGatewayIntegration) Update(ctx context.Context, r *resource.UpdateRe
 		return nil
 	}
 
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -370,7 +403,7 @@ func (p *APIGatewayIntegration) Update(ctx context.Context, r *resource.UpdateRe
 
 	resp, err := svc.UpdateIntegrationRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.IntegrationResponses = make(map[string]APIGatewayIntegrationResponse, len(resp.IntegrationResponses))
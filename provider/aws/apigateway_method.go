@@ -7,6 +7,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/apigateway"
 	"github.com/cenkalti/backoff"
 	"github.com/func/func/provider/aws/internal/apigatewaypatch"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 )
 
@@ -53,8 +54,7 @@ type APIGatewayMethod struct {
 	// method in [PetStore](https://petstore-demo-endpoint.execute-api.com/petstore/pets) example.
 	OperationName *string `func:"input"`
 
-	// The region the API Gateway is deployed to.
-	Region string `func:"input"`
+	base.Resource
 
 	// Specifies the Model resources used for the request's content type. Request
 	// models are represented as a key/value map, with a content type as the key
@@ -83,13 +83,11 @@ type APIGatewayMethod struct {
 	RestAPIID string `func:"input" name:"rest_api_id"`
 
 	// No outputs
-
-	apigatewayService
 }
 
 // Create creates a new resource.
 func (p *APIGatewayMethod) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -113,7 +111,7 @@ func (p *APIGatewayMethod) Create(ctx context.Context, r *resource.CreateRequest
 
 	resp, err := svc.PutMethodRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	// The response is a UpdateMethodOutput but it does not contain any
@@ -125,7 +123,7 @@ func (p *APIGatewayMethod) Create(ctx context.Context, r *resource.CreateRequest
 
 // Delete removes a resource.
 func (p *APIGatewayMethod) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -140,7 +138,7 @@ func (p *APIGatewayMethod) Delete(ctx context.Context, r *resource.DeleteRequest
 	}
 
 	_, err = svc.DeleteMethodRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
 // Update updates the rest api resource. Only the path part can be updated.
@@ -182,7 +180,7 @@ func (p *APIGatewayMethod) Update(ctx context.Context, r *resource.UpdateRequest
 		return nil
 	}
 
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -198,5 +196,5 @@ func (p *APIGatewayMethod) Update(ctx context.Context, r *resource.UpdateRequest
 	}
 
 	_, err = svc.UpdateMethodRequest(input).Send(ctx)
-	return handlePutError(err)
+	return base.Classify(err)
 }
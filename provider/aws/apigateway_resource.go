@@ -7,6 +7,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/apigateway"
 	"github.com/cenkalti/backoff"
 	"github.com/func/func/provider/aws/internal/apigatewaypatch"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 )
 
@@ -21,8 +22,7 @@ type APIGatewayResource struct {
 	// The last path segment for this resource.
 	PathPart string `func:"input"`
 
-	// The region the API Gateway is deployed to.
-	Region string `func:"input"`
+	base.Resource
 
 	// The string identifier of the associated RestApi.
 	RestAPIID string `func:"input" name:"rest_api_id"`
@@ -34,13 +34,11 @@ type APIGatewayResource struct {
 
 	// The full path for this resource.
 	Path *string `func:"output"`
-
-	apigatewayService
 }
 
 // Create creates a new resource.
 func (p *APIGatewayResource) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -56,7 +54,7 @@ func (p *APIGatewayResource) Create(ctx context.Context, r *resource.CreateReque
 
 	resp, err := svc.CreateResourceRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.ID = resp.Id
@@ -73,7 +71,7 @@ func (p *APIGatewayResource) Create(ctx context.Context, r *resource.CreateReque
 
 // Delete removes a resource.
 func (p *APIGatewayResource) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -87,7 +85,7 @@ func (p *APIGatewayResource) Delete(ctx context.Context, r *resource.DeleteReque
 	}
 
 	_, err = svc.DeleteResourceRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
 // Update updates the rest api resource. Only the path part can be updated.
@@ -106,7 +104,7 @@ func (p *APIGatewayResource) Update(ctx context.Context, r *resource.UpdateReque
 		return nil
 	}
 
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -121,5 +119,5 @@ func (p *APIGatewayResource) Update(ctx context.Context, r *resource.UpdateReque
 	}
 
 	_, err = svc.UpdateResourceRequest(input).Send(ctx)
-	return handlePutError(err)
+	return base.Classify(err)
 }
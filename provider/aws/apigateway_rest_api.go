@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/apigateway"
 	"github.com/cenkalti/backoff"
 	"github.com/func/func/provider/aws/internal/apigatewaypatch"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 	"github.com/pkg/errors"
 )
@@ -62,8 +63,7 @@ type APIGatewayRestAPI struct {
 	// of the caller and Method
 	Policy *string `func:"input"`
 
-	// The region the API Gateway is deployed to.
-	Region string `func:"input"`
+	base.Resource
 
 	// A version identifier for the API.
 	Version *string `func:"input"`
@@ -79,13 +79,11 @@ type APIGatewayRestAPI struct {
 
 	// The identifier for the API's root (/) resource.
 	RootResourceID *string `func:"output"`
-
-	apigatewayService
 }
 
 // Create creates a new rest api.
 func (p *APIGatewayRestAPI) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return errors.Wrap(err, "get client")
 	}
@@ -119,7 +117,7 @@ func (p *APIGatewayRestAPI) Create(ctx context.Context, r *resource.CreateReques
 
 	resp, err := svc.CreateRestApiRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.CreatedDate = resp.CreatedDate.Format(time.RFC3339)
@@ -144,7 +142,7 @@ func (p *APIGatewayRestAPI) Create(ctx context.Context, r *resource.CreateReques
 
 // Delete removes a rest api.
 func (p *APIGatewayRestAPI) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return errors.Wrap(err, "get client")
 	}
@@ -157,7 +155,7 @@ func (p *APIGatewayRestAPI) Delete(ctx context.Context, r *resource.DeleteReques
 	}
 
 	_, err = svc.DeleteRestApiRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
 // Update updates the rest api.
@@ -223,7 +221,7 @@ func (p *APIGatewayRestAPI) Update(ctx context.Context, r *resource.UpdateReques
 		return nil
 	}
 
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.APIGateway(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -248,7 +246,7 @@ func (p *APIGatewayRestAPI) Update(ctx context.Context, r *resource.UpdateReques
 				}
 			}
 		}
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	_ = resp
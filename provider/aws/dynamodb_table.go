@@ -7,7 +7,10 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/dynamodbiface"
 	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
+	"github.com/func/func/provider/aws/internal/tags"
 	"github.com/func/func/resource"
 	"github.com/google/go-cmp/cmp"
 )
@@ -298,6 +301,9 @@ type DynamoDBTable struct {
 	} `func:"input"`
 
 	// Represents the settings used to enable server-side encryption.
+	//
+	// Removing the block does not change the encryption of an existing
+	// table. To go back to an AWS owned CMK, set enabled to false.
 	SSE *struct {
 		// Indicates whether server-side encryption is done using an AWS
 		// managed CMK or an AWS owned CMK. If enabled (true), server-side
@@ -348,8 +354,11 @@ type DynamoDBTable struct {
 		ViewType string `validate:"oneof=KEYS_ONLY NEW_IMAGE OLD_IMAGE NEW_AND_OLD_IMAGES"`
 	} `func:"input"`
 
-	// The name of the table to create.
-	TableName string `func:"input" validate:"min=3"`
+	// The name of the table to create. If not set, the name is derived from
+	// the project, module and resource names.
+	//
+	// Changing the name replaces the table.
+	TableName *string `func:"input,force_new" validate:"min=3,max=255"`
 
 	// A list of key-value pairs to label the table. For more information, see
 	// [Tagging for
@@ -365,7 +374,7 @@ type DynamoDBTable struct {
 		Value string
 	} `func:"input" name:"tag"`
 
-	Region string `func:"input"`
+	base.Resource
 
 	// Outputs
 
@@ -377,17 +386,87 @@ type DynamoDBTable struct {
 
 	// Unique identifier for the table for which the backup was created.
 	TableID string `func:"output"`
+}
 
-	dynamoDBService
+// Validate checks that the key schemas of the table and its indexes are
+// valid and that index names are unique. The input to create the table is
+// validated without sending it to AWS.
+func (p *DynamoDBTable) Validate() error {
+	keys := make([]keyElement, len(p.KeySchema))
+	for i, ks := range p.KeySchema {
+		keys[i] = keyElement{ks.Name, ks.Type}
+	}
+	if err := validateKeySchema(keys); err != nil {
+		return fmt.Errorf("key_schema: %v", err)
+	}
+
+	indexes := make(map[string]bool)
+	for _, g := range p.GlobalSecondaryIndexes {
+		if indexes[g.Name] {
+			return fmt.Errorf("duplicate index name %q", g.Name)
+		}
+		indexes[g.Name] = true
+		keys := make([]keyElement, len(g.KeySchema))
+		for i, ks := range g.KeySchema {
+			keys[i] = keyElement{ks.Name, ks.Type}
+		}
+		if err := validateKeySchema(keys); err != nil {
+			return fmt.Errorf("global_secondary_index %q: key_schema: %v", g.Name, err)
+		}
+	}
+	for _, l := range p.LocalSecondaryIndexes {
+		if indexes[l.Name] {
+			return fmt.Errorf("duplicate index name %q", l.Name)
+		}
+		indexes[l.Name] = true
+		keys := make([]keyElement, len(l.KeySchema))
+		for i, ks := range l.KeySchema {
+			keys[i] = keyElement{ks.Name, ks.Type}
+		}
+		if err := validateKeySchema(keys); err != nil {
+			return fmt.Errorf("local_secondary_index %q: key_schema: %v", l.Name, err)
+		}
+	}
+
+	return p.createInput().Validate()
 }
 
-// Create creates a new DynamoDB table.
-func (p *DynamoDBTable) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
-	if err != nil {
-		return err
+// keyElement is a single attribute in a key schema.
+type keyElement struct {
+	Name, Type string
+}
+
+// validateKeySchema checks that a key schema has exactly one HASH key, at
+// most one RANGE key and that no attribute is used more than once.
+func validateKeySchema(keys []keyElement) error {
+	var hash, rng int
+	names := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if names[k.Name] {
+			return fmt.Errorf("duplicate key attribute %q", k.Name)
+		}
+		names[k.Name] = true
+		switch k.Type {
+		case "HASH":
+			hash++
+		case "RANGE":
+			rng++
+		}
+	}
+	if hash > 1 {
+		return fmt.Errorf("more than one HASH key")
+	}
+	if rng > 1 {
+		return fmt.Errorf("more than one RANGE key")
 	}
+	if rng > 0 && hash == 0 {
+		return fmt.Errorf("RANGE key without HASH key")
+	}
+	return nil
+}
 
+// createInput returns the input to create the table with.
+func (p *DynamoDBTable) createInput() *dynamodb.CreateTableInput {
 	input := &dynamodb.CreateTableInput{}
 
 	input.AttributeDefinitions = make([]dynamodb.AttributeDefinition, len(p.Attributes))
@@ -465,14 +544,7 @@ func (p *DynamoDBTable) Create(ctx context.Context, r *resource.CreateRequest) e
 		}
 	}
 
-	if p.SSE != nil {
-		input.SSESpecification = &dynamodb.SSESpecification{
-			Enabled:        p.SSE.Enabled,
-			KMSMasterKeyId: p.SSE.KMSMasterKeyID,
-			SSEType:        dynamodb.SSETypeKms, // Only one supported
-			// SSEType:        dynamodb.SSEType(p.SSE.Type),
-		}
-	}
+	input.SSESpecification = p.sseSpecification()
 	if p.Stream != nil {
 		input.StreamSpecification = &dynamodb.StreamSpecification{
 			StreamEnabled:  p.Stream.Enabled,
@@ -480,7 +552,7 @@ func (p *DynamoDBTable) Create(ctx context.Context, r *resource.CreateRequest) e
 		}
 	}
 
-	input.TableName = aws.String(p.TableName)
+	input.TableName = p.TableName
 
 	if len(p.Tags) > 0 {
 		input.Tags = make([]dynamodb.Tag, len(p.Tags))
@@ -492,13 +564,31 @@ func (p *DynamoDBTable) Create(ctx context.Context, r *resource.CreateRequest) e
 		}
 	}
 
-	if err := input.Validate(); err != nil {
+	return input
+}
+
+// DefaultName sets the table name to a name derived from the address of the
+// resource, if it was not set.
+func (p *DynamoDBTable) DefaultName(addr string) {
+	if p.TableName == nil {
+		p.TableName = aws.String(defaultName(addr, 255))
+	}
+}
+
+// Create creates a new DynamoDB table.
+func (p *DynamoDBTable) Create(ctx context.Context, r *resource.CreateRequest) error {
+	if err := p.Validate(); err != nil {
 		return backoff.Permanent(err)
 	}
 
-	resp, err := svc.CreateTableRequest(input).Send(ctx)
+	svc, err := base.DynamoDB(p, r.Auth)
+	if err != nil {
+		return err
+	}
+
+	resp, err := svc.CreateTableRequest(p.createInput()).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	desc := resp.CreateTableOutput.TableDescription
@@ -509,33 +599,91 @@ func (p *DynamoDBTable) Create(ctx context.Context, r *resource.CreateRequest) e
 	return nil
 }
 
+// WaitReady checks that the table and its global secondary indexes are
+// active. A table cannot be used while it is being created or updated.
+func (p *DynamoDBTable) WaitReady(ctx context.Context, r *resource.WaitRequest) error {
+	svc, err := base.DynamoDB(p, r.Auth)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.DescribeTableInput{
+		TableName: p.TableName,
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+
+	resp, err := svc.DescribeTableRequest(input).Send(ctx)
+	if err != nil {
+		return err
+	}
+
+	desc := resp.DescribeTableOutput.Table
+	if desc.TableStatus != dynamodb.TableStatusActive {
+		return fmt.Errorf("table is %s", desc.TableStatus)
+	}
+	for _, gsi := range desc.GlobalSecondaryIndexes {
+		if gsi.IndexStatus != dynamodb.IndexStatusActive {
+			return fmt.Errorf("index %s is %s", *gsi.IndexName, gsi.IndexStatus)
+		}
+	}
+
+	return nil
+}
+
 // Delete deletes the DynamoDB table.
 func (p *DynamoDBTable) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.DynamoDB(p, r.Auth)
 	if err != nil {
 		return err
 	}
 
 	input := &dynamodb.DeleteTableInput{
-		TableName: aws.String(p.TableName),
+		TableName: p.TableName,
 	}
 	if err := input.Validate(); err != nil {
 		return backoff.Permanent(err)
 	}
 
 	_, err = svc.DeleteTableRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
 // Update updates the DynamoDB table.
 func (p *DynamoDBTable) Update(ctx context.Context, r *resource.UpdateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	if err := p.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+
+	svc, err := base.DynamoDB(p, r.Auth)
 	if err != nil {
 		return err
 	}
 
 	prev := r.Previous.(*DynamoDBTable)
+	p.CreatedTime = prev.CreatedTime
+	p.TableARN = prev.TableARN
+	p.TableID = prev.TableID
+
+	input, err := p.updateInput(prev)
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
 
+	if _, err := svc.UpdateTableRequest(input).Send(ctx); err != nil {
+		return base.Classify(err)
+	}
+
+	return p.updateTags(ctx, svc, prev.tagMap())
+}
+
+// updateInput returns the input for updating the table from prev.
+func (p *DynamoDBTable) updateInput(prev *DynamoDBTable) (*dynamodb.UpdateTableInput, error) {
 	input := &dynamodb.UpdateTableInput{}
 
 	input.AttributeDefinitions = make([]dynamodb.AttributeDefinition, len(p.Attributes))
@@ -550,7 +698,7 @@ func (p *DynamoDBTable) Update(ctx context.Context, r *resource.UpdateRequest) e
 
 	if !cmp.Equal(p.GlobalSecondaryIndexes, prev.GlobalSecondaryIndexes) {
 		// TODO: Compute GSI diff
-		return backoff.Permanent(fmt.Errorf("updating global secondary indexes is not yet supported"))
+		return nil, fmt.Errorf("updating global secondary indexes is not yet supported")
 	}
 
 	if p.ProvisionedThroughput != nil {
@@ -560,13 +708,9 @@ func (p *DynamoDBTable) Update(ctx context.Context, r *resource.UpdateRequest) e
 		}
 	}
 
-	if p.SSE != nil {
-		input.SSESpecification = &dynamodb.SSESpecification{
-			Enabled:        p.SSE.Enabled,
-			KMSMasterKeyId: p.SSE.KMSMasterKeyID,
-			SSEType:        dynamodb.SSETypeKms, // Only one supported
-			// SSEType:        dynamodb.SSEType(p.SSE.Type),
-		}
+	// Setting the same encryption again is rejected.
+	if !cmp.Equal(p.SSE, prev.SSE) {
+		input.SSESpecification = p.sseSpecification()
 	}
 	if p.Stream != nil {
 		input.StreamSpecification = &dynamodb.StreamSpecification{
@@ -577,16 +721,64 @@ func (p *DynamoDBTable) Update(ctx context.Context, r *resource.UpdateRequest) e
 
 	input.TableName = aws.String(prev.TableName)
 
-	if err := input.Validate(); err != nil {
-		return backoff.Permanent(err)
+	return input, nil
+}
+
+// sseSpecification returns the server-side encryption settings to send, or
+// nil if the sse block is not set. The KMS type and key are only set if
+// encryption is enabled; when disabled, an AWS owned CMK is used.
+func (p *DynamoDBTable) sseSpecification() *dynamodb.SSESpecification {
+	if p.SSE == nil {
+		return nil
+	}
+	if p.SSE.Enabled == nil || !*p.SSE.Enabled {
+		return &dynamodb.SSESpecification{Enabled: aws.Bool(false)}
 	}
+	return &dynamodb.SSESpecification{
+		Enabled:        aws.Bool(true),
+		KMSMasterKeyId: p.SSE.KMSMasterKeyID,
+		SSEType:        dynamodb.SSETypeKms, // Only one supported
+	}
+}
 
-	resp, err := svc.UpdateTableRequest(input).Send(ctx)
-	if err != nil {
-		return handlePutError(err)
+func (p *DynamoDBTable) updateTags(ctx context.Context, svc dynamodbiface.ClientAPI, prev map[string]string) error {
+	diff := tags.Compare(prev, p.tagMap())
+	if len(diff.Remove) > 0 {
+		input := &dynamodb.UntagResourceInput{
+			ResourceArn: aws.String(p.TableARN),
+			TagKeys:     diff.Remove,
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		if _, err := svc.UntagResourceRequest(input).Send(ctx); err != nil {
+			return base.Classify(err)
+		}
 	}
 
-	_ = resp
+	set := diff.Set()
+	if len(set) == 0 {
+		return nil
+	}
+	input := &dynamodb.TagResourceInput{
+		ResourceArn: aws.String(p.TableARN),
+	}
+	tags.Convert(set, &input.Tags, "Key", "Value")
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err := svc.TagResourceRequest(input).Send(ctx)
+	return base.Classify(err)
+}
 
-	return nil
+// tagMap returns the tags on the table as a map.
+func (p *DynamoDBTable) tagMap() map[string]string {
+	if len(p.Tags) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(p.Tags))
+	for _, t := range p.Tags {
+		m[t.Key] = t.Value
+	}
+	return m
 }
@@ -0,0 +1,373 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/defaults"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/func/func/resource"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDynamoDBTable_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   string // JSON encoded DynamoDBTable
+		wantErr string // Substring of error
+	}{
+		{
+			name: "Hash",
+			table: `{
+				"TableName": "table",
+				"Attributes": [{"Name": "id", "Type": "S"}],
+				"KeySchema": [{"Name": "id", "Type": "HASH"}]
+			}`,
+		},
+		{
+			name: "Composite",
+			table: `{
+				"TableName": "table",
+				"Attributes": [{"Name": "id", "Type": "S"}],
+				"KeySchema": [{"Name": "id", "Type": "HASH"}, {"Name": "ts", "Type": "RANGE"}],
+				"GlobalSecondaryIndexes": [{
+					"Name": "gsi",
+					"KeySchema": [{"Name": "user", "Type": "HASH"}, {"Name": "ts", "Type": "RANGE"}]
+				}],
+				"LocalSecondaryIndexes": [{
+					"Name": "lsi",
+					"KeySchema": [{"Name": "id", "Type": "HASH"}, {"Name": "score", "Type": "RANGE"}]
+				}]
+			}`,
+		},
+		{
+			name: "TwoHash",
+			table: `{
+				"TableName": "table",
+				"Attributes": [{"Name": "id", "Type": "S"}],
+				"KeySchema": [{"Name": "id", "Type": "HASH"}, {"Name": "ts", "Type": "HASH"}]
+			}`,
+			wantErr: "key_schema: more than one HASH key",
+		},
+		{
+			name: "RangeWithoutHash",
+			table: `{
+				"TableName": "table",
+				"Attributes": [{"Name": "id", "Type": "S"}],
+				"KeySchema": [{"Name": "ts", "Type": "RANGE"}]
+			}`,
+			wantErr: "key_schema: RANGE key without HASH key",
+		},
+		{
+			name: "DuplicateKey",
+			table: `{
+				"TableName": "table",
+				"Attributes": [{"Name": "id", "Type": "S"}],
+				"KeySchema": [{"Name": "id", "Type": "HASH"}, {"Name": "id", "Type": "RANGE"}]
+			}`,
+			wantErr: `key_schema: duplicate key attribute "id"`,
+		},
+		{
+			name: "InvalidInput",
+			table: `{
+				"TableName": "ab",
+				"Attributes": [{"Name": "id", "Type": "S"}],
+				"KeySchema": [{"Name": "id", "Type": "HASH"}]
+			}`,
+			wantErr: "CreateTableInput.TableName",
+		},
+		{
+			name: "GSITwoHash",
+			table: `{
+				"TableName": "table",
+				"Attributes": [{"Name": "id", "Type": "S"}],
+				"KeySchema": [{"Name": "id", "Type": "HASH"}],
+				"GlobalSecondaryIndexes": [{
+					"Name": "gsi",
+					"KeySchema": [{"Name": "a", "Type": "HASH"}, {"Name": "b", "Type": "HASH"}]
+				}]
+			}`,
+			wantErr: `global_secondary_index "gsi": key_schema: more than one HASH key`,
+		},
+		{
+			name: "LSIRangeWithoutHash",
+			table: `{
+				"TableName": "table",
+				"Attributes": [{"Name": "id", "Type": "S"}],
+				"KeySchema": [{"Name": "id", "Type": "HASH"}],
+				"LocalSecondaryIndexes": [{
+					"Name": "lsi",
+					"KeySchema": [{"Name": "score", "Type": "RANGE"}]
+				}]
+			}`,
+			wantErr: `local_secondary_index "lsi": key_schema: RANGE key without HASH key`,
+		},
+		{
+			name: "DuplicateIndexName",
+			table: `{
+				"TableName": "table",
+				"Attributes": [{"Name": "id", "Type": "S"}],
+				"KeySchema": [{"Name": "id", "Type": "HASH"}],
+				"GlobalSecondaryIndexes": [{
+					"Name": "idx",
+					"KeySchema": [{"Name": "user", "Type": "HASH"}]
+				}],
+				"LocalSecondaryIndexes": [{
+					"Name": "idx",
+					"KeySchema": [{"Name": "id", "Type": "HASH"}, {"Name": "score", "Type": "RANGE"}]
+				}]
+			}`,
+			wantErr: `duplicate index name "idx"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var table DynamoDBTable
+			if err := json.Unmarshal([]byte(tt.table), &table); err != nil {
+				t.Fatal(err)
+			}
+			err := table.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() err = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() err = nil, want %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() err = %q, want %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDynamoDBTable_sseSpecification(t *testing.T) {
+	enabled := &dynamodb.SSESpecification{
+		Enabled:        aws.Bool(true),
+		KMSMasterKeyId: aws.String("alias/key"),
+		SSEType:        dynamodb.SSETypeKms,
+	}
+	disabled := &dynamodb.SSESpecification{Enabled: aws.Bool(false)}
+	ignoreSSE := cmpopts.IgnoreUnexported(dynamodb.SSESpecification{})
+
+	tests := []struct {
+		name       string
+		prev, sse  string // JSON encoded SSE blocks
+		wantCreate *dynamodb.SSESpecification
+		wantUpdate *dynamodb.SSESpecification
+	}{
+		{
+			name:       "Omitted",
+			prev:       `null`,
+			sse:        `null`,
+			wantCreate: nil,
+			wantUpdate: nil,
+		},
+		{
+			name:       "Enabled",
+			prev:       `null`,
+			sse:        `{"Enabled": true, "KMSMasterKeyID": "alias/key"}`,
+			wantCreate: enabled,
+			wantUpdate: enabled,
+		},
+		{
+			name:       "Disabled",
+			prev:       `{"Enabled": true, "KMSMasterKeyID": "alias/key"}`,
+			sse:        `{"Enabled": false, "KMSMasterKeyID": "alias/key"}`,
+			wantCreate: disabled,
+			wantUpdate: disabled,
+		},
+		{
+			name:       "Removed",
+			prev:       `{"Enabled": true, "KMSMasterKeyID": "alias/key"}`,
+			sse:        `null`,
+			wantCreate: nil,
+			wantUpdate: nil, // Encryption is not changed
+		},
+		{
+			name:       "RemovedDisabled",
+			prev:       `{"Enabled": false}`,
+			sse:        `null`,
+			wantCreate: nil,
+			wantUpdate: nil,
+		},
+		{
+			name:       "EnabledNotSet",
+			prev:       `null`,
+			sse:        `{}`,
+			wantCreate: disabled,
+			wantUpdate: disabled,
+		},
+		{
+			name:       "Unchanged",
+			prev:       `{"Enabled": true, "KMSMasterKeyID": "alias/key"}`,
+			sse:        `{"Enabled": true, "KMSMasterKeyID": "alias/key"}`,
+			wantCreate: enabled,
+			wantUpdate: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := func(sse string) *DynamoDBTable {
+				var table DynamoDBTable
+				if err := json.Unmarshal([]byte(`{"TableName": "table", "SSE": `+sse+`}`), &table); err != nil {
+					t.Fatal(err)
+				}
+				return &table
+			}
+			p := table(tt.sse)
+
+			if diff := cmp.Diff(p.createInput().SSESpecification, tt.wantCreate, ignoreSSE); diff != "" {
+				t.Errorf("Create SSESpecification (-got +want)\n%s", diff)
+			}
+
+			input, err := p.updateInput(table(tt.prev))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(input.SSESpecification, tt.wantUpdate, ignoreSSE); diff != "" {
+				t.Errorf("Update SSESpecification (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDynamoDBTable_Create(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target := r.Header.Get("X-Amz-Target"); target != "DynamoDB_20120810.CreateTable" {
+			t.Errorf("Unexpected target %q", target)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Decode body: %v", err)
+		}
+		fmt.Fprint(w, `{"TableDescription":{"TableArn":"arn:table","TableId":"id","CreationDateTime":1546300800}}`)
+	}))
+	defer srv.Close()
+
+	p := dynamoDBTable(t, `{
+		"TableName": "table",
+		"Attributes": [{"Name": "id", "Type": "S"}],
+		"KeySchema": [{"Name": "id", "Type": "HASH"}],
+		"SSE": {"Enabled": true, "KMSMasterKeyID": "alias/key"}
+	}`)
+	p.SetClient(dynamoDBClient(srv.URL))
+
+	if err := p.Create(context.Background(), &resource.CreateRequest{}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if got["TableName"] != "table" {
+		t.Errorf("TableName = %v, want %q", got["TableName"], "table")
+	}
+	wantSSE := map[string]interface{}{"Enabled": true, "KMSMasterKeyId": "alias/key", "SSEType": "KMS"}
+	if diff := cmp.Diff(got["SSESpecification"], wantSSE); diff != "" {
+		t.Errorf("SSESpecification (-got +want)\n%s", diff)
+	}
+	if p.TableARN != "arn:table" {
+		t.Errorf("TableARN = %q, want %q", p.TableARN, "arn:table")
+	}
+	if p.TableID != "id" {
+		t.Errorf("TableID = %q, want %q", p.TableID, "id")
+	}
+	if want := "2019-01-01T00:00:00Z"; p.CreatedTime != want {
+		t.Errorf("CreatedTime = %q, want %q", p.CreatedTime, want)
+	}
+}
+
+func TestDynamoDBTable_Update(t *testing.T) {
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.")
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Decode body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch action {
+		case "UpdateTable":
+			// The sse block was removed, the encryption is not changed.
+			if sse, ok := body["SSESpecification"]; ok {
+				t.Errorf("SSESpecification = %v, want omitted", sse)
+			}
+			calls = append(calls, fmt.Sprintf("%s %v", action, body["BillingMode"]))
+			fmt.Fprint(w, `{"TableDescription":{}}`)
+		case "UntagResource":
+			calls = append(calls, fmt.Sprintf("%s %v", action, body["TagKeys"]))
+			fmt.Fprint(w, `{}`)
+		case "TagResource":
+			calls = append(calls, fmt.Sprintf("%s %v", action, body["Tags"]))
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("Unexpected action %q", action)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	prev := dynamoDBTable(t, `{
+		"TableName": "table",
+		"Attributes": [{"Name": "id", "Type": "S"}],
+		"KeySchema": [{"Name": "id", "Type": "HASH"}],
+		"BillingMode": "PROVISIONED",
+		"SSE": {"Enabled": true},
+		"Tags": [{"Key": "a", "Value": "1"}],
+		"CreatedTime": "2019-01-01T00:00:00Z",
+		"TableARN": "arn:table",
+		"TableID": "id"
+	}`)
+	p := dynamoDBTable(t, `{
+		"TableName": "table",
+		"Attributes": [{"Name": "id", "Type": "S"}],
+		"KeySchema": [{"Name": "id", "Type": "HASH"}],
+		"BillingMode": "PAY_PER_REQUEST",
+		"Tags": [{"Key": "b", "Value": "2"}]
+	}`)
+	p.SetClient(dynamoDBClient(srv.URL))
+
+	err := p.Update(context.Background(), &resource.UpdateRequest{Previous: prev, ConfigChanged: true})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	want := []string{
+		"UpdateTable PAY_PER_REQUEST",
+		"UntagResource [a]",
+		"TagResource [map[Key:b Value:2]]",
+	}
+	if diff := cmp.Diff(calls, want); diff != "" {
+		t.Errorf("Calls (-got +want)\n%s", diff)
+	}
+	if p.TableARN != "arn:table" {
+		t.Errorf("TableARN = %q, want %q", p.TableARN, "arn:table")
+	}
+}
+
+func dynamoDBTable(t *testing.T, data string) *DynamoDBTable {
+	t.Helper()
+	var table DynamoDBTable
+	if err := json.Unmarshal([]byte(data), &table); err != nil {
+		t.Fatal(err)
+	}
+	return &table
+}
+
+func dynamoDBClient(endpoint string) *dynamodb.Client {
+	cfg := defaults.Config()
+	cfg.Region = "us-east-1"
+	cfg.Credentials = aws.NewStaticCredentialsProvider("key", "secret", "")
+	cfg.EndpointResolver = aws.ResolveWithEndpointURL(endpoint)
+	return dynamodb.New(cfg)
+}
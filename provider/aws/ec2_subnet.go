@@ -0,0 +1,145 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+)
+
+// EC2Subnet creates a subnet, a range of IP addresses in a VPC. The region
+// must match the region of the VPC.
+//
+// https://docs.aws.amazon.com/vpc/latest/userguide/VPC_Subnets.html
+type EC2Subnet struct {
+	// Inputs
+
+	// The Availability Zone for the subnet, such as us-east-1a. If not set,
+	// AWS selects one.
+	//
+	// Changing the Availability Zone creates a new subnet.
+	AvailabilityZone *string `func:"input,force_new"`
+
+	// The IPv4 network range for the subnet, in CIDR notation. The range must
+	// be within the CIDR block of the VPC. For example, 10.0.1.0/24.
+	//
+	// Changing the CIDR block creates a new subnet.
+	CidrBlock string `func:"input,force_new" validate:"aws_cidr"`
+
+	base.Resource
+
+	// Tags to attach to the subnet.
+	Tags map[string]string `func:"input"`
+
+	// The ID of the VPC to create the subnet in.
+	//
+	// Changing the VPC creates a new subnet.
+	VPCID string `func:"input,force_new" name:"vpc_id"`
+
+	// Outputs
+
+	// The ID of the subnet.
+	ID string `func:"output,id"`
+}
+
+// Create creates a new subnet.
+func (p *EC2Subnet) Create(ctx context.Context, r *resource.CreateRequest) error {
+	svc, err := base.EC2(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	if p.ID == "" {
+		input := &ec2.CreateSubnetInput{
+			AvailabilityZone: p.AvailabilityZone,
+			CidrBlock:        aws.String(p.CidrBlock),
+			VpcId:            aws.String(p.VPCID),
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+
+		resp, err := svc.CreateSubnetRequest(input).Send(ctx)
+		if err != nil {
+			return base.Classify(err)
+		}
+
+		// Set output immediately so a retry does not create another subnet.
+		p.ID = *resp.Subnet.SubnetId
+	}
+
+	if err := updateEC2Tags(ctx, svc, p.ID, nil, p.Tags); err != nil {
+		return errors.Wrap(err, "set tags")
+	}
+	return nil
+}
+
+// WaitReady waits for the subnet to become available.
+func (p *EC2Subnet) WaitReady(ctx context.Context, r *resource.WaitRequest) error {
+	svc, err := base.EC2(p, r.Auth)
+	if err != nil {
+		return err
+	}
+
+	resp, err := svc.DescribeSubnetsRequest(&ec2.DescribeSubnetsInput{
+		SubnetIds: []string{p.ID},
+	}).Send(ctx)
+	if err != nil {
+		return err
+	}
+	if len(resp.Subnets) == 0 {
+		return fmt.Errorf("subnet %s not found", p.ID)
+	}
+	if state := resp.Subnets[0].State; state != ec2.SubnetStateAvailable {
+		return fmt.Errorf("subnet is %s", state)
+	}
+	return nil
+}
+
+// Delete deletes the subnet. All instances and network interfaces in the
+// subnet must be deleted first.
+func (p *EC2Subnet) Delete(ctx context.Context, r *resource.DeleteRequest) error {
+	svc, err := base.EC2(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	input := &ec2.DeleteSubnetInput{
+		SubnetId: aws.String(p.ID),
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err = svc.DeleteSubnetRequest(input).Send(ctx)
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case "InvalidSubnetID.NotFound":
+			// Already deleted
+			return nil
+		case "DependencyViolation":
+			// Network interfaces in the subnet may still be being deleted.
+			return err
+		}
+	}
+	return base.DeleteError(err)
+}
+
+// Update updates the tags on the subnet. All other changes require a new
+// subnet.
+func (p *EC2Subnet) Update(ctx context.Context, r *resource.UpdateRequest) error {
+	svc, err := base.EC2(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	prev := r.Previous.(*EC2Subnet)
+	p.ID = prev.ID
+
+	return updateEC2Tags(ctx, svc, p.ID, prev.Tags, p.Tags)
+}
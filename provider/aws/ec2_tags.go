@@ -0,0 +1,47 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/ec2iface"
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
+	"github.com/func/func/provider/aws/internal/tags"
+)
+
+// updateEC2Tags updates the tags on an EC2 resource from prev to next.
+func updateEC2Tags(ctx context.Context, svc ec2iface.ClientAPI, id string, prev, next map[string]string) error {
+	diff := tags.Compare(prev, next)
+	if len(diff.Remove) > 0 {
+		remove := make([]ec2.Tag, len(diff.Remove))
+		for i, k := range diff.Remove {
+			remove[i] = ec2.Tag{Key: aws.String(k)}
+		}
+		input := &ec2.DeleteTagsInput{
+			Resources: []string{id},
+			Tags:      remove,
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		if _, err := svc.DeleteTagsRequest(input).Send(ctx); err != nil {
+			return base.Classify(err)
+		}
+	}
+
+	set := diff.Set()
+	if len(set) == 0 {
+		return nil
+	}
+	input := &ec2.CreateTagsInput{
+		Resources: []string{id},
+	}
+	tags.Convert(set, &input.Tags, "Key", "Value")
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err := svc.CreateTagsRequest(input).Send(ctx)
+	return base.Classify(err)
+}
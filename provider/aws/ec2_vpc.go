@@ -0,0 +1,207 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/ec2iface"
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+)
+
+// EC2VPC creates a virtual private cloud (VPC), a logically isolated virtual
+// network.
+//
+// https://docs.aws.amazon.com/vpc/latest/userguide/what-is-amazon-vpc.html
+type EC2VPC struct {
+	// Inputs
+
+	// The IPv4 network range for the VPC, in CIDR notation. For example,
+	// 10.0.0.0/16.
+	//
+	// Changing the CIDR block creates a new VPC.
+	CidrBlock string `func:"input,force_new" validate:"aws_cidr"`
+
+	// Indicates whether the instances launched in the VPC get DNS hostnames.
+	// If not set, DNS hostnames are disabled.
+	EnableDNSHostnames *bool `func:"input" name:"enable_dns_hostnames"`
+
+	// Indicates whether DNS resolution is supported for the VPC. If not set,
+	// DNS resolution is enabled.
+	EnableDNSSupport *bool `func:"input" name:"enable_dns_support"`
+
+	base.Resource
+
+	// Tags to attach to the VPC.
+	Tags map[string]string `func:"input"`
+
+	// Outputs
+
+	// The ID of the VPC.
+	ID string `func:"output,id"`
+
+	// The ID of the main route table that was created with the VPC.
+	DefaultRouteTableID string `func:"output"`
+}
+
+// Create creates a new VPC.
+func (p *EC2VPC) Create(ctx context.Context, r *resource.CreateRequest) error {
+	svc, err := base.EC2(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	if p.ID == "" {
+		input := &ec2.CreateVpcInput{
+			CidrBlock: aws.String(p.CidrBlock),
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+
+		resp, err := svc.CreateVpcRequest(input).Send(ctx)
+		if err != nil {
+			return base.Classify(err)
+		}
+
+		// Set output immediately so a retry does not create another VPC.
+		p.ID = *resp.Vpc.VpcId
+	}
+
+	if err := updateEC2Tags(ctx, svc, p.ID, nil, p.Tags); err != nil {
+		return errors.Wrap(err, "set tags")
+	}
+	return p.updateAttributes(ctx, svc, &EC2VPC{})
+}
+
+// WaitReady waits for the VPC to become available and gets the ID of its
+// main route table.
+func (p *EC2VPC) WaitReady(ctx context.Context, r *resource.WaitRequest) error {
+	svc, err := base.EC2(p, r.Auth)
+	if err != nil {
+		return err
+	}
+
+	resp, err := svc.DescribeVpcsRequest(&ec2.DescribeVpcsInput{
+		VpcIds: []string{p.ID},
+	}).Send(ctx)
+	if err != nil {
+		return err
+	}
+	if len(resp.Vpcs) == 0 {
+		return fmt.Errorf("vpc %s not found", p.ID)
+	}
+	if state := resp.Vpcs[0].State; state != ec2.VpcStateAvailable {
+		return fmt.Errorf("vpc is %s", state)
+	}
+
+	tables, err := svc.DescribeRouteTablesRequest(&ec2.DescribeRouteTablesInput{
+		Filters: []ec2.Filter{
+			{Name: aws.String("vpc-id"), Values: []string{p.ID}},
+			{Name: aws.String("association.main"), Values: []string{"true"}},
+		},
+	}).Send(ctx)
+	if err != nil {
+		return err
+	}
+	if len(tables.RouteTables) == 0 {
+		return fmt.Errorf("main route table is not available")
+	}
+	p.DefaultRouteTableID = *tables.RouteTables[0].RouteTableId
+
+	return nil
+}
+
+// Delete deletes the VPC. All subnets and other resources in the VPC must be
+// deleted first.
+func (p *EC2VPC) Delete(ctx context.Context, r *resource.DeleteRequest) error {
+	svc, err := base.EC2(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	input := &ec2.DeleteVpcInput{
+		VpcId: aws.String(p.ID),
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err = svc.DeleteVpcRequest(input).Send(ctx)
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case "InvalidVpcID.NotFound":
+			// Already deleted
+			return nil
+		case "DependencyViolation":
+			// Resources in the VPC may still be being deleted.
+			return err
+		}
+	}
+	return base.DeleteError(err)
+}
+
+// Update updates the tags and DNS attributes of the VPC. Changing the CIDR
+// block requires a new VPC.
+func (p *EC2VPC) Update(ctx context.Context, r *resource.UpdateRequest) error {
+	svc, err := base.EC2(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	prev := r.Previous.(*EC2VPC)
+	p.ID = prev.ID
+	p.DefaultRouteTableID = prev.DefaultRouteTableID
+
+	if err := updateEC2Tags(ctx, svc, p.ID, prev.Tags, p.Tags); err != nil {
+		return errors.Wrap(err, "update tags")
+	}
+	return p.updateAttributes(ctx, svc, prev)
+}
+
+// updateAttributes sets the DNS attributes that changed from prev. Only one
+// attribute can be modified per request.
+func (p *EC2VPC) updateAttributes(ctx context.Context, svc ec2iface.ClientAPI, prev *EC2VPC) error {
+	// Defaults when the VPC is created.
+	support, prevSupport := true, true
+	hostnames, prevHostnames := false, false
+	if p.EnableDNSSupport != nil {
+		support = *p.EnableDNSSupport
+	}
+	if prev.EnableDNSSupport != nil {
+		prevSupport = *prev.EnableDNSSupport
+	}
+	if p.EnableDNSHostnames != nil {
+		hostnames = *p.EnableDNSHostnames
+	}
+	if prev.EnableDNSHostnames != nil {
+		prevHostnames = *prev.EnableDNSHostnames
+	}
+
+	var inputs []*ec2.ModifyVpcAttributeInput
+	if support != prevSupport {
+		inputs = append(inputs, &ec2.ModifyVpcAttributeInput{
+			VpcId:            aws.String(p.ID),
+			EnableDnsSupport: &ec2.AttributeBooleanValue{Value: aws.Bool(support)},
+		})
+	}
+	if hostnames != prevHostnames {
+		inputs = append(inputs, &ec2.ModifyVpcAttributeInput{
+			VpcId:              aws.String(p.ID),
+			EnableDnsHostnames: &ec2.AttributeBooleanValue{Value: aws.Bool(hostnames)},
+		})
+	}
+	for _, input := range inputs {
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		if _, err := svc.ModifyVpcAttributeRequest(input).Send(ctx); err != nil {
+			return base.Classify(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/ecr/ecriface"
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
+	"github.com/func/func/provider/aws/internal/tags"
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+)
+
+// ECRRepository creates a repository in Amazon Elastic Container Registry
+// (ECR).
+//
+// The repository stores container images, such as images for container based
+// Lambda functions or ECS tasks.
+//
+// https://aws.amazon.com/ecr/
+type ECRRepository struct {
+	// Inputs
+
+	// Delete the repository even if it still contains images. If not set,
+	// deleting a repository that contains images fails.
+	ForceDelete *bool `func:"input"`
+
+	// The name of the repository. The name can be used on its own, such as
+	// nginx-web-app, or it can be prepended with a namespace to group the
+	// repository into a category, such as project-a/nginx-web-app.
+	//
+	// Changing the name replaces the repository.
+	Name string `func:"input,force_new"`
+
+	base.ForceNewResource
+
+	// Tags to attach to the repository.
+	Tags map[string]string `func:"input"`
+
+	// Outputs
+
+	// The Amazon Resource Name (ARN) of the repository.
+	ARN string `func:"output"`
+
+	// The URL of the repository, in the form
+	// aws_account_id.dkr.ecr.region.amazonaws.com/name.
+	RepositoryURL string `func:"output"`
+}
+
+// Create creates a new ECR repository.
+func (p *ECRRepository) Create(ctx context.Context, r *resource.CreateRequest) error {
+	svc, err := base.ECR(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	input := &ecr.CreateRepositoryInput{
+		RepositoryName: aws.String(p.Name),
+	}
+	tags.Convert(p.Tags, &input.Tags, "Key", "Value")
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+
+	resp, err := svc.CreateRepositoryRequest(input).Send(ctx)
+	if err != nil {
+		return base.Classify(err)
+	}
+
+	p.ARN = *resp.Repository.RepositoryArn
+	p.RepositoryURL = *resp.Repository.RepositoryUri
+
+	return nil
+}
+
+// Delete deletes the ECR repository. If force_delete is set, images in the
+// repository are deleted too.
+func (p *ECRRepository) Delete(ctx context.Context, r *resource.DeleteRequest) error {
+	svc, err := base.ECR(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	input := &ecr.DeleteRepositoryInput{
+		RepositoryName: aws.String(p.Name),
+		Force:          p.ForceDelete,
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err = svc.DeleteRepositoryRequest(input).Send(ctx)
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case ecr.ErrCodeRepositoryNotFoundException:
+			// Already deleted
+			return nil
+		case ecr.ErrCodeRepositoryNotEmptyException:
+			// Retrying does not help, images must be removed first.
+			return backoff.Permanent(errors.Wrap(err, "set force_delete to delete images"))
+		}
+	}
+	return base.DeleteError(err)
+}
+
+// Update updates the tags of the ECR repository.
+func (p *ECRRepository) Update(ctx context.Context, r *resource.UpdateRequest) error {
+	svc, err := base.ECR(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	prev := r.Previous.(*ECRRepository)
+	p.ARN = prev.ARN
+	p.RepositoryURL = prev.RepositoryURL
+
+	return p.updateTags(ctx, svc, prev.Tags)
+}
+
+func (p *ECRRepository) updateTags(ctx context.Context, svc ecriface.ClientAPI, prev map[string]string) error {
+	diff := tags.Compare(prev, p.Tags)
+	if len(diff.Remove) > 0 {
+		input := &ecr.UntagResourceInput{
+			ResourceArn: aws.String(p.ARN),
+			TagKeys:     diff.Remove,
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		if _, err := svc.UntagResourceRequest(input).Send(ctx); err != nil {
+			return base.Classify(err)
+		}
+	}
+
+	set := diff.Set()
+	if len(set) == 0 {
+		return nil
+	}
+	input := &ecr.TagResourceInput{
+		ResourceArn: aws.String(p.ARN),
+	}
+	tags.Convert(set, &input.Tags, "Key", "Value")
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err := svc.TagResourceRequest(input).Send(ctx)
+	return base.Classify(err)
+}
@@ -0,0 +1,63 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/func/func/provider/aws/internal/tags"
+	"github.com/func/func/resource"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestECRRepository_fields(t *testing.T) {
+	fields := resource.Fields(reflect.TypeOf(ECRRepository{}))
+
+	got := fields.CtyType()
+	want := cty.Object(map[string]cty.Type{
+		"force_delete":   cty.Bool,
+		"name":           cty.String,
+		"region":         cty.String,
+		"tags":           cty.Map(cty.String),
+		"arn":            cty.String,
+		"repository_url": cty.String,
+	})
+	if !got.Equals(want) {
+		t.Errorf("CtyType()\nGot  %#v\nWant %#v", got, want)
+	}
+
+	var inputs, outputs []string
+	for name := range fields.Inputs() {
+		inputs = append(inputs, name)
+	}
+	for name := range fields.Outputs() {
+		outputs = append(outputs, name)
+	}
+	if len(inputs) != 4 {
+		t.Errorf("Got %d inputs, want 4: %v", len(inputs), inputs)
+	}
+	if len(outputs) != 2 {
+		t.Errorf("Got %d outputs, want 2: %v", len(outputs), outputs)
+	}
+	if !fields["name"].ForceNew() {
+		t.Errorf("Name does not force new")
+	}
+	if !fields["region"].ForceNew() {
+		t.Errorf("Region does not force new")
+	}
+}
+
+func TestECRTags(t *testing.T) {
+	var got []ecr.Tag
+	tags.Convert(map[string]string{"b": "2", "a": "1"}, &got, "Key", "Value")
+	want := []ecr.Tag{
+		{Key: aws.String("a"), Value: aws.String("1")},
+		{Key: aws.String("b"), Value: aws.String("2")},
+	}
+	if diff := cmp.Diff(got, want, cmpopts.IgnoreUnexported(ecr.Tag{})); diff != "" {
+		t.Errorf("Convert() (-got, +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,124 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchevents"
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+)
+
+// EventBridgeRule manages an Amazon EventBridge (CloudWatch Events) rule.
+//
+// A rule matches incoming events or triggers on a schedule, and routes them
+// to targets for processing. Either a schedule expression or an event pattern
+// must be set, but not both.
+type EventBridgeRule struct {
+	// Inputs
+
+	// A description of the rule.
+	Description *string `func:"input"`
+
+	// The event pattern to match events against.
+	//
+	// For more information, see
+	// [Events and Event Patterns](https://docs.aws.amazon.com/eventbridge/latest/userguide/eventbridge-and-event-patterns.html)
+	// in the Amazon EventBridge User Guide.
+	EventPattern *string `func:"input"`
+
+	// The name of the rule. Changing the name replaces the rule.
+	Name string `func:"input,force_new"`
+
+	base.Resource
+
+	// The scheduling expression. For example, `cron(0 20 * * ? *)` or
+	// `rate(5 minutes)`.
+	ScheduleExpression *string `func:"input"`
+
+	// Indicates whether the rule is enabled or disabled.
+	//
+	// If not set, the rule is enabled.
+	State *string `func:"input" validate:"oneof=ENABLED DISABLED"`
+
+	// Outputs
+
+	// The Amazon Resource Name (ARN) of the rule.
+	ARN string `func:"output"`
+}
+
+// Validate checks that exactly one of the schedule expression or the event
+// pattern is set.
+func (p *EventBridgeRule) Validate() error {
+	if p.ScheduleExpression != nil && p.EventPattern != nil {
+		return errors.New("schedule_expression and event_pattern are mutually exclusive")
+	}
+	if p.ScheduleExpression == nil && p.EventPattern == nil {
+		return errors.New("one of schedule_expression or event_pattern is required")
+	}
+	return nil
+}
+
+// Create creates a new rule.
+func (p *EventBridgeRule) Create(ctx context.Context, r *resource.CreateRequest) error {
+	return p.put(ctx, r.Auth)
+}
+
+// Update updates the rule.
+func (p *EventBridgeRule) Update(ctx context.Context, r *resource.UpdateRequest) error {
+	return p.put(ctx, r.Auth)
+}
+
+// put creates or updates the rule.
+func (p *EventBridgeRule) put(ctx context.Context, auth resource.AuthProvider) error {
+	if err := p.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+
+	svc, err := base.CloudWatchEvents(p, auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	input := &cloudwatchevents.PutRuleInput{
+		Description:        p.Description,
+		EventPattern:       p.EventPattern,
+		Name:               aws.String(p.Name),
+		ScheduleExpression: p.ScheduleExpression,
+	}
+	if p.State != nil {
+		input.State = cloudwatchevents.RuleState(*p.State)
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+
+	resp, err := svc.PutRuleRequest(input).Send(ctx)
+	if err != nil {
+		return base.Classify(err)
+	}
+
+	p.ARN = *resp.PutRuleOutput.RuleArn
+
+	return nil
+}
+
+// Delete removes the rule.
+func (p *EventBridgeRule) Delete(ctx context.Context, r *resource.DeleteRequest) error {
+	svc, err := base.CloudWatchEvents(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	input := &cloudwatchevents.DeleteRuleInput{
+		Name: aws.String(p.Name),
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+
+	_, err = svc.DeleteRuleRequest(input).Send(ctx)
+	return base.DeleteError(err)
+}
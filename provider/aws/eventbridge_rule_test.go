@@ -0,0 +1,34 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestEventBridgeRule_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule *string
+		pattern  *string
+		valid    bool
+	}{
+		{"Schedule", aws.String("rate(5 minutes)"), nil, true},
+		{"Pattern", nil, aws.String(`{"source":["aws.ec2"]}`), true},
+		{"Both", aws.String("rate(5 minutes)"), aws.String(`{"source":["aws.ec2"]}`), false},
+		{"Neither", nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &EventBridgeRule{
+				Name:               "test",
+				ScheduleExpression: tt.schedule,
+				EventPattern:       tt.pattern,
+			}
+			err := rule.Validate()
+			if (err == nil) != tt.valid {
+				t.Errorf("got err = %v, want err = %t", err, !tt.valid)
+			}
+		})
+	}
+}
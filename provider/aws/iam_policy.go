@@ -2,15 +2,21 @@ package aws
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/iamiface"
 	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 	"github.com/pkg/errors"
 )
 
+// policyVersionLimit is the maximum number of versions a policy can have.
+const policyVersionLimit = 5
+
 // IAMPolicy describes a policy.
 //
 // The policy can be attached to a role using `aws_iam_role_policy_attachment`.
@@ -33,20 +39,23 @@ type IAMPolicy struct {
 	// in the IAM User Guide.
 	//
 	// If the path is not set, it defaults to a slash (`/`).
+	//
+	// The path cannot be changed.
 	Path *string `func:"input"`
 
 	// The JSON policy document that you want to use as the content for the new
 	// policy.
+	//
+	// Changing the document creates a new default version of the policy. The
+	// oldest versions are deleted to stay within the limit of five versions.
 	PolicyDocument string `func:"input"`
 
 	// The friendly name of the policy.
+	//
+	// The name cannot be changed.
 	PolicyName string `func:"input"`
 
-	// Region to use for IAM API calls.
-	//
-	// IAM is global so the calls are not regional but the Region will specify
-	// which region the API calls are sent to.
-	Region *string `func:"input"`
+	base.GlobalResource
 
 	// Outputs
 
@@ -93,13 +102,11 @@ type IAMPolicy struct {
 	// field contains the date and time when the most recent policy version was
 	// created.
 	UpdateDate string `func:"output"`
-
-	iamService
 }
 
 // Create creates a new IAM policy.
 func (p *IAMPolicy) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.IAM(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -116,7 +123,7 @@ func (p *IAMPolicy) Create(ctx context.Context, r *resource.CreateRequest) error
 
 	resp, err := svc.CreatePolicyRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.ARN = resp.Policy.Arn
@@ -133,11 +140,16 @@ func (p *IAMPolicy) Create(ctx context.Context, r *resource.CreateRequest) error
 
 // Delete deletes the IAM policy.
 func (p *IAMPolicy) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.IAM(p, r.Auth)
 	if err != nil {
 		return err
 	}
 
+	// All versions except the default version must be deleted first.
+	if err := p.deleteVersions(ctx, svc, 1); err != nil {
+		return err
+	}
+
 	input := &iam.DeletePolicyInput{
 		PolicyArn: p.ARN,
 	}
@@ -146,13 +158,100 @@ func (p *IAMPolicy) Delete(ctx context.Context, r *resource.DeleteRequest) error
 	}
 
 	_, err = svc.DeletePolicyRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
-// Update returns an error. A policy cannot be updated.
+// Update updates the policy document by creating a new default version of the
+// policy.
+//
+// The policy is not replaced, as a policy cannot be deleted while it is
+// attached. Changing the name, path or description returns an error.
 func (p *IAMPolicy) Update(ctx context.Context, r *resource.UpdateRequest) error {
-	// NOTE: We could delete and create the policy. However this may cause
-	// problems in case the policy is attached somewhere. For now, return an
-	// error instead.
-	return backoff.Permanent(errors.New("policy cannot be updated"))
+	prev := r.Previous.(*IAMPolicy)
+
+	if p.PolicyName != prev.PolicyName ||
+		aws.StringValue(p.Path) != aws.StringValue(prev.Path) ||
+		aws.StringValue(p.Description) != aws.StringValue(prev.Description) {
+		return backoff.Permanent(errors.New("policy name, path and description cannot be updated"))
+	}
+	if p.PolicyDocument == prev.PolicyDocument {
+		return nil
+	}
+
+	svc, err := base.IAM(p, r.Auth)
+	if err != nil {
+		return err
+	}
+
+	// Make room for the new version.
+	if err := p.deleteVersions(ctx, svc, policyVersionLimit-1); err != nil {
+		return err
+	}
+
+	input := &iam.CreatePolicyVersionInput{
+		PolicyArn:      p.ARN,
+		PolicyDocument: aws.String(p.PolicyDocument),
+		SetAsDefault:   aws.Bool(true),
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+
+	resp, err := svc.CreatePolicyVersionRequest(input).Send(ctx)
+	if err != nil {
+		return base.Classify(err)
+	}
+
+	p.DefaultVersionID = resp.PolicyVersion.VersionId
+	if resp.PolicyVersion.CreateDate != nil {
+		p.UpdateDate = resp.PolicyVersion.CreateDate.Format(time.RFC3339)
+	}
+
+	return nil
+}
+
+// deleteVersions deletes the oldest versions of the policy until at most keep
+// versions remain. The default version is never deleted.
+func (p *IAMPolicy) deleteVersions(ctx context.Context, svc iamiface.ClientAPI, keep int) error {
+	var versions []iam.PolicyVersion
+	input := &iam.ListPolicyVersionsInput{
+		PolicyArn: p.ARN,
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	pager := iam.NewListPolicyVersionsPaginator(svc.ListPolicyVersionsRequest(input))
+	for pager.Next(ctx) {
+		versions = append(versions, pager.CurrentPage().Versions...)
+	}
+	if err := pager.Err(); err != nil {
+		return base.DeleteError(err)
+	}
+
+	n := len(versions)
+	if n <= keep {
+		return nil
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		a, b := versions[i].CreateDate, versions[j].CreateDate
+		return a != nil && b != nil && a.Before(*b)
+	})
+	for _, v := range versions {
+		if n <= keep {
+			break
+		}
+		if aws.BoolValue(v.IsDefaultVersion) {
+			continue
+		}
+		_, err := svc.DeletePolicyVersionRequest(&iam.DeletePolicyVersionInput{
+			PolicyArn: p.ARN,
+			VersionId: v.VersionId,
+		}).Send(ctx)
+		if err := base.DeleteError(err); err != nil {
+			return err
+		}
+		n--
+	}
+	return nil
 }
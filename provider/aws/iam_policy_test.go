@@ -0,0 +1,121 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/defaults"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/func/func/resource"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIAMPolicy_fields(t *testing.T) {
+	fields := resource.Fields(reflect.TypeOf(IAMPolicy{}))
+
+	// Replacing a policy fails while it is attached.
+	for name, f := range fields.Inputs() {
+		if f.ForceNew() {
+			t.Errorf("Input %s forces new", name)
+		}
+	}
+}
+
+func TestIAMPolicy_Update(t *testing.T) {
+	// Five versions exist, v2 is the default version.
+	versions := []struct {
+		id, date string
+		def      bool
+	}{
+		{"v3", "2019-01-03T00:00:00Z", false},
+		{"v1", "2019-01-01T00:00:00Z", false},
+		{"v2", "2019-01-02T00:00:00Z", true},
+		{"v4", "2019-01-04T00:00:00Z", false},
+		{"v5", "2019-01-05T00:00:00Z", false},
+	}
+
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		action := r.Form.Get("Action")
+		switch action {
+		case "ListPolicyVersions":
+			calls = append(calls, action)
+			var members strings.Builder
+			for _, v := range versions {
+				fmt.Fprintf(&members, "<member><VersionId>%s</VersionId><IsDefaultVersion>%t</IsDefaultVersion><CreateDate>%s</CreateDate></member>", v.id, v.def, v.date) // nolint: lll
+			}
+			fmt.Fprintf(w, "<ListPolicyVersionsResponse><ListPolicyVersionsResult><IsTruncated>false</IsTruncated><Versions>%s</Versions></ListPolicyVersionsResult></ListPolicyVersionsResponse>", members.String()) // nolint: lll
+		case "DeletePolicyVersion":
+			calls = append(calls, action+" "+r.Form.Get("VersionId"))
+			fmt.Fprint(w, "<DeletePolicyVersionResponse></DeletePolicyVersionResponse>")
+		case "CreatePolicyVersion":
+			calls = append(calls, action+" "+r.Form.Get("PolicyDocument")+" "+r.Form.Get("SetAsDefault"))
+			fmt.Fprint(w, "<CreatePolicyVersionResponse><CreatePolicyVersionResult><PolicyVersion><VersionId>v6</VersionId><IsDefaultVersion>true</IsDefaultVersion><CreateDate>2019-01-06T00:00:00Z</CreateDate></PolicyVersion></CreatePolicyVersionResult></CreatePolicyVersionResponse>") // nolint: lll
+		default:
+			t.Errorf("Unexpected action %q", action)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := defaults.Config()
+	cfg.Region = "us-east-1"
+	cfg.Credentials = aws.NewStaticCredentialsProvider("key", "secret", "")
+	cfg.EndpointResolver = aws.ResolveWithEndpointURL(srv.URL)
+	client := iam.New(cfg)
+
+	prev := &IAMPolicy{
+		PolicyName:     "foo",
+		PolicyDocument: "old",
+		ARN:            aws.String("arn:aws:iam::123456789012:policy/foo"),
+	}
+	p := &IAMPolicy{
+		PolicyName:     "foo",
+		PolicyDocument: "new",
+		ARN:            prev.ARN,
+	}
+	p.SetClient(client)
+
+	err := p.Update(context.Background(), &resource.UpdateRequest{Previous: prev, ConfigChanged: true})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// The policy is not replaced. The oldest version that is not the default
+	// version is deleted to make room for the new version.
+	want := []string{
+		"ListPolicyVersions",
+		"DeletePolicyVersion v1",
+		"CreatePolicyVersion new true",
+	}
+	if diff := cmp.Diff(calls, want); diff != "" {
+		t.Errorf("Calls (-got +want)\n%s", diff)
+	}
+	if got := aws.StringValue(p.DefaultVersionID); got != "v6" {
+		t.Errorf("DefaultVersionID = %q, want %q", got, "v6")
+	}
+	if got := p.UpdateDate; got != "2019-01-06T00:00:00Z" {
+		t.Errorf("UpdateDate = %q, want %q", got, "2019-01-06T00:00:00Z")
+	}
+}
+
+func TestIAMPolicy_Update_name(t *testing.T) {
+	prev := &IAMPolicy{PolicyName: "foo", PolicyDocument: "doc"}
+	p := &IAMPolicy{PolicyName: "bar", PolicyDocument: "doc"}
+
+	err := p.Update(context.Background(), &resource.UpdateRequest{Previous: prev, ConfigChanged: true})
+	if err == nil {
+		t.Fatal("Update() error = nil, want error")
+	}
+}
@@ -2,11 +2,14 @@ package aws
 
 import (
 	"context"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 )
 
@@ -77,11 +80,7 @@ type IAMRole struct {
 	// the role.
 	PermissionsBoundary *string `func:"input"`
 
-	// Region to use for IAM API calls.
-	//
-	// IAM is global so the calls are not regional but the Region will specify
-	// which region the API calls are sent to.
-	Region *string `func:"input"`
+	base.GlobalResource
 
 	// The name of the role to create.
 	//
@@ -92,7 +91,9 @@ type IAMRole struct {
 	//
 	// Role names are not distinguished by case. For example, you cannot create
 	// roles named both "PRODROLE" and "prodrole".
-	RoleName string `func:"input"`
+	//
+	// Changing the name replaces the role.
+	RoleName string `func:"input,force_new"`
 
 	// The Amazon Resource Name (ARN) specifying the role.
 	ARN *string `func:"output"`
@@ -102,13 +103,11 @@ type IAMRole struct {
 
 	// The stable and unique string identifying the role.
 	RoleID *string `func:"output"`
-
-	iamService
 }
 
 // Create creates a new IAM role.
 func (p *IAMRole) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.IAM(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -127,7 +126,7 @@ func (p *IAMRole) Create(ctx context.Context, r *resource.CreateRequest) error {
 
 	resp, err := svc.CreateRoleRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.ARN = resp.Role.Arn
@@ -139,7 +138,7 @@ func (p *IAMRole) Create(ctx context.Context, r *resource.CreateRequest) error {
 
 // Delete deletes the IAM role.
 func (p *IAMRole) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.IAM(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -152,12 +151,12 @@ func (p *IAMRole) Delete(ctx context.Context, r *resource.DeleteRequest) error {
 	}
 
 	_, err = svc.DeleteRoleRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
 // Update updates the IAM role.
 func (p *IAMRole) Update(ctx context.Context, r *resource.UpdateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.IAM(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -172,5 +171,75 @@ func (p *IAMRole) Update(ctx context.Context, r *resource.UpdateRequest) error {
 	}
 
 	_, err = svc.UpdateRoleRequest(input).Send(ctx)
-	return handlePutError(err)
+	return base.Classify(err)
+}
+
+// Import imports an existing IAM role. The import id is the name of the role.
+func (p *IAMRole) Import(ctx context.Context, r *resource.ImportRequest) error {
+	svc, err := base.IAM(p, r.Auth)
+	if err != nil {
+		return err
+	}
+
+	input := &iam.GetRoleInput{
+		RoleName: aws.String(r.ID),
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+
+	resp, err := svc.GetRoleRequest(input).Send(ctx)
+	if err != nil {
+		return base.Classify(err)
+	}
+	role := resp.Role
+
+	// The policy document is returned URL encoded.
+	doc, err := url.QueryUnescape(aws.StringValue(role.AssumeRolePolicyDocument))
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+
+	p.AssumeRolePolicyDocument = doc
+	p.Description = role.Description
+	p.MaxSessionDuration = role.MaxSessionDuration
+	p.Path = role.Path
+	if role.PermissionsBoundary != nil {
+		p.PermissionsBoundary = role.PermissionsBoundary.PermissionsBoundaryArn
+	}
+	p.RoleName = aws.StringValue(role.RoleName)
+
+	p.ARN = role.Arn
+	if role.CreateDate != nil {
+		p.CreateDate = role.CreateDate.Format(time.RFC3339)
+	}
+	p.RoleID = role.RoleId
+
+	return nil
+}
+
+// List lists the names of the IAM roles in the account.
+//
+// Service-linked roles are not listed, they are managed by the services that
+// created them.
+func (p *IAMRole) List(ctx context.Context, r *resource.ListRequest) ([]string, error) {
+	svc, err := base.IAM(p, r.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	pager := iam.NewListRolesPaginator(svc.ListRolesRequest(&iam.ListRolesInput{}))
+	for pager.Next(ctx) {
+		for _, role := range pager.CurrentPage().Roles {
+			if strings.HasPrefix(aws.StringValue(role.Path), "/aws-service-role/") {
+				continue
+			}
+			names = append(names, aws.StringValue(role.RoleName))
+		}
+	}
+	if err := pager.Err(); err != nil {
+		return nil, base.Classify(err)
+	}
+	return names, nil
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 )
 
@@ -19,23 +20,17 @@ type IAMRolePolicy struct {
 	// The name of the policy document.
 	PolicyName string `func:"input"`
 
-	// Region to use for IAM API calls.
-	//
-	// IAM is global so the calls are not regional but the Region will specify
-	// which region the API calls are sent to.
-	Region *string `func:"input"`
+	base.GlobalResource
 
 	// The name of the role to associate the policy with.
 	RoleName string `func:"input"`
 
 	// No outputs
-
-	iamService
 }
 
 // Create attaches an inline role policy to and IAM role.
 func (p *IAMRolePolicy) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.IAM(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -51,7 +46,7 @@ func (p *IAMRolePolicy) Create(ctx context.Context, r *resource.CreateRequest) e
 
 	resp, err := svc.PutRolePolicyRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	// No outputs in response
@@ -62,7 +57,7 @@ func (p *IAMRolePolicy) Create(ctx context.Context, r *resource.CreateRequest) e
 
 // Delete removes an inline role policy from an IAM role.
 func (p *IAMRolePolicy) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.IAM(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -76,7 +71,7 @@ func (p *IAMRolePolicy) Delete(ctx context.Context, r *resource.DeleteRequest) e
 	}
 
 	_, err = svc.DeleteRolePolicyRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
 // Update removes the old role policy and attaches a new one.
@@ -6,6 +6,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 )
 
@@ -22,11 +23,7 @@ type IAMRolePolicyAttachment struct {
 	// in the AWS General Reference.
 	PolicyARN string `func:"input"`
 
-	// Region to use for IAM API calls.
-	//
-	// IAM is global so the calls are not regional but the Region will specify
-	// which region the API calls are sent to.
-	Region *string `func:"input"`
+	base.GlobalResource
 
 	// The name (friendly name, not ARN) of the role to attach the policy to.
 	//
@@ -36,13 +33,11 @@ type IAMRolePolicyAttachment struct {
 	RoleName string `func:"input"`
 
 	// No outputs
-
-	iamService
 }
 
 // Create attaches a policy to a role.
 func (p *IAMRolePolicyAttachment) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.IAM(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -57,7 +52,7 @@ func (p *IAMRolePolicyAttachment) Create(ctx context.Context, r *resource.Create
 
 	resp, err := svc.AttachRolePolicyRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	// No outputs in response
@@ -68,7 +63,7 @@ func (p *IAMRolePolicyAttachment) Create(ctx context.Context, r *resource.Create
 
 // Delete removes a policy attachment.
 func (p *IAMRolePolicyAttachment) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.IAM(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -82,7 +77,7 @@ func (p *IAMRolePolicyAttachment) Delete(ctx context.Context, r *resource.Delete
 	}
 
 	_, err = svc.DetachRolePolicyRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
 // Update removes the previous attachment and creates a new one.
@@ -0,0 +1,98 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/defaults"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/func/func/resource"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestIAMRole_List(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if action := r.Form.Get("Action"); action != "ListRoles" {
+			t.Errorf("Unexpected action %q", action)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// Two pages, the second one contains a service-linked role.
+		if r.Form.Get("Marker") == "" {
+			fmt.Fprint(w, "<ListRolesResponse><ListRolesResult><IsTruncated>true</IsTruncated><Marker>next</Marker><Roles><member><RoleName>foo</RoleName><Path>/</Path></member></Roles></ListRolesResult></ListRolesResponse>") // nolint: lll
+			return
+		}
+		fmt.Fprint(w, "<ListRolesResponse><ListRolesResult><IsTruncated>false</IsTruncated><Roles><member><RoleName>bar</RoleName><Path>/app/</Path></member><member><RoleName>AWSServiceRoleForSupport</RoleName><Path>/aws-service-role/support.amazonaws.com/</Path></member></Roles></ListRolesResult></ListRolesResponse>") // nolint: lll
+	}))
+	defer srv.Close()
+
+	p := &IAMRole{}
+	p.SetClient(iamClient(srv.URL))
+
+	got, err := p.List(context.Background(), &resource.ListRequest{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := []string{"foo", "bar"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("List() (-got +want)\n%s", diff)
+	}
+}
+
+func TestIAMRole_Import(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if action := r.Form.Get("Action"); action != "GetRole" {
+			t.Errorf("Unexpected action %q", action)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if name := r.Form.Get("RoleName"); name != "foo" {
+			t.Errorf("RoleName = %q, want %q", name, "foo")
+		}
+		fmt.Fprint(w, "<GetRoleResponse><GetRoleResult><Role><RoleName>foo</RoleName><Path>/</Path><Arn>arn:aws:iam::123456789012:role/foo</Arn><RoleId>AROA123</RoleId><CreateDate>2019-01-01T00:00:00Z</CreateDate><MaxSessionDuration>3600</MaxSessionDuration><AssumeRolePolicyDocument>%7B%22Version%22%3A%222012-10-17%22%7D</AssumeRolePolicyDocument></Role></GetRoleResult></GetRoleResponse>") // nolint: lll
+	}))
+	defer srv.Close()
+
+	p := &IAMRole{}
+	p.SetClient(iamClient(srv.URL))
+
+	if err := p.Import(context.Background(), &resource.ImportRequest{ID: "foo"}); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	want := &IAMRole{
+		AssumeRolePolicyDocument: `{"Version":"2012-10-17"}`,
+		MaxSessionDuration:       aws.Int64(3600),
+		Path:                     aws.String("/"),
+		RoleName:                 "foo",
+		ARN:                      aws.String("arn:aws:iam::123456789012:role/foo"),
+		CreateDate:               "2019-01-01T00:00:00Z",
+		RoleID:                   aws.String("AROA123"),
+	}
+	if diff := cmp.Diff(p, want, cmpopts.IgnoreUnexported(IAMRole{})); diff != "" {
+		t.Errorf("Import() (-got +want)\n%s", diff)
+	}
+}
+
+func iamClient(endpoint string) *iam.Client {
+	cfg := defaults.Config()
+	cfg.Region = "us-east-1"
+	cfg.Credentials = aws.NewStaticCredentialsProvider("key", "secret", "")
+	cfg.EndpointResolver = aws.ResolveWithEndpointURL(endpoint)
+	return iam.New(cfg)
+}
@@ -0,0 +1,166 @@
+// Package base provides the parts that are shared by all AWS resources: the
+// region input, API clients that are cached per service and region, and
+// classification of the errors returned from AWS APIs.
+package base
+
+import (
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/aws/defaults"
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+)
+
+// Config returns the AWS config for calling APIs in the given region, with
+// credentials from auth.
+func Config(auth resource.AuthProvider, region string) (aws.Config, error) {
+	cfg := defaults.Config()
+	creds, err := auth.AWS()
+	if err != nil {
+		return aws.Config{}, errors.Wrap(err, "get credentials")
+	}
+	cfg.Credentials = creds
+	cfg.Region = region
+	return cfg, nil
+}
+
+// Client returns an API client for the given service and region.
+//
+// If auth implements resource.ClientCache, the client is only created once
+// per service and region and reused for subsequent calls. Otherwise, a new
+// client is created on every call.
+func Client(auth resource.AuthProvider, service, region string, create func(cfg aws.Config) interface{}) (interface{}, error) { // nolint: lll
+	newClient := func() (interface{}, error) {
+		cfg, err := Config(auth, region)
+		if err != nil {
+			return nil, err
+		}
+		return create(cfg), nil
+	}
+	cache, ok := auth.(resource.ClientCache)
+	if !ok {
+		return newClient()
+	}
+	return cache.CachedClient("aws/"+service+"/"+region, newClient)
+}
+
+// retryableCodes are the error codes that AWS APIs return for temporary
+// errors: the request was throttled or the service was unavailable. Not all
+// APIs return status 429 or 5xx with these.
+var retryableCodes = map[string]bool{
+	// Throttling
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"ThrottledException":                     true,
+	"RequestThrottledException":              true,
+	"TooManyRequestsException":               true,
+	"ProvisionedThroughputExceededException": true,
+	"RequestLimitExceeded":                   true,
+	"RequestThrottled":                       true,
+	"SlowDown":                               true,
+	"EC2ThrottledException":                  true,
+
+	// Service unavailable
+	"InternalError":               true,
+	"InternalFailure":             true,
+	"InternalServiceError":        true,
+	"InternalServiceException":    true,
+	"ServiceUnavailable":          true,
+	"ServiceUnavailableException": true,
+	"ServiceException":            true,
+	"RequestTimeout":              true,
+	"RequestTimeoutException":     true,
+}
+
+// permanentCodes are the error codes that AWS APIs return when a request
+// can never succeed as is: the input is invalid, access was denied, or the
+// request conflicts with the current state of the resource.
+var permanentCodes = map[string]bool{
+	// Validation
+	"ValidationError":                  true,
+	"ValidationException":              true,
+	"InvalidParameter":                 true,
+	"InvalidParameterException":        true,
+	"InvalidParameterValue":            true,
+	"InvalidParameterValueException":   true,
+	"InvalidParameterCombination":      true,
+	"InvalidRequestException":          true,
+	"MalformedPolicyDocument":          true,
+	"MalformedPolicyDocumentException": true,
+	"MissingParameter":                 true,
+	"BadRequestException":              true,
+
+	// Access denied
+	"AccessDenied":                true,
+	"AccessDeniedException":       true,
+	"UnauthorizedOperation":       true,
+	"UnrecognizedClientException": true,
+	"InvalidClientTokenId":        true,
+	"ExpiredToken":                true,
+	"ExpiredTokenException":       true,
+	"NotAuthorized":               true,
+	"AuthFailure":                 true,
+	"SignatureDoesNotMatch":       true,
+	"IncompleteSignature":         true,
+	"MissingAuthenticationToken":  true,
+
+	// Conflict
+	"ConflictException":         true,
+	"ResourceConflictException": true,
+	"ResourceExistsException":   true,
+	"EntityAlreadyExists":       true,
+	"AlreadyExistsException":    true,
+}
+
+// Retryable returns true if err is a temporary error, so the request can be
+// retried.
+//
+// The error code is checked first: throttling and service unavailable errors
+// are retryable, validation, access denied and conflict errors are not. For
+// other codes the HTTP status is used: 429 and server errors are retryable,
+// other client errors are not. Errors that were not returned from an AWS
+// API, such as network errors, are retryable.
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if aerr, ok := err.(awserr.Error); ok {
+		if retryableCodes[aerr.Code()] {
+			return true
+		}
+		if permanentCodes[aerr.Code()] {
+			return false
+		}
+	}
+	if aerr, ok := err.(awserr.RequestFailure); ok {
+		code := aerr.StatusCode()
+		if code == http.StatusTooManyRequests {
+			return true
+		}
+		return code < 400 || code >= 500
+	}
+	return true
+}
+
+// Classify classifies an error returned from an AWS API. An error that is
+// not retryable is wrapped with backoff.Permanent, so it is not retried.
+// Retryable errors are returned as is.
+func Classify(err error) error {
+	if err == nil || Retryable(err) {
+		return err
+	}
+	return backoff.Permanent(err)
+}
+
+// DeleteError classifies an error returned when deleting a resource. If the
+// resource was not found, it has already been deleted and nil is returned.
+// Otherwise, the error is classified as in Classify.
+func DeleteError(err error) error {
+	if aerr, ok := err.(awserr.RequestFailure); ok && aerr.StatusCode() == http.StatusNotFound {
+		return nil
+	}
+	return Classify(err)
+}
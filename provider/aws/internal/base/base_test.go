@@ -0,0 +1,96 @@
+package base_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
+)
+
+func requestFailure(code string, status int) error {
+	return awserr.NewRequestFailure(awserr.New(code, "message", nil), status, "req")
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"Nil", nil, false},
+		{"Throttling", requestFailure("ThrottlingException", http.StatusBadRequest), true},
+		{"TooManyRequests", requestFailure("TooManyRequestsException", http.StatusTooManyRequests), true},
+		{"ThroughputExceeded", requestFailure("ProvisionedThroughputExceededException", http.StatusBadRequest), true},
+		{"ThrottlingNoStatus", awserr.New("Throttling", "message", nil), true},
+		{"EC2Throttled", requestFailure("RequestLimitExceeded", http.StatusServiceUnavailable), true},
+		{"Unavailable", requestFailure("ServiceUnavailable", http.StatusServiceUnavailable), true},
+		{"UnavailableNoStatus", awserr.New("ServiceUnavailableException", "message", nil), true},
+		{"InternalFailure", requestFailure("InternalFailure", http.StatusInternalServerError), true},
+		{"ServerError", requestFailure("UnknownError", http.StatusBadGateway), true},
+		{"Timeout", requestFailure("RequestTimeoutException", http.StatusBadRequest), true},
+		{"Validation", requestFailure("ValidationException", http.StatusBadRequest), false},
+		{"ValidationNoStatus", awserr.New("ValidationError", "message", nil), false},
+		{"InvalidParameter", requestFailure("InvalidParameterValueException", http.StatusBadRequest), false},
+		{"AccessDenied", requestFailure("AccessDeniedException", http.StatusForbidden), false},
+		{"UnauthorizedOperation", requestFailure("UnauthorizedOperation", http.StatusForbidden), false},
+		{"ExpiredToken", requestFailure("ExpiredToken", http.StatusForbidden), false},
+		{"Conflict", requestFailure("ConflictException", http.StatusConflict), false},
+		{"ResourceConflict", requestFailure("ResourceConflictException", http.StatusConflict), false},
+		{"ConflictServerStatus", requestFailure("ResourceConflictException", http.StatusInternalServerError), false},
+		{"NotFound", requestFailure("ResourceNotFoundException", http.StatusNotFound), false},
+		{"OtherClientError", requestFailure("SomethingWrong", http.StatusBadRequest), false},
+		{"Other", errors.New("connection reset"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base.Retryable(tt.err); got != tt.want {
+				t.Errorf("Retryable() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		permanent bool
+	}{
+		{"Throttling", requestFailure("ThrottlingException", http.StatusBadRequest), false},
+		{"Validation", requestFailure("ValidationException", http.StatusBadRequest), true},
+		{"NotFound", requestFailure("ResourceNotFoundException", http.StatusNotFound), true},
+		{"ServerError", requestFailure("InternalFailure", http.StatusInternalServerError), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := base.Classify(tt.err)
+			if got == nil {
+				t.Fatal("Classify() = nil, want error")
+			}
+			_, permanent := got.(*backoff.PermanentError)
+			if permanent != tt.permanent {
+				t.Errorf("Permanent = %t, want %t", permanent, tt.permanent)
+			}
+		})
+	}
+	if err := base.Classify(nil); err != nil {
+		t.Errorf("Classify(nil) = %v, want nil", err)
+	}
+}
+
+func TestDeleteError(t *testing.T) {
+	if err := base.DeleteError(requestFailure("ResourceNotFoundException", http.StatusNotFound)); err != nil {
+		t.Errorf("DeleteError(not found) = %v, want nil", err)
+	}
+	err := base.DeleteError(requestFailure("ValidationException", http.StatusBadRequest))
+	if _, ok := err.(*backoff.PermanentError); !ok {
+		t.Errorf("DeleteError(validation) = %T, want permanent error", err)
+	}
+	err = base.DeleteError(requestFailure("ThrottlingException", http.StatusBadRequest))
+	if _, ok := err.(*backoff.PermanentError); ok {
+		t.Errorf("DeleteError(throttling) is permanent, want retryable")
+	}
+}
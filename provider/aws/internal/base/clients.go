@@ -0,0 +1,169 @@
+package base
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	"github.com/aws/aws-sdk-go-v2/service/acm/acmiface"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/apigatewayiface"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchevents"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchevents/cloudwatcheventsiface"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/ecr/ecriface"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/kmsiface"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/lambdaiface"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/sqsiface"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/stsiface"
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/resource"
+)
+
+// A ClientProvider provides API clients for a resource. It is implemented by
+// Resource, ForceNewResource and GlobalResource.
+type ClientProvider interface {
+	Client(auth resource.AuthProvider, service string, create func(cfg aws.Config) interface{}) (interface{}, error)
+}
+
+// ACM returns an ACM API client for the resource.
+func ACM(res ClientProvider, auth resource.AuthProvider) (acmiface.ClientAPI, error) {
+	c, err := res.Client(auth, "acm", func(cfg aws.Config) interface{} {
+		return acm.New(cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.(acmiface.ClientAPI), nil
+}
+
+// APIGateway returns an API Gateway API client for the resource.
+func APIGateway(res ClientProvider, auth resource.AuthProvider) (apigatewayiface.ClientAPI, error) {
+	c, err := res.Client(auth, "apigateway", func(cfg aws.Config) interface{} {
+		return apigateway.New(cfg)
+	})
+	if err != nil {
+		return nil, backoff.Permanent(err)
+	}
+	return c.(apigatewayiface.ClientAPI), nil
+}
+
+// CloudWatchEvents returns a CloudWatch Events API client for the resource.
+func CloudWatchEvents(res ClientProvider, auth resource.AuthProvider) (cloudwatcheventsiface.ClientAPI, error) {
+	c, err := res.Client(auth, "cloudwatchevents", func(cfg aws.Config) interface{} {
+		return cloudwatchevents.New(cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.(cloudwatcheventsiface.ClientAPI), nil
+}
+
+// DynamoDB returns a DynamoDB API client for the resource.
+func DynamoDB(res ClientProvider, auth resource.AuthProvider) (dynamodbiface.ClientAPI, error) {
+	c, err := res.Client(auth, "dynamodb", func(cfg aws.Config) interface{} {
+		return dynamodb.New(cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.(dynamodbiface.ClientAPI), nil
+}
+
+// EC2 returns an EC2 API client for the resource.
+func EC2(res ClientProvider, auth resource.AuthProvider) (ec2iface.ClientAPI, error) {
+	c, err := res.Client(auth, "ec2", func(cfg aws.Config) interface{} {
+		return ec2.New(cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.(ec2iface.ClientAPI), nil
+}
+
+// ECR returns an ECR API client for the resource.
+func ECR(res ClientProvider, auth resource.AuthProvider) (ecriface.ClientAPI, error) {
+	c, err := res.Client(auth, "ecr", func(cfg aws.Config) interface{} {
+		return ecr.New(cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.(ecriface.ClientAPI), nil
+}
+
+// IAM returns an IAM API client for the resource.
+func IAM(res ClientProvider, auth resource.AuthProvider) (iamiface.ClientAPI, error) {
+	c, err := res.Client(auth, "iam", func(cfg aws.Config) interface{} {
+		return iam.New(cfg)
+	})
+	if err != nil {
+		return nil, backoff.Permanent(err)
+	}
+	return c.(iamiface.ClientAPI), nil
+}
+
+// KMS returns a KMS API client for the resource.
+func KMS(res ClientProvider, auth resource.AuthProvider) (kmsiface.ClientAPI, error) {
+	c, err := res.Client(auth, "kms", func(cfg aws.Config) interface{} {
+		return kms.New(cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.(kmsiface.ClientAPI), nil
+}
+
+// Lambda returns a Lambda API client for the resource.
+func Lambda(res ClientProvider, auth resource.AuthProvider) (lambdaiface.ClientAPI, error) {
+	c, err := res.Client(auth, "lambda", func(cfg aws.Config) interface{} {
+		return lambda.New(cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.(lambdaiface.ClientAPI), nil
+}
+
+// SecretsManager returns a Secrets Manager API client for the resource.
+func SecretsManager(res ClientProvider, auth resource.AuthProvider) (secretsmanageriface.ClientAPI, error) {
+	c, err := res.Client(auth, "secretsmanager", func(cfg aws.Config) interface{} {
+		return secretsmanager.New(cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.(secretsmanageriface.ClientAPI), nil
+}
+
+// SQS returns an SQS API client for the resource.
+func SQS(res ClientProvider, auth resource.AuthProvider) (sqsiface.ClientAPI, error) {
+	c, err := res.Client(auth, "sqs", func(cfg aws.Config) interface{} {
+		return sqs.New(cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.(sqsiface.ClientAPI), nil
+}
+
+// STS returns an STS API client for the resource.
+func STS(res ClientProvider, auth resource.AuthProvider) (stsiface.ClientAPI, error) {
+	c, err := res.Client(auth, "sts", func(cfg aws.Config) interface{} {
+		return sts.New(cfg)
+	})
+	if err != nil {
+		return nil, backoff.Permanent(err)
+	}
+	return c.(stsiface.ClientAPI), nil
+}
@@ -0,0 +1,108 @@
+package base
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/endpoints"
+	"github.com/aws/aws-sdk-go-v2/aws/external"
+	"github.com/func/func/resource"
+)
+
+// Resource contains the region input of a regional AWS resource and provides
+// API clients for the region. It is embedded in resource definitions; the
+// region input is promoted to the resource's schema.
+type Resource struct {
+	// The region to create the resource in.
+	Region string `func:"input"`
+
+	override
+}
+
+// Client returns an API client for the given service in the resource's
+// region. See Client for details.
+func (r *Resource) Client(auth resource.AuthProvider, service string, create func(cfg aws.Config) interface{}) (interface{}, error) { // nolint: lll
+	return r.client(auth, service, r.Region, create)
+}
+
+// ForceNewResource is a Resource where changing the region replaces the
+// resource, for resources that cannot be updated in a different region than
+// they were created in.
+type ForceNewResource struct {
+	// The region to create the resource in.
+	//
+	// Changing the region replaces the resource.
+	Region string `func:"input,force_new"`
+
+	override
+}
+
+// Client returns an API client for the given service in the resource's
+// region. See Client for details.
+func (r *ForceNewResource) Client(auth resource.AuthProvider, service string, create func(cfg aws.Config) interface{}) (interface{}, error) { // nolint: lll
+	return r.client(auth, service, r.Region, create)
+}
+
+// GlobalResource is embedded in resources of global services, such as IAM.
+// The calls are not regional but the region specifies which region the API
+// calls are sent to.
+type GlobalResource struct {
+	// The region to send API calls to. If not set, the region is read from
+	// the AWS_DEFAULT_REGION environment variable or ~/.aws/credentials,
+	// falling back to us-east-1.
+	Region *string `func:"input"`
+
+	override
+}
+
+// Client returns an API client for the given service in the resource's
+// region, or the default region if the region is not set. See Client for
+// details.
+func (r *GlobalResource) Client(auth resource.AuthProvider, service string, create func(cfg aws.Config) interface{}) (interface{}, error) { // nolint: lll
+	region := defaultRegion()
+	if r.Region != nil {
+		region = *r.Region
+	}
+	return r.client(auth, service, region, create)
+}
+
+// override allows overriding the API client in tests.
+type override struct {
+	c interface{}
+}
+
+// SetClient sets the API client to return, instead of creating one. The
+// client must implement the API of the resource's service.
+func (o *override) SetClient(client interface{}) {
+	o.c = client
+}
+
+func (o *override) client(auth resource.AuthProvider, service, region string, create func(cfg aws.Config) interface{}) (interface{}, error) { // nolint: lll
+	if o.c != nil {
+		return o.c, nil
+	}
+	return Client(auth, service, region, create)
+}
+
+// defaultRegion determines the default region to use based on:
+//
+//  - From AWS_DEFAULT_REGION environment variable.
+//  - From region in ~/.aws/credentials.
+//  - If neither is set, us-east-1 is used.
+func defaultRegion() string {
+	const fallback = endpoints.UsEast1RegionID
+	var cfgs external.Configs
+	cfgs, err := cfgs.AppendFromLoaders(external.DefaultConfigLoaders)
+	if err != nil {
+		return fallback
+	}
+	cfg, err := cfgs.ResolveAWSConfig([]external.AWSConfigResolver{
+		external.ResolveRegion,
+	})
+	if err != nil {
+		return fallback
+	}
+	if cfg.Region == "" {
+		// No AWS config available
+		return fallback
+	}
+	return cfg.Region
+}
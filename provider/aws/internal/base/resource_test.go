@@ -0,0 +1,68 @@
+package base_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/func/func/provider/aws/internal/base"
+)
+
+// cacheAuth records the keys clients are requested with.
+type cacheAuth struct {
+	keys []string
+}
+
+func (a *cacheAuth) AWS() (aws.CredentialsProvider, error) {
+	return aws.NewStaticCredentialsProvider("key", "secret", ""), nil
+}
+
+func (a *cacheAuth) CachedClient(key string, create func() (interface{}, error)) (interface{}, error) {
+	a.keys = append(a.keys, key)
+	return create()
+}
+
+func TestResource_Client(t *testing.T) {
+	tests := []struct {
+		name    string
+		res     base.ClientProvider
+		wantKey string
+	}{
+		{"Resource", &base.Resource{Region: "eu-west-1"}, "aws/test/eu-west-1"},
+		{"ForceNew", &base.ForceNewResource{Region: "eu-west-2"}, "aws/test/eu-west-2"},
+		{"Global", &base.GlobalResource{Region: aws.String("eu-west-3")}, "aws/test/eu-west-3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &cacheAuth{}
+			var region string
+			_, err := tt.res.Client(auth, "test", func(cfg aws.Config) interface{} {
+				region = cfg.Region
+				return "client"
+			})
+			if err != nil {
+				t.Fatalf("Client() error = %v", err)
+			}
+			if len(auth.keys) != 1 || auth.keys[0] != tt.wantKey {
+				t.Errorf("Keys = %v, want [%s]", auth.keys, tt.wantKey)
+			}
+			if want := tt.wantKey[len("aws/test/"):]; region != want {
+				t.Errorf("Region = %q, want %q", region, want)
+			}
+		})
+	}
+}
+
+func TestResource_SetClient(t *testing.T) {
+	res := &base.Resource{Region: "us-east-1"}
+	res.SetClient("override")
+	got, err := res.Client(&cacheAuth{}, "test", func(aws.Config) interface{} {
+		t.Error("Client was created")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Client() error = %v", err)
+	}
+	if got != "override" {
+		t.Errorf("Client() = %v, want override", got)
+	}
+}
@@ -0,0 +1,117 @@
+// Package tags computes changes to tags on AWS resources.
+//
+// Tags are represented as a map from key to value. Providers convert the map
+// to the tag type of the service they manage.
+package tags
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// A Diff contains the changes required to update the tags on a resource.
+type Diff struct {
+	Add    map[string]string // Tags that were not previously set.
+	Update map[string]string // Tags with a changed value.
+	Remove []string          // Keys for tags to remove, sorted.
+}
+
+// Compare computes the changes to update tags from prev to next.
+func Compare(prev, next map[string]string) Diff {
+	var d Diff
+	for k, v := range next {
+		pv, ok := prev[k]
+		switch {
+		case !ok:
+			if d.Add == nil {
+				d.Add = make(map[string]string)
+			}
+			d.Add[k] = v
+		case pv != v:
+			if d.Update == nil {
+				d.Update = make(map[string]string)
+			}
+			d.Update[k] = v
+		}
+	}
+	for k := range prev {
+		if _, ok := next[k]; !ok {
+			d.Remove = append(d.Remove, k)
+		}
+	}
+	sort.Strings(d.Remove)
+	return d
+}
+
+// IsEmpty returns true if the tags are unchanged.
+func (d Diff) IsEmpty() bool {
+	return len(d.Add) == 0 && len(d.Update) == 0 && len(d.Remove) == 0
+}
+
+// Set returns the tags to set on the resource, both added and updated. In
+// most AWS APIs, tagging a resource with an existing key overwrites the
+// value.
+func (d Diff) Set() map[string]string {
+	if len(d.Add) == 0 && len(d.Update) == 0 {
+		return nil
+	}
+	set := make(map[string]string, len(d.Add)+len(d.Update))
+	for k, v := range d.Add {
+		set[k] = v
+	}
+	for k, v := range d.Update {
+		set[k] = v
+	}
+	return set
+}
+
+// Keys returns the keys for the given tags, sorted. Tags converted to SDK
+// types in the order of Keys are deterministic.
+func Keys(tags map[string]string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Convert sets list to the given tags, sorted by key. List must be a pointer
+// to a slice of an SDK tag type, such as *[]ecr.Tag. For each tag, the
+// fields named key and value are set to the key and value of the tag; both
+// fields must be of type *string. If there are no tags, the slice is set to
+// nil.
+//
+// Panics if list is not a pointer to a slice of structs with the fields.
+func Convert(tags map[string]string, list interface{}, key, value string) {
+	ptr := reflect.ValueOf(list)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Slice || ptr.Type().Elem().Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("tags: list must be a pointer to a slice of structs, not %T", list))
+	}
+	slice := ptr.Elem()
+	keys := Keys(tags)
+	if len(keys) == 0 {
+		slice.Set(reflect.Zero(slice.Type()))
+		return
+	}
+	out := reflect.MakeSlice(slice.Type(), len(keys), len(keys))
+	for i, k := range keys {
+		setString(out.Index(i), key, k)
+		setString(out.Index(i), value, tags[k])
+	}
+	slice.Set(out)
+}
+
+var stringPtr = reflect.TypeOf((*string)(nil))
+
+func setString(v reflect.Value, field, s string) {
+	f := v.FieldByName(field)
+	if !f.IsValid() || f.Type() != stringPtr {
+		panic(fmt.Sprintf("tags: %s does not have a *string field %s", v.Type(), field))
+	}
+	f.Set(reflect.ValueOf(&s))
+}
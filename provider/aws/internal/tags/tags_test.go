@@ -0,0 +1,128 @@
+package tags
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev      map[string]string
+		next      map[string]string
+		want      Diff
+		wantEmpty bool
+	}{
+		{
+			name:      "NoChange",
+			prev:      map[string]string{"a": "1", "b": "2"},
+			next:      map[string]string{"a": "1", "b": "2"},
+			want:      Diff{},
+			wantEmpty: true,
+		},
+		{
+			name:      "Empty",
+			prev:      nil,
+			next:      map[string]string{},
+			want:      Diff{},
+			wantEmpty: true,
+		},
+		{
+			name: "Added",
+			prev: map[string]string{"a": "1"},
+			next: map[string]string{"a": "1", "b": "2"},
+			want: Diff{Add: map[string]string{"b": "2"}},
+		},
+		{
+			name: "Removed",
+			prev: map[string]string{"a": "1", "b": "2", "c": "3"},
+			next: map[string]string{"b": "2"},
+			want: Diff{Remove: []string{"a", "c"}},
+		},
+		{
+			name: "Changed",
+			prev: map[string]string{"a": "1", "b": "2"},
+			next: map[string]string{"a": "1", "b": "3"},
+			want: Diff{Update: map[string]string{"b": "3"}},
+		},
+		{
+			name: "Mixed",
+			prev: map[string]string{"a": "1", "b": "2"},
+			next: map[string]string{"b": "3", "c": "4"},
+			want: Diff{
+				Add:    map[string]string{"c": "4"},
+				Update: map[string]string{"b": "3"},
+				Remove: []string{"a"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare(tt.prev, tt.next)
+			if diff := cmp.Diff(got, tt.want, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Compare() (-got +want)\n%s", diff)
+			}
+			if got.IsEmpty() != tt.wantEmpty {
+				t.Errorf("IsEmpty() = %t, want %t", got.IsEmpty(), tt.wantEmpty)
+			}
+		})
+	}
+}
+
+func TestDiff_Set(t *testing.T) {
+	d := Compare(
+		map[string]string{"a": "1", "b": "2"},
+		map[string]string{"b": "3", "c": "4"},
+	)
+	want := map[string]string{"b": "3", "c": "4"}
+	if diff := cmp.Diff(d.Set(), want); diff != "" {
+		t.Errorf("Set() (-got +want)\n%s", diff)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	got := Keys(map[string]string{"c": "3", "a": "1", "b": "2"})
+	want := []string{"a", "b", "c"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Keys() (-got +want)\n%s", diff)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	type tag struct {
+		Key   *string
+		Value *string
+	}
+	str := func(s string) *string { return &s }
+
+	var got []tag
+	Convert(map[string]string{"b": "2", "a": "1"}, &got, "Key", "Value")
+	want := []tag{
+		{Key: str("a"), Value: str("1")},
+		{Key: str("b"), Value: str("2")},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Convert() (-got +want)\n%s", diff)
+	}
+
+	Convert(nil, &got, "Key", "Value")
+	if got != nil {
+		t.Errorf("Convert(nil) = %v, want nil", got)
+	}
+}
+
+func TestConvert_panic(t *testing.T) {
+	type tag struct {
+		TagKey   *string
+		TagValue *string
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Convert() did not panic")
+		}
+	}()
+	var list []tag
+	Convert(map[string]string{"a": "1"}, &list, "Key", "Value")
+}
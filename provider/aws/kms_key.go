@@ -0,0 +1,382 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/kmsiface"
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
+	"github.com/func/func/provider/aws/internal/tags"
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+)
+
+// KMSKey creates a customer managed customer master key (CMK) in AWS KMS.
+//
+// The key can be used to encrypt data in other services, such as DynamoDB
+// tables and S3 buckets.
+//
+// Deleting a KMS key is destructive and potentially dangerous. When the
+// resource is deleted, the key is scheduled for deletion after a waiting
+// period. The deletion can be canceled in AWS KMS during the waiting period.
+//
+// https://aws.amazon.com/kms/
+type KMSKey struct {
+	// Inputs
+
+	// The display name of the alias to create for the key. The name must
+	// begin with alias/ followed by a name, such as alias/ExampleAlias. The
+	// alias name cannot begin with alias/aws/, this prefix is reserved for
+	// AWS managed CMKs.
+	//
+	// If not set, no alias is created.
+	Alias *string `func:"input"`
+
+	// The waiting period, specified in number of days, before the key is
+	// deleted after the resource has been removed. If not set, the default
+	// waiting period of 30 days is used.
+	DeletionWindowInDays *int64 `func:"input" validate:"min=7,max=30"`
+
+	// A description of the CMK.
+	//
+	// Use a description that helps you decide whether the CMK is appropriate
+	// for a task.
+	Description *string `func:"input"`
+
+	// Enables automatic rotation of the key material for the CMK. When
+	// enabled, AWS KMS rotates the key material every year.
+	EnableKeyRotation *bool `func:"input"`
+
+	// The key policy to attach to the CMK, as a JSON document.
+	//
+	// If not set, AWS KMS attaches a default key policy to the CMK. Removing
+	// the policy restores the default key policy. For more information, see
+	// [Default Key Policy](https://docs.aws.amazon.com/kms/latest/developerguide/key-policies.html#key-policy-default)
+	// in the AWS Key Management Service Developer Guide.
+	KeyPolicy *string `func:"input"`
+
+	base.Resource
+
+	// Tags to attach to the CMK.
+	Tags map[string]string `func:"input"`
+
+	// Outputs
+
+	// The Amazon Resource Name (ARN) of the CMK.
+	ARN string `func:"output"`
+
+	// The globally unique identifier for the CMK.
+	KeyID string `func:"output"`
+}
+
+// Create creates a new KMS key. If an alias is set, the alias is created for
+// the key.
+func (p *KMSKey) Create(ctx context.Context, r *resource.CreateRequest) error {
+	svc, err := base.KMS(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	if p.KeyID == "" {
+		input := &kms.CreateKeyInput{
+			Description: p.Description,
+			Policy:      p.KeyPolicy,
+		}
+		tags.Convert(p.Tags, &input.Tags, "TagKey", "TagValue")
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+
+		resp, err := svc.CreateKeyRequest(input).Send(ctx)
+		if err != nil {
+			return base.Classify(err)
+		}
+
+		// Set outputs immediately so a retry does not create another key.
+		p.KeyID = *resp.KeyMetadata.KeyId
+		p.ARN = *resp.KeyMetadata.Arn
+	}
+
+	if p.EnableKeyRotation != nil && *p.EnableKeyRotation {
+		if err := p.setRotation(ctx, svc, true); err != nil {
+			return err
+		}
+	}
+
+	if p.Alias != nil {
+		return p.createAlias(ctx, svc)
+	}
+
+	return nil
+}
+
+// Delete schedules the KMS key for deletion. The alias, if set, is deleted
+// immediately.
+//
+// Deletion in KMS is asynchronous; Delete returns as soon as the deletion has
+// been scheduled.
+func (p *KMSKey) Delete(ctx context.Context, r *resource.DeleteRequest) error {
+	svc, err := base.KMS(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	if p.Alias != nil {
+		input := &kms.DeleteAliasInput{
+			AliasName: p.Alias,
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		_, err := svc.DeleteAliasRequest(input).Send(ctx)
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == kms.ErrCodeNotFoundException {
+			// Already deleted
+			err = nil
+		}
+		if err := base.DeleteError(err); err != nil {
+			return err
+		}
+	}
+
+	input := &kms.ScheduleKeyDeletionInput{
+		KeyId:               aws.String(p.KeyID),
+		PendingWindowInDays: p.DeletionWindowInDays,
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err = svc.ScheduleKeyDeletionRequest(input).Send(ctx)
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case kms.ErrCodeNotFoundException:
+			// Already deleted
+			return nil
+		case kms.ErrCodeKMSInvalidStateException:
+			// The key may already be pending deletion, or it may be in a
+			// state where it cannot be deleted.
+			pending, derr := p.pendingDeletion(ctx, svc)
+			if derr != nil {
+				return derr
+			}
+			if pending {
+				return nil
+			}
+		}
+	}
+	return base.DeleteError(err)
+}
+
+// pendingDeletion returns true if the key is scheduled for deletion.
+func (p *KMSKey) pendingDeletion(ctx context.Context, svc kmsiface.ClientAPI) (bool, error) {
+	input := &kms.DescribeKeyInput{KeyId: aws.String(p.KeyID)}
+	if err := input.Validate(); err != nil {
+		return false, backoff.Permanent(err)
+	}
+	resp, err := svc.DescribeKeyRequest(input).Send(ctx)
+	if err != nil {
+		return false, base.Classify(err)
+	}
+	return resp.KeyMetadata.KeyState == kms.KeyStatePendingDeletion, nil
+}
+
+// Update updates the KMS key.
+func (p *KMSKey) Update(ctx context.Context, r *resource.UpdateRequest) error {
+	svc, err := base.KMS(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	prev := r.Previous.(*KMSKey)
+	p.KeyID = prev.KeyID
+	p.ARN = prev.ARN
+
+	if !equalStringPtr(p.Description, prev.Description) {
+		desc := ""
+		if p.Description != nil {
+			desc = *p.Description
+		}
+		input := &kms.UpdateKeyDescriptionInput{
+			KeyId:       aws.String(p.KeyID),
+			Description: aws.String(desc),
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		if _, err := svc.UpdateKeyDescriptionRequest(input).Send(ctx); err != nil {
+			return base.Classify(err)
+		}
+	}
+
+	if !equalStringPtr(p.KeyPolicy, prev.KeyPolicy) {
+		policy := p.KeyPolicy
+		if policy == nil {
+			def, err := defaultKeyPolicy(p.ARN)
+			if err != nil {
+				return backoff.Permanent(err)
+			}
+			policy = aws.String(def)
+		}
+		input := &kms.PutKeyPolicyInput{
+			KeyId:      aws.String(p.KeyID),
+			Policy:     policy,
+			PolicyName: aws.String("default"),
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		if _, err := svc.PutKeyPolicyRequest(input).Send(ctx); err != nil {
+			return base.Classify(err)
+		}
+	}
+
+	rotate := p.EnableKeyRotation != nil && *p.EnableKeyRotation
+	prevRotate := prev.EnableKeyRotation != nil && *prev.EnableKeyRotation
+	if rotate != prevRotate {
+		if err := p.setRotation(ctx, svc, rotate); err != nil {
+			return err
+		}
+	}
+
+	if !equalStringPtr(p.Alias, prev.Alias) {
+		if err := p.updateAlias(ctx, svc, prev.Alias); err != nil {
+			return err
+		}
+	}
+
+	return p.updateTags(ctx, svc, prev.Tags)
+}
+
+// defaultKeyPolicy returns the key policy that AWS KMS attaches to a key
+// that is created without a policy. The policy gives the account that owns
+// the key full access to it, and allows IAM policies to grant access.
+func defaultKeyPolicy(arn string) (string, error) {
+	// arn:partition:kms:region:account:key/id
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[4] == "" {
+		return "", fmt.Errorf("cannot get account from key arn %q", arn)
+	}
+	return fmt.Sprintf(`{
+  "Version": "2012-10-17",
+  "Id": "key-default-1",
+  "Statement": [
+    {
+      "Sid": "Enable IAM User Permissions",
+      "Effect": "Allow",
+      "Principal": {"AWS": "arn:%s:iam::%s:root"},
+      "Action": "kms:*",
+      "Resource": "*"
+    }
+  ]
+}`, parts[1], parts[4]), nil
+}
+
+func (p *KMSKey) setRotation(ctx context.Context, svc kmsiface.ClientAPI, enable bool) error {
+	if enable {
+		input := &kms.EnableKeyRotationInput{KeyId: aws.String(p.KeyID)}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		_, err := svc.EnableKeyRotationRequest(input).Send(ctx)
+		return base.Classify(err)
+	}
+	input := &kms.DisableKeyRotationInput{KeyId: aws.String(p.KeyID)}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err := svc.DisableKeyRotationRequest(input).Send(ctx)
+	return base.Classify(err)
+}
+
+func (p *KMSKey) updateAlias(ctx context.Context, svc kmsiface.ClientAPI, prev *string) error {
+	if prev != nil {
+		input := &kms.DeleteAliasInput{AliasName: prev}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		_, err := svc.DeleteAliasRequest(input).Send(ctx)
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == kms.ErrCodeNotFoundException {
+			// Already deleted
+			err = nil
+		}
+		if err := base.DeleteError(err); err != nil {
+			return err
+		}
+	}
+	if p.Alias == nil {
+		return nil
+	}
+	return p.createAlias(ctx, svc)
+}
+
+// createAlias creates the alias for the key. If the alias already exists, it
+// must point to the key; the alias may have been created in a previous
+// attempt. An alias that points to another key is not taken over.
+func (p *KMSKey) createAlias(ctx context.Context, svc kmsiface.ClientAPI) error {
+	input := &kms.CreateAliasInput{
+		AliasName:   p.Alias,
+		TargetKeyId: aws.String(p.KeyID),
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err := svc.CreateAliasRequest(input).Send(ctx)
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != kms.ErrCodeAlreadyExistsException {
+		return base.Classify(err)
+	}
+
+	// Describing the alias returns the key it points to.
+	desc := &kms.DescribeKeyInput{KeyId: p.Alias}
+	if err := desc.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	resp, err := svc.DescribeKeyRequest(desc).Send(ctx)
+	if err != nil {
+		return base.Classify(err)
+	}
+	if target := aws.StringValue(resp.KeyMetadata.KeyId); target != p.KeyID {
+		return backoff.Permanent(fmt.Errorf("alias %s is in use by key %s", *p.Alias, target))
+	}
+	return nil
+}
+
+func (p *KMSKey) updateTags(ctx context.Context, svc kmsiface.ClientAPI, prev map[string]string) error {
+	diff := tags.Compare(prev, p.Tags)
+	if len(diff.Remove) > 0 {
+		input := &kms.UntagResourceInput{
+			KeyId:   aws.String(p.KeyID),
+			TagKeys: diff.Remove,
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		if _, err := svc.UntagResourceRequest(input).Send(ctx); err != nil {
+			return base.Classify(err)
+		}
+	}
+
+	set := diff.Set()
+	if len(set) == 0 {
+		return nil
+	}
+	input := &kms.TagResourceInput{
+		KeyId: aws.String(p.KeyID),
+	}
+	tags.Convert(set, &input.Tags, "TagKey", "TagValue")
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err := svc.TagResourceRequest(input).Send(ctx)
+	return base.Classify(err)
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,230 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/defaults"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
+	"github.com/func/func/provider/aws/internal/tags"
+	"github.com/func/func/resource"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestKMSKey_fields(t *testing.T) {
+	// The region is declared in the embedded base.Resource.
+	if _, ok := resource.Fields(reflect.TypeOf(KMSKey{})).Inputs()["region"]; !ok {
+		t.Errorf("Region is not an input")
+	}
+}
+
+func TestKMSKey_Create_retry(t *testing.T) {
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "TrentService.")
+		calls = append(calls, action)
+		switch action {
+		case "CreateKey":
+			fmt.Fprint(w, `{"KeyMetadata":{"KeyId":"key","Arn":"arn:aws:kms:us-east-1:123456789012:key/key"}}`)
+		case "CreateAlias":
+			// Fails on the first attempt.
+			if len(calls) == 2 {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"__type":"KMSInvalidStateException","message":"key is pending import"}`)
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("Unexpected action %q", action)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	p := &KMSKey{
+		Alias:    aws.String("alias/foo"),
+		Resource: base.Resource{Region: "us-east-1"},
+	}
+	p.SetClient(kmsClient(srv.URL))
+
+	if err := p.Create(context.Background(), &resource.CreateRequest{}); err == nil {
+		t.Fatal("Create() error = nil, want error")
+	}
+	if err := p.Create(context.Background(), &resource.CreateRequest{}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// The key is only created once.
+	want := []string{"CreateKey", "CreateAlias", "CreateAlias"}
+	if diff := cmp.Diff(calls, want); diff != "" {
+		t.Errorf("Calls (-got +want)\n%s", diff)
+	}
+	if p.KeyID != "key" {
+		t.Errorf("KeyID = %q, want %q", p.KeyID, "key")
+	}
+}
+
+func TestKMSKey_Create_aliasExists(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		permanent bool
+	}{
+		{"SameKey", "key", false},
+		{"OtherKey", "other", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch target := r.Header.Get("X-Amz-Target"); target {
+				case "TrentService.CreateAlias":
+					w.WriteHeader(http.StatusBadRequest)
+					fmt.Fprint(w, `{"__type":"AlreadyExistsException","message":"alias exists"}`)
+				case "TrentService.DescribeKey":
+					var body struct{ KeyId string } // nolint: golint
+					if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+						t.Errorf("Decode body: %v", err)
+					}
+					if body.KeyId != "alias/foo" {
+						t.Errorf("DescribeKey KeyId = %q, want alias/foo", body.KeyId)
+					}
+					fmt.Fprintf(w, `{"KeyMetadata":{"KeyId":%q}}`, tt.target)
+				default:
+					t.Errorf("Unexpected target %q", target)
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}))
+			defer srv.Close()
+
+			p := &KMSKey{
+				Alias:    aws.String("alias/foo"),
+				Resource: base.Resource{Region: "us-east-1"},
+				ARN:      "arn:aws:kms:us-east-1:123456789012:key/key",
+				KeyID:    "key",
+			}
+			p.SetClient(kmsClient(srv.URL))
+			err := p.Create(context.Background(), &resource.CreateRequest{})
+			if !tt.permanent {
+				if err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+				return
+			}
+			if _, ok := err.(*backoff.PermanentError); !ok {
+				t.Errorf("Create() error = %v, want permanent error", err)
+			}
+		})
+	}
+}
+
+func TestKMSKey_Update_policyRemoved(t *testing.T) {
+	var policy string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Amz-Target"); got != "TrentService.PutKeyPolicy" {
+			t.Errorf("Unexpected target %q", got)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var body struct{ Policy string }
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Decode body: %v", err)
+		}
+		policy = body.Policy
+		fmt.Fprint(w, `{}`)
+	}))
+	defer srv.Close()
+
+	prev := &KMSKey{
+		KeyPolicy: aws.String(`{"Statement":[]}`),
+		Resource:  base.Resource{Region: "us-east-1"},
+		ARN:       "arn:aws:kms:us-east-1:123456789012:key/key",
+		KeyID:     "key",
+	}
+	p := &KMSKey{
+		Resource: base.Resource{Region: "us-east-1"},
+	}
+	p.SetClient(kmsClient(srv.URL))
+
+	err := p.Update(context.Background(), &resource.UpdateRequest{Previous: prev, ConfigChanged: true})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	want, err := defaultKeyPolicy(prev.ARN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy != want {
+		t.Errorf("Policy = %s, want %s", policy, want)
+	}
+	if !strings.Contains(policy, `"arn:aws:iam::123456789012:root"`) {
+		t.Errorf("Policy does not grant access to the account:\n%s", policy)
+	}
+}
+
+func TestKMSKey_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   kms.KeyState
+		wantErr bool
+	}{
+		{"PendingDeletion", kms.KeyStatePendingDeletion, false},
+		{"PendingImport", kms.KeyStatePendingImport, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch target := r.Header.Get("X-Amz-Target"); target {
+				case "TrentService.ScheduleKeyDeletion":
+					w.WriteHeader(http.StatusBadRequest)
+					fmt.Fprint(w, `{"__type":"KMSInvalidStateException","message":"invalid state"}`)
+				case "TrentService.DescribeKey":
+					fmt.Fprintf(w, `{"KeyMetadata":{"KeyId":"key","KeyState":%q}}`, tt.state)
+				default:
+					t.Errorf("Unexpected target %q", target)
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}))
+			defer srv.Close()
+
+			p := &KMSKey{
+				Resource: base.Resource{Region: "us-east-1"},
+				KeyID:    "key",
+			}
+			p.SetClient(kmsClient(srv.URL))
+			err := p.Delete(context.Background(), &resource.DeleteRequest{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Delete() error = %v, wantErr = %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKMSTags(t *testing.T) {
+	var got []kms.Tag
+	tags.Convert(map[string]string{"b": "2", "a": "1"}, &got, "TagKey", "TagValue")
+	want := []kms.Tag{
+		{TagKey: aws.String("a"), TagValue: aws.String("1")},
+		{TagKey: aws.String("b"), TagValue: aws.String("2")},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Convert() (-got, +want)\n%s", diff)
+	}
+}
+
+func kmsClient(endpoint string) *kms.Client {
+	cfg := defaults.Config()
+	cfg.Region = "us-east-1"
+	cfg.Credentials = aws.NewStaticCredentialsProvider("key", "secret", "")
+	cfg.EndpointResolver = aws.ResolveWithEndpointURL(endpoint)
+	return kms.New(cfg)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 )
 
@@ -60,7 +61,7 @@ type LambdaEventSourceMapping struct {
 	// With StartingPosition set to AT_TIMESTAMP, the RFC3339 formatted time from which to start reading.
 	StartingPositionTimestamp *string `func:"input"`
 
-	Region string `func:"input"`
+	base.Resource
 
 	// Outputs
 
@@ -72,13 +73,11 @@ type LambdaEventSourceMapping struct {
 
 	// The identifier of the event source mapping.
 	UUID string `func:"output"`
-
-	lambdaService
 }
 
 // Create creates an AWS lambda function.
 func (p *LambdaEventSourceMapping) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.Lambda(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -106,7 +105,7 @@ func (p *LambdaEventSourceMapping) Create(ctx context.Context, r *resource.Creat
 
 	resp, err := svc.CreateEventSourceMappingRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.FunctionARN = *resp.FunctionArn
@@ -118,7 +117,7 @@ func (p *LambdaEventSourceMapping) Create(ctx context.Context, r *resource.Creat
 
 // Delete deletes the lambda function.
 func (p *LambdaEventSourceMapping) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.Lambda(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -131,12 +130,12 @@ func (p *LambdaEventSourceMapping) Delete(ctx context.Context, r *resource.Delet
 	}
 
 	_, err = svc.DeleteEventSourceMappingRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
 // Update updates the lambda function.
 func (p *LambdaEventSourceMapping) Update(ctx context.Context, r *resource.UpdateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.Lambda(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -155,7 +154,7 @@ func (p *LambdaEventSourceMapping) Update(ctx context.Context, r *resource.Updat
 
 	resp, err := svc.UpdateEventSourceMappingRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.FunctionARN = *resp.FunctionArn
@@ -12,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/lambda/lambdaiface"
 	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 	"github.com/func/func/source/convert"
 	"github.com/pkg/errors"
@@ -63,7 +64,10 @@ type LambdaFunction struct {
 	//
 	// The length constraint applies only to the full ARN. If you specify only
 	// the function name, it is limited to 64 characters in length.
-	FunctionName string `func:"input" validate:"min=1,max=64"`
+	//
+	// If not set, the name is derived from the project, module and resource
+	// names.
+	FunctionName *string `func:"input" validate:"min=1,max=64"`
 
 	// The name of the method within your code that Lambda calls to execute
 	// your function. For more information, see
@@ -87,8 +91,7 @@ type LambdaFunction struct {
 	// creation.
 	Publish *bool `func:"input"`
 
-	// Region to run the Lambda function in.
-	Region string `func:"input"`
+	base.Resource
 
 	// The Amazon Resource Name (ARN) of the function's execution role
 	// (http://docs.aws.amazon.com/lambda/latest/dg/intro-permission-model.html#lambda-intro-execution-role).
@@ -106,7 +109,7 @@ type LambdaFunction struct {
 	// The amount of time that Lambda allows a function to run before
 	// terminating it. The default is 3 seconds. The maximum allowed value is
 	// 900 seconds.
-	Timeout *int64 `func:"input" validate:"min=1,max=900"`
+	Timeout *int64 `func:"input,duration" validate:"min=1,max=900"`
 
 	// Set Mode to Active to sample and trace a subset of incoming requests
 	// with AWS X-Ray.
@@ -150,8 +153,14 @@ type LambdaFunction struct {
 
 	// The version of the Lambda function.
 	Version *string `func:"output"`
+}
 
-	lambdaService
+// DefaultName sets the function name to a name derived from the address of
+// the resource, if it was not set.
+func (p *LambdaFunction) DefaultName(addr string) {
+	if p.FunctionName == nil {
+		p.FunctionName = aws.String(defaultName(addr, 64))
+	}
 }
 
 // Create creates an AWS lambda function.
@@ -163,7 +172,7 @@ func (p *LambdaFunction) Create(ctx context.Context, r *resource.CreateRequest)
 		return backoff.Permanent(fmt.Errorf("only one source archive allowed"))
 	}
 
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.Lambda(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -185,7 +194,7 @@ func (p *LambdaFunction) Create(ctx context.Context, r *resource.CreateRequest)
 			ZipFile: zip.Bytes(),
 		},
 		Description:  p.Description,
-		FunctionName: aws.String(p.FunctionName),
+		FunctionName: p.FunctionName,
 		Handler:      aws.String(p.Handler),
 		KMSKeyArn:    p.KMSKeyArn,
 		Layers:       p.Layers,
@@ -233,7 +242,7 @@ func (p *LambdaFunction) Create(ctx context.Context, r *resource.CreateRequest)
 				return err
 			}
 		}
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	// OK
@@ -256,7 +265,7 @@ func (p *LambdaFunction) Create(ctx context.Context, r *resource.CreateRequest)
 
 // Delete deletes the lambda function.
 func (p *LambdaFunction) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.Lambda(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -269,12 +278,12 @@ func (p *LambdaFunction) Delete(ctx context.Context, r *resource.DeleteRequest)
 	}
 
 	_, err = svc.DeleteFunctionRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
 // Update updates the lambda function.
 func (p *LambdaFunction) Update(ctx context.Context, r *resource.UpdateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.Lambda(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -323,7 +332,7 @@ func (p *LambdaFunction) updateCode(ctx context.Context, svc lambdaiface.ClientA
 
 	resp, err := svc.UpdateFunctionCodeRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.CodeSha256 = resp.CodeSha256
@@ -383,7 +392,7 @@ func (p *LambdaFunction) updateConfig(ctx context.Context, svc lambdaiface.Clien
 
 	resp, err := svc.UpdateFunctionConfigurationRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.CodeSha256 = resp.CodeSha256
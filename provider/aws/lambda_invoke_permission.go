@@ -6,6 +6,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 )
 
@@ -17,11 +18,11 @@ type LambdaInvokePermission struct {
 	// is a string starting with lambda: followed by the API name . For example,
 	// lambda:CreateFunction. You can use wildcard (lambda:*) to grant permission
 	// for all AWS Lambda actions.
-	Action string `func:"input"`
+	Action string `func:"input,force_new"`
 
 	// A unique token that must be supplied by the principal invoking the function.
 	// This is currently only used for Alexa Smart Home functions.
-	EventSourceToken *string `func:"input"`
+	EventSourceToken *string `func:"input,force_new"`
 
 	// The name of the Lambda function.
 	//
@@ -33,27 +34,26 @@ type LambdaInvokePermission struct {
 	//
 	// The length constraint applies only to the full ARN. If you specify only
 	// the function name, it is limited to 64 characters in length.
-	FunctionName string `func:"input"`
+	FunctionName string `func:"input,force_new"`
 
 	// The principal who is getting this permission. The principal can be an
 	// AWS service (e.g. `s3.amazonaws.com` or `sns.amazonaws.com`) for service
 	// triggers, or an account ID for cross-account access. If you specify a
 	// service as a principal, use the SourceArn parameter to limit who can
 	// invoke the function through that service.
-	Principal string `func:"input"`
+	Principal string `func:"input,force_new"`
 
-	// Region the Lambda function has been deployed to.
-	Region string `func:"input"`
+	base.ForceNewResource
 
 	// Specify a version or alias to add permissions to a published version of the
 	// function.
-	Qualifier *string `func:"input"`
+	Qualifier *string `func:"input,force_new"`
 
 	// An optional value you can use to ensure you are updating the latest update
 	// of the function version or alias. If the RevisionID you pass doesn't match
 	// the latest RevisionID of the function or alias, it will fail with an error
 	// message.
-	RevisionID *string `func:"input"`
+	RevisionID *string `func:"input,force_new"`
 
 	// This parameter is used for S3 and SES. The AWS account ID (without a hyphen)
 	// of the source owner. For example, if the SourceArn identifies a bucket, then
@@ -62,17 +62,17 @@ type LambdaInvokePermission struct {
 	// the bucket owner deleted the bucket and some other AWS account created the
 	// bucket). You can also use this condition to specify all sources (that is,
 	// you don't specify the SourceArn) owned by a specific account.
-	SourceAccount *string `func:"input"`
+	SourceAccount *string `func:"input,force_new"`
 
 	// The Amazon Resource Name of the invoker.
 	//
 	// If you add a permission to a service principal without providing the source
 	// ARN, any AWS account that creates a mapping to your function ARN can invoke
 	// your Lambda function.
-	SourceARN *string `func:"input" validate:"aws_arn"`
+	SourceARN *string `func:"input,force_new" validate:"aws_arn"`
 
 	// A unique statement identifier.
-	StatementID string `func:"input"`
+	StatementID string `func:"input,force_new"`
 
 	// Outputs
 
@@ -80,13 +80,11 @@ type LambdaInvokePermission struct {
 	// the same as a string using a backslash ("\") as an escape character in the
 	// JSON.
 	Statement *string `func:"output"`
-
-	lambdaService
 }
 
 // Create creates an AWS lambda function.
 func (p *LambdaInvokePermission) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.Lambda(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -108,7 +106,7 @@ func (p *LambdaInvokePermission) Create(ctx context.Context, r *resource.CreateR
 
 	resp, err := svc.AddPermissionRequest(input).Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.Statement = resp.Statement
@@ -118,7 +116,7 @@ func (p *LambdaInvokePermission) Create(ctx context.Context, r *resource.CreateR
 
 // Delete deletes the lambda function.
 func (p *LambdaInvokePermission) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.Lambda(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -134,21 +132,11 @@ func (p *LambdaInvokePermission) Delete(ctx context.Context, r *resource.DeleteR
 	}
 
 	_, err = svc.RemovePermissionRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
-// Update updates the lambda function.
+// Update is a no-op. A permission cannot be updated, changing any input
+// replaces the permission.
 func (p *LambdaInvokePermission) Update(ctx context.Context, r *resource.UpdateRequest) error {
-	// Permission cannot be updated, delete and create nwe
-
-	prev := r.Previous.(*LambdaInvokePermission)
-
-	if err := prev.Delete(ctx, r.DeleteRequest()); err != nil {
-		return err
-	}
-	if err := p.Create(ctx, r.CreateRequest()); err != nil {
-		return err
-	}
-
 	return nil
 }
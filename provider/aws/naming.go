@@ -0,0 +1,27 @@
+package aws
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// defaultName returns a name derived from the address of a resource, for
+// resources that are named in AWS. Characters other than letters, digits,
+// hyphens and underscores are replaced with hyphens. A name longer than max
+// is truncated and suffixed with a hash of the address, so truncated names
+// remain unique.
+func defaultName(addr string, max int) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '-'
+	}, addr)
+	if len(name) <= max {
+		return name
+	}
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte(addr)))[:8]
+	return name[:max-len(sum)-1] + "-" + sum
+}
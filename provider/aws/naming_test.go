@@ -0,0 +1,45 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/func/func/resource"
+)
+
+func TestDefaultName(t *testing.T) {
+	tests := []struct {
+		name string
+		addr string
+		max  int
+		want string
+	}{
+		{"Address", "proj-mod-users", 64, "proj-mod-users"},
+		{"Replaced", "proj-users-a b/c", 64, "proj-users-a-b-c"},
+		{"Truncated", "proj-" + strings.Repeat("a", 20), 16, "proj-aa-d193d2e3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := defaultName(tt.addr, tt.max)
+			if got != tt.want {
+				t.Errorf("defaultName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultName_namers(t *testing.T) {
+	table := &DynamoDBTable{}
+	fn := &LambdaFunction{FunctionName: aws.String("custom")}
+	for _, def := range []resource.Definition{table, fn} {
+		def.(resource.Namer).DefaultName("proj-users")
+	}
+	if got := aws.StringValue(table.TableName); got != "proj-users" {
+		t.Errorf("TableName = %q, want %q", got, "proj-users")
+	}
+	// Names set in config are not changed.
+	if got := aws.StringValue(fn.FunctionName); got != "custom" {
+		t.Errorf("FunctionName = %q, want %q", got, "custom")
+	}
+}
@@ -14,6 +14,7 @@ type validator interface {
 
 // Register adds all supported AWS resources to the registry.
 func Register(reg registry) {
+	reg.Register("aws_acm_certificate", &ACMCertificate{})
 	reg.Register("aws_apigateway_deployment", &APIGatewayDeployment{})
 	reg.Register("aws_apigateway_integration", &APIGatewayIntegration{})
 	reg.Register("aws_apigateway_method", &APIGatewayMethod{})
@@ -21,14 +22,20 @@ func Register(reg registry) {
 	reg.Register("aws_apigateway_rest_api", &APIGatewayRestAPI{})
 	reg.Register("aws_apigateway_stage", &APIGatewayStage{})
 	reg.Register("aws_dynamodb_table", &DynamoDBTable{})
+	reg.Register("aws_ec2_subnet", &EC2Subnet{})
+	reg.Register("aws_ec2_vpc", &EC2VPC{})
+	reg.Register("aws_ecr_repository", &ECRRepository{})
+	reg.Register("aws_eventbridge_rule", &EventBridgeRule{})
 	reg.Register("aws_iam_policy", &IAMPolicy{})
 	reg.Register("aws_iam_policy_document", &IAMPolicyDocument{})
 	reg.Register("aws_iam_role", &IAMRole{})
 	reg.Register("aws_iam_role_policy", &IAMRolePolicy{})
 	reg.Register("aws_iam_role_policy_attachment", &IAMRolePolicyAttachment{})
+	reg.Register("aws_kms_key", &KMSKey{})
 	reg.Register("aws_lambda_event_source_mapping", &LambdaEventSourceMapping{})
 	reg.Register("aws_lambda_function", &LambdaFunction{})
 	reg.Register("aws_lambda_invoke_permission", &LambdaInvokePermission{})
+	reg.Register("aws_secretsmanager_secret", &SecretsManagerSecret{})
 	reg.Register("aws_sqs_queue", &SQSQueue{})
 	reg.Register("aws_sts_caller_identity", &STSCallerIdentity{})
 }
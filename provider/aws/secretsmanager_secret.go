@@ -0,0 +1,209 @@
+package aws
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/secretsmanageriface"
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
+	"github.com/func/func/provider/aws/internal/tags"
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+)
+
+// SecretsManagerSecret stores a secret value in AWS Secrets Manager.
+//
+// The secret can be read by other resources, such as Lambda functions, at
+// runtime, to avoid setting secret values in environment variables.
+//
+// When the resource is deleted, the secret is scheduled for deletion after a
+// recovery window. The secret can be restored in Secrets Manager during the
+// recovery window.
+//
+// https://aws.amazon.com/secrets-manager/
+type SecretsManagerSecret struct {
+	// Inputs
+
+	// A description of the secret.
+	Description *string `func:"input"`
+
+	// The ARN, key ID or alias of the AWS KMS customer master key (CMK) to
+	// encrypt the secret value with.
+	//
+	// If not set, the default CMK for Secrets Manager in the account is used.
+	KMSKeyID *string `func:"input" name:"kms_key_id"`
+
+	// The friendly name of the secret. The name can contain ASCII letters,
+	// numbers and the characters /_+=.@-
+	//
+	// Changing the name creates a new secret and deletes the previous one.
+	Name string `func:"input,force_new"`
+
+	// The number of days Secrets Manager waits before deleting the secret
+	// after the resource has been removed. If not set, the default recovery
+	// window of 30 days is used.
+	RecoveryWindowInDays *int64 `func:"input" validate:"min=7,max=30"`
+
+	base.ForceNewResource
+
+	// The secret value to store.
+	//
+	// The value is sensitive and is never displayed.
+	SecretString string `func:"input" sensitive:"true"`
+
+	// Tags to attach to the secret.
+	Tags map[string]string `func:"input"`
+
+	// Outputs
+
+	// The Amazon Resource Name (ARN) of the secret.
+	ARN string `func:"output"`
+
+	// The identifier of the current version of the secret value.
+	VersionID string `func:"output"`
+}
+
+// Create creates a new secret with the secret value.
+func (p *SecretsManagerSecret) Create(ctx context.Context, r *resource.CreateRequest) error {
+	svc, err := base.SecretsManager(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	input := &secretsmanager.CreateSecretInput{
+		Description:  p.Description,
+		KmsKeyId:     p.KMSKeyID,
+		Name:         aws.String(p.Name),
+		SecretString: aws.String(p.SecretString),
+	}
+	tags.Convert(p.Tags, &input.Tags, "Key", "Value")
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+
+	resp, err := svc.CreateSecretRequest(input).Send(ctx)
+	if err != nil {
+		return base.Classify(err)
+	}
+
+	p.ARN = *resp.ARN
+	p.VersionID = *resp.VersionId
+
+	return nil
+}
+
+// Delete schedules the secret for deletion.
+//
+// Deletion in Secrets Manager is asynchronous; Delete returns as soon as the
+// deletion has been scheduled.
+func (p *SecretsManagerSecret) Delete(ctx context.Context, r *resource.DeleteRequest) error {
+	svc, err := base.SecretsManager(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	input := &secretsmanager.DeleteSecretInput{
+		SecretId:             aws.String(p.ARN),
+		RecoveryWindowInDays: p.RecoveryWindowInDays,
+	}
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err = svc.DeleteSecretRequest(input).Send(ctx)
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case secretsmanager.ErrCodeResourceNotFoundException:
+			// Already deleted
+			return nil
+		case secretsmanager.ErrCodeInvalidRequestException:
+			// The code is also used for other invalid requests.
+			if strings.Contains(aerr.Message(), "scheduled for deletion") {
+				return nil
+			}
+		}
+	}
+	return base.DeleteError(err)
+}
+
+// Update updates the secret. A new version of the secret value is only put if
+// the value changed.
+func (p *SecretsManagerSecret) Update(ctx context.Context, r *resource.UpdateRequest) error {
+	prev := r.Previous.(*SecretsManagerSecret)
+
+	svc, err := base.SecretsManager(p, r.Auth)
+	if err != nil {
+		return errors.Wrap(err, "get client")
+	}
+
+	p.ARN = prev.ARN
+	p.VersionID = prev.VersionID
+
+	if !equalStringPtr(p.Description, prev.Description) || !equalStringPtr(p.KMSKeyID, prev.KMSKeyID) {
+		desc := ""
+		if p.Description != nil {
+			desc = *p.Description
+		}
+		input := &secretsmanager.UpdateSecretInput{
+			SecretId:    aws.String(p.ARN),
+			Description: aws.String(desc),
+			KmsKeyId:    p.KMSKeyID,
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		if _, err := svc.UpdateSecretRequest(input).Send(ctx); err != nil {
+			return base.Classify(err)
+		}
+	}
+
+	if p.SecretString != prev.SecretString {
+		input := &secretsmanager.PutSecretValueInput{
+			SecretId:     aws.String(p.ARN),
+			SecretString: aws.String(p.SecretString),
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		resp, err := svc.PutSecretValueRequest(input).Send(ctx)
+		if err != nil {
+			return base.Classify(err)
+		}
+		p.VersionID = *resp.VersionId
+	}
+
+	return p.updateTags(ctx, svc, prev.Tags)
+}
+
+func (p *SecretsManagerSecret) updateTags(ctx context.Context, svc secretsmanageriface.ClientAPI, prev map[string]string) error { // nolint: lll
+	diff := tags.Compare(prev, p.Tags)
+	if len(diff.Remove) > 0 {
+		input := &secretsmanager.UntagResourceInput{
+			SecretId: aws.String(p.ARN),
+			TagKeys:  diff.Remove,
+		}
+		if err := input.Validate(); err != nil {
+			return backoff.Permanent(err)
+		}
+		if _, err := svc.UntagResourceRequest(input).Send(ctx); err != nil {
+			return base.Classify(err)
+		}
+	}
+
+	set := diff.Set()
+	if len(set) == 0 {
+		return nil
+	}
+	input := &secretsmanager.TagResourceInput{
+		SecretId: aws.String(p.ARN),
+	}
+	tags.Convert(set, &input.Tags, "Key", "Value")
+	if err := input.Validate(); err != nil {
+		return backoff.Permanent(err)
+	}
+	_, err := svc.TagResourceRequest(input).Send(ctx)
+	return base.Classify(err)
+}
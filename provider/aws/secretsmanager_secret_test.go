@@ -0,0 +1,185 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/defaults"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/func/func/provider/aws/internal/base"
+	"github.com/func/func/resource"
+	"github.com/func/func/resource/hcldecoder"
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+)
+
+func TestSecretsManagerSecret_fields(t *testing.T) {
+	fields := resource.Fields(reflect.TypeOf(SecretsManagerSecret{}))
+
+	// The name and region cannot be changed, the secret is replaced.
+	for _, name := range []string{"name", "region"} {
+		if !fields[name].ForceNew() {
+			t.Errorf("Input %s does not force new", name)
+		}
+	}
+	if !fields["secret_string"].Sensitive() {
+		t.Errorf("Secret string is not sensitive")
+	}
+}
+
+func TestSecretsManagerSecret_graphJSON(t *testing.T) {
+	src := `
+		resource "db" {
+		  type          = "aws_secretsmanager_secret"
+		  name          = "db-password"
+		  region        = "us-east-1"
+		  secret_string = "hunter2"
+		}
+	`
+	f, diags := hclsyntax.ParseConfig([]byte(src), "func.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	reg := &resource.Registry{}
+	reg.Register("aws_secretsmanager_secret", &SecretsManagerSecret{})
+	dec := &hcldecoder.Decoder{
+		Resources: reg,
+		Validator: nopValidator{},
+	}
+	g := &resource.Graph{}
+	if _, diags := dec.DecodeBody(f.Body, g); diags.HasErrors() {
+		t.Fatalf("DecodeBody() diagnostics = %v", diags)
+	}
+
+	got, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	if bytes.Contains(got, []byte("hunter2")) {
+		t.Errorf("Secret value is not redacted\n%s", got)
+	}
+	if !bytes.Contains(got, []byte("db-password")) {
+		t.Errorf("Name is missing\n%s", got)
+	}
+}
+
+type nopValidator struct{}
+
+func (nopValidator) Validate(interface{}, string) error { return nil }
+
+func TestSecretsManagerSecret_Update(t *testing.T) {
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "secretsmanager.")
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Decode body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch action {
+		case "UpdateSecret":
+			calls = append(calls, fmt.Sprintf("%s %v", action, body["Description"]))
+			fmt.Fprint(w, `{"ARN":"arn:secret"}`)
+		case "PutSecretValue":
+			calls = append(calls, fmt.Sprintf("%s %v", action, body["SecretString"]))
+			fmt.Fprint(w, `{"ARN":"arn:secret","VersionId":"v2"}`)
+		default:
+			t.Errorf("Unexpected action %q", action)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	prev := &SecretsManagerSecret{
+		Name:             "foo",
+		ForceNewResource: base.ForceNewResource{Region: "us-east-1"},
+		SecretString:     "old",
+		ARN:              "arn:secret",
+		VersionID:        "v1",
+	}
+	p := &SecretsManagerSecret{
+		Description:      aws.String("desc"),
+		Name:             "foo",
+		ForceNewResource: base.ForceNewResource{Region: "us-east-1"},
+		SecretString:     "new",
+	}
+	p.SetClient(secretsManagerClient(srv.URL))
+
+	err := p.Update(context.Background(), &resource.UpdateRequest{Previous: prev, ConfigChanged: true})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	want := []string{
+		"UpdateSecret desc",
+		"PutSecretValue new",
+	}
+	if diff := cmp.Diff(calls, want); diff != "" {
+		t.Errorf("Calls (-got +want)\n%s", diff)
+	}
+	if p.ARN != "arn:secret" {
+		t.Errorf("ARN = %q, want %q", p.ARN, "arn:secret")
+	}
+	if p.VersionID != "v2" {
+		t.Errorf("VersionID = %q, want %q", p.VersionID, "v2")
+	}
+}
+
+func TestSecretsManagerSecret_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		message string
+		wantErr bool
+	}{
+		{"OK", "", "", false},
+		{"NotFound", "ResourceNotFoundException", "Secrets Manager can't find the specified secret.", false},
+		{"Scheduled", "InvalidRequestException", "You can't perform this operation on the secret because it was already scheduled for deletion.", false}, // nolint: lll
+		{"InvalidRequest", "InvalidRequestException", "You can't delete a secret that is replicated.", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("X-Amz-Target"); got != "secretsmanager.DeleteSecret" {
+					t.Errorf("Target = %q, want %q", got, "secretsmanager.DeleteSecret")
+				}
+				if tt.code != "" {
+					w.WriteHeader(http.StatusBadRequest)
+					fmt.Fprintf(w, `{"__type":%q,"message":%q}`, tt.code, tt.message)
+					return
+				}
+				fmt.Fprint(w, `{"ARN":"arn:secret","Name":"foo"}`)
+			}))
+			defer srv.Close()
+
+			p := &SecretsManagerSecret{
+				Name:             "foo",
+				ForceNewResource: base.ForceNewResource{Region: "us-east-1"},
+				ARN:              "arn:secret",
+			}
+			p.SetClient(secretsManagerClient(srv.URL))
+			err := p.Delete(context.Background(), &resource.DeleteRequest{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Delete() error = %v, wantErr = %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func secretsManagerClient(endpoint string) *secretsmanager.Client {
+	cfg := defaults.Config()
+	cfg.Region = "us-east-1"
+	cfg.Credentials = aws.NewStaticCredentialsProvider("key", "secret", "")
+	cfg.EndpointResolver = aws.ResolveWithEndpointURL(endpoint)
+	return secretsmanager.New(cfg)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws/awserr"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/cenkalti/backoff"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 	"github.com/pkg/errors"
 )
@@ -38,7 +39,7 @@ type SQSQueue struct {
 	// The length of time, in seconds, for which the delivery of all messages
 	// in the queue is delayed. Valid values: An integer from 0 to 900 seconds
 	// (15 minutes). Default: 0.
-	Delay *int `func:"input" validate:"min=0,max=900"`
+	Delay *int `func:"input,duration" validate:"min=0,max=900"`
 
 	// The limit of how many bytes a message can contain before Amazon SQS
 	// rejects it. Valid values: An integer from 1,024 bytes (1 KiB) to 262,144
@@ -48,7 +49,7 @@ type SQSQueue struct {
 	// The length of time, in seconds, for which Amazon SQS retains a message.
 	// Valid values: An integer from 60 seconds (1 minute) to 1,209,600 seconds
 	// (14 days). Default: 345,600 (4 days).
-	MessageRetentionPeriod *int `func:"input" validate:"min=60,max=1209600"`
+	MessageRetentionPeriod *int `func:"input,duration" validate:"min=60,max=1209600"`
 
 	// The queue's policy. A valid AWS policy. For more information about
 	// policy structure, see Overview of [AWS IAM
@@ -59,7 +60,7 @@ type SQSQueue struct {
 	// The length of time, in seconds, for which a ReceiveMessage action waits
 	// for a message to arrive. Valid values: An integer from 0 to 20
 	// (seconds). Default: 0.
-	ReceiveMessageWaitTime *int `func:"input" validate:"min=0,max=20"`
+	ReceiveMessageWaitTime *int `func:"input,duration" validate:"min=0,max=20"`
 
 	// Parameters for the dead-letter queue functionality of the source queue.
 	// For more information about the redrive policy and dead-letter queues,
@@ -88,7 +89,7 @@ type SQSQueue struct {
 	// For more information about the visibility timeout, see [Visibility
 	// Timeout](https://docs.aws.amazon.com/AWSSimpleQueueService/latest/SQSDeveloperGuide/sqs-visibility-timeout.html)
 	// in the Amazon Simple Queue Service Developer Guide.
-	VisibilityTimeout *int `func:"input" validate:"min=0,max=43200"`
+	VisibilityTimeout *int `func:"input,duration" validate:"min=0,max=43200"`
 
 	// The ID of an AWS-managed customer master key (CMK) for Amazon SQS or a
 	// custom CMK. For more information, see [Key
@@ -110,7 +111,7 @@ type SQSQueue struct {
 	// to KMS which might incur charges after Free Tier. For more information,
 	// see [How Does the Data Key Reuse Period
 	// Work?](https://docs.aws.amazon.com/AWSSimpleQueueService/latest/SQSDeveloperGuide/sqs-server-side-encryption.html).
-	KMSDataKeyReusePeriod *int `func:"input" name:"kms_data_key_reuse_period" validate:"min=60,max=86400"`
+	KMSDataKeyReusePeriod *int `func:"input,duration" name:"kms_data_key_reuse_period" validate:"min=60,max=86400"`
 
 	// Designates a queue as FIFO. If you don't specify the FifoQueue
 	// attribute, Amazon SQS creates a standard queue. You can provide this
@@ -157,23 +158,20 @@ type SQSQueue struct {
 	//
 	// Queue URLs and names are case-sensitive.
 	//
-	// QueueName is a required field
-	QueueName string `func:"input"`
+	// QueueName is a required field. Changing the name replaces the queue.
+	QueueName string `func:"input,force_new"`
 
-	// The region to create the queue in.
-	Region string `func:"input"`
+	base.Resource
 
 	// Outputs
 
 	QueueURL string `func:"output"`
 	QueueARN string `func:"output"`
-
-	sqsService
 }
 
 // Create creates a new rest api.
 func (p *SQSQueue) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.SQS(p, r.Auth)
 	if err != nil {
 		return errors.Wrap(err, "get client")
 	}
@@ -194,7 +192,7 @@ func (p *SQSQueue) Create(ctx context.Context, r *resource.CreateRequest) error
 				return err
 			}
 		}
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.QueueURL = *resp.CreateQueueOutput.QueueUrl
@@ -213,7 +211,7 @@ func (p *SQSQueue) Create(ctx context.Context, r *resource.CreateRequest) error
 
 // Delete removes an SQS queue.
 func (p *SQSQueue) Delete(ctx context.Context, r *resource.DeleteRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.SQS(p, r.Auth)
 	if err != nil {
 		return errors.Wrap(err, "get client")
 	}
@@ -225,14 +223,14 @@ func (p *SQSQueue) Delete(ctx context.Context, r *resource.DeleteRequest) error
 		return backoff.Permanent(err)
 	}
 	_, err = svc.DeleteQueueRequest(input).Send(ctx)
-	return handleDelError(err)
+	return base.DeleteError(err)
 }
 
 // Update updates the attributes of an SQS queue.
 //
 // For now, only updating attributes is supported.
 func (p *SQSQueue) Update(ctx context.Context, r *resource.UpdateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.SQS(p, r.Auth)
 	if err != nil {
 		return errors.Wrap(err, "get client")
 	}
@@ -247,7 +245,7 @@ func (p *SQSQueue) Update(ctx context.Context, r *resource.UpdateRequest) error
 		return backoff.Permanent(err)
 	}
 	_, err = svc.SetQueueAttributesRequest(input).Send(ctx)
-	return handlePutError(err)
+	return base.Classify(err)
 }
 
 func (SQSQueue) attributes(p *SQSQueue) map[string]string {
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/func/func/provider/aws/internal/base"
 	"github.com/func/func/resource"
 )
 
@@ -11,11 +12,7 @@ import (
 type STSCallerIdentity struct {
 	// Inputs
 
-	// Region to use for STS API calls.
-	//
-	// STS is global so the calls are not regional but the Region will specify
-	// which region the API calls are sent to.
-	Region *string `func:"input"`
+	base.GlobalResource
 
 	// Outputs
 
@@ -32,13 +29,11 @@ type STSCallerIdentity struct {
 	// [Principal table](http://docs.aws.amazon.com/IAM/latest/UserGuide/reference_policies_variables.html#principaltable)
 	// found on the Policy Variables reference page in the IAM User Guide.
 	UserID *string `func:"output"`
-
-	stsService
 }
 
 // Create reads the current caller identity
 func (p *STSCallerIdentity) Create(ctx context.Context, r *resource.CreateRequest) error {
-	svc, err := p.service(r.Auth, p.Region)
+	svc, err := base.STS(p, r.Auth)
 	if err != nil {
 		return err
 	}
@@ -46,7 +41,7 @@ func (p *STSCallerIdentity) Create(ctx context.Context, r *resource.CreateReques
 	req := svc.GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
 	resp, err := req.Send(ctx)
 	if err != nil {
-		return handlePutError(err)
+		return base.Classify(err)
 	}
 
 	p.Account = resp.Account
@@ -2,6 +2,7 @@ package aws
 
 import (
 	"fmt"
+	"net"
 
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
 )
@@ -9,6 +10,7 @@ import (
 // AddValidators add AWS specific validator rules.
 func AddValidators(validator validator) {
 	validator.Add("aws_arn", validARN)
+	validator.Add("aws_cidr", validCIDR)
 }
 
 func validARN(value interface{}, param string) error {
@@ -19,3 +21,18 @@ func validARN(value interface{}, param string) error {
 	_, err := arn.Parse(str)
 	return err
 }
+
+func validCIDR(value interface{}, param string) error {
+	str, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("input is not a string")
+	}
+	ip, _, err := net.ParseCIDR(str)
+	if err != nil {
+		return err
+	}
+	if ip.To4() == nil {
+		return fmt.Errorf("%s is not an IPv4 CIDR block", str)
+	}
+	return nil
+}
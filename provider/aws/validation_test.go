@@ -26,3 +26,25 @@ func TestValidationARN(t *testing.T) {
 		})
 	}
 }
+
+func TestValidationCIDR(t *testing.T) {
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{"10.0.0.0/16", true},
+		{"10.0.1.0/24", true},
+		{"10.0.0.0", false},
+		{"10.0.0.0/33", false},
+		{"2001:db8::/32", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			err := validCIDR(tt.input, "")
+			if (err == nil) != tt.valid {
+				t.Errorf("got err = %v, want err = %t", err, tt.valid)
+			}
+		})
+	}
+}
@@ -1,2 +1,3 @@
-// Package provider contains cloud provider specific resource providers.
+// Package provider contains resource providers: cloud provider specific
+// resources, and local resources that are not tied to a cloud provider.
 package provider
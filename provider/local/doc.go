@@ -0,0 +1,7 @@
+// Package local provides resources that are not tied to a cloud provider.
+// The resources run on the machine that applies the resources.
+//
+// The resources run commands from the config with the privileges of the
+// process applying them. They should only be registered where the config is
+// trusted, such as the CLI, and not on a server shared by several clients.
+package local
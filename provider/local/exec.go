@@ -0,0 +1,138 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/resource"
+)
+
+// Exec runs a command on the machine that applies the resources. It is
+// useful for glue steps, such as running a script after another resource has
+// been created.
+//
+// The command is run when the resource is created and again whenever the
+// triggers change. The command is run with sh -c, so it may use shell
+// features such as pipes and redirects.
+type Exec struct {
+	// Inputs
+
+	// The command to run when the resource is created, or when the triggers
+	// change.
+	Command string `func:"input"`
+
+	// The directory to run the command in. If not set, the command is run in
+	// the working directory of the process applying the resources.
+	WorkingDir *string `func:"input"`
+
+	// Environment variables to set for the command, in addition to the
+	// environment of the process applying the resources.
+	Environment map[string]string `func:"input"`
+
+	// Arbitrary values that cause the command to run again when changed.
+	// Changes to other inputs do not run the command again.
+	//
+	// Referring to an output of another resource, such as a version, runs
+	// the command whenever the other resource changes.
+	Triggers map[string]string `func:"input"`
+
+	// An optional command to run when the resource is deleted. The command
+	// is run with the same working directory and environment.
+	DestroyCommand *string `func:"input"`
+
+	// Outputs
+
+	// The standard output of the most recent run of the command.
+	Stdout string `func:"output"`
+
+	// The standard error of the most recent run of the command.
+	Stderr string `func:"output"`
+}
+
+// Create runs the command.
+func (p *Exec) Create(ctx context.Context, r *resource.CreateRequest) error {
+	stdout, stderr, err := p.run(ctx, p.Command)
+	if err != nil {
+		return err
+	}
+	p.Stdout = stdout
+	p.Stderr = stderr
+	return nil
+}
+
+// Update runs the command again if the triggers have changed. Otherwise the
+// output from the previous run is kept.
+func (p *Exec) Update(ctx context.Context, r *resource.UpdateRequest) error {
+	prev := r.Previous.(*Exec)
+	if equalMaps(prev.Triggers, p.Triggers) {
+		p.Stdout = prev.Stdout
+		p.Stderr = prev.Stderr
+		return nil
+	}
+	return p.Create(ctx, r.CreateRequest())
+}
+
+// Delete runs the destroy command, if set.
+func (p *Exec) Delete(ctx context.Context, r *resource.DeleteRequest) error {
+	if p.DestroyCommand == nil {
+		return nil
+	}
+	_, _, err := p.run(ctx, *p.DestroyCommand)
+	return err
+}
+
+// run runs a command and returns its output.
+//
+// A command that exits with an error is not retried, as running it again
+// may repeat side effects. The error contains the standard error output.
+func (p *Exec) run(ctx context.Context, command string) (stdout, stderr string, err error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	if p.WorkingDir != nil {
+		cmd.Dir = *p.WorkingDir
+	}
+	cmd.Env = os.Environ()
+	keys := make([]string, 0, len(p.Environment))
+	for k := range p.Environment {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cmd.Env = append(cmd.Env, k+"="+p.Environment[k])
+	}
+
+	var outbuf, errbuf bytes.Buffer
+	cmd.Stdout = &outbuf
+	cmd.Stderr = &errbuf
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", "", ctx.Err()
+		}
+		msg := strings.TrimSpace(errbuf.String())
+		if msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", "", backoff.Permanent(err)
+	}
+	return outbuf.String(), errbuf.String(), nil
+}
+
+// equalMaps returns true if a and b contain the same keys and values. A nil
+// map is equal to an empty map.
+func equalMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package local
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/func/func/resource"
+)
+
+func TestExec_Create(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := &Exec{
+		Command:     "pwd; echo $GREETING; echo warning >&2",
+		WorkingDir:  &dir,
+		Environment: map[string]string{"GREETING": "hello"},
+	}
+	if err := p.Create(context.Background(), &resource.CreateRequest{}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	wantDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(p.Stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Stdout = %q, want 2 lines", p.Stdout)
+	}
+	if got, _ := filepath.EvalSymlinks(lines[0]); got != wantDir {
+		t.Errorf("Working dir = %q, want %q", lines[0], dir)
+	}
+	if lines[1] != "hello" {
+		t.Errorf("Environment variable = %q, want %q", lines[1], "hello")
+	}
+	if p.Stderr != "warning\n" {
+		t.Errorf("Stderr = %q, want %q", p.Stderr, "warning\n")
+	}
+}
+
+func TestExec_Create_error(t *testing.T) {
+	p := &Exec{Command: "echo failed >&2; exit 3"}
+	err := p.Create(context.Background(), &resource.CreateRequest{})
+	if err == nil {
+		t.Fatal("Create() error = nil, want error")
+	}
+	if _, ok := err.(*backoff.PermanentError); !ok {
+		t.Errorf("Error is %T, want permanent", err)
+	}
+	if !strings.Contains(err.Error(), "failed") {
+		t.Errorf("Error %q does not contain stderr", err)
+	}
+}
+
+func TestExec_Update(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	newExec := func(triggers map[string]string) *Exec {
+		return &Exec{
+			Command:    "echo run >> runs; wc -l < runs",
+			WorkingDir: &dir,
+			Triggers:   triggers,
+		}
+	}
+	runs := func() int {
+		b, err := ioutil.ReadFile(filepath.Join(dir, "runs"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Count(string(b), "run\n")
+	}
+
+	ctx := context.Background()
+	prev := newExec(map[string]string{"version": "1"})
+	if err := prev.Create(ctx, &resource.CreateRequest{}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Triggers not changed
+	next := newExec(map[string]string{"version": "1"})
+	if err := next.Update(ctx, &resource.UpdateRequest{Previous: prev, ConfigChanged: true}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := runs(); got != 1 {
+		t.Errorf("Runs after update with same triggers = %d, want 1", got)
+	}
+	if next.Stdout != prev.Stdout || next.Stderr != prev.Stderr {
+		t.Errorf("Output = %q, %q, want previous output %q, %q", next.Stdout, next.Stderr, prev.Stdout, prev.Stderr)
+	}
+	if got := strings.TrimSpace(next.Stdout); got != "1" {
+		t.Errorf("Stdout = %q, want output from first run", next.Stdout)
+	}
+
+	// Triggers changed
+	prev = next
+	next = newExec(map[string]string{"version": "2"})
+	if err := next.Update(ctx, &resource.UpdateRequest{Previous: prev, ConfigChanged: true}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := runs(); got != 2 {
+		t.Errorf("Runs after update with changed triggers = %d, want 2", got)
+	}
+	if got := strings.TrimSpace(next.Stdout); got != "2" {
+		t.Errorf("Stdout = %q, want output from second run", next.Stdout)
+	}
+}
+
+func TestExec_Delete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+
+	// No destroy command
+	p := &Exec{Command: "exit 1", WorkingDir: &dir}
+	if err := p.Delete(ctx, &resource.DeleteRequest{}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	destroy := "touch destroyed"
+	p.DestroyCommand = &destroy
+	if err := p.Delete(ctx, &resource.DeleteRequest{}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "destroyed")); err != nil {
+		t.Errorf("Destroy command did not run: %v", err)
+	}
+}
@@ -0,0 +1,14 @@
+package local
+
+import (
+	"github.com/func/func/resource"
+)
+
+type registry interface {
+	Register(typename string, def resource.Definition)
+}
+
+// Register adds all local resources to the registry.
+func Register(reg registry) {
+	reg.Register("local_exec", &Exec{})
+}
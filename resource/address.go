@@ -0,0 +1,119 @@
+package resource
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// An Address identifies a resource by its type and name, as in type.name.
+//
+// The name of a resource declared in a module is qualified with the name of
+// the module, as in type.module.name.
+//
+// An instance of a resource with for_each is addressed by its key, as in
+// type.name["key"]. An element can also be addressed by its index, as in
+// type.name[0]. At most one of Index and Key is set.
+type Address struct {
+	Type  string
+	Name  string
+	Index *int
+	Key   *string
+}
+
+// ParseAddress parses a resource address in one of the forms:
+//   type.name
+//   type.module.name
+//   type.name[0]
+//   type.name["key"]
+func ParseAddress(s string) (Address, error) {
+	var addr Address
+
+	dot := strings.Index(s, ".")
+	if dot < 0 {
+		return addr, errors.Errorf("invalid address %q: must be in format type.name", s)
+	}
+	addr.Type = s[:dot]
+	if !validAddressPart(addr.Type) {
+		return addr, errors.Errorf("invalid address %q: invalid type %q", s, addr.Type)
+	}
+
+	rest := s[dot+1:]
+	name := rest
+	if i := strings.Index(rest, "["); i >= 0 {
+		name = rest[:i]
+		rest = rest[i:]
+	} else {
+		rest = ""
+	}
+	for _, part := range strings.Split(name, ".") {
+		if !validAddressPart(part) {
+			return addr, errors.Errorf("invalid address %q: invalid name %q", s, name)
+		}
+	}
+	addr.Name = name
+
+	if rest == "" {
+		return addr, nil
+	}
+	if !strings.HasSuffix(rest, "]") {
+		return addr, errors.Errorf("invalid address %q: unbalanced brackets", s)
+	}
+	inner := rest[1 : len(rest)-1]
+
+	if strings.HasPrefix(inner, `"`) {
+		key, err := strconv.Unquote(inner)
+		if err != nil {
+			return addr, errors.Errorf("invalid address %q: invalid key %s", s, inner)
+		}
+		addr.Key = &key
+		return addr, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil || index < 0 || strings.HasPrefix(inner, "+") {
+		return addr, errors.Errorf("invalid address %q: index must be a non-negative integer or a quoted key", s)
+	}
+	addr.Index = &index
+	return addr, nil
+}
+
+// validAddressPart returns true if s is a valid type, module or resource name.
+func validAddressPart(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ResourceName returns the name of the resource in the graph, including the
+// key or index if set.
+func (a Address) ResourceName() string {
+	switch {
+	case a.Key != nil:
+		return fmt.Sprintf("%s[%q]", a.Name, *a.Key)
+	case a.Index != nil:
+		return fmt.Sprintf("%s[%d]", a.Name, *a.Index)
+	default:
+		return a.Name
+	}
+}
+
+// String returns the address in the form accepted by ParseAddress.
+func (a Address) String() string {
+	return a.Type + "." + a.ResourceName()
+}
+
+// Matches returns true if the address refers to the given resource.
+func (a Address) Matches(res *Desired) bool {
+	return a.Type == res.Type && a.ResourceName() == res.Name
+}
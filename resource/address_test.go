@@ -0,0 +1,96 @@
+package resource
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseAddress(t *testing.T) {
+	index := func(i int) *int { return &i }
+	key := func(k string) *string { return &k }
+
+	tests := []struct {
+		input   string
+		want    Address
+		wantErr bool
+	}{
+		{input: "aws_iam_role.role", want: Address{Type: "aws_iam_role", Name: "role"}},
+		{input: "a.b-c_1", want: Address{Type: "a", Name: "b-c_1"}},
+		{input: "a.b[0]", want: Address{Type: "a", Name: "b", Index: index(0)}},
+		{input: "a.b[12]", want: Address{Type: "a", Name: "b", Index: index(12)}},
+		{input: `a.b["key"]`, want: Address{Type: "a", Name: "b", Key: key("key")}},
+		{input: `a.b["x.y[0]"]`, want: Address{Type: "a", Name: "b", Key: key("x.y[0]")}},
+		{input: `a.b["say \"hi\""]`, want: Address{Type: "a", Name: "b", Key: key(`say "hi"`)}},
+		{input: `a.b[""]`, want: Address{Type: "a", Name: "b", Key: key("")}},
+		{input: "a.mod.b", want: Address{Type: "a", Name: "mod.b"}},
+		{input: "a.mod.sub.b", want: Address{Type: "a", Name: "mod.sub.b"}},
+		{input: `a.mod.b["key"]`, want: Address{Type: "a", Name: "mod.b", Key: key("key")}},
+		{input: "a.mod.b[1]", want: Address{Type: "a", Name: "mod.b", Index: index(1)}},
+
+		{input: "", wantErr: true},
+		{input: "a", wantErr: true},
+		{input: ".b", wantErr: true},
+		{input: "a.", wantErr: true},
+		{input: "a.b.", wantErr: true},
+		{input: "a..b", wantErr: true},
+		{input: "a.b..c", wantErr: true},
+		{input: "a.b.[0]", wantErr: true},
+		{input: "a b.c", wantErr: true},
+		{input: "a.[0]", wantErr: true},
+		{input: "a.b[", wantErr: true},
+		{input: "a.b]", wantErr: true},
+		{input: "a.b[0", wantErr: true},
+		{input: "a.b[0]]", wantErr: true},
+		{input: "a.b[[0]", wantErr: true},
+		{input: "a.b[0][1]", wantErr: true},
+		{input: "a.b[0]x", wantErr: true},
+		{input: "a.b[]", wantErr: true},
+		{input: "a.b[-1]", wantErr: true},
+		{input: "a.b[+1]", wantErr: true},
+		{input: "a.b[key]", wantErr: true},
+		{input: `a.b["key]`, wantErr: true},
+		{input: `a.b["key"`, wantErr: true},
+		{input: `a.b["a"]["b"]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseAddress(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAddress() error = %v, wantErr = %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("ParseAddress() (-got +want)\n%s", diff)
+			}
+			if s := got.String(); s != tt.input {
+				t.Errorf("String() = %q, want %q", s, tt.input)
+			}
+		})
+	}
+}
+
+func TestAddress_Matches(t *testing.T) {
+	key := "web"
+	index := 1
+	tests := []struct {
+		addr Address
+		res  *Desired
+		want bool
+	}{
+		{Address{Type: "a", Name: "b"}, &Desired{Type: "a", Name: "b"}, true},
+		{Address{Type: "a", Name: "b"}, &Desired{Type: "x", Name: "b"}, false},
+		{Address{Type: "a", Name: "b"}, &Desired{Type: "a", Name: `b["web"]`}, false},
+		{Address{Type: "a", Name: "b", Key: &key}, &Desired{Type: "a", Name: `b["web"]`}, true},
+		{Address{Type: "a", Name: "b", Index: &index}, &Desired{Type: "a", Name: "b[1]"}, true},
+		{Address{Type: "a", Name: "mod.b"}, &Desired{Type: "a", Name: "mod.b"}, true},
+		{Address{Type: "a", Name: "b"}, &Desired{Type: "a", Name: "mod.b"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.addr.Matches(tt.res); got != tt.want {
+			t.Errorf("%s Matches(%s.%s) = %t, want %t", tt.addr, tt.res.Type, tt.res.Name, got, tt.want)
+		}
+	}
+}
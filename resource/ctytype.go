@@ -13,12 +13,18 @@ import (
 // structs do not require a cty struct tag. Instead, Fields() is used to get
 // the fields of the nested struct.
 //
+// Slice types that represent sets, such as StringSet, are converted to a cty
+// set.
+//
 // Panics if the type cannot be converted. In practice this only applies to
 // more complex types, such as functions and slices.
 func CtyType(t reflect.Type) cty.Type {
 	for t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
+	if t.Kind() == reflect.Slice && t.Implements(setType) {
+		return cty.Set(CtyType(t.Elem()))
+	}
 	switch t.Kind() {
 	case reflect.Struct:
 		return Fields(t).CtyType()
@@ -41,3 +47,15 @@ func CtyType(t reflect.Type) cty.Type {
 		panic(fmt.Sprintf("no type for %s", t))
 	}
 }
+
+// A StringSet is a set of unique strings.
+//
+// When used as an input, the user may set the value as a list. Duplicate
+// values are removed and the order of the values is not retained.
+type StringSet []string
+
+func (StringSet) isSet() {}
+
+// setType is the interface implemented by types that represent sets. The
+// interface is closed, only sets declared in this package are allowed.
+var setType = reflect.TypeOf((*interface{ isSet() })(nil)).Elem()
@@ -34,6 +34,9 @@ func TestCtyType(t *testing.T) {
 		{reflect.TypeOf(map[string]int{}), cty.Map(cty.Number)},
 		{reflect.TypeOf(map[string]map[string]int{}), cty.Map(cty.Map(cty.Number))},
 		{reflect.TypeOf(map[string][]int{}), cty.Map(cty.List(cty.Number))},
+		// Sets
+		{reflect.TypeOf(resource.StringSet{}), cty.Set(cty.String)},
+		{reflect.TypeOf(&resource.StringSet{}), cty.Set(cty.String)},
 		// Struct
 		{
 			reflect.TypeOf(struct {
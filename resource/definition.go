@@ -12,3 +12,121 @@ type Definition interface {
 	Update(ctx context.Context, req *UpdateRequest) error
 	Delete(ctx context.Context, req *DeleteRequest) error
 }
+
+// A Reader is a Definition that can read the current state of the deployed
+// resource.
+//
+// Read is called on a definition that has been populated with the previously
+// stored inputs and outputs. Read should update the fields to match the live
+// resource, which allows detecting drift between the stored and actual state.
+//
+// Implementing Reader is optional.
+type Reader interface {
+	Read(ctx context.Context, req *ReadRequest) error
+}
+
+// An Importer is a Definition that can import a resource that was created
+// outside of func.
+//
+// Import is called on an empty definition, with the ID of the resource in the
+// provider, such as a name or an ARN. Import should set the inputs and
+// outputs to match the live resource.
+//
+// Implementing Importer is optional.
+type Importer interface {
+	Import(ctx context.Context, req *ImportRequest) error
+}
+
+// A Lister is an Importer that can discover the resources of its type that
+// exist in the provider, so they can be imported in bulk.
+//
+// List is called on an empty definition and returns the IDs that can be
+// passed to Import.
+//
+// Implementing Lister is optional.
+type Lister interface {
+	List(ctx context.Context, req *ListRequest) ([]string, error)
+}
+
+// A Namer is a Definition for a resource that is named in the provider, such
+// as a table or a function.
+//
+// DefaultName is called with the inputs from config set, before the resource
+// is created or updated. The address is unique within the provider account,
+// derived from the project, module and resource names, such as
+// "proj-mod-users". If the name input was not set, DefaultName should set it
+// to the address, or to a value derived from it that fits the provider's
+// naming rules.
+//
+// The default name is stored with the other inputs.
+//
+// Implementing Namer is optional.
+type Namer interface {
+	DefaultName(addr string)
+}
+
+// A Defaulter is a Definition with inputs that default to a value computed
+// from other inputs, such as a field that defaults to the value of another
+// field.
+//
+// SetDefaults is called with the inputs from config set, once all input
+// values have been resolved and before the resource is validated, created or
+// updated. It should set inputs that were not set. Only inputs that were null
+// are changed; the defaults are stored with the other inputs.
+//
+// If the inputs of a resource are known when the configuration is decoded,
+// SetDefaults is also called before Validate then. It must not call external
+// services.
+//
+// Implementing Defaulter is optional.
+type Defaulter interface {
+	SetDefaults()
+}
+
+// A Comparer is a Definition that decides whether its inputs are equal to a
+// previously deployed version of the resource.
+//
+// By default, a resource is updated if any input value changed. Equal is only
+// called when the input values differ, and allows a resource to consider
+// values that are semantically equal unchanged. For example, two JSON
+// documents may only differ in whitespace. If Equal returns true, the
+// resource is not updated.
+//
+// Previous is populated with the previously stored inputs and outputs. The
+// type for previous will match the resource type.
+//
+// Implementing Comparer is optional.
+type Comparer interface {
+	Equal(previous Definition) bool
+}
+
+// A Validator is a Definition that validates its input values as a whole.
+//
+// Validate is called after all input values have been resolved, before the
+// resource is created or updated. Validate should check constraints that
+// involve multiple fields, such as fields that are mutually exclusive. Rules
+// for individual fields are set with the validate struct tag.
+//
+// If the inputs of a resource are known when the configuration is decoded,
+// Validate is also called then, so invalid input is reported before any
+// resources are applied. Validate must not call external services; it may
+// construct an API request and validate it without sending it.
+//
+// Implementing Validator is optional.
+type Validator interface {
+	Validate() error
+}
+
+// A Waiter is a Definition that may not be usable immediately after it has
+// been created or updated.
+//
+// WaitReady is called after a successful Create or Update, before outputs are
+// passed to dependent resources. WaitReady should check the status of the
+// resource once and return nil if it is ready. An error is returned if the
+// resource is not ready yet, in which case WaitReady is retried with backoff.
+// To stop waiting, return a permanent error.
+//
+// Implementing Waiter is optional.
+type Waiter interface {
+	WaitReady(ctx context.Context, req *WaitRequest) error
+}
@@ -3,7 +3,9 @@ package resource
 import (
 	"bytes"
 	"fmt"
+	"sort"
 
+	"github.com/func/func/ctyext"
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
 	"github.com/zclconf/go-cty/cty"
@@ -12,9 +14,9 @@ import (
 
 // An Expression describes a value for a field.
 //
-// The Expression may consist of any combination of literals and references.
-// The exprPart interface is closed, only ExprLiteral and ExprReference are
-// allowed.
+// The Expression may consist of any combination of literals, references and
+// conditionals. The exprPart interface is closed, only ExprLiteral,
+// ExprReference and ExprConditional are allowed.
 type Expression []exprPart
 
 // exprPart is a part in an Expression. The interface is closed, only Parts
@@ -30,21 +32,202 @@ func (e ExprLiteral) isExpr() {}
 
 // ExprReference is a part in an expression that has a reference to another
 // field.
+//
+// An index in the path may be dynamic, meaning the key is the value of
+// another field, as in foo.items[bar.key]. The step for a dynamic index is an
+// index step with an unknown key, and Keys contains the reference to the key,
+// by the position of the step in Path.
+//
+// An index into a list of objects may select the element by key rather than
+// by position, as in foo.items["x"].id. KeyFields contains the name of the
+// key attribute by the position of the step in Path. If the index key is a
+// string, the element whose key attribute equals the key is selected, so the
+// reference is not affected if the provider reorders the list. A number key
+// still selects the element by position.
 type ExprReference struct {
-	Path cty.Path
+	Path      cty.Path
+	Keys      map[int]cty.Path
+	KeyFields map[int]string
 }
 
 func (e ExprReference) isExpr() {}
 
-// References returns all referenced paths that are found in the expression.
+// ExprConditional is a part in an expression that evaluates to one of two
+// expressions, depending on a condition, as in cond ? a : b.
+//
+// The condition must evaluate to a bool. A conditional is only added to a
+// graph if the condition refers to another field; if the condition is
+// static, the chosen expression is used in place of the conditional.
+type ExprConditional struct {
+	Condition Expression
+	True      Expression
+	False     Expression
+}
+
+func (e ExprConditional) isExpr() {}
+
+// value evaluates the condition and returns the chosen expression. Returns
+// false if the condition is not known.
+func (e ExprConditional) value(ctx *EvalContext) (Expression, bool, error) {
+	cond, err := e.Condition.Value(ctx)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "condition")
+	}
+	if !cond.IsKnown() {
+		return nil, false, nil
+	}
+	if cond.IsNull() {
+		return nil, false, errors.New("condition is null")
+	}
+	b, err := convert.Convert(cond, cty.Bool)
+	if err != nil {
+		return nil, false, errors.Errorf("condition must be a bool, got %s", cond.Type().FriendlyName())
+	}
+	if b.True() {
+		return e.True, true, nil
+	}
+	return e.False, true, nil
+}
+
+// WithKey returns a copy of the reference where the dynamic index at pos is
+// replaced with the given key.
+func (e ExprReference) WithKey(pos int, key cty.Value) ExprReference {
+	path := make(cty.Path, len(e.Path))
+	copy(path, e.Path)
+	path[pos] = cty.IndexStep{Key: key}
+	var keys map[int]cty.Path
+	for k, v := range e.Keys {
+		if k == pos {
+			continue
+		}
+		if keys == nil {
+			keys = make(map[int]cty.Path)
+		}
+		keys[k] = v
+	}
+	return ExprReference{Path: path, Keys: keys, KeyFields: e.KeyFields}
+}
+
+// String returns a string representation of the reference. Dynamic indexes
+// contain the reference to the key, as in foo.items[bar.key].name.
+func (e ExprReference) String() string {
+	var buf bytes.Buffer
+	for i := range e.Path {
+		if key, ok := e.Keys[i]; ok {
+			fmt.Fprintf(&buf, "[%s]", ctyext.PathString(key))
+			continue
+		}
+		str := ctyext.PathString(e.Path[i : i+1])
+		if _, ok := e.Path[i].(cty.GetAttrStep); ok && i > 0 {
+			buf.WriteByte('.')
+		}
+		buf.WriteString(str)
+	}
+	return buf.String()
+}
+
+// resolve returns the path with dynamic indexes replaced with the key values
+// from vars. Returns false if a key is not known.
+func (e ExprReference) resolve(vars cty.Value) (cty.Path, bool, error) {
+	if len(e.Keys) == 0 {
+		return e.Path, true, nil
+	}
+	path := make(cty.Path, len(e.Path))
+	copy(path, e.Path)
+	for pos, keyPath := range e.Keys {
+		key, err := applyPath(vars, keyPath, nil)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "key %s", ctyext.PathString(keyPath))
+		}
+		if !key.IsKnown() {
+			return nil, false, nil
+		}
+		if key.IsNull() {
+			return nil, false, errors.Errorf("key %s is null", ctyext.PathString(keyPath))
+		}
+		path[pos] = cty.IndexStep{Key: key}
+	}
+	return path, true, nil
+}
+
+// applyPath applies the steps in path to val. An index step with a string
+// key and a position in keyFields selects the element in a list of objects
+// by key. A number key always selects the element by position.
+func applyPath(val cty.Value, path cty.Path, keyFields map[int]string) (cty.Value, error) {
+	for i, p := range path {
+		if field, ok := keyFields[i]; ok && p.(cty.IndexStep).Key.Type() == cty.String {
+			v, err := matchKey(val, field, p.(cty.IndexStep).Key)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			val = v
+			continue
+		}
+		v, err := p.Apply(val)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		val = v
+	}
+	return val, nil
+}
+
+// matchKey returns the element in a list of objects where the attribute
+// field equals key.
+func matchKey(list cty.Value, field string, key cty.Value) (cty.Value, error) {
+	ty := list.Type()
+	if !ty.IsListType() && !ty.IsTupleType() && !ty.IsSetType() {
+		return cty.NilVal, errors.Errorf("cannot match key in %s", ty.FriendlyName())
+	}
+	if !list.IsKnown() {
+		if ty.IsTupleType() {
+			return cty.DynamicVal, nil
+		}
+		return cty.UnknownVal(ty.ElementType()), nil
+	}
+	if list.IsNull() {
+		return cty.NilVal, errors.New("cannot match key in null value")
+	}
+	for it := list.ElementIterator(); it.Next(); {
+		_, elem := it.Element()
+		if !elem.Type().IsObjectType() || !elem.Type().HasAttribute(field) || elem.IsNull() {
+			continue
+		}
+		v := elem.GetAttr(field)
+		if !v.IsKnown() || v.IsNull() || !v.Type().Equals(key.Type()) {
+			continue
+		}
+		if v.Equals(key).True() {
+			return elem, nil
+		}
+	}
+	return cty.NilVal, errors.Errorf("no element with %s %q", field, key.AsString())
+}
+
+// References returns all referenced paths that are found in the expression,
+// including references to keys for dynamic indexes and references in both
+// branches of conditionals.
 //
 // If the returned slice is empty, the expression contains no dynamic
 // references. Such an expression can be evaluated with expr.Value(nil).
 func (expr Expression) References() []cty.Path {
 	var parts []cty.Path
 	for _, e := range expr {
-		if ref, ok := e.(ExprReference); ok {
-			parts = append(parts, ref.Path)
+		switch p := e.(type) {
+		case ExprReference:
+			parts = append(parts, p.Path)
+			positions := make([]int, 0, len(p.Keys))
+			for pos := range p.Keys {
+				positions = append(positions, pos)
+			}
+			sort.Ints(positions)
+			for _, pos := range positions {
+				parts = append(parts, p.Keys[pos])
+			}
+		case ExprConditional:
+			parts = append(parts, p.Condition.References()...)
+			parts = append(parts, p.True.References()...)
+			parts = append(parts, p.False.References()...)
 		}
 	}
 	return parts
@@ -70,6 +253,11 @@ type EvalContext struct {
 //   - I an unknown value is encountered, an unknown value is returned.
 //     If it was the only part in the expression, the type will match this part.
 //     Otherwise, the returned value will be an unknown string.
+//   - If the key for a dynamic index is unknown, the referenced value is
+//     unknown.
+//   - A conditional evaluates to the value of the chosen expression. If the
+//     condition is unknown, the value is unknown. An error is returned if
+//     the condition is not a bool.
 //
 // If the expression contains a reference to a variable that was not set in the
 // ctx, an error is returned.
@@ -86,13 +274,33 @@ func (expr Expression) Value(ctx *EvalContext) (cty.Value, error) {
 		case ExprLiteral:
 			vals[i] = p.Value
 		case ExprReference:
-			val := cty.ObjectVal(ctx.Variables)
-			for _, p := range p.Path {
-				v, err := p.Apply(val)
-				if err != nil {
-					return cty.NilVal, err
-				}
-				val = v
+			vars := cty.ObjectVal(ctx.Variables)
+			path, known, err := p.resolve(vars)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			if !known {
+				// Dynamic index is not known yet.
+				vals[i] = cty.DynamicVal
+				continue
+			}
+			val, err := applyPath(vars, path, p.KeyFields)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[i] = val
+		case ExprConditional:
+			chosen, known, err := p.value(ctx)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			if !known {
+				vals[i] = cty.DynamicVal
+				continue
+			}
+			val, err := chosen.Value(ctx)
+			if err != nil {
+				return cty.NilVal, err
 			}
 			vals[i] = val
 		default:
@@ -124,6 +332,39 @@ func (expr Expression) Value(ctx *EvalContext) (cty.Value, error) {
 	return cty.StringVal(buf.String()), nil
 }
 
+// ResolveConditionals replaces conditionals whose condition does not contain
+// references with the chosen expression. Conditionals in the chosen
+// expressions are resolved as well. An error is returned if a condition is
+// not a bool.
+func (expr Expression) ResolveConditionals() (Expression, error) {
+	var out Expression // nolint: prealloc
+	for _, e := range expr {
+		cond, ok := e.(ExprConditional)
+		if !ok {
+			out = append(out, e)
+			continue
+		}
+		if len(cond.Condition.References()) > 0 {
+			out = append(out, cond)
+			continue
+		}
+		chosen, known, err := cond.value(nil)
+		if err != nil {
+			return nil, err
+		}
+		if !known {
+			out = append(out, cond)
+			continue
+		}
+		chosen, err = chosen.ResolveConditionals()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chosen...)
+	}
+	return out, nil
+}
+
 // MergeLiterals merges consecutive literal values into a single literal. Parts
 // of the expression that are not literals are returned in place as-is.
 func (expr Expression) MergeLiterals() Expression {
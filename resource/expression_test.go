@@ -46,6 +46,11 @@ func ExampleExpression_MergeLiterals() {
 }
 
 func TestExpression_Value(t *testing.T) {
+	keyedItems := cty.ListVal([]cty.Value{
+		cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("a"), "id": cty.StringVal("id-a")}),
+		cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("b"), "id": cty.StringVal("id-b")}),
+	})
+
 	tests := []struct {
 		name    string
 		expr    resource.Expression
@@ -77,7 +82,7 @@ func TestExpression_Value(t *testing.T) {
 		{
 			name: "Reference",
 			expr: resource.Expression{
-				resource.ExprReference{cty.GetAttrPath("foo").GetAttr("bar")},
+				resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("bar")},
 			},
 			ctx: &resource.EvalContext{
 				Variables: map[string]cty.Value{
@@ -91,9 +96,9 @@ func TestExpression_Value(t *testing.T) {
 		{
 			name: "Mixed",
 			expr: resource.Expression{
-				resource.ExprReference{cty.GetAttrPath("foo").GetAttr("bar")},
+				resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("bar")},
 				resource.ExprLiteral{cty.NumberIntVal(456)},
-				resource.ExprReference{cty.GetAttrPath("bar").GetAttr("baz")},
+				resource.ExprReference{Path: cty.GetAttrPath("bar").GetAttr("baz")},
 			},
 			ctx: &resource.EvalContext{
 				Variables: map[string]cty.Value{
@@ -107,7 +112,7 @@ func TestExpression_Value(t *testing.T) {
 			name: "Unknown",
 			expr: resource.Expression{
 				resource.ExprLiteral{cty.StringVal("known")},
-				resource.ExprReference{cty.GetAttrPath("foo").GetAttr("output")},
+				resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("output")},
 			},
 			ctx: &resource.EvalContext{
 				Variables: map[string]cty.Value{
@@ -116,16 +121,161 @@ func TestExpression_Value(t *testing.T) {
 			},
 			want: cty.UnknownVal(cty.String),
 		},
+		{
+			name: "DynamicIndex",
+			expr: resource.Expression{
+				resource.ExprReference{
+					Path: cty.GetAttrPath("foo").GetAttr("items").Index(cty.DynamicVal),
+					Keys: map[int]cty.Path{2: cty.GetAttrPath("bar").GetAttr("key")},
+				},
+			},
+			ctx: &resource.EvalContext{
+				Variables: map[string]cty.Value{
+					"foo": cty.ObjectVal(map[string]cty.Value{
+						"items": cty.MapVal(map[string]cty.Value{
+							"a": cty.StringVal("first"),
+							"b": cty.StringVal("second"),
+						}),
+					}),
+					"bar": cty.ObjectVal(map[string]cty.Value{"key": cty.StringVal("b")}),
+				},
+			},
+			want: cty.StringVal("second"),
+		},
+		{
+			name: "DynamicIndexUnknown",
+			expr: resource.Expression{
+				resource.ExprReference{
+					Path: cty.GetAttrPath("foo").GetAttr("items").Index(cty.DynamicVal),
+					Keys: map[int]cty.Path{2: cty.GetAttrPath("bar").GetAttr("key")},
+				},
+			},
+			ctx: &resource.EvalContext{
+				Variables: map[string]cty.Value{
+					"foo": cty.ObjectVal(map[string]cty.Value{
+						"items": cty.MapVal(map[string]cty.Value{"a": cty.StringVal("first")}),
+					}),
+					"bar": cty.ObjectVal(map[string]cty.Value{"key": cty.UnknownVal(cty.String)}),
+				},
+			},
+			want: cty.DynamicVal,
+		},
+		{
+			name: "KeyIndex",
+			expr: resource.Expression{
+				resource.ExprReference{
+					Path:      cty.GetAttrPath("foo").GetAttr("items").Index(cty.StringVal("b")).GetAttr("id"),
+					KeyFields: map[int]string{2: "name"},
+				},
+			},
+			ctx: &resource.EvalContext{
+				Variables: map[string]cty.Value{
+					"foo": cty.ObjectVal(map[string]cty.Value{"items": keyedItems}),
+				},
+			},
+			want: cty.StringVal("id-b"),
+		},
+		{
+			name: "KeyIndexNumber",
+			expr: resource.Expression{
+				resource.ExprReference{
+					Path:      cty.GetAttrPath("foo").GetAttr("items").Index(cty.NumberIntVal(0)).GetAttr("id"),
+					KeyFields: map[int]string{2: "name"},
+				},
+			},
+			ctx: &resource.EvalContext{
+				Variables: map[string]cty.Value{
+					"foo": cty.ObjectVal(map[string]cty.Value{"items": keyedItems}),
+				},
+			},
+			want: cty.StringVal("id-a"),
+		},
+		{
+			name: "KeyIndexNotFound",
+			expr: resource.Expression{
+				resource.ExprReference{
+					Path:      cty.GetAttrPath("foo").GetAttr("items").Index(cty.StringVal("c")).GetAttr("id"),
+					KeyFields: map[int]string{2: "name"},
+				},
+			},
+			ctx: &resource.EvalContext{
+				Variables: map[string]cty.Value{
+					"foo": cty.ObjectVal(map[string]cty.Value{"items": keyedItems}),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "KeyIndexUnknown",
+			expr: resource.Expression{
+				resource.ExprReference{
+					Path:      cty.GetAttrPath("foo").GetAttr("items").Index(cty.StringVal("b")).GetAttr("id"),
+					KeyFields: map[int]string{2: "name"},
+				},
+			},
+			ctx: &resource.EvalContext{
+				Variables: map[string]cty.Value{
+					"foo": cty.ObjectVal(map[string]cty.Value{
+						"items": cty.UnknownVal(keyedItems.Type()),
+					}),
+				},
+			},
+			want: cty.UnknownVal(cty.String),
+		},
 		{
 			name: "NotFoundRef",
 			expr: resource.Expression{
-				resource.ExprReference{cty.GetAttrPath("foo")},
+				resource.ExprReference{Path: cty.GetAttrPath("foo")},
 			},
 			ctx: &resource.EvalContext{
 				Variables: map[string]cty.Value{},
 			},
 			wantErr: true,
 		},
+		{
+			name: "Conditional",
+			expr: resource.Expression{
+				resource.ExprConditional{
+					Condition: resource.Expression{resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("prod")}},
+					True:      resource.Expression{resource.ExprLiteral{Value: cty.NumberIntVal(1024)}},
+					False:     resource.Expression{resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("size")}},
+				},
+			},
+			ctx: &resource.EvalContext{
+				Variables: map[string]cty.Value{
+					"foo": cty.ObjectVal(map[string]cty.Value{
+						"prod": cty.False,
+						"size": cty.NumberIntVal(128),
+					}),
+				},
+			},
+			want: cty.NumberIntVal(128),
+		},
+		{
+			name: "ConditionalUnknown",
+			expr: resource.Expression{
+				resource.ExprConditional{
+					Condition: resource.Expression{resource.ExprReference{Path: cty.GetAttrPath("foo")}},
+					True:      resource.Expression{resource.ExprLiteral{Value: cty.NumberIntVal(1024)}},
+					False:     resource.Expression{resource.ExprLiteral{Value: cty.NumberIntVal(128)}},
+				},
+			},
+			ctx: &resource.EvalContext{
+				Variables: map[string]cty.Value{"foo": cty.UnknownVal(cty.Bool)},
+			},
+			want: cty.DynamicVal,
+		},
+		{
+			name: "ConditionalNotBool",
+			expr: resource.Expression{
+				resource.ExprConditional{
+					Condition: resource.Expression{resource.ExprLiteral{Value: cty.StringVal("yes")}},
+					True:      resource.Expression{resource.ExprLiteral{Value: cty.NumberIntVal(1024)}},
+					False:     resource.Expression{resource.ExprLiteral{Value: cty.NumberIntVal(128)}},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
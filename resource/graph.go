@@ -10,6 +10,19 @@ import (
 type Graph struct {
 	Resources    []*Desired
 	Dependencies []*Dependency
+	Outputs      []*Output
+
+	// Moves contains resources that have been renamed. Moves only apply to
+	// the reconciliation of the graph, they are not stored with it.
+	Moves []*Move
+}
+
+// A Move records that a resource has been renamed. The previously deployed
+// resource named From is renamed To, instead of deleting it and creating a
+// new resource.
+type Move struct {
+	From string
+	To   string
 }
 
 // AddResource adds a new resource to the graph.
@@ -64,10 +77,20 @@ func (g *Graph) AddDependency(dep *Dependency) error {
 
 // ParentResources returns the parent resources that are are a dependency to
 // the given child resource. In case multiple references exist to the parent
-// resource, it is included only once.
+// resource, it is included only once. Resources listed in the child's
+// DependsOn are included.
 func (g *Graph) ParentResources(child string) []*Desired {
 	added := make(map[string]struct{}) // Avoid adding the same dependency twice
 	var parents []*Desired
+	if res := g.Resource(child); res != nil {
+		for _, parent := range res.DependsOn {
+			if _, ok := added[parent]; ok {
+				continue
+			}
+			parents = append(parents, g.Resource(parent))
+			added[parent] = struct{}{}
+		}
+	}
 	for _, d := range g.Dependencies {
 		if d.Child != child {
 			continue
@@ -83,6 +106,63 @@ func (g *Graph) ParentResources(child string) []*Desired {
 	return parents
 }
 
+// AddOutput adds an output to the graph.
+//
+// Returns an error if another output with the same name already exists, or if
+// the output refers to a resource that does not exist.
+func (g *Graph) AddOutput(output *Output) error {
+	if output.Name == "" {
+		return fmt.Errorf("output has no name")
+	}
+	for _, o := range g.Outputs {
+		if o.Name == output.Name {
+			return fmt.Errorf("output %q already exists", output.Name)
+		}
+	}
+	for i, r := range output.Expression.References() {
+		attr, ok := r[0].(cty.GetAttrStep)
+		if !ok {
+			return fmt.Errorf("reference %d in expression does not start with resource name", i)
+		}
+		if res := g.Resource(attr.Name); res == nil {
+			return fmt.Errorf("cannot add reference %d to non-existing resource %q", i, attr.Name)
+		}
+	}
+	g.Outputs = append(g.Outputs, output)
+	return nil
+}
+
+// AddMove adds a renamed resource to the graph.
+//
+// Returns an error if the resource to move to does not exist, if the resource
+// to move from still exists or if the resource has already been moved.
+func (g *Graph) AddMove(m *Move) error {
+	if m.From == "" || m.To == "" {
+		return fmt.Errorf("move must have from and to set")
+	}
+	if g.Resource(m.To) == nil {
+		return fmt.Errorf("resource %q does not exist", m.To)
+	}
+	if g.Resource(m.From) != nil {
+		return fmt.Errorf("resource %q still exists", m.From)
+	}
+	for _, ex := range g.Moves {
+		if ex.From == m.From {
+			return fmt.Errorf("resource %q has already been moved to %q", m.From, ex.To)
+		}
+		if ex.To == m.To {
+			return fmt.Errorf("resource %q has already been moved from %q", m.To, ex.From)
+		}
+	}
+	g.Moves = append(g.Moves, m)
+	return nil
+}
+
+// MovedResources returns the resources that have been renamed.
+func (g *Graph) MovedResources() []*Move {
+	return g.Moves
+}
+
 // DependenciesOf returns the dependencies for a given child.
 func (g *Graph) DependenciesOf(child string) []*Dependency {
 	var deps []*Dependency
@@ -109,6 +189,13 @@ func (g *Graph) LeafResources() []*Desired {
 		}
 	}
 
+	// Mark resources that are explicitly depended on.
+	for _, res := range g.Resources {
+		for _, name := range res.DependsOn {
+			parents[name] = struct{}{}
+		}
+	}
+
 	// Collect remaining resources that were not marked.
 	out := make([]*Desired, 0, len(g.Resources)-len(parents))
 	for _, res := range g.Resources {
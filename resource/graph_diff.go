@@ -0,0 +1,164 @@
+package resource
+
+import (
+	"sort"
+
+	"github.com/func/func/ctyext"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// A GraphDiff contains the differences between two graphs.
+//
+// Resources are matched by name. Dependencies are matched by child, field and
+// expression; a dependency with a changed expression is reported as removed
+// and added.
+type GraphDiff struct {
+	Added   []*Desired     // Resources only in the new graph, sorted by name.
+	Removed []*Desired     // Resources only in the old graph, sorted by name.
+	Changed []ResourceDiff // Resources in both graphs that differ, sorted by name.
+
+	AddedDependencies   []*Dependency // Dependencies only in the new graph.
+	RemovedDependencies []*Dependency // Dependencies only in the old graph.
+}
+
+// IsEmpty returns true if there are no differences.
+func (d GraphDiff) IsEmpty() bool {
+	return len(d.Added) == 0 &&
+		len(d.Removed) == 0 &&
+		len(d.Changed) == 0 &&
+		len(d.AddedDependencies) == 0 &&
+		len(d.RemovedDependencies) == 0
+}
+
+// A ResourceDiff contains the changes to a resource that exists in both
+// graphs.
+type ResourceDiff struct {
+	Old, New *Desired
+
+	// Fields contains the paths to changed input values, sorted. If the type
+	// of the resource changed, Fields is empty.
+	Fields []cty.Path
+}
+
+// DiffGraphs computes the differences from the old graph to the new graph.
+//
+// Only resources and dependencies are compared. Unknown input values, which
+// are set by dependencies, are equal to each other.
+func DiffGraphs(old, next *Graph) GraphDiff {
+	var diff GraphDiff
+
+	oldRes := make(map[string]*Desired, len(old.Resources))
+	for _, res := range old.Resources {
+		oldRes[res.Name] = res
+	}
+	newRes := make(map[string]*Desired, len(next.Resources))
+	for _, res := range next.Resources {
+		newRes[res.Name] = res
+	}
+
+	for _, res := range next.Resources {
+		prev, ok := oldRes[res.Name]
+		if !ok {
+			diff.Added = append(diff.Added, res)
+			continue
+		}
+		if prev.Type != res.Type {
+			diff.Changed = append(diff.Changed, ResourceDiff{Old: prev, New: res})
+			continue
+		}
+		if fields := changedPaths(nil, prev.Input, res.Input); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, ResourceDiff{Old: prev, New: res, Fields: fields})
+		}
+	}
+	for _, res := range old.Resources {
+		if _, ok := newRes[res.Name]; !ok {
+			diff.Removed = append(diff.Removed, res)
+		}
+	}
+
+	diff.AddedDependencies = missingDependencies(next.Dependencies, old.Dependencies)
+	diff.RemovedDependencies = missingDependencies(old.Dependencies, next.Dependencies)
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Name < diff.Added[j].Name })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Name < diff.Removed[j].Name })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].New.Name < diff.Changed[j].New.Name })
+
+	return diff
+}
+
+// changedPaths returns the paths to values that differ between a and b.
+// Objects and maps are compared per attribute and lists and tuples of equal
+// length per element. Otherwise the entire value is compared.
+func changedPaths(path cty.Path, a, b cty.Value) []cty.Path {
+	if a.RawEquals(b) {
+		return nil
+	}
+	ty := a.Type()
+	if !ty.Equals(b.Type()) || !a.IsKnown() || !b.IsKnown() || a.IsNull() || b.IsNull() {
+		return []cty.Path{path}
+	}
+
+	switch {
+	case ty.IsObjectType(), ty.IsMapType():
+		keys := make(map[string]bool)
+		for it := a.ElementIterator(); it.Next(); {
+			k, _ := it.Element()
+			keys[k.AsString()] = true
+		}
+		for it := b.ElementIterator(); it.Next(); {
+			k, _ := it.Element()
+			keys[k.AsString()] = true
+		}
+		var out []cty.Path
+		for k := range keys {
+			if ty.IsObjectType() {
+				out = append(out, changedPaths(path.GetAttr(k), a.GetAttr(k), b.GetAttr(k))...)
+				continue
+			}
+			// A key that only exists in one of the maps is compared to null,
+			// which never equals the value.
+			key := cty.StringVal(k)
+			av, bv := cty.NullVal(cty.DynamicPseudoType), cty.NullVal(cty.DynamicPseudoType)
+			if a.HasIndex(key).True() {
+				av = a.Index(key)
+			}
+			if b.HasIndex(key).True() {
+				bv = b.Index(key)
+			}
+			out = append(out, changedPaths(path.Index(key), av, bv)...)
+		}
+		sort.Slice(out, func(i, j int) bool {
+			return ctyext.PathString(out[i]) < ctyext.PathString(out[j])
+		})
+		return out
+	case ty.IsListType(), ty.IsTupleType():
+		if a.LengthInt() != b.LengthInt() {
+			return []cty.Path{path}
+		}
+		var out []cty.Path
+		for i := 0; i < a.LengthInt(); i++ {
+			idx := cty.NumberIntVal(int64(i))
+			out = append(out, changedPaths(path.Index(idx), a.Index(idx), b.Index(idx))...)
+		}
+		return out
+	}
+	return []cty.Path{path}
+}
+
+// missingDependencies returns the dependencies in a that are not in b.
+func missingDependencies(a, b []*Dependency) []*Dependency {
+	var out []*Dependency
+	for _, dep := range a {
+		found := false
+		for _, other := range b {
+			if dep.Child == other.Child && dep.Field.Equals(other.Field) && dep.Expression.Equals(other.Expression) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, dep)
+		}
+	}
+	return out
+}
@@ -0,0 +1,133 @@
+package resource
+
+import (
+	"testing"
+
+	"github.com/func/func/ctyext"
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDiffGraphs(t *testing.T) {
+	foo := &Desired{Name: "foo", Type: "a", Input: cty.ObjectVal(map[string]cty.Value{
+		"name": cty.StringVal("foo"),
+		"tags": cty.MapVal(map[string]cty.Value{"env": cty.StringVal("dev")}),
+		"list": cty.ListVal([]cty.Value{cty.StringVal("x"), cty.StringVal("y")}),
+	})}
+	fooChanged := &Desired{Name: "foo", Type: "a", Input: cty.ObjectVal(map[string]cty.Value{
+		"name": cty.StringVal("foo"),
+		"tags": cty.MapVal(map[string]cty.Value{"env": cty.StringVal("prod"), "team": cty.StringVal("a")}),
+		"list": cty.ListVal([]cty.Value{cty.StringVal("x"), cty.StringVal("z")}),
+	})}
+	bar := &Desired{Name: "bar", Type: "a", Input: cty.ObjectVal(map[string]cty.Value{
+		"name": cty.UnknownVal(cty.String),
+	})}
+	barRetyped := &Desired{Name: "bar", Type: "b", Input: bar.Input}
+	baz := &Desired{Name: "baz", Type: "a", Input: cty.EmptyObjectVal}
+
+	depFooName := &Dependency{
+		Child:      "bar",
+		Field:      cty.GetAttrPath("name"),
+		Expression: Expression{ExprReference{Path: cty.GetAttrPath("foo").GetAttr("name")}},
+	}
+	depFooID := &Dependency{
+		Child:      "bar",
+		Field:      cty.GetAttrPath("name"),
+		Expression: Expression{ExprReference{Path: cty.GetAttrPath("foo").GetAttr("id")}},
+	}
+
+	type resourceDiff struct {
+		Name   string
+		Fields []string
+	}
+	type result struct {
+		Added, Removed, Changed []string
+		Fields                  []resourceDiff
+		AddedDeps, RemovedDeps  int
+	}
+
+	tests := []struct {
+		name      string
+		old, next *Graph
+		want      result
+	}{
+		{
+			name: "NoChange",
+			old:  &Graph{Resources: []*Desired{foo, bar}, Dependencies: []*Dependency{depFooName}},
+			next: &Graph{Resources: []*Desired{bar, foo}, Dependencies: []*Dependency{depFooName}},
+			want: result{},
+		},
+		{
+			name: "Added",
+			old:  &Graph{Resources: []*Desired{foo}},
+			next: &Graph{Resources: []*Desired{foo, baz, bar}},
+			want: result{Added: []string{"bar", "baz"}},
+		},
+		{
+			name: "Removed",
+			old:  &Graph{Resources: []*Desired{foo, baz, bar}},
+			next: &Graph{Resources: []*Desired{bar}},
+			want: result{Removed: []string{"baz", "foo"}},
+		},
+		{
+			name: "InputChanged",
+			old:  &Graph{Resources: []*Desired{foo}},
+			next: &Graph{Resources: []*Desired{fooChanged}},
+			want: result{
+				Changed: []string{"foo"},
+				Fields:  []resourceDiff{{"foo", []string{"list[1]", `tags["env"]`, `tags["team"]`}}},
+			},
+		},
+		{
+			name: "TypeChanged",
+			old:  &Graph{Resources: []*Desired{bar}},
+			next: &Graph{Resources: []*Desired{barRetyped}},
+			want: result{
+				Changed: []string{"bar"},
+				Fields:  []resourceDiff{{"bar", nil}},
+			},
+		},
+		{
+			name: "DependencyChanged",
+			old:  &Graph{Resources: []*Desired{foo, bar}, Dependencies: []*Dependency{depFooName}},
+			next: &Graph{Resources: []*Desired{foo, bar}, Dependencies: []*Dependency{depFooID}},
+			want: result{AddedDeps: 1, RemovedDeps: 1},
+		},
+		{
+			name: "DependencyAdded",
+			old:  &Graph{Resources: []*Desired{foo, bar}},
+			next: &Graph{Resources: []*Desired{foo, bar}, Dependencies: []*Dependency{depFooName}},
+			want: result{AddedDeps: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffGraphs(tt.old, tt.next)
+
+			var got result
+			for _, r := range diff.Added {
+				got.Added = append(got.Added, r.Name)
+			}
+			for _, r := range diff.Removed {
+				got.Removed = append(got.Removed, r.Name)
+			}
+			for _, c := range diff.Changed {
+				got.Changed = append(got.Changed, c.New.Name)
+				rd := resourceDiff{Name: c.New.Name}
+				for _, p := range c.Fields {
+					rd.Fields = append(rd.Fields, ctyext.PathString(p))
+				}
+				got.Fields = append(got.Fields, rd)
+			}
+			got.AddedDeps = len(diff.AddedDependencies)
+			got.RemovedDeps = len(diff.RemovedDependencies)
+
+			if d := cmp.Diff(got, tt.want); d != "" {
+				t.Errorf("DiffGraphs() (-got +want)\n%s", d)
+			}
+			if empty := cmp.Equal(tt.want, result{}); diff.IsEmpty() != empty {
+				t.Errorf("IsEmpty() = %t, want %t", diff.IsEmpty(), empty)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package resource
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/func/func/ctyext"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// MarshalJSON encodes the graph to JSON, for consumption by external tools.
+//
+// Resources are sorted by name, dependencies by child name and field path
+// and outputs by name, so the same graph always produces the same JSON.
+// Input values that depend on other resources are not known until the graph
+// is reconciled and are encoded as null; the dependencies describe how the
+// values are resolved.
+//
+// Sensitive input values are replaced with "(sensitive)". The literal parts
+// of the expression of a dependency that sets a sensitive input are replaced
+// too.
+//
+// Expressions are encoded as a list of parts, where each part is either a
+// literal value, a reference or a conditional:
+//
+//	[{"literal": "arn:"}, {"reference": "role.arn"}]
+//	[{"conditional": {"condition": [...], "true": [...], "false": [...]}}]
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	out := jsonGraph{
+		Resources:    make([]jsonResource, len(g.Resources)),
+		Dependencies: make([]jsonDependency, len(g.Dependencies)),
+		Outputs:      make([]jsonOutput, len(g.Outputs)),
+	}
+
+	sensitive := make(map[string][]cty.Path, len(g.Resources))
+	for i, res := range g.Resources {
+		sensitive[res.Name] = res.Sensitive
+		input, err := jsonValue(redact(cty.UnknownAsNull(res.Input), res.Sensitive))
+		if err != nil {
+			return nil, errors.Wrapf(err, "resource %s: input", res.Name)
+		}
+		out.Resources[i] = jsonResource{
+			Name:        res.Name,
+			Type:        res.Type,
+			Description: res.Description,
+			Input:       input,
+			Sources:     res.Sources,
+			DependsOn:   res.DependsOn,
+		}
+	}
+	sort.Slice(out.Resources, func(i, j int) bool {
+		return out.Resources[i].Name < out.Resources[j].Name
+	})
+
+	for i, dep := range g.Dependencies {
+		expr := dep.Expression
+		if hasPrefix(dep.Field, sensitive[dep.Child]) {
+			expr = redactExpression(expr)
+		}
+		parts, err := jsonExpression(expr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "dependency %s.%s", dep.Child, ctyext.PathString(dep.Field))
+		}
+		out.Dependencies[i] = jsonDependency{
+			Child:      dep.Child,
+			Field:      ctyext.PathString(dep.Field),
+			Expression: parts,
+		}
+	}
+	sort.Slice(out.Dependencies, func(i, j int) bool {
+		a, b := out.Dependencies[i], out.Dependencies[j]
+		if a.Child != b.Child {
+			return a.Child < b.Child
+		}
+		return a.Field < b.Field
+	})
+
+	for i, o := range g.Outputs {
+		expr, err := jsonExpression(o.Expression)
+		if err != nil {
+			return nil, errors.Wrapf(err, "output %s", o.Name)
+		}
+		out.Outputs[i] = jsonOutput{
+			Name:       o.Name,
+			Expression: expr,
+			Sensitive:  o.Sensitive,
+		}
+	}
+	sort.Slice(out.Outputs, func(i, j int) bool {
+		return out.Outputs[i].Name < out.Outputs[j].Name
+	})
+
+	return json.Marshal(out)
+}
+
+type jsonGraph struct {
+	Resources    []jsonResource   `json:"resources"`
+	Dependencies []jsonDependency `json:"dependencies"`
+	Outputs      []jsonOutput     `json:"outputs"`
+}
+
+type jsonResource struct {
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Description string          `json:"description,omitempty"`
+	Input       json.RawMessage `json:"input"`
+	Sources     []string        `json:"sources,omitempty"`
+	DependsOn   []string        `json:"depends_on,omitempty"`
+}
+
+type jsonDependency struct {
+	Child      string     `json:"child"`
+	Field      string     `json:"field"`
+	Expression []jsonPart `json:"expression"`
+}
+
+type jsonOutput struct {
+	Name       string     `json:"name"`
+	Expression []jsonPart `json:"expression"`
+	Sensitive  bool       `json:"sensitive,omitempty"`
+}
+
+// jsonPart is a part in an expression. Only one of the fields is set.
+type jsonPart struct {
+	Literal     json.RawMessage  `json:"literal,omitempty"`
+	Reference   string           `json:"reference,omitempty"`
+	Conditional *jsonConditional `json:"conditional,omitempty"`
+}
+
+type jsonConditional struct {
+	Condition []jsonPart `json:"condition"`
+	True      []jsonPart `json:"true"`
+	False     []jsonPart `json:"false"`
+}
+
+func jsonExpression(expr Expression) ([]jsonPart, error) {
+	parts := make([]jsonPart, len(expr))
+	for i, e := range expr {
+		switch p := e.(type) {
+		case ExprLiteral:
+			v, err := jsonValue(p.Value)
+			if err != nil {
+				return nil, err
+			}
+			parts[i] = jsonPart{Literal: v}
+		case ExprReference:
+			parts[i] = jsonPart{Reference: p.String()}
+		case ExprConditional:
+			var cond jsonConditional
+			var err error
+			if cond.Condition, err = jsonExpression(p.Condition); err != nil {
+				return nil, err
+			}
+			if cond.True, err = jsonExpression(p.True); err != nil {
+				return nil, err
+			}
+			if cond.False, err = jsonExpression(p.False); err != nil {
+				return nil, err
+			}
+			parts[i] = jsonPart{Conditional: &cond}
+		}
+	}
+	return parts, nil
+}
+
+func jsonValue(v cty.Value) (json.RawMessage, error) {
+	if v == cty.NilVal {
+		return json.RawMessage("null"), nil
+	}
+	return ctyext.MarshalJSON(v)
+}
+
+// redacted replaces sensitive values.
+var redacted = cty.StringVal("(sensitive)")
+
+// redact returns v with the non-null values at the given paths replaced.
+func redact(v cty.Value, paths []cty.Path) cty.Value {
+	if len(paths) == 0 {
+		return v
+	}
+	out, _ := cty.Transform(v, func(p cty.Path, v cty.Value) (cty.Value, error) {
+		if v.IsNull() || !hasPrefix(p, paths) {
+			return v, nil
+		}
+		return redacted, nil
+	})
+	return out
+}
+
+// redactExpression returns a copy of expr with the literal parts replaced.
+// References are kept, they do not contain values.
+func redactExpression(expr Expression) Expression {
+	out := make(Expression, len(expr))
+	for i, e := range expr {
+		switch p := e.(type) {
+		case ExprLiteral:
+			out[i] = ExprLiteral{Value: redacted}
+		case ExprConditional:
+			out[i] = ExprConditional{
+				Condition: redactExpression(p.Condition),
+				True:      redactExpression(p.True),
+				False:     redactExpression(p.False),
+			}
+		default:
+			out[i] = e
+		}
+	}
+	return out
+}
+
+// hasPrefix returns true if path is equal to or nested in one of prefixes.
+func hasPrefix(path cty.Path, prefixes []cty.Path) bool {
+	for _, prefix := range prefixes {
+		if len(path) >= len(prefix) && path[:len(prefix)].Equals(prefix) {
+			return true
+		}
+	}
+	return false
+}
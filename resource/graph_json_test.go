@@ -0,0 +1,132 @@
+package resource_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/func/func/resource"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestGraph_MarshalJSON(t *testing.T) {
+	g := &resource.Graph{
+		Resources: []*resource.Desired{
+			{
+				Name: "b",
+				Type: "greeter",
+				Input: cty.ObjectVal(map[string]cty.Value{
+					"greeting": cty.UnknownVal(cty.String),
+					"count":    cty.NumberIntVal(2),
+				}),
+				Sources:   []string{"abc"},
+				DependsOn: []string{"a"},
+			},
+			{
+				Name:  "a",
+				Type:  "person",
+				Input: cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("alice")}),
+			},
+		},
+		Dependencies: []*resource.Dependency{
+			{
+				Child: "b",
+				Field: cty.GetAttrPath("greeting"),
+				Expression: resource.Expression{
+					resource.ExprLiteral{Value: cty.StringVal("Hello, ")},
+					resource.ExprReference{Path: cty.GetAttrPath("a").GetAttr("name")},
+				},
+			},
+		},
+		Outputs: []*resource.Output{
+			{
+				Name: "z",
+				Expression: resource.Expression{
+					resource.ExprReference{Path: cty.GetAttrPath("b").GetAttr("greeting")},
+				},
+			},
+			{
+				Name: "y",
+				Expression: resource.Expression{
+					resource.ExprLiteral{Value: cty.NumberIntVal(1)},
+				},
+			},
+		},
+	}
+
+	got, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+
+	want := `{` +
+		`"resources":[` +
+		`{"name":"a","type":"person","input":{"name":"alice"}},` +
+		`{"name":"b","type":"greeter","input":{"count":2,"greeting":null},"sources":["abc"],"depends_on":["a"]}` +
+		`],` +
+		`"dependencies":[` +
+		`{"child":"b","field":"greeting","expression":[{"literal":"Hello, "},{"reference":"a.name"}]}` +
+		`],` +
+		`"outputs":[` +
+		`{"name":"y","expression":[{"literal":1}]},` +
+		`{"name":"z","expression":[{"reference":"b.greeting"}]}` +
+		`]` +
+		`}`
+	if string(got) != want {
+		t.Errorf("MarshalJSON()\nGot  %s\nWant %s", got, want)
+	}
+
+	// Encoding again must produce identical output.
+	again, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	if !bytes.Equal(got, again) {
+		t.Errorf("MarshalJSON() is not deterministic\nFirst  %s\nSecond %s", got, again)
+	}
+}
+
+func TestGraph_MarshalJSON_sensitive(t *testing.T) {
+	g := &resource.Graph{
+		Resources: []*resource.Desired{
+			{
+				Name: "a",
+				Type: "secret",
+				Input: cty.ObjectVal(map[string]cty.Value{
+					"name":   cty.StringVal("db"),
+					"secret": cty.StringVal("hunter2"),
+					"token":  cty.UnknownVal(cty.String),
+				}),
+				Sensitive: []cty.Path{cty.GetAttrPath("secret"), cty.GetAttrPath("token")},
+			},
+		},
+		Dependencies: []*resource.Dependency{
+			{
+				Child: "a",
+				Field: cty.GetAttrPath("token"),
+				Expression: resource.Expression{
+					resource.ExprLiteral{Value: cty.StringVal("swordfish-")},
+					resource.ExprReference{Path: cty.GetAttrPath("b").GetAttr("id")},
+				},
+			},
+		},
+	}
+
+	got, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+
+	want := `{` +
+		`"resources":[` +
+		`{"name":"a","type":"secret","input":{"name":"db","secret":"(sensitive)","token":null}}` +
+		`],` +
+		`"dependencies":[` +
+		`{"child":"a","field":"token","expression":[{"literal":"(sensitive)"},{"reference":"b.id"}]}` +
+		`],` +
+		`"outputs":[]` +
+		`}`
+	if string(got) != want {
+		t.Errorf("MarshalJSON()\nGot  %s\nWant %s", got, want)
+	}
+}
@@ -166,6 +166,70 @@ func TestGraph_AddDependency_ErrInvalidPath(t *testing.T) {
 	}
 }
 
+func TestGraph_AddOutput(t *testing.T) {
+	g := &Graph{
+		Resources: []*Desired{
+			{Type: "foo", Name: "a"},
+		},
+	}
+
+	out := &Output{
+		Name: "x",
+		Expression: Expression{
+			ExprReference{Path: cty.GetAttrPath("a").GetAttr("b")},
+		},
+	}
+	if err := g.AddOutput(out); err != nil {
+		t.Fatalf("AddOutput() err = %v", err)
+	}
+	if err := g.AddOutput(out); err == nil {
+		t.Errorf("AddOutput() with duplicate name, want error")
+	}
+
+	missing := &Output{
+		Name: "y",
+		Expression: Expression{
+			ExprReference{Path: cty.GetAttrPath("nonexisting").GetAttr("b")},
+		},
+	}
+	if err := g.AddOutput(missing); err == nil {
+		t.Errorf("AddOutput() with missing resource, want error")
+	}
+
+	if len(g.Outputs) != 1 {
+		t.Errorf("Got %d outputs, want 1", len(g.Outputs))
+	}
+}
+
+func TestGraph_AddMove(t *testing.T) {
+	g := &Graph{
+		Resources: []*Desired{
+			{Type: "foo", Name: "a"},
+			{Type: "foo", Name: "b"},
+		},
+	}
+
+	if err := g.AddMove(&Move{From: "old", To: "b"}); err != nil {
+		t.Fatalf("AddMove() err = %v", err)
+	}
+	if err := g.AddMove(&Move{From: "old", To: "a"}); err == nil {
+		t.Errorf("AddMove() with duplicate from, want error")
+	}
+	if err := g.AddMove(&Move{From: "older", To: "b"}); err == nil {
+		t.Errorf("AddMove() with duplicate to, want error")
+	}
+	if err := g.AddMove(&Move{From: "x", To: "nonexisting"}); err == nil {
+		t.Errorf("AddMove() to missing resource, want error")
+	}
+	if err := g.AddMove(&Move{From: "a", To: "b"}); err == nil {
+		t.Errorf("AddMove() from existing resource, want error")
+	}
+
+	if len(g.MovedResources()) != 1 {
+		t.Errorf("Got %d moves, want 1", len(g.MovedResources()))
+	}
+}
+
 func TestGraph_ParentResources(t *testing.T) {
 	a := &Desired{Type: "foo", Name: "a"}
 	b := &Desired{Type: "foo", Name: "b"}
@@ -300,3 +364,41 @@ func TestGraph_LeafResources(t *testing.T) {
 		t.Errorf("Dependencies to a (-got +want)\n%s", diff)
 	}
 }
+
+func TestGraph_DependsOn(t *testing.T) {
+	a := &Desired{Type: "foo", Name: "a"}
+	b := &Desired{Type: "foo", Name: "b"}
+	c := &Desired{Type: "foo", Name: "c", DependsOn: []string{"a", "b"}}
+	d := &Desired{Type: "foo", Name: "d"}
+	g := &Graph{
+		Resources: []*Desired{a, b, c, d},
+		Dependencies: []*Dependency{
+			{
+				// c also has a value dependency on a
+				Child: "c",
+				Field: cty.GetAttrPath("input"),
+				Expression: Expression{
+					ExprReference{
+						Path: cty.GetAttrPath(a.Name).GetAttr("output"),
+					},
+				},
+			},
+		},
+	}
+
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool { return a.Equals(b).True() }),
+	}
+
+	got := g.ParentResources("c")
+	want := []*Desired{a, b}
+	if diff := cmp.Diff(got, want, opts...); diff != "" {
+		t.Errorf("Parent resources c (-got +want)\n%s", diff)
+	}
+
+	got = g.LeafResources()
+	want = []*Desired{c, d}
+	if diff := cmp.Diff(got, want, opts...); diff != "" {
+		t.Errorf("Leaf resources (-got +want)\n%s", diff)
+	}
+}
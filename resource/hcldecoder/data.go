@@ -0,0 +1,242 @@
+package hcldecoder
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/func/func/config"
+	"github.com/func/func/ctyext"
+	"github.com/func/func/suggest"
+	"github.com/hashicorp/hcl2/gohcl"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// A RemoteStateReader reads the outputs of other projects.
+type RemoteStateReader interface {
+	// RemoteOutputs returns the outputs of a project, keyed by output name.
+	// A nil map is returned if the project has not been applied.
+	//
+	// The reader decides which projects and outputs can be read. Values
+	// that must not be exposed to the reading project should be omitted
+	// or an error returned.
+	RemoteOutputs(project string) (map[string]cty.Value, error)
+}
+
+// remoteState contains temporary data for a decoded remote_state data
+// block.
+type remoteState struct {
+	Name     string
+	Project  string
+	Outputs  cty.Value // NilVal if the outputs could not be read, unknown if there is no reader.
+	DefRange hcl.Range
+}
+
+// decodeData decodes a data block.
+func (d *Decoder) decodeData(block *hcl.Block) hcl.Diagnostics {
+	var data config.Data
+	diags := gohcl.DecodeBody(block.Body, nil, &data)
+	if diags.HasErrors() {
+		return diags
+	}
+	data.Type = block.Labels[0]
+	data.Name = block.Labels[1]
+
+	switch data.Type {
+	case "remote_state":
+		return append(diags, d.decodeRemoteState(block, data)...)
+	default:
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported data type",
+			Detail:   fmt.Sprintf("Data of type %q is not supported. The supported type is remote_state.", data.Type),
+			Subject:  block.LabelRanges[0].Ptr(),
+			Context:  block.DefRange.Ptr(),
+		})
+	}
+}
+
+// decodeRemoteState decodes a remote_state data block and reads the outputs
+// of the project it refers to.
+func (d *Decoder) decodeRemoteState(block *hcl.Block, data config.Data) hcl.Diagnostics {
+	if ex, ok := d.remote[data.Name]; ok {
+		return []*hcl.Diagnostic{{
+			Severity: hcl.DiagError,
+			Summary:  "Duplicate remote state",
+			Detail: fmt.Sprintf(
+				"Another remote_state %q was defined in %s on line %d.",
+				data.Name, ex.DefRange.Filename, ex.DefRange.Start.Line,
+			),
+			Subject: block.DefRange.Ptr(),
+		}}
+	}
+
+	// The remote state is added before it has been read, so references to
+	// it do not produce additional errors if it cannot be read.
+	state := &remoteState{
+		Name:     data.Name,
+		DefRange: block.DefRange,
+	}
+	d.remote[data.Name] = state
+
+	var rs config.RemoteState
+	diags := gohcl.DecodeBody(data.Config, nil, &rs)
+	if diags.HasErrors() {
+		return diags
+	}
+	if ok, morediags := d.checkVariables(rs.Project); !ok {
+		return append(diags, morediags...)
+	}
+	project, morediags := rs.Project.Value(d.evalContext())
+	diags = append(diags, morediags...)
+	if morediags.HasErrors() {
+		return diags
+	}
+	project, err := convert.Convert(project, cty.String)
+	if err != nil || project.IsNull() {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid project",
+			Detail:   "The project must be set to a string.",
+			Subject:  rs.Project.Range().Ptr(),
+		})
+	}
+	state.Project = project.AsString()
+
+	if d.RemoteState == nil {
+		// The outputs cannot be read, such as when validating the
+		// configuration without a server. Values that depend on them are
+		// not known.
+		state.Outputs = cty.DynamicVal
+		return diags
+	}
+
+	outputs, err := d.RemoteState.RemoteOutputs(state.Project)
+	if err != nil {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Cannot read remote state",
+			Detail:   fmt.Sprintf("Could not read the outputs of project %q: %v.", state.Project, err),
+			Subject:  block.DefRange.Ptr(),
+		})
+	}
+	if outputs == nil {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Project has not been applied",
+			Detail:   fmt.Sprintf("The project %q does not have any state to read outputs from.", state.Project),
+			Subject:  block.DefRange.Ptr(),
+		})
+	}
+
+	state.Outputs = cty.ObjectVal(outputs)
+
+	return diags
+}
+
+// dataValue returns the value of all decoded data, to be referred to as
+// data.<type>.<name>. Returns cty.NilVal if no data has been decoded.
+func (d *Decoder) dataValue() cty.Value {
+	if len(d.remote) == 0 {
+		return cty.NilVal
+	}
+	states := make(map[string]cty.Value, len(d.remote))
+	for name, rs := range d.remote {
+		if rs.Outputs == cty.NilVal {
+			continue
+		}
+		states[name] = rs.Outputs
+	}
+	return cty.ObjectVal(map[string]cty.Value{
+		"remote_state": cty.ObjectVal(states),
+	})
+}
+
+// checkData checks that a reference to data refers to declared data that has
+// been read. Outputs that do not exist in the remote state are reported.
+//
+// If false is returned, the expression cannot be evaluated.
+func (d *Decoder) checkData(traversal hcl.Traversal) (bool, hcl.Diagnostics) {
+	var typ hcl.TraverseAttr
+	if len(traversal) > 1 {
+		typ, _ = traversal[1].(hcl.TraverseAttr)
+	}
+	name := ""
+	if len(traversal) > 2 {
+		switch step := traversal[2].(type) {
+		case hcl.TraverseAttr:
+			name = step.Name
+		case hcl.TraverseIndex:
+			if step.Key.Type() == cty.String && step.Key.IsKnown() && !step.Key.IsNull() {
+				name = step.Key.AsString()
+			}
+		}
+	}
+	if typ.Name != "remote_state" || name == "" {
+		return false, []*hcl.Diagnostic{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid data reference",
+			Detail:   "Remote state is referred to as data.remote_state.<name>.",
+			Subject:  traversal.SourceRange().Ptr(),
+		}}
+	}
+
+	state, declared := d.remote[name]
+	if !declared {
+		diag := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Undeclared remote state",
+			Detail:   fmt.Sprintf("A remote_state named %q has not been declared.", name),
+			Subject:  traversal.SourceRange().Ptr(),
+		}
+		names := make([]string, 0, len(d.remote))
+		for n := range d.remote {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if s := suggest.String(name, names); s != "" {
+			diag.Detail += fmt.Sprintf(" Did you mean %q?", s)
+		}
+		return false, []*hcl.Diagnostic{diag}
+	}
+	if state.Outputs == cty.NilVal {
+		// Reading the state failed, a diagnostic has already been produced.
+		return false, nil
+	}
+
+	if _, diags := traversal.TraverseAbs(d.evalContext()); diags.HasErrors() {
+		// HCL does not say why an index is invalid, such as an index out of
+		// range for a tuple. Apply the path to the type for a clearer
+		// message.
+		if path, ok := outputPath(traversal[3:]); ok {
+			if _, err := ctyext.ApplyTypePath(state.Outputs.Type(), path); err != nil {
+				for _, diag := range diags {
+					if diag.Summary == "Invalid index" {
+						diag.Detail = fmt.Sprintf("Remote state %s: %v.", name, err)
+					}
+				}
+			}
+		}
+		return false, diags
+	}
+	return true, nil
+}
+
+// outputPath converts a traversal into the outputs of a remote state to a
+// path. Returns false if the traversal contains a step that cannot be
+// converted.
+func outputPath(traversal hcl.Traversal) (cty.Path, bool) {
+	path := make(cty.Path, 0, len(traversal))
+	for _, step := range traversal {
+		switch s := step.(type) {
+		case hcl.TraverseAttr:
+			path = append(path, cty.GetAttrStep{Name: s.Name})
+		case hcl.TraverseIndex:
+			path = append(path, cty.IndexStep{Key: s.Key})
+		default:
+			return nil, false
+		}
+	}
+	return path, true
+}
@@ -3,7 +3,9 @@ package hcldecoder
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/func/func/config"
 	"github.com/func/func/ctyext"
@@ -12,7 +14,9 @@ import (
 	"github.com/func/func/suggest"
 	"github.com/hashicorp/hcl2/gohcl"
 	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
 	"github.com/hashicorp/hcl2/hcldec"
+	"github.com/hashicorp/hcl2/hclpack"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/convert"
 )
@@ -37,8 +41,64 @@ type Decoder struct {
 	Resources ResourceRegistry
 	Validator Validator
 
+	// AllowUnknownAttributes makes the decoder accept resource arguments that
+	// are not supported by the resource. Instead of an error, a warning is
+	// produced and the value is ignored. This allows decoding configurations
+	// written for a different version of a resource.
+	AllowUnknownAttributes bool
+
+	// StrictTypes makes the decoder reject values that must be converted to
+	// a different type, such as a number set to a string input. Instead of a
+	// warning, an error is produced. Conversions that do not change the
+	// value, such as a tuple to a list, are still allowed.
+	StrictTypes bool
+
+	// QuietConversions suppresses the warnings for values that are converted
+	// to the type of an input, such as a number set to a string input. The
+	// conversions are safe and usually intended. Other warnings, such as for
+	// duplicate values removed from a set, are still produced. Has no effect
+	// if StrictTypes is set.
+	QuietConversions bool
+
+	// Variables contains values for variables declared in the configuration.
+	// A value set here overrides the default value of the variable. Values
+	// for variables that have not been declared are ignored, unless
+	// StrictVariables is set.
+	Variables map[string]cty.Value
+
+	// StrictVariables makes the decoder reject declared variables that are
+	// never referenced, and values in Variables for variables that have not
+	// been declared. This catches typos in variable names.
+	StrictVariables bool
+
+	// Env contains the environment variables that can be read with the env
+	// function. The environment of the process decoding the configuration is
+	// never read, as it may be a server that holds its own credentials. See
+	// EnvNames for collecting the values on the client.
+	Env map[string]string
+
+	// Defaults contains default input values per provider, keyed by provider
+	// name and input name. A default is used when the input is not set on a
+	// resource of the provider. Values set in a provider block in the
+	// configuration take precedence.
+	Defaults map[string]map[string]cty.Value
+
+	// RemoteState reads the outputs of other projects for remote_state data
+	// blocks. If not set, the outputs are unknown. Inputs that are set from
+	// unknown outputs are not converted with warnings or validated.
+	RemoteState RemoteStateReader
+
 	resources map[string]*res
+	keyed     map[string]bool // Qualified names of resources with for_each.
+	each      cty.Value       // Current instance when decoding for_each.
+	vars      map[string]*variable
+	providers map[string]*provider
+	remote    map[string]*remoteState
+	outputs   []*output
+	moves     []*move
 	sources   []*config.SourceInfo
+
+	validationErrors []*ValidationError
 }
 
 // DecodeBody decodes a given raw configuration body into the target graph.
@@ -71,23 +131,70 @@ func (d *Decoder) DecodeBody(body hcl.Body, target *resource.Graph) ([]*config.S
 		return nil, diags
 	}
 
+	// Variables are decoded first, so they can be resolved when decoding
+	// other blocks.
+	d.vars = make(map[string]*variable)
 	for _, b := range cont.Blocks {
-		// Keep switch for future reference, in case other blocks are added.
-		switch b.Type { // nolint: gocritic
+		if b.Type == "variable" {
+			diags = append(diags, d.decodeVariable(b)...)
+		}
+	}
+
+	// Providers are decoded before resources, as they set default values
+	// for resource inputs.
+	d.providers = make(map[string]*provider)
+	for _, b := range cont.Blocks {
+		if b.Type == "provider" {
+			diags = append(diags, d.decodeProvider(b)...)
+		}
+	}
+
+	// Data is read before resources, so it can be resolved statically when
+	// decoding resources.
+	d.remote = make(map[string]*remoteState)
+	for _, b := range cont.Blocks {
+		if b.Type == "data" {
+			diags = append(diags, d.decodeData(b)...)
+		}
+	}
+
+	for _, b := range cont.Blocks {
+		switch b.Type {
 		case "resource":
-			if b.Labels[0] == "" {
-				diags = append(diags, &hcl.Diagnostic{
-					Severity: hcl.DiagError,
-					Summary:  "Resource name not set",
-					Subject:  b.LabelRanges[0].Ptr(),
-					Context:  b.DefRange.Ptr(),
-				})
-			}
-			diags = append(diags, d.decodeResource(b)...)
+			diags = append(diags, checkResourceName(b)...)
+			diags = append(diags, d.decodeResource(b, "")...)
+		case "module":
+			diags = append(diags, d.decodeModule(b)...)
+		case "output":
+			diags = append(diags, d.decodeOutput(b)...)
+		}
+	}
+
+	// Moved blocks refer to resources by name, so they are decoded once all
+	// resources have been decoded.
+	for _, b := range cont.Blocks {
+		if b.Type == "moved" {
+			diags = append(diags, d.decodeMoved(b)...)
 		}
 	}
 
-	diags = append(diags, d.resolveValues()...)
+	morediags := d.qualifyReferences()
+	diags = append(diags, morediags...)
+	if !morediags.HasErrors() {
+		morediags := d.resolveValues()
+		diags = append(diags, morediags...)
+		if !morediags.HasErrors() {
+			diags = append(diags, d.checkReferenceTypes()...)
+		}
+		diags = append(diags, d.checkOutputs()...)
+		diags = append(diags, d.checkDependsOn()...)
+	}
+	if d.StrictVariables {
+		diags = append(diags, d.checkUnusedVariables()...)
+	}
+	if !diags.HasErrors() {
+		diags = append(diags, d.validateDefinitions()...)
+	}
 
 	if diags.HasErrors() {
 		return d.sources, diags
@@ -111,12 +218,25 @@ func (d *Decoder) addResources(g *resource.Graph) error {
 	var deps []*resource.Dependency
 	for name, res := range d.resources {
 		r := &resource.Desired{
-			Name: name,
-			Type: res.Type,
+			Name:           name,
+			Type:           res.Type,
+			PreventDestroy: res.PreventDestroy,
+			Description:    res.Description,
+			Profile:        res.Profile,
 		}
 		if len(res.Sources) > 0 {
 			r.Sources = res.Sources
 		}
+		if len(res.IgnoreChanges) > 0 {
+			r.IgnoreChanges = res.IgnoreChanges
+		}
+		if len(res.Unset) > 0 {
+			r.Unset = res.Unset
+		}
+		r.Sensitive = sensitiveInputs(res.InputFields)
+		for _, p := range res.DependsOn {
+			r.DependsOn = append(r.DependsOn, p[0].(cty.GetAttrStep).Name)
+		}
 		v, err := cty.Transform(res.Input, func(p cty.Path, v cty.Value) (cty.Value, error) {
 			if !v.Type().IsCapsuleType() {
 				return v, nil
@@ -146,22 +266,49 @@ func (d *Decoder) addResources(g *resource.Graph) error {
 			return fmt.Errorf("add dependency: %v", err)
 		}
 	}
+	for _, o := range d.outputs {
+		out := &resource.Output{
+			Name:       o.Name,
+			Expression: o.Expression,
+			Sensitive:  o.Sensitive,
+		}
+		if err := g.AddOutput(out); err != nil {
+			return fmt.Errorf("add output: %v", err)
+		}
+	}
+	for _, m := range d.moves {
+		if err := g.AddMove(&resource.Move{From: m.From, To: m.To}); err != nil {
+			return fmt.Errorf("add move: %v", err)
+		}
+	}
 	return nil
 }
 
 // res contains temporary data for a decoded resource.
 type res struct {
-	Name     string
-	DefRange *hcl.Range
-
-	Type    string
-	Sources []string
+	Name      string // Qualified name, including namespace.
+	Namespace string // Name of module the resource was declared in, if any.
+	DefRange  *hcl.Range
+
+	Type           string
+	Description    string
+	Profile        string
+	Sources        []string
+	IgnoreChanges  []cty.Path
+	PreventDestroy bool
+	Unset          []cty.Path
+
+	// Explicit dependencies
+	DependsOn      []cty.Path
+	DependsOnRange hcl.Range
 
 	// Inputs
-	Input cty.Value
+	Input       cty.Value
+	InputFields resource.FieldSet
 
 	// Outputs
-	Outputs cty.Type
+	Outputs      cty.Type
+	OutputFields resource.FieldSet
 }
 
 // expression wraps a graph expression with the source range.
@@ -172,43 +319,613 @@ type expression struct {
 	hcl.Range
 }
 
+// output contains temporary data for a decoded output.
+type output struct {
+	Name      string
+	Sensitive bool
+	resource.Expression
+	hcl.Range
+}
+
 // exprType is the type for an encapsulated expression when the expression is
 // added as an input attribute to a resource. The capsule does not leave this
 // package, the encapsulated value is extracted when values are resolved.
 var exprType = cty.Capsule("expression", reflect.TypeOf(expression{}))
 
-// decodeResource decodes a resource block and adds it to the decoder.
-func (d *Decoder) decodeResource(block *hcl.Block) hcl.Diagnostics {
-	res := &res{
-		Name:     block.Labels[0],
-		DefRange: block.DefRange.Ptr(),
+// moduleSchema is the schema of a module block. Only resources can be
+// declared in a module.
+var moduleSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "resource", LabelNames: []string{"name"}},
+	},
+}
+
+// decodeModule decodes a module block. The resources in the module are added
+// to the decoder, namespaced with the module name.
+func (d *Decoder) decodeModule(block *hcl.Block) hcl.Diagnostics {
+	name := block.Labels[0]
+	if name == "" || strings.Contains(name, ".") {
+		return []*hcl.Diagnostic{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid module name",
+			Detail:   "A module name must be set and cannot contain a period.",
+			Subject:  block.LabelRanges[0].Ptr(),
+			Context:  block.DefRange.Ptr(),
+		}}
 	}
 
-	// Check that another resource with the same name has not already been defined.
-	if ex, ok := d.resources[res.Name]; ok {
+	cont, diags := block.Body.Content(moduleSchema)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for _, b := range cont.Blocks {
+		diags = append(diags, checkResourceName(b)...)
+		diags = append(diags, d.decodeResource(b, name)...)
+	}
+
+	return diags
+}
+
+// checkResourceName returns an error if a resource block does not have a
+// name.
+func checkResourceName(block *hcl.Block) hcl.Diagnostics {
+	if block.Labels[0] != "" {
+		return nil
+	}
+	return []*hcl.Diagnostic{{
+		Severity: hcl.DiagError,
+		Summary:  "Resource name not set",
+		Subject:  block.LabelRanges[0].Ptr(),
+		Context:  block.DefRange.Ptr(),
+	}}
+}
+
+// decodeOutput decodes an output block and adds it to the decoder.
+//
+// The output value is not resolved, as it typically refers to values that are
+// only known after the resources have been applied.
+func (d *Decoder) decodeOutput(block *hcl.Block) hcl.Diagnostics {
+	var out config.Output
+	diags := gohcl.DecodeBody(block.Body, nil, &out)
+	if diags.HasErrors() {
+		return diags
+	}
+	out.Name = block.Labels[0]
+
+	// gohcl does not report a missing expression, it sets it to null. Check
+	// that the value is set, an explicit null is allowed.
+	valueSchema := &hcl.BodySchema{Attributes: []hcl.AttributeSchema{{Name: "value", Required: true}}}
+	if _, _, morediags := block.Body.PartialContent(valueSchema); morediags.HasErrors() {
+		return morediags
+	}
+
+	if out.Name == "" {
 		return []*hcl.Diagnostic{{
 			Severity: hcl.DiagError,
-			Summary:  "Duplicate resource",
+			Summary:  "Output name not set",
+			Subject:  block.LabelRanges[0].Ptr(),
+			Context:  block.DefRange.Ptr(),
+		}}
+	}
+
+	for _, ex := range d.outputs {
+		if ex.Name == out.Name {
+			return []*hcl.Diagnostic{{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate output",
+				Detail: fmt.Sprintf(
+					"Another output %q was defined in %s on line %d.",
+					out.Name, ex.Range.Filename, ex.Range.Start.Line,
+				),
+				Subject: block.DefRange.Ptr(),
+			}}
+		}
+	}
+
+	if ok, morediags := d.checkVariables(out.Value); !ok {
+		return append(diags, morediags...)
+	}
+	ctx := d.evalContext()
+	if !expr.IsStatic(out.Value, ctx) {
+		morediags := checkFunctionCalls(out.Value, ctx)
+		morediags = append(morediags, d.checkConditionals(out.Value, cty.DynamicPseudoType, ctx)...)
+		diags = append(diags, morediags...)
+		if morediags.HasErrors() {
+			return diags
+		}
+	}
+
+	d.outputs = append(d.outputs, &output{
+		Name:       out.Name,
+		Sensitive:  out.Sensitive,
+		Expression: expr.MustConvert(out.Value, ctx),
+		Range:      out.Value.Range(),
+	})
+
+	return diags
+}
+
+// checkOutputs ensures all references in outputs refer to existing fields.
+func (d *Decoder) checkOutputs() hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	for _, o := range d.outputs {
+		o.Expression = mapReferences(o.Expression, func(ref resource.ExprReference) resource.Expression {
+			ref, diag := d.qualifyReference("", ref)
+			if diag != nil {
+				diag.Subject = o.Range.Ptr()
+				diags = append(diags, diag)
+				return resource.Expression{ref}
+			}
+			for _, path := range (resource.Expression{ref}).References() {
+				if diag := d.checkReference(path); diag != nil {
+					diag.Subject = o.Range.Ptr()
+					diags = append(diags, diag)
+					continue
+				}
+				if !o.Sensitive && d.sensitive(path) {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Output refers to sensitive value",
+						Detail: fmt.Sprintf(
+							"The value of %s is sensitive. Set sensitive = true on output %q to allow it to be exposed.",
+							ctyext.PathString(path), o.Name,
+						),
+						Subject: o.Range.Ptr(),
+					})
+				}
+			}
+			return resource.Expression{d.keyFields(ref)}
+		})
+	}
+	return diags
+}
+
+// checkDependsOn ensures all explicit dependencies refer to other existing
+// resources.
+func (d *Decoder) checkDependsOn() hcl.Diagnostics {
+	names := make([]string, 0, len(d.resources))
+	for k := range d.resources {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var diags hcl.Diagnostics
+	for _, name := range names {
+		r := d.resources[name]
+		for _, p := range r.DependsOn {
+			root, ok := p[0].(cty.GetAttrStep)
+			if !ok || len(p) > 1 {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid depends_on",
+					Detail:   "A dependency must refer to a resource, not to a field in it.",
+					Subject:  r.DependsOnRange.Ptr(),
+				})
+				continue
+			}
+			if root.Name == r.Name {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid depends_on",
+					Detail:   "A resource cannot depend on itself.",
+					Subject:  r.DependsOnRange.Ptr(),
+				})
+				continue
+			}
+			if _, ok := d.resources[root.Name]; !ok {
+				diag := &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Referenced value not found",
+					Detail:   fmt.Sprintf("An object named %q is not defined.", root.Name),
+					Subject:  r.DependsOnRange.Ptr(),
+				}
+				if s := suggest.String(root.Name, names); s != "" {
+					diag.Detail += fmt.Sprintf(" Did you mean %q?", s)
+				}
+				diags = append(diags, diag)
+			}
+		}
+	}
+	return diags
+}
+
+// validateDefinitions calls Validate on resources that implement
+// resource.Validator, so invalid input is caught before the resources are
+// applied.
+//
+// Only resources with static inputs are validated. Resources that receive
+// input from other resources, or that set a default name when applied, are
+// validated by the reconciler once all inputs are known.
+func (d *Decoder) validateDefinitions() hcl.Diagnostics {
+	names := make([]string, 0, len(d.resources))
+	for name := range d.resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diags hcl.Diagnostics
+	for _, name := range names {
+		r := d.resources[name]
+		typ := d.Resources.Type(r.Type)
+		if !typ.Implements(validatorType) || typ.Implements(namerType) || hasExpressions(r.Input) {
+			continue
+		}
+		if !r.Input.IsWhollyKnown() {
+			// Set from remote state that could not be read.
+			continue
+		}
+		val := reflect.New(typ)
+		if err := ctyext.FromCtyValue(r.Input, val.Interface(), resource.FieldName); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Cannot set input. This is always a bug.",
+				Detail:   fmt.Sprintf("Error: %v", err),
+				Subject:  r.DefRange,
+			})
+			continue
+		}
+		if def, ok := val.Elem().Interface().(resource.Defaulter); ok {
+			def.SetDefaults()
+		}
+		def := val.Elem().Interface().(resource.Validator)
+		if err := def.Validate(); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid resource",
+				Detail:   fmt.Sprintf("The resource %q is not valid: %v.", name, err),
+				Subject:  r.DefRange,
+			})
+		}
+	}
+	return diags
+}
+
+var (
+	validatorType = reflect.TypeOf((*resource.Validator)(nil)).Elem()
+	namerType     = reflect.TypeOf((*resource.Namer)(nil)).Elem()
+)
+
+// hasExpressions returns true if the value contains expressions that have not
+// been resolved.
+func hasExpressions(v cty.Value) bool {
+	found := false
+	_ = cty.Walk(v, func(p cty.Path, v cty.Value) (bool, error) {
+		if v.Type().IsCapsuleType() {
+			found = true
+		}
+		return !found, nil
+	})
+	return found
+}
+
+// checkReference checks that a reference refers to an existing input or
+// output in a resource. The returned diagnostic does not have a subject set.
+func (d *Decoder) checkReference(path cty.Path) *hcl.Diagnostic {
+	root, ok := path[0].(cty.GetAttrStep)
+	if !ok || len(path) < 2 {
+		return &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid reference",
+			Detail:   "A reference must refer to a field in a resource.",
+		}
+	}
+	parent, ok := d.resources[root.Name]
+	if !ok {
+		return d.notFound(root.Name)
+	}
+	field, ok := path[1].(cty.GetAttrStep)
+	if !ok {
+		return &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Second step must be a field name",
+		}
+	}
+	if outputType, ok := parent.Outputs.AttributeTypes()[field.Name]; ok {
+		if _, err := ctyext.ApplyTypePath(outputType, path[2:]); err != nil {
+			return &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid reference",
+				Detail:   fmt.Sprintf("Object %s (%s): %v.", parent.Name, parent.Type, err),
+			}
+		}
+		return nil
+	}
+	if !parent.Input.Type().HasAttribute(field.Name) {
+		return &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "No such field",
 			Detail: fmt.Sprintf(
-				"Another resource %q was defined in %s on line %d.",
-				res.Name, ex.DefRange.Filename, ex.DefRange.Start.Line,
+				"Object %s (%s) does not have a field %q.",
+				root.Name, parent.Type, field.Name,
 			),
-			Subject: res.DefRange.Ptr(),
-		}}
+		}
+	}
+	return nil
+}
+
+// sensitive returns true if the path refers to a resource field that is
+// marked sensitive. The path must have been checked to refer to an existing
+// field.
+func (d *Decoder) sensitive(path cty.Path) bool {
+	parent := d.resources[path[0].(cty.GetAttrStep).Name]
+	name := path[1].(cty.GetAttrStep).Name
+	if f, ok := parent.OutputFields[name]; ok {
+		return f.Sensitive()
+	}
+	if f, ok := parent.InputFields[name]; ok {
+		return f.Sensitive()
 	}
+	return false
+}
+
+// sensitiveInputs returns the sorted paths to the inputs that are marked
+// sensitive.
+func sensitiveInputs(fields resource.FieldSet) []cty.Path {
+	var names []string
+	for name, f := range fields {
+		if f.Sensitive() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var paths []cty.Path
+	for _, name := range names {
+		paths = append(paths, cty.GetAttrPath(name))
+	}
+	return paths
+}
+
+// keyFields returns the reference with KeyFields set for indexes into output
+// lists of structs that have a key field. Such an index selects the element
+// by key if the index is a string.
+//
+// The reference must have been checked to refer to an existing output.
+func (d *Decoder) keyFields(ref resource.ExprReference) resource.ExprReference {
+	root, ok := ref.Path[0].(cty.GetAttrStep)
+	if !ok || len(ref.Path) < 3 {
+		return ref
+	}
+	parent, ok := d.resources[root.Name]
+	if !ok {
+		return ref
+	}
+	name, ok := ref.Path[1].(cty.GetAttrStep)
+	if !ok {
+		return ref
+	}
+	field, ok := parent.OutputFields[name.Name]
+	if !ok {
+		return ref
+	}
+	var keyFields map[int]string
+	t := field.Type
+	for i, step := range ref.Path[2:] {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch s := step.(type) {
+		case cty.GetAttrStep:
+			if t.Kind() == reflect.Map {
+				t = t.Elem()
+				continue
+			}
+			if t.Kind() != reflect.Struct {
+				return ref
+			}
+			f, ok := resource.Fields(t)[s.Name]
+			if !ok {
+				return ref
+			}
+			t = f.Type
+		case cty.IndexStep:
+			switch t.Kind() {
+			case reflect.Map:
+				t = t.Elem()
+			case reflect.Slice:
+				t = t.Elem()
+				elem := t
+				for elem.Kind() == reflect.Ptr {
+					elem = elem.Elem()
+				}
+				if elem.Kind() != reflect.Struct || (s.Key.IsKnown() && s.Key.Type() == cty.Number) {
+					continue
+				}
+				if key := resource.Fields(elem).KeyField(); key != "" {
+					if keyFields == nil {
+						keyFields = make(map[int]string)
+					}
+					keyFields[i+2] = key
+				}
+			default:
+				return ref
+			}
+		}
+	}
+	ref.KeyFields = keyFields
+	return ref
+}
+
+// hasSource returns true if a source with the given key has been added.
+func (d *Decoder) hasSource(key string) bool {
+	for _, src := range d.sources {
+		if src.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// notFound returns a diagnostic for a reference to a resource that does not
+// exist. The returned diagnostic does not have a subject set.
+func (d *Decoder) notFound(name string) *hcl.Diagnostic {
+	if d.keyed[name] {
+		return &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid instance reference",
+			Detail: fmt.Sprintf(
+				"Resource %q has for_each set. A reference to it must include a static key, as in %s[\"key\"].",
+				name, name,
+			),
+		}
+	}
+	diag := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Referenced value not found",
+		Detail:   fmt.Sprintf("An object named %q is not defined.", name),
+	}
+	names := make([]string, 0, len(d.resources))
+	for k := range d.resources {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	if s := suggest.String(name, names); s != "" {
+		diag.Detail += fmt.Sprintf(" Did you mean %q?", s)
+	}
+	return diag
+}
+
+// qualifiedName returns the name for a resource in the graph. Resources that
+// are declared in a module are prefixed with the module name.
+func qualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// decodeResource decodes a resource block and adds it to the decoder. If the
+// resource was declared in a module, namespace is the name of the module.
+func (d *Decoder) decodeResource(block *hcl.Block, namespace string) hcl.Diagnostics {
+	name := qualifiedName(namespace, block.Labels[0])
 
 	// Decode resource body. The dynamic config will remain in resConfig.Config.
 	// This will catch missing/incorrect high level attributes (type, source).
 	var resConfig config.Resource
 	diags := gohcl.DecodeBody(block.Body, nil, &resConfig)
 	if diags.HasErrors() {
-		// Only return first diagnostic. If an expression was set on the type
-		// attribute, it would otherwise return two diagnostics: one for the
-		// variable not being allowed and another for the variable not being
-		// defined.
-		return diags[:1]
+		// Only return the first diagnostic for each attribute. If an
+		// expression was set on the type attribute, it would otherwise
+		// return two diagnostics: one for the variable not being allowed and
+		// another for the variable not being defined. The resource is not
+		// decoded further, but other resources are.
+		return firstPerSubject(diags)
+	}
+
+	forEach, morediags := d.decodeForEach(resConfig.ForEach)
+	diags = append(diags, morediags...)
+	if morediags.HasErrors() {
+		return diags
+	}
+	if forEach == cty.NilVal {
+		return append(diags, d.decodeInstance(block, resConfig, namespace, name)...)
+	}
+
+	// Check that a resource without for_each does not use the same name.
+	if ex, ok := d.resources[name]; ok {
+		return append(diags, duplicateResource(name, ex, block.DefRange))
+	}
+	if d.keyed == nil {
+		d.keyed = make(map[string]bool)
+	}
+	d.keyed[name] = true
+
+	defer func() { d.each = cty.NilVal }()
+	for it := forEach.ElementIterator(); it.Next(); {
+		k, v := it.Element()
+		d.each = cty.ObjectVal(map[string]cty.Value{
+			"key":   k,
+			"value": v,
+		})
+		diags = append(diags, d.decodeInstance(block, resConfig, namespace, instanceName(name, k.AsString()))...)
+	}
+
+	return diags
+}
+
+// decodeForEach evaluates the for_each expression on a resource. Returns
+// cty.NilVal if for_each is not set.
+func (d *Decoder) decodeForEach(ex hcl.Expression) (cty.Value, hcl.Diagnostics) {
+	if ex == nil {
+		return cty.NilVal, nil
+	}
+	if len(ex.Variables()) == 0 {
+		if v, diags := ex.Value(nil); !diags.HasErrors() && v.IsNull() {
+			return cty.NilVal, nil
+		}
+	}
+	d.markUsed(ex)
+	ctx := d.evalContext()
+	if !expr.IsStatic(ex, ctx) {
+		return cty.NilVal, []*hcl.Diagnostic{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid for_each",
+			Detail:   "The for_each value must be known before applying. It can only refer to variables.",
+			Subject:  ex.Range().Ptr(),
+		}}
+	}
+	v, diags := ex.Value(ctx)
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+	if v.IsNull() {
+		return cty.NilVal, diags
+	}
+	if !v.Type().IsMapType() && !v.Type().IsObjectType() {
+		return cty.NilVal, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid for_each",
+			Detail:   fmt.Sprintf("The for_each value must be a map, got %s.", v.Type().FriendlyName()),
+			Subject:  ex.Range().Ptr(),
+		})
+	}
+	return v, diags
+}
+
+// instanceName returns the name of an instance of a resource with for_each.
+func instanceName(name, key string) string {
+	return fmt.Sprintf("%s[%q]", name, key)
+}
+
+// duplicateResource returns a diagnostic for a resource that has already been
+// defined.
+func duplicateResource(name string, ex *res, rng hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Duplicate resource",
+		Detail: fmt.Sprintf(
+			"Another resource %q was defined in %s on line %d.",
+			name, ex.DefRange.Filename, ex.DefRange.Start.Line,
+		),
+		Subject: rng.Ptr(),
 	}
-	res.Type = resConfig.Type
+}
+
+// decodeInstance decodes a single resource. For resources with for_each, it
+// is called once per instance with the each value set.
+func (d *Decoder) decodeInstance(block *hcl.Block, resConfig config.Resource, namespace, name string) hcl.Diagnostics { // nolint: lll
+	res := &res{
+		Name:      name,
+		Namespace: namespace,
+		DefRange:  block.DefRange.Ptr(),
+		Type:      resConfig.Type,
+	}
+
+	// Check that another resource with the same name has not already been defined.
+	if ex, ok := d.resources[res.Name]; ok {
+		return hcl.Diagnostics{duplicateResource(res.Name, ex, block.DefRange)}
+	}
+	if d.keyed[res.Name] {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Duplicate resource",
+			Detail:   fmt.Sprintf("Another resource %q with for_each was defined.", res.Name),
+			Subject:  res.DefRange.Ptr(),
+		}}
+	}
+
+	var diags hcl.Diagnostics
 
 	// Add source to resource.
 	if resConfig.Source != "" {
@@ -223,7 +940,10 @@ func (d *Decoder) decodeResource(block *hcl.Block) hcl.Diagnostics {
 			}}
 		}
 		res.Sources = append(res.Sources, src.Key)
-		d.sources = append(d.sources, &src)
+		if !d.hasSource(src.Key) {
+			// Instances of a resource with for_each share the source.
+			d.sources = append(d.sources, &src)
+		}
 	}
 
 	// Get resource definition based on resource type.
@@ -244,13 +964,52 @@ func (d *Decoder) decodeResource(block *hcl.Block) hcl.Diagnostics {
 
 	fields := resource.Fields(t)
 
+	// Select provider
+	selected, morediags := d.decodeProviderRef(resConfig.Provider, resConfig.Type)
+	diags = append(diags, morediags...)
+	if morediags.HasErrors() {
+		return diags
+	}
+	if p := d.providerConfig(resConfig.Type, selected); p != nil {
+		res.Profile = p.Profile
+	}
+
+	// Decode description. If the resource type has a description input, the
+	// description is decoded as an input too.
+	_, descInput := fields.Inputs()[descriptionInput]
+	desc, morediags := d.decodeDescription(resConfig.Description, descInput)
+	diags = append(diags, morediags...)
+	res.Description = desc
+	body := resConfig.Config
+	if descInput {
+		body = withDescription(block.Body)
+	}
+
 	// Decode inputs
-	inputs, morediags := d.decodeInputs(resConfig.Config, fields.Inputs())
+	defaults := d.defaults(resConfig.Type, selected, block.DefRange)
+	inputs, morediags := d.decodeInputs(body, fields.Inputs(), defaults, cty.GetAttrPath(res.Name))
 	diags = append(diags, morediags...)
 	res.Input = inputs
+	res.InputFields = fields.Inputs()
+	res.Unset = d.unsetInputs(body, fields.Inputs(), defaults)
 
 	// Decode outputs
-	res.Outputs = fields.Outputs().CtyType()
+	res.OutputFields = fields.Outputs()
+	res.Outputs = res.OutputFields.CtyType()
+
+	// Decode lifecycle
+	if resConfig.Lifecycle != nil {
+		ignore, morediags := decodeIgnoreChanges(resConfig.Lifecycle.IgnoreChanges, fields.Inputs().CtyType())
+		diags = append(diags, morediags...)
+		res.IgnoreChanges = ignore
+		res.PreventDestroy = resConfig.Lifecycle.PreventDestroy
+	}
+
+	// Decode explicit dependencies
+	deps, morediags := decodeDependsOn(resConfig.DependsOn)
+	diags = append(diags, morediags...)
+	res.DependsOn = deps
+	res.DependsOnRange = resConfig.DependsOn.Range()
 
 	// Add resource
 	d.resources[res.Name] = res
@@ -258,17 +1017,174 @@ func (d *Decoder) decodeResource(block *hcl.Block) hcl.Diagnostics {
 	return diags
 }
 
+// descriptionInput is the name of the input that the description of a
+// resource is passed to, if the resource type has one.
+const descriptionInput = "description"
+
+// resourceSchema is the schema of the attributes and blocks in a resource that
+// are not inputs.
+var resourceSchema, _ = gohcl.ImpliedBodySchema(config.Resource{})
+
+// withDescription returns the inputs in a resource body, including the
+// description.
+func withDescription(body hcl.Body) hcl.Body {
+	schema := &hcl.BodySchema{Blocks: resourceSchema.Blocks}
+	for _, a := range resourceSchema.Attributes {
+		if a.Name != descriptionInput {
+			schema.Attributes = append(schema.Attributes, a)
+		}
+	}
+	// Diagnostics have already been reported when decoding the resource.
+	_, remain, _ := body.PartialContent(schema)
+	return remain
+}
+
+// decodeDescription evaluates the description of a resource. The description
+// may refer to variables.
+//
+// If input is set, the description is also decoded as an input of the
+// resource, where it may refer to other resources. A description that is not
+// known before applying is then not set, and errors are reported when the
+// input is decoded.
+func (d *Decoder) decodeDescription(ex hcl.Expression, input bool) (string, hcl.Diagnostics) {
+	if ex == nil {
+		return "", nil
+	}
+	if len(ex.Variables()) == 0 {
+		if v, diags := ex.Value(nil); !diags.HasErrors() && v.IsNull() {
+			return "", nil
+		}
+	}
+	desc, diags := d.evalDescription(ex)
+	if input && diags.HasErrors() {
+		return "", nil
+	}
+	return desc, diags
+}
+
+func (d *Decoder) evalDescription(ex hcl.Expression) (string, hcl.Diagnostics) {
+	d.markUsed(ex)
+	ctx := d.evalContext()
+	if !expr.IsStatic(ex, ctx) {
+		return "", hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid description",
+			Detail:   "The description must be known before applying. It can only refer to variables.",
+			Subject:  ex.Range().Ptr(),
+		}}
+	}
+	v, diags := ex.Value(ctx)
+	if diags.HasErrors() || v.IsNull() {
+		return "", diags
+	}
+	str, err := convert.Convert(v, cty.String)
+	if err != nil {
+		return "", append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid description",
+			Detail:   fmt.Sprintf("A string is required, got %s.", v.Type().FriendlyName()),
+			Subject:  ex.Range().Ptr(),
+		})
+	}
+	if !str.IsKnown() {
+		return "", diags
+	}
+	return str.AsString(), diags
+}
+
+// decodeIgnoreChanges decodes the ignore_changes lifecycle attribute. Every
+// path must refer to an input field in the given type.
+func decodeIgnoreChanges(ex hcl.Expression, inputType cty.Type) ([]cty.Path, hcl.Diagnostics) {
+	paths, diags := expr.Paths(ex)
+	if diags.HasErrors() {
+		return nil, []*hcl.Diagnostic{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid ignore_changes",
+			Detail:   "A list of references to input fields is required.",
+			Subject:  ex.Range().Ptr(),
+		}}
+	}
+	for _, p := range paths {
+		if _, err := ctyext.ApplyTypePath(inputType, p); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid ignore_changes",
+				Detail:   fmt.Sprintf("Cannot ignore changes to %s: %v.", ctyext.PathString(p), err),
+				Subject:  ex.Range().Ptr(),
+			})
+		}
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return paths, diags
+}
+
+// decodeDependsOn decodes the depends_on attribute. The returned paths are not
+// qualified; they are checked against other resources in checkDependsOn.
+func decodeDependsOn(ex hcl.Expression) ([]cty.Path, hcl.Diagnostics) {
+	paths, diags := expr.Paths(ex)
+	if diags.HasErrors() {
+		return nil, []*hcl.Diagnostic{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid depends_on",
+			Detail:   "A list of references to resources is required.",
+			Subject:  ex.Range().Ptr(),
+		}}
+	}
+	return paths, diags
+}
+
+// unsetInputs returns the paths to optional input attributes that are not set
+// in the body. Attributes that are explicitly set to null or have a default
+// value are not included.
+func (d *Decoder) unsetInputs(body hcl.Body, fields resource.FieldSet, defaults map[string]defaultValue) []cty.Path { // nolint: lll
+	// Diagnostics have already been reported when decoding the inputs.
+	cont, _, _ := body.PartialContent(d.bodySchema(fields))
+
+	var names []string
+	for name, f := range fields {
+		if f.Type.Kind() != reflect.Ptr || d.isBlock(f.Type) {
+			continue
+		}
+		if _, ok := defaults[name]; ok {
+			continue
+		}
+		if _, ok := cont.Attributes[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var paths []cty.Path
+	for _, name := range names {
+		paths = append(paths, cty.GetAttrPath(name))
+	}
+	return paths
+}
+
 // deocdeInputs decodes inputs from the body using the given type as schema.
 //
 // The resolved values are converted to the target type if required, and
 // validated if validation tags are returned from parsing the schema.
 //
+// Attributes that are not set in the body are set from defaults, if a default
+// exists. Such attributes are not required.
+//
 // The returned diagnostics may contain warnings, which should be displayed to
 // the user but still result in valid inputs.
-func (d *Decoder) decodeInputs(body hcl.Body, fields resource.FieldSet) (input cty.Value, diags hcl.Diagnostics) { // nolint: lll
+//
+// The path is the path to the body, where the first step is the name of the
+// resource. It is used for reporting validation errors.
+func (d *Decoder) decodeInputs(body hcl.Body, fields resource.FieldSet, defaults map[string]defaultValue, path cty.Path) (input cty.Value, diags hcl.Diagnostics) { // nolint: lll
 	schema := d.bodySchema(fields)
+	for i, a := range schema.Attributes {
+		if _, ok := defaults[a.Name]; ok {
+			schema.Attributes[i].Required = false
+		}
+	}
 
-	cont, diags := body.Content(schema)
+	cont, diags := d.bodyContent(body, schema)
 
 	// NOTE(akupila): We need to proceed even if diags contain errors.
 	// - If cty.NilVal is returned and another object contains a reference to
@@ -280,17 +1196,83 @@ func (d *Decoder) decodeInputs(body hcl.Body, fields resource.FieldSet) (input c
 	inputs := make(map[string]cty.Value)
 
 	// Attributes
-	morediags := d.decodeAttributes(cont, fields, inputs)
+	morediags := d.decodeAttributes(cont, fields, inputs, path)
 	diags = append(diags, morediags...)
 
 	// Blocks
-	morediags = d.decodeBlocks(cont, fields, inputs)
+	morediags = d.decodeBlocks(cont, fields, inputs, path)
 	diags = append(diags, morediags...)
 
+	// Defaults
+	for name, def := range defaults {
+		f, ok := fields[name]
+		if !ok || d.isBlock(f.Type) {
+			continue
+		}
+		if _, ok := cont.Attributes[name]; ok {
+			continue
+		}
+		v := def.Value
+		if typ := resource.CtyType(f.Type); !v.Type().Equals(typ) {
+			converted, morediags := d.convertVal(v, typ, def.Range.Ptr())
+			diags = append(diags, morediags...)
+			if morediags.HasErrors() {
+				continue
+			}
+			v = converted
+		}
+		diags = append(diags, d.validate(v, f, path.GetAttr(name), def.Range)...)
+		inputs[name] = v
+	}
+
 	return cty.ObjectVal(inputs), diags
 }
 
-func (d *Decoder) decodeAttributes(cont *hcl.BodyContent, ff resource.FieldSet, in map[string]cty.Value) hcl.Diagnostics { // nolint: lll
+// bodyContent returns the content of body matching the schema.
+//
+// If AllowUnknownAttributes is set, attributes that are not in the schema
+// produce warnings rather than errors.
+func (d *Decoder) bodyContent(body hcl.Body, schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Diagnostics) { // nolint: lll
+	if !d.AllowUnknownAttributes {
+		return body.Content(schema)
+	}
+
+	cont, remain, diags := body.PartialContent(schema)
+
+	// Any block in the remaining body is still an error.
+	extra, morediags := remain.JustAttributes()
+	diags = append(diags, morediags...)
+
+	names := make([]string, len(schema.Attributes))
+	for i, a := range schema.Attributes {
+		names[i] = a.Name
+	}
+
+	// Sort to report the warnings in a stable order.
+	unknown := make([]string, 0, len(extra))
+	for name := range extra {
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+
+	for _, name := range unknown {
+		attr := extra[name]
+		detail := fmt.Sprintf("An argument named %q is not expected here. The value is ignored.", name)
+		if s := suggest.String(name, names); s != "" {
+			detail += fmt.Sprintf(" Did you mean %q?", s)
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Unsupported argument",
+			Detail:   detail,
+			Subject:  attr.NameRange.Ptr(),
+		})
+	}
+
+	return cont, diags
+}
+
+func (d *Decoder) decodeAttributes(cont *hcl.BodyContent, ff resource.FieldSet, in map[string]cty.Value, path cty.Path) hcl.Diagnostics { // nolint: lll
 	var diags hcl.Diagnostics
 	for name, f := range ff {
 		if d.isBlock(f.Type) {
@@ -306,19 +1288,64 @@ func (d *Decoder) decodeAttributes(cont *hcl.BodyContent, ff resource.FieldSet,
 			continue
 		}
 
-		// Check if attribute contains dynamic references to other fields.
-		if len(attr.Expr.Variables()) > 0 {
-			in[name] = cty.CapsuleVal(exprType, &expression{
-				field:      f,
-				inputType:  typ,
-				Expression: expr.MustConvert(attr.Expr),
-				Range:      attr.Range,
-			})
-			continue
+		if ok, morediags := d.checkVariables(attr.Expr); !ok {
+			diags = append(diags, morediags...)
+			continue
+		}
+
+		ctx := d.evalContext()
+
+		// Check if attribute contains dynamic references to other fields.
+		if !expr.IsStatic(attr.Expr, ctx) {
+			morediags := checkFunctionCalls(attr.Expr, ctx)
+			morediags = append(morediags, d.checkConditionals(attr.Expr, typ, ctx)...)
+			diags = append(diags, morediags...)
+			if morediags.HasErrors() {
+				continue
+			}
+			in[name] = cty.CapsuleVal(exprType, &expression{
+				field:      f,
+				inputType:  typ,
+				Expression: expr.MustConvert(attr.Expr, ctx),
+				Range:      attr.Range,
+			})
+			continue
+		}
+
+		// Get static value.
+		v, morediags := attr.Expr.Value(ctx)
+		diags = append(diags, morediags...)
+		if morediags.HasErrors() {
+			continue
+		}
+
+		// An explicit null unsets an optional attribute. The value is not
+		// converted or validated.
+		if v.IsNull() {
+			if d.isRequired(f.Type) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Required argument is null",
+					Detail:   fmt.Sprintf("The argument %q is required, it cannot be set to null.", name),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+				continue
+			}
+			in[name] = cty.NullVal(typ)
+			continue
+		}
+
+		// The value is not known if it is read from remote state without a
+		// reader. It cannot be validated.
+		if !v.IsWhollyKnown() {
+			if converted, err := convert.Convert(v, typ); err == nil {
+				in[name] = converted
+				continue
+			}
 		}
 
-		// Get static value.
-		v, morediags := attr.Expr.Value(nil)
+		// A duration field may be set with a duration string.
+		v, morediags = d.convertDuration(v, f, attr.Expr.Range())
 		diags = append(diags, morediags...)
 		if morediags.HasErrors() {
 			continue
@@ -335,14 +1362,82 @@ func (d *Decoder) decodeAttributes(cont *hcl.BodyContent, ff resource.FieldSet,
 		}
 
 		// Validate static input
-		diags = append(diags, d.validate(v, f, attr.Expr.Range())...)
+		diags = append(diags, d.validate(v, f, path.GetAttr(name), attr.Expr.Range())...)
 
 		in[name] = v
 	}
 	return diags
 }
 
-func (d *Decoder) validate(val cty.Value, field resource.Field, exprRange hcl.Range) hcl.Diagnostics {
+// checkConditionals checks the conditionals in an expression that refers to
+// other fields. A condition that can be evaluated statically must be a bool.
+// If the expression itself is a conditional, the results that can be
+// evaluated statically must be convertible to want, unless want is
+// cty.DynamicPseudoType.
+func (d *Decoder) checkConditionals(ex hcl.Expression, want cty.Type, ctx *hcl.EvalContext) hcl.Diagnostics {
+	if packexpr, ok := ex.(*hclpack.Expression); ok {
+		parsed, diags := packexpr.Parse()
+		if diags.HasErrors() {
+			return diags
+		}
+		ex = parsed
+	}
+	node, ok := ex.(hclsyntax.Expression)
+	if !ok {
+		return nil
+	}
+
+	var diags hcl.Diagnostics
+	hclsyntax.VisitAll(node, func(n hclsyntax.Node) hcl.Diagnostics {
+		cond, ok := n.(*hclsyntax.ConditionalExpr)
+		if !ok || !expr.IsStatic(cond.Condition, ctx) {
+			return nil
+		}
+		v, morediags := cond.Condition.Value(ctx)
+		diags = append(diags, morediags...)
+		if morediags.HasErrors() {
+			return nil
+		}
+		if v.IsNull() {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid condition result",
+				Detail:   "The condition value is null. Conditions must either be true or false.",
+				Subject:  cond.Condition.Range().Ptr(),
+			})
+			return nil
+		}
+		if _, err := convert.Convert(v, cty.Bool); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Incorrect condition type",
+				Detail:   "The condition expression must be of type bool.",
+				Subject:  cond.Condition.Range().Ptr(),
+			})
+		}
+		return nil
+	})
+
+	cond, ok := node.(*hclsyntax.ConditionalExpr)
+	if !ok || want == cty.DynamicPseudoType {
+		return diags
+	}
+	for _, result := range []hclsyntax.Expression{cond.TrueResult, cond.FalseResult} {
+		if !expr.IsStatic(result, ctx) {
+			continue
+		}
+		v, morediags := result.Value(ctx)
+		diags = append(diags, morediags...)
+		if morediags.HasErrors() || v.Type().Equals(want) {
+			continue
+		}
+		_, morediags = d.convertVal(v, want, result.Range().Ptr())
+		diags = append(diags, morediags...)
+	}
+	return diags
+}
+
+func (d *Decoder) validate(val cty.Value, field resource.Field, path cty.Path, exprRange hcl.Range) hcl.Diagnostics {
 	rule := field.Tags["validate"]
 	if rule == "" {
 		// No validation rule
@@ -358,6 +1453,12 @@ func (d *Decoder) validate(val cty.Value, field resource.Field, exprRange hcl.Ra
 		panic(err)
 	}
 	if err := d.Validator.Validate(goval.Elem().Interface(), rule); err != nil {
+		d.validationErrors = append(d.validationErrors, &ValidationError{
+			Path:  path,
+			Rule:  rule,
+			Range: exprRange,
+			Err:   err,
+		})
 		detail := fmt.Sprintf("%+v", err)
 		diags = append(diags, &hcl.Diagnostic{
 			Severity: hcl.DiagError,
@@ -369,7 +1470,7 @@ func (d *Decoder) validate(val cty.Value, field resource.Field, exprRange hcl.Ra
 	return diags
 }
 
-func (d *Decoder) decodeBlocks(cont *hcl.BodyContent, ff resource.FieldSet, in map[string]cty.Value) hcl.Diagnostics {
+func (d *Decoder) decodeBlocks(cont *hcl.BodyContent, ff resource.FieldSet, in map[string]cty.Value, path cty.Path) hcl.Diagnostics { // nolint: lll
 	var diags hcl.Diagnostics
 
 	blocksByType := cont.Blocks.ByType()
@@ -389,11 +1490,11 @@ func (d *Decoder) decodeBlocks(cont *hcl.BodyContent, ff resource.FieldSet, in m
 			list := make([]cty.Value, len(blocks))
 			for i, b := range blocks {
 				fields := resource.Fields(f.Type.Elem()) // Do not limit to inputs -- only top level input required
-				v, morediags := d.decodeInputs(b.Body, fields)
+				v, morediags := d.decodeInputs(b.Body, fields, nil, path.GetAttr(name).Index(cty.NumberIntVal(int64(i))))
 				diags = append(diags, morediags...)
 				list[i] = v
 			}
-			in[name] = cty.ListVal(list)
+			in[name] = listVal(list)
 			continue
 		}
 
@@ -434,7 +1535,7 @@ func (d *Decoder) decodeBlocks(cont *hcl.BodyContent, ff resource.FieldSet, in m
 		// Single block
 		b := blocks[0]
 		fields := resource.Fields(f.Type) // Do not limit to inputs -- only top level input required
-		v, morediags := d.decodeInputs(b.Body, fields)
+		v, morediags := d.decodeInputs(b.Body, fields, nil, path.GetAttr(name))
 		diags = append(diags, morediags...)
 		in[name] = v
 	}
@@ -442,6 +1543,168 @@ func (d *Decoder) decodeBlocks(cont *hcl.BodyContent, ff resource.FieldSet, in m
 	return diags
 }
 
+// listVal returns the values as a list. Blocks that contain expressions have
+// a different type than blocks with static values only, in which case the
+// values are returned as a tuple.
+func listVal(vals []cty.Value) cty.Value {
+	for _, v := range vals[1:] {
+		if !v.Type().Equals(vals[0].Type()) {
+			return cty.TupleVal(vals)
+		}
+	}
+	return cty.ListVal(vals)
+}
+
+// qualifyReferences rewrites references in expressions to use the qualified
+// resource names in the graph.
+//
+// A reference in the form module.<module>.<resource> refers to a resource in
+// a module. Other references made from a resource in a module refer to
+// resources in the same module, or to a resource outside of any module if
+// the module does not declare a resource with that name.
+func (d *Decoder) qualifyReferences() hcl.Diagnostics {
+	// Sort names so diagnostics are reported in a consistent order.
+	names := make([]string, 0, len(d.resources))
+	for name := range d.resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diags hcl.Diagnostics
+	for _, name := range names {
+		r := d.resources[name]
+		namespace := r.Namespace
+		// The transform never returns an error, diagnostics are collected
+		// for all references instead.
+		_, _ = cty.Transform(r.Input, func(p cty.Path, v cty.Value) (cty.Value, error) {
+			if !v.Type().IsCapsuleType() {
+				// Not an expression
+				return v, nil
+			}
+			expr := v.EncapsulatedValue().(*expression)
+			expr.Expression = mapReferences(expr.Expression, func(ref resource.ExprReference) resource.Expression {
+				ref, diag := d.qualifyReference(namespace, ref)
+				if diag != nil {
+					diag.Subject = expr.Range.Ptr()
+					diags = append(diags, diag)
+				}
+				return resource.Expression{ref}
+			})
+			return v, nil
+		})
+		for i, p := range r.DependsOn {
+			path, diag := d.qualifyPath(namespace, p)
+			if diag != nil {
+				diag.Subject = r.DependsOnRange.Ptr()
+				diags = append(diags, diag)
+				continue
+			}
+			r.DependsOn[i] = path
+		}
+	}
+	return diags
+}
+
+// qualifyReference qualifies the path in a reference, as well as the paths to
+// keys for dynamic indexes. The returned diagnostic does not have a subject
+// set.
+func (d *Decoder) qualifyReference(namespace string, ref resource.ExprReference) (resource.ExprReference, *hcl.Diagnostic) { // nolint: lll
+	path, diag := d.qualifyPath(namespace, ref.Path)
+	if diag != nil {
+		return ref, diag
+	}
+	var keys map[int]cty.Path
+	if len(ref.Keys) > 0 {
+		keys = make(map[int]cty.Path, len(ref.Keys))
+	}
+	for pos, key := range ref.Keys {
+		// The qualified path may have a different number of steps.
+		pos += len(path) - len(ref.Path)
+		keys[pos], diag = d.qualifyPath(namespace, key)
+		if diag != nil {
+			return ref, diag
+		}
+	}
+	return resource.ExprReference{Path: path, Keys: keys}, nil
+}
+
+// qualifyPath returns the path with the first step replaced by the qualified
+// resource name. A reference to an instance of a resource with for_each, in
+// the form <resource>["key"], is replaced by the name of the instance. The
+// returned diagnostic does not have a subject set.
+func (d *Decoder) qualifyPath(namespace string, path cty.Path) (cty.Path, *hcl.Diagnostic) {
+	path, ok := d.qualifyModulePath(namespace, path)
+	if !ok {
+		return nil, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid module reference",
+			Detail:   "A reference to a resource in a module must be in the form module.<module>.<resource>.",
+		}
+	}
+	root, ok := path[0].(cty.GetAttrStep)
+	if !ok || !d.keyed[root.Name] || len(path) < 2 {
+		// References to a resource with for_each without a key are
+		// reported when resolving values.
+		return path, nil
+	}
+	index, ok := path[1].(cty.IndexStep)
+	if !ok || !index.Key.IsKnown() || index.Key.IsNull() || index.Key.Type() != cty.String {
+		return nil, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid instance reference",
+			Detail: fmt.Sprintf(
+				"Resource %q has for_each set. A reference to it must include a static key, as in %s[\"key\"].",
+				root.Name, root.Name,
+			),
+		}
+	}
+	instance := cty.GetAttrPath(instanceName(root.Name, index.Key.AsString()))
+	return append(instance, path[2:]...), nil
+}
+
+// qualifyModulePath returns the path with the first step replaced by the
+// qualified resource name. Returns false if the path is an invalid module
+// reference.
+func (d *Decoder) qualifyModulePath(namespace string, path cty.Path) (cty.Path, bool) {
+	root, ok := path[0].(cty.GetAttrStep)
+	if !ok {
+		// Invalid references are reported when resolving values.
+		return path, true
+	}
+	if root.Name == "module" {
+		if len(path) < 3 {
+			return nil, false
+		}
+		mod, ok := path[1].(cty.GetAttrStep)
+		if !ok {
+			return nil, false
+		}
+		name, ok := path[2].(cty.GetAttrStep)
+		if !ok {
+			return nil, false
+		}
+		qualified := cty.GetAttrPath(qualifiedName(mod.Name, name.Name))
+		return append(qualified, path[3:]...), true
+	}
+	if namespace == "" {
+		return path, true
+	}
+	local := qualifiedName(namespace, root.Name)
+	if !d.declared(local) && d.declared(root.Name) {
+		// Not declared in the module, refers to a root resource.
+		return path, true
+	}
+	qualified := cty.GetAttrPath(local)
+	return append(qualified, path[1:]...), true
+}
+
+// declared returns true if a resource with the given qualified name has been
+// declared, with or without for_each.
+func (d *Decoder) declared(name string) bool {
+	_, ok := d.resources[name]
+	return ok || d.keyed[name]
+}
+
 func (d *Decoder) resolveValues() hcl.Diagnostics {
 	remainingRefs := 1 // ensure at least one cycle
 	for remainingRefs > 0 {
@@ -454,127 +1717,49 @@ func (d *Decoder) resolveValues() hcl.Diagnostics {
 				}
 
 				expr := v.EncapsulatedValue().(*expression)
-				exprRefs := 0
-				for i, part := range expr.Expression {
-					ref, ok := part.(resource.ExprReference)
-					if !ok {
-						continue
-					}
-					path := ref.Path
-					exprRefs++
-
-					// Get resource name
-					root, ok := path[0].(cty.GetAttrStep)
-					if !ok {
-						diag := &hcl.Diagnostic{
-							Severity: hcl.DiagError,
-							Summary:  "First step must be an object name",
-							Subject:  expr.Range.Ptr(),
-						}
-						return cty.NilVal, hcl.Diagnostics{diag}
-					}
-
-					// Find parent resource
-					parent, ok := d.resources[root.Name]
-					if !ok {
-						diag := &hcl.Diagnostic{
-							Severity: hcl.DiagError,
-							Summary:  "Referenced value not found",
-							Detail:   fmt.Sprintf("An object named %q is not defined.", root.Name),
-							Subject:  expr.Range.Ptr(),
-						}
-						names := make([]string, 0, len(d.resources))
-						for k := range d.resources {
-							names = append(names, k)
-						}
-						if s := suggest.String(root.Name, names); s != "" {
-							diag.Detail += fmt.Sprintf(" Did you mean %q?", s)
-						}
-						return cty.NilVal, hcl.Diagnostics{diag}
-					}
-
-					// Get field name
-					field, ok := path[1].(cty.GetAttrStep)
-					if !ok {
-						diag := &hcl.Diagnostic{
-							Severity: hcl.DiagError,
-							Summary:  "Second step must be a field name",
-							Subject:  expr.Range.Ptr(),
-						}
-						return cty.NilVal, hcl.Diagnostics{diag}
-					}
-
-					// Check output
-					outputs := parent.Outputs.AttributeTypes()
-					outputType, ok := outputs[field.Name]
-					if ok {
-						// Reference to output
-						// Ensure the remaining path is valid, in case
-						// reference is to a nested field in an output.
-						_, err := ctyext.ApplyTypePath(outputType, path[2:])
-						if err != nil {
-							diag := &hcl.Diagnostic{
-								Severity: hcl.DiagError,
-								Summary:  "Invalid reference",
-								Detail:   fmt.Sprintf("Object %s (%s): %v.", parent.Name, parent.Type, err),
-								Subject:  expr.Range.Ptr(),
-							}
-							return cty.NilVal, hcl.Diagnostics{diag}
-						}
-						// TODO: Do we need to check if types match? Maybe if expression has a length of 1?
-						continue
-					}
-
-					// Check input
-					inputs := parent.Input.AsValueMap()
-					inputVal, ok := inputs[field.Name]
-					if !ok {
-						diag := &hcl.Diagnostic{
-							Severity: hcl.DiagError,
-							Summary:  "No such field",
-							Detail: fmt.Sprintf(
-								"Object %s (%s) does not have a field %q.",
-								root.Name, parent.Type, field.Name,
-							),
-							Subject: expr.Range.Ptr(),
-						}
-						// Find suggestion
-						var names []string
-						for k := range inputs {
-							names = append(names, k)
-						}
-						for k := range outputs {
-							names = append(names, k)
-						}
-						if s := suggest.String(field.Name, names); s != "" {
-							diag.Detail += fmt.Sprintf(" Did you mean %q?", s)
-						}
-						return cty.NilVal, hcl.Diagnostics{diag}
+				var diags hcl.Diagnostics
+				expr.Expression = mapReferences(expr.Expression, func(ref resource.ExprReference) resource.Expression {
+					if diags.HasErrors() {
+						return resource.Expression{ref}
 					}
-
-					if inputVal.Type().IsCapsuleType() {
-						// Reference to other reference that has not been resolved (yet).
+					resolved, pending, morediags := d.resolveExprReference(ref, expr.Range)
+					diags = append(diags, morediags...)
+					if pending {
 						remainingRefs++
-						continue
 					}
+					return resolved
+				})
+				if diags.HasErrors() {
+					return cty.NilVal, diags
+				}
 
-					expr.Expression[i] = resource.ExprLiteral{Value: inputVal}
-					exprRefs--
+				// A condition that referred to inputs may now be known, in
+				// which case the conditional is replaced with the chosen
+				// expression.
+				resolved, err := expr.Expression.ResolveConditionals()
+				if err != nil {
+					diag := &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Invalid condition",
+						Detail:   fmt.Sprintf("Evaluate condition: %v.", err),
+						Subject:  expr.Range.Ptr(),
+					}
+					return cty.NilVal, hcl.Diagnostics{diag}
 				}
 
 				// References to other inputs enable a reference to be
 				// statically resolved and replaced with the literal value.
 				// Merge any consecutive literals into one.
-				expr.Expression = expr.Expression.MergeLiterals()
+				expr.Expression = resolved.MergeLiterals()
 
-				if exprRefs == 0 {
+				if len(expr.Expression.References()) == 0 {
 					// Expression can now be statically resolved.
 					v, err := expr.Value(nil)
 					if err != nil {
 						return cty.NilVal, err
 					}
 					// Validate resolved value
-					diags := d.validate(v, expr.field, expr.Range)
+					diags := d.validate(v, expr.field, append(cty.GetAttrPath(r.Name), p...), expr.Range)
 					if diags.HasErrors() {
 						return cty.NilVal, diags
 					}
@@ -594,6 +1779,305 @@ func (d *Decoder) resolveValues() hcl.Diagnostics {
 	return nil
 }
 
+// checkReferenceTypes checks that inputs set to a single reference to an
+// output can be set from the output's type. An output that must be converted
+// produces a warning, or an error if StrictTypes is set.
+//
+// Expressions that combine references with other values, such as string
+// templates and conditionals, are converted when the resource is applied.
+func (d *Decoder) checkReferenceTypes() hcl.Diagnostics {
+	names := make([]string, 0, len(d.resources))
+	for name := range d.resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diags hcl.Diagnostics
+	for _, name := range names {
+		_ = cty.Walk(d.resources[name].Input, func(p cty.Path, v cty.Value) (bool, error) {
+			if !v.Type().IsCapsuleType() {
+				return true, nil
+			}
+			expr := v.EncapsulatedValue().(*expression)
+			if len(expr.Expression) != 1 {
+				return false, nil
+			}
+			ref, ok := expr.Expression[0].(resource.ExprReference)
+			if !ok {
+				return false, nil
+			}
+			got, ok := d.outputType(ref.Path)
+			if !ok {
+				return false, nil
+			}
+			diags = append(diags, d.checkType(got, expr.inputType, ref, expr.Range.Ptr())...)
+			return false, nil
+		})
+	}
+	return diags
+}
+
+// outputType returns the type of the output the path refers to. Returns false
+// if the path does not refer to an output.
+func (d *Decoder) outputType(path cty.Path) (cty.Type, bool) {
+	if len(path) < 2 {
+		return cty.NilType, false
+	}
+	root, ok := path[0].(cty.GetAttrStep)
+	if !ok {
+		return cty.NilType, false
+	}
+	parent, ok := d.resources[root.Name]
+	if !ok {
+		return cty.NilType, false
+	}
+	field, ok := path[1].(cty.GetAttrStep)
+	if !ok {
+		return cty.NilType, false
+	}
+	outputType, ok := parent.Outputs.AttributeTypes()[field.Name]
+	if !ok {
+		return cty.NilType, false
+	}
+	ty, err := ctyext.ApplyTypePath(outputType, path[2:])
+	if err != nil {
+		return cty.NilType, false
+	}
+	return ty, true
+}
+
+// isListOrSet returns true if ty is a list or a set type.
+func isListOrSet(ty cty.Type) bool {
+	return ty.IsListType() || ty.IsSetType()
+}
+
+// checkType checks that a value of type got, from the given reference, can be
+// set to a field of type want.
+func (d *Decoder) checkType(got, want cty.Type, ref resource.ExprReference, rng *hcl.Range) hcl.Diagnostics {
+	if got.Equals(want) || got.Equals(cty.DynamicPseudoType) || want.Equals(cty.DynamicPseudoType) {
+		return nil
+	}
+	// A list can be set to a set and the other way around, only the element
+	// types are compared.
+	if isListOrSet(got) && isListOrSet(want) {
+		return d.checkType(got.ElementType(), want.ElementType(), ref, rng)
+	}
+	if convert.GetConversion(got, want) == nil {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Unsuitable value type",
+			Detail: fmt.Sprintf(
+				"The value must be a %s, conversion from %s in %s is not possible.",
+				want.FriendlyName(),
+				got.FriendlyNameForConstraint(),
+				ref,
+			),
+			Subject: rng,
+		}}
+	}
+
+	// Converting collections, such as a list to a set, is expected.
+	if !got.IsPrimitiveType() || !want.IsPrimitiveType() {
+		return nil
+	}
+	if d.QuietConversions && !d.StrictTypes {
+		return nil
+	}
+	severity := hcl.DiagWarning
+	if d.StrictTypes {
+		severity = hcl.DiagError
+	}
+	return hcl.Diagnostics{{
+		Severity: severity,
+		Summary: fmt.Sprintf(
+			"Value is converted from %s to %s",
+			got.FriendlyNameForConstraint(),
+			want.FriendlyName(),
+		),
+		Subject: rng,
+	}}
+}
+
+// resolveExprReference resolves a reference in an expression. A reference to
+// an input with a static value is replaced with the value. A reference to an
+// output is kept. If the reference is to an input that has not been resolved
+// yet, pending is true and the reference should be resolved again later.
+func (d *Decoder) resolveExprReference(ref resource.ExprReference, rng hcl.Range) (resolved resource.Expression, pending bool, diags hcl.Diagnostics) { // nolint: lll
+	// Resolve keys for dynamic indexes first. A key that refers to an input
+	// is replaced with its value.
+	pendingKeys := false
+	for pos, keyPath := range ref.Keys {
+		val, kind, diags := d.resolveReference(keyPath, rng)
+		if diags.HasErrors() {
+			return nil, false, diags
+		}
+		switch kind {
+		case refPending:
+			pendingKeys = true
+		case refInput:
+			key, err := keyPath[2:].Apply(val)
+			if err != nil {
+				diag := &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid index",
+					Detail:   fmt.Sprintf("Get key %s: %v.", ctyext.PathString(keyPath), err),
+					Subject:  rng.Ptr(),
+				}
+				return nil, false, hcl.Diagnostics{diag}
+			}
+			ref = ref.WithKey(pos, key)
+		}
+	}
+	if pendingKeys {
+		return resource.Expression{ref}, true, nil
+	}
+
+	inputVal, kind, diags := d.resolveReference(ref.Path, rng)
+	if diags.HasErrors() {
+		return nil, false, diags
+	}
+	switch kind {
+	case refOutput:
+		return resource.Expression{d.keyFields(ref)}, false, nil
+	case refPending:
+		// Reference to other reference that has not been resolved (yet).
+		return resource.Expression{ref}, true, nil
+	}
+
+	if len(ref.Keys) > 0 {
+		diag := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid index",
+			Detail: fmt.Sprintf(
+				"The index in %s refers to an output. An input can only be indexed with a static value.",
+				ref,
+			),
+			Subject: rng.Ptr(),
+		}
+		return nil, false, hcl.Diagnostics{diag}
+	}
+
+	return resource.Expression{resource.ExprLiteral{Value: inputVal}}, false, nil
+}
+
+// mapReferences returns the expression with every reference replaced by the
+// parts returned from fn, including references in conditionals.
+func mapReferences(expr resource.Expression, fn func(ref resource.ExprReference) resource.Expression) resource.Expression { // nolint: lll
+	out := make(resource.Expression, 0, len(expr))
+	for _, part := range expr {
+		switch p := part.(type) {
+		case resource.ExprReference:
+			out = append(out, fn(p)...)
+		case resource.ExprConditional:
+			p.Condition = mapReferences(p.Condition, fn)
+			p.True = mapReferences(p.True, fn)
+			p.False = mapReferences(p.False, fn)
+			out = append(out, p)
+		default:
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// refKind is the kind of field a reference refers to.
+type refKind int
+
+const (
+	refOutput  refKind = iota // Output, resolved when the parent is applied.
+	refInput                  // Input with a static value.
+	refPending                // Input that refers to another field.
+)
+
+// resolveReference resolves the field the path refers to. If the reference is
+// to an input with a static value, the value of the input is returned.
+func (d *Decoder) resolveReference(path cty.Path, rng hcl.Range) (cty.Value, refKind, hcl.Diagnostics) {
+	// Get resource name
+	root, ok := path[0].(cty.GetAttrStep)
+	if !ok {
+		diag := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "First step must be an object name",
+			Subject:  rng.Ptr(),
+		}
+		return cty.NilVal, 0, hcl.Diagnostics{diag}
+	}
+
+	// Find parent resource
+	parent, ok := d.resources[root.Name]
+	if !ok {
+		diag := d.notFound(root.Name)
+		diag.Subject = rng.Ptr()
+		return cty.NilVal, 0, hcl.Diagnostics{diag}
+	}
+
+	// Get field name
+	field, ok := path[1].(cty.GetAttrStep)
+	if !ok {
+		diag := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Second step must be a field name",
+			Subject:  rng.Ptr(),
+		}
+		return cty.NilVal, 0, hcl.Diagnostics{diag}
+	}
+
+	// Check output
+	outputs := parent.Outputs.AttributeTypes()
+	outputType, ok := outputs[field.Name]
+	if ok {
+		// Reference to output
+		// Ensure the remaining path is valid, in case reference is to a
+		// nested field in an output.
+		_, err := ctyext.ApplyTypePath(outputType, path[2:])
+		if err != nil {
+			diag := &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid reference",
+				Detail:   fmt.Sprintf("Object %s (%s): %v.", parent.Name, parent.Type, err),
+				Subject:  rng.Ptr(),
+			}
+			return cty.NilVal, 0, hcl.Diagnostics{diag}
+		}
+		// The type is checked in checkReferenceTypes, once all references
+		// have been resolved.
+		return cty.NilVal, refOutput, nil
+	}
+
+	// Check input
+	inputs := parent.Input.AsValueMap()
+	inputVal, ok := inputs[field.Name]
+	if !ok {
+		diag := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "No such field",
+			Detail: fmt.Sprintf(
+				"Object %s (%s) does not have a field %q.",
+				root.Name, parent.Type, field.Name,
+			),
+			Subject: rng.Ptr(),
+		}
+		// Find suggestion
+		var names []string
+		for k := range inputs {
+			names = append(names, k)
+		}
+		for k := range outputs {
+			names = append(names, k)
+		}
+		if s := suggest.String(field.Name, names); s != "" {
+			diag.Detail += fmt.Sprintf(" Did you mean %q?", s)
+		}
+		return cty.NilVal, 0, hcl.Diagnostics{diag}
+	}
+
+	if inputVal.Type().IsCapsuleType() {
+		// Reference to other reference that has not been resolved (yet).
+		return cty.NilVal, refPending, nil
+	}
+	return inputVal, refInput, nil
+}
+
 func (d *Decoder) convertVal(input cty.Value, want cty.Type, rng *hcl.Range) (cty.Value, hcl.Diagnostics) {
 	got := input.Type()
 
@@ -623,13 +2107,38 @@ func (d *Decoder) convertVal(input cty.Value, want cty.Type, rng *hcl.Range) (ct
 	if got.IsTupleType() && want.IsListType() {
 		return converted, nil
 	}
+	if (got.IsTupleType() || got.IsListType()) && want.IsSetType() {
+		if !input.IsKnown() || input.IsNull() || !converted.IsKnown() {
+			return converted, nil
+		}
+		// Converting to a set removes duplicate values.
+		if n := input.LengthInt() - converted.LengthInt(); n > 0 {
+			return converted, []*hcl.Diagnostic{{
+				Severity: hcl.DiagWarning,
+				Summary:  "Duplicate values removed",
+				Detail: fmt.Sprintf(
+					"The value is a set of unique values. Number of duplicates removed: %d.",
+					n,
+				),
+				Subject: rng,
+			}}
+		}
+		return converted, nil
+	}
 	if got.IsObjectType() && want.IsMapType() {
 		return converted, nil
 	}
 
 	// Add warning that conversion was necessary.
+	if d.QuietConversions && !d.StrictTypes {
+		return converted, nil
+	}
+	severity := hcl.DiagWarning
+	if d.StrictTypes {
+		severity = hcl.DiagError
+	}
 	diags := []*hcl.Diagnostic{{
-		Severity: hcl.DiagWarning,
+		Severity: severity,
 		Summary: fmt.Sprintf(
 			"Value is converted from %s to %s",
 			got.FriendlyNameForConstraint(),
@@ -641,6 +2150,47 @@ func (d *Decoder) convertVal(input cty.Value, want cty.Type, rng *hcl.Range) (ct
 	return converted, diags
 }
 
+// convertDuration converts a duration string, such as "30s", to a number in
+// the unit of a duration field. A number given as a string is converted to a
+// number. Other values are returned as is.
+func (d *Decoder) convertDuration(v cty.Value, f resource.Field, rng hcl.Range) (cty.Value, hcl.Diagnostics) {
+	unit := f.Duration()
+	if unit == 0 || !v.Type().Equals(cty.String) || !v.IsKnown() {
+		return v, nil
+	}
+	if n, err := convert.Convert(v, cty.Number); err == nil {
+		return n, nil
+	}
+
+	dur, err := time.ParseDuration(v.AsString())
+	if err != nil {
+		return cty.NilVal, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid duration",
+			Detail: fmt.Sprintf(
+				"The value %q is not a valid duration. "+
+					"Set a number or a duration with a unit, such as \"30s\" or \"5m\".",
+				v.AsString(),
+			),
+			Subject: rng.Ptr(),
+		}}
+	}
+
+	unitName := "seconds"
+	if unit == time.Millisecond {
+		unitName = "milliseconds"
+	}
+	if dur%unit != 0 {
+		return cty.NilVal, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid duration",
+			Detail:   fmt.Sprintf("The duration must be a whole number of %s, got %s.", unitName, dur),
+			Subject:  rng.Ptr(),
+		}}
+	}
+	return cty.NumberIntVal(int64(dur / unit)), nil
+}
+
 func (d *Decoder) bodySchema(fields resource.FieldSet) *hcl.BodySchema {
 	s := &hcl.BodySchema{}
 	for name, f := range fields {
@@ -678,6 +2228,9 @@ func (d *Decoder) isBlock(t reflect.Type) bool {
 	return false
 }
 
+// isRequired returns true if an input of the given type must be set. Value
+// types are required, as their zero value cannot be told apart from an unset
+// value. Pointers, slices and maps are optional and null when not set.
 func (d *Decoder) isRequired(t reflect.Type) bool {
 	switch t.Kind() {
 	case reflect.Ptr, reflect.Slice, reflect.Map:
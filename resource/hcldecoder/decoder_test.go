@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"os"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"unicode"
 
 	"github.com/func/func/config"
+	"github.com/func/func/ctyext"
 	"github.com/func/func/resource"
 	"github.com/func/func/resource/hcldecoder"
 	"github.com/go-stack/stack"
@@ -17,6 +21,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/hashicorp/hcl2/hclpack"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -49,6 +54,55 @@ func TestDecodeBody(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Description",
+			config: `
+				resource "foo" {
+					type        = "a"
+					description = "Greets the user"
+					input       = "hello"
+				}
+			`,
+			types: map[string]reflect.Type{"a": reflect.TypeOf(simpleDef{})},
+			want: &resource.Graph{
+				Resources: []*resource.Desired{
+					{
+						Type:        "a",
+						Name:        "foo",
+						Description: "Greets the user",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.StringVal("hello"),
+						}),
+					},
+				},
+			},
+		},
+		{
+			name: "DescriptionInput",
+			config: `
+				resource "foo" {
+					type        = "a"
+					description = "Greets the user"
+				}
+			`,
+			types: map[string]reflect.Type{
+				"a": reflect.TypeOf(struct {
+					Description string `func:"input"`
+				}{}),
+			},
+			want: &resource.Graph{
+				Resources: []*resource.Desired{
+					{
+						Type:        "a",
+						Name:        "foo",
+						Description: "Greets the user",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"description": cty.StringVal("Greets the user"),
+						}),
+					},
+				},
+			},
+		},
 		{
 			name: "ConvertInputs",
 			config: `
@@ -709,6 +763,187 @@ func TestDecodeBody(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "OutputIndexInput",
+			config: `
+				resource "foo" {
+					type = "complex"
+				}
+				resource "key" {
+					type  = "simple"
+					input = "foo"
+				}
+				resource "bar" {
+					type  = "simple"
+					input = foo.nested[key.input].output
+				}
+			`,
+			types: map[string]reflect.Type{
+				"complex": reflect.TypeOf(struct {
+					Nested map[string]simpleDef `func:"output"`
+				}{}),
+				"simple": reflect.TypeOf(simpleDef{}),
+			},
+			want: &resource.Graph{
+				Resources: []*resource.Desired{
+					{
+						Type:  "complex",
+						Name:  "foo",
+						Input: cty.EmptyObjectVal,
+					},
+					{
+						Type: "simple",
+						Name: "key",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.StringVal("foo"),
+						}),
+					},
+					{
+						Type: "simple",
+						Name: "bar",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.UnknownVal(cty.String),
+						}),
+					},
+				},
+				Dependencies: []*resource.Dependency{
+					{
+						Child: "bar",
+						Field: cty.GetAttrPath("input"),
+						Expression: resource.Expression{
+							resource.ExprReference{
+								// Static key is resolved, no dependency to key.
+								Path: cty.GetAttrPath("foo").GetAttr("nested").Index(cty.StringVal("foo")).GetAttr("output"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "OutputIndexReference",
+			config: `
+				resource "foo" {
+					type = "complex"
+				}
+				resource "key" {
+					type = "simple"
+				}
+				resource "bar" {
+					type  = "simple"
+					input = foo.nested[key.output].output
+				}
+			`,
+			types: map[string]reflect.Type{
+				"complex": reflect.TypeOf(struct {
+					Nested map[string]simpleDef `func:"output"`
+				}{}),
+				"simple": reflect.TypeOf(simpleDef{}),
+			},
+			want: &resource.Graph{
+				Resources: []*resource.Desired{
+					{
+						Type:  "complex",
+						Name:  "foo",
+						Input: cty.EmptyObjectVal,
+					},
+					{
+						Type: "simple",
+						Name: "key",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.NullVal(cty.String),
+						}),
+					},
+					{
+						Type: "simple",
+						Name: "bar",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.UnknownVal(cty.String),
+						}),
+					},
+				},
+				Dependencies: []*resource.Dependency{
+					{
+						Child: "bar",
+						Field: cty.GetAttrPath("input"),
+						Expression: resource.Expression{
+							resource.ExprReference{
+								Path: cty.GetAttrPath("foo").GetAttr("nested").Index(cty.DynamicVal).GetAttr("output"),
+								Keys: map[int]cty.Path{
+									2: cty.GetAttrPath("key").GetAttr("output"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "ForEach",
+			config: `
+				resource "foo" {
+					type     = "simple"
+					for_each = { us = "us-east-1", eu = "eu-west-1" }
+					input    = "${each.key}:${each.value}"
+				}
+				resource "bar" {
+					type       = "simple"
+					input      = foo["eu"].output
+					depends_on = [foo["us"]]
+				}
+			`,
+			types: map[string]reflect.Type{
+				"simple": reflect.TypeOf(simpleDef{}),
+			},
+			want: &resource.Graph{
+				Resources: []*resource.Desired{
+					{
+						Type: "simple",
+						Name: `foo["eu"]`,
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.StringVal("eu:eu-west-1"),
+						}),
+					},
+					{
+						Type: "simple",
+						Name: `foo["us"]`,
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.StringVal("us:us-east-1"),
+						}),
+					},
+					{
+						Type:      "simple",
+						Name:      "bar",
+						DependsOn: []string{`foo["us"]`},
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.UnknownVal(cty.String),
+						}),
+					},
+				},
+				Dependencies: []*resource.Dependency{
+					{
+						Child: "bar",
+						Field: cty.GetAttrPath("input"),
+						Expression: resource.Expression{
+							resource.ExprReference{Path: cty.GetAttrPath(`foo["eu"]`).GetAttr("output")},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "ForEachEmpty",
+			config: `
+				resource "foo" {
+					type     = "simple"
+					for_each = {}
+				}
+			`,
+			types: map[string]reflect.Type{
+				"simple": reflect.TypeOf(simpleDef{}),
+			},
+			want: &resource.Graph{},
+		},
 		{
 			name: "NestedDependencies",
 			config: `
@@ -863,75 +1098,291 @@ func TestDecodeBody(t *testing.T) {
 				},
 			},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			defer checkPanic(t)
-			g := &resource.Graph{}
-
-			parser := &testParser{}
-			body := parser.Parse(t, tt.config)
-
-			dec := &hcldecoder.Decoder{
-				Resources: &resource.Registry{Types: tt.types},
-				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
-			}
-			srcs, diags := dec.DecodeBody(body, g)
-			parser.CheckDiags(t, diags)
-
-			opts := []cmp.Option{
-				cmp.Comparer(func(a, b cty.Path) bool { return a.Equals(b) }),
-				cmp.Comparer(func(a, b cty.Value) bool {
-					if a.IsWhollyKnown() && b.IsWhollyKnown() {
-						return a.Equals(b).True()
-					}
-					return a.GoString() == b.GoString()
-				}),
-				// Order of resource or dependencies do not matter
-				cmpopts.SortSlices(func(a, b *resource.Desired) bool { return a.Name < b.Name }),
-				cmpopts.SortSlices(func(a, b *resource.Dependency) bool {
-					astr := fmt.Sprintf("%+v", a)
-					bstr := fmt.Sprintf("%+v", b)
-					return astr < bstr
-				}),
-			}
-			if diff := cmp.Diff(g, tt.want, opts...); diff != "" {
-				t.Errorf("Graph does not match (-got +want)\n%s", diff)
-			}
-			if diff := cmp.Diff(srcs, tt.wantSources, cmpopts.EquateEmpty()); diff != "" {
-				t.Errorf("Sources do not match (-got +want)\n%s", diff)
-			}
-		})
-	}
-}
-
-func TestDecodeBody_Diagnostics(t *testing.T) {
-	tests := []struct {
-		name      string
-		config    string
-		types     map[string]reflect.Type
-		validator hcldecoder.Validator
-		diags     hcl.Diagnostics // filename is always file.hcl
-	}{
 		{
-			name: "ExtraLabel",
+			name: "Modules",
 			config: `
-				resource "foo" "bar" {}
+				module "a" {
+					resource "foo" {
+						type  = "simple"
+						input = "a"
+					}
+					resource "bar" {
+						type  = "simple"
+						input = foo.output
+					}
+				}
+				module "b" {
+					resource "foo" {
+						type  = "simple"
+						input = "b"
+					}
+				}
+				resource "foo" {
+					type  = "simple"
+					input = "${module.a.foo.input}-${module.b.foo.output}"
+				}
 			`,
-			types:     map[string]reflect.Type{"a": reflect.TypeOf(simpleDef{})},
-			validator: ValidateFunc(func(interface{}, string) error { return nil }),
-			diags: hcl.Diagnostics{{
-				Severity: hcl.DiagError,
-				Summary:  "Extraneous label for resource",
-				Detail:   "Only 1 labels (name) are expected for resource blocks.",
-				Subject: &hcl.Range{
-					Filename: "file.hcl",
-					Start:    hcl.Pos{Line: 1, Column: 16, Byte: 15},
-					End:      hcl.Pos{Line: 1, Column: 21, Byte: 20},
-				},
-				Context: &hcl.Range{
-					Filename: "file.hcl",
-					Start:    hcl.Pos{Line: 1, Column: 1, Byte: 0},
+			types: map[string]reflect.Type{
+				"simple": reflect.TypeOf(simpleDef{}),
+			},
+			want: &resource.Graph{
+				Resources: []*resource.Desired{
+					{
+						Type: "simple",
+						Name: "a.foo",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.StringVal("a"),
+						}),
+					},
+					{
+						Type: "simple",
+						Name: "a.bar",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.UnknownVal(cty.String),
+						}),
+					},
+					{
+						Type: "simple",
+						Name: "b.foo",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.StringVal("b"),
+						}),
+					},
+					{
+						Type: "simple",
+						Name: "foo",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.UnknownVal(cty.String),
+						}),
+					},
+				},
+				Dependencies: []*resource.Dependency{
+					{
+						Child: "a.bar",
+						Field: cty.GetAttrPath("input"),
+						Expression: resource.Expression{
+							resource.ExprReference{Path: cty.GetAttrPath("a.foo").GetAttr("output")},
+						},
+					},
+					{
+						Child: "foo",
+						Field: cty.GetAttrPath("input"),
+						Expression: resource.Expression{
+							resource.ExprLiteral{Value: cty.StringVal("a-")},
+							resource.ExprReference{Path: cty.GetAttrPath("b.foo").GetAttr("output")},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "ModuleRootReference",
+			config: `
+				resource "role" {
+					type  = "simple"
+					input = "root"
+				}
+				resource "foo" {
+					type  = "simple"
+					input = "root"
+				}
+				module "a" {
+					resource "foo" {
+						type  = "simple"
+						input = "a"
+					}
+					resource "bar" {
+						type  = "simple"
+						input = "${role.output}-${foo.output}"
+					}
+				}
+			`,
+			types: map[string]reflect.Type{
+				"simple": reflect.TypeOf(simpleDef{}),
+			},
+			want: &resource.Graph{
+				Resources: []*resource.Desired{
+					{
+						Type: "simple",
+						Name: "role",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.StringVal("root"),
+						}),
+					},
+					{
+						Type: "simple",
+						Name: "foo",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.StringVal("root"),
+						}),
+					},
+					{
+						Type: "simple",
+						Name: "a.foo",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.StringVal("a"),
+						}),
+					},
+					{
+						Type: "simple",
+						Name: "a.bar",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.UnknownVal(cty.String),
+						}),
+					},
+				},
+				Dependencies: []*resource.Dependency{
+					{
+						Child: "a.bar",
+						Field: cty.GetAttrPath("input"),
+						Expression: resource.Expression{
+							// role is not declared in the module, foo is.
+							resource.ExprReference{Path: cty.GetAttrPath("role").GetAttr("output")},
+							resource.ExprLiteral{Value: cty.StringVal("-")},
+							resource.ExprReference{Path: cty.GetAttrPath("a.foo").GetAttr("output")},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Outputs",
+			config: `
+				resource "foo" {
+					type  = "simple"
+					input = "hello"
+				}
+				module "mod" {
+					resource "bar" {
+						type  = "simple"
+						input = "world"
+					}
+				}
+				output "static" {
+					value = "abc"
+				}
+				output "out" {
+					value = foo.output
+				}
+				output "combined" {
+					value = "${foo.input}-${module.mod.bar.output}"
+				}
+			`,
+			types: map[string]reflect.Type{
+				"simple": reflect.TypeOf(simpleDef{}),
+			},
+			want: &resource.Graph{
+				Resources: []*resource.Desired{
+					{
+						Type: "simple",
+						Name: "foo",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.StringVal("hello"),
+						}),
+					},
+					{
+						Type: "simple",
+						Name: "mod.bar",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"input": cty.StringVal("world"),
+						}),
+					},
+				},
+				Outputs: []*resource.Output{
+					{
+						Name: "static",
+						Expression: resource.Expression{
+							resource.ExprLiteral{Value: cty.StringVal("abc")},
+						},
+					},
+					{
+						Name: "out",
+						Expression: resource.Expression{
+							resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("output")},
+						},
+					},
+					{
+						Name: "combined",
+						Expression: resource.Expression{
+							resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("input")},
+							resource.ExprLiteral{Value: cty.StringVal("-")},
+							resource.ExprReference{Path: cty.GetAttrPath("mod.bar").GetAttr("output")},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: tt.types},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			srcs, diags := dec.DecodeBody(body, g)
+			parser.CheckDiags(t, diags)
+
+			opts := []cmp.Option{
+				cmp.Comparer(func(a, b cty.Path) bool { return a.Equals(b) }),
+				cmp.Comparer(func(a, b cty.Value) bool {
+					if a.IsWhollyKnown() && b.IsWhollyKnown() {
+						return a.Equals(b).True()
+					}
+					return a.GoString() == b.GoString()
+				}),
+				// Order of resource or dependencies do not matter
+				cmpopts.SortSlices(func(a, b *resource.Desired) bool { return a.Name < b.Name }),
+				cmpopts.SortSlices(func(a, b *resource.Dependency) bool {
+					astr := fmt.Sprintf("%+v", a)
+					bstr := fmt.Sprintf("%+v", b)
+					return astr < bstr
+				}),
+				// Unset inputs are tested in TestDecodeBody_unset.
+				cmpopts.IgnoreFields(resource.Desired{}, "Unset"),
+			}
+			if diff := cmp.Diff(g, tt.want, opts...); diff != "" {
+				t.Errorf("Graph does not match (-got +want)\n%s", diff)
+			}
+			if diff := cmp.Diff(srcs, tt.wantSources, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Sources do not match (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_Diagnostics(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		types     map[string]reflect.Type
+		validator hcldecoder.Validator
+		diags     hcl.Diagnostics // filename is always file.hcl
+	}{
+		{
+			name: "ExtraLabel",
+			config: `
+				resource "foo" "bar" {}
+			`,
+			types:     map[string]reflect.Type{"a": reflect.TypeOf(simpleDef{})},
+			validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			diags: hcl.Diagnostics{{
+				Severity: hcl.DiagError,
+				Summary:  "Extraneous label for resource",
+				Detail:   "Only 1 labels (name) are expected for resource blocks.",
+				Subject: &hcl.Range{
+					Filename: "file.hcl",
+					Start:    hcl.Pos{Line: 1, Column: 16, Byte: 15},
+					End:      hcl.Pos{Line: 1, Column: 21, Byte: 20},
+				},
+				Context: &hcl.Range{
+					Filename: "file.hcl",
+					Start:    hcl.Pos{Line: 1, Column: 1, Byte: 0},
 					End:      hcl.Pos{Line: 1, Column: 23, Byte: 22},
 				},
 			}},
@@ -1603,53 +2054,3299 @@ Want:
 	}
 }
 
-// ---
-
-type testParser struct {
-	filename string
-	files    map[string]*hcl.File
-}
-
-func (p *testParser) Parse(t *testing.T, src string) hcl.Body {
-	t.Helper()
-	if p.filename == "" {
-		p.filename = t.Name()
-	}
-	src = unindent(src)
-	src = strings.TrimSpace(src)
-	f, diags := hclsyntax.ParseConfig([]byte(src), p.filename, hcl.Pos{Byte: 0, Line: 1, Column: 1})
-	if diags.HasErrors() {
-		t.Errorf("Parse test body: %v", diags)
+func TestSummary(t *testing.T) {
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		resource "foo" {
+			type   = "a"
+			string = 123
+		}
+	`)
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"a": reflect.TypeOf(struct {
+				String string `func:"input"`
+			}{}),
+		}},
+		Validator: ValidateFunc(func(interface{}, string) error { return nil }),
 	}
-	if p.files == nil {
-		p.files = make(map[string]*hcl.File)
+	_, converted := dec.DecodeBody(body, &resource.Graph{})
+	if len(converted) == 0 {
+		t.Fatal("Decoding did not produce a conversion warning")
 	}
-	p.files[p.filename] = f
-	return f.Body
-}
 
-func unindent(str string) string {
-	str = strings.TrimLeft(str, "\n")
-	ind := ""
-	for _, c := range str {
-		if !unicode.IsSpace(c) {
-			break
-		}
-		ind += string(c)
+	errDiag := &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "An error"}
+
+	tests := []struct {
+		name         string
+		diags        hcl.Diagnostics
+		wantErrors   int
+		wantWarnings int
+	}{
+		{"Nil", nil, 0, 0},
+		{"Warnings", converted, 0, 1},
+		{"Errors", hcl.Diagnostics{errDiag, errDiag}, 2, 0},
+		{"Mixed", append(hcl.Diagnostics{errDiag}, converted...), 1, 1},
 	}
-	lines := strings.Split(str, "\n")
-	for i, l := range lines {
-		lines[i] = strings.Replace(l, ind, "", 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, warns := hcldecoder.Summary(tt.diags)
+			if errs != tt.wantErrors || warns != tt.wantWarnings {
+				t.Errorf("Summary() = (%d, %d), want (%d, %d)", errs, warns, tt.wantErrors, tt.wantWarnings)
+			}
+			if (errs > 0) != tt.diags.HasErrors() {
+				t.Errorf("Got %d errors, HasErrors() = %t", errs, tt.diags.HasErrors())
+			}
+		})
 	}
-	return strings.Join(lines, "\n")
 }
 
-func indent(str string, ind string) string {
-	lines := strings.Split(str, "\n")
-	for i, s := range lines {
-		lines[i] = ind + s
-	}
-	return strings.Join(lines, "\n")
+func TestDecodeBody_heredoc(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name: "Heredoc",
+			config: `
+				resource "foo" {
+					type  = "a"
+					input = <<EOT
+				{
+				  "Version": "2012-10-17",
+				  "Statement": []
+				}
+				EOT
+				}
+			`,
+			want: "{\n  \"Version\": \"2012-10-17\",\n  \"Statement\": []\n}\n",
+		},
+		{
+			name: "IndentedHeredoc",
+			config: `
+				resource "foo" {
+					type  = "a"
+					input = <<-EOT
+						line one
+						  line two
+						line three
+					EOT
+				}
+			`,
+			want: "line one\n  line two\nline three\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"a": reflect.TypeOf(simpleDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, g)
+			if len(diags) > 0 {
+				// No conversion warnings either.
+				t.Fatalf("DecodeBody() diagnostics:\n%s", parser.DiagString(diags))
+			}
+
+			if len(g.Resources) != 1 {
+				t.Fatalf("Got %d resources, want 1", len(g.Resources))
+			}
+			got := g.Resources[0].Input.GetAttr("input")
+			want := cty.StringVal(tt.want)
+			if !got.RawEquals(want) {
+				t.Errorf("Input does not match\nGot:  %q\nWant: %q", got.AsString(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_allowUnknownAttributes(t *testing.T) {
+	config := `
+		resource "foo" {
+			type    = "a"
+			input   = "hello"
+			removed = "value"
+		}
+	`
+	tests := []struct {
+		name         string
+		allowUnknown bool
+		wantSeverity hcl.DiagnosticSeverity
+	}{
+		{"Strict", false, hcl.DiagError},
+		{"Lenient", true, hcl.DiagWarning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"a": reflect.TypeOf(simpleDef{}),
+				}},
+				Validator:              ValidateFunc(func(interface{}, string) error { return nil }),
+				AllowUnknownAttributes: tt.allowUnknown,
+			}
+			_, diags := dec.DecodeBody(body, g)
+
+			if len(diags) != 1 {
+				t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+			}
+			diag := diags[0]
+			if diag.Summary != "Unsupported argument" {
+				t.Errorf("Summary = %q, want %q", diag.Summary, "Unsupported argument")
+			}
+			if diag.Severity != tt.wantSeverity {
+				t.Errorf("Severity = %v, want %v", diag.Severity, tt.wantSeverity)
+			}
+
+			if diags.HasErrors() {
+				return
+			}
+
+			// Known inputs are still decoded.
+			want := &resource.Graph{
+				Resources: []*resource.Desired{{
+					Type: "a",
+					Name: "foo",
+					Input: cty.ObjectVal(map[string]cty.Value{
+						"input": cty.StringVal("hello"),
+					}),
+				}},
+			}
+			opts := []cmp.Option{
+				cmp.Comparer(func(a, b cty.Value) bool { return a.RawEquals(b) }),
+			}
+			if diff := cmp.Diff(g, want, opts...); diff != "" {
+				t.Errorf("Graph does not match (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_allowUnknownAttributesOrder(t *testing.T) {
+	defer checkPanic(t)
+	g := &resource.Graph{}
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		resource "foo" {
+			type  = "a"
+			input = "hello"
+			zz    = 1
+			bb    = 2
+			mm    = 3
+			aa    = 4
+		}
+	`)
+
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"a": reflect.TypeOf(simpleDef{}),
+		}},
+		Validator:              ValidateFunc(func(interface{}, string) error { return nil }),
+		AllowUnknownAttributes: true,
+	}
+	_, diags := dec.DecodeBody(body, g)
+
+	var got []string
+	for _, diag := range diags {
+		got = append(got, diag.Detail)
+	}
+	want := []string{
+		`An argument named "aa" is not expected here. The value is ignored.`,
+		`An argument named "bb" is not expected here. The value is ignored.`,
+		`An argument named "mm" is not expected here. The value is ignored.`,
+		`An argument named "zz" is not expected here. The value is ignored.`,
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Diagnostics (-got +want)\n%s", diff)
+	}
+}
+
+func TestDecodeBody_forEachErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		wantSummary string
+	}{
+		{
+			name: "NotMap",
+			config: `
+				resource "foo" {
+					type     = "simple"
+					for_each = ["a", "b"]
+				}
+			`,
+			wantSummary: "Invalid for_each",
+		},
+		{
+			name: "NotStatic",
+			config: `
+				resource "foo" {
+					type = "simple"
+				}
+				resource "bar" {
+					type     = "simple"
+					for_each = foo.output
+				}
+			`,
+			wantSummary: "Invalid for_each",
+		},
+		{
+			name: "ReferenceWithoutKey",
+			config: `
+				resource "foo" {
+					type     = "simple"
+					for_each = { a = "a" }
+				}
+				resource "bar" {
+					type  = "simple"
+					input = foo.output
+				}
+			`,
+			wantSummary: "Invalid instance reference",
+		},
+		{
+			name: "KeyNotFound",
+			config: `
+				resource "foo" {
+					type     = "simple"
+					for_each = { a = "a" }
+				}
+				resource "bar" {
+					type  = "simple"
+					input = foo["b"].output
+				}
+			`,
+			wantSummary: "Referenced value not found",
+		},
+		{
+			name: "Duplicate",
+			config: `
+				resource "foo" {
+					type = "simple"
+				}
+				resource "foo" {
+					type     = "simple"
+					for_each = { a = "a" }
+				}
+			`,
+			wantSummary: "Duplicate resource",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"simple": reflect.TypeOf(simpleDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, g)
+			if !diags.HasErrors() {
+				t.Fatal("DecodeBody() want error")
+			}
+			if diags[0].Summary != tt.wantSummary {
+				t.Errorf("Summary = %q, want %q", diags[0].Summary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_multipleErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        string
+		wantSummaries []string
+	}{
+		{
+			name: "Resources",
+			config: `
+				resource "foo" {
+					type = foo.bar
+				}
+				resource "bar" {
+					type   = "simple"
+					source = baz
+				}
+			`,
+			wantSummaries: []string{"Variables not allowed", "Variables not allowed"},
+		},
+		{
+			name: "Attributes",
+			config: `
+				resource "foo" {
+					type   = foo.bar
+					source = baz
+				}
+			`,
+			wantSummaries: []string{"Variables not allowed", "Variables not allowed"},
+		},
+		{
+			name: "MissingType",
+			config: `
+				resource "foo" {
+					source = baz
+				}
+				resource "bar" {
+					input = "a"
+				}
+			`,
+			wantSummaries: []string{"Missing required argument", "Missing required argument", "Variables not allowed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"simple": reflect.TypeOf(simpleDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, g)
+			var got []string
+			for _, d := range diags {
+				got = append(got, d.Summary)
+			}
+			sort.Strings(got)
+			if diff := cmp.Diff(got, tt.wantSummaries); diff != "" {
+				t.Errorf("Diagnostics (-got +want)\n%s\n%s", diff, parser.DiagString(diags))
+			}
+		})
+	}
+}
+
+func TestDecodeBody_strictTypes(t *testing.T) {
+	tests := []struct {
+		name         string
+		strict       bool
+		config       string
+		wantSeverity hcl.DiagnosticSeverity // 0 for no diagnostics
+	}{
+		{
+			name:   "NumberToString",
+			strict: false,
+			config: `
+				resource "foo" {
+					type   = "a"
+					string = 123
+				}
+			`,
+			wantSeverity: hcl.DiagWarning,
+		},
+		{
+			name:   "StrictNumberToString",
+			strict: true,
+			config: `
+				resource "foo" {
+					type   = "a"
+					string = 123
+				}
+			`,
+			wantSeverity: hcl.DiagError,
+		},
+		{
+			name:   "StrictTupleToList",
+			strict: true,
+			config: `
+				resource "foo" {
+					type    = "a"
+					strings = ["a", "b"]
+				}
+			`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"a": reflect.TypeOf(struct {
+						String  *string  `func:"input"`
+						Strings []string `func:"input"`
+					}{}),
+				}},
+				Validator:   ValidateFunc(func(interface{}, string) error { return nil }),
+				StrictTypes: tt.strict,
+			}
+			_, diags := dec.DecodeBody(body, g)
+
+			if tt.wantSeverity == 0 {
+				if len(diags) > 0 {
+					t.Fatalf("DecodeBody() diagnostics:\n%s", parser.DiagString(diags))
+				}
+				return
+			}
+			if len(diags) != 1 {
+				t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+			}
+			diag := diags[0]
+			want := "Value is converted from number to string"
+			if diag.Summary != want {
+				t.Errorf("Summary = %q, want %q", diag.Summary, want)
+			}
+			if diag.Severity != tt.wantSeverity {
+				t.Errorf("Severity = %v, want %v", diag.Severity, tt.wantSeverity)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_moduleErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		wantSummary string
+	}{
+		{
+			name: "InvalidModuleReference",
+			config: `
+				module "a" {
+					resource "foo" {
+						type  = "simple"
+						input = "a"
+					}
+				}
+				resource "bar" {
+					type  = "simple"
+					input = module.a
+				}
+			`,
+			wantSummary: "Invalid module reference",
+		},
+		{
+			name: "NotVisibleOutsideModule",
+			config: `
+				module "a" {
+					resource "foo" {
+						type  = "simple"
+						input = "a"
+					}
+				}
+				resource "bar" {
+					type  = "simple"
+					input = foo.input
+				}
+			`,
+			wantSummary: "Referenced value not found",
+		},
+		{
+			name: "DuplicateInModule",
+			config: `
+				module "a" {
+					resource "foo" {
+						type = "simple"
+					}
+					resource "foo" {
+						type = "simple"
+					}
+				}
+			`,
+			wantSummary: "Duplicate resource",
+		},
+		{
+			name: "InvalidModuleName",
+			config: `
+				module "a.b" {
+				}
+			`,
+			wantSummary: "Invalid module name",
+		},
+		{
+			name: "UnsupportedBlock",
+			config: `
+				module "a" {
+					output "foo" {
+						value = "a"
+					}
+				}
+			`,
+			wantSummary: "Unsupported block type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"simple": reflect.TypeOf(simpleDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, g)
+
+			if len(diags) != 1 {
+				t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+			}
+			if diags[0].Summary != tt.wantSummary {
+				t.Errorf("Summary = %q, want %q", diags[0].Summary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_moduleErrorsOrder(t *testing.T) {
+	// Diagnostics are reported in the order of the resource names.
+	for i := 0; i < 10; i++ {
+		g := &resource.Graph{}
+		parser := &testParser{}
+		body := parser.Parse(t, `
+			resource "c" {
+				type  = "simple"
+				input = module.x
+			}
+			resource "a" {
+				type  = "simple"
+				input = module.y
+			}
+			resource "b" {
+				type  = "simple"
+				input = module.z
+			}
+		`)
+		dec := &hcldecoder.Decoder{
+			Resources: &resource.Registry{Types: map[string]reflect.Type{
+				"simple": reflect.TypeOf(simpleDef{}),
+			}},
+			Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+		}
+		_, diags := dec.DecodeBody(body, g)
+
+		var got []int
+		for _, d := range diags {
+			got = append(got, d.Subject.Start.Line)
+		}
+		want := []int{7, 11, 3}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Fatalf("Diagnostic lines (-got +want)\n%s", diff)
+		}
+	}
+}
+
+func TestDecodeBody_outputNull(t *testing.T) {
+	defer checkPanic(t)
+	g := &resource.Graph{}
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		output "foo" {
+			value = null
+		}
+	`)
+
+	dec := &hcldecoder.Decoder{
+		Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+	}
+	if _, diags := dec.DecodeBody(body, g); diags.HasErrors() {
+		t.Fatalf("DecodeBody() error = %s", parser.DiagString(diags))
+	}
+
+	want := []*resource.Output{{
+		Name: "foo",
+		Expression: resource.Expression{
+			resource.ExprLiteral{Value: cty.NullVal(cty.DynamicPseudoType)},
+		},
+	}}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool { return a.RawEquals(b) }),
+	}
+	if diff := cmp.Diff(g.Outputs, want, opts...); diff != "" {
+		t.Errorf("Outputs (-got +want)\n%s", diff)
+	}
+}
+
+func TestDecodeBody_outputErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		wantSummary string
+	}{
+		{
+			name: "ResourceNotFound",
+			config: `
+				output "foo" {
+					value = bar.output
+				}
+			`,
+			wantSummary: "Referenced value not found",
+		},
+		{
+			name: "FieldNotFound",
+			config: `
+				resource "bar" {
+					type = "simple"
+				}
+				output "foo" {
+					value = bar.nonexisting
+				}
+			`,
+			wantSummary: "No such field",
+		},
+		{
+			name: "Duplicate",
+			config: `
+				output "foo" {
+					value = "a"
+				}
+				output "foo" {
+					value = "b"
+				}
+			`,
+			wantSummary: "Duplicate output",
+		},
+		{
+			name: "NoValue",
+			config: `
+				output "foo" {
+				}
+			`,
+			wantSummary: "Missing required argument",
+		},
+		{
+			name: "SensitiveNotMarked",
+			config: `
+				resource "bar" {
+					type = "secret"
+				}
+				output "foo" {
+					value = "password: ${bar.password}"
+				}
+			`,
+			wantSummary: "Output refers to sensitive value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"simple": reflect.TypeOf(simpleDef{}),
+					"secret": reflect.TypeOf(secretDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, g)
+
+			if len(diags) != 1 {
+				t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+			}
+			if diags[0].Summary != tt.wantSummary {
+				t.Errorf("Summary = %q, want %q", diags[0].Summary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_sensitiveOutput(t *testing.T) {
+	defer checkPanic(t)
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		resource "secret" {
+			type = "secret"
+		}
+		resource "child" {
+			type  = "simple"
+			input = secret.password
+		}
+		output "password" {
+			value     = secret.password
+			sensitive = true
+		}
+		output "child" {
+			value = child.output
+		}
+	`)
+
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"simple": reflect.TypeOf(simpleDef{}),
+			"secret": reflect.TypeOf(secretDef{}),
+		}},
+		Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+	}
+	g := &resource.Graph{}
+	_, diags := dec.DecodeBody(body, g)
+	parser.CheckDiags(t, diags)
+
+	// The sensitive value can be used as the input of another resource.
+	wantDeps := []*resource.Dependency{{
+		Child: "child",
+		Field: cty.GetAttrPath("input"),
+		Expression: resource.Expression{
+			resource.ExprReference{Path: cty.GetAttrPath("secret").GetAttr("password")},
+		},
+	}}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Path) bool { return a.Equals(b) }),
+	}
+	if diff := cmp.Diff(g.Dependencies, wantDeps, opts...); diff != "" {
+		t.Errorf("Dependencies (-got +want)\n%s", diff)
+	}
+
+	wantOutputs := []*resource.Output{
+		{
+			Name: "password",
+			Expression: resource.Expression{
+				resource.ExprReference{Path: cty.GetAttrPath("secret").GetAttr("password")},
+			},
+			Sensitive: true,
+		},
+		{
+			Name: "child",
+			Expression: resource.Expression{
+				resource.ExprReference{Path: cty.GetAttrPath("child").GetAttr("output")},
+			},
+		},
+	}
+	if diff := cmp.Diff(g.Outputs, wantOutputs, opts...); diff != "" {
+		t.Errorf("Outputs (-got +want)\n%s", diff)
+	}
+}
+
+func TestDecodeBody_set(t *testing.T) {
+	type setDef struct {
+		resource.Definition
+		Values resource.StringSet `func:"input"`
+	}
+
+	tests := []struct {
+		name         string
+		config       string
+		want         cty.Value
+		wantWarnings int
+	}{
+		{
+			name: "Unique",
+			config: `
+				resource "foo" {
+					type   = "set"
+					values = ["a", "b"]
+				}
+			`,
+			want: cty.SetVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+		},
+		{
+			name: "Duplicates",
+			config: `
+				resource "foo" {
+					type   = "set"
+					values = ["a", "b", "a", "a"]
+				}
+			`,
+			want:         cty.SetVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			wantWarnings: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"set": reflect.TypeOf(setDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, g)
+			parser.CheckDiags(t, diags)
+
+			if len(diags) != tt.wantWarnings {
+				t.Fatalf("Got %d diagnostics, want %d:\n%s", len(diags), tt.wantWarnings, parser.DiagString(diags))
+			}
+			if tt.wantWarnings > 0 && diags[0].Summary != "Duplicate values removed" {
+				t.Errorf("Summary = %q, want %q", diags[0].Summary, "Duplicate values removed")
+			}
+
+			got := g.Resource("foo").Input.GetAttr("values")
+			if !got.RawEquals(tt.want) {
+				t.Errorf("Value = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_lifecycle(t *testing.T) {
+	defer checkPanic(t)
+	g := &resource.Graph{}
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		resource "foo" {
+			type  = "simple"
+			input = "bar"
+
+			lifecycle {
+				ignore_changes  = [input]
+				prevent_destroy = true
+			}
+		}
+	`)
+
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"simple": reflect.TypeOf(simpleDef{}),
+		}},
+		Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+	}
+	_, diags := dec.DecodeBody(body, g)
+	parser.CheckDiags(t, diags)
+
+	got := g.Resource("foo").IgnoreChanges
+	want := []cty.Path{cty.GetAttrPath("input")}
+	if len(got) != 1 || !got[0].Equals(want[0]) {
+		t.Errorf("IgnoreChanges = %#v, want %#v", got, want)
+	}
+	if !g.Resource("foo").PreventDestroy {
+		t.Errorf("PreventDestroy not set")
+	}
+}
+
+func TestDecodeBody_ignoreChangesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		ignore string
+	}{
+		{"NotList", `input`},
+		{"NotReference", `["input"]`},
+		{"NoSuchField", `[nonexisting]`},
+		{"Output", `[output]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, fmt.Sprintf(`
+				resource "foo" {
+					type = "simple"
+
+					lifecycle {
+						ignore_changes = %s
+					}
+				}
+			`, tt.ignore))
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"simple": reflect.TypeOf(simpleDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, g)
+
+			if len(diags) != 1 {
+				t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+			}
+			if diags[0].Summary != "Invalid ignore_changes" {
+				t.Errorf("Summary = %q, want %q", diags[0].Summary, "Invalid ignore_changes")
+			}
+		})
+	}
+}
+
+func TestDecodeBody_unset(t *testing.T) {
+	defer checkPanic(t)
+	g := &resource.Graph{}
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		resource "omitted" {
+			type = "simple"
+		}
+		resource "null" {
+			type  = "simple"
+			input = null
+		}
+		resource "set" {
+			type  = "simple"
+			input = "hello"
+		}
+	`)
+
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"simple": reflect.TypeOf(simpleDef{}),
+		}},
+		Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+	}
+	_, diags := dec.DecodeBody(body, g)
+	parser.CheckDiags(t, diags)
+
+	tests := []struct {
+		name string
+		want []cty.Path
+	}{
+		{"omitted", []cty.Path{cty.GetAttrPath("input")}},
+		{"null", nil},
+		{"set", nil},
+	}
+	for _, tt := range tests {
+		got := g.Resource(tt.name).Unset
+		if diff := cmp.Diff(got, tt.want, cmp.Comparer(func(a, b cty.Path) bool { return a.Equals(b) })); diff != "" {
+			t.Errorf("Unset %s (-got +want)\n%s", tt.name, diff)
+		}
+	}
+}
+
+func TestDecodeBody_null(t *testing.T) {
+	type nullDef struct {
+		resource.Definition
+		Required    string            `func:"input"`
+		Description *string           `func:"input" validate:"min=1"`
+		Count       *int              `func:"input"`
+		Tags        map[string]string `func:"input"`
+		Items       []string          `func:"input"`
+	}
+
+	defer checkPanic(t)
+	g := &resource.Graph{}
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		resource "foo" {
+			type        = "null"
+			required    = "x"
+			description = null
+			count       = null
+			tags        = null
+			items       = null
+		}
+	`)
+
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"null": reflect.TypeOf(nullDef{}),
+		}},
+		Validator: ValidateFunc(func(interface{}, string) error {
+			t.Error("Null value was validated")
+			return nil
+		}),
+	}
+	_, diags := dec.DecodeBody(body, g)
+	if len(diags) > 0 {
+		t.Fatalf("Got diagnostics, want none:\n%s", parser.DiagString(diags))
+	}
+
+	want := cty.ObjectVal(map[string]cty.Value{
+		"required":    cty.StringVal("x"),
+		"description": cty.NullVal(cty.String),
+		"count":       cty.NullVal(cty.Number),
+		"tags":        cty.NullVal(cty.Map(cty.String)),
+		"items":       cty.NullVal(cty.List(cty.String)),
+	})
+	got := g.Resource("foo").Input
+	if !got.RawEquals(want) {
+		t.Errorf("Input does not match\nGot:  %s\nWant: %s", got.GoString(), want.GoString())
+	}
+}
+
+func TestDecodeBody_numericRequired(t *testing.T) {
+	type timeoutDef struct {
+		resource.Definition
+		Timeout  int  `func:"input"`
+		Attempts *int `func:"input"`
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		want        cty.Value
+		wantUnset   []cty.Path
+		wantSummary string
+	}{
+		{
+			name: "Zero",
+			body: `timeout = 0
+			       attempts = 0`,
+			want: cty.ObjectVal(map[string]cty.Value{
+				"timeout":  cty.NumberIntVal(0),
+				"attempts": cty.NumberIntVal(0),
+			}),
+		},
+		{
+			name: "OptionalOmitted",
+			body: `timeout = 0`,
+			want: cty.ObjectVal(map[string]cty.Value{
+				"timeout":  cty.NumberIntVal(0),
+				"attempts": cty.NullVal(cty.Number),
+			}),
+			wantUnset: []cty.Path{cty.GetAttrPath("attempts")},
+		},
+		{
+			name:        "RequiredOmitted",
+			body:        `attempts = 3`,
+			wantSummary: "Missing required argument",
+		},
+		{
+			name:        "RequiredNull",
+			body:        `timeout = null`,
+			wantSummary: "Required argument is null",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, fmt.Sprintf(`
+				resource "foo" {
+					type = "timeout"
+					%s
+				}
+			`, tt.body))
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"timeout": reflect.TypeOf(timeoutDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, g)
+			if tt.wantSummary != "" {
+				if len(diags) != 1 {
+					t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+				}
+				if got := diags[0].Summary; got != tt.wantSummary {
+					t.Errorf("Summary = %q, want %q", got, tt.wantSummary)
+				}
+				return
+			}
+			parser.CheckDiags(t, diags)
+
+			res := g.Resource("foo")
+			if !res.Input.RawEquals(tt.want) {
+				t.Errorf("Input does not match\nGot:  %s\nWant: %s", res.Input.GoString(), tt.want.GoString())
+			}
+			pathEq := cmp.Comparer(func(a, b cty.Path) bool { return a.Equals(b) })
+			if diff := cmp.Diff(res.Unset, tt.wantUnset, pathEq); diff != "" {
+				t.Errorf("Unset (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_duration(t *testing.T) {
+	type durationDef struct {
+		resource.Definition
+		Timeout *int   `func:"input,duration"`
+		Delay   *int64 `func:"input,duration_ms"`
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		wantTimeout cty.Value
+		wantDelay   cty.Value
+		wantDetail  string
+	}{
+		{
+			name:        "Seconds",
+			body:        `timeout = "30s"`,
+			wantTimeout: cty.NumberIntVal(30),
+			wantDelay:   cty.NullVal(cty.Number),
+		},
+		{
+			name:        "Minutes",
+			body:        `timeout = "5m"`,
+			wantTimeout: cty.NumberIntVal(300),
+			wantDelay:   cty.NullVal(cty.Number),
+		},
+		{
+			name:        "Milliseconds",
+			body:        `delay = "1.5s"`,
+			wantTimeout: cty.NullVal(cty.Number),
+			wantDelay:   cty.NumberIntVal(1500),
+		},
+		{
+			name:        "Number",
+			body:        `timeout = 30`,
+			wantTimeout: cty.NumberIntVal(30),
+			wantDelay:   cty.NullVal(cty.Number),
+		},
+		{
+			name:        "NumberString",
+			body:        `timeout = "30"`,
+			wantTimeout: cty.NumberIntVal(30),
+			wantDelay:   cty.NullVal(cty.Number),
+		},
+		{
+			name:       "Invalid",
+			body:       `timeout = "thirty"`,
+			wantDetail: `The value "thirty" is not a valid duration.`,
+		},
+		{
+			name:       "Fraction",
+			body:       `timeout = "1500ms"`,
+			wantDetail: "The duration must be a whole number of seconds, got 1.5s.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, fmt.Sprintf(`
+				resource "foo" {
+					type = "duration"
+					%s
+				}
+			`, tt.body))
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"duration": reflect.TypeOf(durationDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, g)
+			if tt.wantDetail != "" {
+				if len(diags) != 1 {
+					t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+				}
+				if got := diags[0].Summary; got != "Invalid duration" {
+					t.Errorf("Summary = %q, want %q", got, "Invalid duration")
+				}
+				if got := diags[0].Detail; !strings.HasPrefix(got, tt.wantDetail) {
+					t.Errorf("Detail = %q, want prefix %q", got, tt.wantDetail)
+				}
+				return
+			}
+			parser.CheckDiags(t, diags)
+
+			want := cty.ObjectVal(map[string]cty.Value{
+				"timeout": tt.wantTimeout,
+				"delay":   tt.wantDelay,
+			})
+			got := g.Resource("foo").Input
+			if !got.RawEquals(want) {
+				t.Errorf("Input does not match\nGot:  %s\nWant: %s", got.GoString(), want.GoString())
+			}
+		})
+	}
+}
+
+func TestDecodeBody_referenceTypes(t *testing.T) {
+	type parentDef struct {
+		resource.Definition
+		Name  string   `func:"output"`
+		Count int      `func:"output"`
+		Items []string `func:"output"`
+	}
+	type childDef struct {
+		resource.Definition
+		Number *int               `func:"input"`
+		Text   *string            `func:"input"`
+		Set    resource.StringSet `func:"input"`
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		strict      bool
+		wantSummary string
+		wantSev     hcl.DiagnosticSeverity
+	}{
+		{name: "Match", input: `number = parent.count`},
+		{name: "Template", input: `text = "x-${parent.count}"`},
+		{name: "Collection", input: `set = parent.items`},
+		{
+			name:        "Incompatible",
+			input:       `number = parent.items`,
+			wantSummary: "Unsuitable value type",
+			wantSev:     hcl.DiagError,
+		},
+		{
+			name:        "ListToString",
+			input:       `text = parent.items`,
+			wantSummary: "Unsuitable value type",
+			wantSev:     hcl.DiagError,
+		},
+		{
+			name:        "Convertible",
+			input:       `text = parent.count`,
+			wantSummary: "Value is converted from number to string",
+			wantSev:     hcl.DiagWarning,
+		},
+		{
+			name:        "ConvertibleStrict",
+			input:       `text = parent.count`,
+			strict:      true,
+			wantSummary: "Value is converted from number to string",
+			wantSev:     hcl.DiagError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, fmt.Sprintf(`
+				resource "parent" {
+					type = "parent"
+				}
+				resource "child" {
+					type = "child"
+					%s
+				}
+			`, tt.input))
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"parent": reflect.TypeOf(parentDef{}),
+					"child":  reflect.TypeOf(childDef{}),
+				}},
+				Validator:   ValidateFunc(func(interface{}, string) error { return nil }),
+				StrictTypes: tt.strict,
+			}
+			_, diags := dec.DecodeBody(body, g)
+			if tt.wantSummary == "" {
+				parser.CheckDiags(t, diags)
+				if deps := g.DependenciesOf("child"); len(deps) != 1 {
+					t.Errorf("Got %d dependencies, want 1", len(deps))
+				}
+				return
+			}
+			if len(diags) != 1 {
+				t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+			}
+			if got := diags[0].Summary; got != tt.wantSummary {
+				t.Errorf("Summary = %q, want %q", got, tt.wantSummary)
+			}
+			if got := diags[0].Severity; got != tt.wantSev {
+				t.Errorf("Severity = %v, want %v", got, tt.wantSev)
+			}
+			if diags[0].Subject == nil {
+				t.Errorf("Subject not set")
+			}
+		})
+	}
+}
+
+func TestDecodeBody_quietConversions(t *testing.T) {
+	type convDef struct {
+		resource.Definition
+		Text   string             `func:"input"`
+		Values resource.StringSet `func:"input"`
+		Count  int                `func:"output"`
+	}
+
+	src := `
+		resource "foo" {
+			type   = "conv"
+			text   = 123
+			values = ["a", "a"]
+		}
+		resource "bar" {
+			type   = "conv"
+			text   = foo.count
+			values = []
+		}
+	`
+
+	tests := []struct {
+		name  string
+		quiet bool
+		want  []string
+	}{
+		{
+			name: "Default",
+			want: []string{
+				"Duplicate values removed",
+				"Value is converted from number to string",
+				"Value is converted from number to string",
+			},
+		},
+		{
+			name:  "Quiet",
+			quiet: true,
+			want:  []string{"Duplicate values removed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+
+			parser := &testParser{}
+			body := parser.Parse(t, src)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"conv": reflect.TypeOf(convDef{}),
+				}},
+				Validator:        ValidateFunc(func(interface{}, string) error { return nil }),
+				QuietConversions: tt.quiet,
+			}
+			_, diags := dec.DecodeBody(body, &resource.Graph{})
+			if diags.HasErrors() {
+				t.Fatalf("DecodeBody() errors:\n%s", parser.DiagString(diags))
+			}
+			got := make([]string, len(diags))
+			for i, d := range diags {
+				got[i] = d.Summary
+			}
+			sort.Strings(got)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("Warnings (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_nullRequired(t *testing.T) {
+	defer checkPanic(t)
+	g := &resource.Graph{}
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		resource "foo" {
+			type = "required"
+			name = null
+		}
+	`)
+
+	type requiredDef struct {
+		resource.Definition
+		Name string `func:"input"`
+	}
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"required": reflect.TypeOf(requiredDef{}),
+		}},
+		Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+	}
+	_, diags := dec.DecodeBody(body, g)
+	if len(diags) != 1 {
+		t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+	}
+	if got, want := diags[0].Summary, "Required argument is null"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+}
+
+type remoteStateFunc func(project string) (map[string]cty.Value, error)
+
+func (f remoteStateFunc) RemoteOutputs(project string) (map[string]cty.Value, error) {
+	return f(project)
+}
+
+func TestDecodeBody_remoteState(t *testing.T) {
+	type subnetDef struct {
+		resource.Definition
+		VPC  string `func:"input"`
+		Name string `func:"input"`
+	}
+
+	defer checkPanic(t)
+	g := &resource.Graph{}
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		variable "env" {
+			default = "prod"
+		}
+		data "remote_state" "network" {
+			project = "network-${var.env}"
+		}
+		resource "foo" {
+			type = "subnet"
+			vpc  = data.remote_state.network.vpc_id
+			name = "${data.remote_state["network"].prefix}-foo"
+		}
+		output "vpc" {
+			value = data.remote_state.network.vpc_id
+		}
+	`)
+
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"subnet": reflect.TypeOf(subnetDef{}),
+		}},
+		Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+		RemoteState: remoteStateFunc(func(project string) (map[string]cty.Value, error) {
+			if project != "network-prod" {
+				t.Errorf("Read remote state for project %q", project)
+				return nil, nil
+			}
+			return map[string]cty.Value{
+				"vpc_id": cty.StringVal("vpc-123"),
+				"prefix": cty.StringVal("net"),
+			}, nil
+		}),
+	}
+	_, diags := dec.DecodeBody(body, g)
+	if len(diags) > 0 {
+		t.Fatalf("Got diagnostics, want none:\n%s", parser.DiagString(diags))
+	}
+
+	want := cty.ObjectVal(map[string]cty.Value{
+		"vpc":  cty.StringVal("vpc-123"),
+		"name": cty.StringVal("net-foo"),
+	})
+	got := g.Resource("foo").Input
+	if !got.RawEquals(want) {
+		t.Errorf("Input does not match\nGot:  %s\nWant: %s", got.GoString(), want.GoString())
+	}
+	if len(g.Dependencies) > 0 {
+		t.Errorf("Remote state created dependencies: %v", g.Dependencies)
+	}
+
+	wantOutputs := []*resource.Output{{
+		Name:       "vpc",
+		Expression: resource.Expression{resource.ExprLiteral{Value: cty.StringVal("vpc-123")}},
+	}}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool { return a.RawEquals(b) }),
+	}
+	if diff := cmp.Diff(g.Outputs, wantOutputs, opts...); diff != "" {
+		t.Errorf("Outputs (-got +want)\n%s", diff)
+	}
+}
+
+func TestDecodeBody_remoteStateNoReader(t *testing.T) {
+	type subnetDef struct {
+		resource.Definition
+		VPC  string `func:"input"`
+		Name string `func:"input" validate:"min=5"`
+	}
+
+	defer checkPanic(t)
+	g := &resource.Graph{}
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		data "remote_state" "network" {
+			project = "network"
+		}
+		resource "foo" {
+			type = "subnet"
+			vpc  = data.remote_state.network.vpc_id
+			name = "${data.remote_state.network.prefix}-foo"
+		}
+	`)
+
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"subnet": reflect.TypeOf(subnetDef{}),
+		}},
+		Validator: ValidateFunc(func(interface{}, string) error {
+			t.Error("Unknown value was validated")
+			return nil
+		}),
+	}
+	_, diags := dec.DecodeBody(body, g)
+	if len(diags) > 0 {
+		t.Fatalf("Got diagnostics, want none:\n%s", parser.DiagString(diags))
+	}
+
+	want := cty.ObjectVal(map[string]cty.Value{
+		"vpc":  cty.UnknownVal(cty.String),
+		"name": cty.UnknownVal(cty.String),
+	})
+	got := g.Resource("foo").Input
+	if !got.RawEquals(want) {
+		t.Errorf("Input does not match\nGot:  %s\nWant: %s", got.GoString(), want.GoString())
+	}
+}
+
+func TestDecodeBody_remoteStateErrors(t *testing.T) {
+	network := remoteStateFunc(func(project string) (map[string]cty.Value, error) {
+		switch project {
+		case "network":
+			return map[string]cty.Value{
+				"vpc_id":  cty.StringVal("vpc-123"),
+				"subnets": cty.TupleVal([]cty.Value{cty.StringVal("subnet-a"), cty.NumberIntVal(1)}),
+			}, nil
+		case "unavailable":
+			return nil, fmt.Errorf("boom")
+		default:
+			return nil, nil
+		}
+	})
+
+	tests := []struct {
+		name   string
+		config string
+		reader hcldecoder.RemoteStateReader
+		want   string // Summary of only diagnostic
+		detail string // Detail of only diagnostic, if set
+	}{
+		{
+			name: "UnsupportedType",
+			config: `
+				data "bucket" "network" {}
+			`,
+			reader: network,
+			want:   "Unsupported data type",
+		},
+		{
+			name: "Duplicate",
+			config: `
+				data "remote_state" "network" {
+					project = "network"
+				}
+				data "remote_state" "network" {
+					project = "network"
+				}
+			`,
+			reader: network,
+			want:   "Duplicate remote state",
+		},
+		{
+			name: "NotApplied",
+			config: `
+				data "remote_state" "network" {
+					project = "other"
+				}
+				resource "foo" {
+					type  = "simple"
+					input = data.remote_state.network.vpc_id
+				}
+			`,
+			reader: network,
+			want:   "Project has not been applied",
+		},
+		{
+			name: "ReadError",
+			config: `
+				data "remote_state" "network" {
+					project = "unavailable"
+				}
+			`,
+			reader: network,
+			want:   "Cannot read remote state",
+		},
+		{
+			name: "Undeclared",
+			config: `
+				resource "foo" {
+					type  = "simple"
+					input = data.remote_state.network.vpc_id
+				}
+			`,
+			reader: network,
+			want:   "Undeclared remote state",
+		},
+		{
+			name: "InvalidReference",
+			config: `
+				resource "foo" {
+					type  = "simple"
+					input = data.network.vpc_id
+				}
+			`,
+			reader: network,
+			want:   "Invalid data reference",
+		},
+		{
+			name: "UnknownOutput",
+			config: `
+				data "remote_state" "network" {
+					project = "network"
+				}
+				resource "foo" {
+					type  = "simple"
+					input = "${data.remote_state.network.subnet_id}-foo"
+				}
+			`,
+			reader: network,
+			want:   "Unsupported attribute",
+		},
+		{
+			name: "TupleIndexOutOfRange",
+			config: `
+				data "remote_state" "network" {
+					project = "network"
+				}
+				resource "foo" {
+					type  = "simple"
+					input = data.remote_state.network.subnets[2]
+				}
+			`,
+			reader: network,
+			want:   "Invalid index",
+			detail: "Remote state network: index 2 out of range for tuple with 2 elements in subnets.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"simple": reflect.TypeOf(struct {
+						resource.Definition
+						Input string `func:"input"`
+					}{}),
+				}},
+				Validator:   ValidateFunc(func(interface{}, string) error { return nil }),
+				RemoteState: tt.reader,
+			}
+			_, diags := dec.DecodeBody(body, &resource.Graph{})
+			if len(diags) != 1 {
+				t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+			}
+			if got := diags[0].Summary; got != tt.want {
+				t.Errorf("Summary = %q, want %q", got, tt.want)
+			}
+			if tt.detail != "" && diags[0].Detail != tt.detail {
+				t.Errorf("Detail = %q, want %q", diags[0].Detail, tt.detail)
+			}
+		})
+	}
+}
+
+type rangeDef struct {
+	resource.Definition
+	Min int `func:"input"`
+	Max int `func:"input"`
+}
+
+func (r *rangeDef) Validate() error {
+	if r.Min > r.Max {
+		return fmt.Errorf("min must not be greater than max")
+	}
+	return nil
+}
+
+type numberDef struct {
+	resource.Definition
+	Value int `func:"output"`
+}
+
+func TestDecodeBody_validateDefinition(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{
+			name: "Valid",
+			config: `
+				resource "foo" {
+					type = "range"
+					min  = 1
+					max  = 2
+				}
+			`,
+		},
+		{
+			name: "Invalid",
+			config: `
+				resource "foo" {
+					type = "range"
+					min  = 2
+					max  = 1
+				}
+			`,
+			wantErr: true,
+		},
+		{
+			name: "InvalidResolved",
+			config: `
+				resource "foo" {
+					type = "range"
+					min  = bar.max
+					max  = 1
+				}
+				resource "bar" {
+					type = "range"
+					min  = 1
+					max  = 2
+				}
+			`,
+			wantErr: true,
+		},
+		{
+			name: "Dynamic",
+			config: `
+				resource "foo" {
+					type = "range"
+					min  = num.value
+					max  = 1
+				}
+				resource "num" {
+					type = "number"
+				}
+			`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"range":  reflect.TypeOf(&rangeDef{}),
+					"number": reflect.TypeOf(&numberDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, &resource.Graph{})
+			if !tt.wantErr {
+				if len(diags) > 0 {
+					t.Fatalf("Got diagnostics, want none:\n%s", parser.DiagString(diags))
+				}
+				return
+			}
+			if len(diags) != 1 {
+				t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+			}
+			if got, want := diags[0].Summary, "Invalid resource"; got != want {
+				t.Errorf("Summary = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_moved(t *testing.T) {
+	defer checkPanic(t)
+	g := &resource.Graph{}
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		resource "b" {
+			type = "simple"
+		}
+		module "mod" {
+			resource "c" {
+				type = "simple"
+			}
+		}
+		moved {
+			from = a
+			to   = b
+		}
+		moved {
+			from = module.old.c
+			to   = module.mod.c
+		}
+	`)
+
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"simple": reflect.TypeOf(simpleDef{}),
+		}},
+		Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+	}
+	_, diags := dec.DecodeBody(body, g)
+	parser.CheckDiags(t, diags)
+
+	want := []*resource.Move{
+		{From: "a", To: "b"},
+		{From: "old.c", To: "mod.c"},
+	}
+	if diff := cmp.Diff(g.Moves, want); diff != "" {
+		t.Errorf("Moves (-got +want)\n%s", diff)
+	}
+}
+
+func TestDecodeBody_movedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string // Summary of only diagnostic
+	}{
+		{
+			name: "FromDeclared",
+			config: `
+				resource "a" {
+					type = "simple"
+				}
+				resource "b" {
+					type = "simple"
+				}
+				moved {
+					from = a
+					to   = b
+				}
+			`,
+			want: "Invalid moved",
+		},
+		{
+			name: "ToNotDeclared",
+			config: `
+				moved {
+					from = a
+					to   = b
+				}
+			`,
+			want: "Invalid moved",
+		},
+		{
+			name: "Field",
+			config: `
+				resource "b" {
+					type = "simple"
+				}
+				moved {
+					from = a.name
+					to   = b
+				}
+			`,
+			want: "Invalid moved",
+		},
+		{
+			name: "Duplicate",
+			config: `
+				resource "b" {
+					type = "simple"
+				}
+				moved {
+					from = a
+					to   = b
+				}
+				moved {
+					from = c
+					to   = b
+				}
+			`,
+			want: "Duplicate moved",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"simple": reflect.TypeOf(simpleDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, &resource.Graph{})
+			if len(diags) != 1 {
+				t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+			}
+			if got := diags[0].Summary; got != tt.want {
+				t.Errorf("Summary = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_dependsOn(t *testing.T) {
+	defer checkPanic(t)
+	g := &resource.Graph{}
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		resource "a" {
+			type = "simple"
+		}
+		module "mod" {
+			resource "b" {
+				type = "simple"
+			}
+			resource "c" {
+				type       = "simple"
+				depends_on = [b]
+			}
+		}
+		resource "d" {
+			type       = "simple"
+			depends_on = [a, module.mod.c]
+		}
+	`)
+
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"simple": reflect.TypeOf(simpleDef{}),
+		}},
+		Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+	}
+	_, diags := dec.DecodeBody(body, g)
+	parser.CheckDiags(t, diags)
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"a", nil},
+		{"mod.b", nil},
+		{"mod.c", []string{"mod.b"}},
+		{"d", []string{"a", "mod.c"}},
+	}
+	for _, tt := range tests {
+		got := g.Resource(tt.name).DependsOn
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Errorf("DependsOn %s (-got +want)\n%s", tt.name, diff)
+		}
+	}
+	if len(g.Dependencies) > 0 {
+		t.Errorf("Explicit dependencies must not add value dependencies, got %d", len(g.Dependencies))
+	}
+}
+
+func TestDecodeBody_dependsOnErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		dependsOn   string
+		wantSummary string
+	}{
+		{"NotList", `bar`, "Invalid depends_on"},
+		{"NotReference", `["bar"]`, "Invalid depends_on"},
+		{"Field", `[bar.input]`, "Invalid depends_on"},
+		{"Self", `[foo]`, "Invalid depends_on"},
+		{"NotFound", `[baz]`, "Referenced value not found"},
+		{"InvalidModuleReference", `[module.bar]`, "Invalid module reference"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, fmt.Sprintf(`
+				resource "foo" {
+					type       = "simple"
+					depends_on = %s
+				}
+				resource "bar" {
+					type = "simple"
+				}
+			`, tt.dependsOn))
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"simple": reflect.TypeOf(simpleDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, g)
+
+			if len(diags) != 1 {
+				t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+			}
+			if diags[0].Summary != tt.wantSummary {
+				t.Errorf("Summary = %q, want %q", diags[0].Summary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_dependsOnErrors_order(t *testing.T) {
+	defer checkPanic(t)
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		resource "c" {
+			type       = "simple"
+			depends_on = [z]
+		}
+		resource "a" {
+			type       = "simple"
+			depends_on = [x]
+		}
+		resource "b" {
+			type       = "simple"
+			depends_on = [y]
+		}
+	`)
+
+	// Run multiple times as map iteration order is random.
+	for i := 0; i < 10; i++ {
+		dec := &hcldecoder.Decoder{
+			Resources: &resource.Registry{Types: map[string]reflect.Type{
+				"simple": reflect.TypeOf(simpleDef{}),
+			}},
+			Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+		}
+		_, diags := dec.DecodeBody(body, &resource.Graph{})
+
+		var got []int
+		for _, d := range diags {
+			got = append(got, d.Subject.Start.Line)
+		}
+		want := []int{7, 11, 3} // a, b, c
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Fatalf("Diagnostics (-got +want)\n%s", diff)
+		}
+	}
+}
+
+func TestDecodeBody_env(t *testing.T) {
+	// Set in the process environment, must not be read.
+	os.Setenv("FUNC_DECODER_TEST_PROCESS", "from-process")
+	defer os.Unsetenv("FUNC_DECODER_TEST_PROCESS")
+
+	tests := []struct {
+		name        string
+		expr        string
+		want        cty.Value
+		wantSummary string
+	}{
+		{
+			name: "Set",
+			expr: `env("FUNC_DECODER_TEST_SET")`,
+			want: cty.StringVal("from-env"),
+		},
+		{
+			name: "SetWithDefault",
+			expr: `env("FUNC_DECODER_TEST_SET", "default")`,
+			want: cty.StringVal("from-env"),
+		},
+		{
+			name: "Default",
+			expr: `env("FUNC_DECODER_TEST_UNSET", "default")`,
+			want: cty.StringVal("default"),
+		},
+		{
+			name: "Template",
+			expr: `"prefix-${env("FUNC_DECODER_TEST_SET")}"`,
+			want: cty.StringVal("prefix-from-env"),
+		},
+		{
+			name:        "Unset",
+			expr:        `env("FUNC_DECODER_TEST_UNSET")`,
+			wantSummary: "Error in function call",
+		},
+		{
+			name:        "Process",
+			expr:        `env("FUNC_DECODER_TEST_PROCESS")`,
+			wantSummary: "Error in function call",
+		},
+		{
+			name:        "TooManyArgs",
+			expr:        `env("FUNC_DECODER_TEST_SET", "a", "b")`,
+			wantSummary: "Invalid function argument",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, fmt.Sprintf(`
+				resource "foo" {
+					type  = "simple"
+					input = %s
+				}
+			`, tt.expr))
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"simple": reflect.TypeOf(simpleDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+				Env:       map[string]string{"FUNC_DECODER_TEST_SET": "from-env"},
+			}
+			_, diags := dec.DecodeBody(body, g)
+
+			if tt.wantSummary != "" {
+				if len(diags) != 1 {
+					t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+				}
+				if diags[0].Summary != tt.wantSummary {
+					t.Errorf("Summary = %q, want %q", diags[0].Summary, tt.wantSummary)
+				}
+				return
+			}
+			parser.CheckDiags(t, diags)
+
+			res := g.Resource("foo")
+			got := res.Input.GetAttr("input")
+			if !got.RawEquals(tt.want) {
+				t.Errorf("Value = %#v, want %#v", got, tt.want)
+			}
+			if deps := g.DependenciesOf("foo"); len(deps) > 0 {
+				t.Errorf("Got %d dependencies, want none", len(deps))
+			}
+		})
+	}
+}
+
+func TestEnvNames(t *testing.T) {
+	src := unindent(`
+		variable "name" {
+			default = env("DEFAULT_NAME", "foo")
+		}
+		resource "foo" {
+			type  = "simple"
+			input = "${env("PREFIX")}-${var.name}"
+			nested {
+				value = env("PREFIX")
+			}
+		}
+		resource "bar" {
+			type  = "simple"
+			input = env(var.name)
+		}
+	`)
+	body, diags := hclpack.PackNativeFile([]byte(src), "test.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("PackNativeFile() diags = %v", diags)
+	}
+
+	got := hcldecoder.EnvNames(body)
+	want := []string{"DEFAULT_NAME", "PREFIX"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("EnvNames() (-got +want)\n%s", diff)
+	}
+}
+
+func TestDecodeBody_collectionFunctions(t *testing.T) {
+	vars := map[string]cty.Value{
+		"names": cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b"), cty.StringVal("c")}),
+		"amis": cty.MapVal(map[string]cty.Value{
+			"eu-west-1": cty.StringVal("ami-eu"),
+			"us-east-1": cty.StringVal("ami-us"),
+		}),
+	}
+
+	tests := []struct {
+		name        string
+		expr        string
+		want        cty.Value
+		wantSummary string
+	}{
+		{
+			name: "Length",
+			expr: `length(var.names)`,
+			want: cty.StringVal("3"),
+		},
+		{
+			name: "LengthMap",
+			expr: `length(var.amis)`,
+			want: cty.StringVal("2"),
+		},
+		{
+			name: "LengthTemplate",
+			expr: `"count-${length(var.names)}"`,
+			want: cty.StringVal("count-3"),
+		},
+		{
+			name: "LengthWithReference",
+			expr: `"${length(var.names)}-${bar.output}"`,
+			want: cty.UnknownVal(cty.String),
+		},
+		{
+			name: "Keys",
+			expr: `element(keys(var.amis), 1)`,
+			want: cty.StringVal("us-east-1"),
+		},
+		{
+			name: "Values",
+			expr: `element(values(var.amis), 0)`,
+			want: cty.StringVal("ami-eu"),
+		},
+		{
+			name: "ElementWrap",
+			expr: `element(var.names, 4)`,
+			want: cty.StringVal("b"),
+		},
+		{
+			name: "Lookup",
+			expr: `lookup(var.amis, "us-east-1")`,
+			want: cty.StringVal("ami-us"),
+		},
+		{
+			name: "LookupDefault",
+			expr: `lookup(var.amis, "ap-south-1", "ami-default")`,
+			want: cty.StringVal("ami-default"),
+		},
+		{
+			name:        "LookupMissing",
+			expr:        `lookup(var.amis, "ap-south-1")`,
+			wantSummary: "Invalid function argument",
+		},
+		{
+			name:        "LengthNotCollection",
+			expr:        `length(true)`,
+			wantSummary: "Error in function call",
+		},
+		{
+			name:        "ElementEmpty",
+			expr:        `element([], 0)`,
+			wantSummary: "Invalid function argument",
+		},
+		{
+			name:        "LengthOfOutput",
+			expr:        `length(bar.output)`,
+			wantSummary: "Function argument not known",
+		},
+		{
+			name:        "LookupOutput",
+			expr:        `"ami-${lookup(var.amis, bar.output)}"`,
+			wantSummary: "Function argument not known",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, fmt.Sprintf(`
+				variable "names" {}
+				variable "amis" {}
+				resource "bar" {
+					type = "simple"
+				}
+				resource "foo" {
+					type  = "simple"
+					input = %s
+				}
+			`, tt.expr))
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"simple": reflect.TypeOf(simpleDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+				Variables: vars,
+			}
+			_, diags := dec.DecodeBody(body, g)
+
+			if tt.wantSummary != "" {
+				if len(diags) != 1 {
+					t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+				}
+				if diags[0].Summary != tt.wantSummary {
+					t.Errorf("Summary = %q, want %q", diags[0].Summary, tt.wantSummary)
+				}
+				return
+			}
+			parser.CheckDiags(t, diags)
+
+			if !tt.want.IsKnown() {
+				// Resolved when applying.
+				if deps := g.DependenciesOf("foo"); len(deps) != 1 {
+					t.Errorf("Got %d dependencies, want 1", len(deps))
+				}
+				return
+			}
+			got := g.Resource("foo").Input.GetAttr("input")
+			if !got.RawEquals(tt.want) {
+				t.Errorf("Value = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_providerDefaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    string
+		region      string
+		defaults    map[string]map[string]cty.Value
+		want        cty.Value
+		wantSummary string
+	}{
+		{
+			name:     "Explicit",
+			provider: `provider "aws" { region = "eu-west-1" }`,
+			region:   `region = "ap-south-1"`,
+			defaults: map[string]map[string]cty.Value{"aws": {"region": cty.StringVal("us-east-2")}},
+			want:     cty.StringVal("ap-south-1"),
+		},
+		{
+			name:     "Provider",
+			provider: `provider "aws" { region = "eu-west-1" }`,
+			defaults: map[string]map[string]cty.Value{"aws": {"region": cty.StringVal("us-east-2")}},
+			want:     cty.StringVal("eu-west-1"),
+		},
+		{
+			name:     "ProviderVariable",
+			provider: `provider "aws" { region = var.region }`,
+			want:     cty.StringVal("eu-north-1"),
+		},
+		{
+			name:     "Env",
+			defaults: map[string]map[string]cty.Value{"aws": {"region": cty.StringVal("us-east-2")}},
+			want:     cty.StringVal("us-east-2"),
+		},
+		{
+			name:        "OtherProvider",
+			provider:    `provider "gcp" { region = "europe-west1" }`,
+			defaults:    map[string]map[string]cty.Value{"gcp": {"region": cty.StringVal("us-central1")}},
+			wantSummary: "Missing required argument",
+		},
+		{
+			name:        "None",
+			wantSummary: "Missing required argument",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, fmt.Sprintf(`
+				variable "region" {
+					default = "eu-north-1"
+				}
+				%s
+				resource "foo" {
+					type = "aws_regional"
+					%s
+				}
+			`, tt.provider, tt.region))
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"aws_regional": reflect.TypeOf(struct {
+						Region string `func:"input"`
+					}{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+				Defaults:  tt.defaults,
+			}
+			_, diags := dec.DecodeBody(body, g)
+
+			if tt.wantSummary != "" {
+				if len(diags) != 1 {
+					t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+				}
+				if diags[0].Summary != tt.wantSummary {
+					t.Errorf("Summary = %q, want %q", diags[0].Summary, tt.wantSummary)
+				}
+				return
+			}
+			parser.CheckDiags(t, diags)
+
+			got := g.Resource("foo").Input.GetAttr("region")
+			if !got.RawEquals(tt.want) {
+				t.Errorf("Region = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_providerLoader(t *testing.T) {
+	// Loaded files are packed with hclpack.
+	fsys := fstest.MapFS{
+		"func.hcl": {Data: []byte(`
+provider "aws" {
+  region  = "eu-west-1"
+  name    = "x"
+  timeout = 30
+}
+
+resource "foo" {
+  type = "aws_regional"
+}
+`)},
+	}
+	loader := &config.Loader{FS: fsys}
+	body, diags := loader.Load(".")
+	if diags.HasErrors() {
+		t.Fatalf("Load() error = %v", diags)
+	}
+
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"aws_regional": reflect.TypeOf(struct {
+				Region  string `func:"input"`
+				Name    string `func:"input"`
+				Timeout int    `func:"input"`
+			}{}),
+		}},
+		Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+	}
+	g := &resource.Graph{}
+	if _, diags := dec.DecodeBody(body, g); diags.HasErrors() {
+		t.Fatalf("DecodeBody() error = %v", diags)
+	}
+
+	got := g.Resource("foo").Input
+	want := cty.ObjectVal(map[string]cty.Value{
+		"region":  cty.StringVal("eu-west-1"),
+		"name":    cty.StringVal("x"),
+		"timeout": cty.NumberIntVal(30),
+	})
+	if !got.Equals(want).True() {
+		t.Errorf("Input = %#v, want %#v", got, want)
+	}
+}
+
+func TestDecodeBody_providerAlias(t *testing.T) {
+	tests := []struct {
+		name        string
+		selector    string
+		wantRegion  cty.Value
+		wantProfile string
+		wantSummary string
+	}{
+		{
+			name:        "Default",
+			wantRegion:  cty.StringVal("eu-west-1"),
+			wantProfile: "dev",
+		},
+		{
+			name:        "Alias",
+			selector:    `provider = aws.prod`,
+			wantRegion:  cty.StringVal("us-east-1"),
+			wantProfile: "production",
+		},
+		{
+			name:        "NotFound",
+			selector:    `provider = aws.staging`,
+			wantSummary: "Provider not found",
+		},
+		{
+			name:        "OtherProvider",
+			selector:    `provider = gcp.prod`,
+			wantSummary: "Invalid provider",
+		},
+		{
+			name:        "NotReference",
+			selector:    `provider = "aws.prod"`,
+			wantSummary: "Invalid provider",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, fmt.Sprintf(`
+				provider "aws" {
+					profile = "dev"
+					region  = "eu-west-1"
+				}
+				provider "aws" {
+					alias   = "prod"
+					profile = "production"
+					region  = "us-east-1"
+				}
+				provider "gcp" {
+					alias = "prod"
+				}
+				resource "foo" {
+					type = "aws_regional"
+					%s
+				}
+			`, tt.selector))
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"aws_regional": reflect.TypeOf(struct {
+						Region string `func:"input"`
+					}{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, g)
+
+			if tt.wantSummary != "" {
+				if len(diags) != 1 {
+					t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+				}
+				if diags[0].Summary != tt.wantSummary {
+					t.Errorf("Summary = %q, want %q", diags[0].Summary, tt.wantSummary)
+				}
+				return
+			}
+			parser.CheckDiags(t, diags)
+
+			res := g.Resource("foo")
+			if got := res.Input.GetAttr("region"); !got.RawEquals(tt.wantRegion) {
+				t.Errorf("Region = %#v, want %#v", got, tt.wantRegion)
+			}
+			if res.Profile != tt.wantProfile {
+				t.Errorf("Profile = %q, want %q", res.Profile, tt.wantProfile)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_variables(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		vars        map[string]cty.Value
+		want        cty.Value
+		wantSummary string
+	}{
+		{
+			name: "Default",
+			config: `
+				variable "name" {
+					default = "default"
+				}
+				resource "foo" {
+					type  = "simple"
+					input = var.name
+				}
+			`,
+			want: cty.StringVal("default"),
+		},
+		{
+			name: "Overridden",
+			config: `
+				variable "name" {
+					default = "default"
+				}
+				resource "foo" {
+					type  = "simple"
+					input = var.name
+				}
+			`,
+			vars: map[string]cty.Value{"name": cty.StringVal("override")},
+			want: cty.StringVal("override"),
+		},
+		{
+			name: "NoDefault",
+			config: `
+				variable "name" {}
+				resource "foo" {
+					type  = "simple"
+					input = "hello-${var.name}"
+				}
+			`,
+			vars: map[string]cty.Value{"name": cty.StringVal("world")},
+			want: cty.StringVal("hello-world"),
+		},
+		{
+			name: "Converted",
+			config: `
+				variable "num" {
+					default = 123
+				}
+				resource "foo" {
+					type  = "simple"
+					input = var.num
+				}
+			`,
+			want: cty.StringVal("123"),
+		},
+		{
+			name: "Missing",
+			config: `
+				variable "name" {}
+				resource "foo" {
+					type  = "simple"
+					input = var.name
+				}
+			`,
+			wantSummary: "No value for required variable",
+		},
+		{
+			name: "Undeclared",
+			config: `
+				variable "name" {
+					default = "default"
+				}
+				resource "foo" {
+					type  = "simple"
+					input = var.nmae
+				}
+			`,
+			wantSummary: "Undeclared variable",
+		},
+		{
+			name: "Duplicate",
+			config: `
+				variable "name" {}
+				variable "name" {}
+			`,
+			vars:        map[string]cty.Value{"name": cty.StringVal("world")},
+			wantSummary: "Duplicate variable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"simple": reflect.TypeOf(simpleDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+				Variables: tt.vars,
+			}
+			_, diags := dec.DecodeBody(body, g)
+
+			if tt.wantSummary != "" {
+				if len(diags) != 1 {
+					t.Fatalf("Got %d diagnostics, want 1:\n%s", len(diags), parser.DiagString(diags))
+				}
+				if diags[0].Summary != tt.wantSummary {
+					t.Errorf("Summary = %q, want %q", diags[0].Summary, tt.wantSummary)
+				}
+				return
+			}
+			parser.CheckDiags(t, diags)
+
+			got := g.Resource("foo").Input.GetAttr("input")
+			if !got.RawEquals(tt.want) {
+				t.Errorf("Value = %#v, want %#v", got, tt.want)
+			}
+			if deps := g.DependenciesOf("foo"); len(deps) > 0 {
+				t.Errorf("Got %d dependencies, want none", len(deps))
+			}
+		})
+	}
+}
+
+func TestDecodeBody_strictVariables(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		vars        map[string]cty.Value
+		wantSummary []string
+	}{
+		{
+			name: "Clean",
+			config: `
+				variable "name" {}
+				variable "regions" {
+					default = { us = "us-east-1" }
+				}
+				resource "foo" {
+					type     = "simple"
+					for_each = var.regions
+					input    = "${var.name}-${each.value}"
+				}
+			`,
+			vars: map[string]cty.Value{"name": cty.StringVal("world")},
+		},
+		{
+			name: "UnusedDeclared",
+			config: `
+				variable "name" {
+					default = "default"
+				}
+				variable "unused" {
+					default = "default"
+				}
+				resource "foo" {
+					type  = "simple"
+					input = var.name
+				}
+			`,
+			wantSummary: []string{"Unused variable"},
+		},
+		{
+			name: "UnknownSupplied",
+			config: `
+				variable "name" {}
+				resource "foo" {
+					type  = "simple"
+					input = var.name
+				}
+			`,
+			vars: map[string]cty.Value{
+				"name": cty.StringVal("world"),
+				"nmae": cty.StringVal("typo"),
+			},
+			wantSummary: []string{"Value for undeclared variable"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"simple": reflect.TypeOf(simpleDef{}),
+				}},
+				Validator:       ValidateFunc(func(interface{}, string) error { return nil }),
+				Variables:       tt.vars,
+				StrictVariables: true,
+			}
+			_, diags := dec.DecodeBody(body, g)
+
+			var got []string
+			for _, d := range diags {
+				got = append(got, d.Summary)
+			}
+			if diff := cmp.Diff(got, tt.wantSummary); diff != "" {
+				t.Errorf("Diagnostics (-got +want)\n%s\n%s", diff, parser.DiagString(diags))
+			}
+		})
+	}
+}
+
+func TestDecodeBody_variableWithReference(t *testing.T) {
+	defer checkPanic(t)
+	g := &resource.Graph{}
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		variable "prefix" {
+			default = "hello"
+		}
+		resource "foo" {
+			type  = "simple"
+			input = "${var.prefix}-${bar.output}"
+		}
+		resource "bar" {
+			type = "simple"
+		}
+	`)
+
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"simple": reflect.TypeOf(simpleDef{}),
+		}},
+		Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+	}
+	_, diags := dec.DecodeBody(body, g)
+	parser.CheckDiags(t, diags)
+
+	deps := g.DependenciesOf("foo")
+	if len(deps) != 1 {
+		t.Fatalf("Got %d dependencies, want 1", len(deps))
+	}
+	want := resource.Expression{
+		resource.ExprLiteral{Value: cty.StringVal("hello-")},
+		resource.ExprReference{Path: cty.GetAttrPath("bar").GetAttr("output")},
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool { return a.RawEquals(b) }),
+		cmp.Transformer("Name", func(v cty.GetAttrStep) string { return v.Name }),
+	}
+	if diff := cmp.Diff(deps[0].Expression, want, opts...); diff != "" {
+		t.Errorf("Expression (-got +want)\n%s", diff)
+	}
+}
+
+func TestDecodeBody_conditional(t *testing.T) {
+	defer checkPanic(t)
+	g := &resource.Graph{}
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		variable "prod" {
+			default = true
+		}
+		resource "static" {
+			type   = "sized"
+			memory = var.prod ? 1024 : 128
+		}
+		resource "dynamic" {
+			type   = "sized"
+			memory = flag.enabled ? 1024 : 128
+		}
+		resource "partial" {
+			type   = "sized"
+			memory = var.prod ? static.size : 128
+		}
+		resource "flag" {
+			type = "flag"
+		}
+	`)
+
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"sized": reflect.TypeOf(struct {
+				resource.Definition
+				Memory int `func:"input"`
+				Size   int `func:"output"`
+			}{}),
+			"flag": reflect.TypeOf(struct {
+				resource.Definition
+				Enabled bool `func:"output"`
+			}{}),
+		}},
+		Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+	}
+	_, diags := dec.DecodeBody(body, g)
+	parser.CheckDiags(t, diags)
+
+	// Resolved statically.
+	static := g.Resource("static")
+	if static == nil {
+		t.Fatal("Resource static not found")
+	}
+	if got, want := static.Input.GetAttr("memory"), cty.NumberIntVal(1024); !got.RawEquals(want) {
+		t.Errorf("Static memory = %#v, want %#v", got, want)
+	}
+	if deps := g.DependenciesOf("static"); len(deps) != 0 {
+		t.Errorf("Got %d dependencies for static, want 0", len(deps))
+	}
+
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool { return a.RawEquals(b) }),
+		cmp.Transformer("Name", func(v cty.GetAttrStep) string { return v.Name }),
+	}
+
+	// Condition depends on an output.
+	deps := g.DependenciesOf("dynamic")
+	if len(deps) != 1 {
+		t.Fatalf("Got %d dependencies for dynamic, want 1", len(deps))
+	}
+	want := resource.Expression{
+		resource.ExprConditional{
+			Condition: resource.Expression{resource.ExprReference{Path: cty.GetAttrPath("flag").GetAttr("enabled")}},
+			True:      resource.Expression{resource.ExprLiteral{Value: cty.NumberIntVal(1024)}},
+			False:     resource.Expression{resource.ExprLiteral{Value: cty.NumberIntVal(128)}},
+		},
+	}
+	if diff := cmp.Diff(deps[0].Expression, want, opts...); diff != "" {
+		t.Errorf("Dynamic expression (-got +want)\n%s", diff)
+	}
+
+	// Static condition selects a result that depends on an output.
+	deps = g.DependenciesOf("partial")
+	if len(deps) != 1 {
+		t.Fatalf("Got %d dependencies for partial, want 1", len(deps))
+	}
+	want = resource.Expression{
+		resource.ExprReference{Path: cty.GetAttrPath("static").GetAttr("size")},
+	}
+	if diff := cmp.Diff(deps[0].Expression, want, opts...); diff != "" {
+		t.Errorf("Partial expression (-got +want)\n%s", diff)
+	}
+}
+
+func TestDecodeBody_conditionalErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		wantSummary string
+	}{
+		{
+			name: "ConditionNotBool",
+			config: `
+				variable "size" {
+					default = "large"
+				}
+				resource "foo" {
+					type   = "sized"
+					memory = var.size ? bar.size : 128
+				}
+				resource "bar" {
+					type = "sized"
+				}
+			`,
+			wantSummary: "Incorrect condition type",
+		},
+		{
+			name: "ResultType",
+			config: `
+				resource "foo" {
+					type   = "sized"
+					memory = bar.size ? 1024 : ["a"]
+				}
+				resource "bar" {
+					type = "sized"
+				}
+			`,
+			wantSummary: "Unsuitable value type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, tt.config)
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"sized": reflect.TypeOf(struct {
+						resource.Definition
+						Memory int `func:"input"`
+						Size   int `func:"output"`
+					}{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, g)
+			if !diags.HasErrors() {
+				t.Fatal("DecodeBody() want error")
+			}
+			if diags[0].Summary != tt.wantSummary {
+				t.Errorf("Summary = %q, want %q", diags[0].Summary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_validationErrors(t *testing.T) {
+	defer checkPanic(t)
+	g := &resource.Graph{}
+
+	parser := &testParser{}
+	body := parser.Parse(t, `
+		resource "a" {
+			type  = "validation"
+			input = "foo"
+			nested {
+				value = "foo"
+			}
+			nested {
+				value = "bar"
+			}
+		}
+		resource "b" {
+			type  = "validation"
+			input = a.input
+		}
+	`)
+
+	errInvalid := fmt.Errorf("invalid value")
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{Types: map[string]reflect.Type{
+			"validation": reflect.TypeOf(struct {
+				Input  string `func:"input" validate:"bar"`
+				Nested []struct {
+					Value string `validate:"bar"`
+				} `func:"input"`
+			}{}),
+		}},
+		Validator: ValidateFunc(func(v interface{}, param string) error {
+			if fmt.Sprintf("%v", v) != param {
+				return errInvalid
+			}
+			return nil
+		}),
+	}
+	_, diags := dec.DecodeBody(body, g)
+	if errs, _ := hcldecoder.Summary(diags); errs != 3 {
+		t.Errorf("Got %d errors, want 3", errs)
+	}
+
+	got := make(map[string]*hcldecoder.ValidationError)
+	for _, err := range dec.ValidationErrors() {
+		got[err.Error()] = err
+	}
+	want := []cty.Path{
+		cty.GetAttrPath("a").GetAttr("input"),
+		cty.GetAttrPath("a").GetAttr("nested").Index(cty.NumberIntVal(0)).GetAttr("value"),
+		cty.GetAttrPath("b").GetAttr("input"),
+	}
+	if len(got) != len(want) {
+		t.Errorf("Got %d validation errors, want %d", len(got), len(want))
+	}
+	for _, path := range want {
+		name := ctyext.PathString(path) + ": invalid value"
+		err, ok := got[name]
+		if !ok {
+			t.Errorf("Validation error %q not found", name)
+			continue
+		}
+		if !err.Path.Equals(path) {
+			t.Errorf("Path = %s, want %s", ctyext.PathString(err.Path), ctyext.PathString(path))
+		}
+		if err.Rule != "bar" {
+			t.Errorf("Rule = %q, want %q", err.Rule, "bar")
+		}
+		if err.Cause() != errInvalid {
+			t.Errorf("Cause() = %v, want %v", err.Cause(), errInvalid)
+		}
+	}
+}
+
+func TestDecodeBody_keyIndex(t *testing.T) {
+	type item struct {
+		Name string `key:"true"`
+		ID   string
+	}
+	items := cty.GetAttrPath("foo").GetAttr("items")
+	tests := []struct {
+		name  string
+		input string
+		want  resource.ExprReference
+	}{
+		{
+			name:  "Index",
+			input: `foo.items[1].id`,
+			want: resource.ExprReference{
+				Path: items.Index(cty.NumberIntVal(1)).GetAttr("id"),
+			},
+		},
+		{
+			name:  "Key",
+			input: `foo.items["x"].id`,
+			want: resource.ExprReference{
+				Path:      items.Index(cty.StringVal("x")).GetAttr("id"),
+				KeyFields: map[int]string{2: "name"},
+			},
+		},
+		{
+			name:  "NestedKey",
+			input: `foo.groups["a"].items["x"].id`,
+			want: resource.ExprReference{
+				Path: cty.GetAttrPath("foo").GetAttr("groups").Index(cty.StringVal("a")).
+					GetAttr("items").Index(cty.StringVal("x")).GetAttr("id"),
+				KeyFields: map[int]string{4: "name"},
+			},
+		},
+		{
+			name:  "DynamicKey",
+			input: `foo.items[foo.selected].id`,
+			want: resource.ExprReference{
+				Path:      items.Index(cty.DynamicVal).GetAttr("id"),
+				Keys:      map[int]cty.Path{2: cty.GetAttrPath("foo").GetAttr("selected")},
+				KeyFields: map[int]string{2: "name"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer checkPanic(t)
+			g := &resource.Graph{}
+
+			parser := &testParser{}
+			body := parser.Parse(t, fmt.Sprintf(`
+				resource "foo" {
+					type = "list"
+				}
+				resource "bar" {
+					type  = "simple"
+					input = %s
+				}
+			`, tt.input))
+
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"list": reflect.TypeOf(struct {
+						Items    []item                            `func:"output"`
+						Groups   map[string]struct{ Items []item } `func:"output"`
+						Selected string                            `func:"output"`
+					}{}),
+					"simple": reflect.TypeOf(simpleDef{}),
+				}},
+				Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+			}
+			_, diags := dec.DecodeBody(body, g)
+			parser.CheckDiags(t, diags)
+
+			deps := g.DependenciesOf("bar")
+			if len(deps) != 1 {
+				t.Fatalf("Got %d dependencies, want 1", len(deps))
+			}
+			want := resource.Expression{tt.want}
+			if !deps[0].Expression.Equals(want) {
+				t.Errorf("Expression does not match\nGot  %+v\nWant %+v", deps[0].Expression, want)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_json(t *testing.T) {
+	fsys := fstest.MapFS{
+		"hcl/func.hcl": {Data: []byte(`
+variable "greeting" {
+  default = "hello"
+}
+
+resource "foo" {
+  type   = "nested"
+  input  = var.greeting
+  labels = { env = "dev" }
+
+  nested {
+    value = "a"
+  }
+
+  nested {
+    value = "${module.mod.bar.output}-b"
+  }
+}
+
+module "mod" {
+  resource "bar" {
+    type  = "simple"
+    input = "world"
+  }
+}
+
+output "out" {
+  value = foo.output
+}
+`)},
+		"json/func.hcl.json": {Data: []byte(`{
+  "variable": {
+    "greeting": {
+      "default": "hello"
+    }
+  },
+  "resource": {
+    "foo": {
+      "//": "Nested blocks are lists of objects.",
+      "type": "nested",
+      "input": "${var.greeting}",
+      "labels": { "env": "dev" },
+      "nested": [
+        { "value": "a" },
+        { "value": "${module.mod.bar.output}-b" }
+      ]
+    }
+  },
+  "module": {
+    "mod": {
+      "resource": {
+        "bar": {
+          "type": "simple",
+          "input": "world"
+        }
+      }
+    }
+  },
+  "output": {
+    "out": {
+      "value": "${foo.output}"
+    }
+  }
+}`)},
+	}
+
+	decode := func(dir string) *resource.Graph {
+		t.Helper()
+		loader := &config.Loader{FS: fsys}
+		body, diags := loader.Load(dir)
+		if diags.HasErrors() {
+			t.Fatalf("Load(%q) error = %v", dir, diags)
+		}
+		dec := &hcldecoder.Decoder{
+			Resources: &resource.Registry{Types: map[string]reflect.Type{
+				"simple": reflect.TypeOf(simpleDef{}),
+				"nested": reflect.TypeOf(struct {
+					resource.Definition
+					Input  *string           `func:"input"`
+					Output string            `func:"output"`
+					Labels map[string]string `func:"input"`
+					Nested []struct {
+						Value string `func:"input"`
+					} `func:"input"`
+				}{}),
+			}},
+			Validator: ValidateFunc(func(interface{}, string) error { return nil }),
+		}
+		g := &resource.Graph{}
+		if _, diags := dec.DecodeBody(body, g); diags.HasErrors() {
+			t.Fatalf("DecodeBody(%q) error = %v", dir, diags)
+		}
+		return g
+	}
+
+	hclGraph := decode("hcl")
+	jsonGraph := decode("json")
+
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Path) bool { return a.Equals(b) }),
+		cmp.Comparer(func(a, b cty.Value) bool { return a.GoString() == b.GoString() }),
+		cmpopts.SortSlices(func(a, b *resource.Desired) bool { return a.Name < b.Name }),
+		// Ranges point to different files.
+		cmpopts.IgnoreTypes(hcl.Range{}),
+	}
+	if diff := cmp.Diff(jsonGraph, hclGraph, opts...); diff != "" {
+		t.Errorf("JSON graph does not match HCL (-json +hcl)\n%s", diff)
+	}
+}
+
+// ---
+
+type testParser struct {
+	filename string
+	files    map[string]*hcl.File
+}
+
+func (p *testParser) Parse(t *testing.T, src string) hcl.Body {
+	t.Helper()
+	if p.filename == "" {
+		p.filename = t.Name()
+	}
+	src = unindent(src)
+	src = strings.TrimSpace(src)
+	f, diags := hclsyntax.ParseConfig([]byte(src), p.filename, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Errorf("Parse test body: %v", diags)
+	}
+	if p.files == nil {
+		p.files = make(map[string]*hcl.File)
+	}
+	p.files[p.filename] = f
+	return f.Body
+}
+
+func unindent(str string) string {
+	str = strings.TrimLeft(str, "\n")
+	ind := ""
+	for _, c := range str {
+		if !unicode.IsSpace(c) {
+			break
+		}
+		ind += string(c)
+	}
+	lines := strings.Split(str, "\n")
+	for i, l := range lines {
+		lines[i] = strings.Replace(l, ind, "", 1)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func indent(str string, ind string) string {
+	lines := strings.Split(str, "\n")
+	for i, s := range lines {
+		lines[i] = ind + s
+	}
+	return strings.Join(lines, "\n")
 }
 
 var printWarnings = flag.Bool("warnings", false, "Print warning diagnostics")
@@ -1688,6 +5385,11 @@ type simpleDef struct {
 	Output string  `func:"output"`
 }
 
+type secretDef struct {
+	resource.Definition
+	Password string `func:"output" sensitive:"true"`
+}
+
 type ValidateFunc func(interface{}, string) error
 
 func (fn ValidateFunc) Validate(val interface{}, rule string) error { return fn(val, rule) }
@@ -0,0 +1,94 @@
+package hcldecoder
+
+import (
+	"fmt"
+
+	"github.com/func/func/ctyext"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Summary returns the number of errors and warnings in diags.
+//
+// Only diagnostics with error severity are counted as errors, matching
+// diags.HasErrors(): errors > 0 if and only if diags.HasErrors() is true.
+func Summary(diags hcl.Diagnostics) (errors, warnings int) {
+	for _, d := range diags {
+		switch d.Severity {
+		case hcl.DiagError:
+			errors++
+		case hcl.DiagWarning:
+			warnings++
+		}
+	}
+	return errors, warnings
+}
+
+// A ValidationError is produced when an input value does not pass the
+// validation rule set on its field. Every ValidationError is also reported as
+// a diagnostic.
+type ValidationError struct {
+	// Path is the path to the value. The first step is the qualified name of
+	// the resource, followed by the path to the value in the resource's
+	// input.
+	Path cty.Path
+
+	Rule  string    // The validation rule that failed.
+	Range hcl.Range // The source range of the value.
+	Err   error     // The error returned from the Validator.
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %v", ctyext.PathString(e.Path), e.Err)
+}
+
+// Cause returns the error returned from the Validator.
+func (e *ValidationError) Cause() error {
+	return e.Err
+}
+
+// ValidationErrors returns the validation errors that were produced by
+// DecodeBody, in the order they were encountered.
+func (d *Decoder) ValidationErrors() []*ValidationError {
+	return d.validationErrors
+}
+
+// firstPerSubject returns diags with only the first error for every source
+// range. An error is dropped if its subject overlaps with the subject of an
+// error that was already kept. Warnings and errors without a subject are
+// always kept.
+//
+// Decoding a single invalid expression may produce several errors, such as
+// a variable not being allowed and the same variable not being defined.
+// Reporting both would be noise, but independent errors in other attributes
+// should still be reported.
+func firstPerSubject(diags hcl.Diagnostics) hcl.Diagnostics {
+	var out hcl.Diagnostics
+	var seen []*hcl.Range
+Outer:
+	for _, d := range diags {
+		if d.Severity == hcl.DiagError && d.Subject != nil {
+			for _, s := range seen {
+				if rangesOverlap(*s, *d.Subject) {
+					continue Outer
+				}
+			}
+			seen = append(seen, d.Subject)
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// rangesOverlap reports whether a and b share any bytes. Empty ranges
+// overlap with a range that contains their position.
+func rangesOverlap(a, b hcl.Range) bool {
+	if a.Filename != b.Filename {
+		return false
+	}
+	if a.Start.Byte == a.End.Byte || b.Start.Byte == b.End.Byte {
+		return a.Start.Byte <= b.End.Byte && b.Start.Byte <= a.End.Byte
+	}
+	return a.Start.Byte < b.End.Byte && b.Start.Byte < a.End.Byte
+}
@@ -71,6 +71,189 @@
 // values are only known after the resource provides output values. These will
 // create dependencies in the graph.
 //
+// A conditional chooses between two values:
+//   memory = var.prod ? 1024 : 128
+//
+// The condition must be a bool. If the condition can be statically resolved,
+// the chosen value is used as if it had been set directly. Otherwise, the
+// conditional is resolved when the resources it refers to have been applied,
+// and both values create dependencies.
+//
+// A map or list output may be indexed with the value of another field:
+//   input = other.items[key.name].value
+//
+// If the key is a static input, it is resolved to a literal index. If it
+// refers to an output, the index is resolved once the key is known, and the
+// dependency is created on both resources. Inputs can only be indexed with
+// static values.
+//
+// The order of elements in a list output is decided by the provider and may
+// change when the resource is updated. If the element struct has a field
+// marked with a `key:"true"` struct tag, an element can instead be referenced
+// by its key:
+//   input = other.items["web"].id # The element where the key field is "web"
+//
+// A numeric index still refers to the element by position.
+//
+// Required inputs
+//
+// Inputs with a value type, such as string, int or bool, are required. An
+// omitted required input is an error, unless the provider sets a default for
+// it; the zero value is never used in place of a missing value. Inputs with a
+// pointer, slice or map type are optional and are null when omitted, so a
+// *int input can tell an explicit zero apart from an unset value:
+//
+//   timeout = 0 # A *int input is 0, not null
+//
+// Durations
+//
+// Numeric inputs marked with `func:"input,duration"` can also be set with a
+// duration string, which is converted to seconds. Inputs marked with
+// `func:"input,duration_ms"` are converted to milliseconds:
+//
+//   timeout = "5m" # 300
+//
+// Plain numbers are used as is. The duration must be a whole number of the
+// unit.
+//
+// Unset inputs
+//
+// Optional inputs that are not set are recorded in the resource's Unset. When
+// an existing resource is updated, the previously deployed values are kept
+// for them. To clear a previously set value, set the input to null:
+//
+//   description = null
+//
+// Explicit dependencies
+//
+// A resource may depend on another resource without referring to any of its
+// values, for example if it relies on a side effect of the other resource.
+// Such dependencies are listed in depends_on:
+//
+//   resource "app" {
+//       type       = "aws_lambda_function"
+//       depends_on = [policy]
+//   }
+//
+// The dependencies are added to the resource's DependsOn. The resources are
+// ordered as with other dependencies, but no values are passed.
+//
+// Descriptions
+//
+// A resource may describe what it is for in description. The description is
+// set in the resource's Description and shown in the log output when the
+// resource is applied. It may refer to variables. The description is not
+// passed to the resource as an input, unless the resource type has a
+// description input.
+//
+// For each
+//
+// A resource with for_each set to a map is expanded into one resource per
+// key. Within the block, each.key and each.value refer to the key and value
+// for the instance:
+//
+//   resource "queue" {
+//       type       = "aws_sqs_queue"
+//       for_each   = { us = "us-east-1", eu = "eu-west-1" }
+//       region     = each.value
+//       queue_name = "jobs-${each.key}"
+//   }
+//
+// Each instance is added to the graph with the key in its name, as in
+// queue["us"]. Other resources refer to an instance by its key:
+//
+//   url = queue["eu"].url
+//
+// The for_each value must be known when the configuration is decoded, it can
+// only refer to variables. As instances are named by their key, removing a
+// key from the map deletes only the corresponding resource.
+//
+// Variables
+//
+// Variables parameterize a configuration. A variable is declared with a
+// variable block and referred to as var.<name>:
+//
+//   variable "region" {
+//       default = "us-east-1"
+//   }
+//
+//   resource "bucket" {
+//       type   = "aws_s3_bucket"
+//       region = var.region
+//   }
+//
+// Values for variables are set on the Decoder. If a value is not set, the
+// default value is used. A variable without a default value must be given a
+// value. Variables are resolved when the configuration is decoded, so they
+// never create dependencies.
+//
+// Environment variables
+//
+// Input values may read environment variables with the env function. The
+// value is resolved when the configuration is decoded, so it never creates a
+// dependency:
+//
+//   resource "bucket" {
+//       type   = "aws_s3_bucket"
+//       bucket = env("BUCKET_NAME")
+//   }
+//
+// If the variable is not set, decoding fails. A default value can be given as
+// a second argument: env("BUCKET_NAME", "my-bucket").
+//
+// The variables are read from Decoder.Env, not from the environment of the
+// decoding process. The CLI sets the variables returned by EnvNames from its
+// own environment, so the values come from where the configuration is
+// applied from.
+//
+// Collection functions
+//
+// The functions length, keys, values, element and lookup operate on lists and
+// maps, such as values of variables:
+//
+//   resource "bucket" {
+//       type     = "aws_s3_bucket"
+//       for_each = var.buckets
+//       region   = lookup(var.regions, each.key, "us-east-1")
+//   }
+//
+// Like env, the functions are evaluated when the configuration is decoded.
+// Their arguments must be known at that point; a function call cannot refer
+// to the output of another resource.
+//
+// Providers
+//
+// A provider block sets default input values for resources of a provider. The
+// provider is determined by the resource type, aws_lambda_function belongs to
+// the aws provider:
+//
+//   provider "aws" {
+//       region = "eu-west-1"
+//   }
+//
+// The default is used when the input is not set on the resource, a value set
+// on the resource always takes precedence. Defaults may also be set on the
+// Decoder, for example from the environment. Values in a provider block take
+// precedence over those.
+//
+// Additional configurations for a provider are declared with an alias. A
+// resource selects one with the provider meta-argument, to deploy into
+// another account for example:
+//
+//   provider "aws" {
+//       alias   = "prod"
+//       profile = "production"
+//   }
+//
+//   resource "api" {
+//       type     = "aws_lambda_function"
+//       provider = aws.prod
+//   }
+//
+// The resource only gets the defaults from the selected provider. The profile
+// of the provider is set on the resource in the graph, the reconciler uses it
+// to get the credentials for the resource.
+//
 // Parent references
 //
 // Whenever the source config contains a reference to another resource, a
@@ -78,6 +261,118 @@
 // can be statically resolved, a dependency is not added, but the parent
 // reference is still kept.
 //
+// Modules
+//
+// Resources can be grouped into modules. A module acts as a namespace, a
+// resource name only needs to be unique within the module:
+//
+//   module "alice" {
+//       resource "person" {
+//           type = "person"
+//           name = "alice"
+//       }
+//   }
+//
+//   module "bob" {
+//       resource "person" {
+//           type    = "person"
+//           name    = "bob"
+//           friends = [person.name] # Refers to bob.person
+//       }
+//   }
+//
+// In the graph, the resources are named alice.person and bob.person.
+// References within a module refer to resources in the same module. If the
+// module does not declare a resource with the referenced name, the reference
+// refers to a resource outside of any module. To refer to a resource in a
+// module from outside of it, or from another module, the reference is
+// prefixed with module and the module name:
+//
+//   resource "greet" {
+//       type     = "greeter"
+//       greeting = "Hello, ${module.alice.person.name}!"
+//   }
+//
+// Outputs
+//
+// Output blocks expose values to the user after the resources have been
+// applied:
+//
+//   output "greeting" {
+//       value = greet.greeting
+//   }
+//
+// The output expression is not resolved by the decoder, as it typically
+// depends on values that are only known after the resources have been
+// applied. The references are checked to exist and the output is added to the
+// graph.
+//
+// An output with sensitive = true is not displayed when outputs are listed.
+// An output that refers to a sensitive resource field must be marked
+// sensitive, so a secret is not exposed by accident:
+//
+//   output "password" {
+//       value     = db.master_password
+//       sensitive = true
+//   }
+//
+// Moved resources
+//
+// Renaming a resource would delete the deployed resource and create a new
+// one. A moved block renames the deployed resource instead:
+//
+//   moved {
+//       from = old_name
+//       to   = new_name
+//   }
+//
+// The resource to move from must no longer be declared. Resources in
+// modules are referred to as module.<module>.<name>.
+//
+// Remote state
+//
+// A remote_state data block reads the outputs of another project, so projects
+// can be layered on top of each other. The outputs are referred to as
+// data.remote_state.<name>.<output>:
+//
+//   data "remote_state" "network" {
+//       project = "network"
+//   }
+//
+//   resource "subnet" {
+//       type   = "aws_ec2_subnet"
+//       vpc_id = data.remote_state.network.vpc_id
+//   }
+//
+// The outputs are read with the RemoteState reader set on the Decoder when
+// the configuration is decoded. Like variables, they are resolved statically
+// and never create dependencies. The reader decides which outputs are exposed
+// to other projects. If no reader is set, such as when validating the
+// configuration locally, the outputs are unknown.
+//
+// Lifecycle
+//
+// A resource may contain a lifecycle block to customize how changes are
+// handled. Changes to input fields listed in ignore_changes do not cause the
+// resource to be updated, which is useful for values that are modified
+// outside of func:
+//
+//   resource "table" {
+//       type = "aws_dynamodb_table"
+//       # ...
+//
+//       lifecycle {
+//           ignore_changes = [provisioned_throughput]
+//       }
+//   }
+//
+// The references are relative to the resource's own inputs.
+//
+// Setting prevent_destroy = true protects the resource from being deleted.
+// If the resource is removed from the configuration, the apply fails instead
+// of deleting it. To delete the resource, first apply the configuration with
+// prevent_destroy removed.
+//
 // Source
 //
 // Source code that is set on the resource will be decoded and returned. The
@@ -0,0 +1,315 @@
+package hcldecoder
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/func/func/resource/hcldecoder/internal/expr"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/hashicorp/hcl2/hclpack"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// functions returns the functions that are available when statically
+// resolving values. Only functions that can be resolved when the
+// configuration is decoded can be added.
+func (d *Decoder) functions() map[string]function.Function {
+	return map[string]function.Function{
+		"element": elementFunc,
+		"env":     envFunc(d.Env),
+		"keys":    keysFunc,
+		"length":  stdlib.LengthFunc,
+		"lookup":  lookupFunc,
+		"values":  valuesFunc,
+	}
+}
+
+// checkFunctionCalls checks that all function calls in an expression can be
+// evaluated statically. Functions are evaluated when the configuration is
+// decoded, so the arguments cannot refer to outputs of other resources.
+func checkFunctionCalls(ex hcl.Expression, ctx *hcl.EvalContext) hcl.Diagnostics {
+	if packexpr, ok := ex.(*hclpack.Expression); ok {
+		parsed, diags := packexpr.Parse()
+		if diags.HasErrors() {
+			return diags
+		}
+		ex = parsed
+	}
+	node, ok := ex.(hclsyntax.Expression)
+	if !ok {
+		return nil
+	}
+
+	var diags hcl.Diagnostics
+	hclsyntax.VisitAll(node, func(n hclsyntax.Node) hcl.Diagnostics {
+		call, ok := n.(*hclsyntax.FunctionCallExpr)
+		if !ok || expr.IsStatic(call, ctx) {
+			return nil
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Function argument not known",
+			Detail: fmt.Sprintf(
+				"The arguments to %s() must be known before applying. They can only refer to variables and data.",
+				call.Name,
+			),
+			Subject: call.Range().Ptr(),
+		})
+		return nil
+	})
+	return diags
+}
+
+// envFunc returns a function that reads an environment variable from env.
+// An optional second argument sets a default value to use if the variable is
+// not set.
+//
+//   env("NAME")
+//   env("NAME", "default")
+func envFunc(env map[string]string) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "name", Type: cty.String},
+		},
+		VarParam: &function.Parameter{Name: "default", Type: cty.String},
+		Type:     function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			if len(args) > 2 {
+				return cty.NilVal, function.NewArgErrorf(2, "at most one default value can be given")
+			}
+			name := args[0].AsString()
+			if v, ok := env[name]; ok {
+				return cty.StringVal(v), nil
+			}
+			if len(args) == 2 {
+				return args[1], nil
+			}
+			return cty.NilVal, fmt.Errorf("environment variable %s is not set", name)
+		},
+	})
+}
+
+// EnvNames returns the sorted names of the environment variables read with
+// the env function in a packed configuration. Only names given as a string
+// literal are returned.
+//
+// The configuration may be decoded on a server, so the decoder does not read
+// its own environment. The caller should look up the returned names in the
+// environment the configuration is applied from and set them in Decoder.Env.
+func EnvNames(body *hclpack.Body) []string {
+	seen := make(map[string]bool)
+	envNames(body, seen)
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func envNames(body *hclpack.Body, seen map[string]bool) {
+	for _, attr := range body.Attributes {
+		parsed, diags := attr.Expr.Parse()
+		if diags.HasErrors() {
+			continue
+		}
+		node, ok := parsed.(hclsyntax.Expression)
+		if !ok {
+			continue
+		}
+		hclsyntax.VisitAll(node, func(n hclsyntax.Node) hcl.Diagnostics {
+			call, ok := n.(*hclsyntax.FunctionCallExpr)
+			if !ok || call.Name != "env" || len(call.Args) == 0 {
+				return nil
+			}
+			v, diags := call.Args[0].Value(nil)
+			if diags.HasErrors() || !v.IsKnown() || v.IsNull() || v.Type() != cty.String {
+				return nil
+			}
+			seen[v.AsString()] = true
+			return nil
+		})
+	}
+	for i := range body.ChildBlocks {
+		envNames(&body.ChildBlocks[i].Body, seen)
+	}
+}
+
+// keysFunc returns the keys of a map, or the attribute names of an object,
+// in lexicographical order.
+//
+//   keys({ a = 1, b = 2 })
+var keysFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "map", Type: cty.DynamicPseudoType},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		if ty := args[0].Type(); !ty.IsMapType() && !ty.IsObjectType() {
+			return cty.NilType, function.NewArgErrorf(0, "must be a map or object, got %s", ty.FriendlyName())
+		}
+		return cty.List(cty.String), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		var keys []cty.Value
+		for it := args[0].ElementIterator(); it.Next(); {
+			k, _ := it.Element()
+			keys = append(keys, k)
+		}
+		if len(keys) == 0 {
+			return cty.ListValEmpty(cty.String), nil
+		}
+		return cty.ListVal(keys), nil
+	},
+})
+
+// valuesFunc returns the values of a map, or the attribute values of an
+// object, ordered by their keys.
+//
+//   values({ a = 1, b = 2 })
+var valuesFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "map", Type: cty.DynamicPseudoType},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		ty := args[0].Type()
+		switch {
+		case ty.IsMapType():
+			return cty.List(ty.ElementType()), nil
+		case ty.IsObjectType():
+			attrs := ty.AttributeTypes()
+			names := make([]string, 0, len(attrs))
+			for name := range attrs {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			types := make([]cty.Type, len(names))
+			for i, name := range names {
+				types[i] = attrs[name]
+			}
+			return cty.Tuple(types), nil
+		}
+		return cty.NilType, function.NewArgErrorf(0, "must be a map or object, got %s", ty.FriendlyName())
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		var vals []cty.Value
+		for it := args[0].ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			vals = append(vals, v)
+		}
+		if retType.IsTupleType() {
+			return cty.TupleVal(vals), nil
+		}
+		if len(vals) == 0 {
+			return cty.ListValEmpty(retType.ElementType()), nil
+		}
+		return cty.ListVal(vals), nil
+	},
+})
+
+// elementFunc returns a single element from a list. If the index is greater
+// than the length of the list, the index wraps around.
+//
+//   element(["a", "b"], 1)
+var elementFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "list", Type: cty.DynamicPseudoType},
+		{Name: "index", Type: cty.Number},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		ty := args[0].Type()
+		switch {
+		case ty.IsListType():
+			return ty.ElementType(), nil
+		case ty.IsTupleType():
+			if !args[1].IsKnown() {
+				return cty.DynamicPseudoType, nil
+			}
+			i, err := elementIndex(args[0], args[1])
+			if err != nil {
+				return cty.NilType, err
+			}
+			return ty.TupleElementType(i), nil
+		}
+		return cty.NilType, function.NewArgErrorf(0, "must be a list, got %s", ty.FriendlyName())
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		i, err := elementIndex(args[0], args[1])
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return args[0].Index(cty.NumberIntVal(int64(i))), nil
+	},
+})
+
+// elementIndex returns the index of the element to get from list, wrapping
+// around if the index is greater than the length of the list.
+func elementIndex(list, index cty.Value) (int, error) {
+	bf := index.AsBigFloat()
+	if !bf.IsInt() || bf.Sign() < 0 {
+		return 0, function.NewArgErrorf(1, "index must be a non-negative whole number")
+	}
+	n := list.LengthInt()
+	if n == 0 {
+		return 0, function.NewArgErrorf(0, "cannot get an element from an empty list")
+	}
+	i, _ := new(big.Float).Set(bf).Int(nil)
+	return int(i.Mod(i, big.NewInt(int64(n))).Int64()), nil
+}
+
+// lookupFunc returns the value of a single key in a map. An optional third
+// argument sets a default value to use if the key does not exist.
+//
+//   lookup(var.amis, "us-east-1")
+//   lookup(var.amis, "us-east-1", "ami-123456")
+var lookupFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "map", Type: cty.DynamicPseudoType},
+		{Name: "key", Type: cty.String},
+	},
+	VarParam: &function.Parameter{Name: "default", Type: cty.DynamicPseudoType},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		if len(args) > 3 {
+			return cty.NilType, function.NewArgErrorf(3, "at most one default value can be given")
+		}
+		ty := args[0].Type()
+		switch {
+		case ty.IsMapType():
+			return ty.ElementType(), nil
+		case ty.IsObjectType():
+			key := args[1].AsString()
+			if ty.HasAttribute(key) {
+				return ty.AttributeType(key), nil
+			}
+			if len(args) == 3 {
+				return args[2].Type(), nil
+			}
+			return cty.NilType, function.NewArgErrorf(1, "key %q does not exist", key)
+		}
+		return cty.NilType, function.NewArgErrorf(0, "must be a map or object, got %s", ty.FriendlyName())
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		m, key := args[0], args[1]
+		if m.Type().IsObjectType() {
+			if m.Type().HasAttribute(key.AsString()) {
+				return m.GetAttr(key.AsString()), nil
+			}
+			return args[2], nil
+		}
+		if m.HasIndex(key).True() {
+			return m.Index(key), nil
+		}
+		if len(args) < 3 {
+			return cty.NilVal, function.NewArgErrorf(1, "key %q does not exist", key.AsString())
+		}
+		v, err := convert.Convert(args[2], retType)
+		if err != nil {
+			return cty.NilVal, function.NewArgErrorf(2, "default value: %v", err)
+		}
+		return v, nil
+	},
+})
@@ -8,21 +8,25 @@ import (
 	"github.com/hashicorp/hcl2/hcl/hclsyntax"
 	"github.com/hashicorp/hcl2/hclpack"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
 )
 
 // MustConvert converts a HCL expression into a graph expression.
 //
-// Only simple expression containing template literals or traversals are
-// supported.
+// Only simple expression containing template literals, traversals or
+// conditionals are supported.
+//
+// Parts of the expression that only refer to variables in ctx are resolved
+// to literals. The ctx may be nil. The caller must ensure the parts can be
+// evaluated without errors.
 //
 // Panics if conversion is not possible. This indicates that an expression is
 // not supported.
-func MustConvert(input hcl.Expression) resource.Expression {
-	if len(input.Variables()) == 0 {
-		val, diags := input.Value(nil)
+func MustConvert(input hcl.Expression, ctx *hcl.EvalContext) resource.Expression {
+	if IsStatic(input, ctx) {
+		val, diags := input.Value(ctx)
 		if diags.HasErrors() {
-			// Should not happen; the expression has no variables so it should
-			// be resolvable with a nil eval context.
+			// Should not happen; all variables are set in the eval context.
 			panic(fmt.Sprintf("Get static value for expression conversion: %v", diags))
 		}
 		return resource.Expression{resource.ExprLiteral{Value: val}}
@@ -40,14 +44,14 @@ func MustConvert(input hcl.Expression) resource.Expression {
 	}
 
 	if expr, ok := input.(*hclsyntax.RelativeTraversalExpr); ok {
-		src := MustConvert(expr.Source)
+		src := MustConvert(expr.Source, ctx)
 
 		// The collection will always resolve to a reference value, use the
 		// path from it as a starting point.
-		path := src[0].(resource.ExprReference).Path
-		path = append(path, traversalAsPath(expr.Traversal)...)
+		ref := src[0].(resource.ExprReference)
+		path := append(ref.Path.Copy(), traversalAsPath(expr.Traversal)...)
 
-		return resource.Expression{resource.ExprReference{Path: path}}
+		return resource.Expression{resource.ExprReference{Path: path, Keys: ref.Keys}}
 	}
 
 	if expr, ok := input.(*hclsyntax.ScopeTraversalExpr); ok {
@@ -56,30 +60,71 @@ func MustConvert(input hcl.Expression) resource.Expression {
 	}
 
 	if expr, ok := input.(*hclsyntax.IndexExpr); ok {
-		col := MustConvert(expr.Collection)
-		key := MustConvert(expr.Key)
+		col := MustConvert(expr.Collection, ctx)
+		key := MustConvert(expr.Key, ctx)
 
 		// The collection will always resolve to a reference value, use the
 		// path from it as a starting point.
-		path := col[0].(resource.ExprReference).Path
+		ref := col[0].(resource.ExprReference)
+		path := ref.Path.Copy()
+		keys := make(map[int]cty.Path, len(ref.Keys)+1)
+		for pos, k := range ref.Keys {
+			keys[pos] = k
+		}
 
 		// Append key(s) as indices
 		for _, k := range key {
-			lit := k.(resource.ExprLiteral)
-			path = path.Index(lit.Value)
+			switch k := k.(type) {
+			case resource.ExprLiteral:
+				path = path.Index(k.Value)
+			case resource.ExprReference:
+				if len(k.Keys) > 0 {
+					panic("Nested dynamic index")
+				}
+				// Dynamic index, resolved when the referenced key is known.
+				keys[len(path)] = k.Path
+				path = path.Index(cty.DynamicVal)
+			}
+		}
+		if len(keys) == 0 {
+			keys = nil
 		}
 
-		return resource.Expression{resource.ExprReference{Path: path}}
+		return resource.Expression{resource.ExprReference{Path: path, Keys: keys}}
+	}
+
+	if expr, ok := input.(*hclsyntax.ConditionalExpr); ok {
+		// A static condition selects the expression to use. The caller must
+		// ensure the condition is a bool.
+		if IsStatic(expr.Condition, ctx) {
+			cond, diags := expr.Condition.Value(ctx)
+			if diags.HasErrors() {
+				panic(fmt.Sprintf("Get static value for condition: %v", diags))
+			}
+			cond, err := convert.Convert(cond, cty.Bool)
+			if err != nil {
+				panic(fmt.Sprintf("Convert condition: %v", err))
+			}
+			if cond.True() {
+				return MustConvert(expr.TrueResult, ctx)
+			}
+			return MustConvert(expr.FalseResult, ctx)
+		}
+		return resource.Expression{resource.ExprConditional{
+			Condition: MustConvert(expr.Condition, ctx),
+			True:      MustConvert(expr.TrueResult, ctx),
+			False:     MustConvert(expr.FalseResult, ctx),
+		}}
 	}
 
 	if expr, ok := input.(*hclsyntax.TemplateWrapExpr); ok {
-		return MustConvert(expr.Wrapped)
+		return MustConvert(expr.Wrapped, ctx)
 	}
 
 	if expr, ok := input.(*hclsyntax.TemplateExpr); ok {
 		var out resource.Expression
 		for _, p := range expr.Parts {
-			out = append(out, MustConvert(p)...)
+			out = append(out, MustConvert(p, ctx)...)
 		}
 		return out
 	}
@@ -87,6 +132,58 @@ func MustConvert(input hcl.Expression) resource.Expression {
 	panic(fmt.Sprintf("Unsupported: %T", input))
 }
 
+// IsStatic returns true if all variables in the expression are set in ctx,
+// meaning the expression can be evaluated without references to resources.
+// The ctx may be nil.
+func IsStatic(input hcl.Expression, ctx *hcl.EvalContext) bool {
+	for _, v := range input.Variables() {
+		if ctx == nil {
+			return false
+		}
+		if _, ok := ctx.Variables[v.RootName()]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Paths converts a static list of traversals, such as [foo, bar.baz[0]], into
+// paths.
+//
+// If the expression is null, no paths are returned. Diagnostics are returned
+// if the expression is not a list, or if any element is not a traversal.
+func Paths(input hcl.Expression) ([]cty.Path, hcl.Diagnostics) {
+	if len(input.Variables()) == 0 {
+		if v, diags := input.Value(nil); !diags.HasErrors() && v.IsNull() {
+			return nil, nil
+		}
+	}
+
+	// Special case for hclpack.Expression: convert to hclsyntax.Expression.
+	if packexpr, ok := input.(*hclpack.Expression); ok {
+		ex, diags := packexpr.Parse()
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		input = ex
+	}
+
+	exprs, diags := hcl.ExprList(input)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	paths := make([]cty.Path, 0, len(exprs))
+	for _, ex := range exprs {
+		traversal, moreDiags := hcl.AbsTraversalForExpr(ex)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+		paths = append(paths, traversalAsPath(traversal))
+	}
+	return paths, diags
+}
+
 func traversalAsPath(traversal hcl.Traversal) cty.Path {
 	var path cty.Path
 	for _, part := range traversal {
@@ -103,3 +200,23 @@ func traversalAsPath(traversal hcl.Traversal) cty.Path {
 	}
 	return path
 }
+
+// Path converts a static traversal, such as foo.bar[0], into a path.
+//
+// Diagnostics are returned if the expression is not a traversal.
+func Path(input hcl.Expression) (cty.Path, hcl.Diagnostics) {
+	// Special case for hclpack.Expression: convert to hclsyntax.Expression.
+	if packexpr, ok := input.(*hclpack.Expression); ok {
+		ex, diags := packexpr.Parse()
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		input = ex
+	}
+
+	traversal, diags := hcl.AbsTraversalForExpr(input)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return traversalAsPath(traversal), diags
+}
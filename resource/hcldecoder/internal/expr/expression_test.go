@@ -41,6 +41,23 @@ func TestMustConvert(t *testing.T) {
 				resource.ExprLiteral{Value: cty.StringVal("foo")},
 			},
 		},
+		{
+			"HCLSyntax_conditional",
+			func(t *testing.T) hcl.Expression {
+				ex, diags := hclsyntax.ParseExpression([]byte(`foo.enabled ? 1024 : bar.size`), "", hcl.InitialPos)
+				if diags.HasErrors() {
+					t.Fatal(diags)
+				}
+				return ex
+			},
+			resource.Expression{
+				resource.ExprConditional{
+					Condition: resource.Expression{resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("enabled")}},
+					True:      resource.Expression{resource.ExprLiteral{Value: cty.NumberIntVal(1024)}},
+					False:     resource.Expression{resource.ExprReference{Path: cty.GetAttrPath("bar").GetAttr("size")}},
+				},
+			},
+		},
 		{
 			"HCLSyntax_ref",
 			func(t *testing.T) hcl.Expression {
@@ -119,7 +136,7 @@ func TestMustConvert(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			defer checkPanic(t)
 
-			got := expr.MustConvert(tt.expr(t))
+			got := expr.MustConvert(tt.expr(t), nil)
 
 			opts := []cmp.Option{
 				cmp.Comparer(func(a, b cty.Value) bool { return a.Equals(b).True() }),
@@ -133,6 +150,125 @@ func TestMustConvert(t *testing.T) {
 	}
 }
 
+func TestMustConvert_evalContext(t *testing.T) {
+	defer checkPanic(t)
+
+	ex, diags := hclsyntax.ParseExpression([]byte(`"${var.env}-${foo.bar}"`), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{
+				"env": cty.StringVal("dev"),
+			}),
+		},
+	}
+
+	got := expr.MustConvert(ex, ctx)
+	want := resource.Expression{
+		resource.ExprLiteral{Value: cty.StringVal("dev")},
+		resource.ExprLiteral{Value: cty.StringVal("-")},
+		resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("bar")},
+	}
+
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool { return a.Equals(b).True() }),
+		cmp.Transformer("Name", func(v cty.GetAttrStep) string { return v.Name }),
+	}
+	if diff := cmp.Diff(got, want, opts...); diff != "" {
+		t.Errorf("MustConvert() (-got +want) %s", diff)
+	}
+}
+
+func TestMustConvert_staticCondition(t *testing.T) {
+	defer checkPanic(t)
+
+	ex, diags := hclsyntax.ParseExpression([]byte(`var.prod ? foo.large : "small"`), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{
+				"prod": cty.True,
+			}),
+		},
+	}
+
+	got := expr.MustConvert(ex, ctx)
+	want := resource.Expression{
+		resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("large")},
+	}
+
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool { return a.Equals(b).True() }),
+		cmp.Transformer("Name", func(v cty.GetAttrStep) string { return v.Name }),
+	}
+	if diff := cmp.Diff(got, want, opts...); diff != "" {
+		t.Errorf("MustConvert() (-got +want) %s", diff)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	tests := []struct {
+		name      string
+		expr      hcl.Expression
+		want      []cty.Path
+		wantDiags bool
+	}{
+		{
+			name: "Null",
+			expr: hcl.StaticExpr(cty.NullVal(cty.DynamicPseudoType), hcl.Range{}),
+			want: nil,
+		},
+		{
+			name: "Empty",
+			expr: &hclpack.Expression{Source: []byte(`[]`), SourceType: hclpack.ExprNative},
+			want: []cty.Path{},
+		},
+		{
+			name: "Traversals",
+			expr: &hclpack.Expression{Source: []byte(`[foo, bar.baz[1]]`), SourceType: hclpack.ExprNative},
+			want: []cty.Path{
+				cty.GetAttrPath("foo"),
+				cty.GetAttrPath("bar").GetAttr("baz").Index(cty.NumberIntVal(1)),
+			},
+		},
+		{
+			name:      "NotList",
+			expr:      &hclpack.Expression{Source: []byte(`foo`), SourceType: hclpack.ExprNative},
+			wantDiags: true,
+		},
+		{
+			name:      "NotTraversal",
+			expr:      &hclpack.Expression{Source: []byte(`["foo"]`), SourceType: hclpack.ExprNative},
+			wantDiags: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diags := expr.Paths(tt.expr)
+			if diags.HasErrors() != tt.wantDiags {
+				t.Fatalf("Paths() diags = %v, want diags = %t", diags, tt.wantDiags)
+			}
+			if tt.wantDiags {
+				return
+			}
+
+			opts := []cmp.Option{
+				cmp.Comparer(func(a, b cty.Value) bool { return a.Equals(b).True() }),
+				cmp.Transformer("Name", func(v cty.GetAttrStep) string { return v.Name }),
+				cmp.Transformer("GoString", func(v cty.IndexStep) string { return v.GoString() }),
+			}
+			if diff := cmp.Diff(got, tt.want, opts...); diff != "" {
+				t.Errorf("Paths() (-got +want) %s", diff)
+			}
+		})
+	}
+}
+
 func checkPanic(t *testing.T) {
 	t.Helper()
 	if err := recover(); err != nil {
@@ -140,3 +276,54 @@ func checkPanic(t *testing.T) {
 		t.Fatalf("Panic: %v: %v", c, err)
 	}
 }
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		expr      hcl.Expression
+		want      cty.Path
+		wantDiags bool
+	}{
+		{
+			name: "Traversal",
+			expr: &hclpack.Expression{Source: []byte(`module.foo.bar`), SourceType: hclpack.ExprNative},
+			want: cty.GetAttrPath("module").GetAttr("foo").GetAttr("bar"),
+		},
+		{
+			name: "Index",
+			expr: &hclpack.Expression{Source: []byte(`foo["a"]`), SourceType: hclpack.ExprNative},
+			want: cty.GetAttrPath("foo").Index(cty.StringVal("a")),
+		},
+		{
+			name:      "List",
+			expr:      &hclpack.Expression{Source: []byte(`[foo]`), SourceType: hclpack.ExprNative},
+			wantDiags: true,
+		},
+		{
+			name:      "String",
+			expr:      &hclpack.Expression{Source: []byte(`"foo"`), SourceType: hclpack.ExprNative},
+			wantDiags: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diags := expr.Path(tt.expr)
+			if diags.HasErrors() != tt.wantDiags {
+				t.Fatalf("Path() diags = %v, want diags = %t", diags, tt.wantDiags)
+			}
+			if tt.wantDiags {
+				return
+			}
+
+			opts := []cmp.Option{
+				cmp.Comparer(func(a, b cty.Value) bool { return a.Equals(b).True() }),
+				cmp.Transformer("Name", func(v cty.GetAttrStep) string { return v.Name }),
+				cmp.Transformer("GoString", func(v cty.IndexStep) string { return v.GoString() }),
+			}
+			if diff := cmp.Diff(got, tt.want, opts...); diff != "" {
+				t.Errorf("Path() (-got +want) %s", diff)
+			}
+		})
+	}
+}
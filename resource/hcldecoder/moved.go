@@ -0,0 +1,123 @@
+package hcldecoder
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/func/func/config"
+	"github.com/func/func/resource/hcldecoder/internal/expr"
+	"github.com/func/func/suggest"
+	"github.com/hashicorp/hcl2/gohcl"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// move contains temporary data for a decoded moved block.
+type move struct {
+	From     string // Qualified name of the previous resource.
+	To       string // Qualified name of the new resource.
+	DefRange hcl.Range
+}
+
+// decodeMoved decodes a moved block. The resources must have been decoded
+// before, as the new name must refer to a declared resource.
+func (d *Decoder) decodeMoved(block *hcl.Block) hcl.Diagnostics {
+	var m config.Moved
+	diags := gohcl.DecodeBody(block.Body, nil, &m)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	from, morediags := d.movedName(m.From)
+	diags = append(diags, morediags...)
+	to, morediags := d.movedName(m.To)
+	diags = append(diags, morediags...)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	if _, ok := d.resources[from]; ok {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid moved",
+			Detail:   fmt.Sprintf("The resource %q is still declared. Remove it to move it to %q.", from, to),
+			Subject:  m.From.Range().Ptr(),
+		})
+	}
+	if _, ok := d.resources[to]; !ok {
+		diag := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid moved",
+			Detail:   fmt.Sprintf("A resource named %q is not declared.", to),
+			Subject:  m.To.Range().Ptr(),
+		}
+		names := make([]string, 0, len(d.resources))
+		for k := range d.resources {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		if s := suggest.String(to, names); s != "" {
+			diag.Detail += fmt.Sprintf(" Did you mean %q?", s)
+		}
+		return append(diags, diag)
+	}
+
+	for _, ex := range d.moves {
+		if ex.From == from || ex.To == to {
+			return append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate moved",
+				Detail: fmt.Sprintf(
+					"The resource was already moved from %q to %q in %s on line %d.",
+					ex.From, ex.To, ex.DefRange.Filename, ex.DefRange.Start.Line,
+				),
+				Subject: block.DefRange.Ptr(),
+			})
+		}
+	}
+
+	d.moves = append(d.moves, &move{
+		From:     from,
+		To:       to,
+		DefRange: block.DefRange,
+	})
+
+	return diags
+}
+
+// movedName returns the qualified name of the resource that an expression in
+// a moved block refers to.
+func (d *Decoder) movedName(ex hcl.Expression) (string, hcl.Diagnostics) {
+	invalid := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid moved",
+		Detail:   "A reference to a resource is required, as in foo or module.<module>.foo.",
+		Subject:  ex.Range().Ptr(),
+	}
+
+	path, diags := expr.Path(ex)
+	if diags.HasErrors() {
+		return "", hcl.Diagnostics{invalid}
+	}
+	path, diag := d.qualifyPath("", path)
+	if diag != nil {
+		diag.Subject = ex.Range().Ptr()
+		return "", hcl.Diagnostics{diag}
+	}
+
+	root, ok := path[0].(cty.GetAttrStep)
+	if !ok {
+		return "", hcl.Diagnostics{invalid}
+	}
+	switch len(path) {
+	case 1:
+		return root.Name, nil
+	case 2:
+		// Instance of a resource with for_each that is no longer declared.
+		index, ok := path[1].(cty.IndexStep)
+		if ok && index.Key.IsKnown() && !index.Key.IsNull() && index.Key.Type() == cty.String {
+			return instanceName(root.Name, index.Key.AsString()), nil
+		}
+	}
+	return "", hcl.Diagnostics{invalid}
+}
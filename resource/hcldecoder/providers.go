@@ -0,0 +1,201 @@
+package hcldecoder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/func/func/config"
+	"github.com/hashicorp/hcl2/gohcl"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hclpack"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type provider struct {
+	Name     string
+	Alias    string
+	Profile  string
+	Values   map[string]defaultValue
+	DefRange hcl.Range
+}
+
+// providerKey returns the key for a provider configuration, as it is referred
+// to by the provider meta-argument.
+func providerKey(name, alias string) string {
+	if alias == "" {
+		return name
+	}
+	return name + "." + alias
+}
+
+// decodeProvider decodes a provider block. All values in the block must be
+// statically known.
+func (d *Decoder) decodeProvider(block *hcl.Block) hcl.Diagnostics {
+	var p config.Provider
+	diags := gohcl.DecodeBody(block.Body, nil, &p)
+	if diags.HasErrors() {
+		return diags
+	}
+	p.Name = block.Labels[0]
+	key := providerKey(p.Name, p.Alias)
+
+	if ex, ok := d.providers[key]; ok {
+		return []*hcl.Diagnostic{{
+			Severity: hcl.DiagError,
+			Summary:  "Duplicate provider",
+			Detail: fmt.Sprintf(
+				"Another provider %q was defined in %s on line %d.",
+				key, ex.DefRange.Filename, ex.DefRange.Start.Line,
+			),
+			Subject: block.DefRange.Ptr(),
+		}}
+	}
+
+	attrs, diags := justAttributes(p.Config)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	res := &provider{
+		Name:     p.Name,
+		Alias:    p.Alias,
+		Profile:  p.Profile,
+		Values:   make(map[string]defaultValue, len(attrs)),
+		DefRange: block.DefRange,
+	}
+	for name, attr := range attrs {
+		if ok, morediags := d.checkVariables(attr.Expr); !ok {
+			diags = append(diags, morediags...)
+			continue
+		}
+		v, morediags := attr.Expr.Value(d.evalContext())
+		diags = append(diags, morediags...)
+		if morediags.HasErrors() {
+			continue
+		}
+		res.Values[name] = defaultValue{Value: v, Range: attr.Expr.Range()}
+	}
+	d.providers[key] = res
+
+	return diags
+}
+
+// justAttributes returns the attributes in a body.
+//
+// The attributes returned from hclpack.Body.JustAttributes all share a single
+// expression, so the attributes of a packed body are read directly.
+func justAttributes(body hcl.Body) (hcl.Attributes, hcl.Diagnostics) {
+	attrs, diags := body.JustAttributes()
+	packed, ok := body.(*hclpack.Body)
+	if !ok || diags.HasErrors() {
+		return attrs, diags
+	}
+	for name, a := range packed.Attributes {
+		a := a
+		attrs[name] = &hcl.Attribute{
+			Name:      name,
+			Expr:      &a.Expr,
+			Range:     a.Range,
+			NameRange: a.NameRange,
+		}
+	}
+	return attrs, diags
+}
+
+// decodeProviderRef decodes the provider meta-argument on a resource of the
+// given type. Returns the key of the selected provider, or an empty string if
+// the argument is not set.
+func (d *Decoder) decodeProviderRef(ex hcl.Expression, typename string) (string, hcl.Diagnostics) {
+	if ex == nil {
+		return "", nil
+	}
+	if len(ex.Variables()) == 0 {
+		if v, diags := ex.Value(nil); !diags.HasErrors() && v.IsNull() {
+			return "", nil
+		}
+	}
+	// Special case for hclpack.Expression: convert to hclsyntax.Expression.
+	if packexpr, ok := ex.(*hclpack.Expression); ok {
+		parsed, diags := packexpr.Parse()
+		if diags.HasErrors() {
+			return "", diags
+		}
+		ex = parsed
+	}
+	traversal, diags := hcl.AbsTraversalForExpr(ex)
+	var alias hcl.TraverseAttr
+	if len(traversal) == 2 {
+		alias, _ = traversal[1].(hcl.TraverseAttr)
+	}
+	if diags.HasErrors() || alias.Name == "" {
+		return "", []*hcl.Diagnostic{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid provider",
+			Detail:   "A reference to a provider is required, in the form <provider>.<alias>.",
+			Subject:  ex.Range().Ptr(),
+		}}
+	}
+	name := traversal.RootName()
+	if !strings.HasPrefix(typename, name+"_") {
+		return "", []*hcl.Diagnostic{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid provider",
+			Detail:   fmt.Sprintf("A resource of type %s cannot use a %s provider.", typename, name),
+			Subject:  ex.Range().Ptr(),
+		}}
+	}
+	key := providerKey(name, alias.Name)
+	if _, ok := d.providers[key]; !ok {
+		return "", []*hcl.Diagnostic{{
+			Severity: hcl.DiagError,
+			Summary:  "Provider not found",
+			Detail:   fmt.Sprintf("A provider %q with alias %q has not been defined.", name, alias.Name),
+			Subject:  ex.Range().Ptr(),
+		}}
+	}
+	return key, nil
+}
+
+// A defaultValue is a default value for a resource input.
+type defaultValue struct {
+	Value cty.Value
+	Range hcl.Range // Range to report diagnostics for the value in.
+}
+
+// providerConfig returns the provider configuration for a resource type. If key is
+// set, the selected provider is returned, otherwise the provider without an
+// alias. Returns nil if the provider has not been configured.
+func (d *Decoder) providerConfig(typename, key string) *provider {
+	if key == "" {
+		i := strings.Index(typename, "_")
+		if i < 0 {
+			return nil
+		}
+		key = typename[:i]
+	}
+	return d.providers[key]
+}
+
+// defaults returns the default input values for a resource type. Values set
+// in a provider block take precedence over the decoder's Defaults. If key is
+// set, the values are taken from the selected provider, otherwise from the
+// provider without an alias. Diagnostics for values from Defaults are
+// reported in rng.
+func (d *Decoder) defaults(typename, key string, rng hcl.Range) map[string]defaultValue {
+	i := strings.Index(typename, "_")
+	if i < 0 {
+		return nil
+	}
+	name := typename[:i]
+
+	out := make(map[string]defaultValue)
+	for k, v := range d.Defaults[name] {
+		out[k] = defaultValue{Value: v, Range: rng}
+	}
+	if p := d.providerConfig(typename, key); p != nil {
+		for k, v := range p.Values {
+			out[k] = v
+		}
+	}
+	return out
+}
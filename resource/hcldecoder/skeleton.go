@@ -0,0 +1,159 @@
+package hcldecoder
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/func/func/resource"
+	"github.com/func/func/resource/validation"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Skeleton returns a configuration template for a resource of the given type.
+//
+// Every input is listed with a placeholder value. A comment above the input
+// describes its type, whether it is required and the values allowed by its
+// validation rules, if any. For inputs with a fixed set of values, the first
+// value is used as the placeholder. Nested blocks are rendered with the inputs of the block. Inputs that
+// have a default value in Defaults are optional, the default value is
+// included in the comment.
+//
+// Returns false if the type is not supported.
+func (d *Decoder) Skeleton(typename string) ([]byte, bool) {
+	t := d.Resources.Type(typename)
+	if t == nil {
+		return nil, false
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "resource %q {\n", "example")
+	fmt.Fprintf(&buf, "type = %q\n", typename)
+	d.writeSkeleton(&buf, resource.Fields(t).Inputs(), d.defaults(typename, "", hcl.Range{}))
+	buf.WriteString("}\n")
+
+	return hclwrite.Format(buf.Bytes()), true
+}
+
+func (d *Decoder) writeSkeleton(buf *bytes.Buffer, fields resource.FieldSet, defaults map[string]defaultValue) { // nolint: lll
+	var attrs, blocks []string
+	for name, f := range fields {
+		switch {
+		case f.Type.Kind() == reflect.Interface:
+			// Not representable in config.
+		case d.isBlock(f.Type):
+			blocks = append(blocks, name)
+		default:
+			attrs = append(attrs, name)
+		}
+	}
+	sort.Strings(attrs)
+	sort.Strings(blocks)
+
+	for _, name := range attrs {
+		f := fields[name]
+		typ := resource.CtyType(f.Type)
+		desc := []string{typ.FriendlyNameForConstraint()}
+		def, hasDefault := defaults[name]
+		switch {
+		case hasDefault:
+			desc = append(desc, "default "+strings.TrimSpace(string(hclwrite.TokensForValue(def.Value).Bytes())))
+		case d.isRequired(f.Type):
+			desc = append(desc, "required")
+		default:
+			desc = append(desc, "optional")
+		}
+		c := validation.ParseConstraints(f.Tags["validate"])
+		desc = append(desc, describeConstraints(c, typ)...)
+		value := placeholder(typ)
+		if len(c.OneOf) > 0 {
+			value = literal(c.OneOf[0], typ)
+		}
+		separate(buf)
+		fmt.Fprintf(buf, "# %s\n", strings.Join(desc, ", "))
+		fmt.Fprintf(buf, "%s = %s\n", name, value)
+	}
+
+	for _, name := range blocks {
+		t := fields[name].Type
+		desc := "block, required"
+		switch t.Kind() {
+		case reflect.Ptr:
+			desc = "block, optional"
+		case reflect.Slice:
+			desc = "block, optional, repeatable"
+		}
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+		separate(buf)
+		fmt.Fprintf(buf, "# %s\n", desc)
+		fmt.Fprintf(buf, "%s {\n", name)
+		d.writeSkeleton(buf, resource.Fields(t), nil)
+		buf.WriteString("}\n")
+	}
+}
+
+// separate writes an empty line to separate an entry from the previous one,
+// unless the entry is the first one in a block.
+func separate(buf *bytes.Buffer) {
+	if !bytes.HasSuffix(buf.Bytes(), []byte("{\n")) {
+		buf.WriteByte('\n')
+	}
+}
+
+// describeConstraints describes the values allowed by validation rules.
+func describeConstraints(c validation.Constraints, typ cty.Type) []string {
+	var desc []string
+	if len(c.OneOf) > 0 {
+		values := make([]string, len(c.OneOf))
+		for i, v := range c.OneOf {
+			values[i] = literal(v, typ)
+		}
+		desc = append(desc, "one of "+strings.Join(values, " "))
+	}
+	bound := ""
+	if typ != cty.Number {
+		bound = "length "
+	}
+	if c.Min != "" {
+		desc = append(desc, "min "+bound+c.Min)
+	}
+	if c.Max != "" {
+		desc = append(desc, "max "+bound+c.Max)
+	}
+	if len(c.Other) > 0 {
+		desc = append(desc, "validate "+strings.Join(c.Other, ","))
+	}
+	return desc
+}
+
+// literal returns a value from a validation rule as it would be written in
+// a config file.
+func literal(value string, typ cty.Type) string {
+	if typ == cty.String {
+		return fmt.Sprintf("%q", value)
+	}
+	return value
+}
+
+// placeholder returns an empty value for the given type, as it would be
+// written in a config file.
+func placeholder(typ cty.Type) string {
+	switch {
+	case typ == cty.String:
+		return `""`
+	case typ == cty.Number:
+		return "0"
+	case typ == cty.Bool:
+		return "false"
+	case typ.IsListType(), typ.IsSetType(), typ.IsTupleType():
+		return "[]"
+	default:
+		return "{}"
+	}
+}
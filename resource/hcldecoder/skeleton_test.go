@@ -0,0 +1,126 @@
+package hcldecoder_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/func/func/resource"
+	"github.com/func/func/resource/hcldecoder"
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDecoder_Skeleton(t *testing.T) {
+	type item struct {
+		Key   string
+		Value *string
+	}
+	tests := []struct {
+		name     string
+		def      interface{}
+		defaults map[string]map[string]cty.Value
+		want     string
+	}{
+		{
+			name: "Simple",
+			def: struct {
+				Name   string            `func:"input" validate:"min=1"`
+				Mode   string            `func:"input" validate:"oneof=FAST SLOW"`
+				Count  *int              `func:"input" validate:"min=1,max=10,div=2"`
+				Tags   map[string]string `func:"input"`
+				Region *string           `func:"input"`
+				Arn    string            `func:"output"`
+			}{},
+			defaults: map[string]map[string]cty.Value{
+				"test": {"region": cty.StringVal("eu-west-1")},
+			},
+			want: `resource "example" {
+  type = "test_def"
+
+  # number, optional, min 1, max 10, validate div=2
+  count = 0
+
+  # string, required, one of "FAST" "SLOW"
+  mode = "FAST"
+
+  # string, required, min length 1
+  name = ""
+
+  # string, default "eu-west-1"
+  region = ""
+
+  # map of string, optional
+  tags = {}
+}
+`,
+		},
+		{
+			name: "Nested",
+			def: struct {
+				Name   string `func:"input"`
+				Config *struct {
+					Enabled bool
+					Items   []item
+				} `func:"input"`
+				Items []item `func:"input"`
+			}{},
+			want: `resource "example" {
+  type = "test_def"
+
+  # string, required
+  name = ""
+
+  # block, optional
+  config {
+    # bool, required
+    enabled = false
+
+    # block, optional, repeatable
+    items {
+      # string, required
+      key = ""
+
+      # string, optional
+      value = ""
+    }
+  }
+
+  # block, optional, repeatable
+  items {
+    # string, required
+    key = ""
+
+    # string, optional
+    value = ""
+  }
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := &hcldecoder.Decoder{
+				Resources: &resource.Registry{Types: map[string]reflect.Type{
+					"test_def": reflect.TypeOf(tt.def),
+				}},
+				Defaults: tt.defaults,
+			}
+			got, ok := dec.Skeleton("test_def")
+			if !ok {
+				t.Fatal("Skeleton() ok = false")
+			}
+			if diff := cmp.Diff(string(got), tt.want); diff != "" {
+				t.Errorf("Skeleton() (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecoder_Skeleton_notSupported(t *testing.T) {
+	dec := &hcldecoder.Decoder{
+		Resources: &resource.Registry{},
+	}
+	if _, ok := dec.Skeleton("unknown"); ok {
+		t.Error("Skeleton() ok = true, want false")
+	}
+}
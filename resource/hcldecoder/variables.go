@@ -0,0 +1,238 @@
+package hcldecoder
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/func/func/config"
+	"github.com/func/func/suggest"
+	"github.com/hashicorp/hcl2/gohcl"
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// variable contains temporary data for a decoded variable.
+type variable struct {
+	Name     string
+	Value    cty.Value // Null if a value was not provided.
+	DefRange hcl.Range
+	Used     bool // True if the variable is referenced.
+}
+
+// decodeVariable decodes a variable block and resolves the value for it. The
+// value is taken from the values set on the decoder, falling back to the
+// default value.
+func (d *Decoder) decodeVariable(block *hcl.Block) hcl.Diagnostics {
+	var v config.Variable
+	diags := gohcl.DecodeBody(block.Body, nil, &v)
+	if diags.HasErrors() {
+		return diags
+	}
+	v.Name = block.Labels[0]
+
+	if !hclsyntax.ValidIdentifier(v.Name) {
+		return []*hcl.Diagnostic{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid variable name",
+			Detail:   "A variable name must start with a letter and only contain letters, digits, underscores and dashes.",
+			Subject:  block.LabelRanges[0].Ptr(),
+			Context:  block.DefRange.Ptr(),
+		}}
+	}
+
+	if ex, ok := d.vars[v.Name]; ok {
+		return []*hcl.Diagnostic{{
+			Severity: hcl.DiagError,
+			Summary:  "Duplicate variable",
+			Detail: fmt.Sprintf(
+				"Another variable %q was defined in %s on line %d.",
+				v.Name, ex.DefRange.Filename, ex.DefRange.Start.Line,
+			),
+			Subject: block.DefRange.Ptr(),
+		}}
+	}
+
+	res := &variable{
+		Name:     v.Name,
+		Value:    cty.NullVal(cty.DynamicPseudoType),
+		DefRange: block.DefRange,
+	}
+	d.vars[v.Name] = res
+
+	if val, ok := d.Variables[v.Name]; ok && !val.IsNull() {
+		res.Value = val
+		return diags
+	}
+
+	def, morediags := v.Default.Value(&hcl.EvalContext{Functions: d.functions()})
+	diags = append(diags, morediags...)
+	if morediags.HasErrors() {
+		return diags
+	}
+	if def.IsNull() {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "No value for required variable",
+			Detail: fmt.Sprintf(
+				"The variable %q does not have a default value, a value must be provided.",
+				v.Name,
+			),
+			Subject: block.DefRange.Ptr(),
+		})
+	}
+	res.Value = def
+
+	return diags
+}
+
+// evalContext returns the context to use for statically evaluating
+// expressions. Variables that do not have a value are not included.
+func (d *Decoder) evalContext() *hcl.EvalContext {
+	vars := make(map[string]cty.Value, len(d.vars))
+	for name, v := range d.vars {
+		if v.Value.IsNull() {
+			continue
+		}
+		vars[name] = v.Value
+	}
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(vars),
+		},
+		Functions: d.functions(),
+	}
+	if d.each != cty.NilVal {
+		ctx.Variables["each"] = d.each
+	}
+	if data := d.dataValue(); data != cty.NilVal {
+		ctx.Variables["data"] = data
+	}
+	return ctx
+}
+
+// checkVariables checks that all variables referred to in the expression
+// have been declared and have a value. Data referred to is checked with
+// checkData.
+//
+// If false is returned, the expression cannot be evaluated. If a variable is
+// declared but does not have a value, a diagnostic has already been produced
+// for the declaration, so false is returned without diagnostics.
+func (d *Decoder) checkVariables(ex hcl.Expression) (bool, hcl.Diagnostics) {
+	ok := true
+	var diags hcl.Diagnostics
+	for _, traversal := range ex.Variables() {
+		if traversal.RootName() == "data" {
+			dataOK, morediags := d.checkData(traversal)
+			ok = ok && dataOK
+			diags = append(diags, morediags...)
+			continue
+		}
+		if traversal.RootName() != "var" {
+			continue
+		}
+		var attr hcl.TraverseAttr
+		if len(traversal) > 1 {
+			attr, _ = traversal[1].(hcl.TraverseAttr)
+		}
+		if attr.Name == "" {
+			ok = false
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid variable reference",
+				Detail:   "A variable is referred to as var.<name>.",
+				Subject:  traversal.SourceRange().Ptr(),
+			})
+			continue
+		}
+		v, declared := d.vars[attr.Name]
+		if declared {
+			v.Used = true
+		}
+		if !declared {
+			ok = false
+			diag := &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Undeclared variable",
+				Detail:   fmt.Sprintf("A variable named %q has not been declared.", attr.Name),
+				Subject:  traversal.SourceRange().Ptr(),
+			}
+			names := make([]string, 0, len(d.vars))
+			for name := range d.vars {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if s := suggest.String(attr.Name, names); s != "" {
+				diag.Detail += fmt.Sprintf(" Did you mean %q?", s)
+			}
+			diags = append(diags, diag)
+			continue
+		}
+		if v.Value.IsNull() {
+			ok = false
+		}
+	}
+	return ok, diags
+}
+
+// markUsed marks the variables referred to in the expression as used.
+func (d *Decoder) markUsed(ex hcl.Expression) {
+	for _, traversal := range ex.Variables() {
+		if traversal.RootName() != "var" || len(traversal) < 2 {
+			continue
+		}
+		attr, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		if v, declared := d.vars[attr.Name]; declared {
+			v.Used = true
+		}
+	}
+}
+
+// checkUnusedVariables produces errors for declared variables that have not
+// been referenced, and for values set for variables that have not been
+// declared.
+func (d *Decoder) checkUnusedVariables() hcl.Diagnostics {
+	declared := make([]string, 0, len(d.vars))
+	for name := range d.vars {
+		declared = append(declared, name)
+	}
+	sort.Strings(declared)
+
+	var diags hcl.Diagnostics
+	for _, name := range declared {
+		v := d.vars[name]
+		if v.Used {
+			continue
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unused variable",
+			Detail:   fmt.Sprintf("The variable %q is declared but never used.", name),
+			Subject:  v.DefRange.Ptr(),
+		})
+	}
+
+	supplied := make([]string, 0, len(d.Variables))
+	for name := range d.Variables {
+		supplied = append(supplied, name)
+	}
+	sort.Strings(supplied)
+	for _, name := range supplied {
+		if _, ok := d.vars[name]; ok {
+			continue
+		}
+		diag := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Value for undeclared variable",
+			Detail:   fmt.Sprintf("A value was set for %q but a variable with that name has not been declared.", name),
+		}
+		if s := suggest.String(name, declared); s != "" {
+			diag.Detail += fmt.Sprintf(" Did you mean %q?", s)
+		}
+		diags = append(diags, diag)
+	}
+	return diags
+}
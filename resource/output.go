@@ -0,0 +1,75 @@
+package resource
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// An Output is a named value that is exposed to the user after resources have
+// been reconciled.
+type Output struct {
+	// Name is the name of the output.
+	Name string
+
+	// Expression is the expression that produces the output value. The
+	// expression may refer to inputs and outputs of resources in the graph.
+	Expression Expression
+
+	// Sensitive is set if the output value must not be displayed to the
+	// user. The value is still resolved and returned from ResolveOutputs.
+	Sensitive bool
+}
+
+// ResolveOutputs resolves output values from deployed resources.
+//
+// References in the output expressions are resolved against the inputs and
+// outputs of the deployed resources. An error is returned if an output
+// refers to a resource that has not been deployed, or if an expression
+// cannot be evaluated.
+func ResolveOutputs(outputs []*Output, deployed []*Deployed) (map[string]cty.Value, error) {
+	vars := make(map[string]cty.Value, len(deployed))
+	for _, res := range deployed {
+		if !res.Exists() {
+			continue
+		}
+		vars[res.Name] = deployedValue(res)
+	}
+	ctx := &EvalContext{Variables: vars}
+
+	values := make(map[string]cty.Value, len(outputs))
+	for _, o := range outputs {
+		for _, ref := range o.Expression.References() {
+			root, ok := ref[0].(cty.GetAttrStep)
+			if !ok {
+				return nil, fmt.Errorf("output %s: invalid reference", o.Name)
+			}
+			name := root.Name
+			if _, ok := vars[name]; !ok {
+				return nil, fmt.Errorf("output %s: resource %q has not been deployed", o.Name, name)
+			}
+		}
+		v, err := o.Expression.Value(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "output %s", o.Name)
+		}
+		values[o.Name] = v
+	}
+	return values, nil
+}
+
+// deployedValue returns an object containing both the inputs and outputs of
+// a deployed resource.
+func deployedValue(res *Deployed) cty.Value {
+	attrs := make(map[string]cty.Value)
+	for _, v := range []cty.Value{res.Input, res.Output} {
+		if v == cty.NilVal || v.IsNull() || !v.Type().IsObjectType() {
+			continue
+		}
+		for k, av := range v.AsValueMap() {
+			attrs[k] = av
+		}
+	}
+	return cty.ObjectVal(attrs)
+}
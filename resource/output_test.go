@@ -0,0 +1,92 @@
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/func/func/resource"
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestResolveOutputs(t *testing.T) {
+	deployed := []*resource.Deployed{
+		{
+			Desired: &resource.Desired{
+				Name:  "foo",
+				Type:  "a",
+				Input: cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("hello")}),
+			},
+			Output: cty.ObjectVal(map[string]cty.Value{
+				"arn":   cty.StringVal("arn:foo"),
+				"ports": cty.ListVal([]cty.Value{cty.NumberIntVal(80), cty.NumberIntVal(443)}),
+			}),
+		},
+	}
+	outputs := []*resource.Output{
+		{
+			Name: "literal",
+			Expression: resource.Expression{
+				resource.ExprLiteral{Value: cty.StringVal("static")},
+			},
+		},
+		{
+			Name: "input",
+			Expression: resource.Expression{
+				resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("name")},
+			},
+		},
+		{
+			Name: "output",
+			Expression: resource.Expression{
+				resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("arn")},
+			},
+		},
+		{
+			Name: "index",
+			Expression: resource.Expression{
+				resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("ports").Index(cty.NumberIntVal(1))},
+			},
+		},
+		{
+			Name: "template",
+			Expression: resource.Expression{
+				resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("name")},
+				resource.ExprLiteral{Value: cty.StringVal(": ")},
+				resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("arn")},
+			},
+		},
+	}
+
+	got, err := resource.ResolveOutputs(outputs, deployed)
+	if err != nil {
+		t.Fatalf("ResolveOutputs() error = %v", err)
+	}
+	want := map[string]cty.Value{
+		"literal":  cty.StringVal("static"),
+		"input":    cty.StringVal("hello"),
+		"output":   cty.StringVal("arn:foo"),
+		"index":    cty.NumberIntVal(443),
+		"template": cty.StringVal("hello: arn:foo"),
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool { return a.RawEquals(b) }),
+	}
+	if diff := cmp.Diff(got, want, opts...); diff != "" {
+		t.Errorf("ResolveOutputs() (-got +want)\n%s", diff)
+	}
+}
+
+func TestResolveOutputs_notDeployed(t *testing.T) {
+	outputs := []*resource.Output{
+		{
+			Name: "foo",
+			Expression: resource.Expression{
+				resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("arn")},
+			},
+		},
+	}
+	_, err := resource.ResolveOutputs(outputs, nil)
+	if err == nil {
+		t.Fatal("ResolveOutputs() want error")
+	}
+}
@@ -0,0 +1,111 @@
+package reconciler
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/func/func/resource"
+)
+
+// authSet contains the auth caches for a run, one for the default
+// credentials and one for every credentials profile used.
+type authSet struct {
+	def         *authCache
+	profileAuth func(profile string) resource.AuthProvider
+
+	mu       sync.Mutex
+	profiles map[string]*authCache
+}
+
+func newAuthSet(auth resource.AuthProvider, profileAuth func(profile string) resource.AuthProvider) *authSet {
+	return &authSet{
+		def:         newAuthCache(auth),
+		profileAuth: profileAuth,
+		profiles:    make(map[string]*authCache),
+	}
+}
+
+// Profile returns the auth cache for a credentials profile. If the profile
+// is empty, the default credentials are used.
+func (s *authSet) Profile(profile string) *authCache {
+	if profile == "" {
+		return s.def
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.profiles[profile]
+	if !ok {
+		c = newAuthCache(s.profileAuth(profile))
+		s.profiles[profile] = c
+	}
+	return c
+}
+
+// authCache wraps an auth provider for the duration of a run. Credentials are
+// resolved once and API clients created by providers are cached by key, so
+// resources in the same region can share clients.
+type authCache struct {
+	auth resource.AuthProvider
+
+	credsMu sync.Mutex
+	creds   aws.CredentialsProvider
+
+	mu      sync.Mutex
+	clients map[string]*cachedClient
+}
+
+type cachedClient struct {
+	once   sync.Once
+	client interface{}
+	err    error
+}
+
+func newAuthCache(auth resource.AuthProvider) *authCache {
+	return &authCache{
+		auth:    auth,
+		clients: make(map[string]*cachedClient),
+	}
+}
+
+// AWS returns AWS credentials. The credentials are resolved from the wrapped
+// provider on the first successful call.
+func (a *authCache) AWS() (aws.CredentialsProvider, error) {
+	a.credsMu.Lock()
+	defer a.credsMu.Unlock()
+	if a.creds != nil {
+		return a.creds, nil
+	}
+	creds, err := a.auth.AWS()
+	if err != nil {
+		return nil, err
+	}
+	a.creds = creds
+	return creds, nil
+}
+
+// CachedClient implements resource.ClientCache.
+//
+// Concurrent calls with the same key wait for the first call to create the
+// client. Failed attempts are not cached.
+func (a *authCache) CachedClient(key string, create func() (interface{}, error)) (interface{}, error) {
+	a.mu.Lock()
+	c, ok := a.clients[key]
+	if !ok {
+		c = &cachedClient{}
+		a.clients[key] = c
+	}
+	a.mu.Unlock()
+
+	c.once.Do(func() {
+		c.client, c.err = create()
+	})
+	if c.err != nil {
+		a.mu.Lock()
+		if a.clients[key] == c {
+			delete(a.clients, key)
+		}
+		a.mu.Unlock()
+		return nil, c.err
+	}
+	return c.client, nil
+}
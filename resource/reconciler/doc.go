@@ -22,12 +22,35 @@
 //
 //        - When no resource exists for the type-name combination, it is created.
 //
+//      Input values listed in the resource's IgnoreChanges are not compared.
+//      If the resource is updated due to other changes, the previously
+//      deployed values are kept for the ignored inputs. The same applies to
+//      optional inputs listed in Unset, which were not set in the config.
+//
+//      If the resource implements resource.Comparer, it decides whether
+//      changed input values require an update.
+//
+//      If an input marked with `func:"input,force_new"` changed, the resource
+//      cannot be updated in place and is replaced instead: the existing
+//      resource is deleted and a new one is created. Dependents are processed
+//      after the replacement has been created, so they are updated with the
+//      new outputs.
+//
+//      If the resource implements resource.Waiter, the reconciler waits for
+//      it to become ready after it has been created or updated. Dependents
+//      are only processed once the resource is ready.
+//
 //   3. Delete resources
 //
 //      Resources that were not matched in the create/update phase are cleaned up.
 //      Thus, resources are always created in the desired state, before
 //      anything gets removed.
 //
+//      The delete step is skipped if NoDelete is set on the Reconciler.
+//
+//      If any of the resources to delete has PreventDestroy set, reconcile
+//      fails before any resources are created, updated or deleted.
+//
 // Concurrency
 //
 // When possible, changes are performed concurrently.
@@ -43,9 +66,107 @@
 //       A -> B -> D
 //         \- C -/
 //
+// The order in which resources that are ready at the same time start is
+// arbitrary. If Deterministic is set, they start in order of name instead.
+//
+// Validation
+//
+// Input values that depend on other resources are only known when the
+// resource is processed. If a Validator is set, such values are validated
+// against the validate rule on the input field after they have been resolved.
+// If the value is invalid, processing the resource fails.
+//
+// Resources that implement resource.Validator are validated as a whole once
+// all input values are known, before they are created or updated.
+//
+// Cancellation
+//
+// When the context passed to Reconcile is cancelled, no new operations are
+// started. Operations in flight receive the cancelled context and are
+// expected to return promptly. Reconcile returns after all operations have
+// returned, with an error caused by the context's error. Results from
+// operations that completed are stored.
+//
+// A resource is stored as soon as it has been created or updated, before its
+// children are processed. If a reconcile fails or the process exits, the
+// resources that were completed are known to the next reconcile and are not
+// created again.
+//
+// Project tags
+//
+// With ProjectTags set, resources that have a tags input are tagged with the
+// name of the project and the module the resource was declared in. The tags
+// are merged into the input before the resource is created or updated, tags
+// set in the configuration are not overridden.
+//
+// Moved resources
+//
+// If the graph implements MoveGraph, existing resources that have been
+// renamed are stored with their new name before any changes are computed.
+// The renamed resource is then updated like any other resource, instead of
+// being deleted and created with the new name.
+//
+// Missing resources
+//
+// If the resource storage implements ResourceGetter, resources are checked to
+// still exist in the state before they are updated. A resource may have been
+// removed from the state by a concurrent reconciliation after the existing
+// resources were listed. By default, processing the resource fails with
+// ErrNotInState. If RecreateMissing is set, the resource is created instead.
+//
+// Refresh
+//
+// Refresh compares the stored state of deployed resources with the live
+// state, as read by resources that implement resource.Reader. Resources that
+// have drifted are returned with the paths to the values that changed. The
+// stored state is not modified.
+//
+// If RefreshBeforeApply is set on the Reconciler, Reconcile refreshes existing
+// resources the same way before applying changes, and uses the live state in
+// place of the stored state. This corrects drift at the cost of a read per
+// resource. Without it, only the stored state is used: a resource that was
+// changed outside of func is not updated until its config changes.
+//
+// Import
+//
+// ImportAll adds resources that were created outside of func to the state of
+// a project. The definition lists the resources of its type that exist, and
+// each one is imported with the inputs and outputs read from the provider.
+// The imported resources are named after their ids; a suffix is added if a
+// name is already in use. To manage an imported resource, add it to the
+// config with the same name. Imported resources are not deleted by Reconcile
+// until they have been claimed by the config.
+//
 // Retries
 //
 // All operations are retried with exponential backoff. If a non-retryiable
 // error occurs, the resource definition should wrap the returned error with
 // backoff.PermanentError(err).
+//
+// By default, retries are randomized and stop after
+// backoff.DefaultMaxElapsedTime. The total retry duration can be changed by
+// setting MaxRetryDuration on the Reconciler.
+//
+// Metrics
+//
+// If Metrics is set on the Reconciler, the duration and result of every
+// operation is reported per resource type, as is every retry. The metrics
+// are aggregate; use the Logger for details about individual resources.
+//
+// Hooks
+//
+// BeforeApply and AfterApply are called around every create, update and
+// replace, in dependency order. Unlike metrics, BeforeApply can veto a
+// change by returning an error, for example to enforce an approval gate.
+// A vetoed resource is left as is, and resources that depend on it are
+// skipped. The rest of the graph is reconciled and Reconcile returns a
+// *VetoError listing the vetoed and skipped resources.
+//
+// Quarantine
+//
+// If Quarantine is set, a failure to create or update a resource is recorded
+// in the stored state. Later reconciliations skip the resource, and the
+// resources that depend on it, until the quarantine has passed since the last
+// failure, in the same way as a vetoed resource. A context returned from
+// WithRetry attempts the named resources again regardless.
 package reconciler
@@ -0,0 +1,87 @@
+package reconciler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+)
+
+// errSkipped is returned from processing a resource that was not applied,
+// because BeforeApply vetoed the resource or one of its parents, or the
+// resource or one of its parents is quarantined.
+var errSkipped = errors.New("skipped")
+
+// isSkipped returns true if err is caused by a skipped resource.
+func isSkipped(err error) bool {
+	return errors.Cause(err) == errSkipped
+}
+
+// A VetoError is returned from Reconcile if BeforeApply vetoed changes to
+// one or more resources, or one or more resources are quarantined. Resources
+// that do not depend on a vetoed resource were reconciled.
+type VetoError struct {
+	// Vetoed contains the errors returned from BeforeApply, keyed by
+	// resource name. Quarantined resources are included with the last
+	// recorded failure.
+	Vetoed map[string]error
+
+	// Skipped contains the sorted names of the resources that were not
+	// processed because they depend on a vetoed resource.
+	Skipped []string
+}
+
+func (e *VetoError) Error() string {
+	names := make([]string, 0, len(e.Vetoed))
+	for name := range e.Vetoed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = fmt.Sprintf("%s: %v", name, e.Vetoed[name])
+	}
+	str := "vetoed " + strings.Join(msgs, ", ")
+	if len(e.Skipped) > 0 {
+		str += "; skipped dependents " + strings.Join(e.Skipped, ", ")
+	}
+	return str
+}
+
+// veto records that BeforeApply vetoed a resource.
+func (r *run) veto(name string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.vetoed == nil {
+		r.vetoed = make(map[string]error)
+	}
+	r.vetoed[name] = err
+}
+
+// skip records that a resource was skipped because it depends on a vetoed
+// resource. A previously deployed version of the resource is kept.
+func (r *run) skip(res *resource.Desired) error {
+	r.takeExisting(res)
+	r.takeFailed(res)
+
+	r.mu.Lock()
+	r.skipped = append(r.skipped, res.Name)
+	r.mu.Unlock()
+
+	return errSkipped
+}
+
+// vetoError returns the error to return from Reconcile if resources were
+// vetoed, or nil if no resources were vetoed.
+func (r *run) vetoError() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.vetoed) == 0 {
+		return nil
+	}
+	skipped := append([]string(nil), r.skipped...)
+	sort.Strings(skipped)
+	return &VetoError{Vetoed: r.vetoed, Skipped: skipped}
+}
@@ -0,0 +1,167 @@
+package reconciler
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/func/func/ctyext"
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"go.uber.org/zap"
+)
+
+// ImportAll imports all resources of the given type that exist in the
+// provider into the state of a project. The imported resources are returned.
+//
+// The definition for the type must implement resource.Lister. The resources
+// are named after their import ids. If a name is already in use, a numeric
+// suffix is added. Resources that have already been imported, with the same
+// inputs and outputs as a resource in the state, are skipped.
+//
+// The resources are stored with Imported set, so they are not deleted by a
+// reconcile until a resource with the same name is added to the config.
+func (r *Reconciler) ImportAll(ctx context.Context, id, proj, typename string) ([]*resource.Deployed, error) {
+	run := r.newRun(id, proj, nil)
+	logger := run.Logger.With(zap.String("type", typename))
+
+	logger.Info("Import", zap.String("project", proj))
+
+	defType := r.Registry.Type(typename)
+	if defType == nil {
+		return nil, errors.Errorf("type not registered: %q", typename)
+	}
+	lister, ok := reflect.New(defType.Elem()).Interface().(resource.Lister)
+	if !ok {
+		return nil, errors.Errorf("type %q does not support import", typename)
+	}
+
+	if err := run.GetExisting(ctx); err != nil {
+		return nil, errors.Wrap(err, "get existing resources")
+	}
+
+	auth := run.Auth.Profile("")
+
+	var importIDs []string
+	err := run.retry(ctx, logger, typename, "list", func() error {
+		var err error
+		importIDs, err = lister.List(ctx, &resource.ListRequest{Auth: auth})
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list")
+	}
+
+	logger.Debug("Listed resources", zap.Int("count", len(importIDs)))
+
+	names := make(map[string]bool, len(run.existing))
+	for _, ex := range run.existing {
+		names[ex.Name] = true
+	}
+
+	fields := resource.Fields(defType)
+	var imported []*resource.Deployed
+	for _, importID := range importIDs {
+		logger := logger.With(zap.String("id", importID))
+
+		def := reflect.New(defType.Elem()).Interface().(resource.Importer)
+		req := &resource.ImportRequest{Auth: auth, ID: importID}
+		err := run.retry(ctx, logger, typename, "import", func() error {
+			return def.Import(ctx, req)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "import %s", importID)
+		}
+
+		input, err := ctyext.ToCtyValue(def, fields.Inputs().CtyType(), resource.FieldName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "import %s: convert input values", importID)
+		}
+		output, err := ctyext.ToCtyValue(def, fields.Outputs().CtyType(), resource.FieldName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "import %s: convert output values", importID)
+		}
+
+		if ex := run.findImported(typename, input, output); ex != nil {
+			logger.Info("Already imported", zap.String("name", ex.Name))
+			continue
+		}
+
+		name := importName(importID, names)
+		names[name] = true
+
+		deployed := &resource.Deployed{
+			Desired: &resource.Desired{
+				Name:  name,
+				Type:  typename,
+				Input: input,
+			},
+			ID:       run.IDGen.GenerateID(),
+			Output:   output,
+			Imported: true,
+		}
+
+		// Use new context so a cancelled context still stores the result.
+		pctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = run.retry(pctx, logger, typename, "put_state", func() error {
+			return run.Resources.PutResource(pctx, run.Project, deployed)
+		})
+		cancel()
+		if err != nil {
+			return nil, errors.Wrapf(err, "import %s: store resource", importID)
+		}
+
+		logger.Info("Imported", zap.String("name", name))
+		imported = append(imported, deployed)
+	}
+
+	logger.Info("Done", zap.Int("imported", len(imported)))
+
+	return imported, nil
+}
+
+// findImported returns the existing resource of the given type with the same
+// input and output, or nil if there is none.
+func (r *run) findImported(typename string, input, output cty.Value) *resource.Deployed {
+	for _, ex := range r.existing {
+		if ex.Type != typename {
+			continue
+		}
+		if ex.Input.RawEquals(input) && ex.Output.RawEquals(output) {
+			return ex
+		}
+	}
+	return nil
+}
+
+// importName returns a name for an imported resource that is not already
+// used.
+//
+// The name is based on the last segment of the import id, so an ARN such as
+// arn:aws:iam::123456789012:role/admin is named admin. Characters that are
+// not allowed in names are replaced with underscores.
+func importName(importID string, used map[string]bool) string {
+	base := importID
+	if i := strings.LastIndexAny(base, "/:"); i >= 0 && i < len(base)-1 {
+		base = base[i+1:]
+	}
+	base = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, base)
+	if base == "" || !unicode.IsLetter([]rune(base)[0]) {
+		base = "r_" + base
+	}
+
+	name := base
+	for i := 2; used[name]; i++ {
+		name = base + "_" + strconv.Itoa(i)
+	}
+	return name
+}
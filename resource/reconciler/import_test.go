@@ -0,0 +1,188 @@
+package reconciler_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/func/func/resource"
+	"github.com/func/func/resource/reconciler"
+	"github.com/func/func/storage/teststore"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/zclconf/go-cty/cty"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestReconciler_ImportAll(t *testing.T) {
+	val := func(name, arn string) (cty.Value, cty.Value) {
+		return cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal(name)}),
+			cty.ObjectVal(map[string]cty.Value{"arn": cty.StringVal(arn)})
+	}
+	deployed := func(name, typename, importName, arn, id string) *resource.Deployed {
+		input, output := val(importName, arn)
+		return &resource.Deployed{
+			Desired: &resource.Desired{
+				Name:  name,
+				Type:  typename,
+				Input: input,
+			},
+			ID:     id,
+			Output: output,
+		}
+	}
+
+	store := &teststore.Store{}
+	store.SeedResources("proj", []*resource.Deployed{
+		// Name collides with an imported resource.
+		deployed("b", "other", "b", "arn:other:b", "existing1"),
+		// Already imported.
+		deployed("c", "listed", "c", "arn:listed:role/c", "existing2"),
+	})
+	rec := &teststore.Recorder{Store: store}
+
+	reco := &reconciler.Reconciler{
+		Resources: rec,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"listed": &listed{},
+			"other":  &listed{},
+		}),
+		IDGen:  &sequence{},
+		Logger: zaptest.NewLogger(t),
+	}
+
+	got, err := reco.ImportAll(context.Background(), "", "proj", "listed")
+	if err != nil {
+		t.Fatalf("ImportAll() error = %v", err)
+	}
+
+	want := []*resource.Deployed{
+		deployed("a", "listed", "a", "arn:listed:role/a", "id0"),
+		deployed("b_2", "listed", "b", "arn:listed:role/b", "id1"),
+		deployed("a_2", "listed", "a", "arn:listed:group/a", "id2"),
+	}
+	for _, res := range want {
+		res.Imported = true
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool {
+			return a.RawEquals(b)
+		}),
+		cmpopts.IgnoreFields(resource.Deployed{}, "LastAppliedAt", "LastDuration"),
+	}
+	if diff := cmp.Diff(got, want, opts...); diff != "" {
+		t.Errorf("Imported (-got +want)\n%s", diff)
+	}
+
+	wantEvents := teststore.Events{
+		{Method: "ListResources", Project: "proj"},
+	}
+	for _, res := range want {
+		wantEvents = append(wantEvents, teststore.Event{Method: "PutResource", Project: "proj", Data: res})
+	}
+	if diff := cmp.Diff(rec.Events, wantEvents, opts...); diff != "" {
+		t.Errorf("Store events (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_ImportAll_reconcile(t *testing.T) {
+	store := &teststore.Store{}
+	rec := &teststore.Recorder{Store: store}
+
+	reco := &reconciler.Reconciler{
+		Resources: rec,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"listed": &listed{},
+		}),
+		IDGen:  &sequence{},
+		Logger: zaptest.NewLogger(t),
+	}
+
+	ctx := context.Background()
+	imported, err := reco.ImportAll(ctx, "", "proj", "listed")
+	if err != nil {
+		t.Fatalf("ImportAll() error = %v", err)
+	}
+
+	// The config claims a, the other imported resources are not in the
+	// config.
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{{
+			Name:  "a",
+			Type:  "listed",
+			Input: cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("a")}),
+		}},
+	}
+	rec.Events = nil
+	if err := reco.Reconcile(ctx, "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	claimed := *imported[0]
+	claimed.Imported = false
+	wantEvents := teststore.Events{
+		{Method: "ListResources", Project: "proj"},
+		{Method: "PutResource", Project: "proj", Data: &claimed},
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool {
+			return a.RawEquals(b)
+		}),
+		cmpopts.IgnoreFields(resource.Deployed{}, "LastAppliedAt", "LastDuration"),
+	}
+	if diff := cmp.Diff(rec.Events, wantEvents, opts...); diff != "" {
+		t.Errorf("Events (-got +want)\n%s", diff)
+	}
+
+	got, err := store.ListResources(ctx, "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if len(got) != len(imported) {
+		t.Errorf("Got %d resources in store, want %d", len(got), len(imported))
+	}
+}
+
+func TestReconciler_ImportAll_notSupported(t *testing.T) {
+	reco := &reconciler.Reconciler{
+		Resources: &teststore.Store{},
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"passthrough": &passthrough{},
+		}),
+		Logger: zaptest.NewLogger(t),
+	}
+
+	_, err := reco.ImportAll(context.Background(), "", "proj", "passthrough")
+	if err == nil {
+		t.Fatal("ImportAll() error = nil, want error")
+	}
+}
+
+// listed lists resources with arns as import ids.
+type listed struct {
+	Name string `func:"input"`
+	ARN  string `func:"output"`
+}
+
+func (p *listed) Create(ctx context.Context, req *resource.CreateRequest) error {
+	return nil
+}
+func (p *listed) Update(ctx context.Context, req *resource.UpdateRequest) error {
+	return nil
+}
+func (p *listed) Delete(ctx context.Context, req *resource.DeleteRequest) error {
+	return nil
+}
+func (p *listed) List(ctx context.Context, req *resource.ListRequest) ([]string, error) {
+	return []string{
+		"arn:listed:role/a",
+		"arn:listed:role/b",
+		"arn:listed:role/c",
+		"arn:listed:group/a",
+	}, nil
+}
+func (p *listed) Import(ctx context.Context, req *resource.ImportRequest) error {
+	p.Name = req.ID[strings.LastIndex(req.ID, "/")+1:]
+	p.ARN = req.ID
+	return nil
+}
@@ -0,0 +1,90 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// A MoveGraph is a Graph that contains renamed resources. The graph may
+// optionally implement it, in which case existing resources are renamed
+// before changes are computed.
+type MoveGraph interface {
+	MovedResources() []*resource.Move
+}
+
+// moveExisting renames existing resources that have been moved in the graph.
+// The renamed resource is stored, so it is matched with the desired resource
+// by its new name and is not deleted and created again.
+//
+// A move is ignored if no resource with the previous name exists, for
+// example if it has already been moved.
+func (r *run) moveExisting(ctx context.Context) error {
+	mg, ok := r.Graph.(MoveGraph)
+	if !ok {
+		return nil
+	}
+
+	for _, m := range mg.MovedResources() {
+		index := -1
+		for i, ex := range r.existing {
+			if ex.Name == m.To {
+				return errors.Errorf("cannot move %s to %s: %s already exists", m.From, m.To, m.To)
+			}
+			if ex.Name == m.From {
+				index = i
+			}
+		}
+		if index < 0 {
+			r.Logger.Debug("Nothing to move", zap.String("from", m.From), zap.String("to", m.To))
+			continue
+		}
+
+		ex := r.existing[index]
+		desired := *ex.Desired
+		desired.Name = m.To
+		moved := *ex
+		moved.Desired = &desired
+
+		logger := r.Logger.With(zap.String("type", ex.Type), zap.String("from", m.From), zap.String("to", m.To))
+		logger.Info("Move")
+
+		pctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err := r.retry(pctx, logger, ex.Type, "put_state", func() error {
+			return r.Resources.PutResource(pctx, r.Project, &moved)
+		})
+		cancel()
+		if err != nil {
+			return errors.Wrapf(err, "move %s to %s", m.From, m.To)
+		}
+
+		r.existing[index] = &moved
+		r.renameDeps(m.From, m.To)
+	}
+	return nil
+}
+
+// renameDeps replaces a renamed resource in the dependencies of existing
+// resources. The resources are copied, the change is not stored.
+func (r *run) renameDeps(from, to string) {
+	for i, ex := range r.existing {
+		changed := false
+		deps := make([]string, len(ex.Deps))
+		for j, dep := range ex.Deps {
+			if dep == from {
+				dep = to
+				changed = true
+			}
+			deps[j] = dep
+		}
+		if !changed {
+			continue
+		}
+		cpy := *ex
+		cpy.Deps = deps
+		r.existing[i] = &cpy
+	}
+}
@@ -0,0 +1,83 @@
+package reconciler
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/func/func/ctyext"
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var addressReplacer = strings.NewReplacer(".", "-", `["`, "-", `"]`, "", "[", "-", "]", "")
+
+// addressName returns the address of a resource with the given name in a
+// project, for use as a name in the provider. The address contains the
+// project, the namespace if the resource was declared in a module and the
+// resource name, separated by dashes. A for_each key is appended to the
+// resource name.
+func addressName(project, name string) string {
+	return project + "-" + addressReplacer.Replace(name)
+}
+
+// defaultName sets the default name on the input of a resource that
+// implements resource.Namer. Only inputs that were null are changed. If the
+// resource does not implement Namer, or its input is not fully known, input
+// is returned as is.
+func defaultName(typ reflect.Type, input cty.Value, addr string) (cty.Value, error) {
+	if !input.IsWhollyKnown() {
+		return input, nil
+	}
+	val := reflect.New(typ)
+	if err := ctyext.FromCtyValue(input, val.Interface(), resource.FieldName); err != nil {
+		return cty.NilVal, errors.Wrap(err, "set input")
+	}
+	namer, ok := val.Elem().Interface().(resource.Namer)
+	if !ok {
+		return input, nil
+	}
+	namer.DefaultName(addr)
+	return fillNull(typ, input, namer)
+}
+
+// setDefaults sets the computed defaults on the input of a resource that
+// implements resource.Defaulter. Only inputs that were null are changed. If
+// the resource does not implement Defaulter, or its input is not fully known,
+// input is returned as is.
+func setDefaults(typ reflect.Type, input cty.Value) (cty.Value, error) {
+	if !input.IsWhollyKnown() {
+		return input, nil
+	}
+	val := reflect.New(typ)
+	if err := ctyext.FromCtyValue(input, val.Interface(), resource.FieldName); err != nil {
+		return cty.NilVal, errors.Wrap(err, "set input")
+	}
+	defaulter, ok := val.Elem().Interface().(resource.Defaulter)
+	if !ok {
+		return input, nil
+	}
+	defaulter.SetDefaults()
+	return fillNull(typ, input, defaulter)
+}
+
+// fillNull returns input with the null attributes set from the inputs of def.
+func fillNull(typ reflect.Type, input cty.Value, def interface{}) (cty.Value, error) {
+	set, err := ctyext.ToCtyValue(def, resource.Fields(typ).Inputs().CtyType(), resource.FieldName)
+	if err != nil {
+		return cty.NilVal, errors.Wrap(err, "convert input values")
+	}
+
+	attrs := input.AsValueMap()
+	changed := false
+	for k, v := range set.AsValueMap() {
+		if cur, ok := attrs[k]; ok && cur.IsNull() && !v.IsNull() {
+			attrs[k] = v
+			changed = true
+		}
+	}
+	if !changed {
+		return input, nil
+	}
+	return cty.ObjectVal(attrs), nil
+}
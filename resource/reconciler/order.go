@@ -0,0 +1,162 @@
+package reconciler
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// scheduleOrder returns a stable processing order for the resources in the
+// graph, and the names of the parents of each resource. The returned order
+// contains the position of each resource, keyed by resource name.
+//
+// Resources are ordered topologically, parents always come before their
+// children. Resources that are ready at the same time are ordered by name.
+func scheduleOrder(g Graph) (order map[string]int, parents map[string][]string) {
+	// Collect all resources by walking up from the leaves.
+	parents = make(map[string][]string)
+	var walk func(name string, pp []string)
+	walk = func(name string, pp []string) {
+		if _, ok := parents[name]; ok {
+			return
+		}
+		parents[name] = pp
+		for _, p := range pp {
+			var grandparents []string
+			for _, gp := range g.ParentResources(p) {
+				grandparents = append(grandparents, gp.Name)
+			}
+			walk(p, grandparents)
+		}
+	}
+	for _, leaf := range g.LeafResources() {
+		var pp []string
+		for _, p := range g.ParentResources(leaf.Name) {
+			pp = append(pp, p.Name)
+		}
+		walk(leaf.Name, pp)
+	}
+
+	pending := make(map[string]int, len(parents))
+	children := make(map[string][]string)
+	var ready []string
+	for name, pp := range parents {
+		pending[name] = len(pp)
+		for _, p := range pp {
+			children[p] = append(children[p], name)
+		}
+		if len(pp) == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	order = make(map[string]int, len(parents))
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		name := ready[0]
+		ready = ready[1:]
+		order[name] = len(order)
+		for _, c := range children[name] {
+			pending[c]--
+			if pending[c] == 0 {
+				ready = append(ready, c)
+			}
+		}
+	}
+	return order, parents
+}
+
+// A turnstile orders the start of resources that are ready at the same time.
+//
+// A resource is ready when all of its parents are done. A resource may pass
+// once every other ready resource that comes before it in the schedule order
+// has passed. Resources that are not ready do not hold up others, so
+// independent branches of the graph do not wait for each other.
+type turnstile struct {
+	mu      sync.Mutex
+	order   map[string]int
+	parents map[string][]string
+	passed  map[string]bool
+	done    map[string]bool
+	changed chan struct{}
+}
+
+func newTurnstile(g Graph) *turnstile {
+	order, parents := scheduleOrder(g)
+	return &turnstile{
+		order:   order,
+		parents: parents,
+		passed:  make(map[string]bool, len(order)),
+		done:    make(map[string]bool, len(order)),
+		changed: make(chan struct{}),
+	}
+}
+
+// Wait blocks until it is the turn of the named resource, or the context is
+// cancelled. After passing, the caller must call Pass to let the next
+// resource through.
+func (t *turnstile) Wait(ctx context.Context, name string) error {
+	for {
+		t.mu.Lock()
+		if t.clear(name) {
+			t.mu.Unlock()
+			return nil
+		}
+		ch := t.changed
+		t.mu.Unlock()
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Pass marks the named resource as passed, letting the next resource through.
+func (t *turnstile) Pass(name string) {
+	t.mu.Lock()
+	t.passed[name] = true
+	t.broadcast()
+	t.mu.Unlock()
+}
+
+// Done marks the named resource as done. Its children become ready once all
+// their parents are done. A resource that is done does not hold up others,
+// even if it never passed.
+func (t *turnstile) Done(name string) {
+	t.mu.Lock()
+	t.passed[name] = true
+	t.done[name] = true
+	t.broadcast()
+	t.mu.Unlock()
+}
+
+// clear returns true if no ready resource before name is waiting to pass.
+// The caller must hold the lock.
+func (t *turnstile) clear(name string) bool {
+	n := t.order[name]
+	for other, m := range t.order {
+		if m < n && !t.passed[other] && t.ready(other) {
+			return false
+		}
+	}
+	return true
+}
+
+// ready returns true if all parents of the named resource are done. The
+// caller must hold the lock.
+func (t *turnstile) ready(name string) bool {
+	for _, p := range t.parents[name] {
+		if !t.done[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// broadcast wakes up all waiting callers. The caller must hold the lock.
+func (t *turnstile) broadcast() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
@@ -0,0 +1,154 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"go.uber.org/zap"
+)
+
+type retryKey struct{}
+
+// WithRetry returns a context that makes Reconcile attempt the given
+// resources again even if they are quarantined. The resources are given as
+// addresses accepted by resource.ParseAddress.
+func WithRetry(ctx context.Context, resources ...string) context.Context {
+	retry := make(map[string]bool, len(resources))
+	for _, name := range retryResources(ctx) {
+		retry[name] = true
+	}
+	for _, name := range resources {
+		retry[name] = true
+	}
+	list := make([]string, 0, len(retry))
+	for name := range retry {
+		list = append(list, name)
+	}
+	return context.WithValue(ctx, retryKey{}, list)
+}
+
+// retryResources returns the resources set with WithRetry.
+func retryResources(ctx context.Context) []string {
+	list, _ := ctx.Value(retryKey{}).([]string)
+	return list
+}
+
+// separateFailed moves resources that only record a failure to create them
+// from the existing resources to the failed resources. The resources do not
+// exist, so they must not be updated or deleted.
+func (r *run) separateFailed() {
+	existing := r.existing[:0]
+	for _, ex := range r.existing {
+		if !ex.Exists() {
+			r.failed = append(r.failed, ex)
+			continue
+		}
+		existing = append(existing, ex)
+	}
+	r.existing = existing
+}
+
+// takeFailed returns the recorded failure to create a resource, or nil if
+// there is none. The failure is removed from the failed resources, so it is
+// not removed from the stored state.
+func (r *run) takeFailed(res *resource.Desired) *resource.Deployed {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, f := range r.failed {
+		if f.Type == res.Type && f.Name == res.Name {
+			r.failed = append(r.failed[:i], r.failed[i+1:]...)
+			return f
+		}
+	}
+	return nil
+}
+
+// lastFailure returns the last recorded failure for a resource, from either
+// the existing resource or the failure to create it. Returns nil if the last
+// attempt to apply the resource did not fail.
+func lastFailure(existing, failed *resource.Deployed) *resource.Failure {
+	if existing != nil {
+		return existing.Failure
+	}
+	if failed != nil {
+		return failed.Failure
+	}
+	return nil
+}
+
+// quarantined returns true if a resource with the given failure must be
+// skipped. A resource that is retried with WithRetry is never skipped.
+func (r *run) quarantined(ctx context.Context, res *resource.Desired, f *resource.Failure) bool {
+	if r.Quarantine == 0 || time.Since(f.At) >= r.Quarantine {
+		return false
+	}
+	for _, name := range retryResources(ctx) {
+		addr, err := resource.ParseAddress(name)
+		if err == nil && addr.Matches(res) {
+			return false
+		}
+	}
+	return true
+}
+
+// recordFailure stores a failed attempt to apply a resource. If the resource
+// exists, the failure is added to the existing resource. Otherwise a resource
+// without outputs is stored to record the failure. A resource that was
+// applied but did not become ready is passed as both deployed and existing.
+//
+// Errors storing the failure are logged; the error from applying the
+// resource takes precedence.
+func (r *run) recordFailure(logger *zap.Logger, deployed, existing *resource.Deployed, prev *resource.Failure, err error) { // nolint: lll
+	var rec resource.Deployed
+	if existing != nil {
+		rec = *existing
+	} else {
+		rec = resource.Deployed{
+			Desired: deployed.Desired,
+			ID:      deployed.ID,
+			Output:  cty.NullVal(cty.EmptyObject),
+		}
+	}
+	rec.Failure = &resource.Failure{
+		Error:  err.Error(),
+		At:     time.Now(),
+		Count:  1,
+		Create: existing == nil,
+	}
+	if prev != nil {
+		rec.Failure.Count = prev.Count + 1
+	}
+
+	// Use new context so a cancelled context still stores the result.
+	pctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	logger.Debug("Storing failure", zap.Int("failures", rec.Failure.Count))
+	perr := r.retry(pctx, logger, deployed.Type, "put_state", func() error {
+		return r.Resources.PutResource(pctx, r.Project, &rec)
+	})
+	if perr != nil {
+		logger.Warn("Could not store failure", zap.Error(perr))
+	}
+}
+
+// removeFailed removes recorded failures to create resources that are no
+// longer in the desired graph. The resources do not exist, so only the
+// stored state is removed.
+func (r *run) removeFailed(ctx context.Context) error {
+	for _, f := range r.failed {
+		logger := r.Logger.With(zap.String("type", f.Type), zap.String("name", f.Name))
+		logger.Debug("Removing failure")
+		err := r.retry(ctx, logger, f.Type, "delete_state", func() error {
+			return r.Resources.DeleteResource(ctx, r.Project, f)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "remove failure for %s.%s", f.Type, f.Name)
+		}
+	}
+	r.failed = nil
+	return nil
+}
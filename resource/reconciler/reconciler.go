@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -24,6 +26,12 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
+// ErrNotInState is returned when a resource to update has been removed from
+// the stored state after the reconciliation started, for example by a
+// concurrent reconciliation. The error is wrapped with the resource type and
+// name, use errors.Cause to check for it.
+var ErrNotInState = errors.New("resource not found in state")
+
 // DefaultConcurrency is the default maximum concurrency to use.
 //
 // In practice, the reconciler is likely bound by network i/o.
@@ -36,6 +44,15 @@ type ResourceStorage interface {
 	ListResources(ctx context.Context, project string) ([]*resource.Deployed, error)
 }
 
+// A ResourceGetter gets a single resource by its ID. The resource storage may
+// optionally implement it, in which case the reconciler verifies that a
+// resource is still in the state before updating it.
+//
+// If the resource does not exist, a nil resource is returned.
+type ResourceGetter interface {
+	GetResource(ctx context.Context, project, id string) (*resource.Deployed, error)
+}
+
 // SourceStorage provides resource source code.
 type SourceStorage interface {
 	Get(ctx context.Context, filename string) (io.ReadCloser, error)
@@ -53,6 +70,27 @@ type Graph interface {
 	DependenciesOf(child string) []*resource.Dependency
 }
 
+// Metrics receives aggregate metrics about operations on resources, for
+// example to export them to Prometheus. The methods may be called
+// concurrently.
+type Metrics interface {
+	// ObserveOp is called when an operation on a resource of the given type
+	// has completed, including any retries. The op is create, update, wait,
+	// read or delete for operations on the resource, and put_state or
+	// delete_state for storing the result. The error is nil if the
+	// operation succeeded.
+	ObserveOp(resType, op string, d time.Duration, err error)
+
+	// IncRetry is called every time an operation on a resource of the given
+	// type is retried.
+	IncRetry(resType string)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveOp(string, string, time.Duration, error) {}
+func (nopMetrics) IncRetry(string)                                {}
+
 // An IDGenerator generates unique identifiers for created resources.
 type IDGenerator interface {
 	GenerateID() string
@@ -73,6 +111,18 @@ type Reconciler struct {
 	Registry  Registry
 	IDGen     IDGenerator
 
+	// Auth provides authentication for resources. The credentials and any
+	// API clients created by providers are cached for the duration of a
+	// reconciliation, so resources in the same region share clients. If not
+	// set, credentials are loaded from the local environment.
+	Auth resource.AuthProvider
+
+	// ProfileAuth returns the auth provider for resources that use a
+	// provider with a credentials profile. Credentials and clients are
+	// cached per profile. If not set, the credentials for the profile are
+	// loaded from the local environment.
+	ProfileAuth func(profile string) resource.AuthProvider
+
 	// Concurrency sets the maximum allowed concurrency to use.
 	// If not set, DefaultConcurrency is used.
 	Concurrency uint
@@ -80,12 +130,159 @@ type Reconciler struct {
 	// Logger logs reconciliation updates. If not set, logs are discarded.
 	Logger *zap.Logger
 
-	// Backoff algorithm used for retries. If not set, exponential backoff is used.
+	// Backoff algorithm used for retries. If not set, DefaultBackoff is used.
 	Backoff func() backoff.BackOff
+
+	// MaxRetryDuration sets the maximum total time to spend retrying an
+	// operation on a resource. If not set, backoff.DefaultMaxElapsedTime is
+	// used.
+	//
+	// MaxRetryDuration is ignored if Backoff is set.
+	MaxRetryDuration time.Duration
+
+	// NoDelete disables the delete phase. Existing resources that are not in
+	// the desired graph are left untouched, both in the stored state and in
+	// the real world. The state will therefore not reflect resources that
+	// were removed from the configuration, and they must be cleaned up by
+	// another reconciliation without NoDelete set.
+	NoDelete bool
+
+	// Validator validates input values that are resolved from dependencies
+	// when the resource is processed, using the validation rules set on the
+	// input fields. Values that are known when the config is decoded should
+	// be validated when decoding. If not set, resolved values are not
+	// validated.
+	Validator Validator
+
+	// RecreateMissing creates resources again if they have been removed from
+	// the stored state while an update was pending. If not set, the update
+	// fails with ErrNotInState.
+	RecreateMissing bool
+
+	// Deterministic makes resources that are ready to be processed at the
+	// same time start in a stable order, sorted by name. Processing is still
+	// concurrent; given identical timing, the order of operations is the
+	// same across runs.
+	Deterministic bool
+
+	// ProjectTags adds tags with the name of the project (func:project) and
+	// the module the resource was declared in (func:namespace) to every
+	// resource that has a tags input. Tags set in the configuration take
+	// precedence.
+	ProjectTags bool
+
+	// RefreshBeforeApply reads the live state of existing resources before
+	// applying changes, for resources that implement resource.Reader.
+	// Resources that were changed outside of func are then updated to match
+	// the config, and children use the live outputs. If not set, the stored
+	// state is assumed to be up to date, which avoids a read for every
+	// resource but leaves drift in place until the config changes.
+	RefreshBeforeApply bool
+
+	// Metrics receives metrics about operations on resources. If not set,
+	// metrics are not collected.
+	Metrics Metrics
+
+	// Quarantine sets how long a resource is skipped after creating or
+	// updating it failed. The failure is recorded in the stored state, and
+	// subsequent reconciliations skip the resource until the duration has
+	// passed since the last failure. Resources that depend on a quarantined
+	// resource are skipped too, and Reconcile returns a *VetoError. Use
+	// WithRetry to attempt a quarantined resource again. If not set,
+	// failures are not recorded.
+	Quarantine time.Duration
+
+	// BeforeApply is called before a resource is created, updated or
+	// replaced, with the desired resource and the operation: create, update
+	// or replace. It is not called for resources that do not need changes.
+	//
+	// If BeforeApply returns an error, the resource is left as is and the
+	// resources that depend on it are skipped. Other resources are still
+	// processed, after which Reconcile returns a *VetoError.
+	BeforeApply func(ctx context.Context, res *resource.Desired, op string) error
+
+	// AfterApply is called after a resource has been applied, with the same
+	// arguments as BeforeApply and the error from applying the resource, or
+	// nil if it succeeded.
+	AfterApply func(ctx context.Context, res *resource.Desired, op string, err error)
+}
+
+// DefaultBackoff returns the default backoff algorithm, exponential backoff
+// with jitter. Each interval is randomized by up to 50% in either direction,
+// so resources that fail at the same time are not retried in lockstep.
+//
+// If maxElapsed is set, the backoff stops after the given duration has
+// elapsed. If maxElapsed is 0, the backoff never stops.
+func DefaultBackoff(maxElapsed time.Duration) backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = maxElapsed
+	return b
 }
 
 // Reconcile reconciles changes to the graph.
 func (r *Reconciler) Reconcile(ctx context.Context, id, proj string, graph Graph) error {
+	run := r.newRun(id, proj, graph)
+	logger := run.Logger
+
+	logger.Info("Reconcile", zap.String("project", proj))
+
+	if err := run.GetExisting(ctx); err != nil {
+		return errors.Wrap(err, "get existing resources")
+	}
+
+	if r.RefreshBeforeApply {
+		if err := run.RefreshExisting(ctx); err != nil {
+			return errors.Wrap(err, "refresh existing resources")
+		}
+	}
+
+	// Abort before changing anything if a protected resource would be
+	// deleted.
+	if !r.NoDelete {
+		if err := run.checkPreventDestroy(); err != nil {
+			return err
+		}
+	}
+
+	if err := run.CreateUpdate(ctx); err != nil {
+		return cancelled(ctx, logger, err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return errors.Wrap(err, "cancelled")
+	}
+
+	if r.NoDelete {
+		logger.Debug("Delete disabled", zap.Int("skipped", len(run.existing)))
+	} else if err := run.RemovePrevious(ctx); err != nil {
+		return cancelled(ctx, logger, errors.Wrap(err, "remove previous resources"))
+	}
+
+	logger.Info(
+		"Done",
+		zap.Uint32("create", run.create),
+		zap.Uint32("update", run.update),
+		zap.Uint32("replace", run.replace),
+		zap.Uint32("delete", run.delete),
+	)
+
+	return run.vetoError()
+}
+
+// cancelled returns a cancellation error if ctx was cancelled. Operations that
+// were in flight when the context was cancelled typically fail with errors
+// caused by the cancellation, so the original error is only logged.
+// Otherwise err is returned as is.
+func cancelled(ctx context.Context, logger *zap.Logger, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+	logger.Info("Cancelled", zap.Error(err))
+	return errors.Wrap(ctx.Err(), "cancelled")
+}
+
+// newRun creates a new run, applying defaults to unset fields.
+func (r *Reconciler) newRun(id, proj string, graph Graph) *run {
 	logger := r.Logger
 	if logger == nil {
 		logger = zap.NewNop()
@@ -93,8 +290,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, id, proj string, graph Graph
 
 	algo := r.Backoff
 	if algo == nil {
+		maxElapsed := r.MaxRetryDuration
+		if maxElapsed == 0 {
+			maxElapsed = backoff.DefaultMaxElapsedTime
+		}
 		algo = func() backoff.BackOff {
-			return backoff.NewExponentialBackOff()
+			return DefaultBackoff(maxElapsed)
 		}
 	}
 
@@ -102,47 +303,60 @@ func (r *Reconciler) Reconcile(ctx context.Context, id, proj string, graph Graph
 		logger = logger.With(zap.String("id", id))
 	}
 
-	logger.Info("Reconcile", zap.String("project", proj))
-
 	c := r.Concurrency
 	if c == 0 {
 		c = uint(DefaultConcurrency)
 	}
 
-	run := run{
-		ID:        id,
-		Project:   proj,
-		Graph:     graph,
-		Resources: r.Resources,
-		Source:    r.Source,
-		Registry:  r.Registry,
-		Logger:    logger,
-		Backoff:   algo,
-		IDGen:     r.IDGen,
-		Sem:       semaphore.NewWeighted(int64(c)),
-		outputs:   make(map[string]cty.Value),
+	var auth resource.AuthProvider = tempLocalAuthProvider{}
+	if r.Auth != nil {
+		auth = r.Auth
 	}
-
-	if err := run.GetExisting(ctx); err != nil {
-		return errors.Wrap(err, "get existing resources")
+	profileAuth := r.ProfileAuth
+	if profileAuth == nil {
+		profileAuth = func(profile string) resource.AuthProvider {
+			return tempLocalAuthProvider{profile: profile}
+		}
 	}
 
-	if err := run.CreateUpdate(ctx); err != nil {
-		return err
+	var metrics Metrics = nopMetrics{}
+	if r.Metrics != nil {
+		metrics = r.Metrics
 	}
 
-	if err := run.RemovePrevious(ctx); err != nil {
-		return errors.Wrap(err, "remove previous resources")
+	var referenced map[string]map[string]bool
+	if graph != nil {
+		referenced = referencedOutputs(graph)
 	}
 
-	logger.Info(
-		"Done",
-		zap.Uint32("create", run.create),
-		zap.Uint32("update", run.update),
-		zap.Uint32("delete", run.delete),
-	)
+	var turn *turnstile
+	if r.Deterministic && graph != nil {
+		turn = newTurnstile(graph)
+	}
 
-	return nil
+	return &run{
+		ID:         id,
+		Project:    proj,
+		Graph:      graph,
+		Resources:  r.Resources,
+		Source:     r.Source,
+		Registry:   r.Registry,
+		Logger:     logger,
+		Backoff:    algo,
+		IDGen:      r.IDGen,
+		Validator:  r.Validator,
+		Recreate:   r.RecreateMissing,
+		Tags:       r.ProjectTags,
+		Metrics:    metrics,
+		Quarantine: r.Quarantine,
+		Before:     r.BeforeApply,
+		After:      r.AfterApply,
+		Sem:        semaphore.NewWeighted(int64(c)),
+		Auth:       newAuthSet(auth, profileAuth),
+		outputs:    make(map[string]cty.Value),
+		refs:       referenced,
+		turn:       turn,
+	}
 }
 
 type run struct {
@@ -150,21 +364,36 @@ type run struct {
 	Project string
 	Graph   Graph
 
-	Resources ResourceStorage
-	Source    SourceStorage
-	Registry  Registry
-	Logger    *zap.Logger
-	Backoff   func() backoff.BackOff
-	Sem       *semaphore.Weighted
-	IDGen     IDGenerator
+	Resources  ResourceStorage
+	Source     SourceStorage
+	Registry   Registry
+	Logger     *zap.Logger
+	Backoff    func() backoff.BackOff
+	Sem        *semaphore.Weighted
+	IDGen      IDGenerator
+	Validator  Validator
+	Recreate   bool
+	Tags       bool
+	Auth       *authSet
+	Metrics    Metrics
+	Quarantine time.Duration
+	Before     func(ctx context.Context, res *resource.Desired, op string) error
+	After      func(ctx context.Context, res *resource.Desired, op string, err error)
 
 	mu       sync.RWMutex
 	existing []*resource.Deployed // Existing resource from a previous deployment.
+	failed   []*resource.Deployed // Resources that failed to be created in a previous deployment.
 	outputs  map[string]cty.Value
+	refs     map[string]map[string]bool // Outputs referenced by children, keyed by parent name.
 
 	tasks *task.Group // Maintains a list of actively processing resources.
 
-	create, update, delete uint32
+	turn *turnstile // Enforces order. Nil if not deterministic.
+
+	vetoed  map[string]error // Errors from BeforeApply, keyed by resource name.
+	skipped []string         // Resources skipped due to a vetoed parent.
+
+	create, update, replace, delete uint32
 }
 
 func (r *run) GetExisting(ctx context.Context) error {
@@ -175,6 +404,10 @@ func (r *run) GetExisting(ctx context.Context) error {
 	}
 	r.existing = ex
 	r.Logger.Debug("Got existing", zap.Int("count", len(ex)))
+	if err := r.moveExisting(ctx); err != nil {
+		return err
+	}
+	r.separateFailed()
 	return nil
 }
 
@@ -189,7 +422,10 @@ func (r *run) CreateUpdate(ctx context.Context) error {
 	for _, res := range leaves {
 		res := res
 		g.Go(func() error {
-			return r.processResource(ctx, res)
+			if err := r.processResource(ctx, res); err != nil && !isSkipped(err) {
+				return err
+			}
+			return nil
 		})
 	}
 
@@ -198,8 +434,15 @@ func (r *run) CreateUpdate(ctx context.Context) error {
 
 func (r *run) processResource(ctx context.Context, res *resource.Desired) error {
 	logger := r.Logger.With(zap.String("type", res.Type), zap.String("name", res.Name))
+	if res.Description != "" {
+		logger = logger.With(zap.String("description", res.Description))
+	}
+
+	return r.tasks.Do(res.Name, func() (err error) {
+		if r.turn != nil {
+			defer r.turn.Done(res.Name)
+		}
 
-	return r.tasks.Do(res.Name, func() error {
 		deployed := &resource.Deployed{
 			Desired: res,
 		}
@@ -209,41 +452,37 @@ func (r *run) processResource(ctx context.Context, res *resource.Desired) error
 		// block on low concurrency limits, and end up in a deadlock with
 		// concurrency=1.
 		if err := r.processDependencies(ctx, res.Name, logger); err != nil {
+			if isSkipped(err) {
+				logger.Info("Skipped, depends on vetoed resource")
+				return r.skip(res)
+			}
 			return errors.Wrap(err, "process dependencies")
 		}
 
 		// Ready to process, wait for semaphore.
-		err := r.Sem.Acquire(ctx, 1)
-		if err != nil {
+		if err := r.acquire(ctx, res.Name); err != nil {
 			return errors.Wrap(err, "acquire semaphore")
 		}
 		defer r.Sem.Release(1)
 
+		// The semaphore may be acquired without blocking after the context
+		// has been cancelled. Do not start new operations.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		// Create definition
 		defType := r.Registry.Type(res.Type)
 		if defType == nil {
 			return errors.Errorf("type not registered: %q", res.Type)
 		}
 
-		if err := r.resolveDependencies(res); err != nil {
+		if err := r.resolveDependencies(res, defType); err != nil {
 			return errors.Wrap(err, "resolve dependencies")
 		}
 
 		logger.Debug("Processing")
 
-		// Compute hash based on current inputs.
-		hash := res.Input.Hash()
-		logger = logger.With(zap.Int("hash", hash))
-
-		// Insert config into definition.
-		val := reflect.New(defType)
-		if err := ctyext.FromCtyValue(res.Input, val.Interface(), resource.FieldName); err != nil {
-			return errors.Wrap(err, "set input")
-		}
-		def := val.Elem().Interface().(resource.Definition)
-
-		logger.Debug("Config resolved")
-
 		// Collect sources.
 		sourceList := make([]resource.SourceCode, len(res.Sources))
 		for i, src := range res.Sources {
@@ -256,25 +495,64 @@ func (r *run) processResource(ctx context.Context, res *resource.Desired) error
 		}
 
 		// Find existing.
-		r.mu.Lock()
-		var existing *resource.Deployed
-		for i, ex := range r.existing {
-			if ex.Type == res.Type && ex.Name == res.Name {
-				existing = ex
+		existing := r.takeExisting(res)
+		failed := r.takeFailed(res)
+
+		if f := lastFailure(existing, failed); f != nil && r.quarantined(ctx, res, f) {
+			logger.Info("Quarantined", zap.Time("failed_at", f.At), zap.Int("failures", f.Count))
+			r.veto(res.Name, errors.Errorf("quarantined after %d failed attempts: %s", f.Count, f.Error))
+			return errSkipped
+		}
+
+		// Keep previously deployed values for inputs where changes are
+		// ignored and for inputs that were not set.
+		input := res.Input
+		keep := append(append([]cty.Path(nil), res.IgnoreChanges...), res.Unset...)
+		if existing != nil && len(keep) > 0 {
+			input = ignoreChanges(res.Input, existing.Input, keep)
+		}
+		if r.Tags {
+			input = addTags(defType, input, projectTags(r.Project, res.Name))
+		}
+		input, err = defaultName(defType, input, addressName(r.Project, res.Name))
+		if err != nil {
+			return errors.Wrap(err, "set default name")
+		}
+		input, err = setDefaults(defType, input)
+		if err != nil {
+			return errors.Wrap(err, "set defaults")
+		}
+		if !input.RawEquals(res.Input) {
+			desired := *res
+			desired.Input = input
+			deployed.Desired = &desired
+		}
+
+		// Compute hash based on current inputs.
+		hash := ctyext.Hash(input)
+		logger = logger.With(zap.String("hash", shortHash(hash)))
+
+		// Insert config into definition.
+		val := reflect.New(defType)
+		if err := ctyext.FromCtyValue(input, val.Interface(), resource.FieldName); err != nil {
+			return errors.Wrap(err, "set input")
+		}
+		def := val.Elem().Interface().(resource.Definition)
 
-				// Remove existing so it doesn't get deleted
-				r.existing = append(r.existing[:i], r.existing[i+1:]...)
-				break
+		if v, ok := def.(resource.Validator); ok {
+			if err := v.Validate(); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("validate %s.%s", res.Type, res.Name))
 			}
 		}
-		r.mu.Unlock()
+
+		logger.Debug("Config resolved")
 
 		// Check what (if anything) needs to be updated.
 		updateSource, updateConfig := false, false
 		if existing != nil {
-			exHash := existing.Input.Hash()
+			exHash := ctyext.Hash(existing.Input)
 			logger.Debug("Existing version of resource exists")
-			updateConfig = exHash != hash
+			updateConfig = changed(existing.Input, input, exHash, hash)
 			opts := []cmp.Option{
 				cmpopts.SortSlices(func(a, b string) bool { return a < b }),
 				cmpopts.EquateEmpty(),
@@ -282,21 +560,98 @@ func (r *run) processResource(ctx context.Context, res *resource.Desired) error
 			updateSource = !cmp.Equal(existing.Sources, res.Sources, opts...)
 
 			if updateConfig {
-				logger.Debug("Config changed", zap.Int("prev_hash", exHash))
+				if eq, ok := def.(resource.Comparer); ok {
+					prev, err := deployedDefinition(defType, existing)
+					if err != nil {
+						return err
+					}
+					if eq.Equal(prev) {
+						logger.Debug("Config equal to previous", zap.String("prev_hash", shortHash(exHash)))
+						updateConfig = false
+					}
+				}
+			}
+
+			if updateConfig {
+				logger.Debug("Config changed", zap.String("prev_hash", shortHash(exHash)))
 			}
 			if updateSource {
 				logger.Debug("Source changed", zap.Strings("prev_source", existing.Sources))
 			}
 
 			if !updateConfig && !updateSource {
+				if existing.Imported {
+					// The config has claimed the imported resource.
+					claimed := *existing
+					claimed.Imported = false
+					pctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+					logger.Debug("Claiming imported resource")
+					err := r.retry(pctx, logger, res.Type, "put_state", func() error {
+						return r.Resources.PutResource(pctx, r.Project, &claimed)
+					})
+					if err != nil {
+						return errors.Wrap(err, "store resource")
+					}
+				}
 				r.mu.Lock()
 				r.outputs[res.Name] = existing.Output
 				r.mu.Unlock()
 				logger.Debug("No changes required")
 				return nil
 			}
+
+			// The resource may have been removed from the state since the
+			// existing resources were listed.
+			ok, err := r.inState(ctx, existing)
+			if err != nil {
+				return errors.Wrap(err, "check state")
+			}
+			if !ok {
+				if !r.Recreate {
+					return errors.Wrapf(ErrNotInState, "%s.%s", res.Type, res.Name)
+				}
+				logger.Info("Resource removed from state, recreating")
+				existing = nil
+			}
+		}
+
+		// Inputs that cannot be updated in place require the existing
+		// resource to be deleted before it is created again.
+		replace := existing != nil && updateConfig && forceNew(defType, existing.Input, input)
+
+		hookOp := "create"
+		switch {
+		case replace:
+			hookOp = "replace"
+		case existing != nil:
+			hookOp = "update"
+		}
+		if r.Before != nil {
+			if err := r.Before(ctx, deployed.Desired, hookOp); err != nil {
+				logger.Info("Vetoed", zap.String("op", hookOp), zap.Error(err))
+				r.veto(res.Name, err)
+				return errSkipped
+			}
+		}
+		if r.After != nil {
+			defer func() { r.After(ctx, deployed.Desired, hookOp, err) }()
+		}
+
+		if replace {
+			logger.Info("Replacing resource")
+			if err := r.destroy(ctx, logger, existing); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("replace %s.%s", res.Type, res.Name))
+			}
+			existing = nil
+		}
+		switch {
+		case existing != nil:
 			deployed.ID = existing.ID
-		} else {
+		case failed != nil:
+			// Replace the recorded failure.
+			deployed.ID = failed.ID
+		default:
 			deployed.ID = r.IDGen.GenerateID()
 		}
 
@@ -306,50 +661,105 @@ func (r *run) processResource(ctx context.Context, res *resource.Desired) error
 			logger.Info("Updating resource")
 
 			// Create previous definition.
-			val := reflect.New(r.Registry.Type(res.Type))
+			prev, err := deployedDefinition(defType, existing)
+			if err != nil {
+				return err
+			}
+
+			// Start from the previous outputs, so outputs the provider does
+			// not set on update are kept, including outputs marked as ids.
+			// Nested values are merged: only the fields that the provider
+			// sets are changed.
 			if err := ctyext.FromCtyValue(existing.Output, val.Interface(), resource.FieldName); err != nil {
 				return errors.Wrap(err, "set existing output")
 			}
-			if err := ctyext.FromCtyValue(existing.Input, val.Interface(), resource.FieldName); err != nil {
-				return errors.Wrap(err, "set config")
-			}
-			prev := val.Elem().Interface().(resource.Definition)
+			def = val.Elem().Interface().(resource.Definition)
 
 			req := &resource.UpdateRequest{
-				Auth:          tempLocalAuthProvider{},
+				Auth:          r.Auth.Profile(res.Profile),
 				Source:        sourceList,
 				Previous:      prev,
 				ConfigChanged: updateConfig,
 				SourceChanged: updateSource,
+				Project:       r.Project,
+				Name:          res.Name,
 			}
 
 			op = func() error {
+				req.Attempt++
 				return def.Update(ctx, req)
 			}
 		} else {
 			logger.Info("Creating resource")
 			req := &resource.CreateRequest{
-				Auth:   tempLocalAuthProvider{},
-				Source: sourceList,
+				Auth:    r.Auth.Profile(res.Profile),
+				Source:  sourceList,
+				Project: r.Project,
+				Name:    res.Name,
 			}
 
 			op = func() error {
+				req.Attempt++
 				return def.Create(ctx, req)
 			}
 		}
 
-		algo := backoff.WithContext(r.Backoff(), ctx)
-		notify := func(err error, dur time.Duration) {
-			logger.Info("Retrying", zap.Error(err), zap.Duration("duration", dur))
+		opStr := "create"
+		if existing != nil {
+			opStr = "update"
+		}
+		start := time.Now()
+		if err := r.retry(ctx, logger, res.Type, opStr, op); err != nil {
+			err = errors.Wrap(err, fmt.Sprintf("%s %s.%s", opStr, res.Type, res.Name))
+			if r.Quarantine > 0 && ctx.Err() == nil {
+				r.recordFailure(logger, deployed, existing, lastFailure(existing, failed), err)
+			}
+			return err
+		}
+
+		// Capture resource parents
+		parents := r.Graph.ParentResources(res.Name)
+		if len(parents) > 0 {
+			deployed.Deps = make([]string, len(parents))
+			for i, p := range parents {
+				deployed.Deps[i] = p.Name
+			}
 		}
 
-		if err := backoff.RetryNotify(op, algo, notify); err != nil {
-			opStr := "create"
-			if existing != nil {
-				opStr = "update"
+		// Children must not use the outputs until the resource is ready.
+		if w, ok := def.(resource.Waiter); ok {
+			logger.Debug("Waiting for resource to become ready")
+			req := &resource.WaitRequest{Auth: r.Auth.Profile(res.Profile)}
+			err := r.retry(ctx, logger, res.Type, "wait", func() error {
+				return w.WaitReady(ctx, req)
+			})
+			if err != nil {
+				err = errors.Wrap(err, fmt.Sprintf("wait %s.%s", res.Type, res.Name))
+				// The resource was applied, so it is stored with its id and
+				// outputs. Otherwise it could not be updated or deleted later.
+				outputType := resource.Fields(defType).Outputs().CtyType()
+				outputs, cerr := ctyext.ToCtyValue(def, outputType, resource.FieldName)
+				if cerr != nil {
+					outputs = cty.NullVal(outputType)
+				}
+				deployed.Output = outputs
+				if r.Quarantine > 0 && ctx.Err() == nil {
+					r.recordFailure(logger, deployed, deployed, lastFailure(existing, failed), err)
+					return err
+				}
+				pctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				perr := r.retry(pctx, logger, res.Type, "put_state", func() error {
+					return r.Resources.PutResource(pctx, r.Project, deployed)
+				})
+				if perr != nil {
+					logger.Warn("Could not store resource", zap.Error(perr))
+				}
+				return err
 			}
-			return errors.Wrap(err, fmt.Sprintf("%s %s.%s", opStr, res.Type, res.Name))
 		}
+		deployed.LastDuration = time.Since(start)
+		deployed.LastAppliedAt = time.Now()
 
 		// Capture generated output values
 		outputType := resource.Fields(defType).Outputs().CtyType()
@@ -359,17 +769,15 @@ func (r *run) processResource(ctx context.Context, res *resource.Desired) error
 		}
 		deployed.Output = outputs
 
-		r.mu.Lock()
-		r.outputs[res.Name] = outputs
-		r.mu.Unlock()
-
-		// Capture resource parents
-		parents := r.Graph.ParentResources(res.Name)
-		if len(parents) > 0 {
-			deployed.Deps = make([]string, len(parents))
-			for i, p := range parents {
-				deployed.Deps[i] = p.Name
-			}
+		// An output that a child references but the provider never set is
+		// likely a bug in the provider. The value may legitimately be empty,
+		// so only warn.
+		for _, name := range unsetOutputs(def, defType, r.refs[res.Name]) {
+			logger.Warn("Referenced output was not set", zap.String("output", name))
+		}
+		// Without its id, the resource cannot be updated or deleted later.
+		for _, name := range unsetOutputs(def, defType, idOutputs(defType)) {
+			logger.Warn("ID output was not set", zap.String("output", name))
 		}
 
 		// Use new context so a cancelled context still stores the result.
@@ -377,13 +785,25 @@ func (r *run) processResource(ctx context.Context, res *resource.Desired) error
 		defer cancel()
 
 		logger.Debug("Storing data")
-		if err := r.Resources.PutResource(pctx, r.Project, deployed); err != nil {
+		err = r.retry(pctx, logger, res.Type, "put_state", func() error {
+			return r.Resources.PutResource(pctx, r.Project, deployed)
+		})
+		if err != nil {
 			return errors.Wrap(err, "store resource")
 		}
 
-		if existing != nil {
+		// Children may only use the outputs once the resource has been
+		// stored. A child is processed after this function returns.
+		r.mu.Lock()
+		r.outputs[res.Name] = outputs
+		r.mu.Unlock()
+
+		switch {
+		case existing != nil:
 			atomic.AddUint32(&r.update, 1)
-		} else {
+		case replace:
+			atomic.AddUint32(&r.replace, 1)
+		default:
 			atomic.AddUint32(&r.create, 1)
 		}
 
@@ -391,22 +811,194 @@ func (r *run) processResource(ctx context.Context, res *resource.Desired) error
 	})
 }
 
+// referencedOutputs returns the names of the outputs that are referenced by
+// children in the graph, keyed by parent resource name.
+func referencedOutputs(g Graph) map[string]map[string]bool {
+	refs := make(map[string]map[string]bool)
+	seen := make(map[string]bool)
+	var walk func(name string)
+	walk = func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		for _, dep := range g.DependenciesOf(name) {
+			for _, ref := range dep.Expression.References() {
+				if len(ref) < 2 {
+					continue
+				}
+				parent, ok := ref[0].(cty.GetAttrStep)
+				if !ok {
+					continue
+				}
+				output, ok := ref[1].(cty.GetAttrStep)
+				if !ok {
+					continue
+				}
+				if refs[parent.Name] == nil {
+					refs[parent.Name] = make(map[string]bool)
+				}
+				refs[parent.Name][output.Name] = true
+			}
+		}
+		for _, p := range g.ParentResources(name) {
+			walk(p.Name)
+		}
+	}
+	for _, leaf := range g.LeafResources() {
+		walk(leaf.Name)
+	}
+	return refs
+}
+
+// unsetOutputs returns the sorted names of the outputs in names that have a
+// zero value in def.
+func unsetOutputs(def resource.Definition, typ reflect.Type, names map[string]bool) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	v := reflect.Indirect(reflect.ValueOf(def))
+	var unset []string
+	for name, f := range resource.Fields(typ).Outputs() {
+		if !names[name] {
+			continue
+		}
+		fv := f.Value(v)
+		if reflect.DeepEqual(fv.Interface(), reflect.Zero(f.Type).Interface()) {
+			unset = append(unset, name)
+		}
+	}
+	sort.Strings(unset)
+	return unset
+}
+
+// idOutputs returns the names of the outputs in typ that are marked as ids.
+func idOutputs(typ reflect.Type) map[string]bool {
+	var ids map[string]bool
+	for name, f := range resource.Fields(typ).Outputs() {
+		if !f.ID() {
+			continue
+		}
+		if ids == nil {
+			ids = make(map[string]bool)
+		}
+		ids[name] = true
+	}
+	return ids
+}
+
+// forceNew returns true if the value of an input marked with force_new differs
+// between prev and next.
+func forceNew(typ reflect.Type, prev, next cty.Value) bool {
+	if prev.IsNull() || next.IsNull() {
+		return false
+	}
+	for name, field := range resource.Fields(typ).Inputs() {
+		if !field.ForceNew() {
+			continue
+		}
+		if !prev.Type().HasAttribute(name) || !next.Type().HasAttribute(name) {
+			continue
+		}
+		if !prev.GetAttr(name).RawEquals(next.GetAttr(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// inState checks that a previously listed resource still exists in the
+// stored state. If the resource storage does not implement ResourceGetter,
+// the resource is assumed to exist.
+func (r *run) inState(ctx context.Context, res *resource.Deployed) (bool, error) {
+	getter, ok := r.Resources.(ResourceGetter)
+	if !ok {
+		return true, nil
+	}
+	got, err := getter.GetResource(ctx, r.Project, res.ID)
+	if err != nil {
+		return false, err
+	}
+	return got != nil, nil
+}
+
+// acquire acquires the semaphore for processing a resource. If the run is
+// deterministic, the semaphore is acquired in the scheduled order.
+func (r *run) acquire(ctx context.Context, name string) error {
+	if r.turn == nil {
+		return r.Sem.Acquire(ctx, 1)
+	}
+	if err := r.turn.Wait(ctx, name); err != nil {
+		return err
+	}
+	defer r.turn.Pass(name)
+	return r.Sem.Acquire(ctx, 1)
+}
+
+// ignoreChanges returns the desired input with the values at the given paths
+// replaced by the values from the existing input. A path is not replaced if
+// it does not exist in the existing input or the type of the value changed.
+func ignoreChanges(desired, existing cty.Value, paths []cty.Path) cty.Value {
+	out, _ := cty.Transform(desired, func(path cty.Path, val cty.Value) (cty.Value, error) {
+		for _, p := range paths {
+			if !path.Equals(p) {
+				continue
+			}
+			ex, err := p.Apply(existing)
+			if err != nil || !ex.Type().Equals(val.Type()) {
+				return val, nil
+			}
+			return ex, nil
+		}
+		return val, nil
+	})
+	return out
+}
+
 func (r *run) processDependencies(ctx context.Context, childName string, logger *zap.Logger) error {
 	g, ctx := errgroup.WithContext(ctx)
 	parents := r.Graph.ParentResources(childName)
+	var skipped int32
 	for _, res := range parents {
 		res := res
 		logger.Debug("Waiting on dependency", zap.String("parent", res.Name), zap.String("child", childName))
 		g.Go(func() error {
 			err := r.processResource(ctx, res)
 			logger.Debug("Dependency done", zap.String("parent", res.Name), zap.Bool("error", err != nil))
+			// A skipped parent must not cancel the other parents, which
+			// may be needed by other resources.
+			if isSkipped(err) {
+				atomic.StoreInt32(&skipped, 1)
+				return nil
+			}
 			return err
 		})
 	}
-	return g.Wait()
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	if atomic.LoadInt32(&skipped) == 1 {
+		return errSkipped
+	}
+	return nil
+}
+
+// takeExisting returns the existing version of a resource, or nil if the
+// resource has not been deployed before. The resource is removed from the
+// existing resources, so it is not deleted.
+func (r *run) takeExisting(res *resource.Desired) *resource.Deployed {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, ex := range r.existing {
+		if ex.Type == res.Type && ex.Name == res.Name {
+			r.existing = append(r.existing[:i], r.existing[i+1:]...)
+			return ex
+		}
+	}
+	return nil
 }
 
-func (r *run) resolveDependencies(res *resource.Desired) error {
+func (r *run) resolveDependencies(res *resource.Desired, defType reflect.Type) error {
 	parents := r.Graph.ParentResources(res.Name)
 	if len(parents) == 0 {
 		return nil
@@ -422,6 +1014,7 @@ func (r *run) resolveDependencies(res *resource.Desired) error {
 	ctx := &resource.EvalContext{Variables: vars}
 	for _, dep := range r.Graph.DependenciesOf(res.Name) {
 		processed := false
+		var resolved cty.Value
 		cfg, err := cty.Transform(res.Input, func(path cty.Path, val cty.Value) (cty.Value, error) {
 			if !path.Equals(dep.Field) {
 				return val, nil
@@ -431,6 +1024,7 @@ func (r *run) resolveDependencies(res *resource.Desired) error {
 				return cty.NilVal, errors.Wrap(err, "eval expression")
 			}
 			processed = true
+			resolved = v
 			return v, nil
 		})
 		if err != nil {
@@ -439,6 +1033,11 @@ func (r *run) resolveDependencies(res *resource.Desired) error {
 		if !processed {
 			return fmt.Errorf("dependency %s was not found", ctyext.PathString(dep.Field))
 		}
+		if r.Validator != nil {
+			if err := validateResolved(r.Validator, defType, dep.Field, resolved); err != nil {
+				return errors.Wrapf(err, "invalid value for %s", ctyext.PathString(dep.Field))
+			}
+		}
 		res.Input = cfg
 	}
 
@@ -446,13 +1045,33 @@ func (r *run) resolveDependencies(res *resource.Desired) error {
 }
 
 func (r *run) RemovePrevious(ctx context.Context) error {
-	if len(r.existing) == 0 {
+	if err := r.removeFailed(ctx); err != nil {
+		return err
+	}
+
+	// Imported resources that were not claimed by the config are kept.
+	var existing []*resource.Deployed
+	for _, res := range r.existing {
+		if res.Imported {
+			r.Logger.Debug("Keeping unclaimed imported resource", zap.String("type", res.Type), zap.String("name", res.Name))
+			continue
+		}
+		existing = append(existing, res)
+	}
+
+	if len(existing) == 0 {
 		r.Logger.Debug("No previous resources to remove")
 		return nil
 	}
 	r.Logger.Debug("Remove previous")
-	wgs := make(map[string]*sync.WaitGroup, len(r.existing))
-	for _, res := range r.existing {
+
+	// Abort before deleting anything if a resource is protected.
+	if err := preventDestroy(existing); err != nil {
+		return err
+	}
+
+	wgs := make(map[string]*sync.WaitGroup, len(existing))
+	for _, res := range existing {
 		for _, dep := range res.Deps {
 			wg, ok := wgs[dep]
 			if !ok {
@@ -463,7 +1082,7 @@ func (r *run) RemovePrevious(ctx context.Context) error {
 		}
 	}
 	g, ctx := errgroup.WithContext(ctx)
-	for _, res := range r.existing {
+	for _, res := range existing {
 		res := res
 		g.Go(func() error {
 			if wg, ok := wgs[res.Name]; ok {
@@ -481,8 +1100,55 @@ func (r *run) RemovePrevious(ctx context.Context) error {
 	return g.Wait()
 }
 
+// checkPreventDestroy returns an error if a resource that is no longer in
+// the graph has PreventDestroy set. Imported resources are not deleted, so
+// they are not checked.
+func (r *run) checkPreventDestroy() error {
+	desired := make(map[string]bool)
+	var walk func(res []*resource.Desired)
+	walk = func(res []*resource.Desired) {
+		for _, d := range res {
+			key := d.Type + "." + d.Name
+			if desired[key] {
+				continue
+			}
+			desired[key] = true
+			walk(r.Graph.ParentResources(d.Name))
+		}
+	}
+	walk(r.Graph.LeafResources())
+
+	var remove []*resource.Deployed
+	for _, res := range r.existing {
+		if res.Imported || desired[res.Type+"."+res.Name] {
+			continue
+		}
+		remove = append(remove, res)
+	}
+	return preventDestroy(remove)
+}
+
+// preventDestroy returns an error listing the resources that have
+// PreventDestroy set, or nil if there are none.
+func preventDestroy(resources []*resource.Deployed) error {
+	var protected []string
+	for _, res := range resources {
+		if res.PreventDestroy {
+			protected = append(protected, fmt.Sprintf("%s.%s", res.Type, res.Name))
+		}
+	}
+	if len(protected) == 0 {
+		return nil
+	}
+	sort.Strings(protected)
+	return errors.Errorf("prevent_destroy is set, cannot delete %s", strings.Join(protected, ", "))
+}
+
 func (r *run) removeResource(ctx context.Context, res *resource.Deployed) error {
 	logger := r.Logger.With(zap.String("type", res.Type), zap.String("name", res.Name))
+	if res.Description != "" {
+		logger = logger.With(zap.String("description", res.Description))
+	}
 
 	// Ready to process, wait for semaphore.
 	err := r.Sem.Acquire(ctx, 1)
@@ -491,46 +1157,135 @@ func (r *run) removeResource(ctx context.Context, res *resource.Deployed) error
 	}
 	defer r.Sem.Release(1)
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	logger.Debug("Delete")
 
-	// Create previous definition.
-	val := reflect.New(r.Registry.Type(res.Type))
-	if err := ctyext.FromCtyValue(res.Output, val.Interface(), resource.FieldName); err != nil {
-		return errors.Wrap(err, "set existing output")
+	if err := r.destroy(ctx, logger, res); err != nil {
+		return err
 	}
-	if err := ctyext.FromCtyValue(res.Input, val.Interface(), resource.FieldName); err != nil {
-		return errors.Wrap(err, "set config")
+
+	atomic.AddUint32(&r.delete, 1)
+
+	return nil
+}
+
+// destroy deletes a deployed resource and removes it from the stored state.
+// Resources with PreventDestroy set are not deleted. The caller must hold the
+// semaphore.
+func (r *run) destroy(ctx context.Context, logger *zap.Logger, res *resource.Deployed) error {
+	if res.PreventDestroy {
+		return errors.New("prevent_destroy is set")
 	}
-	def := val.Elem().Interface().(resource.Definition)
 
-	req := &resource.DeleteRequest{Auth: tempLocalAuthProvider{}}
-	err = backoff.RetryNotify(
-		func() error {
-			return def.Delete(ctx, req)
-		},
-		backoff.WithContext(r.Backoff(), ctx),
-		func(err error, dur time.Duration) {
-			logger.Info("Retrying", zap.Error(err), zap.Duration("duration", dur))
-		},
-	)
+	// Create previous definition.
+	def, err := deployedDefinition(r.Registry.Type(res.Type), res)
 	if err != nil {
-		return errors.Wrap(err, "delete")
+		return err
 	}
 
-	atomic.AddUint32(&r.delete, 1)
+	req := &resource.DeleteRequest{
+		Auth:    r.Auth.Profile(res.Profile),
+		Project: r.Project,
+		Name:    res.Name,
+	}
+	err = r.retry(ctx, logger, res.Type, "delete", func() error {
+		req.Attempt++
+		return def.Delete(ctx, req)
+	})
+	if err != nil {
+		return errors.Wrap(err, "delete")
+	}
 
 	// Use new context so a cancelled context still stores the result.
 	pctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	logger.Debug("Deleting data")
-	if err := r.Resources.DeleteResource(pctx, r.Project, res); err != nil {
+	err = r.retry(pctx, logger, res.Type, "delete_state", func() error {
+		return r.Resources.DeleteResource(pctx, r.Project, res)
+	})
+	if err != nil {
 		return errors.Wrap(err, "delete resource")
 	}
 
 	return nil
 }
 
+// retry calls op until it succeeds, returns a permanent error or the backoff
+// algorithm gives up. The operation and retries are reported to the metrics
+// for the resource type.
+func (r *run) retry(ctx context.Context, logger *zap.Logger, resType, opName string, op func() error) error { // nolint: lll
+	algo := &stopBackOff{BackOff: r.Backoff()}
+	attempts := 0
+	start := time.Now()
+	err := backoff.RetryNotify(
+		func() error {
+			attempts++
+			return op()
+		},
+		backoff.WithContext(algo, ctx),
+		func(err error, dur time.Duration) {
+			logger.Info("Retrying", zap.Error(err), zap.Duration("duration", dur))
+			r.Metrics.IncRetry(resType)
+		},
+	)
+	r.Metrics.ObserveOp(resType, opName, time.Since(start), err)
+	if err != nil && algo.stopped {
+		return errors.Wrapf(err, "gave up after %s (attempts: %d)", time.Since(start).Round(time.Millisecond), attempts)
+	}
+	return err
+}
+
+// stopBackOff records whether the wrapped backoff algorithm stopped retrying.
+type stopBackOff struct {
+	backoff.BackOff
+	stopped bool
+}
+
+func (b *stopBackOff) NextBackOff() time.Duration {
+	d := b.BackOff.NextBackOff()
+	if d == backoff.Stop {
+		b.stopped = true
+	}
+	return d
+}
+
+// changed returns true if the next input differs from the previous one.
+// Equal hashes are a fast path, otherwise the values are compared in full.
+// Empty hashes are for values that are not wholly known, which are always
+// considered changed.
+func changed(prev, next cty.Value, prevHash, nextHash string) bool {
+	if prevHash != "" && prevHash == nextHash {
+		return false
+	}
+	eq := prev.Equals(next)
+	return !eq.IsKnown() || eq.False()
+}
+
+// shortHash shortens a hash for logging.
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// deployedDefinition creates a definition of the given type, populated with
+// the stored inputs and outputs of a deployed resource.
+func deployedDefinition(typ reflect.Type, res *resource.Deployed) (resource.Definition, error) {
+	val := reflect.New(typ)
+	if err := ctyext.FromCtyValue(res.Output, val.Interface(), resource.FieldName); err != nil {
+		return nil, errors.Wrap(err, "set existing output")
+	}
+	if err := ctyext.FromCtyValue(res.Input, val.Interface(), resource.FieldName); err != nil {
+		return nil, errors.Wrap(err, "set config")
+	}
+	return val.Elem().Interface().(resource.Definition), nil
+}
+
 type source struct {
 	key     string
 	storage SourceStorage
@@ -541,10 +1296,16 @@ func (s *source) Reader(ctx context.Context) (targz io.ReadCloser, err error) {
 	return s.storage.Get(ctx, s.key)
 }
 
-type tempLocalAuthProvider struct{}
+type tempLocalAuthProvider struct {
+	profile string
+}
 
 func (p tempLocalAuthProvider) AWS() (aws.CredentialsProvider, error) {
-	cfg, err := external.LoadDefaultAWSConfig()
+	var configs []external.Config
+	if p.profile != "" {
+		configs = append(configs, external.WithSharedConfigProfile(p.profile))
+	}
+	cfg, err := external.LoadDefaultAWSConfig(configs...)
 	if err != nil {
 		panic("unable to load SDK config, " + err.Error())
 	}
@@ -2,16 +2,29 @@ package reconciler_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cenkalti/backoff"
 	"github.com/func/func/resource"
 	"github.com/func/func/resource/reconciler"
+	"github.com/func/func/resource/validation"
 	"github.com/func/func/storage/teststore"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/zclconf/go-cty/cty"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 // Everything in same project
@@ -557,6 +570,7 @@ func TestReconciler_Reconcile_events(t *testing.T) {
 				cmp.Comparer(func(a, b cty.Value) bool {
 					return a.Equals(b).True()
 				}),
+				cmpopts.IgnoreFields(resource.Deployed{}, "LastAppliedAt", "LastDuration"),
 			}
 			if diff := cmp.Diff(rec.Events, tt.wantEvents, opts...); diff != "" {
 				t.Errorf("Events (-got +want)\n%s", diff)
@@ -565,6 +579,2483 @@ func TestReconciler_Reconcile_events(t *testing.T) {
 	}
 }
 
+func TestReconciler_Reconcile_noDelete(t *testing.T) {
+	existing := &resource.Deployed{
+		Desired: &resource.Desired{
+			Name:  "foo",
+			Type:  "nop",
+			Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("hello")}),
+		},
+		ID:     "ex0",
+		Output: cty.EmptyObjectVal,
+	}
+
+	store := &teststore.Store{}
+	store.SeedResources("proj", []*resource.Deployed{existing})
+	rec := &teststore.Recorder{Store: store}
+
+	reco := &reconciler.Reconciler{
+		Resources: rec,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"nop": struct {
+				nop
+				Input string `func:"input"`
+			}{},
+		}),
+		Logger:   zaptest.NewLogger(t),
+		IDGen:    &sequence{},
+		NoDelete: true,
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{
+				Name:  "bar",
+				Type:  "nop",
+				Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("hello")}),
+			},
+		},
+	}
+
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	// foo is not deleted.
+	wantEvents := teststore.Events{
+		{Method: "ListResources", Project: "proj"},
+		{Method: "PutResource", Project: "proj", Data: &resource.Deployed{
+			Desired: &resource.Desired{
+				Name:  "bar",
+				Type:  "nop",
+				Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("hello")}),
+			},
+			ID:     "id0",
+			Output: cty.EmptyObjectVal,
+		}},
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool {
+			return a.Equals(b).True()
+		}),
+		cmpopts.IgnoreFields(resource.Deployed{}, "LastAppliedAt", "LastDuration"),
+	}
+	if diff := cmp.Diff(rec.Events, wantEvents, opts...); diff != "" {
+		t.Errorf("Events (-got +want)\n%s", diff)
+	}
+
+	got, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("Got %d resources in store, want 2", len(got))
+	}
+}
+
+func TestReconciler_Reconcile_moved(t *testing.T) {
+	existing := &resource.Deployed{
+		Desired: &resource.Desired{
+			Name:  "a",
+			Type:  "nop",
+			Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("hello")}),
+		},
+		ID:     "ex0",
+		Output: cty.EmptyObjectVal,
+	}
+
+	store := &teststore.Store{}
+	store.SeedResources("proj", []*resource.Deployed{existing})
+	rec := &teststore.Recorder{Store: store}
+
+	reco := &reconciler.Reconciler{
+		Resources: rec,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"nop": struct {
+				nop
+				Input string `func:"input"`
+			}{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{
+				Name:  "b",
+				Type:  "nop",
+				Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("hello")}),
+			},
+		},
+		Moves: []*resource.Move{
+			{From: "a", To: "b"},
+		},
+	}
+
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	// The existing resource is renamed, it is not created or deleted.
+	moved := &resource.Deployed{
+		Desired: &resource.Desired{
+			Name:  "b",
+			Type:  "nop",
+			Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("hello")}),
+		},
+		ID:     "ex0",
+		Output: cty.EmptyObjectVal,
+	}
+	wantEvents := teststore.Events{
+		{Method: "ListResources", Project: "proj"},
+		{Method: "PutResource", Project: "proj", Data: moved},
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool {
+			return a.Equals(b).True()
+		}),
+	}
+	if diff := cmp.Diff(rec.Events, wantEvents, opts...); diff != "" {
+		t.Errorf("Events (-got +want)\n%s", diff)
+	}
+
+	got, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if diff := cmp.Diff(got, []*resource.Deployed{moved}, opts...); diff != "" {
+		t.Errorf("Stored resources (-got +want)\n%s", diff)
+	}
+
+	// The existing resource was not modified.
+	if existing.Name != "a" {
+		t.Errorf("Existing resource name = %q, want %q", existing.Name, "a")
+	}
+}
+
+func TestReconciler_Reconcile_moved_exists(t *testing.T) {
+	store := &teststore.Store{}
+	store.SeedResources("proj", []*resource.Deployed{
+		{
+			Desired: &resource.Desired{Name: "a", Type: "nop", Input: cty.EmptyObjectVal},
+			ID:      "ex0",
+			Output:  cty.EmptyObjectVal,
+		},
+		{
+			Desired: &resource.Desired{Name: "b", Type: "nop", Input: cty.EmptyObjectVal},
+			ID:      "ex1",
+			Output:  cty.EmptyObjectVal,
+		},
+	})
+
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"nop": nop{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "b", Type: "nop", Input: cty.EmptyObjectVal},
+		},
+		Moves: []*resource.Move{
+			{From: "a", To: "b"},
+		},
+	}
+
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err == nil {
+		t.Fatal("Reconcile() want error when moving to an existing resource")
+	}
+}
+
+func TestReconciler_Reconcile_ignoreChanges(t *testing.T) {
+	input := func(name string, capacity int64) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"name":     cty.StringVal(name),
+			"capacity": cty.NumberIntVal(capacity),
+		})
+	}
+	ignore := []cty.Path{cty.GetAttrPath("capacity")}
+
+	store := &teststore.Store{}
+	store.SeedResources("proj", []*resource.Deployed{
+		{
+			Desired: &resource.Desired{Name: "foo", Type: "nop", Input: input("foo", 5)},
+			ID:      "ex0",
+			Output:  cty.EmptyObjectVal,
+		},
+		{
+			Desired: &resource.Desired{Name: "bar", Type: "nop", Input: input("bar", 5)},
+			ID:      "ex1",
+			Output:  cty.EmptyObjectVal,
+		},
+	})
+	rec := &teststore.Recorder{Store: store}
+
+	reco := &reconciler.Reconciler{
+		Resources: rec,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"nop": struct {
+				nop
+				Name     string `func:"input"`
+				Capacity int    `func:"input"`
+			}{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			// Only ignored field changed.
+			{Name: "foo", Type: "nop", Input: input("foo", 10), IgnoreChanges: ignore},
+			// Ignored and other field changed.
+			{Name: "bar", Type: "nop", Input: input("baz", 10), IgnoreChanges: ignore},
+		},
+	}
+
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	// foo is not updated, bar is updated with the previous capacity.
+	wantEvents := teststore.Events{
+		{Method: "ListResources", Project: "proj"},
+		{Method: "PutResource", Project: "proj", Data: &resource.Deployed{
+			Desired: &resource.Desired{
+				Name:          "bar",
+				Type:          "nop",
+				Input:         input("baz", 5),
+				IgnoreChanges: ignore,
+			},
+			ID:     "ex1",
+			Output: cty.EmptyObjectVal,
+		}},
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool {
+			return a.Equals(b).True()
+		}),
+		cmp.Comparer(func(a, b cty.Path) bool {
+			return a.Equals(b)
+		}),
+		cmpopts.IgnoreFields(resource.Deployed{}, "LastAppliedAt", "LastDuration"),
+	}
+	if diff := cmp.Diff(rec.Events, wantEvents, opts...); diff != "" {
+		t.Errorf("Events (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_unset(t *testing.T) {
+	existing := &resource.Deployed{
+		Desired: &resource.Desired{
+			Name:  "foo",
+			Type:  "optional",
+			Input: cty.ObjectVal(map[string]cty.Value{"value": cty.StringVal("prev")}),
+		},
+		ID:     "ex0",
+		Output: cty.EmptyObjectVal,
+	}
+
+	tests := []struct {
+		name       string
+		unset      []cty.Path
+		wantEvents teststore.Events
+	}{
+		{
+			name:  "Omitted",
+			unset: []cty.Path{cty.GetAttrPath("value")},
+			wantEvents: teststore.Events{
+				// Previous value is kept, no changes.
+				{Method: "ListResources", Project: "proj"},
+			},
+		},
+		{
+			name: "Null",
+			wantEvents: teststore.Events{
+				{Method: "ListResources", Project: "proj"},
+				{Method: "PutResource", Project: "proj", Data: &resource.Deployed{
+					Desired: &resource.Desired{
+						Name:  "foo",
+						Type:  "optional",
+						Input: cty.ObjectVal(map[string]cty.Value{"value": cty.NullVal(cty.String)}),
+					},
+					ID:     "ex0",
+					Output: cty.EmptyObjectVal,
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &teststore.Store{}
+			store.SeedResources("proj", []*resource.Deployed{existing})
+			rec := &teststore.Recorder{Store: store}
+
+			reco := &reconciler.Reconciler{
+				Resources: rec,
+				Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+					"optional": &optional{},
+				}),
+				Logger: zaptest.NewLogger(t),
+				IDGen:  &sequence{},
+			}
+
+			graph := &resource.Graph{
+				Resources: []*resource.Desired{{
+					Name:  "foo",
+					Type:  "optional",
+					Input: cty.ObjectVal(map[string]cty.Value{"value": cty.NullVal(cty.String)}),
+					Unset: tt.unset,
+				}},
+			}
+
+			if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			opts := []cmp.Option{
+				cmp.Comparer(func(a, b cty.Value) bool {
+					return a.RawEquals(b)
+				}),
+				cmpopts.IgnoreFields(resource.Deployed{}, "LastAppliedAt", "LastDuration"),
+			}
+			if diff := cmp.Diff(rec.Events, tt.wantEvents, opts...); diff != "" {
+				t.Errorf("Events (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconciler_Reconcile_comparer(t *testing.T) {
+	input := func(doc string) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"document": cty.StringVal(doc),
+		})
+	}
+
+	store := &teststore.Store{}
+	store.SeedResources("proj", []*resource.Deployed{
+		{
+			Desired: &resource.Desired{Name: "foo", Type: "json", Input: input(`{"a":1,"b":[1,2]}`)},
+			ID:      "ex0",
+			Output:  cty.EmptyObjectVal,
+		},
+		{
+			Desired: &resource.Desired{Name: "bar", Type: "json", Input: input(`{"a":1}`)},
+			ID:      "ex1",
+			Output:  cty.EmptyObjectVal,
+		},
+	})
+	rec := &teststore.Recorder{Store: store}
+
+	reco := &reconciler.Reconciler{
+		Resources: rec,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"json": &jsonDoc{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			// Whitespace only change.
+			{Name: "foo", Type: "json", Input: input("{\n  \"a\": 1,\n  \"b\": [1, 2]\n}")},
+			// Value changed.
+			{Name: "bar", Type: "json", Input: input(`{"a":2}`)},
+		},
+	}
+
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	// foo is not updated.
+	wantEvents := teststore.Events{
+		{Method: "ListResources", Project: "proj"},
+		{Method: "PutResource", Project: "proj", Data: &resource.Deployed{
+			Desired: &resource.Desired{Name: "bar", Type: "json", Input: input(`{"a":2}`)},
+			ID:      "ex1",
+			Output:  cty.EmptyObjectVal,
+		}},
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool {
+			return a.Equals(b).True()
+		}),
+		cmpopts.IgnoreFields(resource.Deployed{}, "LastAppliedAt", "LastDuration"),
+	}
+	if diff := cmp.Diff(rec.Events, wantEvents, opts...); diff != "" {
+		t.Errorf("Events (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_preventDestroy(t *testing.T) {
+	tests := []struct {
+		name           string
+		preventDestroy bool
+		wantErr        string
+		wantEvents     teststore.Events
+	}{
+		{
+			name:           "Abort",
+			preventDestroy: true,
+			wantErr:        "prevent_destroy is set, cannot delete nop.foo",
+			wantEvents: teststore.Events{
+				{Method: "ListResources", Project: "proj"},
+			},
+		},
+		{
+			name:           "Delete",
+			preventDestroy: false,
+			wantEvents: teststore.Events{
+				{Method: "ListResources", Project: "proj"},
+				{Method: "DeleteResource", Project: "proj", Data: &resource.Deployed{
+					Desired: &resource.Desired{
+						Name:  "foo",
+						Type:  "nop",
+						Input: cty.EmptyObjectVal,
+					},
+					ID:     "ex0",
+					Output: cty.EmptyObjectVal,
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &teststore.Store{}
+			store.SeedResources("proj", []*resource.Deployed{{
+				Desired: &resource.Desired{
+					Name:           "foo",
+					Type:           "nop",
+					Input:          cty.EmptyObjectVal,
+					PreventDestroy: tt.preventDestroy,
+				},
+				ID:     "ex0",
+				Output: cty.EmptyObjectVal,
+			}})
+			rec := &teststore.Recorder{Store: store}
+
+			reco := &reconciler.Reconciler{
+				Resources: rec,
+				Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+					"nop": &nop{},
+				}),
+				Logger: zaptest.NewLogger(t),
+				IDGen:  &sequence{},
+			}
+
+			// foo is no longer desired.
+			err := reco.Reconcile(context.Background(), "", "proj", &resource.Graph{})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Reconcile() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			opts := []cmp.Option{
+				cmp.Comparer(func(a, b cty.Value) bool {
+					return a.Equals(b).True()
+				}),
+			}
+			if diff := cmp.Diff(rec.Events, tt.wantEvents, opts...); diff != "" {
+				t.Errorf("Events (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconciler_Reconcile_preventDestroyBeforeApply(t *testing.T) {
+	store := &teststore.Store{}
+	store.SeedResources("proj", []*resource.Deployed{
+		{
+			Desired: &resource.Desired{
+				Name:           "foo",
+				Type:           "nop",
+				Input:          cty.EmptyObjectVal,
+				PreventDestroy: true,
+			},
+			ID:     "ex0",
+			Output: cty.EmptyObjectVal,
+		},
+		{
+			Desired: &resource.Desired{
+				Name:  "baz",
+				Type:  "passthrough",
+				Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("old")}),
+			},
+			ID:     "ex1",
+			Output: cty.ObjectVal(map[string]cty.Value{"output": cty.StringVal("old")}),
+		},
+	})
+	rec := &teststore.Recorder{Store: store}
+
+	reco := &reconciler.Reconciler{
+		Resources: rec,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"nop":         &nop{},
+			"passthrough": &passthrough{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	// foo is removed, bar is created and baz is updated.
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "bar", Type: "nop", Input: cty.EmptyObjectVal},
+			{
+				Name:  "baz",
+				Type:  "passthrough",
+				Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("new")}),
+			},
+		},
+	}
+
+	err := reco.Reconcile(context.Background(), "", "proj", graph)
+	want := "prevent_destroy is set, cannot delete nop.foo"
+	if err == nil || err.Error() != want {
+		t.Fatalf("Reconcile() error = %v, want %q", err, want)
+	}
+
+	// Nothing is changed.
+	wantEvents := teststore.Events{
+		{Method: "ListResources", Project: "proj"},
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool {
+			return a.Equals(b).True()
+		}),
+	}
+	if diff := cmp.Diff(rec.Events, wantEvents, opts...); diff != "" {
+		t.Errorf("Events (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_dependsOn(t *testing.T) {
+	store := &teststore.Store{}
+	reco := &reconciler.Reconciler{
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"nop":  &nop{},
+			"slow": &slow{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool {
+			return a.Equals(b).True()
+		}),
+		cmpopts.IgnoreFields(resource.Deployed{}, "LastAppliedAt", "LastDuration"),
+	}
+
+	// foo explicitly depends on bar. bar is slow to create, so foo would be
+	// created first if the dependency was not honored.
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "foo", Type: "nop", Input: cty.EmptyObjectVal, DependsOn: []string{"bar"}},
+			{Name: "bar", Type: "slow", Input: cty.EmptyObjectVal},
+		},
+	}
+
+	rec := &teststore.Recorder{Store: store}
+	reco.Resources = rec
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	want := teststore.Events{
+		{Method: "ListResources", Project: "proj"},
+		{Method: "PutResource", Project: "proj", Data: &resource.Deployed{
+			Desired: &resource.Desired{Name: "bar", Type: "slow", Input: cty.EmptyObjectVal},
+			ID:      "id0",
+			Output:  cty.EmptyObjectVal,
+		}},
+		{Method: "PutResource", Project: "proj", Data: &resource.Deployed{
+			Desired: &resource.Desired{Name: "foo", Type: "nop", Input: cty.EmptyObjectVal, DependsOn: []string{"bar"}},
+			ID:      "id1",
+			Output:  cty.EmptyObjectVal,
+			Deps:    []string{"bar"},
+		}},
+	}
+	if diff := cmp.Diff(rec.Events, want, opts...); diff != "" {
+		t.Fatalf("Create events (-got +want)\n%s", diff)
+	}
+
+	// Remove both resources. foo must be deleted before bar.
+	rec = &teststore.Recorder{Store: store}
+	reco.Resources = rec
+	if err := reco.Reconcile(context.Background(), "", "proj", &resource.Graph{}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	var got []string
+	for _, e := range rec.Events {
+		if e.Method == "DeleteResource" {
+			got = append(got, e.Data.(*resource.Deployed).Name)
+		}
+	}
+	if diff := cmp.Diff(got, []string{"foo", "bar"}); diff != "" {
+		t.Errorf("Delete order (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_storeEagerly(t *testing.T) {
+	identifiedReqs = nil
+	reco := &reconciler.Reconciler{
+		Resources: orderedStore{Store: &teststore.Store{}},
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"identified": &identified{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	// c depends on b, which depends on a.
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "c", Type: "identified", Input: cty.EmptyObjectVal, DependsOn: []string{"b"}},
+			{Name: "b", Type: "identified", Input: cty.EmptyObjectVal, DependsOn: []string{"a"}},
+			{Name: "a", Type: "identified", Input: cty.EmptyObjectVal},
+		},
+	}
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	// Each resource is stored right after it was created, before its
+	// children are processed.
+	want := []string{
+		"create proj/a", "put proj/a",
+		"create proj/b", "put proj/b",
+		"create proj/c", "put proj/c",
+	}
+	if diff := cmp.Diff(identifiedReqs, want); diff != "" {
+		t.Errorf("Order (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_resume(t *testing.T) {
+	resourceNames := func(events teststore.Events, method string) []string {
+		var names []string
+		for _, e := range events {
+			if e.Method == method {
+				names = append(names, e.Data.(*resource.Deployed).Name)
+			}
+		}
+		return names
+	}
+
+	store := &teststore.Store{}
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "a", Type: "nop", Input: cty.EmptyObjectVal},
+			{Name: "b", Type: "child", Input: cty.EmptyObjectVal, DependsOn: []string{"a"}},
+		},
+	}
+
+	// b fails to create.
+	rec := &teststore.Recorder{Store: store}
+	reco := &reconciler.Reconciler{
+		Resources: rec,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"nop":   &nop{},
+			"child": &fail{},
+		}),
+		Logger:  zaptest.NewLogger(t),
+		IDGen:   &sequence{},
+		Backoff: func() backoff.BackOff { return &backoff.StopBackOff{} },
+	}
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err == nil {
+		t.Fatal("Reconcile() want error")
+	}
+	// a is stored as soon as it has been created, before b fails.
+	if diff := cmp.Diff(resourceNames(rec.Events, "PutResource"), []string{"a"}); diff != "" {
+		t.Fatalf("Stored resources in failed run (-got +want)\n%s", diff)
+	}
+
+	// b is fixed. a is picked up from the state and not created again.
+	rec = &teststore.Recorder{Store: store}
+	reco.Resources = rec
+	reco.Registry = resource.RegistryFromDefinitions(map[string]resource.Definition{
+		"nop":   &nop{},
+		"child": &nop{},
+	})
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if diff := cmp.Diff(resourceNames(rec.Events, "PutResource"), []string{"b"}); diff != "" {
+		t.Errorf("Stored resources in resumed run (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(resourceNames(rec.Events, "DeleteResource"), []string(nil)); diff != "" {
+		t.Errorf("Deleted resources in resumed run (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_cancel(t *testing.T) {
+	atomic.StoreInt32(&countingCreates, 0)
+
+	// foo cancels the context when created. bar depends on foo, so it would
+	// be created next. baz is in flight when the context is cancelled.
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "foo", Type: "cancel", Input: cty.EmptyObjectVal},
+			{Name: "bar", Type: "counting", Input: cty.EmptyObjectVal, DependsOn: []string{"foo"}},
+			{Name: "baz", Type: "wait", Input: cty.EmptyObjectVal},
+		},
+	}
+
+	rec := &teststore.Recorder{Store: &teststore.Store{}}
+	reco := &reconciler.Reconciler{
+		Resources: rec,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"cancel":   &cancelling{},
+			"counting": &counting{},
+			"wait":     &wait{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	ctx = context.WithValue(ctx, cancelKey{}, cancelFunc)
+
+	done := make(chan error)
+	go func() {
+		done <- reco.Reconcile(ctx, "", "proj", graph)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reconcile() did not return after cancellation")
+	}
+	if err == nil || err.Error() != "cancelled: context canceled" {
+		t.Fatalf("Reconcile() error = %v, want cancelled", err)
+	}
+	if n := atomic.LoadInt32(&countingCreates); n != 0 {
+		t.Errorf("Got %d creates after cancellation, want 0", n)
+	}
+
+	// foo completed and must be stored.
+	want := teststore.Events{
+		{Method: "ListResources", Project: "proj"},
+		{Method: "PutResource", Project: "proj", Data: &resource.Deployed{
+			Desired: &resource.Desired{Name: "foo", Type: "cancel", Input: cty.EmptyObjectVal},
+			Output:  cty.EmptyObjectVal,
+		}},
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool {
+			return a.Equals(b).True()
+		}),
+		// IDs are generated concurrently with baz.
+		cmpopts.IgnoreFields(resource.Deployed{}, "ID", "LastAppliedAt", "LastDuration"),
+	}
+	if diff := cmp.Diff(rec.Events, want, opts...); diff != "" {
+		t.Errorf("Events (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_validateResolved(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"Valid", "a", ""},
+		{"Invalid", "c", "resolve dependencies: invalid value for value: value must be a or b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// bar.value is resolved from foo.output when foo has been created.
+			graph := &resource.Graph{
+				Resources: []*resource.Desired{
+					{
+						Name:  "foo",
+						Type:  "passthrough",
+						Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal(tt.value)}),
+					},
+					{
+						Name:  "bar",
+						Type:  "choice",
+						Input: cty.ObjectVal(map[string]cty.Value{"value": cty.UnknownVal(cty.String)}),
+					},
+				},
+				Dependencies: []*resource.Dependency{
+					{
+						Child: "bar",
+						Field: cty.GetAttrPath("value"),
+						Expression: resource.Expression{
+							resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("output")},
+						},
+					},
+				},
+			}
+
+			validator := validation.New()
+			validation.AddBuiltin(validator)
+
+			reco := &reconciler.Reconciler{
+				Resources: &teststore.Store{},
+				Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+					"passthrough": &passthrough{},
+					"choice":      &choice{},
+				}),
+				Logger:    zaptest.NewLogger(t),
+				IDGen:     &sequence{},
+				Validator: validator,
+			}
+
+			err := reco.Reconcile(context.Background(), "", "proj", graph)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Reconcile() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Reconcile() error = %v, want to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReconciler_Reconcile_validateDefinition(t *testing.T) {
+	tests := []struct {
+		name    string
+		valid   bool
+		wantErr string
+	}{
+		{"Valid", true, ""},
+		{"Invalid", false, "validate validated.foo: not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &teststore.Store{}
+			reco := &reconciler.Reconciler{
+				Resources: store,
+				Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+					"validated": &validated{},
+				}),
+				Logger: zaptest.NewLogger(t),
+				IDGen:  &sequence{},
+			}
+
+			graph := &resource.Graph{
+				Resources: []*resource.Desired{{
+					Name:  "foo",
+					Type:  "validated",
+					Input: cty.ObjectVal(map[string]cty.Value{"valid": cty.BoolVal(tt.valid)}),
+				}},
+			}
+
+			err := reco.Reconcile(context.Background(), "", "proj", graph)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Reconcile() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Reconcile() error = %v, want to contain %q", err, tt.wantErr)
+			}
+			list, _ := store.ListResources(context.Background(), "proj")
+			if len(list) > 0 {
+				t.Errorf("Invalid resource was created")
+			}
+		})
+	}
+}
+
+func TestReconciler_Reconcile_notInState(t *testing.T) {
+	existing := &resource.Deployed{
+		Desired: &resource.Desired{
+			Name:  "foo",
+			Type:  "passthrough",
+			Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("prev")}),
+		},
+		ID:     "ex0",
+		Output: cty.ObjectVal(map[string]cty.Value{"output": cty.StringVal("prev")}),
+	}
+
+	tests := []struct {
+		name     string
+		recreate bool
+		wantErr  bool
+	}{
+		{"Fail", false, true},
+		{"Recreate", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &teststore.Store{}
+			store.SeedResources("proj", []*resource.Deployed{existing})
+
+			reco := &reconciler.Reconciler{
+				Resources: &lostStore{Store: store},
+				Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+					"passthrough": &passthrough{},
+				}),
+				Logger:          zaptest.NewLogger(t),
+				IDGen:           &sequence{},
+				RecreateMissing: tt.recreate,
+			}
+
+			graph := &resource.Graph{
+				Resources: []*resource.Desired{{
+					Name:  "foo",
+					Type:  "passthrough",
+					Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("next")}),
+				}},
+			}
+
+			err := reco.Reconcile(context.Background(), "", "proj", graph)
+			if tt.wantErr {
+				if err == nil || !strings.HasSuffix(err.Error(), reconciler.ErrNotInState.Error()) {
+					t.Fatalf("Reconcile() error = %v, want %v", err, reconciler.ErrNotInState)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			got, err := store.GetResource(context.Background(), "proj", "id0")
+			if err != nil {
+				t.Fatalf("GetResource() error = %v", err)
+			}
+			if got == nil {
+				t.Fatal("Resource was not recreated")
+			}
+			want := cty.ObjectVal(map[string]cty.Value{"output": cty.StringVal("next")})
+			if !got.Output.RawEquals(want) {
+				t.Errorf("Output = %#v, want %#v", got.Output, want)
+			}
+		})
+	}
+}
+
+func TestReconciler_Reconcile_replace(t *testing.T) {
+	existing := []*resource.Deployed{
+		{
+			Desired: &resource.Desired{
+				Name: "foo",
+				Type: "replaceable",
+				Input: cty.ObjectVal(map[string]cty.Value{
+					"name":  cty.StringVal("a"),
+					"value": cty.StringVal("x"),
+				}),
+			},
+			ID:     "ex0",
+			Output: cty.ObjectVal(map[string]cty.Value{"output": cty.StringVal("a")}),
+		},
+		{
+			Desired: &resource.Desired{
+				Name:  "bar",
+				Type:  "passthrough",
+				Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("a")}),
+			},
+			ID:     "ex1",
+			Output: cty.ObjectVal(map[string]cty.Value{"output": cty.StringVal("a")}),
+			Deps:   []string{"foo"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		fooName string
+		want    []string
+	}{
+		{
+			name:    "Update",
+			fooName: "a",
+			want: []string{
+				"PutResource foo ex0",
+			},
+		},
+		{
+			name:    "Replace",
+			fooName: "b",
+			want: []string{
+				// Previous foo is deleted before it is created again, bar is
+				// updated with the new output after that.
+				"DeleteResource foo ex0",
+				"PutResource foo id0",
+				"PutResource bar ex1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &teststore.Store{}
+			store.SeedResources("proj", existing)
+			rec := &teststore.Recorder{Store: store}
+
+			reco := &reconciler.Reconciler{
+				Resources: rec,
+				Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+					"replaceable": &replaceable{},
+					"passthrough": &passthrough{},
+				}),
+				Logger: zaptest.NewLogger(t),
+				IDGen:  &sequence{},
+			}
+
+			graph := &resource.Graph{
+				Resources: []*resource.Desired{
+					{
+						Name: "foo",
+						Type: "replaceable",
+						Input: cty.ObjectVal(map[string]cty.Value{
+							"name":  cty.StringVal(tt.fooName),
+							"value": cty.StringVal("y"),
+						}),
+					},
+					{
+						Name:  "bar",
+						Type:  "passthrough",
+						Input: cty.ObjectVal(map[string]cty.Value{"input": cty.UnknownVal(cty.String)}),
+					},
+				},
+				Dependencies: []*resource.Dependency{
+					{
+						Child: "bar",
+						Field: cty.GetAttrPath("input"),
+						Expression: resource.Expression{
+							resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("output")},
+						},
+					},
+				},
+			}
+
+			if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			var got []string
+			for _, e := range rec.Events {
+				if res, ok := e.Data.(*resource.Deployed); ok {
+					got = append(got, fmt.Sprintf("%s %s %s", e.Method, res.Name, res.ID))
+				}
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("Events (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconciler_Reconcile_replacePreventDestroy(t *testing.T) {
+	store := &teststore.Store{}
+	store.SeedResources("proj", []*resource.Deployed{{
+		Desired: &resource.Desired{
+			Name: "foo",
+			Type: "replaceable",
+			Input: cty.ObjectVal(map[string]cty.Value{
+				"name":  cty.StringVal("a"),
+				"value": cty.StringVal("x"),
+			}),
+			PreventDestroy: true,
+		},
+		ID:     "ex0",
+		Output: cty.ObjectVal(map[string]cty.Value{"output": cty.StringVal("a")}),
+	}})
+	rec := &teststore.Recorder{Store: store}
+
+	reco := &reconciler.Reconciler{
+		Resources: rec,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"replaceable": &replaceable{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	// Changing the name requires replacing foo.
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{{
+			Name: "foo",
+			Type: "replaceable",
+			Input: cty.ObjectVal(map[string]cty.Value{
+				"name":  cty.StringVal("b"),
+				"value": cty.StringVal("x"),
+			}),
+			PreventDestroy: true,
+		}},
+	}
+
+	err := reco.Reconcile(context.Background(), "", "proj", graph)
+	if err == nil || !strings.Contains(err.Error(), "prevent_destroy is set") {
+		t.Fatalf("Reconcile() error = %v, want prevent_destroy error", err)
+	}
+	for _, e := range rec.Events {
+		if e.Method == "DeleteResource" {
+			t.Errorf("Resource was deleted: %v", e.Data)
+		}
+	}
+}
+
+func TestReconciler_Reconcile_deterministic(t *testing.T) {
+	graph := &resource.Graph{}
+	for _, name := range []string{"e", "c", "a", "d", "b"} {
+		graph.Resources = append(graph.Resources, &resource.Desired{
+			Name:  name,
+			Type:  "nop",
+			Input: cty.EmptyObjectVal,
+		})
+	}
+
+	wantEvents := teststore.Events{
+		{Method: "ListResources", Project: "proj"},
+	}
+	for i, name := range []string{"a", "b", "c", "d", "e"} {
+		wantEvents = append(wantEvents, teststore.Event{
+			Method:  "PutResource",
+			Project: "proj",
+			Data: &resource.Deployed{
+				Desired: &resource.Desired{Name: name, Type: "nop", Input: cty.EmptyObjectVal},
+				ID:      fmt.Sprintf("id%d", i),
+				Output:  cty.EmptyObjectVal,
+			},
+		})
+	}
+
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool {
+			return a.Equals(b).True()
+		}),
+		cmpopts.IgnoreFields(resource.Deployed{}, "LastAppliedAt", "LastDuration"),
+	}
+
+	for i := 0; i < 10; i++ {
+		rec := &teststore.Recorder{Store: &teststore.Store{}}
+		reco := &reconciler.Reconciler{
+			Resources: rec,
+			Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+				"nop": &nop{},
+			}),
+			Logger:        zaptest.NewLogger(t),
+			IDGen:         &sequence{},
+			Concurrency:   1,
+			Deterministic: true,
+		}
+
+		if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+
+		if diff := cmp.Diff(rec.Events, wantEvents, opts...); diff != "" {
+			t.Fatalf("Run %d: Events (-got +want)\n%s", i, diff)
+		}
+	}
+}
+
+func TestReconciler_Reconcile_deterministic_branches(t *testing.T) {
+	gateOpen = make(chan struct{})
+
+	// b comes before c in the schedule order, but only c is ready while a
+	// is processing. a is blocked until c has been created.
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "a", Type: "gate", Input: cty.EmptyObjectVal},
+			{Name: "b", Type: "nop", Input: cty.EmptyObjectVal, DependsOn: []string{"a"}},
+			{Name: "c", Type: "opener", Input: cty.EmptyObjectVal},
+		},
+	}
+
+	reco := &reconciler.Reconciler{
+		Resources: &teststore.Store{},
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"gate":   &gate{},
+			"nop":    &nop{},
+			"opener": &opener{},
+		}),
+		Logger:        zaptest.NewLogger(t),
+		IDGen:         &sequence{},
+		Concurrency:   2,
+		Deterministic: true,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := reco.Reconcile(ctx, "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+}
+
+func TestReconciler_Reconcile_clientCache(t *testing.T) {
+	atomic.StoreInt32(&regionalClients, 0)
+
+	graph := &resource.Graph{}
+	regions := map[string]string{
+		"a": "us-east-1",
+		"b": "us-east-1",
+		"c": "eu-west-1",
+		"d": "us-east-1",
+		"e": "eu-west-1",
+	}
+	for name, region := range regions {
+		graph.Resources = append(graph.Resources, &resource.Desired{
+			Name: name,
+			Type: "regional",
+			Input: cty.ObjectVal(map[string]cty.Value{
+				"region": cty.StringVal(region),
+			}),
+		})
+	}
+
+	auth := &countingAuth{}
+	reco := &reconciler.Reconciler{
+		Resources: &teststore.Store{},
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"regional": &regional{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+		Auth:   auth,
+	}
+
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if got := atomic.LoadInt32(&regionalClients); got != 2 {
+		t.Errorf("Created %d clients, want one per region (2)", got)
+	}
+	if got := atomic.LoadInt32(&auth.calls); got != 1 {
+		t.Errorf("Resolved credentials %d times, want 1", got)
+	}
+}
+
+func TestReconciler_Reconcile_profile(t *testing.T) {
+	store := &teststore.Store{}
+	var profiles []string
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"authenticated": &authenticated{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+		Auth:   profileAuth("default"),
+		ProfileAuth: func(profile string) resource.AuthProvider {
+			profiles = append(profiles, profile)
+			return profileAuth(profile)
+		},
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "a", Type: "authenticated", Input: cty.EmptyObjectVal},
+			{Name: "b", Type: "authenticated", Input: cty.EmptyObjectVal, Profile: "prod"},
+			{Name: "c", Type: "authenticated", Input: cty.EmptyObjectVal, Profile: "prod"},
+		},
+	}
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	list, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	got := make(map[string]string, len(list))
+	for _, res := range list {
+		got[res.Name] = res.Output.GetAttr("profile").AsString()
+	}
+	want := map[string]string{"a": "default", "b": "prod", "c": "prod"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Profiles (-got, +want)\n%s", diff)
+	}
+
+	// The auth provider for a profile is created once per run.
+	if diff := cmp.Diff(profiles, []string{"prod"}); diff != "" {
+		t.Errorf("ProfileAuth calls (-got, +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_metrics(t *testing.T) {
+	metrics := &fakeMetrics{}
+	reco := &reconciler.Reconciler{
+		Resources: &teststore.Store{},
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"retried": &retried{},
+		}),
+		Logger:  zaptest.NewLogger(t),
+		IDGen:   &sequence{},
+		Backoff: func() backoff.BackOff { return &backoff.ZeroBackOff{} },
+		Metrics: metrics,
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "foo", Type: "retried", Input: cty.EmptyObjectVal},
+		},
+	}
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	wantOps := []string{
+		"retried create true",
+		"retried put_state true",
+	}
+	if diff := cmp.Diff(metrics.ops, wantOps); diff != "" {
+		t.Errorf("Ops (-got, +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(metrics.retries, map[string]int{"retried": 1}); diff != "" {
+		t.Errorf("Retries (-got, +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_duration(t *testing.T) {
+	store := &teststore.Store{}
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"slow": &slow{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "foo", Type: "slow", Input: cty.EmptyObjectVal},
+		},
+	}
+
+	before := time.Now()
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	got, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Got %d resources, want 1", len(got))
+	}
+	if got[0].LastDuration < time.Millisecond {
+		t.Errorf("LastDuration = %s, want at least 1ms", got[0].LastDuration)
+	}
+	if got[0].LastAppliedAt.Before(before) || got[0].LastAppliedAt.After(time.Now()) {
+		t.Errorf("LastAppliedAt = %s, want between %s and now", got[0].LastAppliedAt, before)
+	}
+}
+
+func TestReconciler_Reconcile_waitReady(t *testing.T) {
+	atomic.StoreInt32(&warmupPolls, 0)
+
+	store := &teststore.Store{}
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"warmup":      &warmup{},
+			"passthrough": &passthrough{},
+		}),
+		Logger:  zaptest.NewLogger(t),
+		IDGen:   &sequence{},
+		Backoff: func() backoff.BackOff { return &backoff.ZeroBackOff{} },
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{
+				Name:  "foo",
+				Type:  "warmup",
+				Input: cty.ObjectVal(map[string]cty.Value{"polls": cty.NumberIntVal(3)}),
+			},
+			{
+				Name:  "bar",
+				Type:  "passthrough",
+				Input: cty.ObjectVal(map[string]cty.Value{"input": cty.UnknownVal(cty.String)}),
+			},
+		},
+		Dependencies: []*resource.Dependency{
+			{
+				Child: "bar",
+				Field: cty.GetAttrPath("input"),
+				Expression: resource.Expression{
+					resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("status")},
+				},
+			},
+		},
+	}
+
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if n := atomic.LoadInt32(&warmupPolls); n != 3 {
+		t.Errorf("WaitReady called %d times, want 3", n)
+	}
+
+	got, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	for _, res := range got {
+		if res.Name != "bar" {
+			continue
+		}
+		want := cty.ObjectVal(map[string]cty.Value{"output": cty.StringVal("ready")})
+		if !res.Output.RawEquals(want) {
+			t.Errorf("Child output = %#v, want %#v", res.Output, want)
+		}
+		return
+	}
+	t.Errorf("Child resource was not created")
+}
+
+func TestReconciler_Reconcile_waitFailed(t *testing.T) {
+	store := &teststore.Store{}
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"notready": &notReady{},
+		}),
+		Logger:     zaptest.NewLogger(t),
+		IDGen:      &sequence{},
+		Quarantine: time.Hour,
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{{
+			Name:  "foo",
+			Type:  "notready",
+			Input: cty.EmptyObjectVal,
+		}},
+	}
+
+	err := reco.Reconcile(context.Background(), "", "proj", graph)
+	if err == nil {
+		t.Fatal("Reconcile() error = nil, want error")
+	}
+
+	// The resource was created, so it must be stored even though it did not
+	// become ready.
+	got, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Got %d resources, want 1", len(got))
+	}
+	if got[0].ID != "id0" {
+		t.Errorf("ID = %q, want %q", got[0].ID, "id0")
+	}
+	want := cty.ObjectVal(map[string]cty.Value{"arn": cty.StringVal("arn:notready:foo")})
+	if !got[0].Output.RawEquals(want) {
+		t.Errorf("Output = %#v, want %#v", got[0].Output, want)
+	}
+	if got[0].Failure == nil {
+		t.Fatal("Failure is not recorded")
+	}
+	if !got[0].Exists() {
+		t.Errorf("Exists() = false, want true")
+	}
+}
+
+func TestReconciler_Reconcile_waitFailedNoQuarantine(t *testing.T) {
+	store := &teststore.Store{}
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"notready": &notReady{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{{
+			Name:  "foo",
+			Type:  "notready",
+			Input: cty.EmptyObjectVal,
+		}},
+	}
+
+	err := reco.Reconcile(context.Background(), "", "proj", graph)
+	if err == nil {
+		t.Fatal("Reconcile() error = nil, want error")
+	}
+
+	// The resource is stored, but quarantine is disabled so the failure is
+	// not recorded.
+	got, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Got %d resources, want 1", len(got))
+	}
+	if got[0].ID != "id0" {
+		t.Errorf("ID = %q, want %q", got[0].ID, "id0")
+	}
+	if got[0].Failure != nil {
+		t.Errorf("Failure = %+v, want nil", got[0].Failure)
+	}
+}
+
+func TestReconciler_Reconcile_attempt(t *testing.T) {
+	flakyAttempts = nil
+
+	reco := &reconciler.Reconciler{
+		Resources: &teststore.Store{},
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"flaky": &flaky{},
+		}),
+		Logger:  zaptest.NewLogger(t),
+		IDGen:   &sequence{},
+		Backoff: func() backoff.BackOff { return &backoff.ZeroBackOff{} },
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "foo", Type: "flaky", Input: cty.EmptyObjectVal},
+		},
+	}
+
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	want := []int{1, 2, 3}
+	if diff := cmp.Diff(flakyAttempts, want); diff != "" {
+		t.Errorf("Attempts do not match (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_description(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	reco := &reconciler.Reconciler{
+		Resources: &teststore.Store{},
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"passthrough": &passthrough{},
+		}),
+		Logger: zap.New(core),
+		IDGen:  &sequence{},
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{
+				Name:        "foo",
+				Type:        "passthrough",
+				Description: "Greets the user",
+				Input:       cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("hello")}),
+			},
+		},
+	}
+
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	created := logs.FilterMessage("Creating resource").AllUntimed()
+	if len(created) != 1 {
+		t.Fatalf("Got %d create log entries, want 1", len(created))
+	}
+	if got, want := created[0].ContextMap()["description"], "Greets the user"; got != want {
+		t.Errorf("Logged description = %v, want %q", got, want)
+	}
+}
+
+func TestReconciler_Reconcile_unsetOutput(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	reco := &reconciler.Reconciler{
+		Resources: &teststore.Store{},
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"forgetful":   &forgetful{},
+			"passthrough": &passthrough{},
+		}),
+		Logger: zap.New(core),
+		IDGen:  &sequence{},
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "foo", Type: "forgetful", Input: cty.EmptyObjectVal},
+			{
+				Name:  "bar",
+				Type:  "passthrough",
+				Input: cty.ObjectVal(map[string]cty.Value{"input": cty.UnknownVal(cty.String)}),
+			},
+		},
+		Dependencies: []*resource.Dependency{
+			{
+				Child: "bar",
+				Field: cty.GetAttrPath("input"),
+				Expression: resource.Expression{
+					resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("arn")},
+					resource.ExprReference{Path: cty.GetAttrPath("foo").GetAttr("name")},
+				},
+			},
+		},
+	}
+
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	warnings := logs.FilterMessage("Referenced output was not set").AllUntimed()
+	var got []string
+	for _, w := range warnings {
+		got = append(got, w.ContextMap()["output"].(string))
+	}
+	// name is set by the provider and id is not referenced.
+	want := []string{"arn"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Warnings (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_keepNestedOutput(t *testing.T) {
+	store := &teststore.Store{}
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"nested": &nestedOutput{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	reconcile := func(input string) {
+		t.Helper()
+		graph := &resource.Graph{
+			Resources: []*resource.Desired{{
+				Name:  "foo",
+				Type:  "nested",
+				Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal(input)}),
+			}},
+		}
+		if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+
+	reconcile("a")
+	reconcile("b") // Update only sets endpoint.port
+
+	list, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Got %d resources, want 1", len(list))
+	}
+	want := cty.ObjectVal(map[string]cty.Value{
+		"arn": cty.StringVal("arn:a"),
+		"endpoint": cty.ObjectVal(map[string]cty.Value{
+			"host": cty.StringVal("a.example.com"),
+			"port": cty.NumberIntVal(2),
+		}),
+	})
+	if got := list[0].Output; !got.RawEquals(want) {
+		t.Errorf("Output does not match\nGot  %s\nWant %s", got.GoString(), want.GoString())
+	}
+}
+
+func TestReconciler_Reconcile_defaultName(t *testing.T) {
+	atomic.StoreInt32(&namedUpdates, 0)
+
+	store := &teststore.Store{}
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"named": &named{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	unset := cty.ObjectVal(map[string]cty.Value{"name": cty.NullVal(cty.String)})
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "foo", Type: "named", Input: unset},
+			{Name: "mod.bar[\"a\"]", Type: "named", Input: unset},
+			{
+				Name:  "baz",
+				Type:  "named",
+				Input: cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("custom")}),
+			},
+		},
+	}
+
+	want := map[string]string{
+		"foo":            "proj-foo",
+		"mod.bar[\"a\"]": "proj-mod-bar-a",
+		"baz":            "custom",
+	}
+
+	// The default name is stored, so reconciling again does not change it.
+	for i := 0; i < 2; i++ {
+		if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+
+		list, err := store.ListResources(context.Background(), "proj")
+		if err != nil {
+			t.Fatalf("ListResources() error = %v", err)
+		}
+		got := make(map[string]string)
+		for _, res := range list {
+			name := res.Input.GetAttr("name").AsString()
+			if created := res.Output.GetAttr("created").AsString(); created != name {
+				t.Errorf("%s: created %q, stored name %q", res.Name, created, name)
+			}
+			got[res.Name] = name
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("Run %d: names (-got +want)\n%s", i, diff)
+		}
+	}
+
+	if n := atomic.LoadInt32(&namedUpdates); n != 0 {
+		t.Errorf("Got %d updates, want 0", n)
+	}
+}
+
+func TestReconciler_Reconcile_setDefaults(t *testing.T) {
+	store := &teststore.Store{}
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"defaulted": &defaulted{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	input := func(method, integration cty.Value) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"method":             method,
+			"integration_method": integration,
+		})
+	}
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "default", Type: "defaulted", Input: input(cty.StringVal("GET"), cty.NullVal(cty.String))},
+			{Name: "explicit", Type: "defaulted", Input: input(cty.StringVal("GET"), cty.StringVal("POST"))},
+		},
+	}
+
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	list, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	want := map[string]string{
+		"default":  "GET",
+		"explicit": "POST",
+	}
+	got := make(map[string]string)
+	for _, res := range list {
+		// The provider received the default.
+		got[res.Name] = res.Output.GetAttr("sent").AsString()
+		// The default is stored with the inputs.
+		if stored := res.Input.GetAttr("integration_method").AsString(); stored != got[res.Name] {
+			t.Errorf("%s: stored %q, sent %q", res.Name, stored, got[res.Name])
+		}
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Sent methods (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_hooks(t *testing.T) {
+	for _, deterministic := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Deterministic=%t", deterministic), func(t *testing.T) {
+			store := &teststore.Store{}
+			// b was deployed before, it must be kept when skipped.
+			store.SeedResources("proj", []*resource.Deployed{{
+				Desired: &resource.Desired{Name: "b", Type: "passthrough", Input: cty.ObjectVal(map[string]cty.Value{
+					"input": cty.StringVal("old"),
+				})},
+				ID:     "existing",
+				Output: cty.ObjectVal(map[string]cty.Value{"output": cty.StringVal("old")}),
+			}})
+
+			var mu sync.Mutex
+			var before, after []string
+			vetoErr := errors.New("not approved")
+			reco := &reconciler.Reconciler{
+				Resources: store,
+				Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+					"nop":         &nop{},
+					"passthrough": &passthrough{},
+				}),
+				Logger:        zaptest.NewLogger(t),
+				IDGen:         &sequence{},
+				Deterministic: deterministic,
+				BeforeApply: func(ctx context.Context, res *resource.Desired, op string) error {
+					mu.Lock()
+					defer mu.Unlock()
+					before = append(before, res.Name+" "+op)
+					if res.Name == "a" {
+						return vetoErr
+					}
+					return nil
+				},
+				AfterApply: func(ctx context.Context, res *resource.Desired, op string, err error) {
+					mu.Lock()
+					defer mu.Unlock()
+					after = append(after, fmt.Sprintf("%s %s %v", res.Name, op, err))
+				},
+			}
+
+			// b depends on a, c is unrelated.
+			graph := &resource.Graph{
+				Resources: []*resource.Desired{
+					{Name: "a", Type: "nop", Input: cty.EmptyObjectVal},
+					{
+						Name:      "b",
+						Type:      "passthrough",
+						Input:     cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("new")}),
+						DependsOn: []string{"a"},
+					},
+					{Name: "c", Type: "nop", Input: cty.EmptyObjectVal},
+				},
+			}
+
+			err := reco.Reconcile(context.Background(), "", "proj", graph)
+			verr, ok := err.(*reconciler.VetoError)
+			if !ok {
+				t.Fatalf("Reconcile() error = %v, want *VetoError", err)
+			}
+			if len(verr.Vetoed) != 1 || verr.Vetoed["a"] != vetoErr {
+				t.Errorf("Vetoed = %v, want a: %v", verr.Vetoed, vetoErr)
+			}
+			if diff := cmp.Diff(verr.Skipped, []string{"b"}); diff != "" {
+				t.Errorf("Skipped (-got +want)\n%s", diff)
+			}
+
+			sort.Strings(before)
+			if diff := cmp.Diff(before, []string{"a create", "c create"}); diff != "" {
+				t.Errorf("BeforeApply (-got +want)\n%s", diff)
+			}
+			if diff := cmp.Diff(after, []string{"c create <nil>"}); diff != "" {
+				t.Errorf("AfterApply (-got +want)\n%s", diff)
+			}
+
+			list, err := store.ListResources(context.Background(), "proj")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]string)
+			for _, res := range list {
+				got[res.Name] = res.ID
+			}
+			want := map[string]string{
+				"b": "existing", // Not updated or deleted.
+				"c": "id0",
+			}
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Errorf("Stored resources (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconciler_Reconcile_quarantine(t *testing.T) {
+	store := &teststore.Store{}
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"nop":  &nop{},
+			"fail": &fail{},
+		}),
+		Logger:     zaptest.NewLogger(t),
+		IDGen:      &sequence{},
+		Backoff:    func() backoff.BackOff { return &backoff.StopBackOff{} },
+		Quarantine: time.Hour,
+	}
+
+	// b depends on a, c is unrelated.
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "a", Type: "fail", Input: cty.EmptyObjectVal},
+			{Name: "b", Type: "nop", Input: cty.EmptyObjectVal, DependsOn: []string{"a"}},
+			{Name: "c", Type: "nop", Input: cty.EmptyObjectVal},
+		},
+	}
+
+	failure := func() *resource.Failure {
+		t.Helper()
+		list, err := store.ListResources(context.Background(), "proj")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, res := range list {
+			if res.Name == "a" {
+				return res.Failure
+			}
+		}
+		t.Fatalf("Failure for a not stored")
+		return nil
+	}
+
+	// First attempt fails.
+	err := reco.Reconcile(context.Background(), "", "proj", graph)
+	if err == nil {
+		t.Fatal("Reconcile() want error")
+	}
+	if _, ok := err.(*reconciler.VetoError); ok {
+		t.Fatalf("Reconcile() error = %v, want create error", err)
+	}
+	f := failure()
+	if f.Count != 1 || !f.Create || !strings.Contains(f.Error, "fail") {
+		t.Errorf("Failure = %+v, want first failed create", f)
+	}
+
+	// a is quarantined and not attempted, b is skipped.
+	err = reco.Reconcile(context.Background(), "", "proj", graph)
+	verr, ok := err.(*reconciler.VetoError)
+	if !ok {
+		t.Fatalf("Reconcile() error = %v, want *VetoError", err)
+	}
+	if _, ok := verr.Vetoed["a"]; !ok || len(verr.Vetoed) != 1 {
+		t.Errorf("Vetoed = %v, want a", verr.Vetoed)
+	}
+	if diff := cmp.Diff(verr.Skipped, []string{"b"}); diff != "" {
+		t.Errorf("Skipped (-got +want)\n%s", diff)
+	}
+	if got := failure().Count; got != 1 {
+		t.Errorf("Failure count after quarantine = %d, want 1", got)
+	}
+
+	list, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, res := range list {
+		names = append(names, res.Name)
+	}
+	sort.Strings(names)
+	if diff := cmp.Diff(names, []string{"a", "c"}); diff != "" {
+		t.Errorf("Stored resources (-got +want)\n%s", diff)
+	}
+
+	// The quarantine has passed.
+	reco.Quarantine = time.Nanosecond
+	err = reco.Reconcile(context.Background(), "", "proj", graph)
+	if _, ok := err.(*reconciler.VetoError); ok || err == nil {
+		t.Fatalf("Reconcile() error = %v, want create error", err)
+	}
+	if got := failure().Count; got != 2 {
+		t.Errorf("Failure count after quarantine passed = %d, want 2", got)
+	}
+}
+
+func TestReconciler_Reconcile_quarantineRetry(t *testing.T) {
+	store := &teststore.Store{}
+	store.SeedResources("proj", []*resource.Deployed{{
+		Desired: &resource.Desired{Name: "a", Type: "failing", Input: cty.ObjectVal(map[string]cty.Value{
+			"fail": cty.True,
+		})},
+		ID:     "failed",
+		Output: cty.NullVal(cty.EmptyObject),
+		Failure: &resource.Failure{
+			Error:  "limit exceeded",
+			At:     time.Now(),
+			Count:  2,
+			Create: true,
+		},
+	}})
+
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"failing": &failing{},
+		}),
+		Logger:     zaptest.NewLogger(t),
+		IDGen:      &sequence{},
+		Backoff:    func() backoff.BackOff { return &backoff.StopBackOff{} },
+		Quarantine: time.Hour,
+	}
+
+	// The cause of the failure is fixed.
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "a", Type: "failing", Input: cty.ObjectVal(map[string]cty.Value{"fail": cty.False})},
+		},
+	}
+
+	// Without retry, the resource is still quarantined.
+	err := reco.Reconcile(context.Background(), "", "proj", graph)
+	if _, ok := err.(*reconciler.VetoError); !ok {
+		t.Fatalf("Reconcile() error = %v, want *VetoError", err)
+	}
+
+	ctx := reconciler.WithRetry(context.Background(), "failing.a")
+	if err := reco.Reconcile(ctx, "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() with retry error = %v", err)
+	}
+
+	list, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Got %d stored resources, want 1", len(list))
+	}
+	got := list[0]
+	if got.ID != "failed" {
+		t.Errorf("ID = %q, want recorded failure to be replaced", got.ID)
+	}
+	if got.Failure != nil {
+		t.Errorf("Failure = %+v, want cleared", got.Failure)
+	}
+	if !got.Exists() {
+		t.Errorf("Resource does not exist after retry")
+	}
+}
+
+func TestReconciler_Reconcile_quarantineUpdate(t *testing.T) {
+	store := &teststore.Store{}
+	existing := &resource.Deployed{
+		Desired: &resource.Desired{Name: "a", Type: "failing", Input: cty.ObjectVal(map[string]cty.Value{
+			"fail": cty.False,
+		})},
+		ID:     "existing",
+		Output: cty.EmptyObjectVal,
+	}
+	store.SeedResources("proj", []*resource.Deployed{existing})
+
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"failing": &failing{},
+		}),
+		Logger:     zaptest.NewLogger(t),
+		IDGen:      &sequence{},
+		Backoff:    func() backoff.BackOff { return &backoff.StopBackOff{} },
+		Quarantine: time.Hour,
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "a", Type: "failing", Input: cty.ObjectVal(map[string]cty.Value{"fail": cty.True})},
+		},
+	}
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err == nil {
+		t.Fatal("Reconcile() want error")
+	}
+
+	list, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Got %d stored resources, want 1", len(list))
+	}
+	got := list[0]
+	if got.Failure == nil || got.Failure.Create {
+		t.Fatalf("Failure = %+v, want failed update", got.Failure)
+	}
+	// The previously deployed resource is kept.
+	if !got.Input.RawEquals(existing.Input) || got.ID != "existing" {
+		t.Errorf("Stored resource = %v %#v, want existing resource", got.ID, got.Input)
+	}
+}
+
+func TestReconciler_Reconcile_projectTags(t *testing.T) {
+	store := &teststore.Store{}
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"tagged": &tagged{},
+			"nop":    &nop{},
+		}),
+		Logger:      zaptest.NewLogger(t),
+		IDGen:       &sequence{},
+		ProjectTags: true,
+	}
+
+	tags := func(m map[string]string) cty.Value {
+		vals := make(map[string]cty.Value, len(m))
+		for k, v := range m {
+			vals[k] = cty.StringVal(v)
+		}
+		return cty.MapVal(vals)
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{
+				Name:  "foo",
+				Type:  "tagged",
+				Input: cty.ObjectVal(map[string]cty.Value{"tags": cty.NullVal(cty.Map(cty.String))}),
+			},
+			{
+				Name: "mod.bar",
+				Type: "tagged",
+				Input: cty.ObjectVal(map[string]cty.Value{"tags": tags(map[string]string{
+					"team":                  "a",
+					reconciler.NamespaceTag: "custom",
+				})}),
+			},
+			{
+				Name:  "mod.qux",
+				Type:  "tagged",
+				Input: cty.ObjectVal(map[string]cty.Value{"tags": cty.NullVal(cty.Map(cty.String))}),
+			},
+			{Name: "baz", Type: "nop", Input: cty.EmptyObjectVal},
+		},
+	}
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	list, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	got := make(map[string]cty.Value)
+	for _, res := range list {
+		if res.Type == "tagged" {
+			got[res.Name] = res.Output.GetAttr("applied")
+		}
+	}
+	want := map[string]cty.Value{
+		"foo": tags(map[string]string{reconciler.ProjectTag: "proj"}),
+		// User tags win on conflict.
+		"mod.bar": tags(map[string]string{
+			"team":                  "a",
+			reconciler.ProjectTag:   "proj",
+			reconciler.NamespaceTag: "custom",
+		}),
+		"mod.qux": tags(map[string]string{
+			reconciler.ProjectTag:   "proj",
+			reconciler.NamespaceTag: "mod",
+		}),
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool { return a.Equals(b).True() }),
+	}
+	if diff := cmp.Diff(got, want, opts...); diff != "" {
+		t.Errorf("Applied tags (-got +want)\n%s", diff)
+	}
+
+	// Tags are added the same way on the next run, so nothing is updated.
+	rec := &teststore.Recorder{Store: store}
+	reco.Resources = rec
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	for _, e := range rec.Events {
+		if e.Method == "PutResource" {
+			t.Errorf("Resource %s was updated", e.Data.(*resource.Deployed).Name)
+		}
+	}
+}
+
+func TestReconciler_Reconcile_projectTagsList(t *testing.T) {
+	store := &teststore.Store{}
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"tagged": &taggedList{},
+		}),
+		Logger:      zaptest.NewLogger(t),
+		IDGen:       &sequence{},
+		ProjectTags: true,
+	}
+
+	tag := func(k, v string) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"key":   cty.StringVal(k),
+			"value": cty.StringVal(v),
+		})
+	}
+	tagType := cty.Object(map[string]cty.Type{"key": cty.String, "value": cty.String})
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{
+				Name:  "foo",
+				Type:  "tagged",
+				Input: cty.ObjectVal(map[string]cty.Value{"tag": cty.NullVal(cty.List(tagType))}),
+			},
+			{
+				Name: "mod.bar",
+				Type: "tagged",
+				Input: cty.ObjectVal(map[string]cty.Value{"tag": cty.ListVal([]cty.Value{
+					tag("team", "a"),
+					tag(reconciler.NamespaceTag, "custom"),
+				})}),
+			},
+		},
+	}
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	list, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	got := make(map[string]cty.Value)
+	for _, res := range list {
+		got[res.Name] = res.Output.GetAttr("applied")
+	}
+	want := map[string]cty.Value{
+		"foo": cty.MapVal(map[string]cty.Value{
+			reconciler.ProjectTag: cty.StringVal("proj"),
+		}),
+		// User tags win on conflict.
+		"mod.bar": cty.MapVal(map[string]cty.Value{
+			"team":                  cty.StringVal("a"),
+			reconciler.ProjectTag:   cty.StringVal("proj"),
+			reconciler.NamespaceTag: cty.StringVal("custom"),
+		}),
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b cty.Value) bool { return a.Equals(b).True() }),
+	}
+	if diff := cmp.Diff(got, want, opts...); diff != "" {
+		t.Errorf("Applied tags (-got +want)\n%s", diff)
+	}
+
+	// Tags are added the same way on the next run, so nothing is updated.
+	rec := &teststore.Recorder{Store: store}
+	reco.Resources = rec
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	for _, e := range rec.Events {
+		if e.Method == "PutResource" {
+			t.Errorf("Resource %s was updated", e.Data.(*resource.Deployed).Name)
+		}
+	}
+}
+
+func TestReconciler_Reconcile_idOutput(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	store := &teststore.Store{}
+	reco := &reconciler.Reconciler{
+		Resources: store,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"generated": &generated{},
+		}),
+		Logger: zap.New(core),
+		IDGen:  &sequence{},
+	}
+
+	reconcile := func(foo, bar string) {
+		t.Helper()
+		input := func(v string) cty.Value {
+			return cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal(v)})
+		}
+		graph := &resource.Graph{
+			Resources: []*resource.Desired{
+				{Name: "foo", Type: "generated", Input: input(foo)},
+				{Name: "bar", Type: "generated", Input: input(bar)},
+			},
+		}
+		if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+
+	// bar does not get an id.
+	reconcile("a", "")
+	warnings := logs.FilterMessage("ID output was not set").AllUntimed()
+	if len(warnings) != 1 {
+		t.Fatalf("Got %d warnings, want 1", len(warnings))
+	}
+	if got := warnings[0].ContextMap()["name"]; got != "bar" {
+		t.Errorf("Warning for %v, want bar", got)
+	}
+
+	// The id from create is set when foo is updated.
+	reconcile("b", "")
+	list, err := store.ListResources(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	for _, res := range list {
+		if res.Name != "foo" {
+			continue
+		}
+		want := cty.ObjectVal(map[string]cty.Value{
+			"id":   cty.StringVal("gen-a"),
+			"seen": cty.StringVal("gen-a"),
+		})
+		if !res.Output.RawEquals(want) {
+			t.Errorf("Output does not match\nGot  %s\nWant %s", res.Output.GoString(), want.GoString())
+		}
+	}
+}
+
+func TestReconciler_Reconcile_transientStoreErrors(t *testing.T) {
+	errTransient := errors.New("transient")
+	rec := &teststore.Recorder{
+		Store: &teststore.Store{},
+		Errors: map[string][]error{
+			"PutResource":    {errTransient, errTransient},
+			"DeleteResource": {errTransient},
+		},
+	}
+	reco := &reconciler.Reconciler{
+		Resources: rec,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"nop": &nop{},
+		}),
+		Logger:  zaptest.NewLogger(t),
+		IDGen:   &sequence{},
+		Backoff: func() backoff.BackOff { return &backoff.ZeroBackOff{} },
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "foo", Type: "nop", Input: cty.EmptyObjectVal},
+		},
+	}
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := reco.Reconcile(context.Background(), "", "proj", &resource.Graph{}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	type event struct {
+		Method string
+		Err    error
+	}
+	var got []event
+	for _, e := range rec.Events {
+		got = append(got, event{e.Method, e.Err})
+	}
+	want := []event{
+		{"ListResources", nil},
+		{"PutResource", errTransient},
+		{"PutResource", errTransient},
+		{"PutResource", nil},
+		{"ListResources", nil},
+		{"DeleteResource", errTransient},
+		{"DeleteResource", nil},
+	}
+	opts := cmp.Comparer(func(a, b error) bool { return a == b })
+	if diff := cmp.Diff(got, want, opts); diff != "" {
+		t.Errorf("Events (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_requestMetadata(t *testing.T) {
+	identifiedReqs = nil
+
+	reco := &reconciler.Reconciler{
+		Resources: &teststore.Store{},
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"identified": &identified{},
+		}),
+		Logger: zaptest.NewLogger(t),
+		IDGen:  &sequence{},
+	}
+
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "foo", Type: "identified", Input: cty.EmptyObjectVal},
+			{Name: "mod.bar", Type: "identified", Input: cty.EmptyObjectVal},
+		},
+	}
+	if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := reco.Reconcile(context.Background(), "", "proj", &resource.Graph{}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	want := []string{
+		"create proj/foo",
+		"create proj/mod.bar",
+		"delete proj/foo",
+		"delete proj/mod.bar",
+	}
+	got := append([]string(nil), identifiedReqs...)
+	sort.Strings(got)
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Requests (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_maxRetryDuration(t *testing.T) {
+	graph := &resource.Graph{
+		Resources: []*resource.Desired{
+			{Name: "foo", Type: "fail", Input: cty.EmptyObjectVal},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		backoff  func() backoff.BackOff
+		wantText string
+	}{
+		{"Default", nil, "(attempts: 1)"},
+		{
+			"Override",
+			func() backoff.BackOff { return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2) },
+			"(attempts: 3)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reco := &reconciler.Reconciler{
+				Resources: &teststore.Store{},
+				Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+					"fail": &fail{},
+				}),
+				Logger:           zaptest.NewLogger(t),
+				IDGen:            &sequence{},
+				Backoff:          tt.backoff,
+				MaxRetryDuration: time.Nanosecond, // Ignored when Backoff is set.
+			}
+
+			err := reco.Reconcile(context.Background(), "", "proj", graph)
+			if err == nil {
+				t.Fatal("Reconcile() want error")
+			}
+			if !strings.Contains(err.Error(), tt.wantText) {
+				t.Errorf("Error = %q, want to contain %q", err, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	// The first interval is 500ms, randomized by 50%.
+	min, max := 250*time.Millisecond, 750*time.Millisecond
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 20; i++ {
+		d := reconciler.DefaultBackoff(0).NextBackOff()
+		if d < min || d > max {
+			t.Errorf("NextBackOff() = %s, want between %s and %s", d, min, max)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("NextBackOff() returned the same interval every time, want jitter")
+	}
+}
+
 // Test resource definitions
 
 type nop struct{}
@@ -590,6 +3081,498 @@ func (p *passthrough) Delete(ctx context.Context, req *resource.DeleteRequest) e
 	return nil
 }
 
+// nestedOutput sets all outputs on create, but only the endpoint port on
+// update.
+type nestedOutput struct {
+	nop
+	Input    string `func:"input"`
+	Arn      string `func:"output"`
+	Endpoint struct {
+		Host string
+		Port int
+	} `func:"output"`
+}
+
+func (n *nestedOutput) Create(ctx context.Context, req *resource.CreateRequest) error {
+	n.Arn = "arn:" + n.Input
+	n.Endpoint.Host = n.Input + ".example.com"
+	n.Endpoint.Port = 1
+	return nil
+}
+
+func (n *nestedOutput) Update(ctx context.Context, req *resource.UpdateRequest) error {
+	n.Endpoint.Port++
+	return nil
+}
+
+// tagged outputs the tags it was created with.
+type tagged struct {
+	nop
+	Tags    map[string]string `func:"input"`
+	Applied map[string]string `func:"output"`
+}
+
+func (p *tagged) Create(ctx context.Context, req *resource.CreateRequest) error {
+	p.Applied = p.Tags
+	return nil
+}
+
+// taggedList outputs the tags it was created with, declared as a list of
+// key-value pairs.
+type taggedList struct {
+	nop
+	Tags []struct {
+		Key   string
+		Value string
+	} `func:"input" name:"tag"`
+	Applied map[string]string `func:"output"`
+}
+
+func (p *taggedList) Create(ctx context.Context, req *resource.CreateRequest) error {
+	p.Applied = make(map[string]string, len(p.Tags))
+	for _, t := range p.Tags {
+		p.Applied[t.Key] = t.Value
+	}
+	return nil
+}
+
+// namedUpdates counts the updates to named resources.
+var namedUpdates int32
+
+// named creates a resource with the name it was given, or a default name.
+type named struct {
+	nop
+	Name    *string `func:"input"`
+	Created string  `func:"output"`
+}
+
+func (p *named) DefaultName(addr string) {
+	if p.Name == nil {
+		p.Name = &addr
+	}
+}
+
+func (p *named) Create(ctx context.Context, req *resource.CreateRequest) error {
+	p.Created = *p.Name
+	return nil
+}
+
+func (p *named) Update(ctx context.Context, req *resource.UpdateRequest) error {
+	atomic.AddInt32(&namedUpdates, 1)
+	p.Created = *p.Name
+	return nil
+}
+
+// defaulted defaults the integration method to the method.
+type defaulted struct {
+	nop
+	Method            string  `func:"input"`
+	IntegrationMethod *string `func:"input"`
+	Sent              string  `func:"output"`
+}
+
+func (p *defaulted) SetDefaults() {
+	if p.IntegrationMethod == nil {
+		p.IntegrationMethod = &p.Method
+	}
+}
+
+func (p *defaulted) Create(ctx context.Context, req *resource.CreateRequest) error {
+	p.Sent = *p.IntegrationMethod
+	return nil
+}
+
+// generated gets an id on create, which is required to update it.
+type generated struct {
+	nop
+	Input string `func:"input"`
+	ID    string `func:"output,id"`
+	Seen  string `func:"output"`
+}
+
+func (p *generated) Create(ctx context.Context, req *resource.CreateRequest) error {
+	if p.Input != "" {
+		p.ID = "gen-" + p.Input
+	}
+	return nil
+}
+
+func (p *generated) Update(ctx context.Context, req *resource.UpdateRequest) error {
+	if p.ID == "" {
+		return backoff.Permanent(errors.New("id not set"))
+	}
+	p.Seen = p.ID
+	return nil
+}
+
+// forgetful never sets its arn and id outputs.
+type forgetful struct {
+	nop
+	Arn  string `func:"output"`
+	ID   string `func:"output"`
+	Name string `func:"output"`
+}
+
+func (f *forgetful) Create(ctx context.Context, req *resource.CreateRequest) error {
+	f.Name = "foo"
+	return nil
+}
+
+// validated fails validation if valid is not set.
+type validated struct {
+	nop
+	Valid bool `func:"input"`
+}
+
+func (v *validated) Validate() error {
+	if !v.Valid {
+		return errors.New("not valid")
+	}
+	return nil
+}
+
+// replaceable must be replaced when its name changes.
+type replaceable struct {
+	Name   string  `func:"input,force_new"`
+	Value  *string `func:"input"`
+	Output string  `func:"output"`
+}
+
+func (r *replaceable) Create(ctx context.Context, req *resource.CreateRequest) error {
+	r.Output = r.Name
+	return nil
+}
+func (r *replaceable) Update(ctx context.Context, req *resource.UpdateRequest) error {
+	r.Output = r.Name
+	return nil
+}
+func (r *replaceable) Delete(ctx context.Context, req *resource.DeleteRequest) error {
+	return nil
+}
+
+// jsonDoc considers documents equal if they contain the same JSON value.
+type jsonDoc struct {
+	nop
+	Document string `func:"input"`
+}
+
+func (d *jsonDoc) Equal(previous resource.Definition) bool {
+	var a, b interface{}
+	if err := json.Unmarshal([]byte(d.Document), &a); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(previous.(*jsonDoc).Document), &b); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// optional has an optional input.
+type optional struct {
+	nop
+	Value *string `func:"input"`
+}
+
+// choice has an input that only allows certain values.
+type choice struct {
+	nop
+	Value string `func:"input" validate:"oneof=a b"`
+}
+
+// slow takes at least a millisecond to create.
+type slow struct {
+	nop
+}
+
+func (slow) Create(ctx context.Context, req *resource.CreateRequest) error {
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+// fail always fails to create.
+type fail struct {
+	nop
+}
+
+func (fail) Create(ctx context.Context, req *resource.CreateRequest) error {
+	return errors.New("fail")
+}
+
+// failing fails to create or update if Fail is set.
+type failing struct {
+	nop
+	Fail bool `func:"input"`
+}
+
+func (f *failing) Create(ctx context.Context, req *resource.CreateRequest) error {
+	if f.Fail {
+		return errors.New("limit exceeded")
+	}
+	return nil
+}
+
+func (f *failing) Update(ctx context.Context, req *resource.UpdateRequest) error {
+	return f.Create(ctx, nil)
+}
+
+// cancelKey is the context key for the function to cancel a reconcile with.
+type cancelKey struct{}
+
+// cancelling cancels the reconcile when created, after which it completes
+// successfully. The function to call is read from the context.
+type cancelling struct {
+	nop
+}
+
+func (cancelling) Create(ctx context.Context, req *resource.CreateRequest) error {
+	ctx.Value(cancelKey{}).(context.CancelFunc)()
+	return nil
+}
+
+// wait blocks until the context is cancelled.
+type wait struct {
+	nop
+}
+
+func (wait) Create(ctx context.Context, req *resource.CreateRequest) error {
+	<-ctx.Done()
+	return backoff.Permanent(ctx.Err())
+}
+
+// countingCreates counts the created counting resources.
+var countingCreates int32
+
+// counting counts creates.
+type counting struct {
+	nop
+}
+
+func (counting) Create(ctx context.Context, req *resource.CreateRequest) error {
+	atomic.AddInt32(&countingCreates, 1)
+	return nil
+}
+
+// regionalClients counts the clients created by regional resources.
+var regionalClients int32
+
+// regional creates a client for its region using the client cache.
+type regional struct {
+	nop
+	Region string `func:"input"`
+}
+
+func (r *regional) Create(ctx context.Context, req *resource.CreateRequest) error {
+	cache, ok := req.Auth.(resource.ClientCache)
+	if !ok {
+		return errors.New("auth does not implement client cache")
+	}
+	_, err := cache.CachedClient("regional/"+r.Region, func() (interface{}, error) {
+		if _, err := req.Auth.AWS(); err != nil {
+			return nil, err
+		}
+		atomic.AddInt32(&regionalClients, 1)
+		return r.Region, nil
+	})
+	return err
+}
+
+// countingAuth counts the number of times credentials are requested.
+type countingAuth struct {
+	calls int32
+}
+
+func (a *countingAuth) AWS() (aws.CredentialsProvider, error) {
+	atomic.AddInt32(&a.calls, 1)
+	return aws.AnonymousCredentials, nil
+}
+
+// profileAuth returns credentials that identify the profile they are for.
+type profileAuth string
+
+func (a profileAuth) AWS() (aws.CredentialsProvider, error) {
+	return profileCreds{profile: string(a)}, nil
+}
+
+type profileCreds struct {
+	aws.CredentialsProvider
+	profile string
+}
+
+// authenticated outputs the profile of the credentials it was created with.
+type authenticated struct {
+	nop
+	Profile string `func:"output"`
+}
+
+func (p *authenticated) Create(ctx context.Context, req *resource.CreateRequest) error {
+	creds, err := req.Auth.AWS()
+	if err != nil {
+		return err
+	}
+	p.Profile = creds.(profileCreds).profile
+	return nil
+}
+
+// flakyAttempts records the attempts seen by flaky resources.
+var flakyAttempts []int
+
+// flaky fails until its third attempt.
+type flaky struct {
+	nop
+}
+
+func (flaky) Create(ctx context.Context, req *resource.CreateRequest) error {
+	flakyAttempts = append(flakyAttempts, req.Attempt)
+	if req.Attempt < 3 {
+		return errors.New("flaky")
+	}
+	return nil
+}
+
+// retried fails its first attempt.
+type retried struct {
+	nop
+}
+
+func (retried) Create(ctx context.Context, req *resource.CreateRequest) error {
+	if req.Attempt < 2 {
+		return errors.New("first attempt")
+	}
+	return nil
+}
+
+// fakeMetrics records the metrics it receives.
+type fakeMetrics struct {
+	mu      sync.Mutex
+	ops     []string // "type op ok"
+	retries map[string]int
+}
+
+func (m *fakeMetrics) ObserveOp(resType, op string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ops = append(m.ops, fmt.Sprintf("%s %s %t", resType, op, err == nil))
+}
+
+func (m *fakeMetrics) IncRetry(resType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.retries == nil {
+		m.retries = make(map[string]int)
+	}
+	m.retries[resType]++
+}
+
+// identified records the project and name passed in requests, as
+// "method project/name".
+var (
+	identifiedMu   sync.Mutex
+	identifiedReqs []string
+)
+
+type identified struct{}
+
+func (identified) record(method, project, name string) {
+	identifiedMu.Lock()
+	identifiedReqs = append(identifiedReqs, fmt.Sprintf("%s %s/%s", method, project, name))
+	identifiedMu.Unlock()
+}
+
+func (i identified) Create(ctx context.Context, req *resource.CreateRequest) error {
+	i.record("create", req.Project, req.Name)
+	return nil
+}
+
+func (i identified) Update(ctx context.Context, req *resource.UpdateRequest) error {
+	i.record("update", req.Project, req.Name)
+	return nil
+}
+
+func (i identified) Delete(ctx context.Context, req *resource.DeleteRequest) error {
+	i.record("delete", req.Project, req.Name)
+	return nil
+}
+
+// warmupPolls counts the polls for warmup resources to become ready.
+var warmupPolls int32
+
+// warmup becomes ready after Polls calls to WaitReady.
+type warmup struct {
+	nop
+	Polls  int32  `func:"input"`
+	Status string `func:"output"`
+}
+
+func (w *warmup) WaitReady(ctx context.Context, req *resource.WaitRequest) error {
+	if n := atomic.AddInt32(&warmupPolls, 1); n < w.Polls {
+		return errors.New("not ready")
+	}
+	w.Status = "ready"
+	return nil
+}
+
+// gateOpen is closed when an opener resource is created.
+var gateOpen chan struct{}
+
+// gate blocks on create until gateOpen is closed.
+type gate struct{ nop }
+
+func (gate) Create(ctx context.Context, req *resource.CreateRequest) error {
+	select {
+	case <-gateOpen:
+		return nil
+	case <-ctx.Done():
+		return backoff.Permanent(ctx.Err())
+	}
+}
+
+// opener closes gateOpen on create.
+type opener struct{ nop }
+
+func (opener) Create(ctx context.Context, req *resource.CreateRequest) error {
+	close(gateOpen)
+	return nil
+}
+
+// notReady is created but never becomes ready.
+type notReady struct {
+	nop
+	ARN string `func:"output"`
+}
+
+func (n *notReady) Create(ctx context.Context, req *resource.CreateRequest) error {
+	n.ARN = "arn:notready:" + req.Name
+	return nil
+}
+
+func (n *notReady) WaitReady(ctx context.Context, req *resource.WaitRequest) error {
+	return backoff.Permanent(errors.New("failed to start"))
+}
+
+// lostStore simulates resources being removed from the state after they have
+// been listed.
+type lostStore struct {
+	*teststore.Store
+}
+
+func (lostStore) GetResource(ctx context.Context, project, id string) (*resource.Deployed, error) {
+	return nil, nil
+}
+
+// orderedStore records stored resources to identifiedReqs, so the order of
+// writes can be compared to the order of requests to providers.
+type orderedStore struct {
+	*teststore.Store
+}
+
+func (s orderedStore) PutResource(ctx context.Context, project string, res *resource.Deployed) error {
+	if err := s.Store.PutResource(ctx, project, res); err != nil {
+		return err
+	}
+	identified{}.record("put", project, res.Name)
+	return nil
+}
+
 // sequence generates a deterministic sequence of ids.
 type sequence struct {
 	mu    sync.Mutex
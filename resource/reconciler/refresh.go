@@ -0,0 +1,174 @@
+package reconciler
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/func/func/ctyext"
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// Drift describes a deployed resource whose live state no longer matches the
+// stored state.
+type Drift struct {
+	// Stored is the resource as it was previously stored.
+	Stored *resource.Deployed
+
+	// Refreshed is the resource with input and output values read from the
+	// live resource.
+	Refreshed *resource.Deployed
+
+	// Inputs and Outputs contain the paths to values that have drifted. The
+	// paths are relative to the resource's input and output, respectively.
+	Inputs  []cty.Path
+	Outputs []cty.Path
+}
+
+// Refresh reads the live state of all deployed resources in a project and
+// returns the resources that have drifted from the stored state.
+//
+// Only resources whose definition implements resource.Reader are refreshed,
+// other resources are skipped. The stored state is not modified.
+func (r *Reconciler) Refresh(ctx context.Context, id, proj string) ([]*Drift, error) {
+	run := r.newRun(id, proj, nil)
+	logger := run.Logger
+
+	logger.Info("Refresh", zap.String("project", proj))
+
+	if err := run.GetExisting(ctx); err != nil {
+		return nil, errors.Wrap(err, "get existing resources")
+	}
+
+	drift, err := run.Refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Done", zap.Int("drift", len(drift)))
+
+	return drift, nil
+}
+
+// RefreshExisting replaces the existing resources that have drifted with
+// their live state.
+func (r *run) RefreshExisting(ctx context.Context) error {
+	drift, err := r.Refresh(ctx)
+	if err != nil {
+		return err
+	}
+	refreshed := make(map[string]*resource.Deployed, len(drift))
+	for _, d := range drift {
+		refreshed[d.Stored.ID] = d.Refreshed
+	}
+	for i, ex := range r.existing {
+		if res, ok := refreshed[ex.ID]; ok {
+			r.existing[i] = res
+		}
+	}
+	return nil
+}
+
+func (r *run) Refresh(ctx context.Context) ([]*Drift, error) {
+	var mu sync.Mutex
+	var drift []*Drift
+
+	g, ctx := errgroup.WithContext(ctx)
+	for _, res := range r.existing {
+		res := res
+		g.Go(func() error {
+			d, err := r.refreshResource(ctx, res)
+			if err != nil {
+				return errors.Wrapf(err, "refresh %s.%s", res.Type, res.Name)
+			}
+			if d != nil {
+				mu.Lock()
+				drift = append(drift, d)
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(drift, func(i, j int) bool {
+		return drift[i].Stored.Name < drift[j].Stored.Name
+	})
+
+	return drift, nil
+}
+
+func (r *run) refreshResource(ctx context.Context, res *resource.Deployed) (*Drift, error) {
+	logger := r.Logger.With(zap.String("type", res.Type), zap.String("name", res.Name))
+
+	defType := r.Registry.Type(res.Type)
+	if defType == nil {
+		return nil, errors.Errorf("type not registered: %q", res.Type)
+	}
+
+	def, err := deployedDefinition(defType, res)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, ok := def.(resource.Reader)
+	if !ok {
+		logger.Debug("Refresh not supported")
+		return nil, nil
+	}
+
+	// Ready to process, wait for semaphore.
+	if err := r.Sem.Acquire(ctx, 1); err != nil {
+		return nil, errors.Wrap(err, "acquire semaphore")
+	}
+	defer r.Sem.Release(1)
+
+	logger.Debug("Read")
+
+	req := &resource.ReadRequest{Auth: r.Auth.Profile(res.Profile)}
+	err = r.retry(ctx, logger, res.Type, "read", func() error {
+		return reader.Read(ctx, req)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "read")
+	}
+
+	fields := resource.Fields(defType)
+	input, err := ctyext.ToCtyValue(def, fields.Inputs().CtyType(), resource.FieldName)
+	if err != nil {
+		return nil, errors.Wrap(err, "convert input values")
+	}
+	output, err := ctyext.ToCtyValue(def, fields.Outputs().CtyType(), resource.FieldName)
+	if err != nil {
+		return nil, errors.Wrap(err, "convert output values")
+	}
+
+	desired := *res.Desired
+	desired.Input = input
+	drift := &Drift{
+		Stored: res,
+		Refreshed: &resource.Deployed{
+			Desired: &desired,
+			ID:      res.ID,
+			Output:  output,
+			Deps:    res.Deps,
+		},
+		Inputs:  ctyext.Diff(res.Input, input),
+		Outputs: ctyext.Diff(res.Output, output),
+	}
+
+	if len(drift.Inputs) == 0 && len(drift.Outputs) == 0 {
+		logger.Debug("No drift")
+		return nil, nil
+	}
+
+	logger.Info("Drift detected", zap.Int("inputs", len(drift.Inputs)), zap.Int("outputs", len(drift.Outputs)))
+
+	return drift, nil
+}
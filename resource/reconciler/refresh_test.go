@@ -0,0 +1,180 @@
+package reconciler_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/func/func/ctyext"
+	"github.com/func/func/resource"
+	"github.com/func/func/resource/reconciler"
+	"github.com/func/func/storage/teststore"
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestReconciler_Refresh(t *testing.T) {
+	stored := func(name, typename, input, output string) *resource.Deployed {
+		return &resource.Deployed{
+			Desired: &resource.Desired{
+				Name:  name,
+				Type:  typename,
+				Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal(input)}),
+			},
+			ID:     "id-" + name,
+			Output: cty.ObjectVal(map[string]cty.Value{"output": cty.StringVal(output)}),
+		}
+	}
+
+	store := &teststore.Store{}
+	store.SeedResources("proj", []*resource.Deployed{
+		stored("drifted", "upper", "hello", "hello"),
+		stored("unchanged", "upper", "hello", "HELLO"),
+		stored("skipped", "passthrough", "hello", "hello"),
+	})
+	rec := &teststore.Recorder{Store: store}
+
+	reco := &reconciler.Reconciler{
+		Resources: rec,
+		Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+			"upper":       &upper{},
+			"passthrough": &passthrough{},
+		}),
+		Logger: zaptest.NewLogger(t),
+	}
+
+	got, err := reco.Refresh(context.Background(), "", "proj")
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("Got %d drifted resources, want 1", len(got))
+	}
+	drift := got[0]
+	if drift.Stored.Name != "drifted" {
+		t.Errorf("Drifted resource = %q, want %q", drift.Stored.Name, "drifted")
+	}
+	if len(drift.Inputs) != 0 {
+		t.Errorf("Got drifted inputs %v, want none", drift.Inputs)
+	}
+	var paths []string
+	for _, p := range drift.Outputs {
+		paths = append(paths, ctyext.PathString(p))
+	}
+	if diff := cmp.Diff(paths, []string{"output"}); diff != "" {
+		t.Errorf("Drifted outputs (-got +want)\n%s", diff)
+	}
+	wantOutput := cty.ObjectVal(map[string]cty.Value{"output": cty.StringVal("HELLO")})
+	if !drift.Refreshed.Output.RawEquals(wantOutput) {
+		t.Errorf("Refreshed output = %#v, want %#v", drift.Refreshed.Output, wantOutput)
+	}
+
+	// Refresh must not modify stored state.
+	wantEvents := teststore.Events{
+		{Method: "ListResources", Project: "proj"},
+	}
+	if diff := cmp.Diff(rec.Events, wantEvents); diff != "" {
+		t.Errorf("Events (-got +want)\n%s", diff)
+	}
+}
+
+func TestReconciler_Reconcile_refresh(t *testing.T) {
+	tests := []struct {
+		refresh     bool
+		wantReads   int32
+		wantUpdates int32
+	}{
+		{refresh: false, wantReads: 0, wantUpdates: 0},
+		{refresh: true, wantReads: 1, wantUpdates: 1},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Refresh=%t", tt.refresh), func(t *testing.T) {
+			atomic.StoreInt32(&liveReads, 0)
+			atomic.StoreInt32(&liveUpdates, 0)
+
+			input := cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("hello")})
+			store := &teststore.Store{}
+			store.SeedResources("proj", []*resource.Deployed{{
+				Desired: &resource.Desired{Name: "foo", Type: "live", Input: input},
+				ID:      "id-foo",
+				Output:  cty.ObjectVal(map[string]cty.Value{"output": cty.StringVal("hello")}),
+			}})
+
+			reco := &reconciler.Reconciler{
+				Resources: store,
+				Registry: resource.RegistryFromDefinitions(map[string]resource.Definition{
+					"live": &live{},
+				}),
+				Logger:             zaptest.NewLogger(t),
+				RefreshBeforeApply: tt.refresh,
+			}
+			graph := &resource.Graph{
+				Resources: []*resource.Desired{{Name: "foo", Type: "live", Input: input}},
+			}
+			if err := reco.Reconcile(context.Background(), "", "proj", graph); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			if got := atomic.LoadInt32(&liveReads); got != tt.wantReads {
+				t.Errorf("Got %d reads, want %d", got, tt.wantReads)
+			}
+			if got := atomic.LoadInt32(&liveUpdates); got != tt.wantUpdates {
+				t.Errorf("Got %d updates, want %d", got, tt.wantUpdates)
+			}
+		})
+	}
+}
+
+// liveReads and liveUpdates count the operations on live resources.
+var liveReads, liveUpdates int32
+
+// live is read back with a different input, as if it had been changed
+// outside of func.
+type live struct {
+	Input  string `func:"input"`
+	Output string `func:"output"`
+}
+
+func (p *live) Create(ctx context.Context, req *resource.CreateRequest) error {
+	p.Output = p.Input
+	return nil
+}
+func (p *live) Update(ctx context.Context, req *resource.UpdateRequest) error {
+	atomic.AddInt32(&liveUpdates, 1)
+	p.Output = p.Input
+	return nil
+}
+func (p *live) Delete(ctx context.Context, req *resource.DeleteRequest) error {
+	return nil
+}
+func (p *live) Read(ctx context.Context, req *resource.ReadRequest) error {
+	atomic.AddInt32(&liveReads, 1)
+	p.Input = "changed"
+	return nil
+}
+
+// upper is a passthrough resource that is read back in upper case.
+type upper struct {
+	Input  *string `func:"input"`
+	Output string  `func:"output"`
+}
+
+func (p *upper) Create(ctx context.Context, req *resource.CreateRequest) error {
+	p.Output = *p.Input
+	return nil
+}
+func (p *upper) Update(ctx context.Context, req *resource.UpdateRequest) error {
+	p.Output = *p.Input
+	return nil
+}
+func (p *upper) Delete(ctx context.Context, req *resource.DeleteRequest) error {
+	return nil
+}
+func (p *upper) Read(ctx context.Context, req *resource.ReadRequest) error {
+	p.Output = strings.ToUpper(*p.Input)
+	return nil
+}
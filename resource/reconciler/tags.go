@@ -0,0 +1,132 @@
+package reconciler
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/func/func/resource"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Keys for the tags that are added to resources with ProjectTags.
+const (
+	ProjectTag   = "func:project"
+	NamespaceTag = "func:namespace"
+)
+
+// projectTags returns the tags to add to a resource with the given name in a
+// project. The namespace is the name of the module the resource was declared
+// in, if any.
+func projectTags(project, name string) map[string]string {
+	tags := map[string]string{ProjectTag: project}
+	if i := strings.IndexAny(name, ".["); i > 0 && name[i] == '.' {
+		tags[NamespaceTag] = name[:i]
+	}
+	return tags
+}
+
+// tagType is the type of an element in a tags input that is a list of
+// key-value pairs, such as:
+//
+//	Tags []struct {
+//		Key   string
+//		Value string
+//	} `func:"input" name:"tag"`
+var tagType = cty.Object(map[string]cty.Type{
+	"key":   cty.String,
+	"value": cty.String,
+})
+
+// addTags adds tags to the tags input of a resource. The tags input is the
+// input named tags, or tag when it is declared as blocks. It must be a map
+// of strings or a list of key-value pairs. Tags that are already set in the
+// input are not changed. If the resource does not have a tags input, or its
+// value is not known, input is returned as is.
+func addTags(typ reflect.Type, input cty.Value, tags map[string]string) cty.Value {
+	inputs := resource.Fields(typ).Inputs()
+	for _, name := range []string{"tags", "tag"} {
+		field, ok := inputs[name]
+		if !ok {
+			continue
+		}
+		current := input.GetAttr(name)
+		var merged cty.Value
+		switch ty := resource.CtyType(field.Type); {
+		case ty.Equals(cty.Map(cty.String)):
+			merged, ok = mergeTagMap(current, tags)
+		case ty.Equals(cty.List(tagType)):
+			merged, ok = mergeTagList(current, tags)
+		default:
+			ok = false
+		}
+		if !ok {
+			return input
+		}
+		attrs := input.AsValueMap()
+		attrs[name] = merged
+		return cty.ObjectVal(attrs)
+	}
+	return input
+}
+
+// mergeTagMap adds tags to a map of tags. Returns false if the map is not
+// known.
+func mergeTagMap(current cty.Value, tags map[string]string) (cty.Value, bool) {
+	if !current.IsKnown() {
+		return current, false
+	}
+	merged := make(map[string]cty.Value, len(tags))
+	for k, v := range tags {
+		merged[k] = cty.StringVal(v)
+	}
+	if !current.IsNull() {
+		for k, v := range current.AsValueMap() {
+			merged[k] = v
+		}
+	}
+	return cty.MapVal(merged), true
+}
+
+// mergeTagList adds tags to a list of key-value pairs. The tags are appended
+// to the list, sorted by key. Returns false if the list or any of the keys in
+// it are not known.
+func mergeTagList(current cty.Value, tags map[string]string) (cty.Value, bool) {
+	if !current.IsKnown() {
+		return current, false
+	}
+	var list []cty.Value
+	set := make(map[string]bool)
+	if !current.IsNull() {
+		for it := current.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			if !v.IsNull() {
+				k := v.GetAttr("key")
+				if !k.IsKnown() {
+					return current, false
+				}
+				if !k.IsNull() {
+					set[k.AsString()] = true
+				}
+			}
+			list = append(list, v)
+		}
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		if !set[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		list = append(list, cty.ObjectVal(map[string]cty.Value{
+			"key":   cty.StringVal(k),
+			"value": cty.StringVal(tags[k]),
+		}))
+	}
+	if len(list) == 0 {
+		return cty.ListValEmpty(tagType), true
+	}
+	return cty.ListVal(list), true
+}
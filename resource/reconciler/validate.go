@@ -0,0 +1,75 @@
+package reconciler
+
+import (
+	"reflect"
+
+	"github.com/func/func/ctyext"
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// A Validator validates input values.
+type Validator interface {
+	Validate(input interface{}, rule string) error
+}
+
+// validateResolved validates a value that was resolved from dependencies
+// against the validation rule set on the field at path in typ. Values that
+// are null are not validated.
+func validateResolved(v Validator, typ reflect.Type, path cty.Path, val cty.Value) error {
+	field, ok := fieldAt(typ, path)
+	if !ok {
+		return nil
+	}
+	rule := field.Tags["validate"]
+	if rule == "" || val.IsNull() || !val.IsWhollyKnown() {
+		return nil
+	}
+	t := field.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	goval := reflect.New(t)
+	if err := ctyext.FromCtyValue(val, goval.Interface(), resource.FieldName); err != nil {
+		return errors.Wrap(err, "convert value")
+	}
+	return v.Validate(goval.Elem().Interface(), rule)
+}
+
+// fieldAt returns the field that the value at path in typ is set to. Returns
+// false if the path does not refer to a field, for example if it refers to an
+// element in a list.
+func fieldAt(typ reflect.Type, path cty.Path) (resource.Field, bool) {
+	var field resource.Field
+	found := false
+	t := typ
+	for _, step := range path {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch s := step.(type) {
+		case cty.GetAttrStep:
+			if t.Kind() != reflect.Struct {
+				return resource.Field{}, false
+			}
+			f, ok := resource.Fields(t)[s.Name]
+			if !ok {
+				return resource.Field{}, false
+			}
+			field, found = f, true
+			t = f.Type
+		case cty.IndexStep:
+			switch t.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				t = t.Elem()
+			default:
+				return resource.Field{}, false
+			}
+			// The rule on the field applies to the collection, not to the
+			// elements in it.
+			found = false
+		}
+	}
+	return field, found
+}
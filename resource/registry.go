@@ -53,3 +53,13 @@ func (r *Registry) Typenames() []string {
 	sort.Strings(tt)
 	return tt
 }
+
+// Schemas returns the fields for each registered type, keyed by type name.
+// Nested blocks can be inspected with Field.Block().
+func (r *Registry) Schemas() map[string]FieldSet {
+	out := make(map[string]FieldSet, len(r.Types))
+	for name, t := range r.Types {
+		out[name] = Fields(t)
+	}
+	return out
+}
@@ -48,3 +48,60 @@ func TestRegistry_Typenames(t *testing.T) {
 type mockDef struct {
 	resource.Definition
 }
+
+func TestRegistry_Schemas(t *testing.T) {
+	type block struct {
+		Value string `validate:"min=1"`
+	}
+	type def struct {
+		resource.Definition
+		Name   string   `func:"input" validate:"required"`
+		Blocks []block  `func:"input"`
+		Arn    string   `func:"output"`
+		Nested *block   `func:"input"`
+		Tags   []string `func:"input"`
+	}
+	r := &resource.Registry{}
+	r.Register("foo", &def{})
+	r.Register("bar", &mockDef{})
+
+	got := r.Schemas()
+	if len(got) != 2 {
+		t.Fatalf("Got %d schemas, want 2", len(got))
+	}
+
+	foo := got["foo"]
+	types := make(map[string]reflect.Type)
+	for name, f := range foo {
+		types[name] = f.Type
+	}
+	want := map[string]reflect.Type{
+		"definition": reflect.TypeOf((*resource.Definition)(nil)).Elem(),
+		"name":       reflect.TypeOf(""),
+		"blocks":     reflect.TypeOf([]block{}),
+		"arn":        reflect.TypeOf(""),
+		"nested":     reflect.TypeOf(&block{}),
+		"tags":       reflect.TypeOf([]string{}),
+	}
+	if diff := cmp.Diff(types, want, cmp.Comparer(func(a, b reflect.Type) bool { return a == b })); diff != "" {
+		t.Errorf("Schemas() types (-got +want)\n%s", diff)
+	}
+	if v := foo["name"].Tags["validate"]; v != "required" {
+		t.Errorf("name validate = %q, want %q", v, "required")
+	}
+
+	for _, name := range []string{"blocks", "nested"} {
+		nested := foo[name].Block()
+		if nested == nil {
+			t.Fatalf("%s.Block() = nil", name)
+		}
+		if v := nested["value"].Tags["validate"]; v != "min=1" {
+			t.Errorf("%s.value validate = %q, want %q", name, v, "min=1")
+		}
+	}
+	for _, name := range []string{"name", "tags"} {
+		if nested := foo[name].Block(); nested != nil {
+			t.Errorf("%s.Block() = %v, want nil", name, nested)
+		}
+	}
+}
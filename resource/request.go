@@ -13,6 +13,15 @@ type AuthProvider interface {
 	AWS() (aws.CredentialsProvider, error)
 }
 
+// A ClientCache caches API clients, allowing clients to be reused across
+// resources. The AuthProvider passed in a request may implement ClientCache.
+type ClientCache interface {
+	// CachedClient returns the client stored for key. If a client has not
+	// been stored, create is called and the returned client is stored. If
+	// create returns an error, nothing is stored.
+	CachedClient(key string, create func() (interface{}, error)) (interface{}, error)
+}
+
 // SourceCode contains one set of source code, matching a single source entry
 // for the resource.
 type SourceCode interface {
@@ -28,6 +37,19 @@ type SourceCode interface {
 type CreateRequest struct {
 	Auth   AuthProvider
 	Source []SourceCode
+
+	// Project is the name of the project the resource belongs to.
+	Project string
+
+	// Name is the name of the resource in the config. For resources declared
+	// in a module, the name is prefixed with the module name, as in
+	// mod.resource. The name is unique within the project.
+	Name string
+
+	// Attempt is the number of the current attempt, starting from 1. A
+	// higher value indicates that the operation is retried after a previous
+	// attempt returned an error.
+	Attempt int
 }
 
 // An UpdateRequest is passed to a resource's Update method when a new resource
@@ -35,6 +57,10 @@ type CreateRequest struct {
 //
 // Previous contains the previous version of the resource. The type for
 // Previous will match the resource type.
+//
+// When Update is called, the outputs of the resource are already set to the
+// previous outputs. Outputs that are not changed by Update, including nested
+// fields, keep their previous values.
 type UpdateRequest struct {
 	Auth     AuthProvider
 	Source   []SourceCode
@@ -42,24 +68,68 @@ type UpdateRequest struct {
 
 	SourceChanged bool
 	ConfigChanged bool
+
+	// Project and Name identify the resource, see CreateRequest.
+	Project string
+	Name    string
+
+	// Attempt is the number of the current attempt, starting from 1.
+	Attempt int
 }
 
 // CreateRequest converts the update to a Create Request.
 func (r *UpdateRequest) CreateRequest() *CreateRequest {
 	return &CreateRequest{
-		Auth:   r.Auth,
-		Source: r.Source,
+		Auth:    r.Auth,
+		Source:  r.Source,
+		Project: r.Project,
+		Name:    r.Name,
+		Attempt: r.Attempt,
 	}
 }
 
 // DeleteRequest converts the update to a Delete Request.
 func (r *UpdateRequest) DeleteRequest() *DeleteRequest {
 	return &DeleteRequest{
-		Auth: r.Auth,
+		Auth:    r.Auth,
+		Project: r.Project,
+		Name:    r.Name,
+		Attempt: r.Attempt,
 	}
 }
 
 // A DeleteRequest is passed to a resource when it is being deleted.
 type DeleteRequest struct {
 	Auth AuthProvider
+
+	// Project and Name identify the resource, see CreateRequest.
+	Project string
+	Name    string
+
+	// Attempt is the number of the current attempt, starting from 1.
+	Attempt int
+}
+
+// A ReadRequest is passed to a resource when its state is being refreshed.
+type ReadRequest struct {
+	Auth AuthProvider
+}
+
+// An ImportRequest is passed to a resource when it is being imported.
+type ImportRequest struct {
+	Auth AuthProvider
+
+	// ID identifies the resource in the provider.
+	ID string
+}
+
+// A ListRequest is passed to a resource when listing the resources of its
+// type to import.
+type ListRequest struct {
+	Auth AuthProvider
+}
+
+// A WaitRequest is passed to a resource when waiting for it to become ready.
+type WaitRequest struct {
+	Auth AuthProvider
 }
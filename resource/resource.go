@@ -1,6 +1,8 @@
 package resource
 
 import (
+	"time"
+
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -26,6 +28,46 @@ type Desired struct {
 	// Sources contain the source code hashes that were provided to the
 	// resource. The value is only set for resources that have been created.
 	Sources []string
+
+	// IgnoreChanges contains paths to input values that are ignored when
+	// comparing the desired input to a previously deployed input. If only
+	// ignored values have changed, the resource is not updated. The paths are
+	// relative to Input.
+	IgnoreChanges []cty.Path
+
+	// PreventDestroy prevents the resource from being deleted. The value is
+	// stored with the deployed resource, so the resource remains protected
+	// after it has been removed from the desired graph.
+	PreventDestroy bool
+
+	// Unset contains paths to optional inputs that were not set in the
+	// config. When the resource is updated, the previously deployed values
+	// are kept for these inputs. Inputs that are explicitly set to null are
+	// not included, so setting an input to null clears the previous value.
+	Unset []cty.Path
+
+	// Sensitive contains paths to input values that are marked sensitive.
+	// The values must not be displayed to the user or written to logs. The
+	// paths are relative to Input.
+	Sensitive []cty.Path
+
+	// DependsOn contains the names of resources that the resource explicitly
+	// depends on. No values are passed from the resources, but they are
+	// created before and deleted after the resource, as with dependencies
+	// between fields.
+	DependsOn []string
+
+	// Description is a user provided description of what the resource is
+	// for. It is shown in the log output when the resource is applied. The
+	// description is not passed to the resource, unless the resource type has
+	// a description input.
+	Description string
+
+	// Profile is the name of the credentials profile set on the provider the
+	// resource uses. The value is stored with the deployed resource, so the
+	// same credentials are used to delete it. If empty, the default
+	// credentials are used.
+	Profile string
 }
 
 // Deployed is a deployed resource.
@@ -47,4 +89,45 @@ type Deployed struct {
 	//
 	// Deps are used for traversing the graph backwards when deleting resources.
 	Deps []string
+
+	// LastAppliedAt is the time when the resource was last created or
+	// updated. The value is zero if the time was not recorded.
+	LastAppliedAt time.Time
+
+	// LastDuration is the time it took to perform the last create or update
+	// operation, including retries.
+	LastDuration time.Duration
+
+	// Failure is set if the last attempt to create or update the resource
+	// failed. It is cleared when the resource is applied successfully.
+	Failure *Failure
+
+	// Imported is set on resources that were imported and have not yet been
+	// claimed by the config. An imported resource is not deleted when it is
+	// not in the desired graph. It is cleared when a resource with the same
+	// name is applied.
+	Imported bool
+}
+
+// A Failure records failed attempts to apply a resource.
+type Failure struct {
+	// Error is the error from the last failed attempt.
+	Error string
+
+	// At is the time of the last failed attempt.
+	At time.Time
+
+	// Count is the number of consecutive failed attempts.
+	Count int
+
+	// Create is set if creating the resource failed. The resource does not
+	// exist; the deployed resource only records the failure and does not
+	// have outputs.
+	Create bool
+}
+
+// Exists returns true if the resource has been created. It is false for a
+// resource that only records a failure to create it.
+func (d *Deployed) Exists() bool {
+	return d.Failure == nil || !d.Failure.Create
 }
@@ -6,22 +6,100 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/zclconf/go-cty/cty"
 )
 
 // A Field represents an extracted field from a struct.
 type Field struct {
-	Index int               // The field's index, relative to the parent struct.
+	Index int               // The field's index, relative to the struct it is declared in.
 	Type  reflect.Type      // The field's type.
 	Tags  map[string]string // Struct tags set on the field, excluding func and name tags.
 
-	functag string // value for func:""
+	embedded []int         // index sequence of the embedded struct a promoted field is declared in
+	functag  string        // value for func:"", excluding options
+	forceNew bool          // func:"input,force_new"
+	id       bool          // func:"output,id"
+	duration time.Duration // func:"input,duration" or func:"input,duration_ms"
+}
+
+// Sensitive returns true if the field is marked sensitive with a
+// `sensitive:"true"` struct tag. The value of a sensitive field must not be
+// displayed to the user or written to logs.
+func (f Field) Sensitive() bool {
+	return f.Tags["sensitive"] == "true"
+}
+
+// Value returns the value of the field in v, which must be a struct of the
+// type the field was extracted from. For a field promoted from an embedded
+// struct, the value is read from the embedded struct.
+func (f Field) Value(v reflect.Value) reflect.Value {
+	for _, i := range f.embedded {
+		v = v.Field(i)
+	}
+	return v.Field(f.Index)
+}
+
+// Key returns true if the field is marked as the key of a list element with a
+// `key:"true"` struct tag. An element in a list of such structs can be
+// referenced by its key, as in foo.items["x"], rather than by position.
+func (f Field) Key() bool {
+	return f.Tags["key"] == "true"
+}
+
+// ForceNew returns true if the input field is marked with a
+// `func:"input,force_new"` struct tag. Changing the value of such a field
+// cannot be done in place and requires the resource to be replaced.
+func (f Field) ForceNew() bool {
+	return f.forceNew
+}
+
+// ID returns true if the output field is marked with a `func:"output,id"`
+// struct tag. Such an output identifies the resource in the provider's API,
+// such as an id generated on create. It is set from the previous outputs
+// before Update and Delete are called, so the provider can use it like an
+// input.
+func (f Field) ID() bool {
+	return f.id
+}
+
+// Duration returns the unit of a numeric input field that can be set with a
+// duration string, such as "30s". The unit is time.Second if the field is
+// marked with `func:"input,duration"` and time.Millisecond if it is marked
+// with `func:"input,duration_ms"`. Returns 0 for other fields.
+func (f Field) Duration() time.Duration {
+	return f.duration
+}
+
+// Block returns the fields of a nested block, if the field is a struct, or a
+// pointer or slice of structs. Returns nil if the field is not a block.
+func (f Field) Block() FieldSet {
+	t := f.Type
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return Fields(t)
 }
 
 // A FieldSet contains extracted schema fields.
 type FieldSet map[string]Field
 
+// KeyField returns the name of the field marked as the key, or an empty string
+// if no field is the key.
+func (ff FieldSet) KeyField() string {
+	for k, v := range ff {
+		if v.Key() {
+			return k
+		}
+	}
+	return ""
+}
+
 // Inputs filters the FieldSet and returns all fields that are marked as an
 // input, based on the func:"input" struct tag.
 func (ff FieldSet) Inputs() FieldSet {
@@ -68,6 +146,11 @@ func (ff FieldSet) CtyType() cty.Type {
 
 // Fields extracts fields from target. Unexported fields are ignored.
 //
+// The fields of an embedded struct are promoted, as if they were declared in
+// target. If a promoted field has the same name as a field in target, the
+// field in target is used. The Index of a promoted field is relative to the
+// embedded struct; use Value to read the field from a value of target.
+//
 // All fields are extracted, regardless if they are marked as an input, output
 // or neither. The returned FieldSet may be further filtered to get the desired
 // fields. The func struct tag is excluded from the Tags in the returned
@@ -77,8 +160,25 @@ func (ff FieldSet) CtyType() cty.Type {
 // ExampleField becomes example_field. This can be overridden by setting a
 // `name:"<override>"` tag.
 //
+// The extracted fields are cached per type, so repeated calls for the same
+// type are cheap. The returned FieldSet is a copy and may be modified by the
+// caller.
+//
 // Panics if target is not a struct or a pointer to a struct.
 func Fields(target reflect.Type) FieldSet {
+	if cached, ok := fieldCache.Load(target); ok {
+		return cached.(FieldSet).clone()
+	}
+	fields := extractFields(target)
+	fieldCache.Store(target, fields)
+	return fields.clone()
+}
+
+// fieldCache caches extracted fields. The key is a reflect.Type and the value
+// is a FieldSet. Cached values must not be modified.
+var fieldCache sync.Map
+
+func extractFields(target reflect.Type) FieldSet {
 	t := target
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
@@ -87,11 +187,16 @@ func Fields(target reflect.Type) FieldSet {
 		panic(fmt.Sprintf("Target must be a struct or pointer to struct, not %s", target.Kind()))
 	}
 	fields := make(FieldSet, t.NumField())
+	var embedded []reflect.StructField
 	for i := 0; i < t.NumField(); i++ {
 		f := t.Field(i)
 		if f.PkgPath != "" {
 			continue
 		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			embedded = append(embedded, f)
+			continue
+		}
 		field := Field{
 			Type:  f.Type,
 			Index: i,
@@ -104,14 +209,51 @@ func Fields(target reflect.Type) FieldSet {
 		} else {
 			name = FieldName(f)
 		}
-		field.functag = tag["func"]
+		opts := strings.Split(tag["func"], ",")
+		field.functag = opts[0]
+		for _, opt := range opts[1:] {
+			switch opt {
+			case "force_new":
+				field.forceNew = true
+			case "id":
+				field.id = true
+			case "duration":
+				field.duration = time.Second
+			case "duration_ms":
+				field.duration = time.Millisecond
+			}
+		}
 		delete(tag, "func")
 		field.Tags = tag
 		fields[name] = field
 	}
+	for _, e := range embedded {
+		for name, field := range extractFields(e.Type) {
+			if _, ok := fields[name]; ok {
+				continue
+			}
+			field.embedded = append([]int{e.Index[0]}, field.embedded...)
+			fields[name] = field
+		}
+	}
 	return fields
 }
 
+// clone returns a deep copy of the FieldSet.
+func (ff FieldSet) clone() FieldSet {
+	out := make(FieldSet, len(ff))
+	for k, v := range ff {
+		tags := make(map[string]string, len(v.Tags))
+		for tk, tv := range v.Tags {
+			tags[tk] = tv
+		}
+		v.Tags = tags
+		v.embedded = append([]int(nil), v.embedded...)
+		out[k] = v
+	}
+	return out
+}
+
 var reFirstCap = regexp.MustCompile("(.)([A-Z][a-z]+)")
 var reAllCap = regexp.MustCompile("([a-z0-9])([A-Z])")
 
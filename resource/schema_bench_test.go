@@ -0,0 +1,43 @@
+package resource
+
+import (
+	"reflect"
+	"testing"
+)
+
+type benchNested struct {
+	Name       string            `func:"input"`
+	Tags       map[string]string `func:"input"`
+	Attributes []struct {
+		Name string `func:"input"`
+		Type string `func:"input" validate:"oneof=S N B"`
+	} `func:"input"`
+	Indexes []struct {
+		Name       string   `func:"input"`
+		Keys       []string `func:"input"`
+		Projection *struct {
+			Type       string   `func:"input"`
+			Attributes []string `func:"input"`
+		} `func:"input"`
+	} `func:"input"`
+	Throughput *struct {
+		Read  int64 `func:"input" validate:"min=1"`
+		Write int64 `func:"input" validate:"min=1"`
+	} `func:"input"`
+	ARN       string `func:"output"`
+	StreamARN string `func:"output"`
+}
+
+func BenchmarkFields(b *testing.B) {
+	target := reflect.TypeOf(benchNested{})
+	b.Run("Uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = extractFields(target)
+		}
+	})
+	b.Run("Cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = Fields(target)
+		}
+	})
+}
@@ -3,7 +3,9 @@ package resource_test
 import (
 	"fmt"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/func/func/resource"
 	"github.com/google/go-cmp/cmp"
@@ -12,6 +14,11 @@ import (
 )
 
 func TestFields(t *testing.T) {
+	type Embedded struct {
+		Foo int    `func:"input"`
+		Bar string `func:"output"`
+	}
+
 	tests := []struct {
 		name        string
 		target      reflect.Type
@@ -84,6 +91,19 @@ func TestFields(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Option",
+			target: reflect.TypeOf(struct {
+				Foo int `func:"input,force_new"`
+			}{}),
+			wantInputs: resource.FieldSet{
+				"foo": {
+					Index: 0,
+					Type:  reflect.TypeOf(123),
+				},
+			},
+			wantOutputs: nil,
+		},
 		{
 			name: "Pointer",
 			target: reflect.TypeOf(&struct {
@@ -97,6 +117,48 @@ func TestFields(t *testing.T) {
 			},
 			wantOutputs: nil,
 		},
+		{
+			name: "Embedded",
+			target: reflect.TypeOf(struct {
+				Baz bool `func:"input"`
+				Embedded
+			}{}),
+			wantInputs: resource.FieldSet{
+				"baz": {
+					Index: 0,
+					Type:  reflect.TypeOf(true),
+				},
+				"foo": {
+					Index: 0,
+					Type:  reflect.TypeOf(123),
+				},
+			},
+			wantOutputs: resource.FieldSet{
+				"bar": {
+					Index: 1,
+					Type:  reflect.TypeOf("string"),
+				},
+			},
+		},
+		{
+			name: "EmbeddedShadowed",
+			target: reflect.TypeOf(struct {
+				Embedded
+				Foo *int `func:"input"`
+			}{}),
+			wantInputs: resource.FieldSet{
+				"foo": {
+					Index: 1,
+					Type:  reflect.TypeOf((*int)(nil)),
+				},
+			},
+			wantOutputs: resource.FieldSet{
+				"bar": {
+					Index: 1,
+					Type:  reflect.TypeOf("string"),
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -209,3 +271,132 @@ func ExampleFieldName_withCustom() {
 	fmt.Println(got)
 	// Output: rest_api_id
 }
+
+func TestFields_cached(t *testing.T) {
+	target := reflect.TypeOf(struct {
+		Foo string `func:"input" validate:"min=1"`
+	}{})
+
+	first := resource.Fields(target)
+	first["foo"].Tags["validate"] = "modified"
+	delete(first, "foo")
+
+	second := resource.Fields(target)
+	foo, ok := second["foo"]
+	if !ok {
+		t.Fatalf("Modifying returned FieldSet modified cache")
+	}
+	if got, want := foo.Tags["validate"], "min=1"; got != want {
+		t.Errorf("Tags modified in cache, got = %q, want = %q", got, want)
+	}
+}
+
+func TestFields_concurrent(t *testing.T) {
+	target := reflect.TypeOf(struct {
+		Foo string `func:"input"`
+		Bar string `func:"output"`
+	}{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ff := resource.Fields(target)
+			ff["baz"] = resource.Field{}
+			if len(ff.Inputs()) != 1 || len(ff.Outputs()) != 1 {
+				t.Errorf("Unexpected fields %v", ff)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestField_Sensitive(t *testing.T) {
+	target := reflect.TypeOf(struct {
+		Public string `func:"input"`
+		Secret string `func:"input" sensitive:"true"`
+	}{})
+
+	ff := resource.Fields(target)
+	if ff["public"].Sensitive() {
+		t.Errorf("public is sensitive")
+	}
+	if !ff["secret"].Sensitive() {
+		t.Errorf("secret is not sensitive")
+	}
+}
+
+func TestField_ForceNew(t *testing.T) {
+	target := reflect.TypeOf(struct {
+		Mutable   string `func:"input"`
+		Immutable string `func:"input,force_new"`
+	}{})
+
+	ff := resource.Fields(target)
+	if ff["mutable"].ForceNew() {
+		t.Errorf("mutable forces new")
+	}
+	if !ff["immutable"].ForceNew() {
+		t.Errorf("immutable does not force new")
+	}
+}
+
+func TestField_Duration(t *testing.T) {
+	target := reflect.TypeOf(struct {
+		Count   int `func:"input"`
+		Timeout int `func:"input,duration"`
+		Delay   int `func:"input,force_new,duration_ms"`
+	}{})
+
+	ff := resource.Fields(target)
+	tests := map[string]time.Duration{
+		"count":   0,
+		"timeout": time.Second,
+		"delay":   time.Millisecond,
+	}
+	for name, want := range tests {
+		if got := ff[name].Duration(); got != want {
+			t.Errorf("%s Duration() = %v, want %v", name, got, want)
+		}
+	}
+	if !ff["delay"].ForceNew() {
+		t.Errorf("delay does not force new")
+	}
+}
+
+func TestField_ID(t *testing.T) {
+	target := reflect.TypeOf(struct {
+		Name string `func:"output"`
+		ID   string `func:"output,id"`
+	}{})
+
+	ff := resource.Fields(target)
+	if ff["name"].ID() {
+		t.Errorf("name is an id")
+	}
+	if !ff["id"].ID() {
+		t.Errorf("id is not an id")
+	}
+	if _, ok := ff.Outputs()["id"]; !ok {
+		t.Errorf("id is not an output")
+	}
+}
+
+func TestField_Value(t *testing.T) {
+	type Embedded struct {
+		Foo string `func:"input"`
+	}
+	v := reflect.ValueOf(struct {
+		Bar string `func:"input"`
+		Embedded
+	}{"bar", Embedded{"foo"}})
+
+	ff := resource.Fields(v.Type())
+	if got := ff["bar"].Value(v).String(); got != "bar" {
+		t.Errorf("Value(bar) = %q, want %q", got, "bar")
+	}
+	if got := ff["foo"].Value(v).String(); got != "foo" {
+		t.Errorf("Value(foo) = %q, want %q", got, "foo")
+	}
+}
@@ -0,0 +1,45 @@
+package validation
+
+import "strings"
+
+// Constraints describe the values allowed by a set of rules, for
+// documentation.
+type Constraints struct {
+	// OneOf contains the allowed values, set with the oneof rule.
+	OneOf []string
+
+	// Min and Max are the bounds set with the min and max rules. For strings,
+	// slices and maps the bounds apply to the length. Empty if not set.
+	Min, Max string
+
+	// Other contains the rules that are not described by the constraints,
+	// including their parameters.
+	Other []string
+}
+
+// ParseConstraints parses the constraints from rules, in the format accepted
+// by Validate.
+func ParseConstraints(rules string) Constraints {
+	var c Constraints
+	if rules == "" {
+		return c
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		val := strings.SplitN(rule, "=", 2)
+		if len(val) != 2 {
+			c.Other = append(c.Other, rule)
+			continue
+		}
+		switch val[0] {
+		case "oneof":
+			c.OneOf = strings.Split(val[1], " ")
+		case "min":
+			c.Min = val[1]
+		case "max":
+			c.Max = val[1]
+		default:
+			c.Other = append(c.Other, rule)
+		}
+	}
+	return c
+}
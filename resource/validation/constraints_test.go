@@ -0,0 +1,35 @@
+package validation_test
+
+import (
+	"testing"
+
+	"github.com/func/func/resource/validation"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseConstraints(t *testing.T) {
+	tests := []struct {
+		rules string
+		want  validation.Constraints
+	}{
+		{"", validation.Constraints{}},
+		{"oneof=PROVISIONED PAY_PER_REQUEST", validation.Constraints{
+			OneOf: []string{"PROVISIONED", "PAY_PER_REQUEST"},
+		}},
+		{"min=1,max=255", validation.Constraints{Min: "1", Max: "255"}},
+		{"min=128,max=3008,div=64", validation.Constraints{
+			Min:   "128",
+			Max:   "3008",
+			Other: []string{"div=64"},
+		}},
+		{"aws_arn", validation.Constraints{Other: []string{"aws_arn"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rules, func(t *testing.T) {
+			got := validation.ParseConstraints(tt.rules)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("ParseConstraints() (-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"io"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"path"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -17,31 +18,46 @@ type TarGZ struct{}
 // Compress compresses the given files into a tarball that is written into w.
 //
 // The file paths will be relative to the given directory.
-func (TarGZ) Compress(w io.Writer, dir string) error {
-	dir = filepath.Clean(dir)
+func (t TarGZ) Compress(w io.Writer, dir string) error {
+	return t.CompressFS(w, os.DirFS(dir), ".")
+}
+
+// CompressFS compresses the files in the given directory in fsys into a
+// tarball that is written into w.
+//
+// The file paths will be relative to the given directory.
+func (TarGZ) CompressFS(w io.Writer, fsys fs.FS, dir string) error {
+	dir = path.Clean(dir)
 	gz := gzip.NewWriter(w)
 	tf := tar.NewWriter(gz)
 
-	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if path == dir {
+	if err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if name == dir {
 			// Skip self
 			return nil
 		}
 		if err != nil {
 			return errors.WithStack(err)
 		}
+		info, err := d.Info()
+		if err != nil {
+			return errors.WithStack(err)
+		}
 		hdr, err := tar.FileInfoHeader(info, "")
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		hdr.Name = strings.TrimPrefix(path, dir+string(filepath.Separator))
+		hdr.Name = name
+		if dir != "." {
+			hdr.Name = strings.TrimPrefix(name, dir+"/")
+		}
 		if err = tf.WriteHeader(hdr); err != nil {
 			return errors.WithStack(err)
 		}
 		if !info.Mode().IsRegular() {
 			return nil
 		}
-		f, err := os.Open(path)
+		f, err := fsys.Open(name)
 		if err != nil {
 			return errors.WithStack(err)
 		}
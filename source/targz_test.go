@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"testing"
+	"testing/fstest"
 
 	"github.com/func/func/source"
 	"github.com/google/go-cmp/cmp"
@@ -49,6 +50,28 @@ func TestCompress(t *testing.T) {
 	}
 }
 
+func TestTarGZ_CompressFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"project/src/a.txt":     {Data: []byte("aaa\n")},
+		"project/src/sub/b.txt": {Data: []byte("bbb\n")},
+		"project/func.hcl":      {Data: []byte("# not included\n")},
+	}
+
+	var buf bytes.Buffer
+	if err := (source.TarGZ{}).CompressFS(&buf, fsys, "project/src"); err != nil {
+		t.Fatalf("CompressFS() error = %v", err)
+	}
+
+	want := map[string][]byte{
+		"a.txt":     []byte("aaa\n"),
+		"sub/b.txt": []byte("bbb\n"),
+	}
+	got := filesInGzip(t, &buf)
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Files do not match (-got, +want)\n%s", diff)
+	}
+}
+
 func filesInGzip(t *testing.T, r io.Reader) map[string][]byte {
 	t.Helper()
 	gzr, err := gzip.NewReader(r)
@@ -61,25 +61,17 @@ func (d *DynamoDB) CreateTable(ctx context.Context, rcu, wcu int64) error {
 
 // PutResource creates or updates a resource.
 func (d *DynamoDB) PutResource(ctx context.Context, project string, res *resource.Deployed) error {
-	input := &dynamodb.PutItemInput{
-		TableName: aws.String(d.TableName),
-		Item: map[string]dynamodb.AttributeValue{
-			"Project": attr.FromString(project),
-			"ID":      attr.FromString(fmt.Sprintf("resource-%s", res.ID)),
-			"Type":    attr.FromString(res.Type),
-			"Name":    attr.FromString(res.Name),
-			"Input":   attr.FromCtyValue(res.Input),
-			"Output":  attr.FromCtyValue(res.Output),
-		},
+	item, err := attr.MarshalDeployed(res)
+	if err != nil {
+		return errors.Wrap(err, "marshal resource")
 	}
+	item["Project"] = attr.FromString(project)
+	item["ID"] = attr.FromString(fmt.Sprintf("resource-%s", res.ID))
 
-	if len(res.Deps) > 0 {
-		input.Item["Dependencies"] = attr.FromStringSet(res.Deps)
-	}
-	if len(res.Sources) > 0 {
-		input.Item["Sources"] = attr.FromStringSet(res.Sources)
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(d.TableName),
+		Item:      item,
 	}
-
 	if _, err := d.Client.PutItemRequest(input).Send(ctx); err != nil {
 		return errors.Wrap(err, "dynamodb put")
 	}
@@ -126,55 +118,51 @@ func (d *DynamoDB) ListResources(ctx context.Context, project string) ([]*resour
 
 	out := make([]*resource.Deployed, *resp.Count)
 	for i, item := range resp.QueryOutput.Items {
-		res := &resource.Deployed{
-			Desired: &resource.Desired{},
-		}
-
-		id, err := attr.ToString(item["ID"])
-		if err != nil {
-			return nil, fmt.Errorf("%d: field ID: %v", i, err)
-		}
-		res.ID = strings.TrimPrefix(id, "resource-")
-
-		name, err := attr.ToString(item["Name"])
-		if err != nil {
-			return nil, fmt.Errorf("%d: field Name: %v", i, err)
-		}
-		res.Name = name
-
-		typename, err := attr.ToString(item["Type"])
+		res, err := d.resourceFromItem(item)
 		if err != nil {
-			return nil, fmt.Errorf("%d: field Type: %v", i, err)
-		}
-		res.Type = typename
-
-		res.Deps = attr.ToStringSet(item["Dependencies"])
-		res.Sources = attr.ToStringSet(item["Sources"])
-
-		typ := d.Registry.Type(typename)
-		if typ == nil {
-			return nil, fmt.Errorf("%d: type %q not registered", i, typename)
+			return nil, fmt.Errorf("%d: %v", i, err)
 		}
-		fields := resource.Fields(typ)
-
-		input, err := attr.ToCtyValue(item["Input"], fields.Inputs().CtyType())
-		if err != nil {
-			return nil, fmt.Errorf("%d: convert input: %v", i, err)
-		}
-		res.Input = input
-
-		output, err := attr.ToCtyValue(item["Output"], fields.Outputs().CtyType())
-		if err != nil {
-			return nil, fmt.Errorf("%d: convert output: %v", i, err)
-		}
-		res.Output = output
-
 		out[i] = res
 	}
 
 	return out, nil
 }
 
+// GetResource returns a resource in a project by its ID. Returns nil if the
+// resource does not exist.
+func (d *DynamoDB) GetResource(ctx context.Context, project, id string) (*resource.Deployed, error) {
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(d.TableName),
+		Key: map[string]dynamodb.AttributeValue{
+			"Project": {S: aws.String(project)},
+			"ID":      {S: aws.String(fmt.Sprintf("resource-%s", id))},
+		},
+	}
+	resp, err := d.Client.GetItemRequest(input).Send(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "dynamodb get")
+	}
+	if resp.Item == nil {
+		// Not found
+		return nil, nil
+	}
+	return d.resourceFromItem(resp.Item)
+}
+
+// resourceFromItem converts a stored item to a deployed resource.
+func (d *DynamoDB) resourceFromItem(item map[string]dynamodb.AttributeValue) (*resource.Deployed, error) {
+	id, err := attr.ToString(item["ID"])
+	if err != nil {
+		return nil, fmt.Errorf("field ID: %v", err)
+	}
+	res, err := attr.UnmarshalDeployed(item, d.Registry)
+	if err != nil {
+		return nil, err
+	}
+	res.ID = strings.TrimPrefix(id, "resource-")
+	return res, nil
+}
+
 // PutGraph creates or updates a graph.
 func (d *DynamoDB) PutGraph(ctx context.Context, project string, g *resource.Graph) error {
 	resources := make([]dynamodb.AttributeValue, len(g.Resources))
@@ -188,6 +176,15 @@ func (d *DynamoDB) PutGraph(ctx context.Context, project string, g *resource.Gra
 		if len(res.Sources) > 0 {
 			item["Sources"] = attr.FromStringSet(res.Sources)
 		}
+		if len(res.DependsOn) > 0 {
+			item["DependsOn"] = attr.FromStringSet(res.DependsOn)
+		}
+		if res.Description != "" {
+			item["Description"] = attr.FromString(res.Description)
+		}
+		if res.Profile != "" {
+			item["Profile"] = attr.FromString(res.Profile)
+		}
 
 		resources[i] = dynamodb.AttributeValue{M: item}
 	}
@@ -201,6 +198,18 @@ func (d *DynamoDB) PutGraph(ctx context.Context, project string, g *resource.Gra
 		deps[i] = dynamodb.AttributeValue{M: dep}
 	}
 
+	outputs := make([]dynamodb.AttributeValue, len(g.Outputs))
+	for i, o := range g.Outputs {
+		out := map[string]dynamodb.AttributeValue{
+			"Name":       attr.FromString(o.Name),
+			"Expression": attr.FromExpression(o.Expression),
+		}
+		if o.Sensitive {
+			out["Sensitive"] = attr.FromBool(true)
+		}
+		outputs[i] = dynamodb.AttributeValue{M: out}
+	}
+
 	input := &dynamodb.PutItemInput{
 		TableName: aws.String(d.TableName),
 		Item: map[string]dynamodb.AttributeValue{
@@ -215,6 +224,9 @@ func (d *DynamoDB) PutGraph(ctx context.Context, project string, g *resource.Gra
 	if len(deps) > 0 {
 		input.Item["Dependencies"] = dynamodb.AttributeValue{L: deps}
 	}
+	if len(outputs) > 0 {
+		input.Item["Outputs"] = dynamodb.AttributeValue{L: outputs}
+	}
 
 	resp, err := d.Client.PutItemRequest(input).Send(ctx)
 	if err != nil {
@@ -261,6 +273,21 @@ func (d *DynamoDB) GetGraph(ctx context.Context, project string) (*resource.Grap
 		res.Type = typename
 
 		res.Sources = attr.ToStringSet(item.M["Sources"])
+		res.DependsOn = attr.ToStringSet(item.M["DependsOn"])
+		if v, ok := item.M["Description"]; ok {
+			description, err := attr.ToString(v)
+			if err != nil {
+				return nil, fmt.Errorf("%d: field Description: %v", i, err)
+			}
+			res.Description = description
+		}
+		if v, ok := item.M["Profile"]; ok {
+			profile, err := attr.ToString(v)
+			if err != nil {
+				return nil, fmt.Errorf("%d: field Profile: %v", i, err)
+			}
+			res.Profile = profile
+		}
 
 		typ := d.Registry.Type(typename)
 		if typ == nil {
@@ -300,6 +327,27 @@ func (d *DynamoDB) GetGraph(ctx context.Context, project string) (*resource.Grap
 			return nil, fmt.Errorf("add dependency: %v", err)
 		}
 	}
+	for i, out := range resp.Item["Outputs"].L {
+		name, err := attr.ToString(out.M["Name"])
+		if err != nil {
+			return nil, fmt.Errorf("decode output %d: Name: %v", i, err)
+		}
+		expr, err := attr.ToExpression(out.M["Expression"])
+		if err != nil {
+			return nil, fmt.Errorf("decode output %d: Expression: %v", i, err)
+		}
+		var sensitive bool
+		if v, ok := out.M["Sensitive"]; ok {
+			sensitive, err = attr.ToBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("decode output %d: Sensitive: %v", i, err)
+			}
+		}
+		output := &resource.Output{Name: name, Expression: expr, Sensitive: sensitive}
+		if err := g.AddOutput(output); err != nil {
+			return nil, fmt.Errorf("add output: %v", err)
+		}
+	}
 
 	return g, nil
 }
@@ -49,14 +49,18 @@ func TestDynamoDB_Resources(t *testing.T) {
 	}
 	resB := &resource.Deployed{
 		Desired: &resource.Desired{
-			Type:    "foo",
-			Name:    "b",
-			Input:   cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("123")}),
-			Sources: []string{"x", "y", "z"},
+			Type:           "foo",
+			Name:           "b",
+			Input:          cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal("123")}),
+			Sources:        []string{"x", "y", "z"},
+			PreventDestroy: true,
+			Profile:        "prod",
 		},
-		ID:     "b",
-		Output: cty.ObjectVal(map[string]cty.Value{"output": cty.StringVal("456")}),
-		Deps:   []string{"foo", "bar"},
+		ID:            "b",
+		Output:        cty.ObjectVal(map[string]cty.Value{"output": cty.StringVal("456")}),
+		Deps:          []string{"foo", "bar"},
+		LastAppliedAt: time.Date(2019, 6, 1, 12, 30, 15, 123, time.UTC),
+		LastDuration:  1500 * time.Millisecond,
 	}
 
 	// Create
@@ -159,9 +163,10 @@ func TestDynamoDB_Graphs(t *testing.T) {
 				}),
 			},
 			{
-				Name:    "bob",
-				Type:    "person",
-				Sources: []string{"abc"},
+				Name:      "bob",
+				Type:      "person",
+				Sources:   []string{"abc"},
+				DependsOn: []string{"alice"},
 				Input: cty.ObjectVal(map[string]cty.Value{
 					"name": cty.StringVal("bob"),
 					"age":  cty.NumberIntVal(30),
@@ -182,6 +187,22 @@ func TestDynamoDB_Graphs(t *testing.T) {
 				},
 			},
 		},
+		Outputs: []*resource.Output{
+			{
+				Name: "greeting",
+				Expression: resource.Expression{
+					resource.ExprLiteral{Value: cty.StringVal("Hello, ")},
+					resource.ExprReference{Path: cty.GetAttrPath("alice").GetAttr("name")},
+				},
+			},
+			{
+				Name: "secret",
+				Expression: resource.Expression{
+					resource.ExprReference{Path: cty.GetAttrPath("alice").GetAttr("name")},
+				},
+				Sensitive: true,
+			},
+		},
 	}
 
 	// Create
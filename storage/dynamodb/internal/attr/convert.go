@@ -1,8 +1,10 @@
 package attr
 
 import (
+	"encoding/base64"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -91,6 +93,21 @@ func ToFloat64(attr dynamodb.AttributeValue) (float64, error) {
 	return f, nil
 }
 
+// FromTime creates a string attribute from a timestamp. The time is encoded in
+// RFC 3339 format with nanosecond precision.
+func FromTime(t time.Time) dynamodb.AttributeValue {
+	return FromString(t.UTC().Format(time.RFC3339Nano))
+}
+
+// ToTime parses a timestamp from a string attribute.
+func ToTime(attr dynamodb.AttributeValue) (time.Time, error) {
+	str, err := ToString(attr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, str)
+}
+
 // FromStringSlice creates an attribute with a sorted list of strings.
 func FromStringSlice(list []string) dynamodb.AttributeValue {
 	values := make([]dynamodb.AttributeValue, len(list))
@@ -134,11 +151,99 @@ func ToStringSet(attr dynamodb.AttributeValue) []string {
 	return attr.SS
 }
 
+// FromOrderedStringSet creates an ordered set of strings.
+//
+// Unlike FromStringSet, the values are stored in a list (L) attribute, so the
+// order is retained when the value is read back. Duplicate values are removed;
+// the first occurrence of a value determines its position.
+//
+// An ordered set should be used when the order of the values is significant.
+// When it is not, FromStringSet should be preferred, as DynamoDB can operate
+// on native sets.
+func FromOrderedStringSet(list []string) dynamodb.AttributeValue {
+	seen := make(map[string]struct{}, len(list))
+	values := make([]dynamodb.AttributeValue, 0, len(list))
+	for _, v := range list {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		values = append(values, dynamodb.AttributeValue{S: aws.String(v)})
+	}
+	return dynamodb.AttributeValue{L: values}
+}
+
+// ToOrderedStringSet returns an ordered set of strings from an attribute.
+//
+// The values are returned in the order they were stored. A native string set
+// (SS) is accepted as well, in which case the order is not deterministic.
+func ToOrderedStringSet(attr dynamodb.AttributeValue) ([]string, error) {
+	if len(attr.SS) > 0 {
+		return attr.SS, nil
+	}
+	return ToStringSlice(attr)
+}
+
+// Binary is the cty type for binary data. DynamoDB stores values of this type
+// and sets of it natively as binary (B) and binary set (BS) attributes.
+//
+// The cty type system does not have a binary type, so the data is kept as a
+// base64 encoded string in an object with a single $base64 attribute. The
+// data is decoded when writing the attribute and encoded when reading it.
+var Binary = cty.Object(map[string]cty.Type{binaryAttr: cty.String})
+
+const binaryAttr = "$base64"
+
+// BinaryVal returns a cty value of type Binary holding the given data.
+func BinaryVal(data []byte) cty.Value {
+	return cty.ObjectVal(map[string]cty.Value{
+		binaryAttr: cty.StringVal(base64.StdEncoding.EncodeToString(data)),
+	})
+}
+
+// FromBinary creates a binary attribute.
+func FromBinary(data []byte) dynamodb.AttributeValue {
+	if data == nil {
+		data = []byte{}
+	}
+	return dynamodb.AttributeValue{B: data}
+}
+
+// ToBinary returns the binary data from an attribute.
+func ToBinary(attr dynamodb.AttributeValue) ([]byte, error) {
+	if attr.B == nil {
+		return nil, fmt.Errorf("binary value not set")
+	}
+	return attr.B, nil
+}
+
+// binaryData decodes the data from a value of type Binary.
+func binaryData(v cty.Value) []byte {
+	str := v.GetAttr(binaryAttr)
+	if str.IsNull() {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(str.AsString())
+	if err != nil {
+		panic(fmt.Sprintf("Decode binary value: %v", err))
+	}
+	return data
+}
+
 // FromCtyValue encodes a value from the cty type system to a DynamoDB attribute.
 //
 // In case the value contains nested objects such as objects, they are nested
 // into the returned attribute.
 //
+// Values of type Binary are encoded as binary attributes. Panics if the value
+// does not contain valid base64 data.
+//
+// Lists and tuples are encoded as list (L) attributes and retain the order of
+// their elements. Sets of strings, numbers and binary data are encoded as
+// native DynamoDB sets (SS, NS, BS), which are unordered. Sets of other types
+// are encoded as lists. A cty set never has an order to retain; if the order
+// of unique values matters, the value should be a list instead.
+//
 // Unknown, dynamic and capsule values are not supported.
 func FromCtyValue(v cty.Value) dynamodb.AttributeValue {
 	if v.IsNull() {
@@ -149,6 +254,9 @@ func FromCtyValue(v cty.Value) dynamodb.AttributeValue {
 	}
 
 	ty := v.Type()
+	if ty.Equals(Binary) {
+		return FromBinary(binaryData(v))
+	}
 	switch ty {
 	case cty.Bool:
 		return FromBool(v.True())
@@ -203,6 +311,23 @@ func FromCtyValue(v cty.Value) dynamodb.AttributeValue {
 			})
 			return dynamodb.AttributeValue{NS: list}
 		}
+		if ty.ElementType().Equals(Binary) {
+			// DynamoDB has native support for unsorted sets of binary data.
+			// Cannot be empty.
+			if v.LengthInt() == 0 {
+				return dynamodb.AttributeValue{NULL: aws.Bool(true)}
+			}
+			list := make([][]byte, 0, v.LengthInt())
+			v.ForEachElement(func(_ cty.Value, elem cty.Value) bool {
+				data := binaryData(elem)
+				if data == nil {
+					data = []byte{}
+				}
+				list = append(list, data)
+				return false
+			})
+			return dynamodb.AttributeValue{BS: list}
+		}
 		// Sets of other types cannot be represented in DynamoDB. Return list instead.
 		list := make([]dynamodb.AttributeValue, 0, v.LengthInt())
 		v.ForEachElement(func(_ cty.Value, elem cty.Value) bool {
@@ -235,6 +360,12 @@ func ToCtyValue(attr dynamodb.AttributeValue, ty cty.Type) (cty.Value, error) {
 		return cty.NullVal(ty), nil
 
 	}
+	if ty.Equals(Binary) {
+		if attr.B == nil {
+			return cty.NilVal, NotSetError{ty, "B"}
+		}
+		return BinaryVal(attr.B), nil
+	}
 	switch ty {
 	case cty.Bool:
 		v := attr.BOOL
@@ -253,7 +384,7 @@ func ToCtyValue(attr dynamodb.AttributeValue, ty cty.Type) (cty.Value, error) {
 		if v == nil {
 			return cty.NilVal, NotSetError{ty, "N"}
 		}
-		return cty.ParseNumberVal(*v)
+		return parseNumber(*v)
 	}
 
 	switch {
@@ -276,7 +407,7 @@ func ToCtyValue(attr dynamodb.AttributeValue, ty cty.Type) (cty.Value, error) {
 			}
 			vals := make([]cty.Value, len(attr.NS))
 			for i, v := range attr.NS {
-				ev, err := cty.ParseNumberVal(v)
+				ev, err := parseNumber(v)
 				if err != nil {
 					return cty.NilVal, fmt.Errorf("element %d: %v", i, err)
 				}
@@ -292,6 +423,15 @@ func ToCtyValue(attr dynamodb.AttributeValue, ty cty.Type) (cty.Value, error) {
 				vals[i] = cty.StringVal(v)
 			}
 			return cty.ListVal(vals), nil
+		case len(attr.BS) > 0:
+			if !et.Equals(Binary) {
+				return cty.NilVal, fmt.Errorf("set of binary data cannot be assigned to %s", ty)
+			}
+			vals := make([]cty.Value, len(attr.BS))
+			for i, v := range attr.BS {
+				vals[i] = BinaryVal(v)
+			}
+			return cty.ListVal(vals), nil
 		default:
 			return cty.ListValEmpty(et), nil
 		}
@@ -304,7 +444,7 @@ func ToCtyValue(attr dynamodb.AttributeValue, ty cty.Type) (cty.Value, error) {
 			}
 			vals := make([]cty.Value, len(attr.NS))
 			for i, v := range attr.NS {
-				ev, err := cty.ParseNumberVal(v)
+				ev, err := parseNumber(v)
 				if err != nil {
 					return cty.NilVal, fmt.Errorf("element %d: %v", i, err)
 				}
@@ -320,6 +460,15 @@ func ToCtyValue(attr dynamodb.AttributeValue, ty cty.Type) (cty.Value, error) {
 				vals[i] = cty.StringVal(v)
 			}
 			return cty.SetVal(vals), nil
+		case len(attr.BS) > 0:
+			if !et.Equals(Binary) {
+				return cty.NilVal, fmt.Errorf("set of binary data cannot be assigned to %s", ty)
+			}
+			vals := make([]cty.Value, len(attr.BS))
+			for i, v := range attr.BS {
+				vals[i] = BinaryVal(v)
+			}
+			return cty.SetVal(vals), nil
 		default:
 			return cty.SetValEmpty(et), nil
 		}
@@ -349,13 +498,24 @@ func ToCtyValue(attr dynamodb.AttributeValue, ty cty.Type) (cty.Value, error) {
 		}
 		return cty.MapVal(vals), nil
 	case ty.IsObjectType():
-		if len(attr.M) == 0 {
-			return cty.EmptyObjectVal, nil
+		if attr.M == nil {
+			// An empty object is stored as an empty map, so a missing map
+			// means the object was not set.
+			return cty.NullVal(ty), nil
 		}
-		vals := make(map[string]cty.Value, len(attr.M))
 		types := ty.AttributeTypes()
-		for k, v := range attr.M {
-			et := types[k]
+		for k := range attr.M {
+			if _, ok := types[k]; !ok {
+				return cty.NilVal, fmt.Errorf("unexpected element %s", k)
+			}
+		}
+		vals := make(map[string]cty.Value, len(types))
+		for k, et := range types {
+			v, ok := attr.M[k]
+			if !ok {
+				vals[k] = cty.NullVal(et)
+				continue
+			}
 			ev, err := ToCtyValue(v, et)
 			if err != nil {
 				return cty.NilVal, fmt.Errorf("element %s: %v", k, err)
@@ -368,6 +528,17 @@ func ToCtyValue(attr dynamodb.AttributeValue, ty cty.Type) (cty.Value, error) {
 	panic(fmt.Sprintf("Not supported: %s", ty.FriendlyName()))
 }
 
+// parseNumber parses a number attribute. Integers are returned as
+// cty.NumberIntVal, so a decoded value is identical to the encoded one,
+// including in sets. cty.ParseNumberVal always uses a higher precision, which
+// compares equal but not raw equal to the original value.
+func parseNumber(s string) (cty.Value, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return cty.NumberIntVal(i), nil
+	}
+	return cty.ParseNumberVal(s)
+}
+
 // FromCtyPath encodes a cty path to an attribute.
 func FromCtyPath(path cty.Path) dynamodb.AttributeValue {
 	parts := make([]dynamodb.AttributeValue, len(path))
@@ -380,9 +551,14 @@ func FromCtyPath(path cty.Path) dynamodb.AttributeValue {
 				},
 			}
 		case cty.IndexStep:
+			// Dynamic index
+			key := dynamodb.AttributeValue{NULL: aws.Bool(true)}
+			if v.Key.IsKnown() {
+				key = FromCtyValue(v.Key)
+			}
 			parts[i] = dynamodb.AttributeValue{
 				M: map[string]dynamodb.AttributeValue{
-					"Index": FromCtyValue(v.Key),
+					"Index": key,
 				},
 			}
 		default:
@@ -416,7 +592,7 @@ func ToCtyPath(attr dynamodb.AttributeValue) (cty.Path, error) {
 		if index, ok := p.M["Index"]; ok {
 			if index.N != nil {
 				// Numeric index
-				k, err := cty.ParseNumberVal(*index.N)
+				k, err := parseNumber(*index.N)
 				if err != nil {
 					return nil, fmt.Errorf("%d: %v", i, err)
 				}
@@ -428,6 +604,11 @@ func ToCtyPath(attr dynamodb.AttributeValue) (cty.Path, error) {
 				path[i] = cty.IndexStep{Key: cty.StringVal(*index.S)}
 				continue
 			}
+			if index.NULL != nil && *index.NULL {
+				// Dynamic index
+				path[i] = cty.IndexStep{Key: cty.DynamicVal}
+				continue
+			}
 			return nil, fmt.Errorf("%d: index number or name must be set", i)
 		}
 		return nil, fmt.Errorf("%d: Attr or Index must be set", i)
@@ -445,12 +626,35 @@ func FromExpression(expression resource.Expression) dynamodb.AttributeValue {
 				"Literal": FromCtyValue(v.Value),
 			}}
 		case resource.ExprReference:
-			expr[i] = dynamodb.AttributeValue{M: map[string]dynamodb.AttributeValue{
+			m := map[string]dynamodb.AttributeValue{
 				"Reference": FromCtyPath(v.Path),
+			}
+			if len(v.Keys) > 0 {
+				keys := make(map[string]dynamodb.AttributeValue, len(v.Keys))
+				for pos, key := range v.Keys {
+					keys[strconv.Itoa(pos)] = FromCtyPath(key)
+				}
+				m["Keys"] = dynamodb.AttributeValue{M: keys}
+			}
+			if len(v.KeyFields) > 0 {
+				fields := make(map[string]dynamodb.AttributeValue, len(v.KeyFields))
+				for pos, name := range v.KeyFields {
+					fields[strconv.Itoa(pos)] = dynamodb.AttributeValue{S: aws.String(name)}
+				}
+				m["KeyFields"] = dynamodb.AttributeValue{M: fields}
+			}
+			expr[i] = dynamodb.AttributeValue{M: m}
+		case resource.ExprConditional:
+			expr[i] = dynamodb.AttributeValue{M: map[string]dynamodb.AttributeValue{
+				"Conditional": {M: map[string]dynamodb.AttributeValue{
+					"Condition": FromExpression(v.Condition),
+					"True":      FromExpression(v.True),
+					"False":     FromExpression(v.False),
+				}},
 			}}
 		default:
 			// This should not happen, an expression can only consist of
-			// literals and expressions.
+			// literals, references and conditionals.
 			panic(fmt.Sprintf("Unsupported type %T at %d", v, i))
 		}
 	}
@@ -468,24 +672,85 @@ func ToExpression(attr dynamodb.AttributeValue) (resource.Expression, error) {
 			return nil, fmt.Errorf("list does not contain maps")
 		}
 		if lit, ok := p.M["Literal"]; ok {
-			if lit.S == nil {
-				return nil, fmt.Errorf("%d: literal string not set", i)
+			// Literals are strings, except in conditionals, where they may
+			// be any primitive value.
+			var ty cty.Type
+			switch {
+			case lit.S != nil:
+				ty = cty.String
+			case lit.N != nil:
+				ty = cty.Number
+			case lit.BOOL != nil:
+				ty = cty.Bool
+			default:
+				return nil, fmt.Errorf("%d: literal value not set", i)
+			}
+			v, err := ToCtyValue(lit, ty)
+			if err != nil {
+				return nil, fmt.Errorf("%d: literal: %v", i, err)
 			}
-			expr[i] = resource.ExprLiteral{Value: cty.StringVal(*lit.S)}
+			expr[i] = resource.ExprLiteral{Value: v}
+			continue
+		}
+		if c, ok := p.M["Conditional"]; ok {
+			var cond resource.ExprConditional
+			var err error
+			if cond.Condition, err = ToExpression(c.M["Condition"]); err != nil {
+				return nil, fmt.Errorf("%d: conditional condition: %v", i, err)
+			}
+			if cond.True, err = ToExpression(c.M["True"]); err != nil {
+				return nil, fmt.Errorf("%d: conditional true: %v", i, err)
+			}
+			if cond.False, err = ToExpression(c.M["False"]); err != nil {
+				return nil, fmt.Errorf("%d: conditional false: %v", i, err)
+			}
+			if len(cond.Condition) == 0 {
+				return nil, fmt.Errorf("%d: conditional condition is empty", i)
+			}
+			expr[i] = cond
 			continue
 		}
 		if ref, ok := p.M["Reference"]; ok {
-			p, err := ToCtyPath(ref)
+			path, err := ToCtyPath(ref)
 			if err != nil {
 				return nil, fmt.Errorf("%d: parse reference: %v", i, err)
 			}
-			if len(p) == 0 {
+			if len(path) == 0 {
 				return nil, fmt.Errorf("%d: reference path is empty", i)
 			}
-			expr[i] = resource.ExprReference{Path: p}
+			var keys map[int]cty.Path
+			if k, ok := p.M["Keys"]; ok && len(k.M) > 0 {
+				keys = make(map[int]cty.Path, len(k.M))
+				for str, attr := range k.M {
+					pos, err := strconv.Atoi(str)
+					if err != nil || pos < 0 || pos >= len(path) {
+						return nil, fmt.Errorf("%d: invalid key position %q", i, str)
+					}
+					key, err := ToCtyPath(attr)
+					if err != nil {
+						return nil, fmt.Errorf("%d: parse key %d: %v", i, pos, err)
+					}
+					keys[pos] = key
+				}
+			}
+			var keyFields map[int]string
+			if k, ok := p.M["KeyFields"]; ok && len(k.M) > 0 {
+				keyFields = make(map[int]string, len(k.M))
+				for str, attr := range k.M {
+					pos, err := strconv.Atoi(str)
+					if err != nil || pos < 0 || pos >= len(path) {
+						return nil, fmt.Errorf("%d: invalid key field position %q", i, str)
+					}
+					if attr.S == nil {
+						return nil, fmt.Errorf("%d: key field %d not set", i, pos)
+					}
+					keyFields[pos] = *attr.S
+				}
+			}
+			expr[i] = resource.ExprReference{Path: path, Keys: keys, KeyFields: keyFields}
 			continue
 		}
-		return nil, fmt.Errorf("%d: Literal or Reference must be set", i)
+		return nil, fmt.Errorf("%d: Literal, Reference or Conditional must be set", i)
 	}
 	return expr, nil
 }
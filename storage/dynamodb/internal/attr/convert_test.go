@@ -1,10 +1,13 @@
 package attr
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	. "github.com/aws/aws-sdk-go-v2/service/dynamodb" // Dot import to remove a lot of redundant dynamodb.
@@ -156,6 +159,53 @@ func TestToFloat64(t *testing.T) {
 	}
 }
 
+func TestFromTime(t *testing.T) {
+	tests := []struct {
+		val  time.Time
+		want AttributeValue
+	}{
+		{
+			time.Date(2019, 6, 1, 12, 30, 15, 123, time.UTC),
+			AttributeValue{S: aws.String("2019-06-01T12:30:15.000000123Z")},
+		},
+		{
+			time.Date(2019, 6, 1, 14, 30, 15, 0, time.FixedZone("CEST", 2*60*60)),
+			AttributeValue{S: aws.String("2019-06-01T12:30:15Z")},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			got := FromTime(tt.val)
+			compare(t, got, tt.want)
+		})
+	}
+}
+
+func TestToTime(t *testing.T) {
+	tests := []struct {
+		attr    AttributeValue
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			AttributeValue{S: aws.String("2019-06-01T12:30:15.000000123Z")},
+			time.Date(2019, 6, 1, 12, 30, 15, 123, time.UTC),
+			false,
+		},
+		{AttributeValue{S: nil}, time.Time{}, true},               // Not set
+		{AttributeValue{S: aws.String("abc")}, time.Time{}, true}, // Not a time
+	}
+	for i, tt := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			got, err := ToTime(tt.attr)
+			compareErr(t, err, tt.wantErr)
+			if !got.Equal(tt.want) {
+				t.Errorf("ToTime() got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestFromStringSlice(t *testing.T) {
 	tests := []struct {
 		val  []string
@@ -229,6 +279,54 @@ func TestToStringSet(t *testing.T) {
 	}
 }
 
+func TestFromOrderedStringSet(t *testing.T) {
+	tests := []struct {
+		val  []string
+		want AttributeValue
+	}{
+		{nil, AttributeValue{L: []AttributeValue{}}},
+		{[]string{}, AttributeValue{L: []AttributeValue{}}},
+		{[]string{"b", "a"}, AttributeValue{L: []AttributeValue{{S: aws.String("b")}, {S: aws.String("a")}}}},
+		{[]string{"b", "a", "b"}, AttributeValue{L: []AttributeValue{{S: aws.String("b")}, {S: aws.String("a")}}}},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d_%v", i, tt.val), func(t *testing.T) {
+			got := FromOrderedStringSet(tt.val)
+			compare(t, got, tt.want)
+		})
+	}
+}
+
+func TestToOrderedStringSet(t *testing.T) {
+	tests := []struct {
+		attr    AttributeValue
+		want    []string
+		wantErr bool
+	}{
+		{AttributeValue{L: []AttributeValue{}}, nil, false},
+		{AttributeValue{L: []AttributeValue{{S: aws.String("b")}, {S: aws.String("a")}}}, []string{"b", "a"}, false},
+		{AttributeValue{SS: []string{"a"}}, []string{"a"}, false},
+		{AttributeValue{L: []AttributeValue{{S: nil}}}, nil, true},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d_%v", i, tt.attr), func(t *testing.T) {
+			got, err := ToOrderedStringSet(tt.attr)
+			compareErr(t, err, tt.wantErr)
+			compare(t, got, tt.want)
+		})
+	}
+}
+
+func TestOrderedStringSet_roundTrip(t *testing.T) {
+	input := []string{"c", "a", "b", "a"}
+	got, err := ToOrderedStringSet(FromOrderedStringSet(input))
+	if err != nil {
+		t.Fatalf("ToOrderedStringSet() error = %v", err)
+	}
+	want := []string{"c", "a", "b"}
+	compare(t, got, want)
+}
+
 func TestFromCtyValue(t *testing.T) {
 	tests := []struct {
 		val  cty.Value
@@ -371,15 +469,15 @@ func TestToCtyValue(t *testing.T) {
 		wantErr bool
 	}{
 		// B: Binary
-		// not supported
+		// see TestToCtyValue_binary
 
 		// BOOL	Boolean
 		{AttributeValue{BOOL: aws.Bool(true)}, cty.Bool, cty.BoolVal(true), false},
 		{AttributeValue{BOOL: aws.Bool(true)}, cty.String, cty.NilVal, true}, // Type does not match
 		{AttributeValue{BOOL: nil}, cty.Bool, cty.NilVal, true},
 
-		// BS: Boolean set
-		// not supported
+		// BS: Binary set
+		// see TestToCtyValue_binary
 
 		// L: List
 		{
@@ -459,9 +557,27 @@ func TestToCtyValue(t *testing.T) {
 		{
 			AttributeValue{M: nil},
 			cty.Object(map[string]cty.Type{"a": cty.String, "b": cty.Bool}),
+			cty.NullVal(cty.Object(map[string]cty.Type{"a": cty.String, "b": cty.Bool})),
+			false,
+		},
+		{
+			AttributeValue{M: map[string]AttributeValue{}},
+			cty.EmptyObject,
 			cty.EmptyObjectVal,
 			false,
 		},
+		{
+			AttributeValue{M: map[string]AttributeValue{"a": {S: aws.String("A")}}},
+			cty.Object(map[string]cty.Type{"a": cty.String, "b": cty.Bool}),
+			cty.ObjectVal(map[string]cty.Value{"a": cty.StringVal("A"), "b": cty.NullVal(cty.Bool)}),
+			false, // Missing attribute is null
+		},
+		{
+			AttributeValue{M: map[string]AttributeValue{"a": {S: aws.String("A")}, "c": {S: aws.String("C")}}},
+			cty.Object(map[string]cty.Type{"a": cty.String}),
+			cty.NilVal,
+			true, // Attribute not in type
+		},
 		{
 			AttributeValue{M: map[string]AttributeValue{"a": {S: aws.String("A")}, "b": {BOOL: aws.Bool(true)}}},
 			cty.Map(cty.String),
@@ -606,6 +722,171 @@ func TestToCtyValue(t *testing.T) {
 	}
 }
 
+func TestFromCtyValue_binary(t *testing.T) {
+	tests := []struct {
+		val  cty.Value
+		want AttributeValue
+	}{
+		// Binary
+		{BinaryVal([]byte("foo")), AttributeValue{B: []byte("foo")}},
+		{BinaryVal([]byte{}), AttributeValue{B: []byte{}}},
+		{BinaryVal(nil), AttributeValue{B: []byte{}}},
+		{cty.NullVal(Binary), AttributeValue{NULL: aws.Bool(true)}},
+
+		// Binary set
+		{
+			cty.SetVal([]cty.Value{BinaryVal([]byte("a")), BinaryVal([]byte("b"))}),
+			AttributeValue{BS: [][]byte{[]byte("a"), []byte("b")}},
+		},
+		{
+			// Mixed empty and non-empty data.
+			cty.SetVal([]cty.Value{BinaryVal([]byte{}), BinaryVal([]byte{0x00, 0xff})}),
+			AttributeValue{BS: [][]byte{{}, {0x00, 0xff}}},
+		},
+		{cty.SetValEmpty(Binary), AttributeValue{NULL: aws.Bool(true)}}, // Binary set cannot be empty
+		{cty.NullVal(cty.Set(Binary)), AttributeValue{NULL: aws.Bool(true)}},
+
+		// Nested
+		{
+			cty.ListVal([]cty.Value{BinaryVal([]byte("a"))}),
+			AttributeValue{L: []AttributeValue{{B: []byte("a")}}},
+		},
+		{
+			cty.ObjectVal(map[string]cty.Value{"a": BinaryVal([]byte("a")), "b": cty.StringVal("b")}),
+			AttributeValue{M: map[string]AttributeValue{"a": {B: []byte("a")}, "b": {S: aws.String("b")}}},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d_%#v", i, tt.val), func(t *testing.T) {
+			got := FromCtyValue(tt.val)
+			// Order of set elements is not deterministic.
+			sort.Slice(got.BS, func(i, j int) bool { return bytes.Compare(got.BS[i], got.BS[j]) < 0 })
+			compare(t, got, tt.want)
+		})
+	}
+}
+
+func TestToCtyValue_binary(t *testing.T) {
+	tests := []struct {
+		attr    AttributeValue
+		typ     cty.Type
+		want    cty.Value
+		wantErr bool
+	}{
+		// B: Binary
+		{AttributeValue{B: []byte("foo")}, Binary, BinaryVal([]byte("foo")), false},
+		{AttributeValue{B: []byte{}}, Binary, BinaryVal([]byte{}), false},
+		{AttributeValue{B: nil}, Binary, cty.NilVal, true},
+		{AttributeValue{S: aws.String("foo")}, Binary, cty.NilVal, true}, // Type does not match
+		{AttributeValue{NULL: aws.Bool(true)}, Binary, cty.NullVal(Binary), false},
+
+		// BS: Binary set
+		{
+			AttributeValue{BS: [][]byte{[]byte("a"), []byte("b")}},
+			cty.Set(Binary),
+			cty.SetVal([]cty.Value{BinaryVal([]byte("a")), BinaryVal([]byte("b"))}),
+			false,
+		},
+		{
+			// Mixed empty and non-empty data.
+			AttributeValue{BS: [][]byte{{}, {0x00, 0xff}}},
+			cty.Set(Binary),
+			cty.SetVal([]cty.Value{BinaryVal([]byte{}), BinaryVal([]byte{0x00, 0xff})}),
+			false,
+		},
+		{
+			AttributeValue{BS: [][]byte{[]byte("a"), []byte("b")}},
+			cty.List(Binary),
+			cty.ListVal([]cty.Value{BinaryVal([]byte("a")), BinaryVal([]byte("b"))}),
+			false,
+		},
+		{
+			AttributeValue{BS: nil},
+			cty.Set(Binary),
+			cty.SetValEmpty(Binary),
+			false,
+		},
+		{
+			AttributeValue{BS: [][]byte{[]byte("a")}},
+			cty.Set(cty.String),
+			cty.NilVal,
+			true, // Binary set cannot be assigned to set of strings
+		},
+		{
+			AttributeValue{SS: []string{"a"}},
+			cty.Set(Binary),
+			cty.NilVal,
+			true, // String set cannot be assigned to set of binary
+		},
+	}
+	for i, tt := range tests {
+		name := fmt.Sprintf("%d_%s", i, strings.ReplaceAll(tt.attr.String(), " ", ""))
+		t.Run(name, func(t *testing.T) {
+			got, err := ToCtyValue(tt.attr, tt.typ)
+			compareErr(t, err, tt.wantErr)
+			compare(t, got, tt.want)
+		})
+	}
+}
+
+func TestCtyValue_roundTrip(t *testing.T) {
+	obj := cty.Object(map[string]cty.Type{
+		"nested": cty.Object(map[string]cty.Type{"a": cty.String}),
+		"map":    cty.Map(cty.String),
+	})
+	tests := []struct {
+		name string
+		val  cty.Value
+	}{
+		{"NullObject", cty.NullVal(obj)},
+		{"EmptyObject", cty.EmptyObjectVal},
+		{"NullNestedObject", cty.ObjectVal(map[string]cty.Value{
+			"nested": cty.NullVal(cty.Object(map[string]cty.Type{"a": cty.String})),
+			"map":    cty.MapValEmpty(cty.String),
+		})},
+		{"NestedObjectNullAttr", cty.ObjectVal(map[string]cty.Value{
+			"nested": cty.ObjectVal(map[string]cty.Value{"a": cty.NullVal(cty.String)}),
+			"map":    cty.NullVal(cty.Map(cty.String)),
+		})},
+		{"NullMap", cty.NullVal(cty.Map(cty.String))},
+		{"EmptyMap", cty.MapValEmpty(cty.String)},
+		{"Map", cty.MapVal(map[string]cty.Value{"a": cty.StringVal("A")})},
+		{"Binary", BinaryVal([]byte{0x1f, 0x8b, 0x08})},
+		{"BinarySet", cty.SetVal([]cty.Value{BinaryVal([]byte("a")), BinaryVal([]byte{})})},
+		{"StringList", cty.ListVal([]cty.Value{cty.StringVal("c"), cty.StringVal("a"), cty.StringVal("b")})},
+		{"NumberList", cty.ListVal([]cty.Value{cty.NumberIntVal(3), cty.NumberIntVal(1), cty.NumberIntVal(2)})},
+		{"StringSet", cty.SetVal([]cty.Value{cty.StringVal("c"), cty.StringVal("a"), cty.StringVal("b")})},
+		{"NumberSet", cty.SetVal([]cty.Value{cty.NumberIntVal(3), cty.NumberIntVal(1), cty.NumberIntVal(2)})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attr := FromCtyValue(tt.val)
+			got, err := ToCtyValue(attr, tt.val.Type())
+			if err != nil {
+				t.Fatalf("ToCtyValue() error = %v", err)
+			}
+			if !got.RawEquals(tt.val) {
+				t.Errorf("Round trip\nGot  %#v\nWant %#v", got, tt.val)
+			}
+		})
+	}
+}
+
+func TestToCtyValue_setUnordered(t *testing.T) {
+	// Native sets are unordered; any order, including duplicates, decodes to
+	// the same set.
+	want := cty.SetVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")})
+	for _, ss := range [][]string{{"a", "b"}, {"b", "a"}, {"b", "a", "b"}} {
+		got, err := ToCtyValue(AttributeValue{SS: ss}, cty.Set(cty.String))
+		if err != nil {
+			t.Fatalf("ToCtyValue(%v) error = %v", ss, err)
+		}
+		if !got.RawEquals(want) {
+			t.Errorf("ToCtyValue(%v)\nGot  %#v\nWant %#v", ss, got, want)
+		}
+	}
+}
+
 func TestFromCtyPath(t *testing.T) {
 	tests := []struct {
 		path cty.Path
@@ -780,6 +1061,57 @@ func TestFromExpression(t *testing.T) {
 				{M: map[string]AttributeValue{"Literal": {S: aws.String("baz")}}},
 			}},
 		},
+		{
+			resource.Expression{
+				resource.ExprReference{
+					Path: cty.GetAttrPath("foo").Index(cty.DynamicVal),
+					Keys: map[int]cty.Path{1: cty.GetAttrPath("bar")},
+				},
+			},
+			AttributeValue{L: []AttributeValue{
+				{M: map[string]AttributeValue{
+					"Reference": {L: []AttributeValue{
+						{M: map[string]AttributeValue{"Attr": {S: aws.String("foo")}}},
+						{M: map[string]AttributeValue{"Index": {NULL: aws.Bool(true)}}},
+					}},
+					"Keys": {M: map[string]AttributeValue{
+						"1": FromCtyPath(cty.GetAttrPath("bar")),
+					}},
+				}},
+			}},
+		},
+		{
+			resource.Expression{
+				resource.ExprReference{
+					Path:      cty.GetAttrPath("foo").GetAttr("items").Index(cty.StringVal("x")),
+					KeyFields: map[int]string{2: "name"},
+				},
+			},
+			AttributeValue{L: []AttributeValue{
+				{M: map[string]AttributeValue{
+					"Reference": FromCtyPath(cty.GetAttrPath("foo").GetAttr("items").Index(cty.StringVal("x"))),
+					"KeyFields": {M: map[string]AttributeValue{
+						"2": {S: aws.String("name")},
+					}},
+				}},
+			}},
+		},
+		{
+			resource.Expression{
+				resource.ExprConditional{
+					Condition: resource.Expression{resource.ExprReference{Path: cty.GetAttrPath("foo")}},
+					True:      resource.Expression{resource.ExprLiteral{Value: cty.NumberIntVal(1024)}},
+					False:     resource.Expression{resource.ExprLiteral{Value: cty.NumberIntVal(128)}},
+				},
+			},
+			AttributeValue{L: []AttributeValue{
+				{M: map[string]AttributeValue{"Conditional": {M: map[string]AttributeValue{
+					"Condition": {L: []AttributeValue{{M: map[string]AttributeValue{"Reference": FromCtyPath(cty.GetAttrPath("foo"))}}}},
+					"True":      {L: []AttributeValue{{M: map[string]AttributeValue{"Literal": {N: aws.String("1024")}}}}},
+					"False":     {L: []AttributeValue{{M: map[string]AttributeValue{"Literal": {N: aws.String("128")}}}}},
+				}}}},
+			}},
+		},
 	}
 	for i, tt := range tests {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
@@ -828,6 +1160,69 @@ func TestToExpression(t *testing.T) {
 			},
 			false,
 		},
+		{
+			AttributeValue{L: []AttributeValue{
+				{M: map[string]AttributeValue{
+					"Reference": FromCtyPath(cty.GetAttrPath("foo").Index(cty.DynamicVal)),
+					"Keys": {M: map[string]AttributeValue{
+						"1": FromCtyPath(cty.GetAttrPath("bar")),
+					}},
+				}},
+			}},
+			resource.Expression{
+				resource.ExprReference{
+					Path: cty.GetAttrPath("foo").Index(cty.DynamicVal),
+					Keys: map[int]cty.Path{1: cty.GetAttrPath("bar")},
+				},
+			},
+			false,
+		},
+		{
+			AttributeValue{L: []AttributeValue{
+				{M: map[string]AttributeValue{
+					"Reference": FromCtyPath(cty.GetAttrPath("foo").GetAttr("items").Index(cty.StringVal("x"))),
+					"KeyFields": {M: map[string]AttributeValue{
+						"2": {S: aws.String("name")},
+					}},
+				}},
+			}},
+			resource.Expression{
+				resource.ExprReference{
+					Path:      cty.GetAttrPath("foo").GetAttr("items").Index(cty.StringVal("x")),
+					KeyFields: map[int]string{2: "name"},
+				},
+			},
+			false,
+		},
+		{
+			AttributeValue{L: []AttributeValue{
+				{M: map[string]AttributeValue{
+					"Reference": FromCtyPath(cty.GetAttrPath("foo")),
+					"KeyFields": {M: map[string]AttributeValue{
+						"3": {S: aws.String("name")},
+					}},
+				}},
+			}},
+			nil,
+			true, // Key field position out of range
+		},
+		{
+			AttributeValue{L: []AttributeValue{
+				{M: map[string]AttributeValue{"Conditional": {M: map[string]AttributeValue{
+					"Condition": {L: []AttributeValue{{M: map[string]AttributeValue{"Reference": FromCtyPath(cty.GetAttrPath("foo"))}}}},
+					"True":      {L: []AttributeValue{{M: map[string]AttributeValue{"Literal": {N: aws.String("1024")}}}}},
+					"False":     {L: []AttributeValue{{M: map[string]AttributeValue{"Literal": {BOOL: aws.Bool(false)}}}}},
+				}}}},
+			}},
+			resource.Expression{
+				resource.ExprConditional{
+					Condition: resource.Expression{resource.ExprReference{Path: cty.GetAttrPath("foo")}},
+					True:      resource.Expression{resource.ExprLiteral{Value: cty.NumberIntVal(1024)}},
+					False:     resource.Expression{resource.ExprLiteral{Value: cty.False}},
+				},
+			},
+			false,
+		},
 		{
 			AttributeValue{L: []AttributeValue{
 				{S: aws.String("foo")},
@@ -844,10 +1239,20 @@ func TestToExpression(t *testing.T) {
 		},
 		{
 			AttributeValue{L: []AttributeValue{
-				{M: map[string]AttributeValue{"Literal": {N: aws.String("1")}}},
+				{M: map[string]AttributeValue{"Literal": {L: []AttributeValue{}}}},
+			}},
+			nil,
+			true, // Literal must be a primitive value
+		},
+		{
+			AttributeValue{L: []AttributeValue{
+				{M: map[string]AttributeValue{"Conditional": {M: map[string]AttributeValue{
+					"True":  {L: []AttributeValue{{M: map[string]AttributeValue{"Literal": {N: aws.String("1")}}}}},
+					"False": {L: []AttributeValue{{M: map[string]AttributeValue{"Literal": {N: aws.String("2")}}}}},
+				}}}},
 			}},
 			nil,
-			true, // Literal must be a string
+			true, // Condition must be set
 		},
 		{
 			AttributeValue{L: []AttributeValue{
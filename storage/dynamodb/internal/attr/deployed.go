@@ -0,0 +1,190 @@
+package attr
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/func/func/resource"
+)
+
+// A Registry returns the definition type for a resource type name. It is
+// used to decode the inputs and outputs of a deployed resource.
+type Registry interface {
+	Type(typename string) reflect.Type
+}
+
+// MarshalDeployed converts a deployed resource to a DynamoDB item.
+//
+// The item does not contain the resource ID. The ID is part of the key of
+// the stored item and must be added by the caller.
+func MarshalDeployed(res *resource.Deployed) (map[string]dynamodb.AttributeValue, error) {
+	if res.Desired == nil {
+		return nil, fmt.Errorf("desired resource not set")
+	}
+
+	item := map[string]dynamodb.AttributeValue{
+		"Type":   FromString(res.Type),
+		"Name":   FromString(res.Name),
+		"Input":  FromCtyValue(res.Input),
+		"Output": FromCtyValue(res.Output),
+	}
+
+	if len(res.Deps) > 0 {
+		item["Dependencies"] = FromStringSet(res.Deps)
+	}
+	if len(res.Sources) > 0 {
+		item["Sources"] = FromStringSet(res.Sources)
+	}
+	if !res.LastAppliedAt.IsZero() {
+		item["LastAppliedAt"] = FromTime(res.LastAppliedAt)
+	}
+	if res.LastDuration > 0 {
+		item["LastDuration"] = FromInt64(int64(res.LastDuration))
+	}
+	if res.PreventDestroy {
+		item["PreventDestroy"] = FromBool(true)
+	}
+	if res.Description != "" {
+		item["Description"] = FromString(res.Description)
+	}
+	if res.Profile != "" {
+		item["Profile"] = FromString(res.Profile)
+	}
+	if res.Failure != nil {
+		item["Failure"] = dynamodb.AttributeValue{M: map[string]dynamodb.AttributeValue{
+			"Error":  FromString(res.Failure.Error),
+			"At":     FromTime(res.Failure.At),
+			"Count":  FromInt64(int64(res.Failure.Count)),
+			"Create": FromBool(res.Failure.Create),
+		}}
+	}
+	if res.Imported {
+		item["Imported"] = FromBool(true)
+	}
+
+	return item, nil
+}
+
+// UnmarshalDeployed converts an item created with MarshalDeployed back to a
+// deployed resource. The type of the resource must be registered in the
+// registry, it is used to decode the inputs and outputs.
+//
+// The ID of the returned resource is not set.
+func UnmarshalDeployed(item map[string]dynamodb.AttributeValue, reg Registry) (*resource.Deployed, error) { // nolint: lll
+	res := &resource.Deployed{
+		Desired: &resource.Desired{},
+	}
+
+	name, err := ToString(item["Name"])
+	if err != nil {
+		return nil, fmt.Errorf("field Name: %v", err)
+	}
+	res.Name = name
+
+	typename, err := ToString(item["Type"])
+	if err != nil {
+		return nil, fmt.Errorf("field Type: %v", err)
+	}
+	res.Type = typename
+
+	res.Deps = ToStringSet(item["Dependencies"])
+	res.Sources = ToStringSet(item["Sources"])
+
+	if v, ok := item["LastAppliedAt"]; ok {
+		ts, err := ToTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("field LastAppliedAt: %v", err)
+		}
+		res.LastAppliedAt = ts
+	}
+	if v, ok := item["LastDuration"]; ok {
+		dur, err := ToInt64(v)
+		if err != nil {
+			return nil, fmt.Errorf("field LastDuration: %v", err)
+		}
+		res.LastDuration = time.Duration(dur)
+	}
+	if v, ok := item["PreventDestroy"]; ok {
+		b, err := ToBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("field PreventDestroy: %v", err)
+		}
+		res.PreventDestroy = b
+	}
+	if v, ok := item["Description"]; ok {
+		description, err := ToString(v)
+		if err != nil {
+			return nil, fmt.Errorf("field Description: %v", err)
+		}
+		res.Description = description
+	}
+	if v, ok := item["Profile"]; ok {
+		profile, err := ToString(v)
+		if err != nil {
+			return nil, fmt.Errorf("field Profile: %v", err)
+		}
+		res.Profile = profile
+	}
+	if v, ok := item["Failure"]; ok {
+		f, err := toFailure(v)
+		if err != nil {
+			return nil, fmt.Errorf("field Failure: %v", err)
+		}
+		res.Failure = f
+	}
+	if v, ok := item["Imported"]; ok {
+		b, err := ToBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("field Imported: %v", err)
+		}
+		res.Imported = b
+	}
+
+	typ := reg.Type(typename)
+	if typ == nil {
+		return nil, fmt.Errorf("type %q not registered", typename)
+	}
+	fields := resource.Fields(typ)
+
+	input, err := ToCtyValue(item["Input"], fields.Inputs().CtyType())
+	if err != nil {
+		return nil, fmt.Errorf("convert input: %v", err)
+	}
+	res.Input = input
+
+	output, err := ToCtyValue(item["Output"], fields.Outputs().CtyType())
+	if err != nil {
+		return nil, fmt.Errorf("convert output: %v", err)
+	}
+	res.Output = output
+
+	return res, nil
+}
+
+// toFailure converts a failure map attribute created in MarshalDeployed.
+func toFailure(attr dynamodb.AttributeValue) (*resource.Failure, error) {
+	errStr, err := ToString(attr.M["Error"])
+	if err != nil {
+		return nil, fmt.Errorf("Error: %v", err)
+	}
+	at, err := ToTime(attr.M["At"])
+	if err != nil {
+		return nil, fmt.Errorf("At: %v", err)
+	}
+	count, err := ToInt64(attr.M["Count"])
+	if err != nil {
+		return nil, fmt.Errorf("Count: %v", err)
+	}
+	create, err := ToBool(attr.M["Create"])
+	if err != nil {
+		return nil, fmt.Errorf("Create: %v", err)
+	}
+	return &resource.Failure{
+		Error:  errStr,
+		At:     at,
+		Count:  int(count),
+		Create: create,
+	}, nil
+}
@@ -0,0 +1,149 @@
+package attr
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/func/func/resource"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestMarshalDeployed_roundtrip(t *testing.T) {
+	type config struct {
+		Timeout *int              `func:"input"`
+		Env     map[string]string `func:"input"`
+	}
+	reg := &resource.Registry{
+		Types: map[string]reflect.Type{
+			"fn": reflect.TypeOf(struct {
+				Name   string   `func:"input"`
+				Config config   `func:"input"`
+				Layers []string `func:"input"`
+				ARN    string   `func:"output"`
+			}{}),
+		},
+	}
+
+	tests := []struct {
+		name string
+		res  *resource.Deployed
+	}{
+		{
+			name: "Minimal",
+			res: &resource.Deployed{
+				Desired: &resource.Desired{
+					Type: "fn",
+					Name: "a",
+					Input: cty.ObjectVal(map[string]cty.Value{
+						"name": cty.StringVal("a"),
+						"config": cty.ObjectVal(map[string]cty.Value{
+							"timeout": cty.NullVal(cty.Number),
+							"env":     cty.NullVal(cty.Map(cty.String)),
+						}),
+						"layers": cty.NullVal(cty.List(cty.String)),
+					}),
+				},
+				Output: cty.ObjectVal(map[string]cty.Value{
+					"arn": cty.StringVal("arn:a"),
+				}),
+			},
+		},
+		{
+			name: "Full",
+			res: &resource.Deployed{
+				Desired: &resource.Desired{
+					Type: "fn",
+					Name: "b",
+					Input: cty.ObjectVal(map[string]cty.Value{
+						"name": cty.StringVal("b"),
+						"config": cty.ObjectVal(map[string]cty.Value{
+							"timeout": cty.NumberIntVal(30),
+							"env": cty.MapVal(map[string]cty.Value{
+								"FOO": cty.StringVal("foo"),
+								"BAR": cty.StringVal("bar"),
+							}),
+						}),
+						"layers": cty.ListVal([]cty.Value{
+							cty.StringVal("x"),
+							cty.StringVal("y"),
+						}),
+					}),
+					Sources:        []string{"abc", "def", "ghi"},
+					PreventDestroy: true,
+					Description:    "Handles requests",
+					Profile:        "prod",
+				},
+				Output: cty.ObjectVal(map[string]cty.Value{
+					"arn": cty.StringVal("arn:b"),
+				}),
+				Deps:          []string{"a", "c"},
+				LastAppliedAt: time.Date(2019, 6, 1, 12, 30, 15, 123, time.UTC),
+				LastDuration:  1500 * time.Millisecond,
+				Imported:      true,
+			},
+		},
+		{
+			name: "Failure",
+			res: &resource.Deployed{
+				Desired: &resource.Desired{
+					Type: "fn",
+					Name: "c",
+					Input: cty.ObjectVal(map[string]cty.Value{
+						"name":   cty.StringVal("c"),
+						"config": cty.NullVal(cty.Object(map[string]cty.Type{"timeout": cty.Number, "env": cty.Map(cty.String)})),
+						"layers": cty.NullVal(cty.List(cty.String)),
+					}),
+				},
+				Output: cty.NullVal(cty.Object(map[string]cty.Type{"arn": cty.String})),
+				Failure: &resource.Failure{
+					Error:  "limit exceeded",
+					At:     time.Date(2019, 6, 1, 12, 30, 15, 123, time.UTC),
+					Count:  3,
+					Create: true,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := MarshalDeployed(tt.res)
+			if err != nil {
+				t.Fatalf("MarshalDeployed() err = %v", err)
+			}
+			if _, ok := item["ID"]; ok {
+				t.Errorf("Item contains ID, must be set by the caller")
+			}
+			got, err := UnmarshalDeployed(item, reg)
+			if err != nil {
+				t.Fatalf("UnmarshalDeployed() err = %v", err)
+			}
+			compare(t, got, tt.res)
+		})
+	}
+}
+
+func TestMarshalDeployed_noDesired(t *testing.T) {
+	_, err := MarshalDeployed(&resource.Deployed{ID: "a"})
+	if err == nil {
+		t.Fatal("want error")
+	}
+}
+
+func TestUnmarshalDeployed_notRegistered(t *testing.T) {
+	item, err := MarshalDeployed(&resource.Deployed{
+		Desired: &resource.Desired{
+			Type:  "unknown",
+			Name:  "a",
+			Input: cty.EmptyObjectVal,
+		},
+		Output: cty.EmptyObjectVal,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = UnmarshalDeployed(item, &resource.Registry{})
+	if err == nil {
+		t.Fatal("want error")
+	}
+}
@@ -3,4 +3,10 @@
 //
 // Only types that are required are supported. Conversion from cty types can
 // cover a larger range of types.
+//
+// Ordered collections, such as slices and cty lists, are stored as list (L)
+// attributes and retain their order. Unordered sets are stored as native
+// DynamoDB sets (SS, NS, BS) where possible; the order of their values is not
+// retained. FromOrderedStringSet can be used for sets of unique strings where
+// the order is significant.
 package attr
@@ -0,0 +1,191 @@
+// Package multi provides a store that writes state to two backends.
+//
+// The store can be used to migrate state from one backend to another, or to
+// keep a backup of the state. Reads are served from the primary backend,
+// while writes go to both backends.
+package multi
+
+import (
+	"context"
+	"sort"
+
+	"github.com/func/func/resource"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"go.uber.org/zap"
+)
+
+// A Backend is a state store.
+type Backend interface {
+	PutResource(ctx context.Context, project string, res *resource.Deployed) error
+	DeleteResource(ctx context.Context, project string, res *resource.Deployed) error
+	ListResources(ctx context.Context, project string) ([]*resource.Deployed, error)
+	GetResource(ctx context.Context, project, id string) (*resource.Deployed, error)
+	PutGraph(ctx context.Context, project string, g *resource.Graph) error
+	GetGraph(ctx context.Context, project string) (*resource.Graph, error)
+}
+
+// Store writes to a primary and a secondary backend, and reads from the
+// primary.
+//
+// Writes are done to the primary first. If the primary fails, the secondary
+// is not written to. A failed write to the secondary never affects the data
+// written to the primary.
+type Store struct {
+	Primary   Backend
+	Secondary Backend
+
+	// Strict makes a write fail if writing to the secondary fails. By
+	// default, writing to the secondary is best-effort and errors are
+	// logged.
+	Strict bool
+
+	// CompareReads makes resource reads also read from the secondary, and
+	// log any mismatch between the backends. The secondary does not affect
+	// the returned result.
+	CompareReads bool
+
+	// Logger is used for logging errors from the secondary and mismatches.
+	// If not set, nothing is logged.
+	Logger *zap.Logger
+}
+
+// PutResource creates or updates a resource in both backends.
+func (s *Store) PutResource(ctx context.Context, project string, res *resource.Deployed) error {
+	if err := s.Primary.PutResource(ctx, project, res); err != nil {
+		return err
+	}
+	err := s.Secondary.PutResource(ctx, project, res)
+	return s.secondaryErr(err, "put resource", zap.String("id", res.ID))
+}
+
+// DeleteResource deletes a resource from both backends.
+func (s *Store) DeleteResource(ctx context.Context, project string, res *resource.Deployed) error {
+	if err := s.Primary.DeleteResource(ctx, project, res); err != nil {
+		return err
+	}
+	err := s.Secondary.DeleteResource(ctx, project, res)
+	return s.secondaryErr(err, "delete resource", zap.String("id", res.ID))
+}
+
+// PutGraph creates or updates a graph in both backends.
+func (s *Store) PutGraph(ctx context.Context, project string, g *resource.Graph) error {
+	if err := s.Primary.PutGraph(ctx, project, g); err != nil {
+		return err
+	}
+	err := s.Secondary.PutGraph(ctx, project, g)
+	return s.secondaryErr(err, "put graph", zap.String("project", project))
+}
+
+// ListResources lists all resources in a project from the primary.
+func (s *Store) ListResources(ctx context.Context, project string) ([]*resource.Deployed, error) {
+	list, err := s.Primary.ListResources(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	if s.CompareReads {
+		other, err := s.Secondary.ListResources(ctx, project)
+		if err != nil {
+			s.logger().Warn("Could not list resources from secondary", zap.Error(err))
+			return list, nil
+		}
+		if ids := mismatch(list, other); len(ids) > 0 {
+			s.logger().Warn(
+				"Resources in secondary do not match primary",
+				zap.String("project", project),
+				zap.Strings("ids", ids),
+			)
+		}
+	}
+	return list, nil
+}
+
+// GetResource returns a resource from the primary. Returns nil if the
+// resource does not exist.
+func (s *Store) GetResource(ctx context.Context, project, id string) (*resource.Deployed, error) {
+	res, err := s.Primary.GetResource(ctx, project, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.CompareReads {
+		other, err := s.Secondary.GetResource(ctx, project, id)
+		if err != nil {
+			s.logger().Warn("Could not get resource from secondary", zap.String("id", id), zap.Error(err))
+			return res, nil
+		}
+		if !equalResource(res, other) {
+			s.logger().Warn(
+				"Resource in secondary does not match primary",
+				zap.String("project", project),
+				zap.String("id", id),
+			)
+		}
+	}
+	return res, nil
+}
+
+// GetGraph returns the graph for a project from the primary.
+func (s *Store) GetGraph(ctx context.Context, project string) (*resource.Graph, error) {
+	return s.Primary.GetGraph(ctx, project)
+}
+
+// secondaryErr handles an error from writing to the secondary. In strict
+// mode, the error is returned. Otherwise it is logged.
+func (s *Store) secondaryErr(err error, op string, fields ...zap.Field) error {
+	if err == nil {
+		return nil
+	}
+	if s.Strict {
+		return errors.Wrapf(err, "secondary: %s", op)
+	}
+	fields = append(fields, zap.Error(err))
+	s.logger().Warn("Could not write to secondary: "+op, fields...)
+	return nil
+}
+
+func (s *Store) logger() *zap.Logger {
+	if s.Logger == nil {
+		return zap.NewNop()
+	}
+	return s.Logger
+}
+
+// mismatch returns the sorted ids of resources that are not equal in a and b.
+func mismatch(a, b []*resource.Deployed) []string {
+	byID := make(map[string]*resource.Deployed, len(b))
+	for _, res := range b {
+		byID[res.ID] = res
+	}
+	var ids []string
+	for _, res := range a {
+		other, ok := byID[res.ID]
+		delete(byID, res.ID)
+		if !ok || !equalResource(res, other) {
+			ids = append(ids, res.ID)
+		}
+	}
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// equalResource compares the stored state of two resources. Timestamps are
+// not compared.
+func equalResource(a, b *resource.Deployed) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.ID != b.ID || a.Type != b.Type || a.Name != b.Name {
+		return false
+	}
+	return equalValue(a.Input, b.Input) && equalValue(a.Output, b.Output)
+}
+
+func equalValue(a, b cty.Value) bool {
+	if a == cty.NilVal || b == cty.NilVal {
+		return a == cty.NilVal && b == cty.NilVal
+	}
+	return a.RawEquals(b)
+}
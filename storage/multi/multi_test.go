@@ -0,0 +1,179 @@
+package multi_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/func/func/resource"
+	"github.com/func/func/storage/multi"
+	"github.com/func/func/storage/teststore"
+	"github.com/zclconf/go-cty/cty"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestStore_writeFanOut(t *testing.T) {
+	ctx := context.Background()
+	primary := &teststore.Store{}
+	secondary := &teststore.Store{}
+	s := &multi.Store{Primary: primary, Secondary: secondary}
+
+	res := testResource("a", "hello")
+	if err := s.PutResource(ctx, "proj", res); err != nil {
+		t.Fatalf("PutResource() error = %v", err)
+	}
+	g := &resource.Graph{}
+	if err := s.PutGraph(ctx, "proj", g); err != nil {
+		t.Fatalf("PutGraph() error = %v", err)
+	}
+
+	for name, b := range map[string]*teststore.Store{"primary": primary, "secondary": secondary} {
+		got, _ := b.GetResource(ctx, "proj", "a")
+		if got != res {
+			t.Errorf("Resource not written to %s", name)
+		}
+		graph, _ := b.GetGraph(ctx, "proj")
+		if graph != g {
+			t.Errorf("Graph not written to %s", name)
+		}
+	}
+
+	if err := s.DeleteResource(ctx, "proj", res); err != nil {
+		t.Fatalf("DeleteResource() error = %v", err)
+	}
+	for name, b := range map[string]*teststore.Store{"primary": primary, "secondary": secondary} {
+		if got, _ := b.GetResource(ctx, "proj", "a"); got != nil {
+			t.Errorf("Resource not deleted from %s", name)
+		}
+	}
+}
+
+func TestStore_readFromPrimary(t *testing.T) {
+	ctx := context.Background()
+	primary := &teststore.Store{}
+	primary.SeedResources("proj", []*resource.Deployed{testResource("a", "primary")})
+	secondary := &teststore.Store{}
+	secondary.SeedResources("proj", []*resource.Deployed{
+		testResource("a", "secondary"),
+		testResource("b", "secondary"),
+	})
+
+	core, logs := observer.New(zap.WarnLevel)
+	s := &multi.Store{
+		Primary:      primary,
+		Secondary:    secondary,
+		CompareReads: true,
+		Logger:       zap.New(core),
+	}
+
+	list, err := s.ListResources(ctx, "proj")
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if len(list) != 1 || list[0].Input.GetAttr("input").AsString() != "primary" {
+		t.Errorf("ListResources() did not return resources from primary")
+	}
+	res, err := s.GetResource(ctx, "proj", "a")
+	if err != nil {
+		t.Fatalf("GetResource() error = %v", err)
+	}
+	if res.Input.GetAttr("input").AsString() != "primary" {
+		t.Errorf("GetResource() did not return resource from primary")
+	}
+
+	mismatches := logs.FilterMessageSnippet("do not match").AllUntimed()
+	if len(mismatches) != 1 {
+		t.Fatalf("Got %d list mismatches logged, want 1", len(mismatches))
+	}
+	ids := mismatches[0].ContextMap()["ids"]
+	if ids == nil {
+		t.Errorf("Mismatching ids not logged")
+	}
+	if n := logs.FilterMessageSnippet("does not match").Len(); n != 1 {
+		t.Errorf("Got %d get mismatches logged, want 1", n)
+	}
+}
+
+func TestStore_secondaryFails(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		wantErr bool
+	}{
+		{"BestEffort", false, false},
+		{"Strict", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			primary := &teststore.Store{}
+			core, logs := observer.New(zap.WarnLevel)
+			s := &multi.Store{
+				Primary:   primary,
+				Secondary: failing{&teststore.Store{}},
+				Strict:    tt.strict,
+				Logger:    zap.New(core),
+			}
+
+			res := testResource("a", "hello")
+			err := s.PutResource(ctx, "proj", res)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PutResource() error = %v, wantErr = %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && logs.Len() != 1 {
+				t.Errorf("Got %d logs, want secondary error to be logged", logs.Len())
+			}
+
+			// Primary is written regardless.
+			if got, _ := primary.GetResource(ctx, "proj", "a"); got != res {
+				t.Errorf("Resource not written to primary")
+			}
+		})
+	}
+}
+
+func TestStore_primaryFails(t *testing.T) {
+	ctx := context.Background()
+	secondary := &teststore.Store{}
+	s := &multi.Store{
+		Primary:   failing{&teststore.Store{}},
+		Secondary: secondary,
+	}
+
+	if err := s.PutResource(ctx, "proj", testResource("a", "hello")); err == nil {
+		t.Fatal("PutResource() want error")
+	}
+	if got, _ := secondary.GetResource(ctx, "proj", "a"); got != nil {
+		t.Errorf("Resource written to secondary after primary failed")
+	}
+}
+
+func testResource(id, input string) *resource.Deployed {
+	return &resource.Deployed{
+		Desired: &resource.Desired{
+			Type:  "foo",
+			Name:  id,
+			Input: cty.ObjectVal(map[string]cty.Value{"input": cty.StringVal(input)}),
+		},
+		ID:     id,
+		Output: cty.EmptyObjectVal,
+	}
+}
+
+// failing fails all writes.
+type failing struct {
+	*teststore.Store
+}
+
+func (failing) PutResource(ctx context.Context, project string, res *resource.Deployed) error {
+	return errors.New("write failed")
+}
+
+func (failing) DeleteResource(ctx context.Context, project string, res *resource.Deployed) error {
+	return errors.New("write failed")
+}
+
+func (failing) PutGraph(ctx context.Context, project string, g *resource.Graph) error {
+	return errors.New("write failed")
+}
@@ -23,10 +23,29 @@ type store interface {
 type Recorder struct {
 	Store store
 
+	// Errors contains errors to return from the recorder, keyed by method
+	// name. For every call to a method, the next error is returned without
+	// calling the store. Once all errors for a method have been returned,
+	// calls are passed to the store. This can be used to simulate transient
+	// failures.
+	Errors map[string][]error
+
 	mu     sync.Mutex
 	Events Events
 }
 
+// next returns the next injected error for a method, if any.
+func (r *Recorder) next(method string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	errs := r.Errors[method]
+	if len(errs) == 0 {
+		return nil
+	}
+	r.Errors[method] = errs[1:]
+	return errs[0]
+}
+
 // Events is a collection of events.
 type Events []Event
 
@@ -119,7 +138,10 @@ func (r *Recorder) PutResource(ctx context.Context, project string, res *resourc
 		Project: project,
 		Data:    res,
 	}
-	err := r.Store.PutResource(ctx, project, res)
+	err := r.next("PutResource")
+	if err == nil {
+		err = r.Store.PutResource(ctx, project, res)
+	}
 	if err != nil {
 		ev.Err = err
 	}
@@ -138,7 +160,10 @@ func (r *Recorder) DeleteResource(ctx context.Context, project string, res *reso
 		Project: project,
 		Data:    res,
 	}
-	err := r.Store.DeleteResource(ctx, project, res)
+	err := r.next("DeleteResource")
+	if err == nil {
+		err = r.Store.DeleteResource(ctx, project, res)
+	}
 	if err != nil {
 		ev.Err = err
 	}
@@ -154,7 +179,11 @@ func (r *Recorder) ListResources(ctx context.Context, project string) ([]*resour
 		Method:  "ListResources",
 		Project: project,
 	}
-	out, err := r.Store.ListResources(ctx, project)
+	var out []*resource.Deployed
+	err := r.next("ListResources")
+	if err == nil {
+		out, err = r.Store.ListResources(ctx, project)
+	}
 	if err != nil {
 		ev.Err = err
 	}
@@ -171,7 +200,10 @@ func (r *Recorder) PutGraph(ctx context.Context, project string, g *resource.Gra
 		Project: project,
 		Data:    g,
 	}
-	err := r.Store.PutGraph(ctx, project, g)
+	err := r.next("PutGraph")
+	if err == nil {
+		err = r.Store.PutGraph(ctx, project, g)
+	}
 	if err != nil {
 		ev.Err = err
 	}
@@ -187,7 +219,11 @@ func (r *Recorder) GetGraph(ctx context.Context, project string) (*resource.Grap
 		Method:  "GetGraph",
 		Project: project,
 	}
-	out, err := r.Store.GetGraph(ctx, project)
+	var out *resource.Graph
+	err := r.next("GetGraph")
+	if err == nil {
+		out, err = r.Store.GetGraph(ctx, project)
+	}
 	if err != nil {
 		ev.Err = err
 	}
@@ -0,0 +1,50 @@
+package teststore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/func/func/resource"
+	"github.com/func/func/storage/teststore"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestRecorder_Errors(t *testing.T) {
+	ctx := context.Background()
+	errFail := errors.New("fail")
+	store := &teststore.Store{}
+	rec := &teststore.Recorder{
+		Store:  store,
+		Errors: map[string][]error{"PutResource": {errFail, errFail}},
+	}
+
+	res := &resource.Deployed{
+		Desired: &resource.Desired{Type: "foo", Name: "a", Input: cty.EmptyObjectVal},
+		ID:      "a",
+		Output:  cty.EmptyObjectVal,
+	}
+	for i := 0; i < 2; i++ {
+		if err := rec.PutResource(ctx, "proj", res); err != errFail {
+			t.Fatalf("PutResource() attempt %d error = %v, want %v", i+1, err, errFail)
+		}
+		if got, _ := store.GetResource(ctx, "proj", "a"); got != nil {
+			t.Fatalf("Resource stored after failed attempt %d", i+1)
+		}
+	}
+	if err := rec.PutResource(ctx, "proj", res); err != nil {
+		t.Fatalf("PutResource() error = %v", err)
+	}
+	if got, _ := store.GetResource(ctx, "proj", "a"); got != res {
+		t.Errorf("Resource not stored after errors were used up")
+	}
+
+	if len(rec.Events) != 3 {
+		t.Fatalf("Got %d events, want 3", len(rec.Events))
+	}
+	for i, want := range []error{errFail, errFail, nil} {
+		if rec.Events[i].Err != want {
+			t.Errorf("Event %d error = %v, want %v", i, rec.Events[i].Err, want)
+		}
+	}
+}
@@ -88,6 +88,14 @@ func (s *Store) ListResources(ctx context.Context, project string) ([]*resource.
 	return out, nil
 }
 
+// GetResource returns a resource by its ID. Returns nil if the resource does
+// not exist.
+func (s *Store) GetResource(ctx context.Context, project, id string) (*resource.Deployed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.resources[project][id], nil
+}
+
 // PutGraph creates or updates a graph.
 func (s *Store) PutGraph(ctx context.Context, project string, g *resource.Graph) error {
 	s.mu.Lock()
@@ -1,39 +1,82 @@
 package suggest
 
-import "github.com/agext/levenshtein"
+import (
+	"strings"
+
+	"github.com/agext/levenshtein"
+)
+
+// maxDistance is the maximum number of characters that can differ when
+// comparing whole strings, regardless of the length of the input.
+const maxDistance = 3
 
 // String suggests a string that closely matches one of the candidates.
 //
-//   The maximum difference depends on the input string. Users of the package
-//   should not rely on this heuristic as it may change.
+//   Strings are compared case-insensitively. In addition to comparing whole
+//   strings, strings are split into words on underscores and the words are
+//   compared individually, allowing a typo in each word. The maximum
+//   difference depends on the input string. Users of the package should not
+//   rely on this heuristic as it may change.
 //
 // If no close match is found, an empty string is returned.
 func String(want string, candidates []string) string {
-	// Maximum characters that can differ
-	maxDist := len(want) / 5
-	if maxDist == 0 {
-		maxDist = 1
-	}
-
-	var str string
-	dist := maxDist + 1
-
 	for _, cand := range candidates {
 		if want == cand {
 			// Exact match.
 			return want
 		}
-		d := levenshtein.Distance(want, cand, nil)
-		if d < dist {
+	}
+
+	lower := strings.ToLower(want)
+
+	var str string
+	dist := -1
+	for _, cand := range candidates {
+		d, ok := distance(lower, strings.ToLower(cand))
+		if !ok {
+			continue
+		}
+		if dist < 0 || d < dist {
 			str = cand
 			dist = d
 		}
 	}
 
-	if dist > maxDist {
-		// No match within the maximum distance.
-		return ""
+	return str
+}
+
+// distance returns the edit distance between a and b. Returns false if the
+// strings are too different for b to be suggested for a.
+func distance(a, b string) (int, bool) {
+	d := levenshtein.Distance(a, b, nil)
+
+	// Maximum characters that can differ
+	maxDist := len(a) / 5
+	if maxDist == 0 {
+		maxDist = 1
+	}
+	if maxDist > maxDistance {
+		maxDist = maxDistance
+	}
+	if d <= maxDist {
+		return d, true
 	}
 
-	return str
+	// Compare word by word. Every word may contain a typo, as long as the
+	// number of words match.
+	aw := strings.Split(a, "_")
+	bw := strings.Split(b, "_")
+	if len(aw) != len(bw) || len(aw) == 1 {
+		return 0, false
+	}
+	for i := range aw {
+		n := len(aw[i])
+		if len(bw[i]) > n {
+			n = len(bw[i])
+		}
+		if levenshtein.Distance(aw[i], bw[i], nil) > n/3 {
+			return 0, false
+		}
+	}
+	return d, true
 }
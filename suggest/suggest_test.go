@@ -16,6 +16,14 @@ func ExampleString() {
 	// Output: Did you mean "aws:lambda_function"?
 }
 
+var awsTypes = []string{
+	"aws_iam_policy",
+	"aws_iam_role",
+	"aws_lambda_function",
+	"aws_s3_bucket",
+	"aws_sns_topic",
+}
+
 func TestString(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -27,6 +35,14 @@ func TestString(t *testing.T) {
 		{"Almost", "boo", []string{"bar", "foo"}, "foo"},
 		{"NoMatch", "go", []string{"bar", "foo"}, ""},
 		{"Long", "Lorem lipsam", []string{"Lorem ipsum", "Lorem dolor"}, "Lorem ipsum"},
+		{"Case", "AWS_Lambda_Function", awsTypes, "aws_lambda_function"},
+		{"Typo", "aws_lamda_function", awsTypes, "aws_lambda_function"},
+		{"TypoInEveryWord", "aws_lmda_fnctin", awsTypes, "aws_lambda_function"},
+		{"Closest", "aws_iam_polcy", awsTypes, "aws_iam_policy"},
+		{"Unrelated", "aws_s3_object", awsTypes, ""},
+		{"UnrelatedWord", "aws_sqs_queue", awsTypes, ""},
+		{"MissingWord", "aws_lambda", awsTypes, ""},
+		{"ShortWords", "a_b", []string{"c_d"}, ""},
 	}
 
 	for _, tt := range tests {